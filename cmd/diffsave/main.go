@@ -0,0 +1,158 @@
+// Command diffsave prints how two states of a game differ: the game's own
+// fields, and player by player, unit by unit, city by city and tile by
+// tile. A state is a save file, or the game of a save replayed from its
+// event log up to an event given after an @. Given one save it compares
+// the save with its own replay, which should match it exactly; where they
+// differ, replaying the game does not play it as it was played.
+//
+//	go run ./cmd/diffsave saves/<game>/autosave.json
+//	go run ./cmd/diffsave before.json after.json
+//	go run ./cmd/diffsave save.json@120 save.json
+//
+// Replays check each event against the hash of the state it was recorded
+// with, and the first event replayed differently is reported.
+//
+// It exits with status 1 if the states differ and 2 if one cannot be read.
+package main
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	asJSON := flag.Bool("json", false, "Print the differences as JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: diffsave [-json] save[@event] [save[@event]]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var a, b *game.GameState
+	var err error
+	switch flag.NArg() {
+	case 1:
+		a, b, err = saveAndReplay(flag.Arg(0))
+	case 2:
+		if a, err = loadState(flag.Arg(0)); err == nil {
+			b, err = loadState(flag.Arg(1))
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	diffs := game.Diff(a, b)
+	if *asJSON {
+		if diffs == nil {
+			diffs = make([]game.Difference, 0)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(diffs)
+	} else {
+		for _, d := range diffs {
+			fmt.Println(d)
+		}
+		fmt.Printf("%d differences\n", len(diffs))
+	}
+	if len(diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// saveAndReplay loads a save, and its game replayed up to the save's last
+// event
+func saveAndReplay(path string) (saved, replayed *game.GameState, err error) {
+	dto, err := readSave(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	replayed, err = replay(path, dto, dto.Seq)
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.DTOToGameState(dto), replayed, nil
+}
+
+// loadState loads a state: a save, or with "@<event>" after it the save's
+// game replayed up to that event
+func loadState(spec string) (*game.GameState, error) {
+	path, seq, replayed := spec, uint64(0), false
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		if parsed, err := strconv.ParseUint(spec[i+1:], 10, 64); err == nil {
+			path, seq, replayed = spec[:i], parsed, true
+		}
+	}
+
+	dto, err := readSave(path)
+	if err != nil {
+		return nil, err
+	}
+	if replayed {
+		return replay(path, dto, seq)
+	}
+	return api.DTOToGameState(dto), nil
+}
+
+// readSave reads a save file, or the game kept in an async game's file,
+// without checking it can be loaded, so broken saves can be looked into
+func readSave(path string) (*api.GameStateMessage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var record api.AsyncRecord
+	if err := json.Unmarshal(data, &record); err == nil && record.Game.ID != "" {
+		return &record.Game, nil
+	}
+	var dto api.GameStateMessage
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, fmt.Errorf("%s is not a save file: %w", path, err)
+	}
+	if dto.ID == "" {
+		return nil, fmt.Errorf("%s is not a save file: it has no game ID", path)
+	}
+	return &dto, nil
+}
+
+// replay rebuilds a save's game from its event log up to an event. A log
+// whose replay parts from the game as it was played is replayed to the end
+// all the same, saying where it parted.
+func replay(path string, dto *api.GameStateMessage, seq uint64) (g *game.GameState, err error) {
+	if dto.EventLog == nil {
+		return nil, fmt.Errorf("%s has no event log to replay", path)
+	}
+	if seq > dto.Seq {
+		return nil, fmt.Errorf("%s ends at event %d, before %d", path, dto.Seq, seq)
+	}
+
+	// A broken log may not hold together well enough to play
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("replaying %s: %v", path, r)
+		}
+	}()
+	g, err = game.Replay(dto.EventLog, seq)
+	var desync *game.DesyncError
+	if errors.As(err, &desync) {
+		fmt.Fprintf(os.Stderr, "replaying %s: %v\n", path, err)
+		return desync.Game, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", path, err)
+	}
+	return g, nil
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"civilization/pkg/client"
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Settings describe the load to put on the server
+type Settings struct {
+	Clients      int           // Clients to connect
+	Players      int           // Human players; further clients watch
+	Actions      int           // Actions each player takes a turn
+	Duration     time.Duration // How long the players play
+	Think        time.Duration // Pause between a player's actions
+	Simultaneous bool          // Players plan their moves in one shared phase
+	Width        int
+	Height       int
+	Seed         int64
+	AdminToken   string // Admin token of the server, to sample its memory
+}
+
+// Report is what the load test measured
+type Report struct {
+	Clients  int           `json:"clients"`
+	Players  int           `json:"players"`
+	Duration time.Duration `json:"duration"`
+
+	Actions int `json:"actions"` // Actions the server applied
+	Refused int `json:"refused"` // Actions the server refused
+	Turns   int `json:"turns"`   // Turns played
+
+	// How long after an action was sent each client was told of it
+	Latency Percentiles `json:"latency"`
+
+	// Events the clients were told of, and those they should have been
+	// told of and were not
+	Delivered int `json:"delivered"`
+	Lost      int `json:"lost"`
+
+	Disconnected int `json:"disconnected"` // Clients the server closed
+	Resyncs      int `json:"resyncs"`      // Times clients found themselves out of sync
+
+	// Heap the server used, sampled every memorySampleInterval; zero
+	// without the admin token
+	PeakHeap  uint64 `json:"peak_heap"`
+	FinalHeap uint64 `json:"final_heap"`
+}
+
+// Percentiles summarize a set of durations
+type Percentiles struct {
+	Samples int           `json:"samples"`
+	P50     time.Duration `json:"p50"`
+	P90     time.Duration `json:"p90"`
+	P99     time.Duration `json:"p99"`
+	Max     time.Duration `json:"max"`
+}
+
+const (
+	memorySampleInterval = 500 * time.Millisecond
+	drainTime            = 2 * time.Second // How long clients are given to hear of the last actions
+	fortifyChance        = 4               // One action in this many is a fortify, the others moves
+)
+
+// recorder collects what the clients see
+type recorder struct {
+	mu       sync.Mutex
+	sent     map[uint64]time.Time   // Event seq -> when its action was sent
+	received []map[uint64]time.Time // Client -> event seq -> when the client was told of it
+	first    []uint64               // Client -> first event it was told of
+	actions  int
+	refused  int
+	turns    int
+}
+
+// Run starts a game on the server at serverURL, connects the clients and
+// has the players play random moves for the duration
+func Run(serverURL string, s Settings) (*Report, error) {
+	ctx := context.Background()
+	config := game.DefaultGameConfig()
+	config.MapWidth, config.MapHeight = s.Width, s.Height
+	config.PlayerCount, config.HumanPlayers = s.Players, s.Players
+	config.SimultaneousTurns = s.Simultaneous
+	config.Seed = s.Seed
+	state, err := client.NewGame(ctx, serverURL, config)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &recorder{
+		sent:     make(map[uint64]time.Time),
+		received: make([]map[uint64]time.Time, s.Clients),
+		first:    make([]uint64, s.Clients),
+	}
+	clients := make([]*client.Client, s.Clients)
+	for i := range clients {
+		c, err := client.Dial(ctx, serverURL)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+		clients[i] = c
+		rec.received[i] = make(map[uint64]time.Time)
+		go rec.listen(i, c.Events())
+	}
+
+	// The first client in each seat plays it, and the rest watch
+	playing, cancel := context.WithTimeout(ctx, s.Duration)
+	defer cancel()
+	var wg sync.WaitGroup
+	seated := make(map[string]bool)
+	for i, c := range clients {
+		if seated[c.PlayerID()] {
+			continue
+		}
+		seated[c.PlayerID()] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.play(playing, c, s, rand.New(rand.NewSource(s.Seed+int64(i))))
+		}()
+	}
+
+	report := &Report{Clients: s.Clients, Players: s.Players, Duration: s.Duration}
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		if s.AdminToken != "" {
+			report.PeakHeap, report.FinalHeap = sampleMemory(playing, serverURL, s.AdminToken, state.ID)
+		}
+	}()
+
+	wg.Wait()
+	<-sampled
+	time.Sleep(drainTime)
+	for _, c := range clients {
+		if c.Err() != nil {
+			report.Disconnected++
+		}
+		report.Resyncs += c.Resyncs()
+	}
+	rec.summarize(report)
+	return report, nil
+}
+
+// listen records when a client is told of each event
+func (rec *recorder) listen(i int, events <-chan client.Event) {
+	for e := range events {
+		applied, ok := e.(client.ActionApplied)
+		if !ok {
+			continue
+		}
+		now := time.Now()
+		rec.mu.Lock()
+		rec.received[i][applied.Seq] = now
+		if rec.first[i] == 0 {
+			rec.first[i] = applied.Seq
+		}
+		rec.mu.Unlock()
+	}
+}
+
+// play has a client take random moves each turn of its player until the
+// context is done
+func (rec *recorder) play(ctx context.Context, c *client.Client, s Settings, rng *rand.Rand) {
+	for {
+		if err := c.WaitTurn(ctx); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+				log.Printf("Player %s stopped: %v", c.PlayerID(), err)
+			}
+			return
+		}
+		for range s.Actions {
+			if action := randomAction(c, s.Simultaneous, rng); action != nil {
+				rec.act(ctx, c, action)
+			}
+			if s.Think > 0 {
+				time.Sleep(s.Think)
+			}
+		}
+
+		var end game.Action = &game.EndTurnAction{}
+		if s.Simultaneous {
+			end = &game.SubmitOrdersAction{PlayerID: c.PlayerID()}
+		}
+		if rec.act(ctx, c, end) {
+			rec.mu.Lock()
+			rec.turns++
+			rec.mu.Unlock()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// act takes an action and records when it was sent, and whether the
+// server applied it
+func (rec *recorder) act(ctx context.Context, c *client.Client, action game.Action) bool {
+	sent := time.Now()
+	event, err := c.Do(ctx, action)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	var refused *client.ServerError
+	switch {
+	case err == nil:
+		rec.actions++
+		rec.sent[event.Seq] = sent
+		return true
+	case errors.As(err, &refused):
+		rec.refused++
+	}
+	return false
+}
+
+// randomAction returns a move of a random unit of the client's player in a
+// random direction, or now and then a fortify, or nil without units. Many
+// are refused, as moves into the sea are.
+func randomAction(c *client.Client, simultaneous bool, rng *rand.Rand) game.Action {
+	state := c.State()
+	i := slices.IndexFunc(state.Players, func(p api.PlayerDTO) bool { return p.ID == c.PlayerID() })
+	if i < 0 || len(state.Players[i].Units) == 0 {
+		return nil
+	}
+	units := state.Players[i].Units
+	unit := units[rng.Intn(len(units))]
+	if rng.Intn(fortifyChance) == 0 {
+		return &game.FortifyAction{UnitID: unit.ID}
+	}
+
+	to := game.At(unit.X, unit.Y).Step(game.Directions[rng.Intn(len(game.Directions))])
+	if simultaneous {
+		return &game.PlanOrderAction{UnitID: unit.ID, Kind: game.OrderMove, X: to.X, Y: to.Y}
+	}
+	return &game.MoveUnitAction{UnitID: unit.ID, ToX: to.X, ToY: to.Y}
+}
+
+// sampleMemory samples the server's heap until the context is done, and
+// returns the largest and the last sample
+func sampleMemory(ctx context.Context, serverURL, token, gameID string) (peak, last uint64) {
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return peak, last
+		case <-ticker.C:
+		}
+		dto, err := client.Inspect(ctx, serverURL, token, gameID)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Sampling memory: %v", err)
+			}
+			continue
+		}
+		last = dto.HeapBytes
+		peak = max(peak, last)
+	}
+}
+
+// summarize adds the latencies and losses to the report. Each client
+// should have been told of every event from the first it was told of to
+// the last any client was.
+func (rec *recorder) summarize(report *Report) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	report.Actions, report.Refused, report.Turns = rec.actions, rec.refused, rec.turns
+
+	var last uint64
+	for _, received := range rec.received {
+		for seq := range received {
+			last = max(last, seq)
+		}
+	}
+
+	var latencies []time.Duration
+	for i, received := range rec.received {
+		report.Delivered += len(received)
+		if rec.first[i] > 0 {
+			report.Lost += int(last-rec.first[i]+1) - len(received)
+		}
+		for seq, at := range received {
+			if sent, ok := rec.sent[seq]; ok {
+				latencies = append(latencies, at.Sub(sent))
+			}
+		}
+	}
+	report.Latency = percentiles(latencies)
+}
+
+// percentiles summarizes durations
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	slices.Sort(durations)
+	at := func(p int) time.Duration {
+		return durations[(len(durations)-1)*p/100]
+	}
+	return Percentiles{
+		Samples: len(durations),
+		P50:     at(50),
+		P90:     at(90),
+		P99:     at(99),
+		Max:     durations[len(durations)-1],
+	}
+}
@@ -0,0 +1,137 @@
+// Command loadtest connects many simulated clients to a game server over
+// WebSocket. Those seated as players play random moves while the rest
+// watch, and it reports how long clients took to hear of each action,
+// which actions they never heard of and how much memory the server used.
+//
+//	go run ./cmd/loadtest -clients 64 -players 8 -duration 1m
+//	go run ./cmd/loadtest -server http://localhost:8888 -admin-token secret
+package main
+
+import (
+	"civilization/internal/api"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+func main() {
+	serverURL := flag.String("server", "", "Server to load, such as http://localhost:8888 (default: one started in this process)")
+	adminToken := flag.String("admin-token", "", "Admin token of the server, to sample its memory")
+	clients := flag.Int("clients", 16, "Clients to connect")
+	players := flag.Int("players", 4, "Human players; clients beyond one a player watch")
+	actions := flag.Int("actions", 5, "Random actions each player takes a turn")
+	duration := flag.Duration("duration", 30*time.Second, "How long the players play")
+	think := flag.Duration("think", 0, "Pause between a player's actions")
+	simultaneous := flag.Bool("simultaneous", false, "Have the players plan their moves in one shared phase")
+	width := flag.Int("width", 40, "Map width")
+	height := flag.Int("height", 25, "Map height")
+	seed := flag.Int64("seed", 1, "Seed of the game and the players' moves")
+	asJSON := flag.Bool("json", false, "Print the report as JSON")
+	verbose := flag.Bool("v", false, "Log the server and the game to standard error")
+	flag.Parse()
+
+	if *players < 2 || *players > 8 || *clients < *players || *actions < 0 || *duration <= 0 {
+		log.Fatal("Need 2 to 8 players, at least a client for each, and a duration")
+	}
+
+	settings := Settings{
+		Clients:      *clients,
+		Players:      *players,
+		Actions:      *actions,
+		Duration:     *duration,
+		Think:        *think,
+		Simultaneous: *simultaneous,
+		Width:        *width,
+		Height:       *height,
+		Seed:         *seed,
+		AdminToken:   *adminToken,
+	}
+
+	// The server logs every message; only the report is of interest here
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	report, err := load(*serverURL, settings)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printReport(os.Stdout, report)
+}
+
+// load runs the load test against the server at serverURL, or against
+// one started in this process if it is empty
+func load(serverURL string, settings Settings) (*Report, error) {
+	if serverURL != "" {
+		return Run(serverURL, settings)
+	}
+
+	dir, err := os.MkdirTemp("", "loadtest")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if serverURL, settings.AdminToken, err = startServer(dir); err != nil {
+		return nil, err
+	}
+	return Run(serverURL, settings)
+}
+
+// startServer starts a server in this process, keeping its saves in dir,
+// and returns its URL and admin token. Its memory includes the clients'.
+func startServer(dir string) (string, string, error) {
+	if err := os.Chdir(dir); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 16)
+	rand.Read(secret)
+	s := api.NewServer("")
+	s.AdminToken = hex.EncodeToString(secret)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", err
+	}
+	go http.Serve(listener, s.SetupRoutes())
+	return "http://" + listener.Addr().String(), s.AdminToken, nil
+}
+
+// printReport prints the report for people
+func printReport(w io.Writer, r *Report) {
+	fmt.Fprintf(w, "%d clients, %d players, %s\n", r.Clients, r.Players, r.Duration)
+	fmt.Fprintf(w, "Actions:   %d applied, %d refused, %d turns\n", r.Actions, r.Refused, r.Turns)
+	fmt.Fprintf(w, "Latency:   p50 %s, p90 %s, p99 %s, max %s over %d deliveries\n",
+		round(r.Latency.P50), round(r.Latency.P90), round(r.Latency.P99), round(r.Latency.Max), r.Latency.Samples)
+	fmt.Fprintf(w, "Delivered: %d events, %d lost, %d clients disconnected, %d resyncs\n", r.Delivered, r.Lost, r.Disconnected, r.Resyncs)
+	if r.PeakHeap > 0 {
+		fmt.Fprintf(w, "Heap:      peak %.1f MiB, final %.1f MiB\n", mib(r.PeakHeap), mib(r.FinalHeap))
+	}
+}
+
+// mib converts bytes to mebibytes
+func mib(bytes uint64) float64 {
+	return float64(bytes) / (1 << 20)
+}
+
+// round rounds a latency for printing
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
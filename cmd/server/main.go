@@ -3,50 +3,150 @@ package main
 import (
 	"civilization/internal/api"
 	"civilization/internal/game"
+	"civilization/internal/locale"
+	"errors"
 	"flag"
 	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof" // Registers profiling handlers on the default mux
 	"os"
-	"path/filepath"
+	"strings"
+	"time"
 )
 
 func main() {
 	// Command line flags
 	addr := flag.String("addr", ":8888", "HTTP server address")
-	webDir := flag.String("web", "", "Path to web directory (default: ./web)")
+	webDir := flag.String("web", "", "Directory to serve the web client from, to work on it without rebuilding (default: the client built into the binary)")
+	grpcAddr := flag.String("grpc", "", "Address for the gRPC API bots and tools play over, e.g. :8889 (disabled if empty)")
+	pprofAddr := flag.String("pprof", "", "Address for the pprof debug server, e.g. localhost:6060 (disabled if empty)")
+	rulesDir := flag.String("rules", "", "Directory of JSON rules files overriding units, buildings, terrain and resources")
+	scenariosDir := flag.String("scenarios", "scenarios", "Directory of scenario files new games can be started with")
+	localesDir := flag.String("locales", "locales", "Directory of JSON language packs games can be played in")
+	gamesDir := flag.String("games", "games", "Directory async games are kept in; the latest unfinished one is resumed at startup")
+	preferencesDir := flag.String("preferences", "preferences", "Directory players' preferences are kept in, by profile (not kept if empty)")
+	smtpAddr := flag.String("smtp", "", "Mail server (host:port) for turn notification emails (disabled if empty)")
+	mailFrom := flag.String("mail-from", "yac@localhost", "Sender address of turn notification emails")
+	webhookHosts := flag.String("webhook-hosts", "", "Comma-separated hosts turn notification webhooks may go to though they are on this machine or a private network (others must be public)")
+	publicURL := flag.String("public-url", "", "Address players reach the server at, to link their map in turn notifications")
+	dbPath := flag.String("db", "", "SQLite database recording every game turn by turn, to load earlier turns and recover from crashes (disabled if empty)")
+	retention := flag.Duration("retention", api.DefaultRetention, "How long saves, replays and stats of games nothing is written for are kept (0 keeps them for good)")
+	adminToken := flag.String("admin-token", os.Getenv("YAC_ADMIN_TOKEN"), "Token the admin API under /api/admin/ requires (disabled if empty; defaults to $YAC_ADMIN_TOKEN)")
+	checkInvariants := flag.Bool("check-invariants", false, "Debug mode: check the game state after every action and stop the server when it is corrupt")
+	single := flag.Bool("single", false, "Desktop mode: serve only this machine on a free port, open the browser and exit once it has been closed for -idle")
+	instance := flag.String("instance", "", "Serve the games kept in -games as this named instance of several behind a load balancer, loading them as requests name them (disabled if empty)")
+	idle := flag.Duration("idle", 5*time.Minute, "With -single, how long to wait with no browser connected before exiting")
 	flag.Parse()
 
-	// Determine web directory path
-	staticPath := *webDir
-	if staticPath == "" {
-		// Try to find web directory relative to executable or working directory
-		execPath, err := os.Executable()
-		if err == nil {
-			// Try relative to executable
-			staticPath = filepath.Join(filepath.Dir(execPath), "web")
-			if _, err := os.Stat(staticPath); os.IsNotExist(err) {
-				// Try relative to working directory
-				staticPath = "web"
-			}
-		} else {
-			staticPath = "web"
+	game.CheckInvariantsAfterActions = *checkInvariants
+	if *single && *instance != "" {
+		log.Fatal("-single and -instance cannot be used together")
+	}
+
+	// Mods must be in place before the first game is created
+	if *rulesDir != "" {
+		rules, err := game.LoadRules(*rulesDir)
+		if err != nil {
+			log.Fatalf("Loading rules: %v", err)
+		}
+		if err := rules.Apply(); err != nil {
+			log.Fatalf("Invalid rules in %s: %v", *rulesDir, err)
 		}
+		log.Printf("Rules loaded from %s", *rulesDir)
+	}
+
+	// A missing directory just leaves English
+	if err := locale.Load(*localesDir); err != nil {
+		log.Fatalf("Loading language packs: %v", err)
+	}
+
+	// Profiling runs on its own listener so it is never exposed on the game port
+	if *pprofAddr != "" {
+		go func() {
+			log.Printf("pprof available at http://%s/debug/pprof/", *pprofAddr)
+			if err := http.ListenAndServe(*pprofAddr, nil); err != nil {
+				log.Printf("pprof server error: %v", err)
+			}
+		}()
 	}
 
 	// Verify web directory exists
-	if _, err := os.Stat(staticPath); os.IsNotExist(err) {
-		log.Fatalf("Web directory not found: %s", staticPath)
+	if *webDir != "" {
+		if _, err := os.Stat(*webDir); os.IsNotExist(err) {
+			log.Fatalf("Web directory not found: %s", *webDir)
+		}
 	}
 
 	log.Printf("Starting Civilization server...")
-	log.Printf("Web directory: %s", staticPath)
 	log.Printf("Server address: %s", *addr)
 
 	// Create server
-	server := api.NewServer(staticPath)
+	server := api.NewServer(*webDir)
+	server.ScenariosPath = *scenariosDir
+	server.GamesPath = *gamesDir
+	server.Preferences = api.NewPreferenceStore(*preferencesDir)
+	server.Notifier = api.NewNotifier(*smtpAddr, *mailFrom)
+	server.Notifier.PublicURL = strings.TrimSuffix(*publicURL, "/")
+	if *webhookHosts != "" {
+		server.Notifier.WebhookHosts = strings.Split(*webhookHosts, ",")
+	}
+	server.Retention = *retention
+	server.AdminToken = *adminToken
+	server.LocalOnly = *single
+	if *dbPath != "" {
+		store, err := api.OpenSQLStore(*dbPath)
+		if err != nil {
+			log.Fatalf("Opening %s: %v", *dbPath, err)
+		}
+		defer store.Close()
+		server.Store = store
+	}
 
-	// Create a default game to start with
-	config := game.DefaultGameConfig()
-	server.NewGame(config)
+	// An instance has no game of its own; it loads the games requests name.
+	// Otherwise pick up an async game where it was left, or the game
+	// recorded last from the start of its turn, or create a default game.
+	if *instance != "" {
+		if err := server.StartInstance(*instance); err != nil {
+			log.Fatalf("Starting instance %s: %v", *instance, err)
+		}
+		log.Printf("Serving the games in %s as instance %s", *gamesDir, *instance)
+	} else if path := api.LatestAsyncGame(*gamesDir); path != "" {
+		if err := server.ResumeAsyncGame(path); err != nil {
+			log.Fatalf("Resuming %s: %v", path, err)
+		}
+		log.Printf("Resumed async game from %s", path)
+	} else if recovered, err := server.RecoverGame(); err != nil {
+		log.Fatalf("Recovering from %s: %v", *dbPath, err)
+	} else if recovered {
+		log.Printf("Recovered game from %s", *dbPath)
+	} else {
+		config := game.DefaultGameConfig()
+		if err := server.NewGame(config); err != nil {
+			log.Fatalf("Creating game: %v", err)
+		}
+	}
+
+	// Bots and tools play the same game over gRPC, on a port of its own
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatalf("gRPC listener: %v", err)
+		}
+		go func() {
+			log.Printf("gRPC API available at %s", listener.Addr())
+			if err := server.ServeGRPC(listener); err != nil {
+				log.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
+
+	if *single {
+		if err := runSingle(server, *idle); err != nil {
+			log.Fatalf("Server error: %v", err)
+		}
+		return
+	}
 
 	// Start server
 	log.Printf("Open http://localhost%s in your browser to play", *addr)
@@ -54,3 +154,28 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// runSingle serves the game on a free port of this machine, opens it in
+// the browser and returns once no browser has been connected for idle
+func runSingle(server *api.Server, idle time.Duration) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	url := "http://" + listener.Addr().String()
+	log.Printf("Open %s in your browser to play", url)
+	if err := openBrowser(url); err != nil {
+		log.Printf("Could not open the browser: %v", err)
+	}
+
+	go func() {
+		server.WaitIdle(idle)
+		log.Printf("No browser connected for %s, exiting", idle)
+		listener.Close()
+	}()
+	if err := server.Serve(listener); !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
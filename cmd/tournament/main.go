@@ -0,0 +1,143 @@
+// Command tournament plays headless games between AI configurations and
+// reports how each fared, for balance work.
+//
+//	go run ./cmd/tournament -ai normal,hard:200ms -games 20 -format csv -o results.csv
+package main
+
+import (
+	"civilization/internal/tournament"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	aiList := flag.String("ai", "normal,hard", "Comma-separated AI configurations: normal, hard or hard:<thinking time per turn>, each with an optional @<production weights file>")
+	games := flag.Int("games", 10, "Number of games to play")
+	seed := flag.Int64("seed", 1, "Seed of the first game; each next game uses the next seed")
+	players := flag.Int("players", 4, "Players per game")
+	turns := flag.Int("turns", 200, "Turn limit; games without a winner by then are draws")
+	width := flag.Int("width", 40, "Map width")
+	height := flag.Int("height", 25, "Map height")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "Games played at the same time")
+	format := flag.String("format", "json", "Report format: json, or csv with a row per player of each game")
+	output := flag.String("o", "", "Report file (default: standard output)")
+	verbose := flag.Bool("v", false, "Log the games, with the stalls the AI watchdog catches, to standard error")
+	flag.Parse()
+
+	var entrants []tournament.Entrant
+	for _, spec := range strings.Split(*aiList, ",") {
+		e, err := tournament.ParseEntrant(strings.TrimSpace(spec))
+		if err != nil {
+			log.Fatal(err)
+		}
+		entrants = append(entrants, e)
+	}
+	if *format != "json" && *format != "csv" {
+		log.Fatalf("Unknown report format %q", *format)
+	}
+	if *games < 1 || *players < 2 || *players > 8 || *turns < 1 || *width < 10 || *height < 10 {
+		log.Fatal("Need at least one game of 2 to 8 players, a turn limit and a map of at least 10x10")
+	}
+
+	settings := tournament.Settings{
+		Games:   *games,
+		Seed:    *seed,
+		Players: *players,
+		Turns:   *turns,
+		Width:   *width,
+		Height:  *height,
+	}
+
+	// The game logs every turn; only the report is of interest here
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	start := time.Now()
+	report := tournament.Run(settings, entrants, *parallel)
+	log.SetOutput(os.Stderr)
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var err error
+	if *format == "csv" {
+		err = writeCSV(out, report)
+	} else {
+		err = writeJSON(out, report)
+	}
+	if err != nil {
+		log.Fatalf("Writing report: %v", err)
+	}
+
+	printSummary(os.Stderr, report, time.Since(start))
+}
+
+// writeJSON writes the whole report
+func writeJSON(w io.Writer, report *tournament.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeCSV writes a row for every player of every game
+func writeCSV(w io.Writer, report *tournament.Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"game", "seed", "turns", "stalemate", "seat", "entrant", "won", "alive", "score", "cities", "population", "military", "stalls"})
+	for _, g := range report.Games {
+		for _, s := range g.Seats {
+			cw.Write([]string{
+				strconv.Itoa(g.Game),
+				strconv.FormatInt(g.Seed, 10),
+				strconv.Itoa(g.Turns),
+				strconv.FormatBool(g.Stalemate),
+				strconv.Itoa(s.Seat),
+				s.Entrant,
+				strconv.FormatBool(s.Won),
+				strconv.FormatBool(s.Alive),
+				strconv.Itoa(s.Score),
+				strconv.Itoa(s.Cities),
+				strconv.Itoa(s.Population),
+				strconv.Itoa(s.Military),
+				strconv.Itoa(s.Stalls.Total()),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// printSummary prints the win rates as a table, and the games that
+// reached the turn limit so they can be replayed
+func printSummary(w io.Writer, report *tournament.Report, elapsed time.Duration) {
+	fmt.Fprintf(w, "%d games, %d draws (%d stalemates), in %s\n",
+		len(report.Games), report.Draws, report.Stalemates, elapsed.Round(time.Second))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENTRANT\tGAMES\tWINS\tWIN RATE\tAVG VICTORY TURN\tAVG SCORE\tSURVIVAL\tSTALLS")
+	for _, s := range report.Summary {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f%%\t%.1f\t%.1f\t%.0f%%\t%d\n",
+			s.Entrant, s.Games, s.Wins, s.WinRate*100, s.AvgVictoryTurn, s.AvgScore, s.SurvivalRate*100, s.Stalls)
+	}
+	tw.Flush()
+	for _, g := range report.Games {
+		if g.Stalemate {
+			fmt.Fprintf(w, "Stalemate: game %d (seed %d) had no winner after %d turns\n", g.Game, g.Seed, g.Turns)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Command tui plays a game on a game server in the terminal: the map is
+// drawn as colored characters, and units are moved, attack and found
+// cities from the keyboard. It needs no browser, so it suits headless
+// machines and trying out rules quickly.
+//
+//	go run ./cmd/tui -new -players 2 -humans 2
+//	go run ./cmd/tui -server http://localhost:8888
+package main
+
+import (
+	"civilization/internal/game"
+	"civilization/pkg/client"
+	"context"
+	"flag"
+	"log"
+	"time"
+)
+
+const dialTimeout = 10 * time.Second
+
+func main() {
+	serverURL := flag.String("server", "http://localhost:8888", "Server to play on")
+	newGame := flag.Bool("new", false, "Start a new game rather than join the server's current one")
+	players := flag.Int("players", 4, "Players in a new game")
+	humans := flag.Int("humans", 1, "Human players in a new game; the rest are AI")
+	simultaneous := flag.Bool("simultaneous", false, "Have the players of a new game plan their moves in one shared phase")
+	width := flag.Int("width", 40, "Map width of a new game")
+	height := flag.Int("height", 25, "Map height of a new game")
+	seed := flag.Int64("seed", 0, "Seed of a new game (default: random)")
+	flag.Parse()
+
+	if *newGame && (*players < 2 || *players > 8 || *humans < 1 || *humans > *players) {
+		log.Fatal("Need 2 to 8 players, at least one of them human")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	if *newGame {
+		config := game.DefaultGameConfig()
+		config.MapWidth, config.MapHeight = *width, *height
+		config.PlayerCount, config.HumanPlayers = *players, *humans
+		config.SimultaneousTurns = *simultaneous
+		config.Seed = *seed
+		if _, err := client.NewGame(ctx, *serverURL, config); err != nil {
+			log.Fatal(err)
+		}
+	}
+	c, err := client.Dial(ctx, *serverURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer c.Close()
+
+	if err := play(c); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// play plays the client's game in the terminal until the player quits
+func play(c *client.Client) error {
+	term := openTerminal()
+	defer term.close()
+	u, err := newUI(c, term)
+	if err != nil {
+		return err
+	}
+	return u.run()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// The terminal is put into cbreak mode with stty, so keys arrive as they
+// are pressed and are not echoed. Where there is no stty keys arrive a
+// line at a time instead.
+
+// Keys other than characters, outside the range of bytes
+const (
+	keyUp = 256 + iota
+	keyDown
+	keyLeft
+	keyRight
+)
+
+// terminal is the terminal the game is played in
+type terminal struct {
+	saved string // stty settings to restore, empty if unchanged
+}
+
+// openTerminal puts the terminal into cbreak mode and hides the cursor
+func openTerminal() *terminal {
+	t := &terminal{}
+	if saved, err := stty("-g"); err == nil {
+		if _, err := stty("-icanon", "-echo", "min", "1"); err == nil {
+			t.saved = strings.TrimSpace(saved)
+		}
+	}
+	os.Stdout.WriteString("\x1b[?25l\x1b[2J")
+	return t
+}
+
+// close restores the terminal as it was
+func (t *terminal) close() {
+	os.Stdout.WriteString("\x1b[0m\x1b[2J\x1b[H\x1b[?25h")
+	if t.saved != "" {
+		stty(t.saved)
+	}
+}
+
+// size returns the rows and columns of the terminal, or 24 by 80 if it
+// cannot tell
+func (t *terminal) size() (rows, cols int) {
+	out, err := stty("size")
+	if err != nil {
+		return 24, 80
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 24, 80
+	}
+	rows, err1 := strconv.Atoi(fields[0])
+	cols, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil || rows <= 0 || cols <= 0 {
+		return 24, 80
+	}
+	return rows, cols
+}
+
+// stty runs stty on the terminal with args
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// readKeys sends the keys read from r until it ends, then closes keys.
+// Arrow keys are sent as keyUp, keyDown, keyLeft and keyRight.
+func readKeys(r io.Reader, keys chan<- int) {
+	defer close(keys)
+	in := bufio.NewReader(r)
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return
+		}
+		if b != 0x1b {
+			keys <- int(b)
+			continue
+		}
+
+		// Arrow keys are ESC [ A to ESC [ D
+		if next, err := in.ReadByte(); err != nil || next != '[' {
+			continue
+		}
+		code, err := in.ReadByte()
+		if err != nil {
+			return
+		}
+		switch code {
+		case 'A':
+			keys <- keyUp
+		case 'B':
+			keys <- keyDown
+		case 'C':
+			keys <- keyRight
+		case 'D':
+			keys <- keyLeft
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"civilization/pkg/client"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The cursor picks the tile looked at. On a tile with a unit of the
+// player's, the direction keys move or attack with it and the cursor goes
+// along; elsewhere they move the cursor. Shifted direction keys always
+// move the cursor.
+
+const actionTimeout = 10 * time.Second
+
+const helpLine = "hjklyubn/arrows: move  HJKLYUBN: cursor  tab: next unit  f: fortify  c: found city  e: end turn  q: quit"
+
+// directionKeys are the keys moving a step each way, as in roguelikes
+var directionKeys = map[int]game.Direction{
+	'k': game.North, 'j': game.South, 'l': game.East, 'h': game.West,
+	'u': game.NorthEast, 'y': game.NorthWest, 'n': game.SouthEast, 'b': game.SouthWest,
+	keyUp: game.North, keyDown: game.South, keyRight: game.East, keyLeft: game.West,
+}
+
+// ui is a game being played in the terminal
+type ui struct {
+	client  *client.Client
+	term    *terminal
+	tiles   []api.TileDTO // Tiles of a map sent in chunks
+	cursor  game.Coord
+	message string // Last thing that happened
+}
+
+// newUI starts playing the client's game, with the cursor on one of the
+// player's units or cities
+func newUI(c *client.Client, term *terminal) (*ui, error) {
+	u := &ui{client: c, term: term}
+	state := c.State()
+	if state.Map.ChunkSize > 0 {
+		if err := u.fetchTiles(state); err != nil {
+			return nil, err
+		}
+	}
+
+	u.cursor = game.At(state.Map.Width/2, state.Map.Height/2)
+	if me := u.view().me; me != nil {
+		switch {
+		case len(me.Units) > 0:
+			u.cursor = game.At(me.Units[0].X, me.Units[0].Y)
+		case len(me.Cities) > 0:
+			u.cursor = game.At(me.Cities[0].X, me.Cities[0].Y)
+		}
+	}
+	return u, nil
+}
+
+// fetchTiles fetches the tiles of a map sent in chunks
+func (u *ui) fetchTiles(state *api.GameStateMessage) error {
+	size := state.Map.ChunkSize
+	for cy := 0; cy*size < state.Map.Height; cy++ {
+		for cx := 0; cx*size < state.Map.Width; cx++ {
+			var chunk api.MapChunkMessage
+			if err := u.client.Query(context.Background(), "map_chunk", api.MapChunkQuery{ChunkX: cx, ChunkY: cy}, &chunk); err != nil {
+				return fmt.Errorf("fetching the map: %w", err)
+			}
+			u.tiles = append(u.tiles, chunk.Tiles...)
+		}
+	}
+	return nil
+}
+
+// view returns the game as it is to be drawn now
+func (u *ui) view() *view {
+	return newView(u.client.State(), u.tiles, u.client.PlayerID())
+}
+
+// run plays until the player quits, the keys end or the connection closes
+func (u *ui) run() error {
+	keys := make(chan int)
+	go readKeys(os.Stdin, keys)
+	events := u.client.Events()
+
+	u.draw()
+	for {
+		select {
+		case key, ok := <-keys:
+			if !ok || key == 'q' {
+				return nil
+			}
+			u.press(key)
+		case e, ok := <-events:
+			if !ok {
+				return u.client.Err()
+			}
+			u.note(e)
+		}
+		u.draw()
+	}
+}
+
+// press handles a key
+func (u *ui) press(key int) {
+	if d, ok := directionKeys[key]; ok {
+		u.step(d)
+		return
+	}
+	if key >= 'A' && key <= 'Z' {
+		if d, ok := directionKeys[key-'A'+'a']; ok {
+			u.moveCursor(d)
+		}
+		return
+	}
+
+	switch key {
+	case '\t', ' ':
+		u.nextUnit()
+	case 'f':
+		if unit := u.selected(); unit != nil {
+			u.do(&game.FortifyAction{UnitID: unit.ID})
+		}
+	case 'c':
+		if unit := u.selected(); unit != nil {
+			u.do(&game.FoundCityAction{SettlerID: unit.ID})
+		}
+	case 'e':
+		if u.client.State().Phase == game.PhaseSimultaneous.String() {
+			u.do(&game.SubmitOrdersAction{PlayerID: u.client.PlayerID()})
+		} else {
+			u.do(&game.EndTurnAction{})
+		}
+	}
+}
+
+// selected returns the player's unit on the cursor, preferring one that
+// may still move, or nil if there is none
+func (u *ui) selected() *api.UnitDTO {
+	var found *api.UnitDTO
+	for _, unit := range u.view().units[u.cursor] {
+		if unit.OwnerID != u.client.PlayerID() {
+			continue
+		}
+		if unit.MovementLeft > 0 {
+			return unit
+		}
+		if found == nil {
+			found = unit
+		}
+	}
+	return found
+}
+
+// step moves or attacks a step with the selected unit, or moves the
+// cursor without one
+func (u *ui) step(d game.Direction) {
+	unit := u.selected()
+	if unit == nil {
+		u.moveCursor(d)
+		return
+	}
+
+	v := u.view()
+	to := u.cursor.Step(d)
+	enemy := slices.ContainsFunc(v.units[to], func(other *api.UnitDTO) bool { return other.OwnerID != unit.OwnerID })
+	if city := v.cities[to]; city != nil && city.OwnerID != unit.OwnerID {
+		enemy = true
+	}
+
+	if v.state.Phase == game.PhaseSimultaneous.String() {
+		kind := game.OrderMove
+		if enemy {
+			kind = game.OrderAttack
+		}
+		if u.do(&game.PlanOrderAction{UnitID: unit.ID, Kind: kind, X: to.X, Y: to.Y}) {
+			u.message = fmt.Sprintf("%s ordered to %s (%d, %d)", unit.Type, kind, to.X, to.Y)
+		}
+		return
+	}
+
+	var action game.Action = &game.MoveUnitAction{UnitID: unit.ID, ToX: to.X, ToY: to.Y}
+	if enemy {
+		action = &game.AttackAction{AttackerID: unit.ID, TargetX: to.X, TargetY: to.Y}
+	}
+	if !u.do(action) {
+		return
+	}
+	// The cursor follows the unit, if it is still there to follow
+	for _, moved := range u.view().units[to] {
+		if moved.ID == unit.ID {
+			u.cursor = to
+		}
+	}
+}
+
+// moveCursor moves the cursor a step, staying on the map
+func (u *ui) moveCursor(d game.Direction) {
+	m := u.client.State().Map
+	to := u.cursor.Step(d)
+	if to.X >= 0 && to.Y >= 0 && to.X < m.Width && to.Y < m.Height {
+		u.cursor = to
+	}
+}
+
+// nextUnit puts the cursor on the player's next unit after the selected
+// one that may still move and is not fortified
+func (u *ui) nextUnit() {
+	me := u.view().me
+	if me == nil {
+		return
+	}
+	ready := func(unit api.UnitDTO) bool { return unit.MovementLeft > 0 && !unit.IsFortified }
+	start := 0
+	if unit := u.selected(); unit != nil {
+		start = slices.IndexFunc(me.Units, func(other api.UnitDTO) bool { return other.ID == unit.ID }) + 1
+	}
+	for i := range me.Units {
+		unit := me.Units[(start+i)%len(me.Units)]
+		if ready(unit) {
+			u.cursor = game.At(unit.X, unit.Y)
+			return
+		}
+	}
+	u.message = "No units left to move"
+}
+
+// do takes an action, and reports whether the server applied it
+func (u *ui) do(action game.Action) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+	defer cancel()
+	if _, err := u.client.Do(ctx, action); err != nil {
+		var refused *client.ServerError
+		if errors.As(err, &refused) {
+			u.message = "Refused: " + refused.Error()
+		} else {
+			u.message = "Error: " + err.Error()
+		}
+		return false
+	}
+	u.message = ""
+	return true
+}
+
+// note tells the player of an event worth telling
+func (u *ui) note(e client.Event) {
+	switch e := e.(type) {
+	case client.CombatResult:
+		outcome := "lost"
+		if e.AttackerWon {
+			outcome = "won"
+		}
+		u.message = fmt.Sprintf("%s attacked %s at (%d, %d) and %s", e.AttackerUnit, e.DefenderUnit, e.X, e.Y, outcome)
+		if e.CityCaptured != "" {
+			u.message += ", capturing " + e.CityCaptured
+		}
+	case client.CityFounded:
+		u.message = e.Name + " founded"
+	case client.TurnChange:
+		u.message = fmt.Sprintf("Turn %d: %s", e.Turn, e.PlayerName)
+	}
+}
+
+// draw redraws the screen
+func (u *ui) draw() {
+	rows, cols := u.term.size()
+	v := u.view()
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	v.drawMap(&b, u.cursor, max(1, rows-statusLines), cols)
+	for i, line := range []string{u.status(v), u.describe(v), u.message, helpLine} {
+		if len(line) > cols {
+			line = line[:cols]
+		}
+		b.WriteString(line + "\x1b[K")
+		if i < statusLines-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	os.Stdout.WriteString(b.String())
+}
+
+// status returns the line telling the turn, whose it is and the player's
+// gold
+func (u *ui) status(v *view) string {
+	state := v.state
+	line := fmt.Sprintf("Turn %d", state.Turn)
+	switch state.Phase {
+	case game.PhaseSimultaneous.String():
+		if slices.Contains(state.Submitted, u.client.PlayerID()) {
+			line += "  Orders submitted, waiting for the others"
+		} else {
+			line += "  Planning orders"
+		}
+	case game.PhaseGameOver.String():
+		line += "  Game over"
+		if state.Winner != nil {
+			line += ", " + state.Winner.Name + " won"
+		}
+	default:
+		for _, p := range state.Players {
+			if p.ID == state.CurrentPlayer {
+				line += "  " + p.Name + "'s turn"
+			}
+		}
+		if state.CurrentPlayer == u.client.PlayerID() {
+			line += " (you)"
+		}
+	}
+	if v.me != nil {
+		line += fmt.Sprintf("  Gold %d", v.me.Gold)
+	}
+	return line
+}
+
+// describe returns the line telling what is on the tile under the cursor
+func (u *ui) describe(v *view) string {
+	at := u.cursor
+	if !v.explored(at) {
+		return fmt.Sprintf("(%d, %d) Unexplored", at.X, at.Y)
+	}
+	parts := []string{fmt.Sprintf("(%d, %d)", at.X, at.Y)}
+	if tile := v.tiles[at]; tile != nil {
+		parts = append(parts, tile.Terrain)
+	}
+	if city := v.cities[at]; city != nil {
+		parts = append(parts, fmt.Sprintf("%s (size %d)", city.Name, city.Population))
+	}
+	for _, unit := range v.units[at] {
+		desc := fmt.Sprintf("%s %d/%d hp", unitTitle(unit), unit.Health, unit.MaxHealth)
+		if unit.OwnerID == u.client.PlayerID() {
+			desc += fmt.Sprintf(", %d moves", unit.MovementLeft)
+			if unit.IsFortified {
+				desc += ", fortified"
+			}
+		}
+		parts = append(parts, desc)
+	}
+	return strings.Join(parts, "  ")
+}
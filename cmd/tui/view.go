@@ -0,0 +1,187 @@
+package main
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Each tile is a character on a background of its terrain's color: the
+// terrain's own character, or a city as # or the top unit by the first
+// letter of its type, in the color of their owner. Tiles the player has
+// not explored are blank.
+
+// terrainLooks are the character and background color of each terrain
+var terrainLooks = map[string]struct {
+	char  byte
+	color string
+}{
+	game.TerrainOcean.String():     {'~', "0040a0"},
+	game.TerrainGrassland.String(): {'.', "00a800"},
+	game.TerrainPlains.String():    {',', "c8b040"},
+	game.TerrainDesert.String():    {':', "e8d858"},
+	game.TerrainHills.String():     {'n', "987850"},
+	game.TerrainMountains.String(): {'^', "808080"},
+	game.TerrainForest.String():    {'%', "006800"},
+}
+
+const (
+	statusLines  = 4 // Lines under the map
+	unknownColor = "000000"
+	terrainFG    = "202020" // Color of terrain characters
+	riverMarkFG  = "80c0ff" // Color of terrain characters on river tiles
+)
+
+// rgb returns the escape code setting the foreground, or with bg the
+// background, to a hex color such as "ff8000" or "#ff8000"
+func rgb(hex string, bg bool) string {
+	hex = strings.TrimPrefix(hex, "#")
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		v = 0xffffff
+	}
+	layer := 38
+	if bg {
+		layer = 48
+	}
+	return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, v>>16, v>>8&0xff, v&0xff)
+}
+
+// view is what is drawn of the game: its tiles, units and cities by
+// position
+type view struct {
+	state  *api.GameStateMessage
+	me     *api.PlayerDTO
+	tiles  map[game.Coord]*api.TileDTO
+	units  map[game.Coord][]*api.UnitDTO
+	cities map[game.Coord]*api.CityDTO
+	colors map[string]string // Player ID -> color
+}
+
+// newView indexes a game state for drawing, as seen by a player. On maps
+// sent in chunks the tiles are those fetched apart from the state.
+func newView(state *api.GameStateMessage, tiles []api.TileDTO, playerID string) *view {
+	v := &view{
+		state:  state,
+		tiles:  make(map[game.Coord]*api.TileDTO),
+		units:  make(map[game.Coord][]*api.UnitDTO),
+		cities: make(map[game.Coord]*api.CityDTO),
+		colors: make(map[string]string),
+	}
+	if len(state.Map.Tiles) > 0 {
+		tiles = state.Map.Tiles
+	}
+	for i := range tiles {
+		tile := &tiles[i]
+		v.tiles[game.At(tile.X, tile.Y)] = tile
+	}
+	for i := range state.Players {
+		p := &state.Players[i]
+		if p.ID == playerID {
+			v.me = p
+		}
+		v.colors[p.ID] = p.Color
+		for j := range p.Units {
+			unit := &p.Units[j]
+			at := game.At(unit.X, unit.Y)
+			v.units[at] = append(v.units[at], unit)
+		}
+		for j := range p.Cities {
+			city := &p.Cities[j]
+			v.cities[game.At(city.X, city.Y)] = city
+		}
+	}
+	return v
+}
+
+// explored reports whether the player has seen a tile. Without a bitset,
+// as for a player who has left the game, every tile is shown.
+func (v *view) explored(at game.Coord) bool {
+	if v.me == nil || len(v.me.Explored) == 0 {
+		return true
+	}
+	i := at.Y*v.state.Map.Width + at.X
+	return i/8 < len(v.me.Explored) && v.me.Explored[i/8]&(1<<(i%8)) != 0
+}
+
+// cell returns the escape codes and character drawing a tile
+func (v *view) cell(at game.Coord) string {
+	tile := v.tiles[at]
+	if tile == nil || !v.explored(at) {
+		return rgb(unknownColor, true) + " "
+	}
+	look, ok := terrainLooks[tile.Terrain]
+	if !ok {
+		look.char, look.color = '?', unknownColor
+	}
+	bg := rgb(look.color, true)
+
+	if city := v.cities[at]; city != nil {
+		return bg + "\x1b[1m" + rgb(v.colors[city.OwnerID], false) + "#\x1b[22m"
+	}
+	if units := v.units[at]; len(units) > 0 {
+		return bg + "\x1b[1m" + rgb(v.colors[units[0].OwnerID], false) + unitChar(units[0]) + "\x1b[22m"
+	}
+	fg := terrainFG
+	if tile.HasRiver {
+		fg = riverMarkFG
+	}
+	return bg + rgb(fg, false) + string(look.char)
+}
+
+// unitChar returns the character a unit is drawn as: the first letter of
+// its type
+func unitChar(unit *api.UnitDTO) string {
+	if unit.Type == "" {
+		return "?"
+	}
+	return strings.ToUpper(unit.Type[:1])
+}
+
+// unitTitle returns what a unit is called: the name its owner gave it or
+// its type, with any honorific it has earned
+func unitTitle(unit *api.UnitDTO) string {
+	title := unit.Type
+	if unit.Name != "" {
+		title = unit.Name
+	}
+	if unit.Honorific != "" {
+		title += " " + unit.Honorific
+	}
+	return title
+}
+
+// origin returns the top left tile of a window of the map of the given
+// size that keeps the cursor in the middle, as far as the map allows
+func origin(cursor game.Coord, mapWidth, mapHeight, width, height int) game.Coord {
+	clamp := func(center, size, window int) int {
+		return max(0, min(center-window/2, size-window))
+	}
+	return game.At(clamp(cursor.X, mapWidth, width), clamp(cursor.Y, mapHeight, height))
+}
+
+// drawMap writes the window of the map onto b, with the cursor shown in
+// inverse
+func (v *view) drawMap(b *strings.Builder, cursor game.Coord, rows, cols int) {
+	width := min(cols, v.state.Map.Width)
+	height := min(rows, v.state.Map.Height)
+	top := origin(cursor, v.state.Map.Width, v.state.Map.Height, width, height)
+	for y := top.Y; y < top.Y+height; y++ {
+		for x := top.X; x < top.X+width; x++ {
+			at := game.At(x, y)
+			if at == cursor {
+				b.WriteString("\x1b[7m")
+			}
+			b.WriteString(v.cell(at))
+			if at == cursor {
+				b.WriteString("\x1b[27m")
+			}
+		}
+		b.WriteString("\x1b[0m\x1b[K\r\n")
+	}
+	for y := height; y < rows; y++ {
+		b.WriteString("\x1b[K\r\n")
+	}
+}
@@ -0,0 +1,80 @@
+package ai
+
+import "civilization/internal/game"
+
+// An AI given an advanced start spends all its points in its first turn:
+// on cities at the best sites near its units, each with a phalanx to hold
+// it, then on technologies, and what is left on warriors for its cities.
+
+// maxStartCities is the most cities an AI buys with its start points
+const maxStartCities = 4
+
+// spendStartPoints plans the AI's purchases on a copy of the game. It
+// returns them with the copy they were made on, so the rest of the turn
+// can be decided with the cities and units bought.
+func (c *Controller) spendStartPoints() ([]game.Action, *game.GameState) {
+	g := c.Game.Clone()
+	player := g.GetPlayer(c.PlayerID)
+	actions := make([]game.Action, 0)
+	buy := func(a *game.BuyStartAction) bool {
+		a.PlayerID = c.PlayerID
+		if _, err := g.Apply(c.PlayerID, a); err != nil {
+			return false
+		}
+		actions = append(actions, a)
+		return true
+	}
+	if len(player.Units) == 0 && len(player.Cities) == 0 {
+		return actions, g
+	}
+
+	// Leave the AI's settlers the dots they are sent to
+	dots := c.dotMap()
+	var anchor game.Coord
+	if len(player.Cities) > 0 {
+		anchor = player.Cities[0].Coord()
+	} else {
+		anchor = player.Units[0].Coord()
+	}
+	for _, unit := range player.Units {
+		if !unit.CanFoundCity() {
+			continue
+		}
+		dots.assign(unit)
+	}
+
+	defender := game.ScaleCost(game.UnitTemplates[game.UnitPhalanx].Cost, g.Config.Speed)
+	bought := make([]game.Coord, 0, maxStartCities)
+	for len(bought) < maxStartCities && player.StartPoints >= game.AdvancedStartCityCost+defender {
+		site := dots.nearestFree(anchor.X, anchor.Y)
+		if site == nil {
+			break
+		}
+		dots.drop(*site)
+		if !buy(&game.BuyStartAction{Item: game.BuyCity, X: site.X, Y: site.Y}) {
+			continue
+		}
+		buy(&game.BuyStartAction{Item: game.BuyUnit, X: site.X, Y: site.Y, UnitType: game.UnitPhalanx})
+		bought = append(bought, *site)
+	}
+
+	for buy(&game.BuyStartAction{Item: game.BuyTech}) {
+	}
+
+	// Warriors for the cities bought, in turn, while the points last
+	for len(bought) > 0 {
+		spent := false
+		for _, site := range bought {
+			if buy(&game.BuyStartAction{Item: game.BuyUnit, X: site.X, Y: site.Y, UnitType: game.UnitWarrior}) {
+				spent = true
+			}
+		}
+		if !spent {
+			break
+		}
+	}
+
+	// Dot the map again around the cities bought
+	dots.plan(g)
+	return actions, g
+}
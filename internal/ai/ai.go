@@ -2,6 +2,7 @@ package ai
 
 import (
 	"civilization/internal/game"
+	"time"
 )
 
 // Strategy represents the AI's current strategic focus
@@ -32,14 +33,40 @@ type Controller struct {
 	Game     *game.GameState
 	PlayerID string
 	Strategy Strategy
+	paths    *PathCache
+	dots     *dotMap    // Planned city sites, kept from turn to turn
+	war      *warPlan   // War waged while aggressive, kept from turn to turn
+	threats  *threatMap // Enemy reach for the current turn, built on demand
+	watchdog *watchdog  // Stalls watched for from turn to turn
+
+	// Lookahead weighs unit decisions on copies of the game. It is set for
+	// hard AIs and nil otherwise.
+	Lookahead *Lookahead
+
+	// Weights score what cities build; nil for DefaultProductionWeights
+	Weights *ProductionWeights
+
+	// Stalls counts the stalls the watchdog caught
+	Stalls Stalls
 }
 
-// NewController creates a new AI controller
+// NewController creates a new AI controller with the brain of the game's
+// AI difficulty
 func NewController(g *game.GameState, playerID string) *Controller {
+	c := newRuleController(g, playerID)
+	if g.Config.AIDifficulty == game.DifficultyHard {
+		c.Lookahead = NewLookahead(DefaultLookaheadBudget)
+	}
+	return c
+}
+
+// newRuleController creates an AI controller that decides by rules alone
+func newRuleController(g *game.GameState, playerID string) *Controller {
 	return &Controller{
 		Game:     g,
 		PlayerID: playerID,
 		Strategy: StrategyExpansion,
+		paths:    NewPathCache(),
 	}
 }
 
@@ -55,11 +82,38 @@ func (c *Controller) TakeTurn() []game.Action {
 		return []game.Action{&game.EndTurnAction{}}
 	}
 
+	// Forget routes of units that were lost since the last turn
+	c.paths.Prune(c.Game)
+	c.threats = nil
+	if c.dots != nil {
+		c.dots.refresh(c.Game, player)
+	}
+	c.watch()
+
 	// Update strategy based on game state
 	c.updateStrategy()
+	c.updateWarPlan()
+	if c.Lookahead != nil {
+		c.Lookahead.startTurn(c.Game, time.Now())
+	}
 
 	actions := make([]game.Action, 0)
 
+	// Spend advanced start points first, and decide the rest of the turn
+	// on the copy of the game they were spent on
+	if player.StartPoints > 0 {
+		bought, spent := c.spendStartPoints()
+		actions = append(actions, bought...)
+		defer func(g *game.GameState) { c.Game = g }(c.Game)
+		c.Game = spent
+	}
+
+	// Answer deals offered since the last turn
+	actions = append(actions, c.answerOffers()...)
+
+	// Keep the treasury out of debt
+	actions = append(actions, c.setTaxRate()...)
+
 	// Process cities first (set production)
 	actions = append(actions, c.processCities()...)
 
@@ -72,6 +126,48 @@ func (c *Controller) TakeTurn() []game.Action {
 	return actions
 }
 
+// PlayTurns lets AI controllers play every player, including humans, until
+// the game reaches the given turn or ends. It is used for headless games.
+func PlayTurns(g *game.GameState, untilTurn int) {
+	controllers := make(map[string]*Controller)
+	for _, p := range g.Players {
+		controllers[p.ID] = NewController(g, p.ID)
+	}
+	PlayTurnsWith(g, controllers, untilTurn)
+}
+
+// PlayTurnsWith is PlayTurns with a controller of the caller's choosing for
+// each player, so differently configured AIs can play each other
+func PlayTurnsWith(g *game.GameState, controllers map[string]*Controller, untilTurn int) {
+	for g.CurrentTurn < untilTurn && g.Phase != game.PhaseGameOver {
+		if !playTurn(g, controllers) {
+			return
+		}
+	}
+}
+
+// playTurn plays the current player's turn with their controller. It
+// reports false when the turn could not be ended.
+func playTurn(g *game.GameState, controllers map[string]*Controller) bool {
+	player := g.GetCurrentPlayer()
+	if player == nil {
+		return false
+	}
+
+	turn, current := g.CurrentTurn, g.TurnOrder.Current
+	for _, action := range controllers[player.ID].TakeTurn() {
+		g.Apply(player.ID, action)
+	}
+
+	// Make sure the turn advances even if the end turn action failed
+	if g.CurrentTurn == turn && g.TurnOrder.Current == current {
+		if _, err := g.Apply(player.ID, &game.EndTurnAction{}); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 // updateStrategy adjusts the AI strategy based on current game state
 func (c *Controller) updateStrategy() {
 	player := c.GetPlayer()
@@ -93,13 +189,18 @@ func (c *Controller) updateStrategy() {
 		// Ready to attack
 		c.Strategy = StrategyAggression
 	}
+
+	// A nuclear strike by anyone puts established empires on a war footing
+	if cityCount >= 3 && len(c.Game.NuclearAggressors(c.PlayerID)) > 0 {
+		c.Strategy = StrategyAggression
+	}
 }
 
-// countMilitaryUnits counts non-settler units
+// countMilitaryUnits counts units other than settlers and caravans
 func (c *Controller) countMilitaryUnits(player *game.Player) int {
 	count := 0
 	for _, unit := range player.Units {
-		if !unit.CanFoundCity() {
+		if !unit.CanFoundCity() && !unit.HelpsWonder() {
 			count++
 		}
 	}
@@ -115,7 +216,10 @@ func (c *Controller) processCities() []game.Action {
 	}
 
 	for _, city := range player.Cities {
-		if city.CurrentBuild == nil {
+		// Caravans repeat like other units until the wonder has enough, and
+		// a lost Palace is rebuilt whatever the city was building
+		rebuildsPalace := city == c.palaceCity() && !buildsPalace(city)
+		if city.CurrentBuild == nil || c.rechooses(city) || rebuildsPalace {
 			buildItem := c.decideCityProduction(city)
 			action := &game.SetProductionAction{
 				CityID:    city.ID,
@@ -125,42 +229,52 @@ func (c *Controller) processCities() []game.Action {
 				actions = append(actions, action)
 			}
 		}
+
+		if strike := c.cityStrike(city); strike != nil {
+			actions = append(actions, strike)
+		}
 	}
 
 	return actions
 }
 
-// decideCityProduction determines what a city should build
-func (c *Controller) decideCityProduction(city *game.City) game.BuildItem {
-	player := c.GetPlayer()
-
-	switch c.Strategy {
-	case StrategyExpansion:
-		// Build settlers if we have capacity
-		if len(player.Cities) < 5 && city.Population >= 2 {
-			return game.BuildItem{IsUnit: true, UnitType: game.UnitSettler}
-		}
-		// Build warriors for protection
-		return game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior}
+// rechooses reports whether a city building units should choose again:
+// when its last unit is just finished and no shields would be lost, or
+// when it builds caravans no wonder needs
+func (c *Controller) rechooses(city *game.City) bool {
+	if buildsCaravans(city) && !c.needsCaravans(city) {
+		return true
+	}
+	return city.CurrentBuild.IsUnit && city.Production == 0
+}
 
-	case StrategyBuildup:
-		// Build barracks first for veteran units
-		if !city.HasBarracks() {
-			return game.BuildItem{IsUnit: false, Building: game.BuildingBarracks}
+// cityStrike returns a ranged strike on an enemy next to the city, or nil
+func (c *Controller) cityStrike(city *game.City) game.Action {
+	for _, at := range city.Coord().Neighbors() {
+		action := &game.CityStrikeAction{
+			CityID:  city.ID,
+			TargetX: at.X,
+			TargetY: at.Y,
 		}
-		// Build walls for defense
-		if !city.HasWalls() && city.Population >= 3 {
-			return game.BuildItem{IsUnit: false, Building: game.BuildingWalls}
+		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			return action
 		}
-		// Build defensive units
+	}
+	return nil
+}
+
+// decideCityProduction determines what a city should build
+func (c *Controller) decideCityProduction(city *game.City) game.BuildItem {
+	player := c.GetPlayer()
+	if city == c.palaceCity() {
+		return game.BuildItem{IsUnit: false, Building: game.BuildingPalace}
+	}
+	if city == c.kingCity() && militaryDefenders(player, city) < kingGuards {
 		return game.BuildItem{IsUnit: true, UnitType: game.UnitPhalanx}
+	}
 
-	case StrategyAggression:
-		// Build offensive units
-		if city.Population >= 4 {
-			return game.BuildItem{IsUnit: true, UnitType: game.UnitHorseman}
-		}
-		return game.BuildItem{IsUnit: true, UnitType: game.UnitArcher}
+	if item, ok := c.bestProduction(city); ok {
+		return item
 	}
 
 	// Default
@@ -176,18 +290,33 @@ func (c *Controller) processUnits() []game.Action {
 	}
 
 	for _, unit := range player.Units {
-		if !unit.CanMove() {
+		if !unit.NeedsOrders() {
 			continue
 		}
 
 		var unitActions []game.Action
 
-		if unit.CanFoundCity() {
+		if c.isResting(unit) {
+			actions = append(actions, c.rest(unit)...)
+			continue
+		}
+
+		if unit.IsKing() {
+			unitActions = c.handleKing(unit)
+		} else if unit.CanFoundCity() {
 			unitActions = c.handleSettler(unit)
+		} else if unit.IsNuclear() {
+			unitActions = c.handleNuclear(unit)
+		} else if unit.HelpsWonder() {
+			unitActions = c.handleCaravan(unit)
 		} else {
 			unitActions = c.handleMilitaryUnit(unit)
 		}
 
+		if c.Lookahead != nil && !unit.IsNuclear() && !unit.HelpsWonder() && !unit.IsKing() {
+			unitActions = c.Lookahead.choose(c, unit, unitActions)
+		}
+
 		actions = append(actions, unitActions...)
 	}
 
@@ -198,31 +327,58 @@ func (c *Controller) processUnits() []game.Action {
 func (c *Controller) handleSettler(unit *game.Unit) []game.Action {
 	actions := make([]game.Action, 0)
 
-	// Check if current location is good for a city
-	if c.isGoodCityLocation(unit.X, unit.Y) {
-		action := &game.FoundCityAction{
-			SettlerID: unit.ID,
-			CityName:  c.generateCityName(),
+	// The first city is founded where the first settler starts, so no turns
+	// are lost getting the empire going
+	if player := c.GetPlayer(); len(player.Cities) == 0 && firstSettler(player) == unit {
+		action := &game.FoundCityAction{SettlerID: unit.ID}
+		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			actions = append(actions, action)
+			return actions
+		}
+	}
+
+	// Settlers that waited too long for a city found one where they can
+	if c.settlesAnywhere() {
+		action := &game.FoundCityAction{SettlerID: unit.ID}
+		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			actions = append(actions, action)
+			return actions
 		}
+	}
+
+	// Settle on the dot the settler was sent to
+	dots := c.dotMap()
+	target := dots.assign(unit)
+	if target != nil && unit.X == target.X && unit.Y == target.Y {
+		// The game names the city
+		action := &game.FoundCityAction{SettlerID: unit.ID}
 		if err := action.Validate(c.Game, c.PlayerID); err == nil {
 			actions = append(actions, action)
 			return actions
 		}
+
+		// The site cannot be settled after all; try another next turn
+		dots.release(unit)
+		dots.drop(*target)
+		return actions
 	}
 
-	// Find a good location and move toward it
-	target := c.findGoodCityLocation(unit)
+	// Move toward it
 	if target != nil {
-		nextMove := GetNextMove(c.Game, unit, target.X, target.Y)
-		if nextMove != nil {
-			action := &game.MoveUnitAction{
-				UnitID: unit.ID,
-				ToX:    nextMove.X,
-				ToY:    nextMove.Y,
-			}
-			if err := action.Validate(c.Game, c.PlayerID); err == nil {
-				actions = append(actions, action)
-			}
+		nextMove := c.paths.NextMove(c.Game, unit, target.X, target.Y)
+		if nextMove == nil {
+			// Out of reach; another dot next turn
+			dots.release(unit)
+			dots.drop(*target)
+			return actions
+		}
+		action := &game.MoveUnitAction{
+			UnitID: unit.ID,
+			ToX:    nextMove.X,
+			ToY:    nextMove.Y,
+		}
+		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			actions = append(actions, action)
 		}
 	}
 
@@ -233,19 +389,24 @@ func (c *Controller) handleSettler(unit *game.Unit) []game.Action {
 func (c *Controller) handleMilitaryUnit(unit *game.Unit) []game.Action {
 	actions := make([]game.Action, 0)
 
-	switch c.Strategy {
-	case StrategyExpansion, StrategyBuildup:
+	switch {
+	case c.guardsKing(unit):
+		// Stay with the King
+	case shouldRetreat(unit):
+		// Withdraw to heal
+		actions = c.retreat(unit)
+	case c.Strategy == StrategyExpansion, c.Strategy == StrategyBuildup:
 		// Defend cities
 		actions = c.defendCity(unit)
 
-	case StrategyAggression:
+	case c.Strategy == StrategyAggression:
 		// Attack enemies
-		actions = c.attackEnemy(unit)
+		actions = c.wageWar(unit)
 	}
 
 	// If no specific action, try to fortify in a good position
 	if len(actions) == 0 {
-		if c.shouldFortify(unit) {
+		if c.guardsKing(unit) || c.shouldFortify(unit) {
 			action := &game.FortifyAction{UnitID: unit.ID}
 			if err := action.Validate(c.Game, c.PlayerID); err == nil {
 				actions = append(actions, action)
@@ -256,6 +417,32 @@ func (c *Controller) handleMilitaryUnit(unit *game.Unit) []game.Action {
 	return actions
 }
 
+// handleNuclear keeps nuclear units as a deterrent, striking only at the
+// largest city of a player that has already used nuclear weapons
+func (c *Controller) handleNuclear(unit *game.Unit) []game.Action {
+	var target *game.City
+	for _, aggressor := range c.Game.NuclearAggressors(c.PlayerID) {
+		for _, city := range aggressor.Cities {
+			if target == nil || city.Population > target.Population {
+				target = city
+			}
+		}
+	}
+
+	var action game.Action = &game.FortifyAction{UnitID: unit.ID}
+	if target != nil {
+		action = &game.NukeAction{
+			UnitID:  unit.ID,
+			TargetX: target.X,
+			TargetY: target.Y,
+		}
+	}
+	if err := action.Validate(c.Game, c.PlayerID); err != nil {
+		return nil
+	}
+	return []game.Action{action}
+}
+
 // defendCity moves unit toward an undefended city
 func (c *Controller) defendCity(unit *game.Unit) []game.Action {
 	actions := make([]game.Action, 0)
@@ -266,16 +453,8 @@ func (c *Controller) defendCity(unit *game.Unit) []game.Action {
 	minDist := 9999
 
 	for _, city := range player.Cities {
-		defenders := player.GetUnitsAt(city.X, city.Y)
-		militaryDefenders := 0
-		for _, d := range defenders {
-			if !d.CanFoundCity() {
-				militaryDefenders++
-			}
-		}
-
-		if militaryDefenders == 0 {
-			dist := DistanceTo(unit.X, unit.Y, city.X, city.Y)
+		if militaryDefenders(player, city) < c.guardsNeeded(city) {
+			dist := unit.Coord().Distance(city.Coord())
 			if dist < minDist {
 				minDist = dist
 				targetCity = city
@@ -292,7 +471,7 @@ func (c *Controller) defendCity(unit *game.Unit) []game.Action {
 			}
 		} else {
 			// Move toward city
-			nextMove := GetNextMove(c.Game, unit, targetCity.X, targetCity.Y)
+			nextMove := c.paths.NextMove(c.Game, unit, targetCity.X, targetCity.Y)
 			if nextMove != nil {
 				action := &game.MoveUnitAction{
 					UnitID: unit.ID,
@@ -304,6 +483,10 @@ func (c *Controller) defendCity(unit *game.Unit) []game.Action {
 				}
 			}
 		}
+	} else if city := c.Game.GetCityAt(unit.X, unit.Y); city == nil || city.OwnerID != c.PlayerID ||
+		militaryDefenders(player, city) > c.guardsNeeded(city) {
+		// Every city is held, so spare units hold the border
+		actions = c.holdGround(unit)
 	}
 
 	return actions
@@ -319,29 +502,26 @@ func (c *Controller) attackEnemy(unit *game.Unit) []game.Action {
 		return actions
 	}
 
-	// Check if adjacent - can attack
-	dx := target.X - unit.X
-	dy := target.Y - unit.Y
-	if dx < 0 {
-		dx = -dx
-	}
-	if dy < 0 {
-		dy = -dy
-	}
-
-	if dx <= 1 && dy <= 1 && !(dx == 0 && dy == 0) {
-		// Adjacent - attack!
-		action := &game.AttackAction{
-			AttackerID: unit.ID,
-			TargetX:    target.X,
-			TargetY:    target.Y,
+	// Siege units soften targets from range while they can
+	if unit.IsSiegeUnit() && unit.Coord().Distance(*target) <= game.BombardRange {
+		action := &game.BombardAction{
+			UnitID:  unit.ID,
+			TargetX: target.X,
+			TargetY: target.Y,
 		}
 		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			return append(actions, action)
+		}
+	}
+
+	if unit.Coord().Adjacent(*target) {
+		// Adjacent - attack the best target there is
+		if action := c.bestAttack(unit); action != nil {
 			actions = append(actions, action)
 		}
 	} else {
 		// Move toward enemy
-		nextMove := GetNextMove(c.Game, unit, target.X, target.Y)
+		nextMove := c.paths.NextMove(c.Game, unit, target.X, target.Y)
 		if nextMove != nil {
 			action := &game.MoveUnitAction{
 				UnitID: unit.ID,
@@ -358,9 +538,9 @@ func (c *Controller) attackEnemy(unit *game.Unit) []game.Action {
 }
 
 // findNearestEnemy finds the nearest enemy unit or city
-func (c *Controller) findNearestEnemy(unit *game.Unit) *Point {
+func (c *Controller) findNearestEnemy(unit *game.Unit) *game.Coord {
 	minDist := 9999
-	var nearest *Point
+	var nearest *game.Coord
 
 	for _, player := range c.Game.Players {
 		if player.ID == c.PlayerID || !player.IsAlive {
@@ -369,19 +549,19 @@ func (c *Controller) findNearestEnemy(unit *game.Unit) *Point {
 
 		// Check enemy units
 		for _, enemy := range player.Units {
-			dist := DistanceTo(unit.X, unit.Y, enemy.X, enemy.Y)
-			if dist < minDist {
+			if dist := unit.Coord().Distance(enemy.Coord()); dist < minDist {
 				minDist = dist
-				nearest = &Point{enemy.X, enemy.Y}
+				at := enemy.Coord()
+				nearest = &at
 			}
 		}
 
 		// Check enemy cities
 		for _, city := range player.Cities {
-			dist := DistanceTo(unit.X, unit.Y, city.X, city.Y)
-			if dist < minDist {
+			if dist := unit.Coord().Distance(city.Coord()); dist < minDist {
 				minDist = dist
-				nearest = &Point{city.X, city.Y}
+				at := city.Coord()
+				nearest = &at
 			}
 		}
 	}
@@ -389,60 +569,23 @@ func (c *Controller) findNearestEnemy(unit *game.Unit) *Point {
 	return nearest
 }
 
-// isGoodCityLocation checks if a location is suitable for a city
-func (c *Controller) isGoodCityLocation(x, y int) bool {
-	tile := c.Game.Map.GetTile(x, y)
-	if tile == nil {
-		return false
-	}
-
-	// Must be suitable terrain
-	if tile.IsWater() || tile.Terrain == game.TerrainMountains || tile.Terrain == game.TerrainDesert {
-		return false
-	}
-
-	// Must not be too close to existing cities
-	for _, player := range c.Game.Players {
-		for _, city := range player.Cities {
-			if DistanceTo(x, y, city.X, city.Y) < 4 {
-				return false
-			}
-		}
-	}
-
-	// Check surrounding resources
-	neighbors := c.Game.Map.GetTilesInRadius(x, y, 2)
-	goodTiles := 0
-	for _, n := range neighbors {
-		if n.Terrain == game.TerrainGrassland || n.Terrain == game.TerrainPlains || n.Terrain == game.TerrainForest {
-			goodTiles++
+// firstSettler returns the first of the player's units that can found a
+// city, or nil
+func firstSettler(player *game.Player) *game.Unit {
+	for _, unit := range player.Units {
+		if unit.CanFoundCity() {
+			return unit
 		}
 	}
-
-	return goodTiles >= 5
+	return nil
 }
 
-// findGoodCityLocation finds a good location for a new city
-func (c *Controller) findGoodCityLocation(unit *game.Unit) *Point {
-	// Search in expanding circles
-	for radius := 1; radius <= 20; radius++ {
-		for dy := -radius; dy <= radius; dy++ {
-			for dx := -radius; dx <= radius; dx++ {
-				x := unit.X + dx
-				y := unit.Y + dy
-
-				if !c.Game.Map.IsValidCoord(x, y) {
-					continue
-				}
-
-				if c.isGoodCityLocation(x, y) {
-					return &Point{x, y}
-				}
-			}
-		}
+// dotMap returns the AI's plan of city sites, making it on first use
+func (c *Controller) dotMap() *dotMap {
+	if c.dots == nil {
+		c.dots = newDotMap(c.Game)
 	}
-
-	return nil
+	return c.dots
 }
 
 // shouldFortify checks if unit should fortify at current position
@@ -462,18 +605,6 @@ func (c *Controller) shouldFortify(unit *game.Unit) bool {
 	return false
 }
 
-// generateCityName generates a name for a new city
-func (c *Controller) generateCityName() string {
-	player := c.GetPlayer()
-	suffixes := []string{"polis", "burg", "ville", "ton", "grad", "heim"}
-
-	cityNum := len(player.Cities)
-	prefix := player.Name[:min(4, len(player.Name))]
-	suffix := suffixes[cityNum%len(suffixes)]
-
-	return prefix + suffix
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/mapgen"
+	"io"
+	"log"
+	"testing"
+)
+
+// newLateGame plays a 200x200 game with 8 AI players for 40 turns, which
+// leaves over a hundred cities and about a thousand units on the map
+func newLateGame(b *testing.B) *game.GameState {
+	b.Helper()
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	config.PlayerCount = 8
+	g := game.NewGame(config)
+	g.SetMap(mapgen.GenerateWithPlayers(mapgen.GeneratorConfig{
+		Width:         200,
+		Height:        200,
+		Seed:          config.Seed,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+	}, g.Players))
+	g.Start()
+
+	PlayTurns(g, 40)
+	return g
+}
+
+func BenchmarkTakeTurnLateGame(b *testing.B) {
+	g := newLateGame(b)
+	player := g.GetCurrentPlayer()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		// A fresh controller so cached paths and sites are not reused
+		NewController(g, player.ID).TakeTurn()
+	}
+}
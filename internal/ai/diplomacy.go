@@ -0,0 +1,23 @@
+package ai
+
+import "civilization/internal/game"
+
+// answerOffers answers the deals other players have offered. Maps are
+// always worth trading and luxuries always worth taking; vision is shared
+// with anyone but a player who has used nuclear weapons.
+func (c *Controller) answerOffers() []game.Action {
+	actions := make([]game.Action, 0)
+	for _, offer := range c.Game.Offers {
+		if offer.To != c.PlayerID {
+			continue
+		}
+
+		from := c.Game.GetPlayer(offer.From)
+		if offer.Deal == game.DealSharedVision && from != nil && from.NuclearStrikes > 0 {
+			actions = append(actions, &game.DeclineDealAction{DealOffer: offer})
+		} else {
+			actions = append(actions, &game.AcceptDealAction{DealOffer: offer})
+		}
+	}
+	return actions
+}
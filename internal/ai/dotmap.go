@@ -0,0 +1,250 @@
+package ai
+
+import (
+	"sort"
+
+	"civilization/internal/game"
+)
+
+// The AI plans its expansion once, on its first turn: it dots the map with
+// the sites it means to settle, best first and kept apart from each other
+// and from every city, and sends each settler to a dot of its own. The
+// plan lasts from turn to turn and is made again whenever a city is
+// founded near one of its dots, by the AI or anyone else.
+
+const (
+	citySpacing       = 3  // Distance kept between cities, more than rules.MinCityDistance
+	minGoodSiteTiles  = 5  // Good tiles needed in the city radius
+	maxSiteSearchDist = 20 // How far settlers look for a dot
+	resourceSiteValue = 2  // Worth of each resource in a site's radius over its yields
+	coastalSiteValue  = 4  // Worth of a site's access to the sea
+)
+
+// dotMap is the AI's plan of city sites and the settlers sent to them
+type dotMap struct {
+	dots     []game.Coord          // Planned sites, best first
+	assigned map[string]game.Coord // Settler ID -> the dot it is sent to
+}
+
+// isSiteTerrain reports whether the AI would place a city on the tile: one
+// the game lets a city stand on, other than desert
+func isSiteTerrain(tile *game.Tile) bool {
+	return tile.CanHoldCity() && tile.Terrain != game.TerrainDesert
+}
+
+// isProductiveTerrain reports whether a tile counts toward a site's quality
+func isProductiveTerrain(t game.TerrainType) bool {
+	return t == game.TerrainGrassland || t == game.TerrainPlains || t == game.TerrainForest
+}
+
+// newDotMap plans the city sites of the map as it stands
+func newDotMap(g *game.GameState) *dotMap {
+	m := &dotMap{assigned: make(map[string]game.Coord)}
+	m.plan(g)
+	return m
+}
+
+// plan dots the map: every tile with enough productive land around it is
+// valued by the yields and resources of its radius and its access to the
+// sea, and the best are taken in turn, each far enough from the cities and
+// the dots taken before it
+func (m *dotMap) plan(g *game.GameState) {
+	w, h := g.Map.Width, g.Map.Height
+
+	// Prefix sums of productive tiles and of tile values, so each radius
+	// is summed in O(1)
+	productive := make([]int, (w+1)*(h+1))
+	value := make([]int, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			tile := g.Map.GetTileUnsafe(x, y)
+			p, v := 0, tile.FoodYield()+tile.ProductionYield()+tile.TradeYield()
+			if isProductiveTerrain(tile.Terrain) {
+				p = 1
+			}
+			if tile.Resource != game.ResourceNone {
+				v += resourceSiteValue
+			}
+			i := (y+1)*(w+1) + x + 1
+			productive[i] = p + productive[i-(w+1)] + productive[i-1] - productive[i-(w+1)-1]
+			value[i] = v + value[i-(w+1)] + value[i-1] - value[i-(w+1)-1]
+		}
+	}
+	sum := func(sums []int, x, y int) int {
+		x0, y0 := max(x-game.CityRadius, 0), max(y-game.CityRadius, 0)
+		x1, y1 := min(x+game.CityRadius, w-1)+1, min(y+game.CityRadius, h-1)+1
+		return sums[y1*(w+1)+x1] - sums[y0*(w+1)+x1] - sums[y1*(w+1)+x0] + sums[y0*(w+1)+x0]
+	}
+
+	type site struct {
+		game.Coord
+		value int
+	}
+	sites := make([]site, 0)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			tile := g.Map.GetTileUnsafe(x, y)
+			if !isSiteTerrain(tile) {
+				continue
+			}
+			count := sum(productive, x, y)
+			if isProductiveTerrain(tile.Terrain) {
+				count-- // The city tile itself is not part of its radius
+			}
+			if count < minGoodSiteTiles {
+				continue
+			}
+			v := sum(value, x, y)
+			if isCoastalSite(g, x, y) {
+				v += coastalSiteValue
+			}
+			sites = append(sites, site{game.At(x, y), v})
+		}
+	}
+	sort.SliceStable(sites, func(i, j int) bool {
+		return sites[i].value > sites[j].value
+	})
+
+	taken := make([]game.Coord, 0)
+	for _, player := range g.Players {
+		for _, city := range player.Cities {
+			taken = append(taken, city.Coord())
+		}
+	}
+	m.dots = m.dots[:0]
+	for _, s := range sites {
+		if !tooClose(s.Coord, taken) {
+			taken = append(taken, s.Coord)
+			m.dots = append(m.dots, s.Coord)
+		}
+	}
+
+	// Settlers keep their dots where the new plan has them
+	for id, dot := range m.assigned {
+		if !m.isDot(dot.X, dot.Y) {
+			delete(m.assigned, id)
+		}
+	}
+}
+
+// isCoastalSite reports whether a city at (x, y) would reach the sea
+func isCoastalSite(g *game.GameState, x, y int) bool {
+	for _, at := range game.At(x, y).Neighbors() {
+		if tile := g.Map.TileAt(at); tile != nil && tile.IsWater() {
+			return true
+		}
+	}
+	return false
+}
+
+// tooClose reports whether a city at p would stand too near any of the
+// points
+func tooClose(p game.Coord, points []game.Coord) bool {
+	for _, q := range points {
+		if p.Distance(q) < citySpacing {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh brings the plan up to date at the start of a turn: settlers that
+// were lost give up their dots, and the map is dotted again if a city was
+// founded near a dot since
+func (m *dotMap) refresh(g *game.GameState, player *game.Player) {
+	for id := range m.assigned {
+		if unit := player.GetUnit(id); unit == nil || !unit.CanFoundCity() {
+			delete(m.assigned, id)
+		}
+	}
+
+	cities := make([]game.Coord, 0)
+	for _, p := range g.Players {
+		for _, city := range p.Cities {
+			cities = append(cities, city.Coord())
+		}
+	}
+	for _, dot := range m.dots {
+		if tooClose(dot, cities) {
+			m.plan(g)
+			return
+		}
+	}
+}
+
+// isDot reports whether (x, y) is one of the planned sites
+func (m *dotMap) isDot(x, y int) bool {
+	for _, dot := range m.dots {
+		if dot.X == x && dot.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+// isFree reports whether no settler has been sent to a dot
+func (m *dotMap) isFree(dot game.Coord) bool {
+	for _, d := range m.assigned {
+		if d == dot {
+			return false
+		}
+	}
+	return true
+}
+
+// nearestFree returns the closest dot no settler has been sent to, within
+// maxSiteSearchDist of (x, y); of dots as close, the better one
+func (m *dotMap) nearestFree(x, y int) *game.Coord {
+	var nearest *game.Coord
+	best := maxSiteSearchDist + 1
+	for i, dot := range m.dots {
+		if d := game.At(x, y).Distance(dot); d < best && m.isFree(dot) {
+			nearest, best = &m.dots[i], d
+		}
+	}
+	if nearest == nil {
+		return nil
+	}
+	p := *nearest
+	return &p
+}
+
+// freeNear counts the dots no settler has been sent to within
+// maxSiteSearchDist of (x, y)
+func (m *dotMap) freeNear(x, y int) int {
+	count := 0
+	for _, dot := range m.dots {
+		if game.At(x, y).Distance(dot) <= maxSiteSearchDist && m.isFree(dot) {
+			count++
+		}
+	}
+	return count
+}
+
+// assign sends a settler to its dot, choosing the nearest free one if it
+// has none yet. It returns nil when no dot is left for it.
+func (m *dotMap) assign(unit *game.Unit) *game.Coord {
+	if dot, ok := m.assigned[unit.ID]; ok {
+		return &dot
+	}
+	dot := m.nearestFree(unit.X, unit.Y)
+	if dot != nil {
+		m.assigned[unit.ID] = *dot
+	}
+	return dot
+}
+
+// release frees the dot a settler was sent to
+func (m *dotMap) release(unit *game.Unit) {
+	delete(m.assigned, unit.ID)
+}
+
+// drop takes a dot out of the plan until it is next made
+func (m *dotMap) drop(dot game.Coord) {
+	for i, d := range m.dots {
+		if d == dot {
+			m.dots = append(m.dots[:i], m.dots[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"strings"
+	"testing"
+)
+
+// renderDots draws the map one character per tile as gametest lays it out,
+// with the planned sites as * and cities as C
+func renderDots(g *game.GameState, m *dotMap) string {
+	chars := map[game.TerrainType]byte{
+		game.TerrainOcean: '~', game.TerrainGrassland: 'g', game.TerrainPlains: 'p', game.TerrainDesert: 'd',
+		game.TerrainHills: 'h', game.TerrainMountains: 'm', game.TerrainForest: 'f',
+	}
+	var sb strings.Builder
+	for y := 0; y < g.Map.Height; y++ {
+		for x := 0; x < g.Map.Width; x++ {
+			switch {
+			case g.GetCityAt(x, y) != nil:
+				sb.WriteByte('C')
+			case m.isDot(x, y):
+				sb.WriteByte('*')
+			default:
+				sb.WriteByte(chars[g.Map.GetTileUnsafe(x, y).Terrain])
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// TestDotMap plans the sites of a small island with one city on it and sends
+// settlers to them
+func TestDotMap(t *testing.T) {
+	b := gametest.New(t,
+		"~~~~~~~~~~~~~~",
+		"~ggggppgggfgg~",
+		"~gddggpgggfgg~",
+		"~gddggmmggggp~",
+		"~ggggggmggppp~",
+		"~ffgggggggppp~",
+		"~~~~~gggg~~~~~",
+		"~~~~~~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerAI)
+	b.City("alice", "Alpha", 2, 4, 1)
+	first := b.Unit("alice", game.UnitSettler, 7, 5)
+	second := b.Unit("alice", game.UnitSettler, 7, 5)
+	g := b.Start()
+
+	m := newDotMap(g)
+	want := strings.Join([]string{
+		"~~~~~~~~~~~~~~",
+		"~gg*gp*gggfgg~",
+		"~gddggpgggfgg~",
+		"~gddggmmgg*gp~",
+		"~gCggg*mggppp~",
+		"~ffgggggggppp~",
+		"~~~~~gggg~~~~~",
+		"~~~~~~~~~~~~~~",
+	}, "\n") + "\n"
+	if got := renderDots(g, m); got != want {
+		t.Errorf("planned sites\n%s\nwant\n%s", got, want)
+	}
+
+	// Every dot keeps its distance from the others and from Alpha
+	alpha := g.GetCityAt(2, 4).Coord()
+	for i, dot := range m.dots {
+		if d := dot.Distance(alpha); d < citySpacing {
+			t.Errorf("dot %v is %d from Alpha, want at least %d", dot, d, citySpacing)
+		}
+		for _, other := range m.dots[i+1:] {
+			if d := dot.Distance(other); d < citySpacing {
+				t.Errorf("dots %v and %v are %d apart, want at least %d", dot, other, d, citySpacing)
+			}
+		}
+	}
+
+	// Settlers are sent to the nearest dot not taken by another
+	if dot := m.assign(first); dot == nil || *dot != game.At(6, 4) {
+		t.Errorf("first settler sent to %v, want (6,4)", dot)
+	}
+	if dot := m.assign(second); dot == nil || *dot != game.At(10, 3) {
+		t.Errorf("second settler sent to %v, want (10,3)", dot)
+	}
+}
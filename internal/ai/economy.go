@@ -0,0 +1,42 @@
+package ai
+
+import "civilization/internal/game"
+
+// setTaxRate sets the lowest tax rate at which the treasury pays the
+// upkeep of the empire and of the buildings under way, leaving the rest of
+// the trade to science
+func (c *Controller) setTaxRate() []game.Action {
+	player := c.GetPlayer()
+	needed := c.pendingUpkeep()
+	rate := 100
+	for r := 0; r < 100; r += game.TaxRateStep {
+		if c.Game.Budget(player, r).Net >= needed {
+			rate = r
+			break
+		}
+	}
+
+	if rate == player.TaxRate {
+		return nil
+	}
+	return []game.Action{&game.SetTaxRateAction{PlayerID: player.ID, TaxRate: rate}}
+}
+
+// pendingUpkeep returns the upkeep of the buildings the AI's cities are
+// building
+func (c *Controller) pendingUpkeep() int {
+	upkeep := 0
+	for _, city := range c.GetPlayer().Cities {
+		if build := city.CurrentBuild; build != nil && !build.IsUnit {
+			upkeep += game.BuildingUpkeep[build.Building]
+		}
+	}
+	return upkeep
+}
+
+// affords reports whether the treasury could pay the upkeep of another
+// building at the highest tax rate
+func (c *Controller) affords(building game.BuildingType) bool {
+	budget := c.Game.Budget(c.GetPlayer(), 100)
+	return budget.Net >= c.pendingUpkeep()+game.BuildingUpkeep[building]
+}
@@ -0,0 +1,234 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"math/rand/v2"
+	"time"
+)
+
+// Lookahead settings
+const (
+	DefaultLookaheadBudget = 500 * time.Millisecond // Thinking time per turn
+	lookaheadRollouts      = 6                      // Simulated futures per option
+	lookaheadPlies         = 3                      // Turns of other players in a future
+	minAttackOdds          = 0.25                   // Attacks less likely to win are not considered
+	combatSamples          = 200                    // Combats simulated to judge an attack
+)
+
+// Position values used to compare simulated futures
+const (
+	lostGameValue = 10000
+	cityValue     = 20
+	citizenValue  = 10
+	yieldValue    = 0.5 // Per point of food, shields and trade around a city
+	settlerValue  = 40  // A city yet to be founded
+	strengthValue = 2   // Per point of attack and defense at full health
+	goldValue     = 0.1
+)
+
+// Lookahead weighs a unit's options by playing each of them out on copies
+// of the game: the option is taken, the player's turn ends and the next
+// players reply with rule-based controllers. The option that leaves the
+// player best off on average is chosen. Each copy draws its own random
+// stream, so combats are sampled rather than foreseen.
+type Lookahead struct {
+	Budget   time.Duration // Thinking time per turn
+	Rollouts int           // Simulated futures per option
+	Plies    int           // Turns of other players played in a future
+
+	deadline time.Time
+	rng      *rand.Rand
+}
+
+// NewLookahead creates a lookahead that thinks for at most budget a turn
+func NewLookahead(budget time.Duration) *Lookahead {
+	return &Lookahead{
+		Budget:   budget,
+		Rollouts: lookaheadRollouts,
+		Plies:    lookaheadPlies,
+	}
+}
+
+// startTurn sets the deadline for the turn's thinking
+func (l *Lookahead) startTurn(g *game.GameState, now time.Time) {
+	l.deadline = now.Add(l.Budget)
+	l.rng = rand.New(rand.NewPCG(uint64(g.Seed), g.Seq))
+}
+
+// choose returns the unit's best option. The rule-based proposal is kept
+// when there is nothing else to consider or no time left to weigh it.
+func (l *Lookahead) choose(c *Controller, unit *game.Unit, proposal []game.Action) []game.Action {
+	options := l.options(c, unit, proposal)
+	if len(options) < 2 {
+		return proposal
+	}
+
+	totals := make([]float64, len(options))
+	played := make([]int, len(options))
+	for r := 0; r < l.Rollouts && time.Now().Before(l.deadline); r++ {
+		for i, option := range options {
+			if value, ok := l.rollout(c.Game, c.PlayerID, option); ok {
+				totals[i] += value
+				played[i]++
+			}
+		}
+	}
+
+	best := 0
+	bestValue := 0.0
+	for i := range options {
+		if played[i] == 0 {
+			continue
+		}
+		value := totals[i] / float64(played[i])
+		if played[best] == 0 || value > bestValue {
+			best, bestValue = i, value
+		}
+	}
+	return options[best]
+}
+
+// options lists what the unit could do this turn: the proposal first, then
+// settling where it stands, attacking a neighbor it has a fair chance
+// against and fortifying
+func (l *Lookahead) options(c *Controller, unit *game.Unit, proposal []game.Action) [][]game.Action {
+	options := [][]game.Action{proposal}
+	seen := map[string]bool{optionKey(proposal): true}
+	add := func(action game.Action) {
+		if action.Validate(c.Game, c.PlayerID) != nil {
+			return
+		}
+		option := []game.Action{action}
+		if key := optionKey(option); !seen[key] {
+			seen[key] = true
+			options = append(options, option)
+		}
+	}
+
+	if unit.CanFoundCity() {
+		add(&game.FoundCityAction{SettlerID: unit.ID})
+		return options
+	}
+
+	threatened := false
+	for _, at := range unit.Coord().Neighbors() {
+		city := c.Game.GetCityAt(at.X, at.Y)
+		enemyCity := city != nil && city.OwnerID != c.PlayerID
+		if len(c.Game.GetEnemyUnitsAt(at.X, at.Y, c.PlayerID)) == 0 && !enemyCity {
+			continue
+		}
+		threatened = true
+		if l.attackOdds(c.Game, unit, at.X, at.Y) >= minAttackOdds {
+			add(&game.AttackAction{AttackerID: unit.ID, TargetX: at.X, TargetY: at.Y})
+		}
+	}
+	if threatened {
+		add(&game.FortifyAction{UnitID: unit.ID})
+	}
+	return options
+}
+
+// attackOdds returns the chance the unit wins an attack on (x, y), 0 if it
+// cannot attack there. The battles are fought by copies of the units, as
+// the game is only read while a turn is planned.
+func (l *Lookahead) attackOdds(g *game.GameState, unit *game.Unit, x, y int) float64 {
+	odds, err := g.PreviewAttack(unit.OwnerID, unit.ID, x, y)
+	if err != nil {
+		return 0
+	}
+	if odds.Defender == nil {
+		return odds.WinChance
+	}
+
+	tile := g.Map.GetTile(x, y)
+	city := g.GetCityAt(x, y)
+	hasWalls := city != nil && city.WallsStanding()
+	return game.SimulateCombat(l.rng, *unit, *odds.Defender, tile, city != nil, odds.Defender.IsFortified, hasWalls, g.Config.WithdrawPercent(), combatSamples)
+}
+
+// rollout plays an option out on a copy of the game and values the
+// result for the player. It reports false if the option cannot be taken.
+func (l *Lookahead) rollout(g *game.GameState, playerID string, option []game.Action) (float64, bool) {
+	sim := g.Clone()
+	sim.Seed = l.rng.Int64()
+
+	for _, action := range option {
+		if _, err := sim.Apply(playerID, action); err != nil {
+			return 0, false
+		}
+	}
+
+	// The other players reply until the player is to move again
+	if _, err := sim.Apply(playerID, &game.EndTurnAction{}); err == nil {
+		controllers := make(map[string]*Controller, len(sim.Players))
+		for _, p := range sim.Players {
+			controllers[p.ID] = newRuleController(sim, p.ID)
+		}
+		for ply := 0; ply < l.Plies; ply++ {
+			if sim.Phase != game.PhaseAITurn && sim.Phase != game.PhasePlayerTurn {
+				break
+			}
+			if next := sim.GetCurrentPlayer(); next == nil || next.ID == playerID || !playTurn(sim, controllers) {
+				break
+			}
+		}
+	}
+
+	return evaluate(sim, playerID), true
+}
+
+// optionKey identifies an option so the same one is not weighed twice
+func optionKey(option []game.Action) string {
+	key := ""
+	for _, action := range option {
+		data, _ := json.Marshal(action)
+		key += action.Type() + string(data) + ";"
+	}
+	return key
+}
+
+// evaluate values a position for a player: the worth of their empire less
+// the average worth of their living rivals
+func evaluate(g *game.GameState, playerID string) float64 {
+	player := g.GetPlayer(playerID)
+	if player == nil || !player.IsAlive || (g.Winner != nil && g.Winner.ID != playerID) {
+		return -lostGameValue
+	}
+	if g.Winner != nil {
+		return lostGameValue
+	}
+
+	rivals, total := 0, 0.0
+	for _, p := range g.Players {
+		if p.ID != playerID && p.IsAlive {
+			total += worth(g, p)
+			rivals++
+		}
+	}
+	value := worth(g, player)
+	if rivals > 0 {
+		value -= total / float64(rivals)
+	}
+	return value
+}
+
+// worth values a player's cities, units and gold
+func worth(g *game.GameState, p *game.Player) float64 {
+	value := float64(p.Gold) * goldValue
+	for _, city := range p.Cities {
+		value += cityValue + float64(city.Population)*citizenValue
+		for _, tile := range g.GetCityTiles(city) {
+			value += float64(tile.FoodYield()+tile.ProductionYield()+tile.TradeYield()) * yieldValue
+		}
+	}
+	for _, unit := range p.Units {
+		if unit.CanFoundCity() {
+			value += settlerValue
+			continue
+		}
+		t := unit.Template()
+		value += float64(t.Attack+t.Defense) * float64(unit.Health) / game.BaseHealthPoints * strengthValue
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"testing"
+	"time"
+)
+
+// newSiegeGame lays out a game in which alice's horseman stands next to
+// Beta, a city of bob's with its defenses almost broken
+func newSiegeGame(tb testing.TB) (*game.GameState, *game.Unit, *game.City) {
+	b := gametest.New(tb, gametest.Island...)
+	b.Player("alice", game.PlayerAI)
+	b.Player("bob", game.PlayerAI)
+	horseman := b.Unit("alice", game.UnitHorseman, 5, 2)
+	b.Unit("bob", game.UnitWarrior, 8, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Gamma", 8, 4, 1)
+	beta := b.City("bob", "Beta", 6, 2, 2)
+	beta.Damage = beta.MaxDefense() - game.DamagePerRound
+	return b.Start(), horseman, beta
+}
+
+// TestLookaheadChoice offers the horseman fortifying or taking Beta, in
+// either order, and checks that the lookahead takes the city
+func TestLookaheadChoice(t *testing.T) {
+	g, horseman, beta := newSiegeGame(t)
+	c := newRuleController(g, "alice")
+	attack := &game.AttackAction{AttackerID: horseman.ID, TargetX: beta.X, TargetY: beta.Y}
+	fortify := &game.FortifyAction{UnitID: horseman.ID}
+
+	for _, proposal := range []game.Action{fortify, attack} {
+		l := NewLookahead(time.Minute)
+		l.startTurn(g, time.Now())
+		if options := l.options(c, horseman, []game.Action{proposal}); len(options) != 2 {
+			t.Fatalf("proposing %s the horseman has %d options, want attacking and fortifying", proposal.Type(), len(options))
+		}
+		chosen := l.choose(c, horseman, []game.Action{proposal})
+		if len(chosen) != 1 || optionKey(chosen) != optionKey([]game.Action{attack}) {
+			t.Errorf("proposing %s the lookahead chose %s, want the attack on Beta", proposal.Type(), optionKey(chosen))
+		}
+	}
+	if beta.OwnerID != "bob" || g.GetUnit(horseman.ID).X != 5 {
+		t.Error("weighing the options changed the game")
+	}
+}
+
+func BenchmarkLookaheadChoose(b *testing.B) {
+	g, horseman, _ := newSiegeGame(b)
+	c := newRuleController(g, "alice")
+	proposal := []game.Action{&game.FortifyAction{UnitID: horseman.ID}}
+	l := NewLookahead(time.Hour)
+	l.startTurn(g, time.Now())
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		l.choose(c, horseman, proposal)
+	}
+}
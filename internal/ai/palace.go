@@ -0,0 +1,36 @@
+package ai
+
+import "civilization/internal/game"
+
+// An AI whose capital has been captured rebuilds its Palace in its largest
+// city, since until it does every city of its loses the most to corruption
+// and waste.
+
+// palaceCity returns the city of the AI's that is rebuilding its Palace,
+// or the one that should, or nil while the AI has a capital. Cities
+// building a wonder are left to finish it.
+func (c *Controller) palaceCity() *game.City {
+	player := c.GetPlayer()
+	if player.Capital() != nil {
+		return nil
+	}
+	var largest *game.City
+	for _, city := range player.Cities {
+		if buildsPalace(city) {
+			return city
+		}
+		if city == c.wonderCity() {
+			continue
+		}
+		if largest == nil || city.Population > largest.Population {
+			largest = city
+		}
+	}
+	return largest
+}
+
+// buildsPalace reports whether a city is building a Palace
+func buildsPalace(city *game.City) bool {
+	build := city.CurrentBuild
+	return build != nil && !build.IsUnit && build.Building == game.BuildingPalace
+}
@@ -2,21 +2,20 @@ package ai
 
 import (
 	"civilization/internal/game"
+	"civilization/internal/game/rules"
 	"container/heap"
+	"sync"
 )
 
-// Point represents a coordinate on the map
-type Point struct {
-	X, Y int
-}
-
 // pathNode represents a node in the A* search
 type pathNode struct {
-	Point
-	G      int // Cost from start
-	H      int // Heuristic to goal
-	Parent *pathNode
-	Index  int // Index in priority queue
+	game.Coord
+	G         int // Movement points spent from start, including points lost at turn ends
+	H         int // Heuristic to goal
+	MovesLeft int // Movement points left in the current turn on arrival
+	Parent    *pathNode
+	Index     int    // Index in priority queue, -1 once searched from
+	gen       uint32 // Search generation the node belongs to
 }
 
 // F returns the total estimated cost
@@ -56,63 +55,149 @@ func (pq *priorityQueue) Pop() interface{} {
 	return node
 }
 
-// FindPath finds the shortest path between two points using A*
-func FindPath(g *game.GameState, unit *game.Unit, startX, startY, goalX, goalY int) []Point {
+// pathSearch holds the reusable state of an A* search. Nodes are stored per
+// tile and invalidated by bumping the generation counter, so a search does
+// not have to allocate or clear anything proportional to the map size.
+type pathSearch struct {
+	width   int
+	height  int
+	gen     uint32
+	nodes   []pathNode
+	blocked []uint32 // Generation stamp of tiles occupied by enemies
+	open    priorityQueue
+}
+
+// searchPool recycles search state between FindPath calls
+var searchPool = sync.Pool{
+	New: func() interface{} { return &pathSearch{} },
+}
+
+// acquireSearch returns search state sized for the given map
+func acquireSearch(width, height int) *pathSearch {
+	s := searchPool.Get().(*pathSearch)
+	if s.width != width || s.height != height {
+		s.width = width
+		s.height = height
+		s.gen = 0
+		s.nodes = make([]pathNode, width*height)
+		s.blocked = make([]uint32, width*height)
+	}
+
+	s.gen++
+	if s.gen == 0 {
+		// Generation counter wrapped, stale stamps could match again
+		for i := range s.nodes {
+			s.nodes[i].gen = 0
+			s.blocked[i] = 0
+		}
+		s.gen = 1
+	}
+	s.open = s.open[:0]
+	return s
+}
+
+// node returns the node for a tile, resetting it if it is from an older search
+func (s *pathSearch) node(x, y int) (*pathNode, bool) {
+	n := &s.nodes[y*s.width+x]
+	if n.gen != s.gen {
+		*n = pathNode{Coord: game.At(x, y), gen: s.gen, Index: -1}
+		return n, false
+	}
+	return n, true
+}
+
+// markEnemies stamps every tile holding an enemy unit or city as blocked
+func (s *pathSearch) markEnemies(g *game.GameState, ownerID string) {
+	for _, p := range g.Players {
+		if p.ID == ownerID {
+			continue
+		}
+		for _, u := range p.Units {
+			s.blocked[u.Y*s.width+u.X] = s.gen
+		}
+		for _, c := range p.Cities {
+			s.blocked[c.Y*s.width+c.X] = s.gen
+		}
+	}
+}
+
+// FindPath finds the cheapest path between two points using A*.
+// Costs are counted in movement points and follow the game's movement rules:
+// a unit may always enter a tile if it has any movement left, so points
+// that cannot be used before the turn ends are charged as well. A tile
+// reached again as cheaply with more movement left is searched again from
+// there. Tiles held by other players are avoided unless they are the goal.
+func FindPath(g *game.GameState, unit *game.Unit, startX, startY, goalX, goalY int) []game.Coord {
 	if startX == goalX && startY == goalY {
-		return []Point{{startX, startY}}
+		return []game.Coord{game.At(startX, startY)}
+	}
+	if !g.Map.IsValidCoord(startX, startY) || !g.Map.IsValidCoord(goalX, goalY) {
+		return nil
 	}
 
-	openSet := &priorityQueue{}
-	heap.Init(openSet)
+	s := acquireSearch(g.Map.Width, g.Map.Height)
+	defer searchPool.Put(s)
 
-	closedSet := make(map[Point]bool)
-	nodeMap := make(map[Point]*pathNode)
+	s.markEnemies(g, unit.OwnerID)
 
-	start := &pathNode{
-		Point: Point{startX, startY},
-		G:     0,
-		H:     heuristic(startX, startY, goalX, goalY),
+	maxMoves := unit.Template().Movement
+	startMoves := unit.MovementLeft
+	if startX != unit.X || startY != unit.Y {
+		startMoves = maxMoves
 	}
-	heap.Push(openSet, start)
-	nodeMap[start.Point] = start
 
-	for openSet.Len() > 0 {
-		current := heap.Pop(openSet).(*pathNode)
+	// The distance in moves estimates the cost: no tile costs less than 1,
+	// so it never overestimates
+	start, _ := s.node(startX, startY)
+	start.H = rules.Distance(startX, startY, goalX, goalY)
+	start.MovesLeft = startMoves
+	heap.Push(&s.open, start)
+
+	for s.open.Len() > 0 {
+		current := heap.Pop(&s.open).(*pathNode)
 
 		if current.X == goalX && current.Y == goalY {
 			return reconstructPath(current)
 		}
 
-		closedSet[current.Point] = true
-
 		// Check all neighbors
-		for _, neighbor := range getNeighbors(g, current.Point, unit) {
-			if closedSet[neighbor] {
+		for _, d := range game.Directions {
+			next := current.Step(d)
+			nx, ny := next.X, next.Y
+			if !canEnter(g, unit, nx, ny) {
 				continue
 			}
+			if s.blocked[ny*s.width+nx] == s.gen && (nx != goalX || ny != goalY) {
+				continue
+			}
+
+			neighbor, seen := s.node(nx, ny)
+
+			// Start a new turn if no movement is left
+			moves := current.MovesLeft
+			if moves <= 0 {
+				moves = maxMoves
+			}
 
-			tile := g.Map.GetTile(neighbor.X, neighbor.Y)
-			if tile == nil {
+			cost := g.GetMovementCost(current.X, current.Y, nx, ny)
+			spent := cost
+			if spent > moves {
+				spent = moves
+			}
+			tentativeG := current.G + spent
+			left := moves - spent
+			if seen && (tentativeG > neighbor.G || (tentativeG == neighbor.G && left <= neighbor.MovesLeft)) {
 				continue
 			}
 
-			moveCost := tile.MovementCost()
-			tentativeG := current.G + moveCost
-
-			existingNode, exists := nodeMap[neighbor]
-			if !exists {
-				newNode := &pathNode{
-					Point:  neighbor,
-					G:      tentativeG,
-					H:      heuristic(neighbor.X, neighbor.Y, goalX, goalY),
-					Parent: current,
-				}
-				heap.Push(openSet, newNode)
-				nodeMap[neighbor] = newNode
-			} else if tentativeG < existingNode.G {
-				existingNode.G = tentativeG
-				existingNode.Parent = current
-				heap.Fix(openSet, existingNode.Index)
+			neighbor.G = tentativeG
+			neighbor.H = rules.Distance(nx, ny, goalX, goalY)
+			neighbor.MovesLeft = left
+			neighbor.Parent = current
+			if neighbor.Index >= 0 {
+				heap.Fix(&s.open, neighbor.Index)
+			} else {
+				heap.Push(&s.open, neighbor)
 			}
 		}
 	}
@@ -120,91 +205,141 @@ func FindPath(g *game.GameState, unit *game.Unit, startX, startY, goalX, goalY i
 	return nil // No path found
 }
 
-// heuristic calculates the estimated cost between two points
-func heuristic(x1, y1, x2, y2 int) int {
-	// Manhattan distance
-	dx := x2 - x1
-	dy := y2 - y1
-	if dx < 0 {
-		dx = -dx
-	}
-	if dy < 0 {
-		dy = -dy
+// canEnter checks whether the unit's terrain rules allow entering a tile
+func canEnter(g *game.GameState, unit *game.Unit, x, y int) bool {
+	tile := g.Map.GetTile(x, y)
+	if tile == nil {
+		return false
 	}
-	return dx + dy
+
+	// Check terrain passability
+	return tile.CanEnter(unit.Template().IsNaval)
 }
 
-// getNeighbors returns valid neighboring points for movement
-func getNeighbors(g *game.GameState, p Point, unit *game.Unit) []Point {
-	neighbors := make([]Point, 0, 8)
-	directions := [][2]int{
-		{-1, -1}, {0, -1}, {1, -1},
-		{-1, 0}, {1, 0},
-		{-1, 1}, {0, 1}, {1, 1},
+// reconstructPath builds the path from goal to start
+func reconstructPath(node *pathNode) []game.Coord {
+	length := 0
+	for current := node; current != nil; current = current.Parent {
+		length++
 	}
 
-	template := unit.Template()
+	path := make([]game.Coord, length)
+	for current := node; current != nil; current = current.Parent {
+		length--
+		path[length] = current.Coord
+	}
 
-	for _, d := range directions {
-		nx, ny := p.X+d[0], p.Y+d[1]
+	return path
+}
 
-		if !g.Map.IsValidCoord(nx, ny) {
-			continue
-		}
+// GetNextMove returns the next position to move toward a goal
+func GetNextMove(g *game.GameState, unit *game.Unit, goalX, goalY int) *game.Coord {
+	path := FindPath(g, unit, unit.X, unit.Y, goalX, goalY)
+	if path == nil || len(path) < 2 {
+		return nil
+	}
 
-		tile := g.Map.GetTile(nx, ny)
-		if tile == nil {
-			continue
-		}
+	// Return the next step (index 1, since index 0 is current position)
+	return &path[1]
+}
 
-		// Check terrain passability
-		if !template.IsNaval && tile.IsWater() {
-			continue
-		}
-		if template.IsNaval && !tile.IsWater() {
-			continue
-		}
+// cachedPath is a previously computed route for a unit
+type cachedPath struct {
+	Goal  game.Coord
+	Steps []game.Coord
+}
 
-		neighbors = append(neighbors, Point{nx, ny})
-	}
+// PathCache keeps each unit's route between turns so it is only recomputed
+// when the goal changes or the next step becomes blocked
+type PathCache struct {
+	paths  map[string]*cachedPath
+	failed map[string]bool // Units no route was found for since takeFailed
+}
 
-	return neighbors
+// NewPathCache creates an empty path cache
+func NewPathCache() *PathCache {
+	return &PathCache{
+		paths:  make(map[string]*cachedPath),
+		failed: make(map[string]bool),
+	}
 }
 
-// reconstructPath builds the path from goal to start
-func reconstructPath(node *pathNode) []Point {
-	path := make([]Point, 0)
-	current := node
+// NextMove returns the next step for the unit toward the goal, reusing the
+// cached route while it is still valid
+func (pc *PathCache) NextMove(g *game.GameState, unit *game.Unit, goalX, goalY int) *game.Coord {
+	goal := game.At(goalX, goalY)
 
-	for current != nil {
-		path = append([]Point{current.Point}, path...)
-		current = current.Parent
+	if cached, ok := pc.paths[unit.ID]; ok && cached.Goal == goal {
+		for i := 0; i < len(cached.Steps)-1; i++ {
+			if cached.Steps[i].X != unit.X || cached.Steps[i].Y != unit.Y {
+				continue
+			}
+			next := cached.Steps[i+1]
+			if pc.isOpen(g, unit, next, goal) {
+				cached.Steps = cached.Steps[i:]
+				return &next
+			}
+			break
+		}
 	}
 
-	return path
-}
-
-// GetNextMove returns the next position to move toward a goal
-func GetNextMove(g *game.GameState, unit *game.Unit, goalX, goalY int) *Point {
 	path := FindPath(g, unit, unit.X, unit.Y, goalX, goalY)
+	if path == nil {
+		pc.failed[unit.ID] = true
+	}
 	if path == nil || len(path) < 2 {
+		delete(pc.paths, unit.ID)
 		return nil
 	}
 
-	// Return the next step (index 1, since index 0 is current position)
+	pc.paths[unit.ID] = &cachedPath{Goal: goal, Steps: path}
 	return &path[1]
 }
 
-// DistanceTo calculates the Manhattan distance between two points
-func DistanceTo(x1, y1, x2, y2 int) int {
-	return heuristic(x1, y1, x2, y2)
+// isOpen checks that a cached step can still be taken
+func (pc *PathCache) isOpen(g *game.GameState, unit *game.Unit, p game.Coord, goal game.Coord) bool {
+	if !g.IsValidMove(unit, p.X, p.Y) {
+		return false
+	}
+	if p == goal {
+		return true
+	}
+	if len(g.GetEnemyUnitsAt(p.X, p.Y, unit.OwnerID)) > 0 {
+		return false
+	}
+	if city := g.GetCityAt(p.X, p.Y); city != nil && city.OwnerID != unit.OwnerID {
+		return false
+	}
+	return true
+}
+
+// takeFailed returns the units no route was found for since it was last
+// called
+func (pc *PathCache) takeFailed() map[string]bool {
+	failed := pc.failed
+	pc.failed = make(map[string]bool)
+	return failed
+}
+
+// forget drops the unit's cached route
+func (pc *PathCache) forget(unitID string) {
+	delete(pc.paths, unitID)
+}
+
+// Prune drops cached routes of units that no longer exist
+func (pc *PathCache) Prune(g *game.GameState) {
+	for unitID := range pc.paths {
+		if g.GetUnit(unitID) == nil {
+			delete(pc.paths, unitID)
+		}
+	}
 }
 
 // FindNearestTile finds the nearest tile matching a condition
-func FindNearestTile(g *game.GameState, startX, startY int, maxRange int, condition func(*game.Tile) bool) *Point {
+func FindNearestTile(g *game.GameState, startX, startY int, maxRange int, condition func(*game.Tile) bool) *game.Coord {
 	// BFS search
-	visited := make(map[Point]bool)
-	queue := []Point{{startX, startY}}
+	visited := make(map[game.Coord]bool)
+	queue := []game.Coord{game.At(startX, startY)}
 	visited[queue[0]] = true
 
 	for len(queue) > 0 {
@@ -212,7 +347,7 @@ func FindNearestTile(g *game.GameState, startX, startY int, maxRange int, condit
 		queue = queue[1:]
 
 		// Check distance limit
-		if DistanceTo(startX, startY, current.X, current.Y) > maxRange {
+		if current.Distance(game.At(startX, startY)) > maxRange {
 			continue
 		}
 
@@ -222,16 +357,10 @@ func FindNearestTile(g *game.GameState, startX, startY int, maxRange int, condit
 		}
 
 		// Add neighbors
-		for dx := -1; dx <= 1; dx++ {
-			for dy := -1; dy <= 1; dy++ {
-				if dx == 0 && dy == 0 {
-					continue
-				}
-				next := Point{current.X + dx, current.Y + dy}
-				if !visited[next] && g.Map.IsValidCoord(next.X, next.Y) {
-					visited[next] = true
-					queue = append(queue, next)
-				}
+		for _, next := range current.Neighbors() {
+			if !visited[next] && g.Map.Contains(next) {
+				visited[next] = true
+				queue = append(queue, next)
 			}
 		}
 	}
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/mapgen"
+	"io"
+	"log"
+	"testing"
+)
+
+// newBenchmarkGame generates a 200x200 game and returns it together with a
+// settler and the farthest land tile it can reach
+func newBenchmarkGame(b *testing.B) (*game.GameState, *game.Unit, game.Coord) {
+	b.Helper()
+	// Map generation logs heavily, keep benchmark output readable
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	g := game.NewGame(config)
+	gm := mapgen.GenerateWithPlayers(mapgen.GeneratorConfig{
+		Width:         200,
+		Height:        200,
+		Seed:          config.Seed,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+	}, g.Players)
+	g.SetMap(gm)
+	g.Start()
+
+	var settler *game.Unit
+	for _, u := range g.Players[0].Units {
+		if u.CanFoundCity() {
+			settler = u
+		}
+	}
+	if settler == nil {
+		b.Fatal("no settler placed")
+	}
+
+	// Breadth-first search for the farthest reachable tile
+	start := settler.Coord()
+	visited := map[game.Coord]bool{start: true}
+	queue := []game.Coord{start}
+	goal := start
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		goal = current
+		for _, next := range current.Neighbors() {
+			if !visited[next] && canEnter(g, settler, next.X, next.Y) {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return g, settler, goal
+}
+
+// TestFindPathTurnEnds crosses a wall of mountains with a warrior, which
+// enters any tile with its one movement point and so loses what a mountain
+// would cost beyond it: straight across is cheaper than around
+func TestFindPathTurnEnds(t *testing.T) {
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	g := game.NewGame(config)
+	gm := game.NewGameMap(5, 7)
+	for y := 1; y < 6; y++ {
+		for x := 1; x < 4; x++ {
+			gm.GetTile(x, y).Terrain = game.TerrainMountains
+		}
+	}
+	g.SetMap(gm)
+	warrior := game.NewUnit(game.UnitWarrior, g.Players[0].ID, 0, 3)
+	g.Players[0].AddUnit(warrior)
+
+	// Any path of four moves crosses the mountains, going around takes six
+	if path := FindPath(g, warrior, 0, 3, 4, 3); len(path) != 5 {
+		t.Errorf("path %v, want four moves across the mountains", path)
+	}
+}
+
+func BenchmarkFindPath(b *testing.B) {
+	g, unit, goal := newBenchmarkGame(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if FindPath(g, unit, unit.X, unit.Y, goal.X, goal.Y) == nil {
+			b.Fatal("no path found")
+		}
+	}
+}
+
+func BenchmarkPathCacheNextMove(b *testing.B) {
+	g, unit, goal := newBenchmarkGame(b)
+	cache := NewPathCache()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if cache.NextMove(g, unit, goal.X, goal.Y) == nil {
+			b.Fatal("no path found")
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"civilization/internal/game"
+)
+
+// The AI decides what a city builds by scoring everything the city can
+// build and taking the best. Each item is wanted more or less under each
+// strategy, and more or less the larger the city, the more enemies could
+// strike it and the more of its kind the AI already has. The weights are
+// data: a JSON file in the shape of ProductionWeights can replace any of
+// them, to tune the AI without changing its code.
+
+// Unit roles, which units are weighted by
+const (
+	RoleSettler  = "settler"
+	RoleDefender = "defender"
+	RoleAttacker = "attacker"
+	RoleSiege    = "siege"
+	RoleNaval    = "naval"
+	RoleNuclear  = "nuclear"
+	RoleCaravan  = "caravan"
+)
+
+// ItemWeights are how much the AI wants an item and what makes it want it
+// more. Negative weights make it want the item less.
+type ItemWeights struct {
+	Expansion  float64 `json:"expansion"` // Base wish under each strategy
+	Buildup    float64 `json:"buildup"`
+	Aggression float64 `json:"aggression"`
+	Size       float64 `json:"size,omitempty"`     // Added per citizen of the city
+	Threat     float64 `json:"threat,omitempty"`   // Added per point of enemy attack within reach of the city
+	Owned      float64 `json:"owned,omitempty"`    // Added per unit of the type the AI has for each city
+	Strength   float64 `json:"strength,omitempty"` // Added per point of the attack or defense the unit is built for
+	Mobility   float64 `json:"mobility,omitempty"` // Added per movement point over one
+	MinSize    int     `json:"min_size,omitempty"` // Smallest city that builds the item
+}
+
+// ProductionWeights are the weights of everything a city can build. Items
+// without weights are never built by choice.
+type ProductionWeights struct {
+	Units     map[string]ItemWeights `json:"units"`      // By role
+	Buildings map[string]ItemWeights `json:"buildings"`  // By building name
+	BuildTurn float64                `json:"build_turn"` // Added per turn the city needs to build the item
+}
+
+// DefaultProductionWeights returns the weights the AI plays with unless
+// it is given others
+func DefaultProductionWeights() *ProductionWeights {
+	return &ProductionWeights{
+		Units: map[string]ItemWeights{
+			RoleSettler:  {Expansion: 14, Buildup: 10, Aggression: 6, Size: 1, Owned: -8, MinSize: 2},
+			RoleDefender: {Expansion: 5, Buildup: 5, Aggression: 2, Threat: 0.2, Owned: -1.5, Strength: 1},
+			RoleAttacker: {Expansion: 1, Buildup: 2, Aggression: 7, Size: 0.2, Owned: -1.5, Strength: 1, Mobility: 0.5},
+			RoleSiege:    {Aggression: 4, Size: 0.2, Owned: -2, Strength: 0.5, MinSize: 4},
+			RoleNuclear:  {Aggression: 30, MinSize: 6},
+			RoleCaravan:  {Buildup: 20},
+		},
+		Buildings: map[string]ItemWeights{
+			game.BuildingBarracks.String():         {Buildup: 7, Aggression: 3},
+			game.BuildingWalls.String():            {Buildup: 4, Aggression: 2, Threat: 0.3, MinSize: 3},
+			game.BuildingHarbor.String():           {Buildup: 3, Size: 0.5},
+			game.BuildingGranary.String():          {Buildup: 1, Size: 0.4, MinSize: 3},
+			game.BuildingMarketplace.String():      {Buildup: 1, Size: 0.5, MinSize: 4},
+			game.BuildingLibrary.String():          {Buildup: 1, Size: 0.5, MinSize: 4},
+			game.BuildingManhattanProject.String(): {Buildup: 30},
+		},
+		BuildTurn: -0.2,
+	}
+}
+
+// LoadProductionWeights reads weights from a JSON file. Weights the file
+// leaves out keep their defaults.
+func LoadProductionWeights(path string) (*ProductionWeights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	weights := DefaultProductionWeights()
+	if err := json.Unmarshal(data, weights); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return weights, nil
+}
+
+// unitRole returns the role a unit type is weighted by, or "" for units
+// the AI never builds by choice
+func unitRole(t game.UnitTemplate) string {
+	switch {
+	case t.IsKing:
+		return ""
+	case t.CanFoundCity:
+		return RoleSettler
+	case t.HelpsWonder:
+		return RoleCaravan
+	case t.IsNuclear:
+		return RoleNuclear
+	case t.IsNaval:
+		return RoleNaval
+	case t.IsSiege:
+		return RoleSiege
+	case t.Attack == 0:
+		return ""
+	case t.Attack > t.Defense:
+		return RoleAttacker
+	default:
+		return RoleDefender
+	}
+}
+
+// weights returns the production weights the AI plays with
+func (c *Controller) weights() *ProductionWeights {
+	if c.Weights == nil {
+		c.Weights = DefaultProductionWeights()
+	}
+	return c.Weights
+}
+
+// scoreItem returns how much the city should build an item under the
+// weights, ignoring whether it can
+func (c *Controller) scoreItem(city *game.City, w ItemWeights, cost int) float64 {
+	score := w.Expansion
+	switch c.Strategy {
+	case StrategyBuildup:
+		score = w.Buildup
+	case StrategyAggression:
+		score = w.Aggression
+	}
+	score += w.Size * float64(city.Population)
+	score += w.Threat * float64(c.threatMap().at(city.X, city.Y))
+
+	shields := max(city.CalculateProductionPerTurn(c.Game.GetCityTiles(city)), 1)
+	score += c.weights().BuildTurn * float64((cost+shields-1)/shields)
+	return score
+}
+
+// bestProduction returns the item the city wants most, or false if it
+// wants nothing it can build
+func (c *Controller) bestProduction(city *game.City) (game.BuildItem, bool) {
+	player := c.GetPlayer()
+	weights := c.weights()
+	speed := c.Game.Config.Speed
+
+	owned := make(map[game.UnitType]int)
+	for _, unit := range player.Units {
+		owned[unit.Type]++
+	}
+	cities := max(len(player.Cities), 1)
+
+	var best game.BuildItem
+	bestScore, found := 0.0, false
+	consider := func(item game.BuildItem, score float64) {
+		action := &game.SetProductionAction{CityID: city.ID, BuildItem: item}
+		if (!found || score > bestScore) && action.Validate(c.Game, c.PlayerID) == nil {
+			best, bestScore, found = item, score, true
+		}
+	}
+
+	for _, t := range game.UnitTypes() {
+		template := game.UnitTemplates[t]
+		role := unitRole(template)
+		w, ok := weights.Units[role]
+		if !ok || city.Population < w.MinSize || !c.wantsRole(city, role) {
+			continue
+		}
+		if template.IsNaval && !c.Game.IsCoastal(city) {
+			continue
+		}
+		score := c.scoreItem(city, w, game.ScaleCost(template.Cost, speed))
+		score += w.Owned * float64(owned[t]) / float64(cities)
+		strength := template.Defense
+		if role != RoleDefender {
+			strength = template.Attack
+		}
+		score += w.Strength * float64(strength)
+		score += w.Mobility * float64(template.Movement-1)
+		consider(game.BuildItem{IsUnit: true, UnitType: t}, score)
+	}
+
+	for _, b := range game.BuildingTypes() {
+		w, ok := weights.Buildings[b.String()]
+		if !ok || city.HasBuilding(b) || city.Population < w.MinSize {
+			continue
+		}
+		if b.IsWonder() && c.wonderToStart(city) != b || !b.IsWonder() && !c.affords(b) {
+			continue
+		}
+		consider(game.BuildItem{Building: b}, c.scoreItem(city, w, game.ScaleCost(game.BuildingCosts[b], speed)))
+	}
+	return best, found
+}
+
+// unsentSettlers counts the AI's settlers not yet sent to a dot
+func (c *Controller) unsentSettlers() int {
+	count := 0
+	for _, unit := range c.GetPlayer().Units {
+		if _, sent := c.dotMap().assigned[unit.ID]; unit.CanFoundCity() && !sent {
+			count++
+		}
+	}
+	return count
+}
+
+// wantsRole reports whether the AI has a use for another unit of the role
+// from the city at all: settlers need a free dot to go to that no settler
+// yet to be sent will take, caravans a
+// wonder to help, and nuclear weapons an aggressor to answer
+func (c *Controller) wantsRole(city *game.City, role string) bool {
+	switch role {
+	case RoleSettler:
+		return c.dotMap().freeNear(city.X, city.Y) > c.unsentSettlers()
+	case RoleCaravan:
+		return c.needsCaravans(city)
+	case RoleNuclear:
+		return len(c.Game.NuclearAggressors(c.PlayerID)) > 0
+	}
+	return true
+}
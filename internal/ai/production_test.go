@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"testing"
+)
+
+// TestProduction checks what a city of three builds under each strategy,
+// and that changing the weights changes the choice
+func TestProduction(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		change   func(w *ProductionWeights)
+		want     string
+	}{
+		{"expansion", StrategyExpansion, nil, "Settler"},
+		{"buildup", StrategyBuildup, nil, "Settler"},
+		{"aggression", StrategyAggression, nil, "Horseman"},
+		{"buildup without settlers", StrategyBuildup, func(w *ProductionWeights) {
+			delete(w.Units, RoleSettler)
+		}, "Phalanx"},
+		{"expansion wanting walls", StrategyExpansion, func(w *ProductionWeights) {
+			w.Buildings[game.BuildingWalls.String()] = ItemWeights{Expansion: 50}
+		}, "Walls"},
+		{"aggression wanting defenders", StrategyAggression, func(w *ProductionWeights) {
+			defender := w.Units[RoleDefender]
+			defender.Aggression = 20
+			w.Units[RoleDefender] = defender
+		}, "Phalanx"},
+	}
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			b := gametest.New(t, gametest.Island...)
+			b.Player("alice", game.PlayerAI)
+			b.Player("bob", game.PlayerAI)
+			b.Unit("alice", game.UnitWarrior, 1, 2)
+			alpha := b.City("alice", "Alpha", 1, 2, 3)
+			b.City("bob", "Beta", 8, 3, 1)
+			g := b.Start()
+
+			ctrl := newRuleController(g, "alice")
+			ctrl.Strategy = c.strategy
+			ctrl.Weights = DefaultProductionWeights()
+			if c.change != nil {
+				c.change(ctrl.Weights)
+			}
+			item, ok := ctrl.bestProduction(alpha)
+			if !ok || item.Name() != c.want {
+				t.Errorf("Alpha builds %q (found %v), want %q", item.Name(), ok, c.want)
+			}
+		})
+	}
+}
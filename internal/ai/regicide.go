@@ -0,0 +1,70 @@
+package ai
+
+import "civilization/internal/game"
+
+// In a regicide game the AI keeps its King fortified in its capital and
+// holds that city with more defenders than any other: it builds phalanxes
+// until kingGuards defend it, and units sent to defend cities go there
+// first.
+
+// kingGuards is how many military units the AI keeps with its King
+const kingGuards = 3
+
+// kingCity returns the city the AI's King shelters in, or nil without a
+// King or a city
+func (c *Controller) kingCity() *game.City {
+	player := c.GetPlayer()
+	if player.King() == nil || len(player.Cities) == 0 {
+		return nil
+	}
+	if capital := player.Capital(); capital != nil {
+		return capital
+	}
+	return player.Cities[0]
+}
+
+// guardsNeeded returns how many military units the AI wants in a city
+func (c *Controller) guardsNeeded(city *game.City) int {
+	if city == c.kingCity() {
+		return kingGuards
+	}
+	return 1
+}
+
+// militaryDefenders counts the player's units in a city that fight for it
+func militaryDefenders(player *game.Player, city *game.City) int {
+	defenders := 0
+	for _, d := range player.GetUnitsAt(city.X, city.Y) {
+		if !d.CanFoundCity() && !d.HelpsWonder() && !d.IsKing() {
+			defenders++
+		}
+	}
+	return defenders
+}
+
+// guardsKing reports whether a unit is one of the guards the King's city
+// needs
+func (c *Controller) guardsKing(unit *game.Unit) bool {
+	city := c.kingCity()
+	return city != nil && unit.X == city.X && unit.Y == city.Y &&
+		militaryDefenders(c.GetPlayer(), city) <= kingGuards
+}
+
+// handleKing takes the King to its city and fortifies it there. Without a
+// city it waits where it is.
+func (c *Controller) handleKing(unit *game.Unit) []game.Action {
+	city := c.kingCity()
+	var action game.Action = &game.SkipUnitAction{UnitID: unit.ID}
+	switch {
+	case city != nil && unit.X == city.X && unit.Y == city.Y:
+		action = &game.FortifyAction{UnitID: unit.ID}
+	case city != nil:
+		if next := c.paths.NextMove(c.Game, unit, city.X, city.Y); next != nil {
+			action = &game.MoveUnitAction{UnitID: unit.ID, ToX: next.X, ToY: next.Y}
+		}
+	}
+	if err := action.Validate(c.Game, c.PlayerID); err != nil {
+		return nil
+	}
+	return []game.Action{action}
+}
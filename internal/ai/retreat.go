@@ -0,0 +1,133 @@
+package ai
+
+import (
+	"civilization/internal/game"
+)
+
+// Wounded AI units withdraw to the nearest city of theirs to heal instead
+// of fighting on, and keep off the tiles enemies can strike on the way.
+// They still attack when they are all but sure to win. A threat map, built
+// once a turn, holds for every tile the attack strength of the enemy units
+// that could reach and strike it on their next turn.
+
+const (
+	retreatHealth        = 50   // Percent of full health below which units withdraw
+	minWoundedAttackOdds = 0.75 // Wounded units attack only when this likely to win
+)
+
+// threatMap holds the enemy attack strength that can reach each tile
+type threatMap struct {
+	width  int
+	height int
+	threat []int
+}
+
+// newThreatMap adds up the attack of every enemy unit over the tiles it
+// could strike next turn: those within its movement
+func newThreatMap(g *game.GameState, playerID string) *threatMap {
+	m := &threatMap{
+		width:  g.Map.Width,
+		height: g.Map.Height,
+		threat: make([]int, g.Map.Width*g.Map.Height),
+	}
+	for _, player := range g.Players {
+		if player.ID == playerID || !player.IsAlive {
+			continue
+		}
+		for _, enemy := range player.Units {
+			attack := enemy.EffectiveAttack()
+			if attack == 0 || enemy.IsNuclear() {
+				continue
+			}
+			reach := enemy.Template().Movement
+			for y := max(enemy.Y-reach, 0); y <= min(enemy.Y+reach, m.height-1); y++ {
+				for x := max(enemy.X-reach, 0); x <= min(enemy.X+reach, m.width-1); x++ {
+					m.threat[y*m.width+x] += attack
+				}
+			}
+		}
+	}
+	return m
+}
+
+// at returns the enemy attack strength that can reach a tile
+func (m *threatMap) at(x, y int) int {
+	if x < 0 || x >= m.width || y < 0 || y >= m.height {
+		return 0
+	}
+	return m.threat[y*m.width+x]
+}
+
+// threatMap returns this turn's threat map, building it on first use
+func (c *Controller) threatMap() *threatMap {
+	if c.threats == nil {
+		c.threats = newThreatMap(c.Game, c.PlayerID)
+	}
+	return c.threats
+}
+
+// shouldRetreat reports whether a unit is too badly wounded to fight on
+func shouldRetreat(unit *game.Unit) bool {
+	return unit.Health*100 < game.BaseHealthPoints*retreatHealth
+}
+
+// retreat finishes off a neighbor the wounded unit is all but sure to
+// beat, or else withdraws it toward the nearest city of the AI's, by the
+// least threatened step that brings it no farther away. In a city it
+// fortifies to heal.
+func (c *Controller) retreat(unit *game.Unit) []game.Action {
+	here := unit.Coord()
+	for _, at := range here.Neighbors() {
+		odds, err := c.Game.PreviewAttack(c.PlayerID, unit.ID, at.X, at.Y)
+		if err != nil || odds.WinChance < minWoundedAttackOdds {
+			continue
+		}
+		action := &game.AttackAction{AttackerID: unit.ID, TargetX: at.X, TargetY: at.Y}
+		if action.Validate(c.Game, c.PlayerID) == nil {
+			return []game.Action{action}
+		}
+	}
+
+	var refuge *game.City
+	for _, city := range c.GetPlayer().Cities {
+		if refuge == nil || here.Distance(city.Coord()) < here.Distance(refuge.Coord()) {
+			refuge = city
+		}
+	}
+	if refuge == nil || here == refuge.Coord() {
+		action := &game.FortifyAction{UnitID: unit.ID}
+		if action.Validate(c.Game, c.PlayerID) != nil {
+			return nil
+		}
+		return []game.Action{action}
+	}
+
+	// The step the path takes comes first, so it wins ties
+	candidates := make([]game.Coord, 0, 9)
+	if next := c.paths.NextMove(c.Game, unit, refuge.X, refuge.Y); next != nil {
+		candidates = append(candidates, *next)
+	}
+	distance := here.Distance(refuge.Coord())
+	for _, at := range here.Neighbors() {
+		if at.Distance(refuge.Coord()) <= distance {
+			candidates = append(candidates, at)
+		}
+	}
+
+	threats := c.threatMap()
+	var best *game.MoveUnitAction
+	bestThreat := 0
+	for _, p := range candidates {
+		action := &game.MoveUnitAction{UnitID: unit.ID, ToX: p.X, ToY: p.Y}
+		if action.Validate(c.Game, c.PlayerID) != nil {
+			continue
+		}
+		if threat := threats.at(p.X, p.Y); best == nil || threat < bestThreat {
+			best, bestThreat = action, threat
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return []game.Action{best}
+}
@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"testing"
+)
+
+// TestRetreat puts a badly wounded warrior next to a horseman it cannot
+// beat, and checks that it steps back toward its city rather than fight,
+// then fortifies once there
+func TestRetreat(t *testing.T) {
+	b := gametest.New(t, gametest.Island...)
+	b.Player("alice", game.PlayerAI)
+	b.Player("bob", game.PlayerAI)
+	warrior := b.Unit("alice", game.UnitWarrior, 4, 2)
+	warrior.Health = 20
+	b.Unit("bob", game.UnitHorseman, 5, 2)
+	alpha := b.City("alice", "Alpha", 1, 2, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+	c := newRuleController(g, "alice")
+
+	if !shouldRetreat(warrior) {
+		t.Fatalf("a warrior with %d health fights on", warrior.Health)
+	}
+	actions := c.retreat(warrior)
+	if len(actions) != 1 {
+		t.Fatalf("retreat planned %d actions, want a single move", len(actions))
+	}
+	move, ok := actions[0].(*game.MoveUnitAction)
+	if !ok {
+		t.Fatalf("retreat planned %s, want a move", actions[0].Type())
+	}
+	to := game.Coord{X: move.ToX, Y: move.ToY}
+	if to.Distance(alpha.Coord()) >= warrior.Coord().Distance(alpha.Coord()) {
+		t.Errorf("the warrior withdraws to %v, no nearer Alpha at %v", to, alpha.Coord())
+	}
+
+	// Back in its city the warrior fortifies to heal
+	warrior.X, warrior.Y = alpha.X, alpha.Y
+	actions = c.retreat(warrior)
+	if len(actions) != 1 || actions[0].Type() != "fortify" {
+		t.Errorf("retreat planned %v in Alpha, want to fortify", actions)
+	}
+}
@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"civilization/internal/game"
+)
+
+// AI units make use of the lie of the land. They pick the attack they are
+// likeliest to win, and of those the one onto the weakest ground, and
+// leave enemies dug in on hills, forests and mountains alone unless the
+// odds are good. Defenders not needed in a city hold the strongest ground
+// along the AI's borders.
+
+const (
+	riverLineValue      = 0.25 // Worth of a river tile to a defender over its terrain
+	minStrongGroundOdds = 0.5  // Odds needed to attack a unit on defensive terrain
+	maxPostDistance     = 6    // How far a spare defender looks for a post
+	postDistanceCost    = 0.05 // Worth lost per tile to walk to a post
+)
+
+// positionValue scores a tile as ground to hold: its defense bonus, with
+// rivers as lines worth holding
+func positionValue(tile *game.Tile) float64 {
+	value := tile.DefenseBonus()
+	if tile.HasRiver {
+		value += riverLineValue
+	}
+	return value
+}
+
+// bestAttack returns the attack on a neighboring tile the unit is
+// likeliest to win, and of those as likely the one onto the weakest
+// ground, or nil. Units outside cities on defensive terrain are attacked
+// only with minStrongGroundOdds.
+func (c *Controller) bestAttack(unit *game.Unit) *game.AttackAction {
+	var best *game.AttackAction
+	bestOdds, bestGround := 0.0, 0.0
+	for _, at := range unit.Coord().Neighbors() {
+		odds, err := c.Game.PreviewAttack(c.PlayerID, unit.ID, at.X, at.Y)
+		if err != nil {
+			continue
+		}
+		ground := c.Game.Map.TileAt(at).DefenseBonus()
+		if ground > 1 && odds.WinChance < minStrongGroundOdds && c.Game.GetCityAt(at.X, at.Y) == nil {
+			continue
+		}
+		if best != nil && (odds.WinChance < bestOdds || odds.WinChance == bestOdds && ground >= bestGround) {
+			continue
+		}
+		action := &game.AttackAction{AttackerID: unit.ID, TargetX: at.X, TargetY: at.Y}
+		if action.Validate(c.Game, c.PlayerID) == nil {
+			best, bestOdds, bestGround = action, odds.WinChance, ground
+		}
+	}
+	return best
+}
+
+// isBorder reports whether (x, y) is the AI's territory next to land that
+// is not
+func (c *Controller) isBorder(x, y int) bool {
+	if c.Game.TerritoryOwner(x, y) != c.PlayerID {
+		return false
+	}
+	for _, at := range game.At(x, y).Neighbors() {
+		tile := c.Game.Map.TileAt(at)
+		if tile != nil && !tile.IsWater() && tile.Owner != c.PlayerID {
+			return true
+		}
+	}
+	return false
+}
+
+// holdGround sends a defender no city needs to the strongest free ground
+// on the AI's borders near it and fortifies it there. It returns nil when
+// no post is better than open ground.
+func (c *Controller) holdGround(unit *game.Unit) []game.Action {
+	here := unit.Coord()
+	var post *game.Coord
+	bestValue := 1.0 // Open ground is no post
+	if tile := c.Game.Map.TileAt(here); tile != nil && c.isBorder(here.X, here.Y) {
+		post, bestValue = &here, positionValue(tile)
+	}
+	for at := range here.Within(maxPostDistance) {
+		tile := c.Game.Map.TileAt(at)
+		if tile == nil || !tile.IsPassable() || !c.isBorder(at.X, at.Y) || c.Game.GetCityAt(at.X, at.Y) != nil {
+			continue
+		}
+		if len(c.Game.GetUnitsAt(at.X, at.Y)) > 0 {
+			continue
+		}
+		value := positionValue(tile) - postDistanceCost*float64(here.Distance(at))
+		if value > bestValue {
+			post, bestValue = &at, value
+		}
+	}
+	if post == nil {
+		return nil
+	}
+
+	var action game.Action = &game.FortifyAction{UnitID: unit.ID}
+	if *post != here {
+		next := c.paths.NextMove(c.Game, unit, post.X, post.Y)
+		if next == nil {
+			return nil
+		}
+		action = &game.MoveUnitAction{UnitID: unit.ID, ToX: next.X, ToY: next.Y}
+	}
+	if action.Validate(c.Game, c.PlayerID) != nil {
+		return nil
+	}
+	return []game.Action{action}
+}
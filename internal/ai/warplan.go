@@ -0,0 +1,250 @@
+package ai
+
+import (
+	"civilization/internal/game"
+)
+
+// An aggressive AI wages one war at a time. It picks the rival that is
+// weakest and closest, the city of theirs nearest its own capital to take,
+// and a staging area short of that city where its forces gather. Rivals
+// and cities its units can walk to come before those across the sea. Once
+// enough have gathered, or they have waited long enough, they march on the
+// city together, fighting what stands in the way. When the city falls the
+// next one is chosen, and a new war is planned when the rival is gone.
+
+const (
+	warForce          = 4 // Units to gather before the attack
+	maxStagingTurns   = 8 // Turns to wait for them before attacking anyway
+	stagingDistance   = 3 // How far short of the target city forces gather
+	stagingRadius     = 2 // How close to the staging area a unit counts as gathered
+	warStrengthWeight = 2 // Distance worth one point of a rival's military strength
+)
+
+// warPlan is the war the AI wages
+type warPlan struct {
+	enemyID  string     // Player the war is against
+	cityID   string     // City to take
+	staging  game.Coord // Where forces gather before the attack
+	since    int        // Turn the forces began gathering
+	launched bool       // Whether the forces march on the city
+}
+
+// homeCity returns the city the AI measures its wars from, or nil
+func (c *Controller) homeCity() *game.City {
+	player := c.GetPlayer()
+	if capital := player.Capital(); capital != nil {
+		return capital
+	}
+	if len(player.Cities) > 0 {
+		return player.Cities[0]
+	}
+	return nil
+}
+
+// militaryStrength adds up the attack and defense of a player's fighting
+// units
+func militaryStrength(player *game.Player) int {
+	strength := 0
+	for _, unit := range player.Units {
+		if !unit.CanFoundCity() && !unit.HelpsWonder() && !unit.IsKing() && !unit.IsNuclear() {
+			template := unit.Template()
+			strength += template.Attack + template.Defense
+		}
+	}
+	return strength
+}
+
+// landReach returns the positions land units can walk to from a position
+func (c *Controller) landReach(from game.Coord) map[game.Coord]bool {
+	reach := map[game.Coord]bool{from: true}
+	queue := []game.Coord{from}
+	for len(queue) > 0 {
+		at := queue[0]
+		queue = queue[1:]
+		for _, next := range at.Neighbors() {
+			tile := c.Game.Map.TileAt(next)
+			if reach[next] || tile == nil || !tile.IsPassable() {
+				continue
+			}
+			reach[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return reach
+}
+
+// reachesCity reports whether any of a player's cities lies in reach
+func reachesCity(player *game.Player, reach map[game.Coord]bool) bool {
+	for _, city := range player.Cities {
+		if reach[city.Coord()] {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseEnemy returns the rival to make war on: the one whose military
+// strength and the distance to whose capital add up to the least, among
+// those with a city in reach if there are any
+func (c *Controller) chooseEnemy(home *game.City, reach map[game.Coord]bool) *game.Player {
+	var enemy *game.Player
+	best, bestReached := 0, false
+	for _, player := range c.Game.Players {
+		if player.ID == c.PlayerID || !player.IsAlive || len(player.Cities) == 0 {
+			continue
+		}
+		capital := player.Capital()
+		if capital == nil {
+			capital = player.Cities[0]
+		}
+		score := militaryStrength(player)*warStrengthWeight + home.Coord().Distance(capital.Coord())
+		reached := reachesCity(player, reach)
+		if enemy == nil || reached && !bestReached || reached == bestReached && score < best {
+			enemy, best, bestReached = player, score, reached
+		}
+	}
+	return enemy
+}
+
+// chooseTarget returns the enemy city to take: the one nearest the AI's
+// home city, among those in reach if there are any
+func chooseTarget(enemy *game.Player, home *game.City, reach map[game.Coord]bool) *game.City {
+	var target *game.City
+	for _, city := range enemy.Cities {
+		if target == nil || reach[city.Coord()] && !reach[target.Coord()] ||
+			reach[city.Coord()] == reach[target.Coord()] && home.Coord().Distance(city.Coord()) < home.Coord().Distance(target.Coord()) {
+			target = city
+		}
+	}
+	return target
+}
+
+// updateWarPlan keeps the war plan up to date at the start of a turn. The
+// plan is dropped once the AI is no longer aggressive.
+func (c *Controller) updateWarPlan() {
+	home := c.homeCity()
+	if c.Strategy != StrategyAggression || home == nil {
+		c.war = nil
+		return
+	}
+
+	if c.war != nil {
+		enemy := c.Game.GetPlayer(c.war.enemyID)
+		if enemy == nil || !enemy.IsAlive || len(enemy.Cities) == 0 {
+			c.war = nil
+		}
+	}
+	var reach map[game.Coord]bool // Found only when there is a choice to make
+	if c.war == nil {
+		reach = c.landReach(home.Coord())
+		enemy := c.chooseEnemy(home, reach)
+		if enemy == nil {
+			return
+		}
+		c.war = &warPlan{enemyID: enemy.ID}
+	}
+
+	// A new target once the last one has fallen
+	if city := c.Game.GetCity(c.war.cityID); city == nil || city.OwnerID != c.war.enemyID {
+		if reach == nil {
+			reach = c.landReach(home.Coord())
+		}
+		target := chooseTarget(c.Game.GetPlayer(c.war.enemyID), home, reach)
+		c.war.cityID = target.ID
+		c.war.staging = c.stagingArea(target, home)
+		c.war.since = c.Game.CurrentTurn
+		c.war.launched = false
+	}
+
+	if !c.war.launched {
+		c.war.launched = c.gathered() >= warForce || c.Game.CurrentTurn-c.war.since >= maxStagingTurns
+	}
+}
+
+// stagingArea returns the land tile stagingDistance from the target city
+// that lies nearest the AI's home city, or the target city itself when
+// there is none
+func (c *Controller) stagingArea(target, home *game.City) game.Coord {
+	best := target.Coord()
+	bestDist := -1
+	for at := range target.Coord().Within(stagingDistance) {
+		if at.Distance(target.Coord()) != stagingDistance {
+			continue
+		}
+		tile := c.Game.Map.TileAt(at)
+		if tile == nil || !tile.IsPassable() || c.Game.GetCityAt(at.X, at.Y) != nil {
+			continue
+		}
+		if d := at.Distance(home.Coord()); bestDist < 0 || d < bestDist {
+			best, bestDist = at, d
+		}
+	}
+	return best
+}
+
+// gathered counts the AI's fighting units at the staging area
+func (c *Controller) gathered() int {
+	count := 0
+	for _, unit := range c.GetPlayer().Units {
+		if unit.EffectiveAttack() > 0 && !unit.IsNuclear() &&
+			unit.Coord().Distance(c.war.staging) <= stagingRadius {
+			count++
+		}
+	}
+	return count
+}
+
+// struckDown reports whether the first strike of the defender of (x, y)
+// would destroy the unit before a battle began
+func (c *Controller) struckDown(unit *game.Unit, x, y int) bool {
+	odds, err := c.Game.PreviewAttack(c.PlayerID, unit.ID, x, y)
+	return err == nil && odds.FirstStrike >= unit.Health
+}
+
+// wageWar has a unit play its part in the war plan: gather at the staging
+// area, then march on the target city, attacking enemies next to it on the
+// way. Units that cannot reach the war fight the nearest enemy instead.
+func (c *Controller) wageWar(unit *game.Unit) []game.Action {
+	if c.war == nil || c.Game.GetCity(c.war.cityID) == nil {
+		return c.attackEnemy(unit)
+	}
+	city := c.Game.GetCity(c.war.cityID)
+	goal := c.war.staging
+	if c.war.launched {
+		goal = city.Coord()
+	}
+
+	// Siege units bombard the city once in range
+	if c.war.launched && unit.IsSiegeUnit() {
+		action := &game.BombardAction{UnitID: unit.ID, TargetX: city.X, TargetY: city.Y}
+		if action.Validate(c.Game, c.PlayerID) == nil {
+			return []game.Action{action}
+		}
+	}
+
+	// Strike the city first, unless its defender's first strike would
+	// destroy the unit before the battle, then the best target next to it
+	if c.war.launched && !c.struckDown(unit, city.X, city.Y) {
+		action := &game.AttackAction{AttackerID: unit.ID, TargetX: city.X, TargetY: city.Y}
+		if action.Validate(c.Game, c.PlayerID) == nil {
+			return []game.Action{action}
+		}
+	}
+	if action := c.bestAttack(unit); action != nil {
+		return []game.Action{action}
+	}
+
+	if !c.war.launched && unit.Coord().Distance(goal) <= stagingRadius {
+		// Wait for the others
+		return nil
+	}
+	next := c.paths.NextMove(c.Game, unit, goal.X, goal.Y)
+	if next == nil {
+		return c.attackEnemy(unit)
+	}
+	action := &game.MoveUnitAction{UnitID: unit.ID, ToX: next.X, ToY: next.Y}
+	if action.Validate(c.Game, c.PlayerID) != nil {
+		return nil
+	}
+	return []game.Action{action}
+}
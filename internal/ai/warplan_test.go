@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"testing"
+)
+
+// TestWarPlan has alice choose between bob, who is weakest and nearest but
+// across the sea, and carol, whose cities she can walk to. She goes for
+// the nearer of carol's cities, and gathers her forces short of it before
+// marching on it.
+func TestWarPlan(t *testing.T) {
+	b := gametest.New(t,
+		"~~~~~~~~~~~~~~~~~~~~",
+		"~gggggggggggggg~ggg~",
+		"~gggggggggggggg~ggg~",
+		"~gggggggggggggg~ggg~",
+		"~gggggggggggggg~ggg~",
+		"~~~~~~~~~~~~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerAI)
+	b.Player("bob", game.PlayerAI)
+	b.Player("carol", game.PlayerAI)
+	b.City("alice", "Alpha", 12, 2, 3)
+	b.City("bob", "Beta", 17, 2, 1)
+	b.City("carol", "Gamma", 2, 2, 1)
+	b.City("carol", "Delta", 6, 4, 1)
+	b.Unit("carol", game.UnitWarrior, 2, 2)
+	var force []*game.Unit
+	for _, at := range []game.Coord{{X: 9, Y: 1}, {X: 9, Y: 2}, {X: 10, Y: 1}, {X: 12, Y: 2}} {
+		force = append(force, b.Unit("alice", game.UnitHorseman, at.X, at.Y))
+	}
+	g := b.Start()
+	c := newRuleController(g, "alice")
+	c.Strategy = StrategyAggression
+
+	c.updateWarPlan()
+	if c.war == nil || c.war.enemyID != "carol" || c.war.cityID != "Delta" {
+		t.Fatalf("war plan %+v, want a war on carol's Delta", c.war)
+	}
+	if want := game.At(9, 1); c.war.staging != want {
+		t.Errorf("forces gather at %v, want %v", c.war.staging, want)
+	}
+
+	// Three horsemen have gathered and wait for the fourth
+	if c.war.launched {
+		t.Fatalf("the attack is launched with %d of %d units gathered", c.gathered(), warForce)
+	}
+	if actions := c.wageWar(force[0]); len(actions) != 0 {
+		t.Errorf("a gathered horseman plans %v, want it to wait", actions)
+	}
+	if actions := c.wageWar(force[3]); len(actions) != 1 || actions[0].Type() != "move" {
+		t.Errorf("the last horseman plans %v, want it to move to the others", actions)
+	}
+
+	// Once all four have gathered they march on Delta
+	force[3].X, force[3].Y = 10, 2
+	c.updateWarPlan()
+	if !c.war.launched {
+		t.Fatalf("the attack waits with %d of %d units gathered", c.gathered(), warForce)
+	}
+	actions := c.wageWar(force[0])
+	if len(actions) != 1 {
+		t.Fatalf("a gathered horseman plans %v, want a move on Delta", actions)
+	}
+	move, ok := actions[0].(*game.MoveUnitAction)
+	delta := game.At(6, 4)
+	if !ok || game.At(move.ToX, move.ToY).Distance(delta) >= force[0].Coord().Distance(delta) {
+		t.Errorf("a gathered horseman plans %v, want a move nearer Delta", actions[0])
+	}
+}
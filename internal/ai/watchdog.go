@@ -0,0 +1,205 @@
+package ai
+
+import (
+	"log"
+
+	"civilization/internal/game"
+)
+
+// The watchdog keeps AI games from stalling. At the start of each turn it
+// looks for units moving back and forth between two tiles, units standing
+// still because no route can be found for them, and settlers that go on
+// too long without a city being founded. It logs what it finds and has the
+// AI fall back: stuck units in the AI's cities fortify there, others rest
+// a few turns and then try fresh routes, or fortify where they are once
+// they get stuck again, and settlers found cities wherever they can.
+
+const (
+	oscillationTurns    = 4  // Turns of positions that show a unit moving back and forth
+	maxPathFailures     = 3  // Turns a unit may stand still with no route before it is stuck
+	maxTurnsWithoutCity = 20 // Turns settlers may wait without a city being founded
+	stuckRestTurns      = 3  // Turns a stuck unit rests before trying again
+	maxTimesStuck       = 2  // Times a unit may get stuck before it fortifies for good
+)
+
+// Stalls counts the stalls the watchdog caught over a game
+type Stalls struct {
+	NoCities     int `json:"no_cities"`     // Times settlers waited maxTurnsWithoutCity for a city
+	Oscillations int `json:"oscillations"`  // Units caught moving back and forth
+	PathFailures int `json:"path_failures"` // Units caught standing still with no route
+}
+
+// Total returns the number of stalls caught
+func (s Stalls) Total() int {
+	return s.NoCities + s.Oscillations + s.PathFailures
+}
+
+// watchdog is what the AI remembers from turn to turn to catch stalls
+type watchdog struct {
+	turn           int                     // Turn last watched
+	positions      map[string][]game.Coord // Unit ID -> where it started its last turns, oldest first
+	failures       map[string]int          // Unit ID -> turns in a row it stood still with no route
+	resting        map[string]int          // Unit ID -> turn its rest ends
+	stuck          map[string]int          // Unit ID -> times it got stuck
+	cities         int                     // Cities the AI had when last watched
+	since          int                     // Turn settlers began waiting for a city
+	settleAnywhere bool                    // Whether settlers found cities where they stand
+}
+
+// oscillates reports whether the positions go back and forth between two
+// tiles
+func oscillates(positions []game.Coord) bool {
+	if len(positions) < oscillationTurns {
+		return false
+	}
+	for i := 2; i < len(positions); i++ {
+		if positions[i] != positions[i-2] {
+			return false
+		}
+	}
+	return positions[0] != positions[1]
+}
+
+// watch looks for stalls at the start of a turn and sets the AI's
+// fallbacks for those it finds
+func (c *Controller) watch() {
+	player := c.GetPlayer()
+	turn := c.Game.CurrentTurn
+	w := c.watchdog
+	if w == nil {
+		w = &watchdog{
+			turn:      -1,
+			positions: make(map[string][]game.Coord),
+			failures:  make(map[string]int),
+			resting:   make(map[string]int),
+			stuck:     make(map[string]int),
+			cities:    len(player.Cities),
+			since:     turn,
+		}
+		c.watchdog = w
+	}
+	if w.turn == turn {
+		return
+	}
+	w.turn = turn
+	failed := c.paths.takeFailed()
+
+	hasSettlers := false
+	alive := make(map[string]bool, len(player.Units))
+	for _, unit := range player.Units {
+		alive[unit.ID] = true
+		if unit.CanFoundCity() {
+			hasSettlers = true
+		}
+		if w.resting[unit.ID] <= turn {
+			delete(w.resting, unit.ID)
+		}
+		if !unit.NeedsOrders() || c.isResting(unit) {
+			delete(w.positions, unit.ID)
+			delete(w.failures, unit.ID)
+			continue
+		}
+
+		here := unit.Coord()
+		positions := append(w.positions[unit.ID], here)
+		if len(positions) > oscillationTurns {
+			positions = positions[len(positions)-oscillationTurns:]
+		}
+		w.positions[unit.ID] = positions
+		if failed[unit.ID] && len(positions) > 1 && positions[len(positions)-2] == here {
+			w.failures[unit.ID]++
+		} else {
+			delete(w.failures, unit.ID)
+		}
+
+		name := unit.Template().Name
+		switch {
+		case oscillates(positions):
+			c.Stalls.Oscillations++
+			log.Printf("AI %s: %s %s moves back and forth between (%d,%d) and (%d,%d)",
+				player.Name, name, unit.ID, positions[0].X, positions[0].Y, positions[1].X, positions[1].Y)
+			c.unstick(unit)
+		case w.failures[unit.ID] >= maxPathFailures:
+			c.Stalls.PathFailures++
+			log.Printf("AI %s: no route found for %s %s at (%d,%d) for %d turns",
+				player.Name, name, unit.ID, unit.X, unit.Y, w.failures[unit.ID])
+			c.unstick(unit)
+		}
+	}
+	for _, units := range []map[string]int{w.failures, w.resting, w.stuck} {
+		for id := range units {
+			if !alive[id] {
+				delete(units, id)
+			}
+		}
+	}
+	for id := range w.positions {
+		if !alive[id] {
+			delete(w.positions, id)
+		}
+	}
+
+	switch {
+	case len(player.Cities) > w.cities || !hasSettlers:
+		w.since = turn
+		w.settleAnywhere = false
+	case turn-w.since >= maxTurnsWithoutCity:
+		c.Stalls.NoCities++
+		log.Printf("AI %s: no city founded for %d turns with settlers waiting; settling where they stand",
+			player.Name, turn-w.since)
+		w.since = turn
+		w.settleAnywhere = true
+	}
+	w.cities = len(player.Cities)
+}
+
+// unstick sends a stuck unit to rest on a fresh route. A settler gives up
+// its dot, which is left out of the plan until it is next made.
+func (c *Controller) unstick(unit *game.Unit) {
+	w := c.watchdog
+	w.stuck[unit.ID]++
+	w.resting[unit.ID] = c.Game.CurrentTurn + stuckRestTurns
+	delete(w.positions, unit.ID)
+	delete(w.failures, unit.ID)
+	c.paths.forget(unit.ID)
+
+	if dots := c.dots; dots != nil && unit.CanFoundCity() {
+		if dot, ok := dots.assigned[unit.ID]; ok {
+			dots.release(unit)
+			dots.drop(dot)
+		}
+	}
+}
+
+// isResting reports whether the unit is resting after getting stuck
+func (c *Controller) isResting(unit *game.Unit) bool {
+	return c.watchdog != nil && c.watchdog.resting[unit.ID] > c.Game.CurrentTurn
+}
+
+// settlesAnywhere reports whether settlers waited so long for a city that
+// they found one wherever they can
+func (c *Controller) settlesAnywhere() bool {
+	return c.watchdog != nil && c.watchdog.settleAnywhere
+}
+
+// rest has a resting unit sit out its turn. Settlers found a city where
+// they stand if they can, and units in the AI's cities or stuck too often
+// fortify for good.
+func (c *Controller) rest(unit *game.Unit) []game.Action {
+	var actions []game.Action
+	if unit.CanFoundCity() {
+		actions = append(actions, &game.FoundCityAction{SettlerID: unit.ID})
+	}
+	city := c.Game.GetCityAt(unit.X, unit.Y)
+	if city != nil && city.OwnerID == c.PlayerID || c.watchdog.stuck[unit.ID] >= maxTimesStuck {
+		actions = append(actions, &game.FortifyAction{UnitID: unit.ID})
+	}
+	actions = append(actions, &game.SkipUnitAction{UnitID: unit.ID})
+
+	for _, action := range actions {
+		if action.Validate(c.Game, c.PlayerID) == nil {
+			return []game.Action{action}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package ai
+
+import "civilization/internal/game"
+
+// Once its army is being built up, the AI has its largest city build a
+// wonder no one has yet, and its other cities build caravans and send them
+// there to hurry the wonder along.
+
+// wonderCitySize is the population a city needs to start a wonder
+const wonderCitySize = 6
+
+// caravanCitySize is the population a city needs to build caravans
+const caravanCitySize = 3
+
+// wonderCity returns the city of the AI's that is building a wonder, or nil
+func (c *Controller) wonderCity() *game.City {
+	for _, city := range c.GetPlayer().Cities {
+		if build := city.CurrentBuild; build != nil && !build.IsUnit && build.Building.IsWonder() {
+			return city
+		}
+	}
+	return nil
+}
+
+// wonderToStart returns the wonder the city should start, or BuildingNone.
+// Only the largest city starts one, and only while no other city of the
+// AI's is building one.
+func (c *Controller) wonderToStart(city *game.City) game.BuildingType {
+	if city.Population < wonderCitySize || c.wonderCity() != nil {
+		return game.BuildingNone
+	}
+	for _, other := range c.GetPlayer().Cities {
+		if other.Population > city.Population {
+			return game.BuildingNone
+		}
+	}
+	for _, b := range game.BuildingTypes() {
+		if b.IsWonder() && !c.Game.WonderBuilt(b) {
+			return b
+		}
+	}
+	return game.BuildingNone
+}
+
+// needsCaravans reports whether the city should build caravans for the
+// wonder: it is not the wonder city, and the caravans already on their way
+// do not cover what the wonder still needs
+func (c *Controller) needsCaravans(city *game.City) bool {
+	wonder := c.wonderCity()
+	if wonder == nil || wonder == city || city.Population < caravanCitySize {
+		return false
+	}
+
+	speed := c.Game.Config.Speed
+	needed := wonder.CurrentBuild.CostAt(speed) - wonder.Production
+	for _, unit := range c.GetPlayer().Units {
+		if unit.HelpsWonder() {
+			needed -= game.ScaleCost(unit.Template().Cost, speed)
+		}
+	}
+	for _, other := range c.GetPlayer().Cities {
+		if other != city && buildsCaravans(other) {
+			needed -= other.CurrentBuild.CostAt(speed)
+		}
+	}
+	return needed > 0
+}
+
+// buildsCaravans reports whether the city is building caravans
+func buildsCaravans(city *game.City) bool {
+	build := city.CurrentBuild
+	return build != nil && build.IsUnit && game.UnitTemplates[build.UnitType].HelpsWonder
+}
+
+// caravanType returns the unit type that helps build wonders
+func caravanType() (game.UnitType, bool) {
+	for _, t := range game.UnitTypes() {
+		if game.UnitTemplates[t].HelpsWonder {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// handleCaravan takes a caravan to the wonder city and adds it to the
+// wonder there. With no wonder being built it waits where it is.
+func (c *Controller) handleCaravan(unit *game.Unit) []game.Action {
+	city := c.wonderCity()
+	if city == nil {
+		return nil
+	}
+
+	if unit.X == city.X && unit.Y == city.Y {
+		action := &game.HelpWonderAction{UnitID: unit.ID}
+		if err := action.Validate(c.Game, c.PlayerID); err == nil {
+			return []game.Action{action}
+		}
+		return nil
+	}
+
+	nextMove := c.paths.NextMove(c.Game, unit, city.X, city.Y)
+	if nextMove == nil {
+		return nil
+	}
+	action := &game.MoveUnitAction{UnitID: unit.ID, ToX: nextMove.X, ToY: nextMove.Y}
+	if err := action.Validate(c.Game, c.PlayerID); err != nil {
+		return nil
+	}
+	return []game.Action{action}
+}
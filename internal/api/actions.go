@@ -0,0 +1,81 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"fmt"
+	"runtime/debug"
+)
+
+// ActionResult is what became of an action submitted to the hub
+type ActionResult struct {
+	Event      *game.Event       // Event the action was recorded as, nil if refused
+	Published  []game.BusEvent   // What the game told of as the action was applied
+	UnitHashes map[string]string // Hash of each player's units, if the action changed nothing else
+	Code       ErrorCode         // Why the action was refused, for the client
+	Err        error             // Why the action was refused
+}
+
+// Applied reports whether the action was applied to the game
+func (r ActionResult) Applied() bool {
+	return r.Err == nil
+}
+
+// submit applies an action on behalf of a player. The pause and turn
+// checks, validation and execution happen under the game lock, so no
+// client, AI or turn timer can change the game between an action being
+// found valid and it being executed. Clients are told of what the game published as
+// the action was applied, and of the units it changed when they are all
+// it changed. A panic applying the action fails the game.
+func (h *Hub) submit(playerID string, action game.Action) (result ActionResult) {
+	if h.failure() != "" {
+		return ActionResult{Code: CodeGameFailed, Err: ErrGameFailed}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			h.fail(fmt.Sprintf("%s by %s", action.Type(), playerID), r, debug.Stack())
+			result = ActionResult{Code: CodeGameFailed, Err: ErrGameFailed}
+		}
+	}()
+
+	result, messages := h.apply(playerID, action)
+	for _, m := range messages {
+		if m.playerID != "" {
+			h.sendToPlayer(m.playerID, m.data)
+		} else {
+			h.queue(outbound{data: m.data})
+		}
+	}
+	return result
+}
+
+// apply applies an action under the game lock, and returns the messages
+// telling clients what it did
+func (h *Hub) apply(playerID string, action game.Action) (ActionResult, []busMessage) {
+	h.gameMu.Lock()
+	defer h.gameMu.Unlock()
+
+	// The host changes how the game is run paused or not, on any turn
+	if !game.IsHostAction(action) {
+		if h.isPaused() {
+			return ActionResult{Code: CodeGamePaused, Err: ErrGamePaused}, nil
+		}
+		if !h.game.IsCurrentPlayerTurn(playerID) {
+			return ActionResult{Code: CodeNotYourTurn, Err: game.ErrNotYourTurn}, nil
+		}
+	}
+
+	event, err := h.game.Apply(playerID, action)
+	published := h.takePublished()
+	if err != nil {
+		return ActionResult{Code: ActionErrorCode(err), Err: err}, nil
+	}
+	result := ActionResult{Event: event, Published: published}
+	if onlyUnits(result) {
+		result.UnitHashes = h.unitHashes()
+	}
+	messages := h.busMessages(published)
+	if data := h.unitsChanged(result); data != nil {
+		messages = append(messages, busMessage{data: data})
+	}
+	return result, messages
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// The admin API is for operators of a server, to look into and unstick
+// games in production. Every request must carry the server's admin token
+// as "Authorization: Bearer <token>"; without a token set the API is off.
+// A server plays one game at a time, which the requests name by "id"; an
+// instance plays those it has loaded, and loads the one a request names.
+
+// AdminGameDTO describes a game for operators
+type AdminGameDTO struct {
+	ID              string         `json:"id"`
+	Turn            int            `json:"turn"`
+	Phase           string         `json:"phase"`
+	CurrentPlayer   string         `json:"current_player"`
+	PlayersToMove   []string       `json:"players_to_move"`
+	Seq             uint64         `json:"seq"`
+	Clients         int            `json:"clients"`
+	ClientsByPlayer map[string]int `json:"clients_by_player"`
+	Async           bool           `json:"async,omitempty"`
+	Host            string         `json:"host,omitempty"`
+	Paused          bool           `json:"paused,omitempty"`
+	AIRunning       bool           `json:"ai_running,omitempty"` // AI players are taking their turns
+	Winner          string         `json:"winner,omitempty"`
+	Failed          string         `json:"failed,omitempty"` // Panic the game failed with
+}
+
+// AdminInspectDTO is a closer look at a game and at the server's
+// goroutines and memory
+type AdminInspectDTO struct {
+	AdminGameDTO
+	Players    []AdminPlayerDTO `json:"players"`
+	Goroutines int              `json:"goroutines"`
+	HeapBytes  uint64           `json:"heap_bytes"` // Heap in use by the server
+}
+
+// AdminPlayerDTO is a player as operators see them
+type AdminPlayerDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsHuman   bool   `json:"is_human"`
+	IsAlive   bool   `json:"is_alive"`
+	Kicked    bool   `json:"kicked,omitempty"`
+	Submitted bool   `json:"submitted,omitempty"` // Orders submitted in the simultaneous phase
+	Units     int    `json:"units"`
+	Cities    int    `json:"cities"`
+}
+
+// clientCounts returns the number of clients connected for each player
+func (h *Hub) clientCounts() map[string]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	counts := make(map[string]int)
+	for client := range h.clients {
+		counts[client.playerID]++
+	}
+	return counts
+}
+
+// adminGame describes the hub's game. Callers must read-hold the game lock.
+func (h *Hub) adminGame() AdminGameDTO {
+	g := h.game
+	dto := AdminGameDTO{
+		ID:              g.ID,
+		Turn:            g.CurrentTurn,
+		Phase:           g.Phase.String(),
+		CurrentPlayer:   g.GetCurrentPlayer().ID,
+		PlayersToMove:   make([]string, 0),
+		Seq:             g.Seq,
+		ClientsByPlayer: h.clientCounts(),
+		Async:           h.async != nil,
+	}
+	for _, p := range g.PlayersToMove() {
+		dto.PlayersToMove = append(dto.PlayersToMove, p.ID)
+	}
+	for _, n := range dto.ClientsByPlayer {
+		dto.Clients += n
+	}
+	if g.Winner != nil {
+		dto.Winner = g.Winner.ID
+	}
+
+	h.mu.RLock()
+	dto.Host, dto.Paused, dto.AIRunning, dto.Failed = h.host, h.paused, h.aiRunning, h.failed
+	h.mu.RUnlock()
+	return dto
+}
+
+// adminInspect takes a closer look at the hub's game. Callers must
+// read-hold the game lock.
+func (h *Hub) adminInspect() AdminInspectDTO {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	dto := AdminInspectDTO{
+		AdminGameDTO: h.adminGame(),
+		Players:      make([]AdminPlayerDTO, len(h.game.Players)),
+		Goroutines:   runtime.NumGoroutine(),
+		HeapBytes:    mem.HeapAlloc,
+	}
+	for i, p := range h.game.Players {
+		dto.Players[i] = AdminPlayerDTO{
+			ID:        p.ID,
+			Name:      p.Name,
+			IsHuman:   p.Type == game.PlayerHuman,
+			IsAlive:   p.IsAlive,
+			Kicked:    h.isKicked(p.ID),
+			Submitted: h.game.OrdersSubmitted(p.ID),
+			Units:     len(p.Units),
+			Cities:    len(p.Cities),
+		}
+	}
+	return dto
+}
+
+// forceEndTurn ends the turn of every player who is to move, and restarts
+// AI players who stopped partway through theirs
+func (h *Hub) forceEndTurn() {
+	h.mu.RLock()
+	aiRunning := h.aiRunning
+	h.mu.RUnlock()
+
+	if h.phase() == game.PhaseAITurn {
+		if !aiRunning {
+			go h.ProcessAITurns()
+		}
+		return
+	}
+	for _, p := range h.playersOnTurn() {
+		h.playInactive(p, game.InactivitySkip)
+	}
+}
+
+// requireAdmin answers a request that does not carry the admin token, and
+// reports whether it did
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if s.AdminToken == "" {
+		http.NotFound(w, r)
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// adminHub returns the hub of the game a request names, or answers it
+// with an error
+func (s *Server) adminHub(w http.ResponseWriter, r *http.Request, method string) *Hub {
+	if !s.requireAdmin(w, r) {
+		return nil
+	}
+	if r.Method != method {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil
+	}
+	id := r.URL.Query().Get("id")
+	if s.engine != nil {
+		hub, err := s.engine.hub(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return nil
+		}
+		return hub
+	}
+	hub := s.hub
+	if hub == nil || s.game == nil || id != s.game.ID {
+		http.Error(w, "No such game", http.StatusNotFound)
+		return nil
+	}
+	return hub
+}
+
+// handleAdminGames lists the games in progress, or with DELETE removes one
+func (s *Server) handleAdminGames(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		s.handleAdminDeleteGame(w, r)
+		return
+	}
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var hubs []*Hub
+	if s.engine != nil {
+		hubs = s.engine.hubs()
+	} else if s.hub != nil && s.game != nil {
+		hubs = append(hubs, s.hub)
+	}
+	games := make([]AdminGameDTO, 0, len(hubs))
+	for _, hub := range hubs {
+		hub.gameMu.RLock()
+		games = append(games, hub.adminGame())
+		hub.gameMu.RUnlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(games)
+}
+
+// handleAdminInspect describes a game in detail. With "stacks" set it
+// dumps the stacks of every goroutine of the server instead.
+func (s *Server) handleAdminInspect(w http.ResponseWriter, r *http.Request) {
+	hub := s.adminHub(w, r, http.MethodGet)
+	if hub == nil {
+		return
+	}
+
+	if r.URL.Query().Get("stacks") != "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		pprof.Lookup("goroutine").WriteTo(w, 2)
+		return
+	}
+
+	hub.gameMu.RLock()
+	dto := hub.adminInspect()
+	hub.gameMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto)
+}
+
+// handleAdminSnapshot sends a game's full state as a save file, for
+// debugging
+func (s *Server) handleAdminSnapshot(w http.ResponseWriter, r *http.Request) {
+	hub := s.adminHub(w, r, http.MethodGet)
+	if hub == nil {
+		return
+	}
+
+	hub.gameMu.RLock()
+	data, err := json.MarshalIndent(SaveToDTO(hub.game), "", "  ")
+	hub.gameMu.RUnlock()
+	if err != nil {
+		http.Error(w, "Failed to serialize game state", http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("snapshot_%s_%s.json", hub.game.ID, time.Now().Format("2006-01-02_15-04-05"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(data)
+}
+
+// handleAdminSave saves a game to a slot of its own directory, and an
+// async game to its storage too
+func (s *Server) handleAdminSave(w http.ResponseWriter, r *http.Request) {
+	hub := s.adminHub(w, r, http.MethodPost)
+	if hub == nil {
+		return
+	}
+
+	hub.gameMu.RLock()
+	filename, err := s.saveSlot(hub.game, "admin_"+time.Now().Format("2006-01-02_15-04-05"))
+	hub.gameMu.RUnlock()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to write save file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	savePath := filepath.Join(s.savesPath, filename)
+	if hub.async != nil {
+		hub.save()
+	}
+	log.Printf("Admin saved game %s to %s", hub.game.ID, savePath)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"filename": filename,
+		"path":     savePath,
+	})
+}
+
+// handleAdminEndTurn ends the turn of the players a game waits for
+func (s *Server) handleAdminEndTurn(w http.ResponseWriter, r *http.Request) {
+	hub := s.adminHub(w, r, http.MethodPost)
+	if hub == nil {
+		return
+	}
+
+	log.Printf("Admin ended turn %d of game %s", hub.game.CurrentTurn, hub.game.ID)
+	hub.forceEndTurn()
+
+	hub.gameMu.RLock()
+	dto := hub.adminGame()
+	hub.gameMu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto)
+}
+
+// handleAdminDeleteGame ends a game for good: its clients are
+// disconnected and an async game's storage and its recorded history are
+// removed, so it is not resumed
+func (s *Server) handleAdminDeleteGame(w http.ResponseWriter, r *http.Request) {
+	hub := s.adminHub(w, r, http.MethodDelete)
+	if hub == nil {
+		return
+	}
+
+	id := hub.game.ID
+	if s.engine != nil {
+		s.engine.remove(id)
+	} else {
+		hub.Close()
+	}
+	if hub.async != nil {
+		if err := os.Remove(hub.async.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Removing async game %s: %v", id, err)
+		}
+	}
+	if s.Store != nil {
+		if err := s.Store.Delete(id); err != nil {
+			log.Printf("Removing history of game %s: %v", id, err)
+		}
+	}
+	if hub == s.hub {
+		s.hub, s.game = nil, nil
+	}
+	log.Printf("Admin deleted game %s", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
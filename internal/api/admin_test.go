@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// adminRequest sends a request to the admin API with a token
+func adminRequest(s *Server, method, target, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.SetupRoutes().ServeHTTP(w, r)
+	return w
+}
+
+// TestAdminAPI lists a game, ends alice's turn and deletes the game, and
+// checks that the API turns away requests without the token
+func TestAdminAPI(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{hub: c.hub, game: c.hub.game, savesPath: t.TempDir()}
+	id := s.game.ID
+
+	if w := adminRequest(s, http.MethodGet, "/api/admin/games", "secret"); w.Code != http.StatusNotFound {
+		t.Errorf("admin API without a token set answered %d, want 404", w.Code)
+	}
+	s.AdminToken = "secret"
+	if w := adminRequest(s, http.MethodGet, "/api/admin/games", "guess"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token answered %d, want 401", w.Code)
+	}
+
+	var games []AdminGameDTO
+	w := adminRequest(s, http.MethodGet, "/api/admin/games", "secret")
+	if err := json.Unmarshal(w.Body.Bytes(), &games); err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0].ID != id || games[0].CurrentPlayer != "alice" {
+		t.Fatalf("games %+v, want %s with alice to move", games, id)
+	}
+
+	var game AdminGameDTO
+	w = adminRequest(s, http.MethodPost, "/api/admin/games/end-turn?id="+id, "secret")
+	if err := json.Unmarshal(w.Body.Bytes(), &game); err != nil {
+		t.Fatal(err)
+	}
+	if game.CurrentPlayer != "bob" {
+		t.Errorf("%s to move after ending alice's turn, want bob", game.CurrentPlayer)
+	}
+
+	if w := adminRequest(s, http.MethodGet, "/api/admin/games/snapshot?id=other", "secret"); w.Code != http.StatusNotFound {
+		t.Errorf("snapshot of an unknown game answered %d, want 404", w.Code)
+	}
+	if w := adminRequest(s, http.MethodDelete, "/api/admin/games?id="+id, "secret"); w.Code != http.StatusOK {
+		t.Errorf("delete answered %d, want 200", w.Code)
+	}
+	if s.game != nil || s.hub != nil {
+		t.Error("game still in progress after it was deleted")
+	}
+}
@@ -0,0 +1,555 @@
+package api
+
+import (
+	"bytes"
+	"civilization/internal/ai"
+	"civilization/internal/game"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// InactivityCheckInterval is how often async games look for players who
+// have run out of time
+const InactivityCheckInterval = 30 * time.Second
+
+// AsyncRecord is what an async game keeps in storage: the game with its
+// event log, where to notify each player, when the current turn began and
+// the host's settings
+type AsyncRecord struct {
+	Game        GameStateMessage  `json:"game"`
+	Notify      map[string]string `json:"notify,omitempty"` // Notification target by player ID
+	TurnStarted time.Time         `json:"turn_started"`
+	Host        string            `json:"host,omitempty"`
+	Paused      bool              `json:"paused,omitempty"`
+	Kicked      []string          `json:"kicked,omitempty"`
+}
+
+// TurnNotification is sent to a player when it becomes their turn. It is
+// the body of webhook requests.
+type TurnNotification struct {
+	GameID     string `json:"game_id"`
+	PlayerID   string `json:"player_id"`
+	PlayerName string `json:"player_name"`
+	Turn       int    `json:"turn"`
+	MapURL     string `json:"map_url,omitempty"` // The map as the player has explored it
+
+	// Subject and Text tell the player in the game's language
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// SetNotifyMessage is sent by a client to choose where its player is told
+// that it is their turn: an http(s) webhook URL, a mailto: address, or
+// empty for no notifications
+type SetNotifyMessage struct {
+	Target string `json:"target"`
+}
+
+// Notifier delivers turn notifications by webhook or email. Players choose
+// where webhooks go, so they are only sent to public addresses: a player
+// cannot have the server make requests into the network it runs in, unless
+// the operator lists the host in WebhookHosts.
+type Notifier struct {
+	SMTPAddr  string // Mail server as host:port; email is disabled if empty
+	MailFrom  string
+	PublicURL string // Where players reach the server, to link their map
+
+	// WebhookHosts are the hosts webhooks may be sent to even though they
+	// are on this machine or a private network
+	WebhookHosts []string
+
+	client  *http.Client // Connects to public addresses alone
+	trusted *http.Client // Connects to the hosts in WebhookHosts
+}
+
+// NewNotifier creates a notifier sending mail through smtpAddr
+func NewNotifier(smtpAddr, mailFrom string) *Notifier {
+	// The address is checked as it is connected to, once the host name has
+	// been resolved, so a name cannot be made to resolve elsewhere later
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: publicAddressOnly}
+	return &Notifier{
+		SMTPAddr: smtpAddr,
+		MailFrom: mailFrom,
+		client: &http.Client{
+			Timeout:       10 * time.Second,
+			Transport:     &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: noRedirects,
+		},
+		trusted: &http.Client{Timeout: 10 * time.Second, CheckRedirect: noRedirects},
+	}
+}
+
+// noRedirects keeps webhooks from being redirected, to an address that is
+// not public or anywhere else
+func noRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// publicAddressOnly refuses connections to addresses that are not public
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, private like the
+// ranges net.IP.IsPrivate knows
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicIP reports whether an address is reachable on the internet rather
+// than this machine, a private network or a link
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// trustedHost reports whether the operator lets webhooks go to a host
+// whatever its address
+func (n *Notifier) trustedHost(host string) bool {
+	return slices.ContainsFunc(n.WebhookHosts, func(h string) bool { return strings.EqualFold(h, host) })
+}
+
+// validWebhook checks that a webhook URL is one notifications may be sent
+// to: one of the operator's hosts, or a host all of whose addresses are
+// public
+func (n *Notifier) validWebhook(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	host := u.Hostname()
+	if n.trustedHost(host) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("webhook host %s cannot be resolved", host)
+	}
+	for _, addr := range addrs {
+		if !publicIP(addr.IP) {
+			return fmt.Errorf("webhook host %s is not public", host)
+		}
+	}
+	return nil
+}
+
+// validTarget checks that a notification target can be delivered to
+func (n *Notifier) validTarget(target string) error {
+	switch {
+	case target == "":
+		return nil
+	case strings.HasPrefix(target, "http://"), strings.HasPrefix(target, "https://"):
+		return n.validWebhook(target)
+	case strings.HasPrefix(target, "mailto:"):
+		if n.SMTPAddr == "" {
+			return fmt.Errorf("email notifications are not enabled on this server")
+		}
+		return nil
+	default:
+		return fmt.Errorf("notification target must be a webhook URL or a mailto: address")
+	}
+}
+
+// Notify delivers a turn notification to a target
+func (n *Notifier) Notify(target string, msg TurnNotification) error {
+	if address, ok := strings.CutPrefix(target, "mailto:"); ok {
+		body := fmt.Sprintf("To: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+			address, mime.QEncoding.Encode("utf-8", msg.Subject), strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+		return smtp.SendMail(n.SMTPAddr, nil, n.MailFrom, []string{address}, []byte(body))
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	client := n.client
+	if u, err := url.Parse(target); err == nil && n.trustedHost(u.Hostname()) {
+		client = n.trusted
+	}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// asyncGame tracks the storage, notifications and turn timer of an async
+// game
+type asyncGame struct {
+	path     string // File the game is kept in
+	notifier *Notifier
+
+	mu          sync.Mutex
+	notify      map[string]string
+	turnStarted time.Time
+	turn        string    // Identifies the turn the timer is running for
+	pausedAt    time.Time // When the host paused the game
+	done        chan struct{}
+}
+
+// EnableAsync keeps the hub's game in dir and starts the turn timer. A
+// record restores the notification targets and timer of a resumed game.
+func (h *Hub) EnableAsync(dir string, notifier *Notifier, record *AsyncRecord) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if notifier == nil {
+		notifier = NewNotifier("", "")
+	}
+
+	a := &asyncGame{
+		path:        filepath.Join(dir, h.game.ID+".json"),
+		notifier:    notifier,
+		notify:      make(map[string]string),
+		turnStarted: time.Now(),
+		turn:        turnKey(h.game),
+		done:        make(chan struct{}),
+	}
+	if record != nil {
+		for id, target := range record.Notify {
+			a.notify[id] = target
+		}
+		if !record.TurnStarted.IsZero() {
+			a.turnStarted = record.TurnStarted
+		}
+
+		h.mu.Lock()
+		if h.game.GetPlayer(record.Host) != nil {
+			h.host = record.Host
+		}
+		h.paused = record.Paused
+		for _, id := range record.Kicked {
+			h.kicked[id] = true
+		}
+		h.mu.Unlock()
+		if record.Paused {
+			a.pausedAt = time.Now()
+		}
+	}
+	h.async = a
+
+	h.save()
+	go h.watchInactivity()
+	return nil
+}
+
+// LoadAsyncRecord reads an async game from storage
+func LoadAsyncRecord(path string) (*AsyncRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var record AsyncRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid async game %s: %w", filepath.Base(path), err)
+	}
+	return &record, nil
+}
+
+// LatestAsyncGame returns the most recently saved async game in dir that
+// is not over, or "" if there is none
+func LatestAsyncGame(dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	type candidate struct {
+		path     string
+		modified time.Time
+	}
+	candidates := make([]candidate, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, candidate{file, info.ModTime()})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modified.After(candidates[j].modified) })
+
+	for _, c := range candidates {
+		record, err := LoadAsyncRecord(c.path)
+		if err == nil && record.Game.Phase != game.PhaseGameOver.String() {
+			return c.path
+		}
+	}
+	return ""
+}
+
+// turnKey identifies whose turn it is
+func turnKey(g *game.GameState) string {
+	return fmt.Sprintf("%d/%s/%s", g.CurrentTurn, g.TurnOrder.Current, g.Phase)
+}
+
+// turnChanged is called whenever play may have moved on. The game's replay
+// and stats are written out, its history recorded, and kicked players
+// have their turns played for them. In async games a new turn restarts the
+// timer and notifies the players now to move, and the game is saved either
+// way. Nothing is written for a game that failed.
+func (h *Hub) turnChanged() {
+	if h.failure() != "" {
+		return
+	}
+	h.writeHistory()
+	h.record()
+	if h.playKicked() {
+		return
+	}
+
+	a := h.async
+	if a == nil {
+		return
+	}
+
+	h.gameMu.RLock()
+	key := turnKey(h.game)
+	h.gameMu.RUnlock()
+
+	a.mu.Lock()
+	changed := key != a.turn
+	if changed {
+		a.turn = key
+		a.turnStarted = time.Now()
+	}
+	a.mu.Unlock()
+
+	if changed && h.phase() != game.PhaseGameOver {
+		h.notifyPlayersToMove()
+	}
+	h.save()
+}
+
+// notifyPlayersToMove tells the humans now playing, who have no client
+// connected, that it is their turn
+func (h *Hub) notifyPlayersToMove() {
+	a := h.async
+
+	h.mu.RLock()
+	connected := make(map[string]bool)
+	for client := range h.clients {
+		connected[client.playerID] = true
+	}
+	h.mu.RUnlock()
+
+	h.gameMu.RLock()
+	players := h.game.PlayersToMove()
+	turn := h.game.CurrentTurn
+	h.gameMu.RUnlock()
+
+	for _, p := range players {
+		a.mu.Lock()
+		target := a.notify[p.ID]
+		a.mu.Unlock()
+		if p.Type != game.PlayerHuman || target == "" || connected[p.ID] {
+			continue
+		}
+
+		msg := TurnNotification{GameID: h.game.ID, PlayerID: p.ID, PlayerName: p.Name, Turn: turn}
+		if a.notifier.PublicURL != "" {
+			msg.MapURL = a.notifier.PublicURL + "/api/game/map.png?player=" + url.QueryEscape(p.ID)
+		}
+		pack := h.pack()
+		msg.Subject = pack.Text("notify.subject", "turn", strconv.Itoa(msg.Turn))
+		msg.Text = pack.Text("notify.body", "player", p.Name)
+		if msg.MapURL != "" {
+			msg.Text += "\n\n" + pack.Text("notify.map", "url", msg.MapURL)
+		}
+		go func() {
+			if err := a.notifier.Notify(target, msg); err != nil {
+				log.Printf("Notifying %s: %v", msg.PlayerName, err)
+			}
+		}()
+	}
+}
+
+// save writes the async game to storage, unless it failed and may be
+// left in a state it should not be resumed from
+func (h *Hub) save() {
+	if h.failure() != "" {
+		return
+	}
+	a := h.async
+	h.gameMu.RLock()
+	state := SaveToDTO(h.game)
+	h.gameMu.RUnlock()
+	host := h.hostState()
+
+	a.mu.Lock()
+	record := AsyncRecord{
+		Game:        state,
+		Notify:      a.notify,
+		TurnStarted: a.turnStarted,
+		Host:        host.Host,
+		Paused:      host.Paused,
+		Kicked:      host.Kicked,
+	}
+	data, err := json.Marshal(record)
+	a.mu.Unlock()
+	if err != nil {
+		log.Printf("Error marshaling async game: %v", err)
+		return
+	}
+
+	// Write to a temporary file first so a crash never leaves half a game
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("Error saving async game: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, a.path); err != nil {
+		log.Printf("Error saving async game: %v", err)
+	}
+}
+
+// setNotify sets where a player is notified that it is their turn
+func (h *Hub) setNotify(playerID, target string) error {
+	a := h.async
+	if a == nil {
+		return fmt.Errorf("turn notifications are only sent in async games")
+	}
+	if err := a.notifier.validTarget(target); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	if target == "" {
+		delete(a.notify, playerID)
+	} else {
+		a.notify[playerID] = target
+	}
+	a.mu.Unlock()
+
+	h.save()
+	return nil
+}
+
+// watchInactivity checks for inactive players every
+// InactivityCheckInterval until the hub is closed
+func (h *Hub) watchInactivity() {
+	a := h.async
+	ticker := time.NewTicker(InactivityCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			h.checkInactivity()
+		}
+	}
+}
+
+// checkInactivity applies the game's inactivity policy to humans who have
+// taken longer than the turn timeout. The host may change both at any
+// time.
+func (h *Hub) checkInactivity() {
+	a := h.async
+	minutes, policy := h.turnTimer()
+	if minutes <= 0 || policy == "" || h.isPaused() {
+		return
+	}
+
+	a.mu.Lock()
+	expired := time.Since(a.turnStarted) > time.Duration(minutes)*time.Minute
+	a.mu.Unlock()
+	if !expired {
+		return
+	}
+
+	for _, p := range h.playersOnTurn() {
+		if p.Type == game.PlayerHuman {
+			h.playInactive(p, policy)
+		}
+	}
+}
+
+// playInactive ends the turn of a player who ran out of time or was
+// kicked, letting an AI play it first under the AI policy
+func (h *Hub) playInactive(player *game.Player, policy string) {
+	h.gameMu.RLock()
+	log.Printf("Ending the turn of %s on turn %d", player.Name, h.game.CurrentTurn)
+	var end game.Action = &game.EndTurnAction{}
+	if h.game.Phase == game.PhaseSimultaneous {
+		end = &game.SubmitOrdersAction{PlayerID: player.ID}
+	}
+	h.gameMu.RUnlock()
+
+	actions := make([]game.Action, 0)
+	if policy == game.InactivityAI {
+		planned := h.planAITurn(ai.NewController(h.game, player.ID))
+		h.gameMu.RLock()
+		for _, action := range planned {
+			if action.Type() != "end_turn" {
+				actions = append(actions, plannedAction(h.game, action))
+			}
+		}
+		h.gameMu.RUnlock()
+	}
+	actions = append(actions, end)
+
+	// The player may still act until their turn is ended, so every action
+	// is checked against the turn as it is applied
+	for _, action := range actions {
+		if result := h.submit(player.ID, action); result.Applied() {
+			h.BroadcastEvent(result)
+		} else if action == end {
+			log.Printf("Could not end the turn of %s: %v", player.Name, result.Err)
+		}
+	}
+	h.BroadcastGameState()
+
+	if h.phase() == game.PhaseAITurn {
+		h.ProcessAITurns()
+		return
+	}
+	h.BroadcastTurnChange()
+	h.SendTurnSummary()
+	h.SendAdvice()
+	h.SendTurnStatus()
+	h.turnChanged()
+}
+
+// plannedAction turns a move or attack into a planned order when the game
+// is in the simultaneous phase
+func plannedAction(g *game.GameState, action game.Action) game.Action {
+	if g.Phase != game.PhaseSimultaneous {
+		return action
+	}
+	switch a := action.(type) {
+	case *game.MoveUnitAction:
+		return &game.PlanOrderAction{UnitID: a.UnitID, Kind: game.OrderMove, X: a.ToX, Y: a.ToY}
+	case *game.AttackAction:
+		return &game.PlanOrderAction{UnitID: a.AttackerID, Kind: game.OrderAttack, X: a.TargetX, Y: a.TargetY}
+	}
+	return action
+}
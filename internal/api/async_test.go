@@ -0,0 +1,155 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newAsyncHub returns the hub of an async game of alice against bob with a
+// one minute turn timeout and the given inactivity policy, which sends
+// bob's turn notifications to the returned channel
+func newAsyncHub(t *testing.T, policy string) (*Hub, <-chan TurnNotification) {
+	t.Helper()
+	notified := make(chan TurnNotification, 8)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg TurnNotification
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("invalid notification: %v", err)
+		}
+		notified <- msg
+	}))
+	t.Cleanup(webhook.Close)
+
+	h := newTestClient(t, "alice").hub
+	h.game.Config.TurnTimeout = 1
+	h.game.Config.InactivityPolicy = policy
+	notifier := NewNotifier("", "")
+	notifier.WebhookHosts = []string{"127.0.0.1"}
+	if err := h.EnableAsync(t.TempDir(), notifier, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	if err := h.setNotify("bob", webhook.URL); err != nil {
+		t.Fatal(err)
+	}
+	return h, notified
+}
+
+// expire moves the start of the current turn back past the turn timeout
+func expire(h *Hub) {
+	h.async.mu.Lock()
+	h.async.turnStarted = time.Now().Add(-2 * time.Minute)
+	h.async.mu.Unlock()
+}
+
+// awaitNotification returns the next turn notification sent, failing the
+// test if none comes
+func awaitNotification(t *testing.T, notified <-chan TurnNotification) TurnNotification {
+	t.Helper()
+	select {
+	case msg := <-notified:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no turn notification was sent")
+		return TurnNotification{}
+	}
+}
+
+// TestInactivitySkip lets alice's turn run out under the skip policy and
+// checks that it is ended only once the timeout has passed, and that bob
+// is told it is their turn
+func TestInactivitySkip(t *testing.T) {
+	h, notified := newAsyncHub(t, game.InactivitySkip)
+
+	h.checkInactivity()
+	if !h.game.IsCurrentPlayerTurn("alice") {
+		t.Fatal("turn ended before the timeout")
+	}
+
+	expire(h)
+	h.checkInactivity()
+	if !h.game.IsCurrentPlayerTurn("bob") {
+		t.Fatalf("turn of %s after the timeout, want bob's", h.game.TurnOrder.Current)
+	}
+	for _, e := range h.game.EventsSince(0) {
+		if e.PlayerID == "alice" && e.Type != "end_turn" {
+			t.Errorf("alice's turn was skipped with a %s", e.Type)
+		}
+	}
+
+	msg := awaitNotification(t, notified)
+	if msg.PlayerID != "bob" || msg.Turn != h.game.CurrentTurn || msg.GameID != h.game.ID || msg.Subject == "" {
+		t.Errorf("notification %+v, want bob told of turn %d", msg, h.game.CurrentTurn)
+	}
+}
+
+// TestInactivityAI lets alice's turn run out under the AI policy and checks
+// that an AI plays it before it is ended
+func TestInactivityAI(t *testing.T) {
+	h, notified := newAsyncHub(t, game.InactivityAI)
+
+	expire(h)
+	h.checkInactivity()
+	if !h.game.IsCurrentPlayerTurn("bob") {
+		t.Fatalf("turn of %s after the timeout, want bob's", h.game.TurnOrder.Current)
+	}
+	played := 0
+	for _, e := range h.game.EventsSince(0) {
+		if e.PlayerID == "alice" && e.Type != "end_turn" {
+			played++
+		}
+	}
+	if played == 0 {
+		t.Error("alice's turn was ended without the AI playing it")
+	}
+
+	if msg := awaitNotification(t, notified); msg.PlayerID != "bob" {
+		t.Errorf("notification %+v, want bob told it is their turn", msg)
+	}
+}
+
+// TestWebhookTargets checks that webhooks are only sent to public hosts or
+// to those the operator lists, even once a target has been accepted
+func TestWebhookTargets(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer webhook.Close()
+
+	n := NewNotifier("", "")
+	for _, target := range []string{
+		"http://127.0.0.1:8080/admin",
+		"http://localhost/",
+		"https://[::1]/",
+		"http://10.0.0.1/",
+		"http://192.168.1.20:9000/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://100.64.0.1/",
+		"http://0.0.0.0/",
+		"http:///nohost",
+		webhook.URL,
+	} {
+		if err := n.validTarget(target); err == nil {
+			t.Errorf("webhook target %s was accepted", target)
+		}
+	}
+	if err := n.validTarget("ftp://example.com/"); err == nil {
+		t.Error("ftp target was accepted")
+	}
+
+	// A host that resolved to a public address when it was set is checked
+	// again as the notification is sent
+	if err := n.Notify(webhook.URL, TurnNotification{}); err == nil {
+		t.Errorf("notification was sent to %s", webhook.URL)
+	}
+
+	n.WebhookHosts = []string{"127.0.0.1"}
+	if err := n.validTarget(webhook.URL); err != nil {
+		t.Errorf("webhook target %s of a listed host: %v", webhook.URL, err)
+	}
+	if err := n.Notify(webhook.URL, TurnNotification{}); err != nil {
+		t.Errorf("notification to a listed host: %v", err)
+	}
+}
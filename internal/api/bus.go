@@ -0,0 +1,197 @@
+package api
+
+import (
+	"civilization/internal/game"
+)
+
+// The hub subscribes to its game's event bus, and tells clients what an
+// action did as it is applied: units moving and cities being founded as
+// updates, and battles as combat results. An action that changed nothing
+// but units is told only that way, with the units it changed otherwise
+// as a units_changed update, without a new game state. What a
+// player's cities finish building, how they grow, when they start and
+// stop celebrating and the technologies the player discovers are told to
+// that player alone. Everyone is told of a King being lost. Entries of the
+// game log are told to those who may read them.
+
+// UpdateUnitMoved is the update type of a unit moving. Its entity is a
+// UnitMovedDTO. A unit that moves several tiles in one action is told of
+// once, from where it started.
+const UpdateUnitMoved = "unit_moved"
+
+// UpdateUnitsChanged is the update type of the units an action changed,
+// when they are all it changed, such as a unit fortifying or joining a
+// group. Its entity is a list of UnitDTOs. Units told of as moving are
+// left out.
+const UpdateUnitsChanged = "units_changed"
+
+// UpdateCityFounded is the update type of a city being founded. Its entity
+// is the CityDTO.
+const UpdateCityFounded = "city_founded"
+
+// UpdateProductionCompleted is the update type of a city finishing a unit
+// or building, sent to its owner. Its entity is a game.CompletedReport.
+const UpdateProductionCompleted = "production_completed"
+
+// UpdateCityGrew is the update type of a city growing, sent to its owner.
+// Its entity is a game.CityReport.
+const UpdateCityGrew = "city_grew"
+
+// UpdateCelebrationStarted and UpdateCelebrationEnded are the update types
+// of a city starting and stopping celebrating, sent to its owner. Their
+// entity is a game.CityReport.
+const (
+	UpdateCelebrationStarted = "celebration_started"
+	UpdateCelebrationEnded   = "celebration_ended"
+)
+
+// UpdateKingLost is the update type of a player in a regicide game losing
+// their King, and with it the game. Its entity is a KingLostDTO.
+const UpdateKingLost = "king_lost"
+
+// UpdateLogEntry is the update type of an entry written in the game log,
+// sent to everyone or, for a private entry, to the player it is about. Its
+// entity is a game.LogEntry.
+const UpdateLogEntry = "log_entry"
+
+// UpdateTurnEnded is the update type of a player's turn ending. Its entity
+// is a TurnEndedDTO.
+const UpdateTurnEnded = "turn_ended"
+
+// UnitMovedDTO is a unit as it is after moving, with where it came from
+type UnitMovedDTO struct {
+	Unit  UnitDTO `json:"unit"`
+	FromX int     `json:"from_x"`
+	FromY int     `json:"from_y"`
+}
+
+// KingLostDTO is a player who lost their King, and where
+type KingLostDTO struct {
+	PlayerID string `json:"player_id"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+}
+
+// TurnEndedDTO is a player's turn that ended
+type TurnEndedDTO struct {
+	PlayerID string `json:"player_id"`
+	Turn     int    `json:"turn"`
+}
+
+// collect takes note of an event of the hub's game. It is called while
+// the game lock is held.
+func (h *Hub) collect(e game.BusEvent) {
+	h.published = append(h.published, e)
+}
+
+// takePublished returns the events published since it was last called.
+// Callers must hold the game lock.
+func (h *Hub) takePublished() []game.BusEvent {
+	events := h.published
+	h.published = nil
+	return events
+}
+
+// busMessage is a message telling clients of an event. It is sent to the
+// clients of one player, or broadcast if playerID is empty.
+type busMessage struct {
+	playerID string
+	data     []byte
+}
+
+// busMessages encodes the messages that tell clients of events, with the
+// units and cities as they are now. Callers must hold the game lock.
+func (h *Hub) busMessages(events []game.BusEvent) []busMessage {
+	var messages []busMessage
+	broadcast := func(data []byte) {
+		messages = append(messages, busMessage{data: data})
+	}
+	moved := make(map[string]bool)
+	for _, e := range events {
+		switch e := e.(type) {
+		case game.UnitMoved:
+			unit := h.game.GetUnit(e.UnitID)
+			if unit == nil || moved[e.UnitID] {
+				continue
+			}
+			moved[e.UnitID] = true
+			broadcast(encodeUpdate(UpdateUnitMoved, UnitMovedDTO{Unit: UnitToDTO(unit), FromX: e.FromX, FromY: e.FromY}))
+		case game.CityFounded:
+			if city := h.game.GetCity(e.CityID); city != nil {
+				broadcast(encodeUpdate(UpdateCityFounded, CityToDTO(city)))
+			}
+		case game.CombatResolved:
+			broadcast(encodeMessage(MsgTypeCombatResult, CombatResultMessage{e.CombatLogEntry}))
+		case game.ProductionCompleted:
+			completed := game.CompletedReport{CityID: e.CityID, CityName: e.CityName, Item: e.Item, UnitID: e.UnitID}
+			messages = append(messages, busMessage{playerID: e.PlayerID, data: encodeUpdate(UpdateProductionCompleted, completed)})
+		case game.CityGrew:
+			grew := game.CityReport{CityID: e.CityID, CityName: e.CityName, Population: e.Population, Granary: e.GranaryFood}
+			messages = append(messages, busMessage{playerID: e.PlayerID, data: encodeUpdate(UpdateCityGrew, grew)})
+		case game.CelebrationChanged:
+			update := UpdateCelebrationEnded
+			if e.Celebrating {
+				update = UpdateCelebrationStarted
+			}
+			city := game.CityReport{CityID: e.CityID, CityName: e.CityName, Population: e.Population}
+			messages = append(messages, busMessage{playerID: e.PlayerID, data: encodeUpdate(update, city)})
+		case game.TechDiscovered:
+			revealed := ResourcesRevealedToDTO(h.game.Map, e.Tech)
+			messages = append(messages, busMessage{playerID: e.PlayerID, data: encodeUpdate(UpdateResourcesRevealed, revealed)})
+		case game.KingLost:
+			broadcast(encodeUpdate(UpdateKingLost, KingLostDTO{PlayerID: e.PlayerID, X: e.X, Y: e.Y}))
+		case game.TurnEnded:
+			broadcast(encodeUpdate(UpdateTurnEnded, TurnEndedDTO{PlayerID: e.PlayerID, Turn: e.Turn}))
+		case game.Logged:
+			if e.Private {
+				messages = append(messages, busMessage{playerID: e.PlayerID, data: encodeUpdate(UpdateLogEntry, e.LogEntry)})
+			} else {
+				broadcast(encodeUpdate(UpdateLogEntry, e.LogEntry))
+			}
+		}
+	}
+	return messages
+}
+
+// onlyUnits reports whether an action changed nothing but units, all of
+// which clients are told of by its bus events and unitsChanged, so no new
+// game state need be sent
+func onlyUnits(result ActionResult) bool {
+	event := result.Event
+	if event == nil || event.Result == nil || len(event.Promotions) > 0 {
+		return false
+	}
+	changed := event.Result
+	if changed.Whole || len(changed.Units) == 0 || len(changed.Removed)+len(changed.Cities)+len(changed.Players)+len(changed.Tiles) > 0 {
+		return false
+	}
+	for _, e := range result.Published {
+		if _, ok := e.(game.UnitMoved); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// unitsChanged encodes the update of the units an action changed that
+// were not told of as moving, or returns nil if there are none or the
+// action changed more than units. Callers must hold the game lock.
+func (h *Hub) unitsChanged(result ActionResult) []byte {
+	if !onlyUnits(result) {
+		return nil
+	}
+	moved := make(map[string]bool)
+	for _, e := range result.Published {
+		moved[e.(game.UnitMoved).UnitID] = true
+	}
+	var units []UnitDTO
+	for _, id := range result.Event.Result.Units {
+		if unit := h.game.GetUnit(id); unit != nil && !moved[id] {
+			units = append(units, UnitToDTO(unit))
+		}
+	}
+	if len(units) == 0 {
+		return nil
+	}
+	return encodeUpdate(UpdateUnitsChanged, units)
+}
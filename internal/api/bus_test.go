@@ -0,0 +1,197 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// broadcastUpdates returns the types of the updates waiting to be
+// broadcast
+func broadcastUpdates(tb testing.TB, h *Hub) []string {
+	var types []string
+	for {
+		select {
+		case out := <-h.broadcast:
+			var msg WSMessage
+			if err := json.Unmarshal(out.data, &msg); err != nil {
+				tb.Fatal(err)
+			}
+			var update UpdateMessage
+			if msg.Type == MsgTypeUpdate && json.Unmarshal(msg.Payload, &update) == nil {
+				types = append(types, update.UpdateType)
+			}
+		default:
+			return types
+		}
+	}
+}
+
+// TestBusEvents checks that what the game publishes as an action is
+// applied is broadcast, that a plain move or fortifying needs no new game
+// state, and that clones of the game publish nothing
+func TestBusEvents(t *testing.T) {
+	h := newTestClient(t, "alice").hub
+	var warrior, settler *game.Unit
+	for _, u := range h.game.GetPlayer("alice").Units {
+		switch u.Type {
+		case game.UnitWarrior:
+			warrior = u
+		case game.UnitSettler:
+			settler = u
+		}
+	}
+
+	clone := h.game.Clone()
+	if _, err := clone.Apply("alice", &game.MoveUnitAction{UnitID: warrior.ID, ToX: 3, ToY: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if len(h.published) > 0 {
+		t.Fatalf("clone published %v to the hub", h.published)
+	}
+
+	result := h.submit("alice", &game.MoveUnitAction{UnitID: warrior.ID, ToX: 3, ToY: 2})
+	if !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	want := game.UnitMoved{UnitID: warrior.ID, PlayerID: "alice", FromX: 2, FromY: 2, ToX: 3, ToY: 2}
+	if len(result.Published) != 1 || result.Published[0] != want {
+		t.Errorf("move published %+v, want %+v", result.Published, want)
+	}
+	if !onlyUnits(result) {
+		t.Error("a plain move needed a new game state")
+	}
+	if got := broadcastUpdates(t, h); len(got) != 1 || got[0] != UpdateUnitMoved {
+		t.Errorf("move broadcast updates %v, want %s", got, UpdateUnitMoved)
+	}
+
+	result = h.submit("alice", &game.FortifyAction{UnitID: warrior.ID})
+	if !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	if !onlyUnits(result) {
+		t.Error("fortifying needed a new game state")
+	}
+	if got := broadcastUpdates(t, h); len(got) != 1 || got[0] != UpdateUnitsChanged {
+		t.Errorf("fortifying broadcast updates %v, want %s", got, UpdateUnitsChanged)
+	}
+
+	result = h.submit("alice", &game.FoundCityAction{SettlerID: settler.ID, CityName: "Gamma"})
+	if !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	if onlyUnits(result) {
+		t.Error("founding a city sent no new game state")
+	}
+	if got, want := broadcastUpdates(t, h), []string{UpdateCityFounded, UpdateLogEntry}; !slices.Equal(got, want) {
+		t.Errorf("founding broadcast updates %v, want %v", got, want)
+	}
+	if len(h.published) > 0 {
+		t.Errorf("events %v left after the action", h.published)
+	}
+}
+
+// TestCityNotices checks that a city finishing a building and growing
+// with a granary is told to its owner alone
+func TestCityNotices(t *testing.T) {
+	alice := newTestClient(t, "alice")
+	h := alice.hub
+	bob := &Client{hub: h, send: make(chan []byte, 256), playerID: "bob"}
+	h.clients[alice] = true
+	h.clients[bob] = true
+
+	city := h.game.GetPlayer("alice").Cities[0]
+	city.AddBuilding(game.BuildingGranary)
+	city.CurrentBuild = &game.BuildItem{Building: game.BuildingBarracks}
+	city.Production = city.CurrentBuild.CostAt(h.game.Config.Speed)
+	city.FoodStore = city.FoodNeededForGrowth(h.game.Config.Speed)
+
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	updates := byType(sent[rawUpdate](t, alice, MsgTypeUpdate))
+	var completed game.CompletedReport
+	if err := json.Unmarshal(updates[UpdateProductionCompleted], &completed); err != nil {
+		t.Fatal(err)
+	}
+	if want := (game.CompletedReport{CityID: city.ID, CityName: "Alpha", Item: "Barracks"}); completed != want {
+		t.Errorf("completed %+v, want %+v", completed, want)
+	}
+	var grew game.CityReport
+	if err := json.Unmarshal(updates[UpdateCityGrew], &grew); err != nil {
+		t.Fatal(err)
+	}
+	if grew.Population != 3 || grew.Granary == 0 || grew.Granary != city.FoodStore {
+		t.Errorf("grew %+v, want size 3 with the granary keeping %d food", grew, city.FoodStore)
+	}
+	if updates[UpdateLogEntry] == nil {
+		t.Error("alice was not sent the game log entries of Alpha")
+	}
+	if updates := sent[rawUpdate](t, bob, MsgTypeUpdate); len(updates) > 0 {
+		t.Errorf("bob was told of alice's city: %v", byType(updates))
+	}
+}
+
+// TestResourcesRevealed checks that iron is left out of what players are
+// sent until they discover Bronze Working, and that the player who does
+// is sent where it lies
+func TestResourcesRevealed(t *testing.T) {
+	alice := newTestClient(t, "alice")
+	h := alice.hub
+	bob := &Client{hub: h, send: make(chan []byte, 256), playerID: "bob"}
+	h.clients[alice] = true
+	h.clients[bob] = true
+
+	iron := h.game.Map.GetTile(2, 1)
+	iron.Resource = game.ResourceIron
+	player := h.game.GetPlayer("alice")
+	player.Science = game.TechCost[game.TechBronzeWorking] - 1
+	player.TaxRate = 0
+
+	ironSent := func(c *Client, perClient map[*Client][][]byte, state GameStateMessage) bool {
+		if messages, ok := perClient[c]; ok {
+			var msg WSMessage
+			if err := json.Unmarshal(messages[0], &msg); err != nil {
+				t.Fatal(err)
+			}
+			state = GameStateMessage{}
+			if err := json.Unmarshal(msg.Payload, &state); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return state.Map.Tiles[h.game.Map.Index(2, 1)].Resource == game.ResourceIron.String()
+	}
+	state := ClientStateToDTO(h.game)
+	perClient := h.clientMessages(state)
+	if ironSent(alice, perClient, state) || ironSent(bob, perClient, state) {
+		t.Error("iron sent before Bronze Working")
+	}
+	chunk, err := alice.queryMapChunk(json.RawMessage(`{"chunk_x": 0, "chunk_y": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunk.(MapChunkMessage).Tiles[h.game.Map.Index(2, 1)].Resource != "" {
+		t.Error("iron sent in a map chunk before Bronze Working")
+	}
+
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	var revealed ResourcesRevealedDTO
+	if err := json.Unmarshal(byType(sent[rawUpdate](t, alice, MsgTypeUpdate))[UpdateResourcesRevealed], &revealed); err != nil {
+		t.Fatal(err)
+	}
+	if revealed.Tech != "Bronze Working" || len(revealed.Tiles) != 1 || revealed.Tiles[0] != TileToDTO(iron) {
+		t.Errorf("revealed %+v, want the iron at (2, 1)", revealed)
+	}
+	if _, ok := byType(sent[rawUpdate](t, bob, MsgTypeUpdate))[UpdateResourcesRevealed]; ok {
+		t.Error("bob was told of alice's discovery")
+	}
+
+	state = ClientStateToDTO(h.game)
+	perClient = h.clientMessages(state)
+	if !ironSent(alice, perClient, state) || ironSent(bob, perClient, state) {
+		t.Error("after Bronze Working iron is not sent to alice alone")
+	}
+}
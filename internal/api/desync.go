@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"civilization/internal/game"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Every event is recorded with the hash of the game's state after it, and
+// clients are sent it in the event message. Clients cannot hash the game
+// themselves, holding only what they are sent of it, so an event that
+// changed nothing but units, told of without a new game state, is sent
+// with the hash of each player's units too. A client whose own units hash
+// differently has missed or misapplied an update: it sends a resync
+// message with the units it holds, and is sent the game afresh while the
+// server logs how its units differ from the game's.
+//
+// Replays check events against their hashes as well. A save whose event
+// log no longer replays to the game it records still loads, from its state
+// rather than its log, and how the replay differs is logged.
+
+// maxLoggedDifferences limits the differences logged of a desync
+const maxLoggedDifferences = 20
+
+// EventMessage is the payload of an event message: the event an action was
+// recorded as, with the hash of each player's units after it when it
+// changed nothing but units
+type EventMessage struct {
+	game.Event
+	UnitHashes map[string]string `json:"unit_hashes,omitempty"`
+}
+
+// ResyncMessage is sent by a client whose units do not hash as an event
+// said they would, to be sent the game afresh
+type ResyncMessage struct {
+	Seq       uint64    `json:"seq"`        // The event the client found itself out of sync at
+	UnitsHash string    `json:"units_hash"` // Hash of its player's units as it holds them
+	Units     []UnitDTO `json:"units"`      // Its player's units as it holds them
+}
+
+// UnitsHash returns the hash of a player's units as clients are sent them,
+// in any order
+func UnitsHash(units []UnitDTO) string {
+	sorted := slices.Clone(units)
+	slices.SortFunc(sorted, func(a, b UnitDTO) int { return strings.Compare(a.ID, b.ID) })
+
+	h := fnv.New64a()
+	enc := json.NewEncoder(h)
+	for _, u := range sorted {
+		enc.Encode(u)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// playerUnits returns a player's units as clients are sent them
+func playerUnits(p *game.Player) []UnitDTO {
+	units := make([]UnitDTO, len(p.Units))
+	for i, u := range p.Units {
+		units[i] = UnitToDTO(u)
+	}
+	return units
+}
+
+// unitHashes returns the hash of each player's units. Callers must hold
+// the game lock.
+func (h *Hub) unitHashes() map[string]string {
+	hashes := make(map[string]string, len(h.game.Players))
+	for _, p := range h.game.Players {
+		hashes[p.ID] = UnitsHash(playerUnits(p))
+	}
+	return hashes
+}
+
+// unitDesync is a unit a client holds differently from the game, or holds
+// and the game has not or the other way round
+type unitDesync struct {
+	ID     string   `json:"id"`
+	Client *UnitDTO `json:"client,omitempty"`
+	Server *UnitDTO `json:"server,omitempty"`
+}
+
+// desyncReport is what is logged of a client out of sync with the game
+type desyncReport struct {
+	GameID     string       `json:"game_id"`
+	PlayerID   string       `json:"player_id"`
+	ClientSeq  uint64       `json:"client_seq"`
+	Seq        uint64       `json:"seq"`
+	ClientHash string       `json:"client_hash"`
+	Hash       string       `json:"hash"`
+	Units      []unitDesync `json:"units"`
+}
+
+// handleResync logs how the units of a client out of sync differ from the
+// game's, and sends it the game afresh
+func (c *Client) handleResync(payload json.RawMessage) {
+	var msg ResyncMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		c.sendError(CodeInvalidMessage, err.Error())
+		return
+	}
+
+	h := c.hub
+	h.gameMu.RLock()
+	report := desyncReport{GameID: h.game.ID, PlayerID: c.playerID, ClientSeq: msg.Seq, Seq: h.game.Seq, ClientHash: msg.UnitsHash}
+	if p := h.game.GetPlayer(c.playerID); p != nil {
+		units := playerUnits(p)
+		report.Hash = UnitsHash(units)
+		report.Units = diffUnits(msg.Units, units)
+	}
+	h.gameMu.RUnlock()
+
+	data, _ := json.Marshal(report)
+	log.Printf("Client of %s out of sync in game %s, resyncing: %s", c.playerID, report.GameID, data)
+	h.resync(c)
+}
+
+// diffUnits returns the units a client holds differently from the game
+func diffUnits(held, units []UnitDTO) []unitDesync {
+	byID := make(map[string]*UnitDTO, len(units))
+	for i := range units {
+		byID[units[i].ID] = &units[i]
+	}
+
+	var diffs []unitDesync
+	for i := range held {
+		u := &held[i]
+		server, ok := byID[u.ID]
+		delete(byID, u.ID)
+		if !ok || !sameUnit(u, server) {
+			diffs = append(diffs, unitDesync{ID: u.ID, Client: u, Server: server})
+		}
+	}
+	for i := range units {
+		if u := &units[i]; byID[u.ID] != nil {
+			diffs = append(diffs, unitDesync{ID: u.ID, Server: u})
+		}
+	}
+	return diffs
+}
+
+// sameUnit reports whether two units are sent to clients alike
+func sameUnit(a, b *UnitDTO) bool {
+	dataA, _ := json.Marshal(a)
+	dataB, _ := json.Marshal(b)
+	return bytes.Equal(dataA, dataB)
+}
+
+// resync sends a client the game afresh: the game state, or for a client
+// subscribed to a viewport everything in it
+func (h *Hub) resync(c *Client) {
+	h.gameMu.RLock()
+	state := ClientStateToDTO(h.game)
+
+	var messages [][]byte
+	h.mu.Lock()
+	if c.view != nil {
+		c.view.tiles, c.view.minimap = make(map[int]TileDTO), nil
+		messages = c.view.messages(h.game, c.playerID, state)
+	} else {
+		messages = [][]byte{encodeMessage(MsgTypeGameState, playerState(h.game, c.playerID, state))}
+	}
+	h.mu.Unlock()
+	h.gameMu.RUnlock()
+
+	h.queue(outbound{perClient: map[*Client][][]byte{c: messages}, state: true})
+}
+
+// logReplayDesync logs how a save's game differs from its event log's
+// replay. Both are compared as saved, so what saves leave out does not
+// show.
+func logReplayDesync(dto *GameStateMessage, desync *game.DesyncError) {
+	replayed := SaveToDTO(desync.Game)
+	diffs := game.Diff(DTOToGameState(dto), DTOToGameState(&replayed))
+	log.Printf("Game %s: %v; the save is loaded as saved, and its replay differs in %d fields", dto.ID, desync, len(diffs))
+	for i, d := range diffs {
+		if i == maxLoggedDifferences {
+			log.Printf("  ...")
+			break
+		}
+		log.Printf("  %s", d)
+	}
+}
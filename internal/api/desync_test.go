@@ -0,0 +1,61 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"testing"
+)
+
+// TestResync checks that an event changing only units is sent with the
+// hash of each player's units, and that a client finding its own out of
+// sync is sent the game afresh
+func TestResync(t *testing.T) {
+	c := newTestClient(t, "alice")
+	h := c.hub
+	h.clients[c] = true
+	bob := &Client{hub: h, send: make(chan []byte, 256), playerID: "bob"}
+	h.clients[bob] = true
+	alice := h.game.GetPlayer("alice")
+
+	result := h.submit("alice", &game.FortifyAction{UnitID: alice.Units[0].ID})
+	if !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	h.BroadcastEvent(result)
+
+	var event, told EventMessage
+	for event.Seq == 0 {
+		out := <-h.broadcast
+		var msg WSMessage
+		if len(out.perClient[c]) == 0 || json.Unmarshal(out.perClient[c][0], &msg) != nil || msg.Type != MsgTypeEvent {
+			continue
+		}
+		json.Unmarshal(msg.Payload, &event)
+		json.Unmarshal(out.perClient[bob][0], &msg)
+		json.Unmarshal(msg.Payload, &told)
+	}
+	units := playerUnits(alice)
+	if want := UnitsHash(units); event.UnitHashes["alice"] != want || event.Hash != h.game.Hash() || event.Data == nil {
+		t.Errorf("event sent with units hash %q, hash %q and data %s, want %q, %q and the action", event.UnitHashes["alice"], event.Hash, event.Data, want, h.game.Hash())
+	}
+
+	// Bob is told where the game is, not what alice's unit did
+	if told.Seq != event.Seq || told.Hash != event.Hash || string(told.Data) != "null" || told.Result != nil {
+		t.Errorf("other player was sent %+v, want only the sequence number and hash", told.Event)
+	}
+	if want := UnitsHash(playerUnits(h.game.GetPlayer("bob"))); len(told.UnitHashes) != 1 || told.UnitHashes["bob"] != want {
+		t.Errorf("other player was sent units hashes %v, want their own, %q", told.UnitHashes, want)
+	}
+
+	held := append([]UnitDTO(nil), units...)
+	held[1].X++
+	payload, _ := json.Marshal(ResyncMessage{Seq: event.Seq, UnitsHash: UnitsHash(held), Units: held})
+	c.handleResync(payload)
+	deliver(h, c)
+	if states := sent[GameStateMessage](t, c, MsgTypeGameState); len(states) != 1 {
+		t.Errorf("client out of sync was sent %d game states, want 1", len(states))
+	}
+	if diffs := diffUnits(held, units); len(diffs) != 1 || diffs[0].ID != held[1].ID {
+		t.Errorf("units out of sync found: %+v", diffs)
+	}
+}
@@ -0,0 +1,345 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Several servers can serve the same games behind a load balancer, as
+// instances sharing the games directory. An instance keeps no game of its
+// own: its engine loads the games requests name from the directory when
+// first asked for one, and unloads them once no one has played for a while,
+// so any instance can pick a game up where another left it. Every game it
+// plays is written to the directory after each action, as async games are.
+//
+// The balancer routes a game's requests to one instance at a time by the
+// game's ID, found in the "game" query parameter or the yac_game cookie.
+// A lease file beside each game loaded names the instance playing it, so
+// another one asked for it refuses rather than play it alongside; it is
+// sticky routing, not the lease, that keeps two instances from loading a
+// game at the same moment.
+
+const (
+	GameParam  = "game"     // Query parameter naming the game of a request
+	GameCookie = "yac_game" // Cookie naming the game of a request without one
+)
+
+// LeaseTTL is how long an instance's claim on a game lasts unless it is
+// renewed. Instances renew theirs three times as often, so a game is free
+// again this long after its instance stopped.
+var LeaseTTL = 30 * time.Second
+
+// EngineIdleTimeout is how long a game nobody is connected to is kept
+// loaded
+var EngineIdleTimeout = 5 * time.Minute
+
+// ErrNoSuchGame is returned for a game not kept in the games directory
+var ErrNoSuchGame = errors.New("no such game")
+
+// LeasedError is returned for a game another instance is playing. Requests
+// for it are answered 409 with the instance in the X-Yac-Instance header,
+// for the balancer to route them there.
+type LeasedError struct {
+	GameID   string
+	Instance string
+}
+
+func (e *LeasedError) Error() string {
+	return fmt.Sprintf("game %s is played on instance %s", e.GameID, e.Instance)
+}
+
+// lease is an instance's claim on a game, kept as <id>.lease in the games
+// directory
+type lease struct {
+	Instance string    `json:"instance"`
+	Expires  time.Time `json:"expires"`
+}
+
+// hubStorage is where a hub keeps its game
+type hubStorage struct {
+	savesPath string    // Replays and stats, each turn
+	store     *SQLStore // Turn history, if set
+	gamesPath string    // The game itself, if it is kept
+	notifier  *Notifier // Tells the players of a kept game it is their turn
+
+	preferences *PreferenceStore // Players' preferences, by profile
+}
+
+// launchHub starts a hub playing g. A kept game is written to storage from
+// here on; record restores one resumed.
+func launchHub(g *game.GameState, storage hubStorage, keep bool, record *AsyncRecord) (*Hub, error) {
+	hub := NewHub(g)
+	hub.savesPath = storage.savesPath
+	hub.store = storage.store
+	hub.preferences = storage.preferences
+	hub.record()
+	go hub.Run()
+
+	if keep {
+		return hub, hub.EnableAsync(storage.gamesPath, storage.notifier, record)
+	}
+	return hub, nil
+}
+
+// engine plays the games of an instance
+type engine struct {
+	instance string
+	storage  hubStorage
+
+	mu    sync.Mutex
+	games map[string]*engineGame
+	done  chan struct{}
+}
+
+// engineGame is a game an engine has loaded
+type engineGame struct {
+	hub       *Hub
+	idleSince time.Time // When its last client left; zero while it has clients
+}
+
+// newEngine creates an engine playing the games kept in storage as the
+// named instance
+func newEngine(instance string, storage hubStorage) (*engine, error) {
+	if err := os.MkdirAll(storage.gamesPath, 0755); err != nil {
+		return nil, err
+	}
+	return &engine{
+		instance: instance,
+		storage:  storage,
+		games:    make(map[string]*engineGame),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// StartInstance makes the server one instance of several sharing its
+// GamesPath, playing whichever of the games kept there requests name
+func (s *Server) StartInstance(instance string) error {
+	e, err := newEngine(instance, s.hubStorage())
+	if err != nil {
+		return err
+	}
+	s.engine = e
+	go e.run()
+	return nil
+}
+
+// hub returns the hub playing a game, loading the game if the engine has
+// not yet
+func (e *engine) hub(id string) (*Hub, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if loaded := e.games[id]; loaded != nil {
+		return loaded.hub, nil
+	}
+	if !validFileName(id) {
+		return nil, ErrNoSuchGame
+	}
+
+	record, err := LoadAsyncRecord(filepath.Join(e.storage.gamesPath, id+".json"))
+	if os.IsNotExist(err) {
+		return nil, ErrNoSuchGame
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.acquire(id); err != nil {
+		return nil, err
+	}
+
+	hub, err := launchHub(DTOToGameState(&record.Game), e.storage, true, record)
+	if err != nil {
+		hub.Close()
+		e.release(id)
+		return nil, err
+	}
+	e.games[id] = &engineGame{hub: hub}
+	log.Printf("Loaded game %s", id)
+	return hub, nil
+}
+
+// start plays a new game, keeping it from now on
+func (e *engine) start(g *game.GameState) (*Hub, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.acquire(g.ID); err != nil {
+		return nil, err
+	}
+	hub, err := launchHub(g, e.storage, true, nil)
+	if err != nil {
+		hub.Close()
+		e.release(g.ID)
+		return nil, err
+	}
+	e.games[g.ID] = &engineGame{hub: hub}
+	return hub, nil
+}
+
+// hubs returns the hubs of the games loaded
+func (e *engine) hubs() []*Hub {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	hubs := make([]*Hub, 0, len(e.games))
+	for _, loaded := range e.games {
+		hubs = append(hubs, loaded.hub)
+	}
+	return hubs
+}
+
+// run renews the engine's leases and unloads idle games until it is closed
+func (e *engine) run() {
+	ticker := time.NewTicker(LeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			e.maintain(now)
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// maintain unloads the games nobody has been connected to for
+// EngineIdleTimeout, and renews the leases of the rest
+func (e *engine) maintain(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for id, loaded := range e.games {
+		hub := loaded.hub
+		hub.mu.RLock()
+		busy := len(hub.clients) > 0 || hub.aiRunning
+		hub.mu.RUnlock()
+
+		switch {
+		case busy:
+			loaded.idleSince = time.Time{}
+		case loaded.idleSince.IsZero():
+			loaded.idleSince = now
+		case now.Sub(loaded.idleSince) >= EngineIdleTimeout:
+			e.unload(id)
+			continue
+		}
+		if err := e.renew(id); err != nil {
+			log.Printf("Renewing the lease of game %s: %v", id, err)
+		}
+	}
+}
+
+// unload saves a game and stops playing it. Callers must hold e.mu.
+func (e *engine) unload(id string) {
+	hub := e.games[id].hub
+	hub.save()
+	hub.Close()
+	e.release(id)
+	delete(e.games, id)
+	log.Printf("Unloaded game %s", id)
+}
+
+// remove stops playing a game without saving it, to delete it
+func (e *engine) remove(id string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if loaded := e.games[id]; loaded != nil {
+		loaded.hub.Close()
+		e.release(id)
+		delete(e.games, id)
+	}
+}
+
+// close unloads every game, freeing them for other instances, and stops
+// the engine
+func (e *engine) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for id := range e.games {
+		e.unload(id)
+	}
+	close(e.done)
+}
+
+// leasePath returns the file a game's lease is kept in
+func (e *engine) leasePath(id string) string {
+	return filepath.Join(e.storage.gamesPath, id+".lease")
+}
+
+// acquire claims a game for the instance, unless another instance holds a
+// lease on it that has not expired
+func (e *engine) acquire(id string) error {
+	data, err := os.ReadFile(e.leasePath(id))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		var held lease
+		if json.Unmarshal(data, &held) == nil && held.Instance != e.instance && time.Now().Before(held.Expires) {
+			return &LeasedError{GameID: id, Instance: held.Instance}
+		}
+	}
+	return e.renew(id)
+}
+
+// renew extends the instance's lease on a game
+func (e *engine) renew(id string) error {
+	data, err := json.Marshal(lease{Instance: e.instance, Expires: time.Now().Add(LeaseTTL)})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(e.leasePath(id), data)
+}
+
+// release gives up the instance's lease on a game
+func (e *engine) release(id string) {
+	if err := os.Remove(e.leasePath(id)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Releasing the lease of game %s: %v", id, err)
+	}
+}
+
+// requestHub returns the hub of the game a request is for: the game it
+// names on an instance, or the server's game otherwise. Requests it cannot
+// be found for are answered with an error.
+func (s *Server) requestHub(w http.ResponseWriter, r *http.Request) *Hub {
+	if s.engine == nil {
+		if s.hub == nil {
+			http.Error(w, "No game in progress", http.StatusNotFound)
+		}
+		return s.hub
+	}
+
+	id := r.URL.Query().Get(GameParam)
+	if id == "" {
+		if cookie, err := r.Cookie(GameCookie); err == nil {
+			id = cookie.Value
+		}
+	}
+	if id == "" {
+		http.Error(w, "No game named", http.StatusNotFound)
+		return nil
+	}
+
+	hub, err := s.engine.hub(id)
+	var leased *LeasedError
+	switch {
+	case errors.As(err, &leased):
+		w.Header().Set("X-Yac-Instance", leased.Instance)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil
+	case errors.Is(err, ErrNoSuchGame):
+		http.Error(w, "No such game", http.StatusNotFound)
+		return nil
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+	return hub
+}
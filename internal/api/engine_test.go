@@ -0,0 +1,140 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newEngineGame returns a small game for engines to play
+func newEngineGame(tb testing.TB) *game.GameState {
+	b := gametest.New(tb,
+		"~~~~~~",
+		"~gggg~",
+		"~gggg~",
+		"~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.Unit("bob", game.UnitWarrior, 4, 2)
+	return b.Start()
+}
+
+// TestEngineHandsOver checks that a game is played by one instance at a
+// time, and picked up by another from storage once the first unloads it
+func TestEngineHandsOver(t *testing.T) {
+	dir := t.TempDir()
+	a, err := newEngine("a", hubStorage{gamesPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newEngine("b", hubStorage{gamesPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.close()
+
+	g := newEngineGame(t)
+	hub, err := a.start(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := hub.submit("alice", &game.FortifyAction{UnitID: g.Players[0].Units[0].ID}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	var leased *LeasedError
+	if _, err := b.hub(g.ID); !errors.As(err, &leased) || leased.Instance != "a" {
+		t.Fatalf("loading a game played elsewhere: %v, want it leased to a", err)
+	}
+	if _, err := b.hub("missing"); !errors.Is(err, ErrNoSuchGame) {
+		t.Errorf("loading a missing game: %v, want ErrNoSuchGame", err)
+	}
+
+	// Unloaded once idle for long enough, and not before
+	now := time.Now()
+	a.maintain(now)
+	a.maintain(now.Add(EngineIdleTimeout - time.Second))
+	if len(a.hubs()) != 1 {
+		t.Fatal("game unloaded before it was idle for long")
+	}
+	a.maintain(now.Add(EngineIdleTimeout))
+	if len(a.hubs()) != 0 {
+		t.Fatal("idle game still loaded")
+	}
+	if _, err := os.Stat(filepath.Join(dir, g.ID+".lease")); !os.IsNotExist(err) {
+		t.Errorf("lease kept after unloading: %v", err)
+	}
+	a.close()
+
+	picked, err := b.hub(g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if picked.game.Seq != g.Seq || !picked.game.Players[0].Units[0].IsFortified {
+		t.Errorf("game picked up at seq %d, want %d with the warrior fortified", picked.game.Seq, g.Seq)
+	}
+}
+
+// TestInstanceRequests checks that an instance serves the game a request
+// names by parameter or cookie, and sends requests for a game another
+// instance plays there
+func TestInstanceRequests(t *testing.T) {
+	dir := t.TempDir()
+	s := &Server{GamesPath: dir}
+	e, err := newEngine("a", s.hubStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.engine = e
+	defer e.close()
+
+	g := newEngineGame(t)
+	if _, err := e.start(g); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(target string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, r)
+		return w
+	}
+	gameID := func(w *httptest.ResponseRecorder) string {
+		var state GameStateMessage
+		json.Unmarshal(w.Body.Bytes(), &state)
+		return state.ID
+	}
+
+	if w := get("/api/game?game="+g.ID, nil); w.Code != http.StatusOK || gameID(w) != g.ID {
+		t.Errorf("game by parameter answered %d with %q", w.Code, gameID(w))
+	}
+	if w := get("/api/game", &http.Cookie{Name: GameCookie, Value: g.ID}); w.Code != http.StatusOK || gameID(w) != g.ID {
+		t.Errorf("game by cookie answered %d with %q", w.Code, gameID(w))
+	}
+	if w := get("/api/game", nil); w.Code != http.StatusNotFound {
+		t.Errorf("request naming no game answered %d, want 404", w.Code)
+	}
+
+	other := newEngineGame(t)
+	other.ID = "other"
+	data, _ := json.Marshal(AsyncRecord{Game: SaveToDTO(other)})
+	os.WriteFile(filepath.Join(dir, "other.json"), data, 0644)
+	data, _ = json.Marshal(lease{Instance: "b", Expires: time.Now().Add(time.Minute)})
+	os.WriteFile(filepath.Join(dir, "other.lease"), data, 0644)
+	w := get("/api/game?game=other", nil)
+	if w.Code != http.StatusConflict || w.Header().Get("X-Yac-Instance") != "b" {
+		t.Errorf("game played by b answered %d with instance %q", w.Code, w.Header().Get("X-Yac-Instance"))
+	}
+}
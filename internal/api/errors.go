@@ -0,0 +1,235 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/locale"
+	"errors"
+)
+
+// ErrorCode says what went wrong in a way clients can rely on, e.g. to show
+// the error in the player's language. A code never changes meaning; new
+// codes may be added.
+type ErrorCode string
+
+// Errors of messages and requests
+const (
+	CodeInvalidMessage     ErrorCode = "invalid_message"
+	CodeUnknownMessage     ErrorCode = "unknown_message"
+	CodeInvalidQuery       ErrorCode = "invalid_query"
+	CodeUnknownQuery       ErrorCode = "unknown_query"
+	CodeInvalidNotify      ErrorCode = "invalid_notify"
+	CodeInvalidPreferences ErrorCode = "invalid_preferences"
+	CodeNotHost            ErrorCode = "not_host"
+	CodeHostCommand        ErrorCode = "host_command"
+	CodeGamePaused         ErrorCode = "game_paused"
+	CodeGameFailed         ErrorCode = "game_failed"
+)
+
+// Errors of actions
+const (
+	CodeInvalidAction       ErrorCode = "invalid_action" // Refused for a reason without a code of its own
+	CodeUnknownAction       ErrorCode = "unknown_action"
+	CodeInvalidPayload      ErrorCode = "invalid_payload"
+	CodeGameNotStarted      ErrorCode = "game_not_started"
+	CodeGameOver            ErrorCode = "game_over"
+	CodeNotYourTurn         ErrorCode = "not_your_turn"
+	CodePlayerNotFound      ErrorCode = "player_not_found"
+	CodeUnitNotFound        ErrorCode = "unit_not_found"
+	CodeNotYourUnit         ErrorCode = "not_your_unit"
+	CodeCityNotFound        ErrorCode = "city_not_found"
+	CodeNotYourCity         ErrorCode = "not_your_city"
+	CodeGroupNotFound       ErrorCode = "group_not_found"
+	CodeNoMovementLeft      ErrorCode = "no_movement_left"
+	CodeInvalidMove         ErrorCode = "invalid_move"
+	CodeInvalidTarget       ErrorCode = "invalid_target"
+	CodeInvalidTile         ErrorCode = "invalid_tile"
+	CodeCannotFoundCity     ErrorCode = "cannot_found_city"
+	CodeProductionRequired  ErrorCode = "production_required"
+	CodeBuildingExists      ErrorCode = "building_exists"
+	CodeWonderBuilt         ErrorCode = "wonder_built"
+	CodeRequiresWonder      ErrorCode = "requires_wonder"
+	CodeCannotHelpWonder    ErrorCode = "cannot_help_wonder"
+	CodeNoWonderHere        ErrorCode = "no_wonder_here"
+	CodeCannotFortify       ErrorCode = "cannot_fortify"
+	CodeAlreadyAwake        ErrorCode = "already_awake"
+	CodeCannotBuildRoad     ErrorCode = "cannot_build_road"
+	CodeRoadExists          ErrorCode = "road_exists"
+	CodeCannotBuildRoadHere ErrorCode = "cannot_build_road_here"
+	CodeUnknownMode         ErrorCode = "unknown_mode"
+	CodeUsePatrol           ErrorCode = "use_patrol"
+	CodePatrolTooShort      ErrorCode = "patrol_too_short"
+	CodeWaypointUnreachable ErrorCode = "waypoint_unreachable"
+	CodeRepeatedWaypoint    ErrorCode = "repeated_waypoint"
+	CodeGroupTooSmall       ErrorCode = "group_too_small"
+	CodeUnitListedTwice     ErrorCode = "unit_listed_twice"
+	CodeNotStacked          ErrorCode = "not_stacked"
+	CodeAlreadyInGroup      ErrorCode = "already_in_group"
+	CodeInvalidCityName     ErrorCode = "invalid_city_name"
+	CodeCityNameTaken       ErrorCode = "city_name_taken"
+	CodeInvalidUnitName     ErrorCode = "invalid_unit_name"
+	CodeNotCoastal          ErrorCode = "not_coastal"
+	CodeUnknownDeal         ErrorCode = "unknown_deal"
+	CodeNotYourDeal         ErrorCode = "not_your_deal"
+	CodeInvalidPartner      ErrorCode = "invalid_partner"
+	CodeDealOffered         ErrorCode = "deal_offered"
+	CodeNoOffer             ErrorCode = "no_offer"
+	CodeAlreadySharing      ErrorCode = "already_sharing"
+	CodeNotSharing          ErrorCode = "not_sharing"
+	CodeNotLuxury           ErrorCode = "not_luxury"
+	CodeNoSpareLuxury       ErrorCode = "no_spare_luxury"
+	CodeCannotTerraform     ErrorCode = "cannot_terraform"
+	CodeUnknownJob          ErrorCode = "unknown_job"
+	CodeCannotWorkHere      ErrorCode = "cannot_work_here"
+	CodeInvalidTaxRate      ErrorCode = "invalid_tax_rate"
+	CodeNotYourTaxes        ErrorCode = "not_your_taxes"
+	CodeNotYourPoints       ErrorCode = "not_your_points"
+	CodeNoStartPoints       ErrorCode = "no_start_points"
+	CodeNotEnoughPoints     ErrorCode = "not_enough_points"
+	CodeUnknownPurchase     ErrorCode = "unknown_purchase"
+	CodeOutOfStartReach     ErrorCode = "out_of_start_reach"
+	CodeAllTechsKnown       ErrorCode = "all_techs_known"
+	CodeNotBuildable        ErrorCode = "not_buildable"
+	CodeNuclearOnly         ErrorCode = "nuclear_only"
+	CodeNotNuclear          ErrorCode = "not_nuclear"
+	CodeCannotBombard       ErrorCode = "cannot_bombard"
+	CodeReloading           ErrorCode = "reloading"
+	CodeCityStruck          ErrorCode = "city_struck"
+	CodePlanOrders          ErrorCode = "plan_orders"
+	CodeNotSimultaneous     ErrorCode = "not_simultaneous"
+	CodeSubmitOrders        ErrorCode = "submit_orders"
+	CodeNotYourOrders       ErrorCode = "not_your_orders"
+	CodeOrdersSubmitted     ErrorCode = "orders_submitted"
+	CodeUnknownOrder        ErrorCode = "unknown_order"
+)
+
+// actionErrorCodes gives the code of each error an action can be refused with
+var actionErrorCodes = map[error]ErrorCode{
+	game.ErrUnknownAction:       CodeUnknownAction,
+	game.ErrGameNotStarted:      CodeGameNotStarted,
+	game.ErrGameOver:            CodeGameOver,
+	game.ErrNotYourTurn:         CodeNotYourTurn,
+	game.ErrPlayerNotFound:      CodePlayerNotFound,
+	game.ErrUnitNotFound:        CodeUnitNotFound,
+	game.ErrNotYourUnit:         CodeNotYourUnit,
+	game.ErrCityNotFound:        CodeCityNotFound,
+	game.ErrNotYourCity:         CodeNotYourCity,
+	game.ErrGroupNotFound:       CodeGroupNotFound,
+	game.ErrNoMovementLeft:      CodeNoMovementLeft,
+	game.ErrInvalidMove:         CodeInvalidMove,
+	game.ErrInvalidTarget:       CodeInvalidTarget,
+	game.ErrInvalidTile:         CodeInvalidTile,
+	game.ErrCannotFoundCity:     CodeCannotFoundCity,
+	game.ErrProductionRequired:  CodeProductionRequired,
+	game.ErrBuildingExists:      CodeBuildingExists,
+	game.ErrWonderBuilt:         CodeWonderBuilt,
+	game.ErrRequiresWonder:      CodeRequiresWonder,
+	game.ErrCannotHelpWonder:    CodeCannotHelpWonder,
+	game.ErrNoWonderHere:        CodeNoWonderHere,
+	game.ErrCannotFortify:       CodeCannotFortify,
+	game.ErrAlreadyAwake:        CodeAlreadyAwake,
+	game.ErrCannotBuildRoad:     CodeCannotBuildRoad,
+	game.ErrRoadExists:          CodeRoadExists,
+	game.ErrCannotBuildRoadHere: CodeCannotBuildRoadHere,
+	game.ErrUnknownMode:         CodeUnknownMode,
+	game.ErrUsePatrol:           CodeUsePatrol,
+	game.ErrPatrolTooShort:      CodePatrolTooShort,
+	game.ErrWaypointUnreachable: CodeWaypointUnreachable,
+	game.ErrRepeatedWaypoint:    CodeRepeatedWaypoint,
+	game.ErrGroupTooSmall:       CodeGroupTooSmall,
+	game.ErrUnitListedTwice:     CodeUnitListedTwice,
+	game.ErrNotStacked:          CodeNotStacked,
+	game.ErrAlreadyInGroup:      CodeAlreadyInGroup,
+	game.ErrInvalidCityName:     CodeInvalidCityName,
+	game.ErrCityNameTaken:       CodeCityNameTaken,
+	game.ErrInvalidUnitName:     CodeInvalidUnitName,
+	game.ErrNotCoastal:          CodeNotCoastal,
+	game.ErrUnknownDeal:         CodeUnknownDeal,
+	game.ErrNotYourDeal:         CodeNotYourDeal,
+	game.ErrInvalidPartner:      CodeInvalidPartner,
+	game.ErrDealOffered:         CodeDealOffered,
+	game.ErrNoOffer:             CodeNoOffer,
+	game.ErrAlreadySharing:      CodeAlreadySharing,
+	game.ErrNotSharing:          CodeNotSharing,
+	game.ErrNotLuxury:           CodeNotLuxury,
+	game.ErrNoSpareLuxury:       CodeNoSpareLuxury,
+	game.ErrCannotTerraform:     CodeCannotTerraform,
+	game.ErrUnknownJob:          CodeUnknownJob,
+	game.ErrCannotWorkHere:      CodeCannotWorkHere,
+	game.ErrInvalidTaxRate:      CodeInvalidTaxRate,
+	game.ErrNotYourTaxes:        CodeNotYourTaxes,
+	game.ErrNotYourPoints:       CodeNotYourPoints,
+	game.ErrNoStartPoints:       CodeNoStartPoints,
+	game.ErrNotEnoughPoints:     CodeNotEnoughPoints,
+	game.ErrUnknownPurchase:     CodeUnknownPurchase,
+	game.ErrOutOfStartReach:     CodeOutOfStartReach,
+	game.ErrAllTechsKnown:       CodeAllTechsKnown,
+	game.ErrNotBuildable:        CodeNotBuildable,
+	game.ErrNuclearOnly:         CodeNuclearOnly,
+	game.ErrNotNuclear:          CodeNotNuclear,
+	game.ErrCannotBombard:       CodeCannotBombard,
+	game.ErrReloading:           CodeReloading,
+	game.ErrCityStruck:          CodeCityStruck,
+	game.ErrPlanOrders:          CodePlanOrders,
+	game.ErrNotSimultaneous:     CodeNotSimultaneous,
+	game.ErrSubmitOrders:        CodeSubmitOrders,
+	game.ErrNotYourOrders:       CodeNotYourOrders,
+	game.ErrOrdersSubmitted:     CodeOrdersSubmitted,
+	game.ErrUnknownOrder:        CodeUnknownOrder,
+	ErrGameFailed:               CodeGameFailed,
+	ErrGamePaused:               CodeGamePaused,
+}
+
+// ActionErrorCode returns the code of the error an action was refused with
+func ActionErrorCode(err error) ErrorCode {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if code, ok := actionErrorCodes[e]; ok {
+			return code
+		}
+	}
+	return CodeInvalidAction
+}
+
+// ActionErrorToDTO converts the error an action was refused with to the
+// message sent to the client, in the language of a pack and with the
+// details the game gave
+func ActionErrorToDTO(err error, pack *locale.Pack) ErrorMessage {
+	code := ActionErrorCode(err)
+	msg := ErrorMessage{
+		Code:    code,
+		Message: err.Error(),
+	}
+
+	var actionErr *game.ActionError
+	if !errors.As(err, &actionErr) {
+		if text, ok := pack.Lookup("error." + string(code)); ok {
+			msg.Message = text
+		}
+		return msg
+	}
+
+	values := []string{
+		"unit_id", actionErr.UnitID,
+		"city_id", actionErr.CityID,
+		"group_id", actionErr.GroupID,
+		"terrain", actionErr.Terrain,
+		"item", actionErr.Item,
+	}
+	if text, ok := pack.Lookup("error."+string(code), values...); ok {
+		msg.Message = text
+	}
+
+	details := &ErrorDetails{
+		UnitID:  actionErr.UnitID,
+		CityID:  actionErr.CityID,
+		GroupID: actionErr.GroupID,
+		Tile:    actionErr.Tile,
+		Terrain: actionErr.Terrain,
+		Item:    actionErr.Item,
+	}
+	if actionErr.MovementRequired > 0 {
+		details.MovementLeft = &actionErr.MovementLeft
+		details.MovementRequired = actionErr.MovementRequired
+	}
+	msg.Details = details
+	return msg
+}
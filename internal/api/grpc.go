@@ -0,0 +1,201 @@
+package api
+
+import (
+	"bytes"
+	"civilization/pkg/yacpb"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The gRPC API serves the game protocol defined in proto/yac/v1 alongside
+// the WebSocket API. A gRPC client is a hub client like any other: its
+// stream stands in for the WebSocket connection, so it is seated, sent
+// messages and has its actions applied the same way.
+
+// errConnClosed is returned by writes to a gRPC stream that has ended
+var errConnClosed = errors.New("connection closed")
+
+// ServeGRPC serves the gRPC API on a listener until it is closed
+func (s *Server) ServeGRPC(listener net.Listener) error {
+	server := grpc.NewServer()
+	yacpb.RegisterGameServer(server, &gameService{server: s})
+	return server.Serve(listener)
+}
+
+// gameService implements the Game service on the server's current game
+type gameService struct {
+	yacpb.UnimplementedGameServer
+	server *Server
+}
+
+// Play seats the client and plays until the stream or the hub ends it
+func (gs *gameService) Play(stream yacpb.Game_PlayServer) error {
+	hub, err := gs.server.streamHub(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	conn := &grpcConn{stream: stream, done: make(chan struct{})}
+	client := hub.seat(conn, "")
+	conn.playerID = client.playerID
+	client.serve()
+
+	select {
+	case <-conn.done:
+	case <-stream.Context().Done():
+		conn.Close()
+	}
+	return conn.closeErr()
+}
+
+// streamHub returns the hub of the game a stream is for: on an instance,
+// the game its "game" metadata names, or the server's game otherwise
+func (s *Server) streamHub(ctx context.Context) (*Hub, error) {
+	if s.engine == nil {
+		if s.hub == nil {
+			return nil, status.Error(codes.Unavailable, "No game in progress")
+		}
+		return s.hub, nil
+	}
+
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if names := md.Get(GameParam); len(names) > 0 {
+			id = names[0]
+		}
+	}
+	hub, err := s.engine.hub(id)
+	var leased *LeasedError
+	switch {
+	case errors.As(err, &leased):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrNoSuchGame):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return hub, nil
+}
+
+// grpcConn carries a client's messages over a gRPC stream rather than a
+// WebSocket: the hub's messages are sent as ServerMessages, and the
+// ClientMessages received are handed to it as the JSON it reads. gRPC
+// keeps the connection alive itself, so pings and deadlines are ignored.
+type grpcConn struct {
+	stream   yacpb.Game_PlayServer
+	playerID string // Seat the client plays, whose orders SubmitOrders submits
+
+	mu     sync.Mutex // Guards sending, closed and reason
+	closed bool
+	reason []byte // Close message the hub ended the stream with, if any
+	done   chan struct{}
+}
+
+// ReadMessage receives the client's next message
+func (c *grpcConn) ReadMessage() (int, []byte, error) {
+	msg, err := c.stream.Recv()
+	if err != nil {
+		return 0, nil, err
+	}
+	return websocket.TextMessage, clientMessageJSON(msg, c.playerID), nil
+}
+
+// WriteMessage sends the client a message. A close message ends the
+// stream.
+func (c *grpcConn) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case websocket.CloseMessage:
+		return c.WriteControl(messageType, data, time.Time{})
+	case websocket.PingMessage, websocket.PongMessage:
+		return nil
+	}
+
+	msg, err := serverMessage(data)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errConnClosed
+	}
+	return c.stream.Send(msg)
+}
+
+// WriteControl keeps the reason of a close message to end the stream with
+func (c *grpcConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType == websocket.CloseMessage {
+		c.mu.Lock()
+		c.reason = data
+		c.mu.Unlock()
+		c.Close()
+	}
+	return nil
+}
+
+// NextWriter returns a writer sending what is written as one message once
+// it is closed
+func (c *grpcConn) NextWriter(messageType int) (io.WriteCloser, error) {
+	return &grpcWriter{conn: c, messageType: messageType}, nil
+}
+
+func (c *grpcConn) SetReadLimit(limit int64)                    {}
+func (c *grpcConn) SetReadDeadline(t time.Time) error           { return nil }
+func (c *grpcConn) SetWriteDeadline(t time.Time) error          { return nil }
+func (c *grpcConn) SetPongHandler(h func(appData string) error) {}
+
+// Close ends the stream. The client is unregistered once its read fails.
+func (c *grpcConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+// closeErr returns the status the stream ends with: the reason the hub
+// closed it for, or none when it was simply closed
+func (c *grpcConn) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.reason) < 2 {
+		return nil
+	}
+	code := binary.BigEndian.Uint16(c.reason)
+	text := string(c.reason[2:])
+	switch code {
+	case websocket.CloseNormalClosure, websocket.CloseGoingAway:
+		return nil
+	case websocket.ClosePolicyViolation:
+		return status.Error(codes.PermissionDenied, text)
+	}
+	return status.Error(codes.Aborted, text)
+}
+
+// grpcWriter gathers a message for a grpcConn
+type grpcWriter struct {
+	conn        *grpcConn
+	messageType int
+	buf         bytes.Buffer
+}
+
+func (w *grpcWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *grpcWriter) Close() error {
+	return w.conn.WriteMessage(w.messageType, w.buf.Bytes())
+}
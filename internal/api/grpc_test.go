@@ -0,0 +1,98 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"civilization/pkg/yacpb"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TestGRPCPlay checks that a gRPC client is seated, sent the game and has
+// its actions applied and refused like a WebSocket client
+func TestGRPCPlay(t *testing.T) {
+	b := gametest.New(t,
+		"~~~~~~",
+		"~gggg~",
+		"~gggg~",
+		"~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	warrior := b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.Unit("bob", game.UnitWarrior, 4, 2)
+
+	s := &Server{game: b.Start()}
+	if err := s.startHub(nil); err != nil {
+		t.Fatal(err)
+	}
+	defer s.hub.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.ServeGRPC(listener)
+	defer listener.Close()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := yacpb.NewGameClient(conn).Play(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// recvUntil returns the first message found wants
+	recvUntil := func(found func(*yacpb.ServerMessage) bool) *yacpb.ServerMessage {
+		t.Helper()
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("stream ended: %v", err)
+			}
+			if found(msg) {
+				return msg
+			}
+		}
+	}
+
+	welcome := recvUntil(func(m *yacpb.ServerMessage) bool { return m.GetWelcome() != nil })
+	if id := welcome.GetWelcome().PlayerId; id != "alice" {
+		t.Fatalf("seated as %q, want alice", id)
+	}
+	state := recvUntil(func(m *yacpb.ServerMessage) bool { return m.GetState() != nil }).GetState()
+	if state.Width != 6 || state.Height != 4 || len(state.Players) != 2 {
+		t.Fatalf("state is %dx%d with %d players", state.Width, state.Height, len(state.Players))
+	}
+
+	fortify := &yacpb.Action{Action: &yacpb.Action_Fortify{Fortify: &yacpb.Fortify{UnitId: warrior.ID}}}
+	if err := stream.Send(&yacpb.ClientMessage{Message: &yacpb.ClientMessage_Action{Action: fortify}}); err != nil {
+		t.Fatal(err)
+	}
+	changed := recvUntil(func(m *yacpb.ServerMessage) bool { return m.GetUnitsChanged() != nil }).GetUnitsChanged()
+	if len(changed.Units) != 1 || !changed.Units[0].IsFortified {
+		t.Errorf("units changed by fortifying: %v", changed.Units)
+	}
+	event := recvUntil(func(m *yacpb.ServerMessage) bool { return m.GetEvent() != nil }).GetEvent()
+	if event.Type != "fortify" || event.PlayerId != "alice" {
+		t.Errorf("fortifying was recorded as %s by %s", event.Type, event.PlayerId)
+	}
+
+	move := &yacpb.Action{Action: &yacpb.Action_MoveUnit{MoveUnit: &yacpb.MoveUnit{UnitId: "nobody", ToX: 2, ToY: 1}}}
+	if err := stream.Send(&yacpb.ClientMessage{Message: &yacpb.ClientMessage_Action{Action: move}}); err != nil {
+		t.Fatal(err)
+	}
+	if refused := recvUntil(func(m *yacpb.ServerMessage) bool { return m.GetError() != nil }).GetError(); refused.Code == "" {
+		t.Error("moving a missing unit was refused without a code")
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"civilization/pkg/yacpb"
+	"encoding/json"
+)
+
+// The hub speaks JSON, so messages to and from gRPC clients are converted
+// at the stream: ClientMessages into the WebSocket messages they stand for,
+// and the hub's messages into ServerMessages, typed where the protocol has
+// a type for them.
+
+// clientMessageJSON returns a client's message as the WebSocket message it
+// stands for. A message of no kind becomes one of no type, which the hub
+// refuses as unknown.
+func clientMessageJSON(msg *yacpb.ClientMessage, playerID string) []byte {
+	switch m := msg.Message.(type) {
+	case *yacpb.ClientMessage_Action:
+		if a, ok := m.Action.Action.(*yacpb.Action_Json); ok {
+			return encodeMessage(MsgTypeAction, ActionMessage{ActionType: a.Json.Type, Data: rawJSON(a.Json.Data)})
+		}
+		action := actionFromPB(m.Action, playerID)
+		if action == nil {
+			return encodeMessage(MsgTypeAction, ActionMessage{})
+		}
+		data, _ := json.Marshal(action)
+		return encodeMessage(MsgTypeAction, ActionMessage{ActionType: action.Type(), Data: data})
+	case *yacpb.ClientMessage_Query:
+		q := m.Query
+		return encodeMessage(MsgTypeQuery, QueryMessage{QueryType: q.QueryType, RequestID: q.RequestId, Data: rawJSON(q.Data)})
+	}
+	return encodeMessage("", nil)
+}
+
+// actionFromPB returns the game action of a typed action, or nil if it has
+// none
+func actionFromPB(a *yacpb.Action, playerID string) game.Action {
+	switch a := a.Action.(type) {
+	case *yacpb.Action_MoveUnit:
+		return &game.MoveUnitAction{UnitID: a.MoveUnit.UnitId, ToX: int(a.MoveUnit.ToX), ToY: int(a.MoveUnit.ToY)}
+	case *yacpb.Action_Attack:
+		return &game.AttackAction{AttackerID: a.Attack.AttackerId, TargetX: int(a.Attack.TargetX), TargetY: int(a.Attack.TargetY)}
+	case *yacpb.Action_Fortify:
+		return &game.FortifyAction{UnitID: a.Fortify.UnitId}
+	case *yacpb.Action_FoundCity:
+		return &game.FoundCityAction{SettlerID: a.FoundCity.SettlerId, CityName: a.FoundCity.CityName}
+	case *yacpb.Action_EndTurn:
+		return &game.EndTurnAction{}
+	case *yacpb.Action_SetProduction:
+		p := a.SetProduction
+		return &game.SetProductionAction{CityID: p.CityId, BuildItem: game.BuildItem{
+			IsUnit:   p.IsUnit,
+			UnitType: game.UnitType(p.UnitType),
+			Building: game.BuildingType(p.Building),
+		}}
+	case *yacpb.Action_PlanOrder:
+		o := a.PlanOrder
+		return &game.PlanOrderAction{UnitID: o.UnitId, Kind: o.Kind, X: int(o.X), Y: int(o.Y)}
+	case *yacpb.Action_SubmitOrders:
+		return &game.SubmitOrdersAction{PlayerID: playerID}
+	}
+	return nil
+}
+
+// rawJSON returns JSON sent by a client, as null if it sent none
+func rawJSON(data []byte) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// serverMessage returns one of the hub's messages as a ServerMessage
+func serverMessage(data []byte) (*yacpb.ServerMessage, error) {
+	var msg WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+
+	var err error
+	out := &yacpb.ServerMessage{}
+	switch msg.Type {
+	case MsgTypeWelcome:
+		var welcome WelcomeMessage
+		err = json.Unmarshal(msg.Payload, &welcome)
+		out.Message = &yacpb.ServerMessage_Welcome{Welcome: &yacpb.Welcome{PlayerId: welcome.PlayerID}}
+	case MsgTypeGameState:
+		var state GameStateMessage
+		err = json.Unmarshal(msg.Payload, &state)
+		out.Message = &yacpb.ServerMessage_State{State: stateToPB(&state)}
+	case MsgTypeUpdate:
+		return updateToPB(msg.Payload)
+	case MsgTypeEvent:
+		var e game.Event
+		err = json.Unmarshal(msg.Payload, &e)
+		out.Message = &yacpb.ServerMessage_Event{Event: &yacpb.Event{
+			Seq:      e.Seq,
+			Turn:     int32(e.Turn),
+			PlayerId: e.PlayerID,
+			Type:     e.Type,
+			Data:     e.Data,
+			Hash:     e.Hash,
+		}}
+	case MsgTypeTurnChange:
+		var tc TurnChangeMessage
+		err = json.Unmarshal(msg.Payload, &tc)
+		out.Message = &yacpb.ServerMessage_TurnChange{TurnChange: &yacpb.TurnChange{
+			Turn:          int32(tc.Turn),
+			CurrentPlayer: tc.CurrentPlayer,
+			PlayerName:    tc.PlayerName,
+			Phase:         tc.Phase,
+		}}
+	case MsgTypeCombatResult:
+		var cr CombatResultMessage
+		err = json.Unmarshal(msg.Payload, &cr)
+		out.Message = &yacpb.ServerMessage_CombatResult{CombatResult: &yacpb.CombatResult{
+			X:            int32(cr.X),
+			Y:            int32(cr.Y),
+			AttackerId:   cr.AttackerID,
+			DefenderId:   cr.DefenderID,
+			Odds:         cr.Odds,
+			AttackerWon:  cr.AttackerWon,
+			AttackerLost: cr.AttackerLost,
+			DefenderLost: cr.DefenderLost,
+			CityCaptured: cr.CityCaptured,
+		}}
+	case MsgTypeQueryResult:
+		var qr struct {
+			QueryType string          `json:"query_type"`
+			RequestID string          `json:"request_id"`
+			Result    json.RawMessage `json:"result"`
+		}
+		err = json.Unmarshal(msg.Payload, &qr)
+		out.Message = &yacpb.ServerMessage_QueryResult{QueryResult: &yacpb.QueryResult{
+			RequestId: qr.RequestID,
+			QueryType: qr.QueryType,
+			Result:    qr.Result,
+		}}
+	case MsgTypeError:
+		var e ErrorMessage
+		err = json.Unmarshal(msg.Payload, &e)
+		out.Message = &yacpb.ServerMessage_Error{Error: &yacpb.Error{Code: string(e.Code), Message: e.Message}}
+	default:
+		out.Message = jsonMessage(msg)
+	}
+	return out, err
+}
+
+// updateToPB returns an update as its typed message, or as JSON if the
+// protocol has no type for it
+func updateToPB(payload json.RawMessage) (*yacpb.ServerMessage, error) {
+	var update struct {
+		UpdateType string          `json:"update_type"`
+		Entity     json.RawMessage `json:"entity"`
+	}
+	if err := json.Unmarshal(payload, &update); err != nil {
+		return nil, err
+	}
+
+	switch update.UpdateType {
+	case UpdateUnitMoved:
+		var moved UnitMovedDTO
+		err := json.Unmarshal(update.Entity, &moved)
+		return &yacpb.ServerMessage{Message: &yacpb.ServerMessage_UnitMoved{UnitMoved: &yacpb.UnitMoved{
+			Unit:  unitToPB(&moved.Unit),
+			FromX: int32(moved.FromX),
+			FromY: int32(moved.FromY),
+		}}}, err
+	case UpdateUnitsChanged:
+		var units []UnitDTO
+		err := json.Unmarshal(update.Entity, &units)
+		changed := &yacpb.UnitsChanged{}
+		for i := range units {
+			changed.Units = append(changed.Units, unitToPB(&units[i]))
+		}
+		return &yacpb.ServerMessage{Message: &yacpb.ServerMessage_UnitsChanged{UnitsChanged: changed}}, err
+	case UpdateCityFounded:
+		var city CityDTO
+		err := json.Unmarshal(update.Entity, &city)
+		return &yacpb.ServerMessage{Message: &yacpb.ServerMessage_CityFounded{CityFounded: &yacpb.CityFounded{City: cityToPB(&city)}}}, err
+	}
+	return &yacpb.ServerMessage{Message: jsonMessage(WSMessage{Type: MsgTypeUpdate, Payload: payload})}, nil
+}
+
+// jsonMessage returns a message as the WebSocket API sends it
+func jsonMessage(msg WSMessage) *yacpb.ServerMessage_Json {
+	return &yacpb.ServerMessage_Json{Json: &yacpb.JSONMessage{Type: string(msg.Type), Payload: msg.Payload}}
+}
+
+func stateToPB(s *GameStateMessage) *yacpb.GameState {
+	state := &yacpb.GameState{
+		Id:            s.ID,
+		Turn:          int32(s.Turn),
+		CurrentPlayer: s.CurrentPlayer,
+		Phase:         s.Phase,
+		Seq:           s.Seq,
+		Width:         int32(s.Map.Width),
+		Height:        int32(s.Map.Height),
+		Submitted:     s.Submitted,
+		ChunkSize:     int32(s.Map.ChunkSize),
+	}
+	if s.Winner != nil {
+		state.Winner = s.Winner.ID
+	}
+	for i := range s.Map.Tiles {
+		state.Tiles = append(state.Tiles, tileToPB(&s.Map.Tiles[i]))
+	}
+	for i := range s.Players {
+		state.Players = append(state.Players, playerToPB(&s.Players[i]))
+	}
+	return state
+}
+
+func tileToPB(t *TileDTO) *yacpb.Tile {
+	return &yacpb.Tile{
+		X:             int32(t.X),
+		Y:             int32(t.Y),
+		Terrain:       t.Terrain,
+		Resource:      t.Resource,
+		HasRoad:       t.HasRoad,
+		HasMine:       t.HasMine,
+		HasIrrigation: t.HasIrrigation,
+		HasRiver:      t.HasRiver,
+		Owner:         t.Owner,
+	}
+}
+
+func playerToPB(p *PlayerDTO) *yacpb.Player {
+	player := &yacpb.Player{
+		Id:       p.ID,
+		Name:     p.Name,
+		Color:    p.Color,
+		IsHuman:  p.IsHuman,
+		IsAlive:  p.IsAlive,
+		Gold:     int32(p.Gold),
+		Explored: p.Explored,
+	}
+	for i := range p.Units {
+		player.Units = append(player.Units, unitToPB(&p.Units[i]))
+	}
+	for i := range p.Cities {
+		player.Cities = append(player.Cities, cityToPB(&p.Cities[i]))
+	}
+	return player
+}
+
+func unitToPB(u *UnitDTO) *yacpb.Unit {
+	return &yacpb.Unit{
+		Id:           u.ID,
+		Type:         u.Type,
+		OwnerId:      u.OwnerID,
+		X:            int32(u.X),
+		Y:            int32(u.Y),
+		MovementLeft: int32(u.MovementLeft),
+		Health:       int32(u.Health),
+		MaxHealth:    int32(u.MaxHealth),
+		IsVeteran:    u.IsVeteran,
+		IsFortified:  u.IsFortified,
+		Mode:         u.Mode,
+		Attack:       int32(u.Attack),
+		Defense:      int32(u.Defense),
+		CanFoundCity: u.CanFoundCity,
+		Name:         u.Name,
+		Honorific:    u.Honorific,
+	}
+}
+
+func cityToPB(c *CityDTO) *yacpb.City {
+	city := &yacpb.City{
+		Id:               c.ID,
+		Name:             c.Name,
+		OwnerId:          c.OwnerID,
+		X:                int32(c.X),
+		Y:                int32(c.Y),
+		Population:       int32(c.Population),
+		FoodStore:        int32(c.FoodStore),
+		FoodNeeded:       int32(c.FoodNeeded),
+		Production:       int32(c.Production),
+		ProductionNeeded: int32(c.ProductionNeeded),
+		Buildings:        c.Buildings,
+	}
+	if c.CurrentBuild != nil {
+		city.CurrentBuild = c.CurrentBuild.Name
+	}
+	return city
+}
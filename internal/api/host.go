@@ -0,0 +1,317 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrGamePaused is returned for actions while the host has paused the game
+var ErrGamePaused = errors.New("the game is paused")
+
+// HostStateMessage tells clients who hosts the game and what the host has
+// set
+type HostStateMessage struct {
+	Host             string   `json:"host"`
+	Paused           bool     `json:"paused"`
+	TurnTimeout      int      `json:"turn_timeout"`
+	InactivityPolicy string   `json:"inactivity_policy,omitempty"`
+	Kicked           []string `json:"kicked,omitempty"`
+}
+
+// KickMessage is sent by the host to remove a player from the game
+type KickMessage struct {
+	PlayerID string `json:"player_id"`
+}
+
+// SetTurnTimerMessage is sent by the host to change the turn time limit of
+// an async game. An empty policy keeps the current one.
+type SetTurnTimerMessage struct {
+	Minutes int    `json:"minutes"`
+	Policy  string `json:"policy,omitempty"`
+}
+
+// TransferHostMessage is sent by the host to hand hosting to another player
+type TransferHostMessage struct {
+	PlayerID string `json:"player_id"`
+}
+
+// firstHuman returns the first human player, who hosts a new game
+func firstHuman(g *game.GameState) string {
+	for _, p := range g.Players {
+		if p.Type == game.PlayerHuman {
+			return p.ID
+		}
+	}
+	return ""
+}
+
+// isPaused reports whether the host has paused the game
+func (h *Hub) isPaused() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.paused
+}
+
+// isKicked reports whether a player has been kicked from the game
+func (h *Hub) isKicked(playerID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.kicked[playerID]
+}
+
+// hostState returns the host settings to send to clients
+func (h *Hub) hostState() HostStateMessage {
+	h.mu.RLock()
+	msg := HostStateMessage{Host: h.host, Paused: h.paused}
+	for id := range h.kicked {
+		msg.Kicked = append(msg.Kicked, id)
+	}
+	h.mu.RUnlock()
+
+	msg.TurnTimeout, msg.InactivityPolicy = h.turnTimer()
+	return msg
+}
+
+// hostStateData returns the host settings as a message to send
+func (h *Hub) hostStateData() []byte {
+	payload, _ := json.Marshal(h.hostState())
+	data, _ := json.Marshal(WSMessage{
+		Type:    MsgTypeHostState,
+		Payload: payload,
+	})
+	return data
+}
+
+// sendHostState sends the host settings to a client
+func (h *Hub) sendHostState(client *Client) {
+	client.enqueue(h.hostStateData(), false)
+}
+
+// BroadcastHostState sends the host settings to all clients
+func (h *Hub) BroadcastHostState() {
+	h.queue(outbound{data: h.hostStateData()})
+}
+
+// turnTimer returns the turn time limit in minutes and the inactivity
+// policy
+func (h *Hub) turnTimer() (int, string) {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	return h.game.Config.TurnTimeout, h.game.Config.InactivityPolicy
+}
+
+// setPaused pauses or resumes the game. While paused no actions are taken,
+// AI players wait and the turn timer stops.
+func (h *Hub) setPaused(paused bool) error {
+	h.mu.Lock()
+	if h.paused == paused {
+		h.mu.Unlock()
+		if paused {
+			return fmt.Errorf("the game is already paused")
+		}
+		return fmt.Errorf("the game is not paused")
+	}
+	h.paused = paused
+	h.mu.Unlock()
+
+	if a := h.async; a != nil {
+		a.mu.Lock()
+		if paused {
+			a.pausedAt = time.Now()
+		} else {
+			// The time spent paused does not count against the player
+			a.turnStarted = a.turnStarted.Add(time.Since(a.pausedAt))
+			a.pausedAt = time.Time{}
+		}
+		a.mu.Unlock()
+		h.save()
+	}
+
+	h.BroadcastHostState()
+	if !paused {
+		h.resumePlay()
+	}
+	return nil
+}
+
+// resumePlay carries on with the turns that waited while the game was
+// paused
+func (h *Hub) resumePlay() {
+	if h.phase() == game.PhaseAITurn {
+		go h.ProcessAITurns()
+		return
+	}
+	h.playKicked()
+}
+
+// kick removes a player from the game. Their clients are disconnected, the
+// seat is not given to new connections and their turns are ended for them,
+// or played by an AI under the AI inactivity policy.
+func (h *Hub) kick(playerID string) error {
+	player := h.player(playerID)
+	if player == nil || player.Type != game.PlayerHuman {
+		return fmt.Errorf("no human player %q in this game", playerID)
+	}
+
+	h.mu.Lock()
+	if playerID == h.host {
+		h.mu.Unlock()
+		return fmt.Errorf("the host cannot be kicked")
+	}
+	if h.kicked[playerID] {
+		h.mu.Unlock()
+		return fmt.Errorf("%s has already been kicked", player.Name)
+	}
+	h.kicked[playerID] = true
+	conns := make([]wsConn, 0)
+	for client := range h.clients {
+		if client.playerID == playerID {
+			conns = append(conns, client.conn)
+		}
+	}
+	h.mu.Unlock()
+
+	// The read pumps see the connections close and unregister the clients
+	reason := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "You have been removed from the game by the host")
+	for _, conn := range conns {
+		conn.WriteControl(websocket.CloseMessage, reason, time.Now().Add(time.Second))
+		conn.Close()
+	}
+
+	log.Printf("%s was kicked from the game", player.Name)
+	if h.async != nil {
+		h.save()
+	}
+	h.BroadcastHostState()
+	if !h.isPaused() {
+		h.playKicked()
+	}
+	return nil
+}
+
+// playKicked ends the turns of kicked players who are now to move. It
+// reports whether it played any.
+func (h *Hub) playKicked() bool {
+	if h.isPaused() || !h.seatedHumanAlive() {
+		return false
+	}
+
+	_, policy := h.turnTimer()
+	if policy != game.InactivityAI {
+		policy = game.InactivitySkip
+	}
+
+	for _, p := range h.playersOnTurn() {
+		if p.Type == game.PlayerHuman && h.isKicked(p.ID) {
+			h.playInactive(p, policy)
+			return true
+		}
+	}
+	return false
+}
+
+// seatedHumanAlive reports whether a human who has not been kicked is still
+// in the game. Without one there is nobody to play for.
+func (h *Hub) seatedHumanAlive() bool {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	for _, p := range h.game.Players {
+		if p.Type == game.PlayerHuman && p.IsAlive && !h.isKicked(p.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// setTurnTimer changes the turn time limit of an async game on behalf of
+// its host. The change is applied as an action, so the event log and
+// replays of the game keep it.
+func (h *Hub) setTurnTimer(hostID string, minutes int, policy string) error {
+	if h.async == nil {
+		return fmt.Errorf("turn timers are only kept in async games")
+	}
+
+	result := h.submit(hostID, &game.SetTurnTimerAction{Minutes: minutes, Policy: policy})
+	if !result.Applied() {
+		return result.Err
+	}
+	h.BroadcastEvent(result)
+	h.save()
+	h.BroadcastHostState()
+	return nil
+}
+
+// player returns a player of the game, read under the game lock
+func (h *Hub) player(playerID string) *game.Player {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	return h.game.GetPlayer(playerID)
+}
+
+// transferHost makes another human player the host
+func (h *Hub) transferHost(playerID string) error {
+	player := h.player(playerID)
+	if player == nil || player.Type != game.PlayerHuman {
+		return fmt.Errorf("no human player %q in this game", playerID)
+	}
+
+	h.mu.Lock()
+	if h.kicked[playerID] {
+		h.mu.Unlock()
+		return fmt.Errorf("%s has been kicked", player.Name)
+	}
+	h.host = playerID
+	h.mu.Unlock()
+
+	if h.async != nil {
+		h.save()
+	}
+	h.BroadcastHostState()
+	return nil
+}
+
+// handleHostCommand carries out a host-only message from this client
+func (c *Client) handleHostCommand(msgType MessageType, payload json.RawMessage) {
+	h := c.hub
+
+	h.mu.RLock()
+	isHost := c.playerID == h.host
+	h.mu.RUnlock()
+	if !isHost {
+		c.sendError(CodeNotHost, "")
+		return
+	}
+
+	var err error
+	switch msgType {
+	case MsgTypePause:
+		err = h.setPaused(true)
+	case MsgTypeResume:
+		err = h.setPaused(false)
+	case MsgTypeKick:
+		var msg KickMessage
+		if err = json.Unmarshal(payload, &msg); err == nil {
+			err = h.kick(msg.PlayerID)
+		}
+	case MsgTypeSetTurnTimer:
+		var msg SetTurnTimerMessage
+		if err = json.Unmarshal(payload, &msg); err == nil {
+			err = h.setTurnTimer(c.playerID, msg.Minutes, msg.Policy)
+		}
+	case MsgTypeTransferHost:
+		var msg TransferHostMessage
+		if err = json.Unmarshal(payload, &msg); err == nil {
+			err = h.transferHost(msg.PlayerID)
+		}
+	}
+
+	if err != nil {
+		c.sendError(CodeHostCommand, err.Error())
+	}
+}
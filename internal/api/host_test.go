@@ -0,0 +1,119 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"testing"
+)
+
+// newHostedHub returns the hub of an async game of alice, who hosts it,
+// against bob, both human
+func newHostedHub(t *testing.T) *Hub {
+	t.Helper()
+	h := newTestClient(t, "alice").hub
+	if err := h.EnableAsync(t.TempDir(), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Close)
+	return h
+}
+
+// TestTurnTimerKept has the host set the turn timer, on bob's turn, and
+// checks that the setting is recorded: the saved game and a replay of its
+// event log keep it
+func TestTurnTimerKept(t *testing.T) {
+	h := newHostedHub(t)
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+
+	if err := h.setTurnTimer("alice", 5, game.InactivityAI); err != nil {
+		t.Fatal(err)
+	}
+	for _, bad := range []struct {
+		minutes int
+		policy  string
+	}{{-1, ""}, {5, "forfeit"}} {
+		if err := h.setTurnTimer("alice", bad.minutes, bad.policy); err == nil {
+			t.Errorf("turn timer of %d minutes under policy %q was set", bad.minutes, bad.policy)
+		}
+	}
+	check := func(from string, g *game.GameState) {
+		t.Helper()
+		if g.Config.TurnTimeout != 5 || g.Config.InactivityPolicy != game.InactivityAI {
+			t.Errorf("%s has a turn timer of %d minutes under policy %q, want 5 under %q",
+				from, g.Config.TurnTimeout, g.Config.InactivityPolicy, game.InactivityAI)
+		}
+	}
+	check("the game", h.game)
+
+	replayed, err := game.Replay(h.game.EventLog(), h.game.Seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("the replay", replayed)
+
+	record, err := LoadAsyncRecord(h.async.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := DTOToGameState(&record.Game)
+	check("the save", saved)
+	if replayed, err = game.Replay(saved.EventLog(), saved.Seq); err != nil {
+		t.Fatal(err)
+	}
+	check("the replayed save", replayed)
+}
+
+// TestKickedSeatPlayedByAI kicks bob in a game under the AI inactivity
+// policy and checks that an AI plays his turns from then on, and that his
+// seat is given to no one
+func TestKickedSeatPlayedByAI(t *testing.T) {
+	h := newHostedHub(t)
+	if err := h.setTurnTimer("alice", 0, game.InactivityAI); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.kick("alice"); err == nil {
+		t.Error("the host was kicked")
+	}
+
+	if err := h.kick("bob"); err != nil {
+		t.Fatal(err)
+	}
+	seq := h.game.Seq
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	h.turnChanged()
+
+	if !h.game.IsCurrentPlayerTurn("alice") {
+		t.Fatalf("turn of %s after bob was kicked, want alice's again", h.game.TurnOrder.Current)
+	}
+	played, ended := 0, false
+	for _, e := range h.game.EventsSince(seq) {
+		if e.PlayerID != "bob" {
+			continue
+		}
+		if e.Type == "end_turn" {
+			ended = true
+		} else {
+			played++
+		}
+	}
+	if played == 0 || !ended {
+		t.Errorf("bob's turn was played with %d actions and ended %v, want an AI to play and end it", played, ended)
+	}
+
+	h.mu.Lock()
+	seat := h.freeSeat()
+	h.mu.Unlock()
+	if seat == "bob" {
+		t.Error("bob's seat was given to a new connection")
+	}
+	record, err := LoadAsyncRecord(h.async.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(record.Kicked) != 1 || record.Kicked[0] != "bob" {
+		t.Errorf("saved game kicked %v, want bob", record.Kicked)
+	}
+}
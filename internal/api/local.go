@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A server run as a desktop game serves only the browser on the same
+// machine. It answers no cross-origin requests, so other sites open in the
+// browser cannot reach it, nor requests naming another host, so a site
+// whose name is made to resolve to this machine cannot either. It is
+// stopped once its player has closed every tab for a while.
+
+// IdleCheckInterval is how often WaitIdle looks for connected clients
+var IdleCheckInterval = 5 * time.Second
+
+// sameOrigin reports whether a request is for this machine and comes from
+// a page the server served, or from a client other than a browser, which
+// sends no origin
+func sameOrigin(r *http.Request) bool {
+	if !localHost(r) {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// localHost reports whether a request names the server as 127.0.0.1 or
+// localhost, on the port it was received on when that is known
+func localHost(r *http.Request) bool {
+	host, port, err := net.SplitHostPort(r.Host)
+	if err != nil || host != "127.0.0.1" && !strings.EqualFold(host, "localhost") {
+		return false
+	}
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	if !ok {
+		return true
+	}
+	_, local, err := net.SplitHostPort(addr.String())
+	return err == nil && port == local
+}
+
+// connected reports whether any client is connected to a game of the
+// server, its own or one its engine plays
+func (s *Server) connected() bool {
+	hubs := []*Hub{s.hub}
+	if s.engine != nil {
+		hubs = append(hubs, s.engine.hubs()...)
+	}
+	for _, hub := range hubs {
+		if hub != nil && len(hub.clientCounts()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WaitIdle returns once no client has been connected for timeout, counting
+// from when it is called
+func (s *Server) WaitIdle(timeout time.Duration) {
+	ticker := time.NewTicker(IdleCheckInterval)
+	defer ticker.Stop()
+	idleSince := time.Now()
+	for now := range ticker.C {
+		if s.connected() {
+			idleSince = now
+		} else if now.Sub(idleSince) >= timeout {
+			return
+		}
+	}
+}
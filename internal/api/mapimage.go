@@ -0,0 +1,123 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// Map image scales in pixels per tile
+const (
+	DefaultMapImageScale = 4
+	MaxMapImageScale     = 16
+)
+
+// terrainColors match the client's terrain colors
+var terrainColors = map[game.TerrainType]color.RGBA{
+	game.TerrainOcean:     {0x00, 0x40, 0xa0, 0xff},
+	game.TerrainGrassland: {0x00, 0xa8, 0x00, 0xff},
+	game.TerrainPlains:    {0xc8, 0xb0, 0x40, 0xff},
+	game.TerrainDesert:    {0xe8, 0xd8, 0x58, 0xff},
+	game.TerrainHills:     {0x98, 0x78, 0x50, 0xff},
+	game.TerrainMountains: {0x80, 0x80, 0x80, 0xff},
+	game.TerrainForest:    {0x00, 0x68, 0x00, 0xff},
+}
+
+var (
+	riverColor      = color.RGBA{0x30, 0x70, 0xe0, 0xff}
+	unexploredColor = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	cityBorderColor = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// RenderMap draws the map with scale pixels per tile: terrain, rivers,
+// cities as squares and units as dots in their owner's color. With a
+// viewer, only the tiles that player has explored are shown.
+func RenderMap(g *game.GameState, viewer *game.Player, scale int) *image.RGBA {
+	m := g.Map
+	img := image.NewRGBA(image.Rect(0, 0, m.Width*scale, m.Height*scale))
+	visible := func(x, y int) bool {
+		return viewer == nil || g.IsExplored(viewer, x, y)
+	}
+
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			c := unexploredColor
+			if visible(x, y) {
+				c = terrainColors[m.GetTileUnsafe(x, y).Terrain]
+			}
+			fillRect(img, x*scale, y*scale, scale, scale, c)
+		}
+	}
+
+	for _, river := range m.Rivers {
+		drawRiver(img, river.Points, scale, visible)
+		for _, branch := range river.Delta {
+			drawRiver(img, branch, scale, visible)
+		}
+	}
+
+	for _, p := range g.Players {
+		owner := parseColor(p.Color)
+		for _, city := range p.Cities {
+			if !visible(city.X, city.Y) {
+				continue
+			}
+			fillRect(img, city.X*scale, city.Y*scale, scale, scale, cityBorderColor)
+			if scale > 2 {
+				fillRect(img, city.X*scale+1, city.Y*scale+1, scale-2, scale-2, owner)
+			}
+		}
+		for _, unit := range p.Units {
+			if !visible(unit.X, unit.Y) || g.GetCityAt(unit.X, unit.Y) != nil {
+				continue
+			}
+			size := (scale + 1) / 2
+			offset := (scale - size) / 2
+			fillRect(img, unit.X*scale+offset, unit.Y*scale+offset, size, size, owner)
+		}
+	}
+
+	return img
+}
+
+// drawRiver draws a river path given in tile coordinates
+func drawRiver(img *image.RGBA, points []game.RiverPoint, scale int, visible func(x, y int) bool) {
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		steps := int(math.Ceil(math.Max(math.Abs(b.X-a.X), math.Abs(b.Y-a.Y)) * float64(scale)))
+		for s := 0; s <= steps; s++ {
+			t := 0.0
+			if steps > 0 {
+				t = float64(s) / float64(steps)
+			}
+			x := a.X + (b.X-a.X)*t
+			y := a.Y + (b.Y-a.Y)*t
+			if visible(int(x), int(y)) {
+				img.SetRGBA(int(x*float64(scale)), int(y*float64(scale)), riverColor)
+			}
+		}
+	}
+}
+
+// fillRect fills a rectangle of the image
+func fillRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	for py := y; py < y+h; py++ {
+		for px := x; px < x+w; px++ {
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// parseColor reads a #RRGGBB player color
+func parseColor(hex string) color.RGBA {
+	if len(hex) != 7 || hex[0] != '#' {
+		return color.RGBA{0xff, 0xff, 0xff, 0xff}
+	}
+	v, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{0xff, 0xff, 0xff, 0xff}
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"fmt"
+)
+
+// Maps may be as large as MaxMapWidth by MaxMapHeight. A map of more than
+// StreamedMapTiles tiles is too large to send whole with every game state,
+// so clients are sent it without its tiles and fetch the chunks of
+// MapChunkSize by MapChunkSize tiles they look at with map_chunk queries.
+const (
+	MaxMapWidth      = 400
+	MaxMapHeight     = 250
+	StreamedMapTiles = 200 * 200
+	MapChunkSize     = 32
+)
+
+// MapChunkQuery asks for the tiles of one chunk of a streamed map
+type MapChunkQuery struct {
+	ChunkX int `json:"chunk_x"`
+	ChunkY int `json:"chunk_y"`
+}
+
+// MapChunkMessage answers a MapChunkQuery with the tiles of the chunk, row
+// by row. Chunks on the right and bottom edges of the map may be smaller
+// than MapChunkSize.
+type MapChunkMessage struct {
+	ChunkX int       `json:"chunk_x"`
+	ChunkY int       `json:"chunk_y"`
+	X      int       `json:"x"`
+	Y      int       `json:"y"`
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	Tiles  []TileDTO `json:"tiles"`
+}
+
+// Streamed reports whether a map is sent to clients chunk by chunk
+func Streamed(m *game.GameMap) bool {
+	return len(m.Tiles) > StreamedMapTiles
+}
+
+// ClientStateToDTO converts a GameState to the DTO sent over WebSocket,
+// which leaves out the map's tiles when the map is streamed
+func ClientStateToDTO(g *game.GameState) GameStateMessage {
+	if !Streamed(g.Map) {
+		return GameStateToDTO(g)
+	}
+	m := mapOutlineToDTO(g.Map)
+	m.ChunkSize = MapChunkSize
+	return gameStateToDTO(g, m)
+}
+
+// MapChunkToDTO returns the tiles of one chunk of a map
+func MapChunkToDTO(m *game.GameMap, chunkX, chunkY int) (MapChunkMessage, error) {
+	x, y := chunkX*MapChunkSize, chunkY*MapChunkSize
+	if chunkX < 0 || chunkY < 0 || x >= m.Width || y >= m.Height {
+		return MapChunkMessage{}, fmt.Errorf("no chunk %d,%d on a %dx%d map", chunkX, chunkY, m.Width, m.Height)
+	}
+
+	msg := MapChunkMessage{
+		ChunkX: chunkX,
+		ChunkY: chunkY,
+		X:      x,
+		Y:      y,
+		Width:  min(MapChunkSize, m.Width-x),
+		Height: min(MapChunkSize, m.Height-y),
+	}
+	msg.Tiles = make([]TileDTO, 0, msg.Width*msg.Height)
+	for ty := y; ty < y+msg.Height; ty++ {
+		for tx := x; tx < x+msg.Width; tx++ {
+			msg.Tiles = append(msg.Tiles, TileToDTO(m.GetTile(tx, ty)))
+		}
+	}
+	return msg, nil
+}
+
+// queryMapChunk sends the client the tiles of one chunk of the map
+func (c *Client) queryMapChunk(data json.RawMessage) (interface{}, error) {
+	var q MapChunkQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	chunk, err := MapChunkToDTO(c.hub.game.Map, q.ChunkX, q.ChunkY)
+	hideResources(chunk.Tiles, hiddenResources(c.hub.game, c.playerID))
+	return chunk, err
+}
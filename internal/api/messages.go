@@ -3,6 +3,7 @@ package api
 import (
 	"civilization/internal/game"
 	"encoding/json"
+	"slices"
 )
 
 // MessageType identifies the type of WebSocket message
@@ -10,7 +11,21 @@ type MessageType string
 
 const (
 	// Client -> Server messages
-	MsgTypeAction MessageType = "action"
+	MsgTypeAction    MessageType = "action"
+	MsgTypeQuery     MessageType = "query"
+	MsgTypeSetNotify MessageType = "set_notify"
+	MsgTypeViewport  MessageType = "viewport"
+	MsgTypeResync    MessageType = "resync"
+
+	// Client -> Server, and Server -> Client to tell of them
+	MsgTypeSetPreferences MessageType = "set_preferences"
+
+	// Client -> Server messages only the host may send
+	MsgTypePause        MessageType = "pause"
+	MsgTypeResume       MessageType = "resume"
+	MsgTypeKick         MessageType = "kick"
+	MsgTypeSetTurnTimer MessageType = "set_turn_timer"
+	MsgTypeTransferHost MessageType = "transfer_host"
 
 	// Server -> Client messages
 	MsgTypeGameState    MessageType = "game_state"
@@ -18,6 +33,14 @@ const (
 	MsgTypeCombatResult MessageType = "combat_result"
 	MsgTypeTurnChange   MessageType = "turn_change"
 	MsgTypeError        MessageType = "error"
+	MsgTypeEvent        MessageType = "event"
+	MsgTypeQueryResult  MessageType = "query_result"
+	MsgTypeTurnSummary  MessageType = "turn_summary"
+	MsgTypeTurnStatus   MessageType = "turn_status"
+	MsgTypeAdvice       MessageType = "advice"
+	MsgTypeWelcome      MessageType = "welcome"
+	MsgTypeHostState    MessageType = "host_state"
+	MsgTypePreferences  MessageType = "preferences"
 )
 
 // WSMessage is the base WebSocket message structure
@@ -32,21 +55,71 @@ type ActionMessage struct {
 	Data       json.RawMessage `json:"data"`
 }
 
-// ErrorMessage is sent when an error occurs
+// QueryMessage is sent by the client to ask for information without
+// changing the game state
+type QueryMessage struct {
+	QueryType string          `json:"query_type"`
+	RequestID string          `json:"request_id,omitempty"` // Echoed back in the result
+	Data      json.RawMessage `json:"data"`
+}
+
+// QueryResultMessage answers a QueryMessage
+type QueryResultMessage struct {
+	QueryType string      `json:"query_type"`
+	RequestID string      `json:"request_id,omitempty"`
+	Result    interface{} `json:"result"`
+}
+
+// ErrorMessage is sent when an error occurs. Message is in the language
+// of the game; clients showing errors in another language go by Code and
+// Details.
 type ErrorMessage struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    ErrorCode     `json:"code"`
+	Message string        `json:"message"`
+	Details *ErrorDetails `json:"details,omitempty"`
+}
+
+// ErrorDetails says what a refused action was refused over. Only the
+// fields that apply to the error are set.
+type ErrorDetails struct {
+	UnitID           string         `json:"unit_id,omitempty"`
+	CityID           string         `json:"city_id,omitempty"`
+	GroupID          string         `json:"group_id,omitempty"`
+	Tile             *game.Waypoint `json:"tile,omitempty"`
+	Terrain          string         `json:"terrain,omitempty"`
+	Item             string         `json:"item,omitempty"`
+	MovementLeft     *int           `json:"movement_left,omitempty"`
+	MovementRequired int            `json:"movement_required,omitempty"`
 }
 
 // GameStateMessage contains the full game state
 type GameStateMessage struct {
-	ID            string       `json:"id"`
-	Turn          int          `json:"turn"`
-	CurrentPlayer string       `json:"current_player"`
-	Phase         string       `json:"phase"`
-	Map           MapDTO       `json:"map"`
-	Players       []PlayerDTO  `json:"players"`
-	Winner        *PlayerDTO   `json:"winner,omitempty"`
+	ID            string                `json:"id"`
+	Turn          int                   `json:"turn"`
+	CurrentPlayer string                `json:"current_player"`
+	TurnOrder     []string              `json:"turn_order,omitempty"` // Player IDs in the order they play; listed order if empty
+	Phase         string                `json:"phase"`
+	Map           MapDTO                `json:"map"`
+	Players       []PlayerDTO           `json:"players"`
+	Winner        *PlayerDTO            `json:"winner,omitempty"`
+	Seed          int64                 `json:"seed"`
+	Config        game.GameConfig       `json:"config"`
+	Seq           uint64                `json:"seq"` // Last applied event
+	Scenario      *game.Scenario        `json:"scenario,omitempty"`
+	Submitted     []string              `json:"submitted,omitempty"`     // Players done planning in the simultaneous phase
+	Offers        []game.DealOffer      `json:"offers,omitempty"`        // Deals offered and not yet answered
+	LuxuryTrades  []game.LuxuryTrade    `json:"luxury_trades,omitempty"` // Luxuries shared in deals
+	Orders        []game.Order          `json:"orders,omitempty"`        // Only present in save files
+	History       []game.TurnStats      `json:"history,omitempty"`       // Only present in save files
+	CombatLog     []game.CombatLogEntry `json:"combat_log,omitempty"`    // Only present in save files
+	GameLog       []game.LogEntry       `json:"game_log,omitempty"`      // Only present in save files
+	EventLog      *game.EventLog        `json:"event_log,omitempty"`     // Only present in save files
+	Version       int                   `json:"version,omitempty"`       // Save format version, only present in save files
+}
+
+// WelcomeMessage tells a newly connected client which player it plays
+type WelcomeMessage struct {
+	PlayerID string `json:"player_id"`
 }
 
 // TurnChangeMessage notifies clients of turn changes
@@ -57,13 +130,179 @@ type TurnChangeMessage struct {
 	Phase         string `json:"phase"`
 }
 
-// CombatResultMessage contains combat outcome
+// CombatResultMessage contains combat outcome, as the battle's entry in
+// the combat log
 type CombatResultMessage struct {
-	AttackerID        string `json:"attacker_id"`
-	DefenderID        string `json:"defender_id"`
-	AttackerWon       bool   `json:"attacker_won"`
-	AttackerDestroyed bool   `json:"attacker_destroyed"`
-	DefenderDestroyed bool   `json:"defender_destroyed"`
+	game.CombatLogEntry
+}
+
+// CombatOddsMessage previews the outcome of an attack
+type CombatOddsMessage struct {
+	AttackerID      string                `json:"attacker_id"`
+	DefenderID      string                `json:"defender_id,omitempty"`
+	TargetX         int                   `json:"target_x"`
+	TargetY         int                   `json:"target_y"`
+	AttackStrength  int                   `json:"attack_strength"`
+	DefenseStrength int                   `json:"defense_strength"`
+	HitChance       float64               `json:"hit_chance"` // Per combat round
+	WinChance       float64               `json:"win_chance"`
+	FirstStrike     int                   `json:"first_strike,omitempty"` // Damage the defender deals before the battle
+	Modifiers       []game.CombatModifier `json:"modifiers"`
+}
+
+// TileInfoMessage describes a tile the client clicked on, with the actions
+// its selected unit could take aimed at it
+type TileInfoMessage struct {
+	Tile         TileDTO   `json:"tile"`
+	Food         int       `json:"food"` // Yields with the tile's improvements
+	Production   int       `json:"production"`
+	Trade        int       `json:"trade"`
+	DefenseBonus float64   `json:"defense_bonus"`
+	MovementCost int       `json:"movement_cost"` // From the selected unit, roads counted, or else off road
+	OwnerID      string    `json:"owner_id,omitempty"`
+	CityID       string    `json:"city_id,omitempty"`
+	CityName     string    `json:"city_name,omitempty"`
+	WorkedBy     string    `json:"worked_by,omitempty"` // City working the tile
+	Units        []UnitDTO `json:"units"`               // Those the player can see
+	UnitID       string    `json:"unit_id,omitempty"`   // Selected unit
+	Actions      []string  `json:"actions"`             // Types of the actions it could take
+}
+
+// ReachableMessage lists the tiles a unit can move to or attack this turn,
+// from where it stands
+type ReachableMessage struct {
+	UnitID string               `json:"unit_id"`
+	X      int                  `json:"x"`
+	Y      int                  `json:"y"`
+	Tiles  []game.ReachableTile `json:"tiles"`
+}
+
+// AdviceMessage is the advisor's hints for a player as their turn begins,
+// the most pressing first
+type AdviceMessage struct {
+	PlayerID string      `json:"player_id"`
+	Turn     int         `json:"turn"`
+	Hints    []game.Hint `json:"hints"`
+}
+
+// TurnSummaryMessage summarizes what happened to a player since their last turn
+type TurnSummaryMessage struct {
+	PlayerID            string                  `json:"player_id"`
+	Turn                int                     `json:"turn"`
+	CitiesGrown         []game.CityReport       `json:"cities_grown"`
+	CitiesStarved       []game.CityReport       `json:"cities_starved"`
+	CitiesInDisorder    []game.CityReport       `json:"cities_in_disorder"`
+	CitiesCelebrating   []game.CityReport       `json:"cities_celebrating"`
+	Completed           []game.CompletedReport  `json:"completed"`
+	CombatsAgainst      []CombatReportDTO       `json:"combats_against"`
+	ResourcesDiscovered []ResourceReportDTO     `json:"resources_discovered"`
+	UnitsWoken          []UnitNoticeDTO         `json:"units_woken"`
+	Detonations         []game.DetonationReport `json:"detonations"`
+	RandomEvents        []RandomEventDTO        `json:"random_events"`
+	ScenarioNotices     []game.ScenarioNotice   `json:"scenario_notices"`
+	TradeGold           int                     `json:"trade_gold"`
+	Economy             *game.EconomyReport     `json:"economy,omitempty"`
+}
+
+// RandomEventDTO describes a random event that struck one of the player's cities
+type RandomEventDTO struct {
+	Kind       string `json:"kind"`
+	CityID     string `json:"city_id"`
+	CityName   string `json:"city_name"`
+	Population int    `json:"population,omitempty"`
+	Food       int    `json:"food,omitempty"`
+	Building   string `json:"building,omitempty"`
+
+	// An eruption's volcano, and what an eruption or flood did
+	X            int `json:"x,omitempty"`
+	Y            int `json:"y,omitempty"`
+	UnitsLost    int `json:"units_lost,omitempty"`
+	TilesChanged int `json:"tiles_changed,omitempty"`
+}
+
+// UnitNoticeDTO describes an automated unit that stopped and needs orders
+type UnitNoticeDTO struct {
+	UnitID   string `json:"unit_id"`
+	UnitType string `json:"unit_type"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	Reason   string `json:"reason"`
+}
+
+// CombatReportDTO describes an attack made against the player
+type CombatReportDTO struct {
+	Turn         int    `json:"turn"`
+	AttackerID   string `json:"attacker_id"`
+	AttackerName string `json:"attacker_name"`
+	AttackerUnit string `json:"attacker_unit,omitempty"` // Empty for a city strike
+	AttackerCity string `json:"attacker_city,omitempty"`
+	DefenderUnit string `json:"defender_unit,omitempty"` // Empty if the city was undefended
+	X            int    `json:"x"`
+	Y            int    `json:"y"`
+	DefenderWon  bool   `json:"defender_won"`
+	UnitLost     bool   `json:"unit_lost"`
+	StackLost    int    `json:"stack_lost,omitempty"` // Other units destroyed with the defender
+	CityLost     string `json:"city_lost,omitempty"`
+	Bombard      bool   `json:"bombard,omitempty"`
+	Damage       int    `json:"damage,omitempty"`
+	CityDamage   int    `json:"city_damage,omitempty"`
+}
+
+// ResourceReportDTO describes a resource newly within reach of a city
+type ResourceReportDTO struct {
+	Resource string `json:"resource"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	CityName string `json:"city_name"`
+}
+
+// RulesMessage lists the unit, building, terrain and resource definitions
+// in play, which rules files may have changed
+type RulesMessage struct {
+	Speed        string              `json:"speed,omitempty"` // Game speed the costs are given at
+	Units        []UnitRuleDTO       `json:"units"`
+	Buildings    []BuildingRuleDTO   `json:"buildings"`
+	Terrain      []game.TerrainRule  `json:"terrain"`
+	Resources    []game.ResourceRule `json:"resources"`
+	Difficulties []HandicapDTO       `json:"difficulties"` // What AI players get at each difficulty
+}
+
+// StatsMessage is the per-turn history of every player's standing, for
+// drawing score graphs
+type StatsMessage struct {
+	Players  []StatsPlayerDTO `json:"players"`
+	History  []game.TurnStats `json:"history"`
+	Handicap HandicapDTO      `json:"handicap"` // What the game's AI players get
+}
+
+// StatsPlayerDTO names a player in the stats history
+type StatsPlayerDTO struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"`
+	IsAlive bool   `json:"is_alive"`
+}
+
+// UnitRuleDTO is a unit definition with the type ID used in actions
+type UnitRuleDTO struct {
+	Type game.UnitType `json:"type"`
+	game.UnitRule
+}
+
+// BuildingRuleDTO is a building definition with the type ID used in actions
+type BuildingRuleDTO struct {
+	Type    game.BuildingType `json:"type"`
+	Wonder  bool              `json:"wonder,omitempty"`
+	Coastal bool              `json:"coastal,omitempty"` // Only coastal cities can build it
+	game.BuildingRule
+}
+
+// HandicapDTO is what AI players get at a difficulty
+type HandicapDTO struct {
+	Difficulty        string   `json:"difficulty"`
+	ProductionPercent int      `json:"production_percent"` // Percent more shields AI cities make
+	FreeUnits         []string `json:"free_units"`         // Names of the units AI players start with besides everyone's
+	CombatPercent     int      `json:"combat_percent"`     // Percent AI units are stronger in combat, as veterans
 }
 
 // UpdateMessage contains incremental state updates
@@ -72,6 +311,19 @@ type UpdateMessage struct {
 	Entity     interface{} `json:"entity"`
 }
 
+// UpdateBorders is the update type of tiles changing hands. Its entity is
+// the list of changed tiles, each with its new owner.
+const UpdateBorders = "borders"
+
+// UpdateReveal is the update type of tiles newly explored by the player a
+// client plays, whether by its own units or through a deal. Its entity is
+// the list of tile indexes into the map's tiles.
+const UpdateReveal = "reveal"
+
+// UpdateUnitPromoted is the update type of a unit made a veteran by winning
+// a battle. Its entity is the game.UnitPromotion.
+const UpdateUnitPromoted = "unit_promoted"
+
 // Data Transfer Objects (DTOs)
 
 // RiverPointDTO represents a point along a river path
@@ -88,10 +340,11 @@ type RiverDTO struct {
 
 // MapDTO represents the map in JSON format
 type MapDTO struct {
-	Width  int        `json:"width"`
-	Height int        `json:"height"`
-	Tiles  []TileDTO  `json:"tiles"`
-	Rivers []RiverDTO `json:"rivers"`
+	Width     int        `json:"width"`
+	Height    int        `json:"height"`
+	Tiles     []TileDTO  `json:"tiles"`
+	Rivers    []RiverDTO `json:"rivers"`
+	ChunkSize int        `json:"chunk_size,omitempty"` // Set when the tiles are left out, to be fetched chunk by chunk
 }
 
 // TileDTO represents a single tile
@@ -104,74 +357,260 @@ type TileDTO struct {
 	HasMine       bool   `json:"has_mine,omitempty"`
 	HasIrrigation bool   `json:"has_irrigation,omitempty"`
 	HasRiver      bool   `json:"has_river,omitempty"`
+	Fallout       bool   `json:"fallout,omitempty"`
+	Volcano       bool   `json:"volcano,omitempty"`
+	Owner         string `json:"owner,omitempty"` // Player whose territory the tile is in
+	Coastal       bool   `json:"coastal,omitempty"`
+	Ford          bool   `json:"ford,omitempty"`
+	Job           string `json:"job,omitempty"`          // Terrain job under way
+	JobProgress   int    `json:"job_progress,omitempty"` // Turns of work done on Job
+	JobTurns      int    `json:"job_turns,omitempty"`    // Turns of work Job takes
 }
 
 // PlayerDTO represents a player
 type PlayerDTO struct {
-	ID      string    `json:"id"`
-	Name    string    `json:"name"`
-	Color   string    `json:"color"`
-	IsHuman bool      `json:"is_human"`
-	IsAlive bool      `json:"is_alive"`
-	Gold    int       `json:"gold"`
-	Units   []UnitDTO `json:"units"`
-	Cities  []CityDTO `json:"cities"`
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	Color   string      `json:"color"`
+	IsHuman bool        `json:"is_human"`
+	IsAlive bool        `json:"is_alive"`
+	Gold    int         `json:"gold"`
+	Science int         `json:"science"`
+	TaxRate *int        `json:"tax_rate,omitempty"` // Unset in saves from before taxes
+	Budget  game.Budget `json:"budget"`             // What the end of the player's turn will bring
+	Units   []UnitDTO   `json:"units"`
+	Cities  []CityDTO   `json:"cities"`
+
+	NuclearStrikes int  `json:"nuclear_strikes,omitempty"`
+	StartPoints    int  `json:"start_points,omitempty"` // Left to spend on an advanced start
+	Defeated       bool `json:"defeated,omitempty"`
+
+	Explored     []byte   `json:"explored,omitempty"`      // Bitset of explored tiles
+	SharedVision []string `json:"shared_vision,omitempty"` // Players sharing vision with this one
+	Met          []string `json:"met,omitempty"`           // Players whose units or cities this one has seen
 }
 
 // UnitDTO represents a unit
 type UnitDTO struct {
-	ID           string `json:"id"`
-	Type         string `json:"type"`
-	OwnerID      string `json:"owner_id"`
-	X            int    `json:"x"`
-	Y            int    `json:"y"`
-	MovementLeft int    `json:"movement_left"`
-	Health       int    `json:"health"`
-	IsVeteran    bool   `json:"is_veteran"`
-	IsFortified  bool   `json:"is_fortified"`
-	Attack       int    `json:"attack"`
-	Defense      int    `json:"defense"`
-	CanFoundCity bool   `json:"can_found_city"`
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	OwnerID       string          `json:"owner_id"`
+	X             int             `json:"x"`
+	Y             int             `json:"y"`
+	MovementLeft  int             `json:"movement_left"`
+	Health        int             `json:"health"`
+	MaxHealth     int             `json:"max_health"`
+	Healing       int             `json:"healing"` // Health regained at the end of the turn
+	IsVeteran     bool            `json:"is_veteran"`
+	IsFortified   bool            `json:"is_fortified"`
+	Mode          string          `json:"mode"`
+	Patrol        []game.Waypoint `json:"patrol,omitempty"`
+	PatrolIndex   int             `json:"patrol_index,omitempty"`
+	GroupID       string          `json:"group_id,omitempty"`
+	Cooldown      int             `json:"cooldown,omitempty"`
+	Acted         bool            `json:"acted,omitempty"`          // Moved or attacked this turn
+	VeteranOrigin string          `json:"veteran_origin,omitempty"` // "barracks", "combat" or "handicap"
+	XP            int             `json:"xp,omitempty"`             // Battles won
+	Name          string          `json:"name,omitempty"`           // Given by its owner
+	Honorific     string          `json:"honorific,omitempty"`      // Earned by winning battles
+	CanBombard    bool            `json:"can_bombard"`
+	CanNuke       bool            `json:"can_nuke"`
+	Attack        int             `json:"attack"`
+	Defense       int             `json:"defense"`
+	CanFoundCity  bool            `json:"can_found_city"`
+	CanHelpWonder bool            `json:"can_help_wonder"`
+	IsKing        bool            `json:"is_king,omitempty"` // Its owner's King in a regicide game
 }
 
 // CityDTO represents a city
 type CityDTO struct {
-	ID              string        `json:"id"`
-	Name            string        `json:"name"`
-	OwnerID         string        `json:"owner_id"`
-	X               int           `json:"x"`
-	Y               int           `json:"y"`
-	Population      int           `json:"population"`
-	FoodStore       int           `json:"food_store"`
-	FoodNeeded      int           `json:"food_needed"`
-	Production      int           `json:"production"`
-	ProductionNeeded int          `json:"production_needed"`
-	CurrentBuild    *BuildItemDTO `json:"current_build,omitempty"`
-	Buildings       []string      `json:"buildings"`
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	OwnerID          string        `json:"owner_id"`
+	X                int           `json:"x"`
+	Y                int           `json:"y"`
+	Population       int           `json:"population"`
+	FoodStore        int           `json:"food_store"`
+	FoodNeeded       int           `json:"food_needed"`
+	Production       int           `json:"production"`
+	ProductionNeeded int           `json:"production_needed"`
+	CurrentBuild     *BuildItemDTO `json:"current_build,omitempty"`
+	Buildings        []string      `json:"buildings"`
+	Damage           int           `json:"damage,omitempty"`
+	MaxDefense       int           `json:"max_defense"`
+	Struck           bool          `json:"struck,omitempty"`
+	Disorder         bool          `json:"disorder,omitempty"`
+	Celebrating      bool          `json:"celebrating,omitempty"`
+	WonderShields    int           `json:"wonder_shields,omitempty"`   // Of Production, brought by caravans
+	Corruption       int           `json:"corruption,omitempty"`       // Percent of trade lost to distance from the capital
+	Waste            int           `json:"waste,omitempty"`            // Percent of shields lost to distance from the capital
+	Bonus            int           `json:"bonus,omitempty"`            // Percent more shields from the owner's AI handicap
+	TradeLost        int           `json:"trade_lost,omitempty"`       // Trade lost to corruption each turn
+	ShieldsLost      int           `json:"shields_lost,omitempty"`     // Shields lost to waste each turn
+	OriginalCapital  string        `json:"original_capital,omitempty"` // Player whose first city this was
+	Connected        bool          `json:"connected,omitempty"`        // Joined to the capital by road, or the capital
+	Coastal          bool          `json:"coastal,omitempty"`          // Next to the ocean, so it can build a Harbor
 }
 
 // BuildItemDTO represents what's being built
 type BuildItemDTO struct {
-	IsUnit   bool   `json:"is_unit"`
-	Name     string `json:"name"`
-	Cost     int    `json:"cost"`
+	IsUnit bool   `json:"is_unit"`
+	Name   string `json:"name"`
+	Cost   int    `json:"cost"`
 }
 
 // Conversion functions
 
+// RulesToDTO describes the definitions currently in play, with costs at a
+// game speed
+func RulesToDTO(speed string) RulesMessage {
+	rules := game.CurrentRules()
+	msg := RulesMessage{
+		Speed:        speed,
+		Units:        make([]UnitRuleDTO, 0, len(rules.Units)),
+		Buildings:    make([]BuildingRuleDTO, 0, len(rules.Buildings)),
+		Terrain:      rules.Terrain,
+		Resources:    rules.Resources,
+		Difficulties: make([]HandicapDTO, 0, len(game.Difficulties())),
+	}
+
+	for _, t := range game.UnitTypes() {
+		rule := game.UnitTemplates[t].Rule()
+		rule.Cost = game.ScaleCost(rule.Cost, speed)
+		msg.Units = append(msg.Units, UnitRuleDTO{Type: t, UnitRule: rule})
+	}
+
+	for _, b := range game.BuildingTypes() {
+		msg.Buildings = append(msg.Buildings, BuildingRuleDTO{
+			Type:         b,
+			Wonder:       b.IsWonder(),
+			Coastal:      b == game.BuildingHarbor,
+			BuildingRule: game.BuildingRule{Name: b.String(), Cost: game.ScaleCost(game.BuildingCosts[b], speed)},
+		})
+	}
+
+	for _, difficulty := range game.Difficulties() {
+		msg.Difficulties = append(msg.Difficulties, HandicapToDTO(difficulty))
+	}
+
+	return msg
+}
+
+// HandicapToDTO returns what AI players get at a difficulty
+func HandicapToDTO(difficulty string) HandicapDTO {
+	if difficulty == "" {
+		difficulty = game.DifficultyNormal
+	}
+	handicap := game.Handicaps[difficulty]
+	dto := HandicapDTO{
+		Difficulty:        difficulty,
+		ProductionPercent: handicap.ProductionPercent,
+		FreeUnits:         make([]string, 0, len(handicap.FreeUnits)),
+	}
+	for _, t := range handicap.FreeUnits {
+		dto.FreeUnits = append(dto.FreeUnits, t.String())
+	}
+	if handicap.VeteranUnits {
+		dto.CombatPercent = game.VeteranBonus
+	}
+	return dto
+}
+
+// StatsToDTO returns the stats history of a game
+func StatsToDTO(g *game.GameState) StatsMessage {
+	msg := StatsMessage{
+		Players:  make([]StatsPlayerDTO, len(g.Players)),
+		History:  g.History,
+		Handicap: HandicapToDTO(g.Config.AIDifficulty),
+	}
+	if msg.History == nil {
+		msg.History = make([]game.TurnStats, 0)
+	}
+	for i, p := range g.Players {
+		msg.Players[i] = StatsPlayerDTO{ID: p.ID, Name: p.Name, Color: p.Color, IsAlive: p.IsAlive}
+	}
+	return msg
+}
+
+// PlayerStatsToDTO returns the stats history as a player may see it: all
+// of it once the game is over, and before then only the player's own
+// standing and the captures they took part in. Other players and clients
+// naming no player see no standing before the end.
+func PlayerStatsToDTO(g *game.GameState, playerID string) StatsMessage {
+	msg := StatsToDTO(g)
+	if g.Phase == game.PhaseGameOver {
+		return msg
+	}
+	history := make([]game.TurnStats, len(msg.History))
+	for i, entry := range msg.History {
+		turn := game.TurnStats{Turn: entry.Turn, Players: make([]game.PlayerStats, 0, 1)}
+		if playerID != "" {
+			for _, p := range entry.Players {
+				if p.PlayerID == playerID {
+					turn.Players = append(turn.Players, p)
+				}
+			}
+			for _, c := range entry.Captures {
+				if c.From == playerID || c.To == playerID {
+					turn.Captures = append(turn.Captures, c)
+				}
+			}
+		}
+		history[i] = turn
+	}
+	msg.History = history
+	return msg
+}
+
+// SaveToDTO converts a GameState to a DTO with everything a save file
+// keeps besides what clients are sent
+func SaveToDTO(g *game.GameState) GameStateMessage {
+	dto := GameStateToDTO(g)
+	dto.Orders = g.Orders
+	dto.History = g.History
+	dto.CombatLog = g.CombatLog
+	dto.GameLog = g.GameLog
+	dto.EventLog = g.EventLog()
+	dto.Version = SaveFormatVersion
+	return dto
+}
+
 // GameStateToDTO converts a GameState to a DTO
 func GameStateToDTO(g *game.GameState) GameStateMessage {
+	return gameStateToDTO(g, MapToDTO(g.Map))
+}
+
+// gameStateToDTO converts a GameState to a DTO carrying the given map
+func gameStateToDTO(g *game.GameState, m MapDTO) GameStateMessage {
 	dto := GameStateMessage{
 		ID:            g.ID,
 		Turn:          g.CurrentTurn,
-		CurrentPlayer: g.Players[g.CurrentPlayer].ID,
+		CurrentPlayer: g.TurnOrder.Current,
+		TurnOrder:     g.TurnOrder.Seats,
 		Phase:         g.Phase.String(),
-		Map:           MapToDTO(g.Map),
+		Map:           m,
 		Players:       make([]PlayerDTO, len(g.Players)),
+		Seed:          g.Seed,
+		Config:        g.Config,
+		Seq:           g.Seq,
+		Scenario:      g.Scenario,
+		Submitted:     g.Submitted,
+		Offers:        g.Offers,
+		LuxuryTrades:  g.LuxuryTrades,
 	}
 
 	for i, p := range g.Players {
 		dto.Players[i] = PlayerToDTO(p)
+		dto.Players[i].Budget = g.Budget(p, p.TaxRate)
+		for j, u := range p.Units {
+			dto.Players[i].Units[j].Healing = g.HealAmount(u)
+		}
+		for j, c := range p.Cities {
+			dto.Players[i].Cities[j].Connected = g.ConnectedToCapital(c)
+			dto.Players[i].Cities[j].Coastal = g.IsCoastal(c)
+			dto.Players[i].Cities[j].TradeLost, dto.Players[i].Cities[j].ShieldsLost = g.Losses(c)
+			scaleCityCosts(&dto.Players[i].Cities[j], c, g.Config.Speed)
+		}
 	}
 
 	if g.Winner != nil {
@@ -184,20 +623,24 @@ func GameStateToDTO(g *game.GameState) GameStateMessage {
 
 // MapToDTO converts a GameMap to a DTO
 func MapToDTO(m *game.GameMap) MapDTO {
+	dto := mapOutlineToDTO(m)
+	dto.Tiles = make([]TileDTO, len(m.Tiles))
+
+	// Tiles are stored row by row, which is also the order clients expect
+	for i := range m.Tiles {
+		dto.Tiles[i] = TileToDTO(&m.Tiles[i])
+	}
+	return dto
+}
+
+// mapOutlineToDTO converts everything of a GameMap but its tiles to a DTO
+func mapOutlineToDTO(m *game.GameMap) MapDTO {
 	dto := MapDTO{
 		Width:  m.Width,
 		Height: m.Height,
-		Tiles:  make([]TileDTO, 0, m.Width*m.Height),
 		Rivers: make([]RiverDTO, 0, len(m.Rivers)),
 	}
 
-	for y := 0; y < m.Height; y++ {
-		for x := 0; x < m.Width; x++ {
-			tile := m.GetTile(x, y)
-			dto.Tiles = append(dto.Tiles, TileToDTO(tile))
-		}
-	}
-
 	// Convert rivers
 	for _, river := range m.Rivers {
 		riverDTO := RiverDTO{
@@ -239,11 +682,20 @@ func TileToDTO(t *game.Tile) TileDTO {
 		HasMine:       t.HasMine,
 		HasIrrigation: t.HasIrrigation,
 		HasRiver:      t.HasRiver,
+		Fallout:       t.Fallout,
+		Volcano:       t.Volcano,
+		Owner:         t.Owner,
+		Coastal:       t.Coastal,
+		Ford:          t.Ford,
+		Job:           t.Job,
+		JobProgress:   t.JobProgress,
+		JobTurns:      t.JobTurns(t.Job),
 	}
 }
 
 // PlayerToDTO converts a Player to a DTO
 func PlayerToDTO(p *game.Player) PlayerDTO {
+	taxRate := p.TaxRate
 	dto := PlayerDTO{
 		ID:      p.ID,
 		Name:    p.Name,
@@ -251,8 +703,18 @@ func PlayerToDTO(p *game.Player) PlayerDTO {
 		IsHuman: p.Type == game.PlayerHuman,
 		IsAlive: p.IsAlive,
 		Gold:    p.Gold,
+		Science: p.Science,
+		TaxRate: &taxRate,
 		Units:   make([]UnitDTO, len(p.Units)),
 		Cities:  make([]CityDTO, len(p.Cities)),
+
+		NuclearStrikes: p.NuclearStrikes,
+		StartPoints:    p.StartPoints,
+		Defeated:       p.Defeated,
+
+		Explored:     p.Explored,
+		SharedVision: p.SharedVision,
+		Met:          p.Met,
 	}
 
 	for i, u := range p.Units {
@@ -270,34 +732,153 @@ func PlayerToDTO(p *game.Player) PlayerDTO {
 func UnitToDTO(u *game.Unit) UnitDTO {
 	template := u.Template()
 	return UnitDTO{
-		ID:           u.ID,
-		Type:         template.Name,
-		OwnerID:      u.OwnerID,
-		X:            u.X,
-		Y:            u.Y,
-		MovementLeft: u.MovementLeft,
-		Health:       u.Health,
-		IsVeteran:    u.IsVeteran,
-		IsFortified:  u.IsFortified,
-		Attack:       template.Attack,
-		Defense:      template.Defense,
-		CanFoundCity: template.CanFoundCity,
+		ID:            u.ID,
+		Type:          template.Name,
+		OwnerID:       u.OwnerID,
+		X:             u.X,
+		Y:             u.Y,
+		MovementLeft:  u.MovementLeft,
+		Health:        u.Health,
+		MaxHealth:     game.BaseHealthPoints,
+		IsVeteran:     u.IsVeteran,
+		IsFortified:   u.IsFortified,
+		Mode:          u.Mode.String(),
+		Patrol:        u.Patrol,
+		PatrolIndex:   u.PatrolIndex,
+		GroupID:       u.GroupID,
+		Cooldown:      u.Cooldown,
+		Acted:         u.Acted,
+		VeteranOrigin: u.VeteranOrigin,
+		XP:            u.XP,
+		Name:          u.Name,
+		Honorific:     u.Honorific,
+		CanBombard:    template.IsSiege,
+		CanNuke:       template.IsNuclear,
+		Attack:        template.Attack,
+		Defense:       template.Defense,
+		CanFoundCity:  template.CanFoundCity,
+		CanHelpWonder: template.HelpsWonder,
+		IsKing:        template.IsKing,
+	}
+}
+
+// TurnReportToDTO converts a TurnReport to a DTO
+func TurnReportToDTO(r *game.TurnReport) TurnSummaryMessage {
+	msg := TurnSummaryMessage{
+		PlayerID:            r.PlayerID,
+		Turn:                r.Turn,
+		CitiesGrown:         r.CitiesGrown,
+		CitiesStarved:       r.CitiesStarved,
+		CitiesInDisorder:    r.CitiesInDisorder,
+		CitiesCelebrating:   r.CitiesCelebrating,
+		Completed:           r.Completed,
+		CombatsAgainst:      make([]CombatReportDTO, len(r.CombatsAgainst)),
+		ResourcesDiscovered: make([]ResourceReportDTO, len(r.ResourcesDiscovered)),
+		UnitsWoken:          make([]UnitNoticeDTO, len(r.UnitsWoken)),
+		Detonations:         r.Detonations,
+		RandomEvents:        make([]RandomEventDTO, len(r.RandomEvents)),
+		ScenarioNotices:     r.ScenarioNotices,
+		TradeGold:           r.TradeGold,
+		Economy:             r.Economy,
+	}
+
+	for i, c := range r.CombatsAgainst {
+		msg.CombatsAgainst[i] = CombatReportDTO{
+			Turn:         c.Turn,
+			AttackerID:   c.AttackerID,
+			AttackerName: c.AttackerName,
+			AttackerCity: c.AttackerCity,
+			X:            c.X,
+			Y:            c.Y,
+			DefenderWon:  c.DefenderWon,
+			UnitLost:     c.UnitLost,
+			StackLost:    c.StackLost,
+			CityLost:     c.CityLost,
+			Bombard:      c.Bombard,
+			Damage:       c.Damage,
+			CityDamage:   c.CityDamage,
+		}
+		if c.AttackerCity == "" {
+			msg.CombatsAgainst[i].AttackerUnit = c.AttackerUnit.String()
+		}
+		if !c.Undefended {
+			msg.CombatsAgainst[i].DefenderUnit = c.DefenderUnit.String()
+		}
+	}
+
+	for i, res := range r.ResourcesDiscovered {
+		msg.ResourcesDiscovered[i] = ResourceReportDTO{
+			Resource: res.Resource.String(),
+			X:        res.X,
+			Y:        res.Y,
+			CityName: res.CityName,
+		}
+	}
+
+	for i, e := range r.RandomEvents {
+		msg.RandomEvents[i] = RandomEventDTO{
+			Kind:         e.Kind,
+			CityID:       e.CityID,
+			CityName:     e.CityName,
+			Population:   e.Population,
+			Food:         e.Food,
+			X:            e.X,
+			Y:            e.Y,
+			UnitsLost:    e.UnitsLost,
+			TilesChanged: e.TilesChanged,
+		}
+		if e.Building != game.BuildingNone {
+			msg.RandomEvents[i].Building = e.Building.String()
+		}
+	}
+
+	for i, n := range r.UnitsWoken {
+		msg.UnitsWoken[i] = UnitNoticeDTO{
+			UnitID:   n.UnitID,
+			UnitType: n.UnitType.String(),
+			X:        n.X,
+			Y:        n.Y,
+			Reason:   n.Reason,
+		}
+	}
+
+	return msg
+}
+
+// scaleCityCosts sets what a city needs to grow and to finish its build at
+// the game's speed; CityToDTO gives them at standard speed
+func scaleCityCosts(dto *CityDTO, c *game.City, speed string) {
+	dto.FoodNeeded = c.FoodNeededForGrowth(speed)
+	if c.CurrentBuild != nil {
+		dto.CurrentBuild.Cost = c.CurrentBuild.CostAt(speed)
+		dto.ProductionNeeded = dto.CurrentBuild.Cost
 	}
 }
 
 // CityToDTO converts a City to a DTO
 func CityToDTO(c *game.City) CityDTO {
 	dto := CityDTO{
-		ID:          c.ID,
-		Name:        c.Name,
-		OwnerID:     c.OwnerID,
-		X:           c.X,
-		Y:           c.Y,
-		Population:  c.Population,
-		FoodStore:   c.FoodStore,
-		FoodNeeded:  c.FoodNeededForGrowth(),
-		Production:  c.Production,
-		Buildings:   make([]string, 0),
+		ID:            c.ID,
+		Name:          c.Name,
+		OwnerID:       c.OwnerID,
+		X:             c.X,
+		Y:             c.Y,
+		Population:    c.Population,
+		FoodStore:     c.FoodStore,
+		FoodNeeded:    c.FoodNeededForGrowth(game.SpeedStandard),
+		Production:    c.Production,
+		Buildings:     make([]string, 0),
+		Damage:        c.Damage,
+		MaxDefense:    c.MaxDefense(),
+		Struck:        c.Struck,
+		Disorder:      c.Disorder,
+		Celebrating:   c.Celebrating,
+		WonderShields: c.WonderShields,
+		Corruption:    c.Corruption,
+		Waste:         c.Waste,
+		Bonus:         c.Bonus,
+
+		OriginalCapital: c.OriginalCapital,
 	}
 
 	if c.CurrentBuild != nil {
@@ -372,23 +953,30 @@ func ResourceFromString(s string) game.ResourceType {
 	}
 }
 
-// UnitTypeFromString converts unit type string to UnitType
+// UnitTypeFromString converts unit type string to UnitType. Unknown names
+// become warriors; ValidateSave refuses saves holding them.
 func UnitTypeFromString(s string) game.UnitType {
+	if t, ok := game.UnitTypeByName(s); ok {
+		return t
+	}
+	return game.UnitWarrior
+}
+
+// UnitModeFromString converts unit mode string to UnitMode
+func UnitModeFromString(s string) game.UnitMode {
 	switch s {
-	case "Settler":
-		return game.UnitSettler
-	case "Warrior":
-		return game.UnitWarrior
-	case "Phalanx":
-		return game.UnitPhalanx
-	case "Archer":
-		return game.UnitArcher
-	case "Horseman":
-		return game.UnitHorseman
-	case "Catapult":
-		return game.UnitCatapult
+	case "sentry":
+		return game.ModeSentry
+	case "explore":
+		return game.ModeExplore
+	case "work":
+		return game.ModeWork
+	case "patrol":
+		return game.ModePatrol
+	case "terraform":
+		return game.ModeTerraform
 	default:
-		return game.UnitWarrior
+		return game.ModeNone
 	}
 }
 
@@ -403,6 +991,8 @@ func PhaseFromString(s string) game.GamePhase {
 		return game.PhaseAITurn
 	case "game_over":
 		return game.PhaseGameOver
+	case "simultaneous":
+		return game.PhaseSimultaneous
 	default:
 		return game.PhasePlayerTurn
 	}
@@ -411,9 +1001,20 @@ func PhaseFromString(s string) game.GamePhase {
 // DTOToGameState converts a GameStateMessage to a GameState
 func DTOToGameState(dto *GameStateMessage) *game.GameState {
 	g := &game.GameState{
-		ID:          dto.ID,
-		CurrentTurn: dto.Turn,
-		Phase:       PhaseFromString(dto.Phase),
+		ID:           dto.ID,
+		CurrentTurn:  dto.Turn,
+		Phase:        PhaseFromString(dto.Phase),
+		Seed:         dto.Seed,
+		Config:       dto.Config,
+		Seq:          dto.Seq,
+		Scenario:     dto.Scenario,
+		Orders:       dto.Orders,
+		Submitted:    dto.Submitted,
+		History:      dto.History,
+		Offers:       dto.Offers,
+		LuxuryTrades: dto.LuxuryTrades,
+		CombatLog:    dto.CombatLog,
+		GameLog:      dto.GameLog,
 	}
 
 	// Convert map
@@ -423,10 +1024,35 @@ func DTOToGameState(dto *GameStateMessage) *game.GameState {
 	g.Players = make([]*game.Player, len(dto.Players))
 	for i, p := range dto.Players {
 		g.Players[i] = DTOToPlayer(&p)
-		// Find current player index
-		if p.ID == dto.CurrentPlayer {
-			g.CurrentPlayer = i
-		}
+	}
+
+	// Saves from before turn orders were kept play in listed order
+	g.TurnOrder = game.NewTurnOrder(g.Players)
+	if len(dto.TurnOrder) > 0 {
+		g.TurnOrder.Seats = slices.Clone(dto.TurnOrder)
+	}
+	if g.GetPlayer(dto.CurrentPlayer) != nil {
+		g.TurnOrder.Current = dto.CurrentPlayer
+	}
+
+	// Saves from before palaces had each player's first city as their
+	// capital
+	if dto.Version < 2 {
+		g.GrantPalaces()
+	}
+	// Saves from before waste was kept apart from corruption have none
+	if dto.Version < 3 {
+		g.UpdateCorruption()
+	}
+
+	// Borders follow the cities, whatever the save says
+	g.UpdateBorders()
+
+	// Restore the event log, or start a new one from the loaded state
+	if dto.EventLog != nil {
+		g.RestoreEventLog(dto.EventLog)
+	} else {
+		g.Checkpoint()
 	}
 
 	return g
@@ -445,6 +1071,12 @@ func DTOToMap(dto *MapDTO) *game.GameMap {
 			tile.HasMine = t.HasMine
 			tile.HasIrrigation = t.HasIrrigation
 			tile.HasRiver = t.HasRiver
+			tile.Fallout = t.Fallout
+			tile.Volcano = t.Volcano
+			tile.Owner = t.Owner
+			tile.Ford = t.Ford
+			tile.Job = t.Job
+			tile.JobProgress = t.JobProgress
 		}
 	}
 
@@ -475,6 +1107,8 @@ func DTOToMap(dto *MapDTO) *game.GameMap {
 		}
 	}
 
+	gm.MarkCoast()
+	gm.UpdateRoads()
 	return gm
 }
 
@@ -492,8 +1126,22 @@ func DTOToPlayer(dto *PlayerDTO) *game.Player {
 		Type:    playerType,
 		IsAlive: dto.IsAlive,
 		Gold:    dto.Gold,
+		Science: dto.Science,
+		TaxRate: game.DefaultTaxRate,
 		Units:   make([]*game.Unit, len(dto.Units)),
 		Cities:  make([]*game.City, len(dto.Cities)),
+
+		NuclearStrikes: dto.NuclearStrikes,
+		StartPoints:    dto.StartPoints,
+		Defeated:       dto.Defeated,
+
+		Explored:     dto.Explored,
+		SharedVision: dto.SharedVision,
+		Met:          dto.Met,
+	}
+
+	if dto.TaxRate != nil {
+		p.TaxRate = *dto.TaxRate
 	}
 
 	for i, u := range dto.Units {
@@ -519,21 +1167,42 @@ func DTOToUnit(dto *UnitDTO) *game.Unit {
 		Health:       dto.Health,
 		IsVeteran:    dto.IsVeteran,
 		IsFortified:  dto.IsFortified,
+		Mode:         UnitModeFromString(dto.Mode),
+		Patrol:       dto.Patrol,
+		PatrolIndex:  dto.PatrolIndex,
+		GroupID:      dto.GroupID,
+		Cooldown:     dto.Cooldown,
+		Acted:        dto.Acted,
+
+		VeteranOrigin: dto.VeteranOrigin,
+		XP:            dto.XP,
+		Name:          dto.Name,
+		Honorific:     dto.Honorific,
 	}
 }
 
 // DTOToCity converts a CityDTO to a City
 func DTOToCity(dto *CityDTO) *game.City {
 	c := &game.City{
-		ID:         dto.ID,
-		Name:       dto.Name,
-		OwnerID:    dto.OwnerID,
-		X:          dto.X,
-		Y:          dto.Y,
-		Population: dto.Population,
-		FoodStore:  dto.FoodStore,
-		Production: dto.Production,
-		Buildings:  make(map[game.BuildingType]bool),
+		ID:            dto.ID,
+		Name:          dto.Name,
+		OwnerID:       dto.OwnerID,
+		X:             dto.X,
+		Y:             dto.Y,
+		Population:    dto.Population,
+		FoodStore:     dto.FoodStore,
+		Production:    dto.Production,
+		Buildings:     make(map[game.BuildingType]bool),
+		Damage:        dto.Damage,
+		Struck:        dto.Struck,
+		Disorder:      dto.Disorder,
+		Celebrating:   dto.Celebrating,
+		WonderShields: dto.WonderShields,
+		Corruption:    dto.Corruption,
+		Waste:         dto.Waste,
+		Bonus:         dto.Bonus,
+
+		OriginalCapital: dto.OriginalCapital,
 	}
 
 	// Convert buildings
@@ -560,6 +1229,10 @@ func BuildingTypeFromString(s string) game.BuildingType {
 		return game.BuildingMarketplace
 	case "Library":
 		return game.BuildingLibrary
+	case "Manhattan Project":
+		return game.BuildingManhattanProject
+	case "Harbor":
+		return game.BuildingHarbor
 	default:
 		return game.BuildingNone
 	}
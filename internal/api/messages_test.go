@@ -0,0 +1,261 @@
+package api
+
+import (
+	"bytes"
+	"civilization/internal/ai"
+	"civilization/internal/game"
+	"civilization/internal/mapgen"
+	"encoding/json"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newBenchmarkMap generates a map without log noise
+func newBenchmarkMap(b *testing.B, width, height int) *game.GameMap {
+	b.Helper()
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	return mapgen.NewGenerator(mapgen.GeneratorConfig{
+		Width:         width,
+		Height:        height,
+		Seed:          1,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+	}).Generate()
+}
+
+func BenchmarkMapToDTO(b *testing.B) {
+	gm := newBenchmarkMap(b, 200, 200)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		MapToDTO(gm)
+	}
+}
+
+func BenchmarkMapSerialize(b *testing.B) {
+	benchmarkMapSerialize(b, 200, 200)
+}
+
+func BenchmarkMapSerialize400x250(b *testing.B) {
+	benchmarkMapSerialize(b, MaxMapWidth, MaxMapHeight)
+}
+
+func benchmarkMapSerialize(b *testing.B, width, height int) {
+	gm := newBenchmarkMap(b, width, height)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(MapToDTO(gm)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDTOToMap(b *testing.B) {
+	benchmarkDTOToMap(b, 200, 200)
+}
+
+func BenchmarkDTOToMap400x250(b *testing.B) {
+	benchmarkDTOToMap(b, MaxMapWidth, MaxMapHeight)
+}
+
+func benchmarkDTOToMap(b *testing.B, width, height int) {
+	dto := MapToDTO(newBenchmarkMap(b, width, height))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		DTOToMap(&dto)
+	}
+}
+
+// BenchmarkMapChunks400x250 fetches every chunk of the largest map, as a
+// client scrolling over all of it would
+func BenchmarkMapChunks400x250(b *testing.B) {
+	gm := newBenchmarkMap(b, MaxMapWidth, MaxMapHeight)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for cy := 0; cy*MapChunkSize < gm.Height; cy++ {
+			for cx := 0; cx*MapChunkSize < gm.Width; cx++ {
+				chunk, err := MapChunkToDTO(gm, cx, cy)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := json.Marshal(chunk); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkGameStateToDTOLateGame(b *testing.B) {
+	g := newLateGame(b, 200, 200)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(GameStateToDTO(g)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkClientState400x250 measures the game state sent over WebSocket
+// on the largest map, whose tiles are streamed
+func BenchmarkClientState400x250(b *testing.B) {
+	g := newLateGame(b, MaxMapWidth, MaxMapHeight)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(ClientStateToDTO(g)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// newLateGame plays 40 turns of an 8 player AI game on a generated map
+func newLateGame(b *testing.B, width, height int) *game.GameState {
+	b.Helper()
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	config.PlayerCount = 8
+	g := game.NewGame(config)
+	g.SetMap(mapgen.GenerateWithPlayers(mapgen.GeneratorConfig{
+		Width:         width,
+		Height:        height,
+		Seed:          config.Seed,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+	}, g.Players))
+	g.Start()
+	ai.PlayTurns(g, 40)
+	log.SetOutput(prev)
+	return g
+}
+
+// BenchmarkViewportState400x250 measures what a client subscribed to a
+// viewport of the largest map is sent for each game state
+func BenchmarkViewportState400x250(b *testing.B) {
+	g := newLateGame(b, MaxMapWidth, MaxMapHeight)
+	player := g.Players[0].ID
+	v := &viewport{ViewportMessage: ViewportMessage{Width: 64, Height: 32}, tiles: make(map[int]TileDTO)}
+	v.messages(g, player, ClientStateToDTO(g))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sent := 0
+	for i := 0; i < b.N; i++ {
+		for _, data := range v.messages(g, player, ClientStateToDTO(g)) {
+			sent += len(data)
+		}
+	}
+	b.ReportMetric(float64(sent)/float64(b.N), "bytes/state")
+}
+
+// TestSaveKeepsTurnOrder checks that the turn order survives a save that
+// lists the players in another order
+func TestSaveKeepsTurnOrder(t *testing.T) {
+	g := newTestClient(t, "alice").hub.game
+	if _, err := g.Apply("alice", &game.EndTurnAction{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dto := SaveToDTO(g)
+	dto.Players[0], dto.Players[1] = dto.Players[1], dto.Players[0]
+	data, err := json.Marshal(dto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save, err := ParseSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := DTOToGameState(save)
+	if current := loaded.GetCurrentPlayer(); current == nil || current.ID != "bob" {
+		t.Fatalf("loaded game has %v to move, want bob", current)
+	}
+
+	if _, err := loaded.Apply("bob", &game.EndTurnAction{}); err != nil {
+		t.Fatal(err)
+	}
+	if current := loaded.GetCurrentPlayer(); current.ID != "alice" || loaded.CurrentTurn != 2 {
+		t.Errorf("after bob, %s moves on turn %d, want alice on turn 2", current.ID, loaded.CurrentTurn)
+	}
+	if err := loaded.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestOldSaveGetsPalaces checks that a save from before palaces is loaded
+// with each player's first city as their capital
+func TestOldSaveGetsPalaces(t *testing.T) {
+	dto := SaveToDTO(newTestClient(t, "alice").hub.game)
+	dto.Version = 1
+	for i := range dto.Players {
+		for j := range dto.Players[i].Cities {
+			city := &dto.Players[i].Cities[j]
+			city.Buildings = slices.DeleteFunc(city.Buildings, func(b string) bool { return b == "Palace" })
+			city.OriginalCapital = ""
+			city.Corruption = 0
+		}
+	}
+	data, err := json.Marshal(dto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save, err := ParseSave(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := DTOToGameState(save)
+	for _, p := range loaded.Players {
+		first := p.Cities[0]
+		if p.Capital() != first || loaded.OriginalCapital(p.ID) != first {
+			t.Errorf("%s's capital is %v, want %s", p.ID, p.Capital(), first.Name)
+		}
+	}
+	if err := loaded.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestSaveOutOfSync checks that a save whose event log no longer replays
+// to its game still loads, from its state
+func TestSaveOutOfSync(t *testing.T) {
+	prev := log.Writer()
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(prev)
+
+	g := newTestClient(t, "alice").hub.game
+	if _, err := g.Apply("alice", &game.EndTurnAction{}); err != nil {
+		t.Fatal(err)
+	}
+	dto := SaveToDTO(g)
+	dto.EventLog.Events[0].Hash = "0000000000000000"
+	data, err := json.Marshal(dto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSave(data); err != nil {
+		t.Fatalf("save out of sync refused: %v", err)
+	}
+	if !strings.Contains(logged.String(), "out of sync at event 1") {
+		t.Errorf("desync not logged: %q", logged.String())
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Connections are kept alive with pings, and a client that stops answering
+// them is dropped.
+const (
+	WriteTimeout = 10 * time.Second // Longest a write to a client may take
+	PongTimeout  = 60 * time.Second // Longest a client may go without answering a ping
+	PingInterval = 30 * time.Second // Well inside PongTimeout, so one lost ping is survived
+)
+
+// Messages wait for a client's write pump in a buffer of SendBufferSize.
+// A client that falls behind so that the buffer fills is slow: messages
+// are held for it instead, a newer game state replacing the ones held
+// before it, as it tells the client all they would have. A client still
+// behind after SlowClientGrace, or with more than MaxHeldMessages held,
+// is disconnected with CloseSlowClient; it reconnects to a fresh state.
+const (
+	SendBufferSize          = 256
+	SlowClientGrace         = 10 * time.Second
+	SlowClientCheckInterval = 250 * time.Millisecond
+	MaxHeldMessages         = 1024
+	CloseSlowClient         = 4008
+)
+
+// outbox holds the messages a slow client's send buffer has no room for
+type outbox struct {
+	mu        sync.Mutex
+	held      []heldMessage
+	slowSince time.Time // Zero while the client keeps up
+	closed    bool      // The send channel is closed
+}
+
+// heldMessage is a message held for a slow client
+type heldMessage struct {
+	data  []byte
+	state bool // A full game state, which replaces older ones
+}
+
+// enqueue sends a message to the client, or holds it while the client is
+// slow. A state is a full game state.
+func (c *Client) enqueue(data []byte, state bool) {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	if c.out.closed {
+		return
+	}
+
+	if c.flushHeld() {
+		select {
+		case c.send <- data:
+			return
+		default:
+		}
+		c.out.slowSince = time.Now()
+		log.Printf("Client of %s is slow, holding its messages", c.playerID)
+	}
+
+	if state {
+		c.out.held = slices.DeleteFunc(c.out.held, func(m heldMessage) bool { return m.state })
+	}
+	c.out.held = append(c.out.held, heldMessage{data: data, state: state})
+}
+
+// flushHeld moves held messages into the send buffer while it has room,
+// and reports whether none are left. Callers must hold the outbox lock.
+func (c *Client) flushHeld() bool {
+	for len(c.out.held) > 0 {
+		select {
+		case c.send <- c.out.held[0].data:
+			c.out.held[0] = heldMessage{}
+			c.out.held = c.out.held[1:]
+		default:
+			return false
+		}
+	}
+	if !c.out.slowSince.IsZero() {
+		log.Printf("Client of %s caught up after %v", c.playerID, time.Since(c.out.slowSince).Round(time.Millisecond))
+		c.out.slowSince = time.Time{}
+	}
+	return true
+}
+
+// catchUp sends the client what was held for it that there is room for,
+// and reports whether it is too slow to keep
+func (c *Client) catchUp(now time.Time) bool {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	if c.out.closed || c.flushHeld() {
+		return false
+	}
+	return now.Sub(c.out.slowSince) > SlowClientGrace || len(c.out.held) > MaxHeldMessages
+}
+
+// closeSend closes the client's send channel, once, which ends its write
+// pump
+func (c *Client) closeSend() {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+	if !c.out.closed {
+		c.out.closed = true
+		c.out.held = nil
+		close(c.send)
+	}
+}
+
+// catchUpClients sends slow clients what was held for them, and
+// disconnects those too slow to keep. The read pumps see the connections
+// close and unregister the clients.
+func (h *Hub) catchUpClients(now time.Time) {
+	h.mu.RLock()
+	var tooSlow []*Client
+	for client := range h.clients {
+		if client.catchUp(now) {
+			tooSlow = append(tooSlow, client)
+		}
+	}
+	h.mu.RUnlock()
+
+	reason := websocket.FormatCloseMessage(CloseSlowClient, "Connection too slow to keep up with the game")
+	for _, client := range tooSlow {
+		log.Printf("Disconnecting client of %s, too slow to keep up", client.playerID)
+		client.conn.WriteControl(websocket.CloseMessage, reason, time.Now().Add(time.Second))
+		client.conn.Close()
+	}
+}
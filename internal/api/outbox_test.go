@@ -0,0 +1,78 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// closedConn records how a client's connection was closed
+type closedConn struct {
+	wsConn
+	code   int
+	closed bool
+}
+
+func (c *closedConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	if messageType == websocket.CloseMessage && len(data) >= 2 {
+		c.code = int(data[0])<<8 | int(data[1])
+	}
+	return nil
+}
+
+func (c *closedConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestSlowClient fills a client's send buffer and checks that messages are
+// held rather than dropped, that a newer game state replaces the held ones,
+// and that the client is disconnected once it stays behind too long
+func TestSlowClient(t *testing.T) {
+	conn := &closedConn{}
+	c := newTestClient(t, "alice")
+	c.conn = conn
+	c.send = make(chan []byte, 2)
+	h := c.hub
+	h.clients[c] = true
+
+	c.enqueue([]byte("state 1"), true)
+	c.enqueue([]byte("event 1"), false)
+	c.enqueue([]byte("state 2"), true)
+	c.enqueue([]byte("event 2"), false)
+	c.enqueue([]byte("state 3"), true)
+	if c.out.slowSince.IsZero() {
+		t.Fatal("client with a full buffer not found slow")
+	}
+
+	var got []string
+	for range 2 {
+		got = append(got, string(<-c.send))
+	}
+	h.catchUpClients(time.Now())
+	for range 2 {
+		got = append(got, string(<-c.send))
+	}
+	want := []string{"state 1", "event 1", "event 2", "state 3"}
+	if !slices.Equal(got, want) {
+		t.Errorf("client was sent %q, want %q", got, want)
+	}
+	h.catchUpClients(time.Now())
+	if !c.out.slowSince.IsZero() {
+		t.Error("client that caught up still slow")
+	}
+
+	for i := 0; i < 3; i++ {
+		c.enqueue([]byte("event"), false)
+	}
+	h.catchUpClients(time.Now().Add(SlowClientGrace / 2))
+	if conn.closed {
+		t.Fatal("client disconnected within the grace period")
+	}
+	h.catchUpClients(time.Now().Add(2 * SlowClientGrace))
+	if !conn.closed || conn.code != CloseSlowClient {
+		t.Errorf("client behind past the grace period: closed %v with code %d", conn.closed, conn.code)
+	}
+}
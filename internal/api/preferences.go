@@ -0,0 +1,173 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Players' preferences are kept on the server, so they follow a player to
+// any device: whether their turn ends by itself once nothing waits for
+// orders, whether battles are animated and which notices they are shown.
+// There are no accounts; preferences are kept by profile, a key the client
+// makes up and connects with as the profile parameter of the WebSocket.
+// A client connecting with the same profile, from any device and in any
+// game, is sent the same preferences after its welcome.
+
+const (
+	ProfileParam     = "profile" // Query parameter of the WebSocket naming the client's profile
+	MinProfileLength = 16        // Shortest profile preferences are kept for, so that profiles cannot be guessed
+)
+
+// Kinds of notices players can mute
+const (
+	NoticeCombat   = "combat"   // Captured cities and fallen kings
+	NoticeCities   = "cities"   // Cities growing, celebrating and completing builds
+	NoticeResearch = "research" // Technologies learned and resources revealed
+)
+
+// noticeKinds are the kinds of notices players can mute
+var noticeKinds = []string{NoticeCombat, NoticeCities, NoticeResearch}
+
+// Preferences are how a player likes to play, whatever the game. The same
+// message sets them and tells clients of them.
+type Preferences struct {
+	AutoEndTurn      bool     `json:"auto_end_turn"`     // End the turn once no unit or city waits for orders
+	CombatAnimations bool     `json:"combat_animations"` // Animate battles
+	MutedNotices     []string `json:"muted_notices"`     // Kinds of notices not shown
+}
+
+// DefaultPreferences returns the preferences of a player who has set none
+func DefaultPreferences() Preferences {
+	return Preferences{CombatAnimations: true, MutedNotices: []string{}}
+}
+
+// validate checks that the preferences only mute known kinds of notices
+func (p Preferences) validate() error {
+	for _, kind := range p.MutedNotices {
+		if !slices.Contains(noticeKinds, kind) {
+			return fmt.Errorf("unknown kind of notice %q", kind)
+		}
+	}
+	return nil
+}
+
+// PreferenceStore keeps preferences by profile, a file each in a
+// directory. Instances sharing the directory share preferences.
+type PreferenceStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewPreferenceStore returns a store keeping preferences in dir, or nil
+// if dir is empty
+func NewPreferenceStore(dir string) *PreferenceStore {
+	if dir == "" {
+		return nil
+	}
+	return &PreferenceStore{dir: dir}
+}
+
+// path returns the file a profile's preferences are kept in, named by its
+// hash so that the directory does not give profiles away
+func (s *PreferenceStore) path(profile string) string {
+	sum := sha256.Sum256([]byte(profile))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns a profile's preferences, or the defaults if it has none
+func (s *PreferenceStore) Get(profile string) Preferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := DefaultPreferences()
+	data, err := os.ReadFile(s.path(profile))
+	if err != nil {
+		return prefs
+	}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return DefaultPreferences()
+	}
+	if prefs.MutedNotices == nil {
+		prefs.MutedNotices = []string{}
+	}
+	return prefs
+}
+
+// Set keeps a profile's preferences
+func (s *PreferenceStore) Set(profile string, prefs Preferences) error {
+	if err := prefs.validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	path := s.path(profile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// clientProfile returns the profile a client connected with, or "" if it
+// gave none long enough to keep preferences for
+func clientProfile(profile string) string {
+	if len(profile) < MinProfileLength {
+		return ""
+	}
+	return profile
+}
+
+// sendPreferences sends a client the preferences of its profile, if it
+// connected with one
+func (h *Hub) sendPreferences(c *Client) {
+	if c.profile == "" || h.preferences == nil {
+		return
+	}
+	c.enqueue(encodeMessage(MsgTypePreferences, h.preferences.Get(c.profile)), false)
+}
+
+// handleSetPreferences keeps the preferences of the client's profile and
+// sends them to every client connected with it
+func (c *Client) handleSetPreferences(payload json.RawMessage) {
+	h := c.hub
+	if c.profile == "" || h.preferences == nil {
+		c.sendError(CodeInvalidPreferences, "connect with a profile to keep preferences")
+		return
+	}
+
+	prefs := DefaultPreferences()
+	if err := json.Unmarshal(payload, &prefs); err != nil {
+		c.sendError(CodeInvalidPreferences, err.Error())
+		return
+	}
+	if prefs.MutedNotices == nil {
+		prefs.MutedNotices = []string{}
+	}
+	if err := h.preferences.Set(c.profile, prefs); err != nil {
+		c.sendError(CodeInvalidPreferences, err.Error())
+		return
+	}
+
+	data := encodeMessage(MsgTypePreferences, prefs)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.profile == c.profile {
+			client.enqueue(data, false)
+		}
+	}
+}
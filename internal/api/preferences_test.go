@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// TestPreferences sets a profile's preferences from one client and checks
+// that every client of the profile is sent them, and that a client
+// connecting later with the profile, to another server, is sent them too
+func TestPreferences(t *testing.T) {
+	dir := t.TempDir()
+	const profile = "0123456789abcdef"
+
+	c := newTestClient(t, "alice")
+	c.profile = profile
+	h := c.hub
+	h.preferences = NewPreferenceStore(dir)
+	other := &Client{hub: h, send: make(chan []byte, 256), playerID: "alice", profile: profile}
+	stranger := &Client{hub: h, send: make(chan []byte, 256), playerID: "bob", profile: "fedcba9876543210"}
+	for _, client := range []*Client{c, other, stranger} {
+		h.clients[client] = true
+	}
+
+	h.sendPreferences(c)
+	if prefs := sent[Preferences](t, c, MsgTypePreferences); len(prefs) != 1 || !prefs[0].CombatAnimations || prefs[0].AutoEndTurn {
+		t.Fatalf("profile without preferences was sent %+v, want the defaults", prefs)
+	}
+
+	want := Preferences{AutoEndTurn: true, MutedNotices: []string{NoticeCities}}
+	payload, _ := json.Marshal(want)
+	c.handleSetPreferences(payload)
+	for _, client := range []*Client{c, other} {
+		if prefs := sent[Preferences](t, client, MsgTypePreferences); len(prefs) != 1 || !prefs[0].AutoEndTurn || prefs[0].CombatAnimations || !slices.Equal(prefs[0].MutedNotices, want.MutedNotices) {
+			t.Errorf("client of the profile was sent %+v, want %+v", prefs, want)
+		}
+	}
+	if prefs := sent[Preferences](t, stranger, MsgTypePreferences); len(prefs) > 0 {
+		t.Errorf("client of another profile was sent %+v", prefs)
+	}
+
+	// Another device, connecting to another server keeping preferences in
+	// the same place
+	later := newTestClient(t, "bob")
+	later.profile = profile
+	later.hub.preferences = NewPreferenceStore(dir)
+	later.hub.sendPreferences(later)
+	if prefs := sent[Preferences](t, later, MsgTypePreferences); len(prefs) != 1 || !prefs[0].AutoEndTurn || !slices.Equal(prefs[0].MutedNotices, want.MutedNotices) {
+		t.Errorf("client reconnecting with the profile was sent %+v, want %+v", prefs, want)
+	}
+
+	c.handleSetPreferences(json.RawMessage(`{"muted_notices": ["gossip"]}`))
+	if errs := sent[ErrorMessage](t, c, MsgTypeError); len(errs) != 1 || errs[0].Code != CodeInvalidPreferences {
+		t.Errorf("muting an unknown kind of notice sent %+v, want %s", errs, CodeInvalidPreferences)
+	}
+	if prefs := h.preferences.Get(profile); !prefs.AutoEndTurn {
+		t.Errorf("refused preferences were kept: %+v", prefs)
+	}
+
+	anonymous := newTestClient(t, "alice")
+	anonymous.hub.preferences = NewPreferenceStore(dir)
+	anonymous.handleSetPreferences(payload)
+	if errs := sent[ErrorMessage](t, anonymous, MsgTypeError); len(errs) != 1 || errs[0].Code != CodeInvalidPreferences {
+		t.Errorf("client without a profile setting preferences sent %+v, want %s", errs, CodeInvalidPreferences)
+	}
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// CombatOddsQuery asks for the odds of an attack before it is made
+type CombatOddsQuery struct {
+	AttackerID string `json:"attacker_id"`
+	TargetX    int    `json:"target_x"`
+	TargetY    int    `json:"target_y"`
+}
+
+// SimulationQuery asks what would happen if the client's player took some
+// actions, without taking them
+type SimulationQuery struct {
+	Actions []ActionMessage `json:"actions"`
+	Trials  int             `json:"trials,omitempty"` // 0 for game.DefaultSimulationTrials
+}
+
+// EmpireQuery asks for the client's player's cities, sorted by a column of
+// the empire overview, "" for the order they were founded in
+type EmpireQuery struct {
+	Sort       string `json:"sort,omitempty"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// TileInfoQuery asks about a tile, and what a unit of the client's could do
+// aimed at it
+type TileInfoQuery struct {
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	UnitID string `json:"unit_id,omitempty"` // Selected unit, if any
+}
+
+// ReachableQuery asks where one of the client's units can go this turn
+type ReachableQuery struct {
+	UnitID string `json:"unit_id"`
+}
+
+// errUnknownQuery is returned for query types the server does not answer
+var errUnknownQuery = errors.New("unknown query type")
+
+// handleQuery answers read-only queries from the client
+func (c *Client) handleQuery(payload json.RawMessage) {
+	var query QueryMessage
+	if err := json.Unmarshal(payload, &query); err != nil {
+		c.sendError(CodeInvalidQuery, err.Error())
+		return
+	}
+
+	c.hub.gameMu.RLock()
+	result, err := c.answerQuery(query)
+	c.hub.gameMu.RUnlock()
+
+	if errors.Is(err, errUnknownQuery) {
+		c.sendError(CodeUnknownQuery, query.QueryType)
+		return
+	}
+	if err != nil {
+		c.sendError(CodeInvalidQuery, err.Error())
+		return
+	}
+
+	c.sendQueryResult(query, result)
+}
+
+// answerQuery works out the answer to a query. Callers must read-hold the
+// game lock.
+func (c *Client) answerQuery(query QueryMessage) (interface{}, error) {
+	switch query.QueryType {
+	case "combat_odds":
+		return c.queryCombatOdds(query.Data)
+	case "turn_status":
+		return c.hub.game.TurnStatus(c.playerID), nil
+	case "demographics":
+		return c.hub.game.Demographics(c.playerID)
+	case "happiness":
+		return c.hub.game.Happiness(c.playerID)
+	case "simulate":
+		return c.querySimulation(query.Data)
+	case "map_chunk":
+		return c.queryMapChunk(query.Data)
+	case "empire":
+		return c.queryEmpire(query.Data)
+	case "tile_info":
+		return c.queryTileInfo(query.Data)
+	case "reachable":
+		return c.queryReachable(query.Data)
+	}
+	return nil, errUnknownQuery
+}
+
+// queryCombatOdds previews an attack by one of the client's units
+func (c *Client) queryCombatOdds(data json.RawMessage) (interface{}, error) {
+	var q CombatOddsQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+
+	odds, err := c.hub.game.PreviewAttack(c.playerID, q.AttackerID, q.TargetX, q.TargetY)
+	if err != nil {
+		return nil, err
+	}
+
+	return CombatOddsToDTO(odds, q.TargetX, q.TargetY), nil
+}
+
+// queryEmpire lists the client's player's cities for the empire overview
+func (c *Client) queryEmpire(data json.RawMessage) (interface{}, error) {
+	var q EmpireQuery
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q); err != nil {
+			return nil, err
+		}
+	}
+	return c.hub.game.EmpireOverview(c.playerID, q.Sort, q.Descending)
+}
+
+// queryTileInfo describes a tile for the client
+func (c *Client) queryTileInfo(data json.RawMessage) (interface{}, error) {
+	var q TileInfoQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+
+	info, err := c.hub.game.TileInfo(c.playerID, q.X, q.Y, q.UnitID)
+	if err != nil {
+		return nil, err
+	}
+
+	return TileInfoToDTO(info, q.UnitID), nil
+}
+
+// queryReachable lists the tiles one of the client's units can move to or
+// attack this turn
+func (c *Client) queryReachable(data json.RawMessage) (interface{}, error) {
+	var q ReachableQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+
+	tiles, err := c.hub.game.ReachableTiles(c.playerID, q.UnitID)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := c.hub.game.GetUnit(q.UnitID)
+	return ReachableMessage{UnitID: unit.ID, X: unit.X, Y: unit.Y, Tiles: tiles}, nil
+}
+
+// querySimulation plays actions for the client's player on copies of the
+// game and reports how they turned out
+func (c *Client) querySimulation(data json.RawMessage) (interface{}, error) {
+	var q SimulationQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	if len(q.Actions) == 0 {
+		return nil, fmt.Errorf("no actions to simulate")
+	}
+
+	actions := make([]game.Action, len(q.Actions))
+	for i, msg := range q.Actions {
+		action, err := game.DecodeAction(msg.ActionType, msg.Data)
+		if err != nil {
+			return nil, err
+		}
+		actions[i] = action
+	}
+
+	return c.hub.game.Simulate(c.playerID, actions, q.Trials)
+}
+
+// CombatOddsToDTO converts an attack preview to its DTO
+func CombatOddsToDTO(odds *game.CombatOdds, targetX, targetY int) CombatOddsMessage {
+	msg := CombatOddsMessage{
+		AttackerID:      odds.Attacker.ID,
+		TargetX:         targetX,
+		TargetY:         targetY,
+		AttackStrength:  odds.AttackStrength,
+		DefenseStrength: odds.DefenseStrength,
+		HitChance:       odds.HitChance,
+		WinChance:       odds.WinChance,
+		FirstStrike:     odds.FirstStrike,
+		Modifiers:       odds.Modifiers,
+	}
+	if odds.Defender != nil {
+		msg.DefenderID = odds.Defender.ID
+	}
+	return msg
+}
+
+// TileInfoToDTO converts what a player can learn of a tile to its DTO
+func TileInfoToDTO(info *game.TileInfo, unitID string) TileInfoMessage {
+	msg := TileInfoMessage{
+		Tile:         TileToDTO(info.Tile),
+		Food:         info.Food,
+		Production:   info.Production,
+		Trade:        info.Trade,
+		DefenseBonus: info.DefenseBonus,
+		MovementCost: info.MovementCost,
+		OwnerID:      info.OwnerID,
+		Units:        make([]UnitDTO, len(info.Units)),
+		UnitID:       unitID,
+		Actions:      info.Actions,
+	}
+	if info.City != nil {
+		msg.CityID = info.City.ID
+		msg.CityName = info.City.Name
+	}
+	if info.WorkedBy != nil {
+		msg.WorkedBy = info.WorkedBy.ID
+	}
+	for i, u := range info.Units {
+		msg.Units[i] = UnitToDTO(u)
+	}
+	return msg
+}
+
+// sendQueryResult sends the answer to a query to this client
+func (c *Client) sendQueryResult(query QueryMessage, result interface{}) {
+	payload, err := json.Marshal(QueryResultMessage{
+		QueryType: query.QueryType,
+		RequestID: query.RequestID,
+		Result:    result,
+	})
+	if err != nil {
+		log.Printf("Error marshaling query result: %v", err)
+		return
+	}
+
+	data, _ := json.Marshal(WSMessage{
+		Type:    MsgTypeQueryResult,
+		Payload: payload,
+	})
+
+	c.enqueue(data, false)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// A panic in a game's logic must not take the server down with it. Actions,
+// AI turns and client messages recover from panics: the game is marked
+// failed and takes no more actions or saves, it is snapshotted to a save
+// slot in its directory with the panic's stack beside it, and its clients
+// are told.
+
+// ErrGameFailed is returned for actions in a game that failed
+var ErrGameFailed = errors.New("the game failed and takes no more actions")
+
+// CrashSlotPrefix starts the names of the save slots failed games are
+// snapshotted to. The stack of the panic is kept next to the slot, in a
+// file of the same name ending in ".txt".
+const CrashSlotPrefix = "crash_"
+
+// recoverPanic marks the game failed if the goroutine is panicking. It
+// must be deferred directly.
+func (h *Hub) recoverPanic(where string) {
+	if r := recover(); r != nil {
+		h.fail(where, r, debug.Stack())
+	}
+}
+
+// fail marks the game failed by a panic, snapshots it and tells its
+// clients. Only the first failure is reported. The game lock must not be
+// held.
+func (h *Hub) fail(where string, value interface{}, stack []byte) {
+	reason := fmt.Sprintf("panic in %s: %v", where, value)
+	log.Printf("Game %s failed: %s\n%s", h.game.ID, reason, stack)
+
+	h.mu.Lock()
+	first := h.failed == ""
+	if first {
+		h.failed = reason
+	}
+	h.mu.Unlock()
+	if !first {
+		return
+	}
+
+	if filename, err := h.writeCrashReport(reason, stack); err != nil {
+		log.Printf("Error snapshotting failed game %s: %v", h.game.ID, err)
+	} else if filename != "" {
+		log.Printf("Failed game %s snapshotted to %s", h.game.ID, filename)
+	}
+
+	message, _ := h.pack().Lookup("error." + string(CodeGameFailed))
+	h.BroadcastError(CodeGameFailed, message)
+}
+
+// failure returns why the game failed, or "" if it did not
+func (h *Hub) failure() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.failed
+}
+
+// writeCrashReport snapshots a failed game to a save slot of its own, as
+// the panic left it, and writes the panic's stack next to it. It returns
+// the slot's file name relative to the saves directory, or "" when the hub
+// keeps no saves.
+func (h *Hub) writeCrashReport(reason string, stack []byte) (filename string, err error) {
+	if h.savesPath == "" {
+		return "", nil
+	}
+	if !validFileName(h.game.ID) {
+		return "", fmt.Errorf("invalid game ID %q", h.game.ID)
+	}
+	dir := filepath.Join(h.savesPath, h.game.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	slot := CrashSlotPrefix + time.Now().Format("2006-01-02_15-04-05")
+	report := fmt.Sprintf("Game %s failed on turn %d: %s\n\n%s", h.game.ID, h.game.CurrentTurn, reason, stack)
+	if err := writeFileAtomic(filepath.Join(dir, slot+".txt"), []byte(report)); err != nil {
+		return "", err
+	}
+
+	// The game may be left in any state, even one that cannot be saved
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("game cannot be saved: %v", r)
+		}
+	}()
+	if !h.gameMu.TryRLock() {
+		return "", errors.New("game is locked")
+	}
+	data, err := func() ([]byte, error) {
+		defer h.gameMu.RUnlock()
+		return json.MarshalIndent(SaveToDTO(h.game), "", "  ")
+	}()
+	if err != nil {
+		return "", err
+	}
+	filename = filepath.Join(h.game.ID, slot+".json")
+	if err := writeFileAtomic(filepath.Join(h.savesPath, filename), data); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(filename), nil
+}
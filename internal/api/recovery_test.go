@@ -0,0 +1,68 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// panicAction panics while it is executed, as a bug in an action would
+type panicAction struct{}
+
+func (a *panicAction) Type() string                                  { return "panic" }
+func (a *panicAction) Validate(*game.GameState, string) error        { return nil }
+func (a *panicAction) Execute(*game.GameState) (*game.Result, error) { panic("broken rule") }
+
+// TestPanicFailsGame checks that a panic in an action fails only its game:
+// the game lock is released, the game is snapshotted with the stack, its
+// clients are told, and further actions are refused
+func TestPanicFailsGame(t *testing.T) {
+	h := newTestClient(t, "alice").hub
+	h.savesPath = t.TempDir()
+
+	result := h.submit("alice", &panicAction{})
+	if !errors.Is(result.Err, ErrGameFailed) || result.Code != CodeGameFailed {
+		t.Fatalf("panicking action gave %+v", result)
+	}
+	if h.failure() == "" {
+		t.Fatal("game not marked failed")
+	}
+	if !h.gameMu.TryLock() {
+		t.Fatal("game lock still held after the panic")
+	}
+	h.gameMu.Unlock()
+
+	var msg WSMessage
+	if err := json.Unmarshal((<-h.broadcast).data, &msg); err != nil {
+		t.Fatal(err)
+	}
+	var errMsg ErrorMessage
+	json.Unmarshal(msg.Payload, &errMsg)
+	if msg.Type != MsgTypeError || errMsg.Code != CodeGameFailed {
+		t.Errorf("clients were sent %s %s, want a %s error", msg.Type, msg.Payload, CodeGameFailed)
+	}
+
+	reports, _ := filepath.Glob(filepath.Join(h.savesPath, h.game.ID, CrashSlotPrefix+"*"))
+	if len(reports) != 2 {
+		t.Fatalf("crash files %v, want a save slot and a stack", reports)
+	}
+	for _, path := range reports {
+		if filepath.Ext(path) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParseSave(data); err != nil {
+			t.Errorf("snapshot of the failed game does not load: %v", err)
+		}
+	}
+
+	if result := h.submit("alice", &game.EndTurnAction{}); !errors.Is(result.Err, ErrGameFailed) {
+		t.Errorf("failed game took an action: %+v", result)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"slices"
+)
+
+// Strategic resources a player does not know the technology to see are
+// left out of the tiles their clients are sent: in game states, viewport
+// tiles and map chunks. When a technology reveals a resource, the player
+// who discovered it is sent the tiles where it lies.
+
+// UpdateResourcesRevealed is the update type of a technology being
+// discovered, sent to the player who discovered it. Its entity is a
+// ResourcesRevealedDTO.
+const UpdateResourcesRevealed = "resources_revealed"
+
+// ResourcesRevealedDTO is a technology discovered and the resources it
+// revealed
+type ResourcesRevealedDTO struct {
+	Tech      string    `json:"tech"`
+	Resources []string  `json:"resources"`
+	Tiles     []TileDTO `json:"tiles"` // Where the resources lie
+}
+
+// hiddenResources returns the names of the resources a player cannot see,
+// or nil if they see them all
+func hiddenResources(g *game.GameState, playerID string) map[string]bool {
+	player := g.GetPlayer(playerID)
+	var hidden map[string]bool
+	for resource := range game.RevealedBy {
+		if g.SeesResource(player, resource) {
+			continue
+		}
+		if hidden == nil {
+			hidden = make(map[string]bool)
+		}
+		hidden[resource.String()] = true
+	}
+	return hidden
+}
+
+// hideResources blanks the hidden resources of tiles
+func hideResources(tiles []TileDTO, hidden map[string]bool) {
+	if hidden == nil {
+		return
+	}
+	for i := range tiles {
+		if hidden[tiles[i].Resource] {
+			tiles[i].Resource = ""
+		}
+	}
+}
+
+// playerState returns a game state as a player is sent it, without the
+// resources they cannot see. The tiles of state are left as they were.
+func playerState(g *game.GameState, playerID string, state GameStateMessage) GameStateMessage {
+	hidden := hiddenResources(g, playerID)
+	if hidden == nil || len(state.Map.Tiles) == 0 {
+		return state
+	}
+	state.Map.Tiles = slices.Clone(state.Map.Tiles)
+	hideResources(state.Map.Tiles, hidden)
+	return state
+}
+
+// ResourcesRevealedToDTO returns a technology with the resources it
+// reveals and the tiles of the map where they lie
+func ResourcesRevealedToDTO(m *game.GameMap, tech game.Technology) ResourcesRevealedDTO {
+	dto := ResourcesRevealedDTO{Tech: tech.String(), Resources: make([]string, 0), Tiles: make([]TileDTO, 0)}
+	revealed := make(map[game.ResourceType]bool)
+	for resource, by := range game.RevealedBy {
+		if by == tech {
+			revealed[resource] = true
+			dto.Resources = append(dto.Resources, resource.String())
+		}
+	}
+	slices.Sort(dto.Resources)
+
+	for i := range m.Tiles {
+		if revealed[m.Tiles[i].Resource] {
+			dto.Tiles = append(dto.Tiles, TileToDTO(&m.Tiles[i]))
+		}
+	}
+	return dto
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SaveFormatVersion is the version of the save file format this server
+// writes. Saves from before versioning have no version and are read as 0.
+const SaveFormatVersion = 3
+
+// MaxSaveFileSize limits uploaded save files
+const MaxSaveFileSize = 64 << 20
+
+// ParseSave reads a save file and checks that it can be loaded
+func ParseSave(data []byte) (*GameStateMessage, error) {
+	var dto GameStateMessage
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, fmt.Errorf("not a save file: %w", err)
+	}
+	if err := ValidateSave(&dto); err != nil {
+		return nil, err
+	}
+	return &dto, nil
+}
+
+// ValidateSave checks that a save is from a compatible server and holds a
+// consistent game
+func ValidateSave(dto *GameStateMessage) error {
+	if dto.Version > SaveFormatVersion {
+		return fmt.Errorf("save format version %d is newer than this server supports (%d)", dto.Version, SaveFormatVersion)
+	}
+	if dto.ID == "" {
+		return fmt.Errorf("save has no game ID")
+	}
+	switch dto.Phase {
+	case "setup", "player_turn", "ai_turn", "game_over", "simultaneous":
+	default:
+		return fmt.Errorf("unknown phase %q", dto.Phase)
+	}
+
+	m := dto.Map
+	if m.Width < 1 || m.Height < 1 || len(m.Tiles) != m.Width*m.Height {
+		return fmt.Errorf("map has %d tiles, expected %d x %d", len(m.Tiles), m.Width, m.Height)
+	}
+	inBounds := func(x, y int) bool { return x >= 0 && x < m.Width && y >= 0 && y < m.Height }
+	for _, t := range m.Tiles {
+		if !inBounds(t.X, t.Y) {
+			return fmt.Errorf("tile (%d, %d) is off the map", t.X, t.Y)
+		}
+	}
+
+	if len(dto.Players) == 0 {
+		return fmt.Errorf("save has no players")
+	}
+	current := false
+	ids := make(map[string]bool)
+	for _, p := range dto.Players {
+		if p.ID == "" || ids[p.ID] {
+			return fmt.Errorf("players need unique IDs")
+		}
+		ids[p.ID] = true
+		current = current || p.ID == dto.CurrentPlayer
+		if p.TaxRate != nil && !game.ValidTaxRate(*p.TaxRate) {
+			return fmt.Errorf("player %s has invalid tax rate %d", p.ID, *p.TaxRate)
+		}
+
+		for _, u := range p.Units {
+			if _, ok := game.UnitTypeByName(u.Type); !ok {
+				return fmt.Errorf("unit %s has unknown type %q", u.ID, u.Type)
+			}
+			if !inBounds(u.X, u.Y) {
+				return fmt.Errorf("unit %s is off the map", u.ID)
+			}
+		}
+		for _, c := range p.Cities {
+			if !inBounds(c.X, c.Y) {
+				return fmt.Errorf("city %s is off the map", c.Name)
+			}
+		}
+	}
+	if !current && dto.Phase != "game_over" {
+		return fmt.Errorf("current player %q is not in the game", dto.CurrentPlayer)
+	}
+	if len(dto.TurnOrder) > 0 {
+		seated := make(map[string]bool)
+		for _, id := range dto.TurnOrder {
+			if !ids[id] || seated[id] {
+				return fmt.Errorf("turn order seats %q, who is not in the game or seated twice", id)
+			}
+			seated[id] = true
+		}
+		if len(seated) != len(ids) {
+			return fmt.Errorf("turn order seats %d of %d players", len(seated), len(ids))
+		}
+	}
+
+	if dto.EventLog != nil {
+		if err := checkEventLog(dto); err != nil {
+			return fmt.Errorf("event log: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkEventLog replays a save's event log to make sure it leads to the
+// saved sequence number. A log replaying out of sync with the game is
+// logged and let be, as the game is loaded from its state.
+func checkEventLog(dto *GameStateMessage) (err error) {
+	// A damaged base snapshot may not hold together well enough to play
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("replay failed: %v", r)
+		}
+	}()
+
+	seq := dto.Seq
+	g, err := game.Replay(dto.EventLog, seq)
+	var desync *game.DesyncError
+	if errors.As(err, &desync) {
+		logReplayDesync(dto, desync)
+		g, err = desync.Game, nil
+	}
+	if err != nil {
+		return err
+	}
+	if g.Seq != seq {
+		return fmt.Errorf("replay ends at event %d, the save is at %d", g.Seq, seq)
+	}
+	return nil
+}
+
+// saveFilePath returns the path of a save file, named "<game ID>/<slot>.json"
+// or, for saves from before per-game directories, "<name>.json". Names that
+// would leave the saves directory are refused.
+func (s *Server) saveFilePath(filename string) (string, error) {
+	dir, name, ok := strings.Cut(filename, "/")
+	if !ok {
+		dir, name = "", filename
+	}
+	if ok && !validFileName(dir) || !validFileName(name) || filepath.Ext(name) != ".json" {
+		return "", fmt.Errorf("invalid save file name %q", filename)
+	}
+	return filepath.Join(s.savesPath, dir, name), nil
+}
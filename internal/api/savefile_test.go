@@ -0,0 +1,58 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestValidateSave damages a save of a game in play one way at a time and
+// checks that each is refused with the reason
+func TestValidateSave(t *testing.T) {
+	h := newTestClient(t, "alice").hub
+	if result := h.submit("alice", &game.FortifyAction{UnitID: "u1"}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	data, err := json.Marshal(SaveToDTO(h.game))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		damage func(dto *GameStateMessage)
+		want   string // Part of the error, "" if the save is valid
+	}{
+		{"intact", func(dto *GameStateMessage) {}, ""},
+		{"newer version", func(dto *GameStateMessage) { dto.Version = SaveFormatVersion + 1 }, "newer than this server supports"},
+		{"bad tile count", func(dto *GameStateMessage) { dto.Map.Tiles = dto.Map.Tiles[1:] }, "map has"},
+		{"duplicate player IDs", func(dto *GameStateMessage) { dto.Players[1].ID = dto.Players[0].ID }, "unique IDs"},
+		{"player seated twice", func(dto *GameStateMessage) { dto.TurnOrder = []string{"alice", "alice"} }, "seated twice"},
+		{"player not seated", func(dto *GameStateMessage) { dto.TurnOrder = []string{"alice"} }, "seats 1 of 2 players"},
+		{"replay panics", func(dto *GameStateMessage) {
+			// A map missing its tiles, which the replay looks up
+			var base map[string]json.RawMessage
+			json.Unmarshal(dto.EventLog.Base, &base)
+			base["map"] = json.RawMessage(`{"width": 8, "height": 5, "tiles": []}`)
+			dto.EventLog.Base, _ = json.Marshal(base)
+		}, "replay failed"},
+	}
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			var dto GameStateMessage
+			if err := json.Unmarshal(data, &dto); err != nil {
+				t.Fatal(err)
+			}
+			c.damage(&dto)
+
+			err := ValidateSave(&dto)
+			switch {
+			case c.want == "" && err != nil:
+				t.Errorf("save refused: %v", err)
+			case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+				t.Errorf("got error %v, want one saying %q", err, c.want)
+			}
+		})
+	}
+}
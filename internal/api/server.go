@@ -2,13 +2,21 @@ package api
 
 import (
 	"civilization/internal/game"
+	"civilization/internal/locale"
 	"civilization/internal/mapgen"
+	"civilization/web"
 	"encoding/json"
 	"fmt"
+	"image/png"
+	"io"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,11 +24,40 @@ import (
 type Server struct {
 	hub        *Hub
 	game       *game.GameState
-	staticPath string
+	engine     *engine // Plays the games requests name, on an instance
+	staticPath string  // Directory the browser client is served from; the built-in one if empty
 	savesPath  string
+
+	// ScenariosPath is the directory scenario files are read from
+	ScenariosPath string
+
+	// GamesPath is the directory async games are kept in, and Notifier
+	// tells their players when it is their turn
+	GamesPath string
+	Notifier  *Notifier
+
+	// Retention is how long games nothing is written for are kept on disk;
+	// zero keeps them for good
+	Retention time.Duration
+
+	// AdminToken must be sent with requests to the admin API, which is
+	// off while it is empty
+	AdminToken string
+
+	// Store records the history of games turn by turn, if set
+	Store *SQLStore
+
+	// Preferences keeps players' preferences by profile, if set
+	Preferences *PreferenceStore
+
+	// LocalOnly turns away requests from pages of other origins, for a
+	// server played on from the same machine only, rather than allowing
+	// every origin
+	LocalOnly bool
 }
 
-// NewServer creates a new API server
+// NewServer creates a new API server serving the browser client from
+// staticPath, or the client built into the binary if it is empty
 func NewServer(staticPath string) *Server {
 	// Create saves directory relative to working directory
 	savesPath := "saves"
@@ -31,33 +68,112 @@ func NewServer(staticPath string) *Server {
 	return &Server{
 		staticPath: staticPath,
 		savesPath:  savesPath,
+
+		ScenariosPath: "scenarios",
+		GamesPath:     "games",
+		Notifier:      NewNotifier("", ""),
+		Retention:     DefaultRetention,
+		Preferences:   NewPreferenceStore("preferences"),
 	}
 }
 
-// NewGame creates a new game with the given configuration
-func (s *Server) NewGame(config game.GameConfig) {
+// NewGame creates a new game with the given configuration. The current
+// game is kept if the configured scenario cannot be loaded.
+func (s *Server) NewGame(config game.GameConfig) error {
+	g, err := s.newGame(config)
+	if err != nil {
+		return err
+	}
+	s.game = g
+	return s.startHub(nil)
+}
+
+// newGame creates and starts a game with the given configuration
+func (s *Server) newGame(config game.GameConfig) (*game.GameState, error) {
+	var scenario *game.Scenario
+	if config.Scenario != "" {
+		loaded, err := s.loadScenario(config.Scenario)
+		if err != nil {
+			return nil, err
+		}
+		scenario = loaded
+	}
+
 	// Create game state
-	s.game = game.NewGame(config)
+	g := game.NewGame(config)
+	if config.TrueStarts {
+		if err := mapgen.CheckTrueStarts(g.Players); err != nil {
+			return nil, err
+		}
+	}
+	if scenario != nil {
+		if err := g.SetScenario(scenario); err != nil {
+			return nil, err
+		}
+	}
 
 	// Generate map with players
 	mapConfig := mapgen.GeneratorConfig{
 		Width:         config.MapWidth,
 		Height:        config.MapHeight,
-		Seed:          config.Seed,
+		Seed:          g.Seed,
 		WaterLevel:    0.35,
 		MountainLevel: 0.75,
 		MapType:       config.MapType,
+		TrueStarts:    config.TrueStarts,
+		NavalPlay:     mapgen.HasNavalUnits(),
 	}
 
-	gm := mapgen.GenerateWithPlayers(mapConfig, s.game.Players)
-	s.game.SetMap(gm)
+	gm := mapgen.GenerateWithPlayers(mapConfig, g.Players)
+	g.SetMap(gm)
 
 	// Start the game
-	s.game.Start()
+	g.Start()
+
+	return g, nil
+}
+
+// ResumeAsyncGame continues the async game kept at path
+func (s *Server) ResumeAsyncGame(path string) error {
+	record, err := LoadAsyncRecord(path)
+	if err != nil {
+		return err
+	}
+
+	s.game = DTOToGameState(&record.Game)
+	return s.startHub(record)
+}
 
-	// Create hub for WebSocket connections
-	s.hub = NewHub(s.game)
-	go s.hub.Run()
+// startHub replaces the hub with one for the current game. Async games are
+// kept in storage from here on; record restores a resumed one.
+func (s *Server) startHub(record *AsyncRecord) error {
+	if s.hub != nil {
+		// Close existing hub connections
+		s.hub.Close()
+	}
+	hub, err := launchHub(s.game, s.hubStorage(), s.game.Config.Async, record)
+	s.hub = hub
+	return err
+}
+
+// hubStorage returns where the server's hubs keep their games
+func (s *Server) hubStorage() hubStorage {
+	return hubStorage{
+		savesPath: s.savesPath,
+		store:     s.Store,
+		gamesPath: s.GamesPath,
+		notifier:  s.Notifier,
+
+		preferences: s.Preferences,
+	}
+}
+
+// loadScenario reads a scenario by name from the scenarios directory
+func (s *Server) loadScenario(name string) (*game.Scenario, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid scenario name %q", name)
+	}
+	return game.LoadScenario(filepath.Join(s.ScenariosPath, name+".json"))
 }
 
 // SetupRoutes configures HTTP routes
@@ -70,16 +186,35 @@ func (s *Server) SetupRoutes() http.Handler {
 	mux.HandleFunc("/api/game/save", s.handleSaveGame)
 	mux.HandleFunc("/api/game/load", s.handleLoadGame)
 	mux.HandleFunc("/api/game/saves", s.handleListSaves)
+	mux.HandleFunc("/api/game/export", s.handleExportGame)
+	mux.HandleFunc("/api/game/import", s.handleImportGame)
+	mux.HandleFunc("/api/game/turns", s.handleListTurns)
+	mux.HandleFunc("/api/game/turns/load", s.handleLoadTurn)
+	mux.HandleFunc("/api/game/events", s.handleGetEvents)
+	mux.HandleFunc("/api/game/stats", s.handleGetStats)
+	mux.HandleFunc("/api/game/timeline", s.handleGetTimeline)
+	mux.HandleFunc("/api/game/combatlog", s.handleGetCombatLog)
+	mux.HandleFunc("/api/game/log", s.handleGetGameLog)
+	mux.HandleFunc("/api/game/map.png", s.handleMapImage)
+	mux.HandleFunc("/api/rules", s.handleGetRules)
+	mux.HandleFunc("/api/scenarios", s.handleListScenarios)
+	mux.HandleFunc("/api/locales", s.handleListLocales)
+
+	// Admin API
+	mux.HandleFunc("/api/admin/games", s.handleAdminGames)
+	mux.HandleFunc("/api/admin/games/inspect", s.handleAdminInspect)
+	mux.HandleFunc("/api/admin/games/snapshot", s.handleAdminSnapshot)
+	mux.HandleFunc("/api/admin/games/save", s.handleAdminSave)
+	mux.HandleFunc("/api/admin/games/end-turn", s.handleAdminEndTurn)
 
 	// WebSocket
 	mux.HandleFunc("/ws", s.handleWebSocket)
 
 	// Static files
-	fs := http.FileServer(http.Dir(s.staticPath))
-	mux.Handle("/", fs)
+	mux.Handle("/", http.FileServerFS(s.staticFiles()))
 
 	// Wrap with CORS middleware
-	return corsMiddleware(mux)
+	return s.corsMiddleware(mux)
 }
 
 // handleNewGame creates a new game
@@ -104,14 +239,14 @@ func (s *Server) handleNewGame(w http.ResponseWriter, r *http.Request) {
 	if config.MapWidth < 20 {
 		config.MapWidth = 20
 	}
-	if config.MapWidth > 200 {
-		config.MapWidth = 200
+	if config.MapWidth > MaxMapWidth {
+		config.MapWidth = MaxMapWidth
 	}
 	if config.MapHeight < 20 {
 		config.MapHeight = 20
 	}
-	if config.MapHeight > 200 {
-		config.MapHeight = 200
+	if config.MapHeight > MaxMapHeight {
+		config.MapHeight = MaxMapHeight
 	}
 	if config.PlayerCount < 2 {
 		config.PlayerCount = 2
@@ -119,15 +254,93 @@ func (s *Server) handleNewGame(w http.ResponseWriter, r *http.Request) {
 	if config.PlayerCount > 8 {
 		config.PlayerCount = 8
 	}
+	if config.RandomEventChance < 0 {
+		config.RandomEventChance = 0
+	}
+	if config.RandomEventChance > 100 {
+		config.RandomEventChance = 100
+	}
+	if config.PlunderPercent < 0 {
+		config.PlunderPercent = 0
+	}
+	if config.PlunderPercent > game.MaxPlunderPercent {
+		config.PlunderPercent = game.MaxPlunderPercent
+	}
+	if config.WithdrawChance < 0 {
+		config.WithdrawChance = 0
+	}
+	if config.WithdrawChance > 100 {
+		config.WithdrawChance = 100
+	}
+	if config.AdvancedStart < 0 {
+		config.AdvancedStart = 0
+	}
+	if config.AdvancedStart > game.MaxAdvancedStart {
+		config.AdvancedStart = game.MaxAdvancedStart
+	}
+	if config.TurnTimeout < 0 {
+		config.TurnTimeout = 0
+	}
+	switch config.InactivityPolicy {
+	case "", game.InactivitySkip, game.InactivityAI:
+	default:
+		http.Error(w, "Unknown inactivity policy: "+config.InactivityPolicy, http.StatusBadRequest)
+		return
+	}
+	switch config.AIDifficulty {
+	case "", game.DifficultyNormal, game.DifficultyHard:
+	default:
+		http.Error(w, "Unknown AI difficulty: "+config.AIDifficulty, http.StatusBadRequest)
+		return
+	}
+	if _, ok := game.SpeedCostPercent[config.Speed]; config.Speed != "" && !ok {
+		http.Error(w, "Unknown speed: "+config.Speed, http.StatusBadRequest)
+		return
+	}
+	if config.TrueStarts && config.MapType != "earth" {
+		http.Error(w, "True starts need an earth map", http.StatusBadRequest)
+		return
+	}
+	if config.Locale != "" && !locale.Known(config.Locale) {
+		http.Error(w, "Unknown locale: "+config.Locale, http.StatusBadRequest)
+		return
+	}
+	if config.HumanPlayers < 1 {
+		config.HumanPlayers = 1
+	}
+	if config.HumanPlayers > config.PlayerCount {
+		config.HumanPlayers = config.PlayerCount
+	}
 	if config.PlayerName == "" {
 		config.PlayerName = "Player"
 	}
 
-	s.NewGame(config)
+	// An instance plays the game as one of the games kept, which the
+	// client is then sent to by the cookie
+	var hub *Hub
+	if s.engine != nil {
+		created, err := s.newGame(config)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if hub, err = s.engine.start(created); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: GameCookie, Value: created.ID, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode})
+	} else {
+		if err := s.NewGame(config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hub = s.hub
+	}
 
-	state := GameStateToDTO(s.game)
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(state)
+	hub.gameMu.RLock()
+	data, err := json.Marshal(GameStateToDTO(hub.game))
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
 }
 
 // handleGetGame returns the current game state
@@ -137,14 +350,243 @@ func (s *Server) handleGetGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.game == nil {
-		http.Error(w, "No game in progress", http.StatusNotFound)
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	hub.gameMu.RLock()
+	data, err := json.Marshal(GameStateToDTO(hub.game))
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
+}
+
+// handleGetEvents returns the events applied after the "since" sequence
+// number, as the player given by the "player" parameter may see them: in
+// full only the actions they took themselves
+func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since parameter", http.StatusBadRequest)
+			return
+		}
+		since = parsed
+	}
+
+	id := r.URL.Query().Get("player")
+	if !hub.knowsPlayer(id) {
+		http.Error(w, "Unknown player", http.StatusNotFound)
+		return
+	}
+
+	hub.gameMu.RLock()
+	events := make([]game.Event, 0)
+	for _, e := range hub.game.EventsSince(since) {
+		events = append(events, eventFor(e, id))
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"seq":    hub.game.Seq,
+		"events": events,
+	})
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
+}
+
+// handleGetStats returns every player's standing on each turn so far once
+// the game is over. Before then the "player" parameter names the player
+// whose own standing is returned.
+func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	id := r.URL.Query().Get("player")
+	if !hub.knowsPlayer(id) {
+		http.Error(w, "Unknown player", http.StatusNotFound)
 		return
 	}
+	hub.gameMu.RLock()
+	data, err := json.Marshal(PlayerStatsToDTO(hub.game, id))
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
+}
 
-	state := GameStateToDTO(s.game)
+// handleGetTimeline returns the game's timeline, for drawing a time-lapse
+// of it. It shows every player's borders and units, so it is only served
+// once the game is over.
+func (s *Server) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	hub.gameMu.RLock()
+	if hub.game.Phase != game.PhaseGameOver {
+		hub.gameMu.RUnlock()
+		http.Error(w, "The timeline is served once the game is over", http.StatusForbidden)
+		return
+	}
+	data, err := json.Marshal(TimelineToDTO(hub.game))
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
+}
+
+// handleGetCombatLog returns the last battles fought, oldest first. The
+// "player" parameter limits them to the battles that player fought in.
+func (s *Server) handleGetCombatLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	id := r.URL.Query().Get("player")
+	if !hub.knowsPlayer(id) {
+		http.Error(w, "Unknown player", http.StatusNotFound)
+		return
+	}
+
+	hub.gameMu.RLock()
+	entries := hub.game.CombatLog
+	if id != "" {
+		entries = hub.game.CombatLogFor(id)
+	}
+	if entries == nil {
+		entries = make([]game.CombatLogEntry, 0)
+	}
+	data, err := json.Marshal(entries)
+	hub.gameMu.RUnlock()
+	writeJSON(w, data, err)
+}
+
+// handleGetGameLog returns the game log, oldest first: the entries
+// everyone may read or, with the "player" parameter, those that player
+// may. With "format=text" it is plain text, a line per entry headed by its
+// turn, in the game's language.
+func (s *Server) handleGetGameLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	id := r.URL.Query().Get("player")
+	if !hub.knowsPlayer(id) {
+		http.Error(w, "Unknown player", http.StatusNotFound)
+		return
+	}
+
+	hub.gameMu.RLock()
+	entries := hub.game.GameLogFor(id)
+	language := hub.game.Config.Locale
+	hub.gameMu.RUnlock()
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, e := range entries {
+			fmt.Fprintln(w, e.Line(language))
+		}
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(state)
+	json.NewEncoder(w).Encode(entries)
+}
+
+// handleMapImage renders the map as a PNG. The "player" parameter limits
+// it to what that player has explored and "scale" sets the pixels per tile.
+func (s *Server) handleMapImage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	hub := s.requestHub(w, r)
+	if hub == nil {
+		return
+	}
+	scale := DefaultMapImageScale
+	if v := r.URL.Query().Get("scale"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > MaxMapImageScale {
+			http.Error(w, fmt.Sprintf("Scale must be between 1 and %d", MaxMapImageScale), http.StatusBadRequest)
+			return
+		}
+		scale = parsed
+	}
+
+	id := r.URL.Query().Get("player")
+	if !hub.knowsPlayer(id) {
+		http.Error(w, "Unknown player", http.StatusBadRequest)
+		return
+	}
+
+	// The map is drawn under the game lock, and encoded once it is let go
+	hub.gameMu.RLock()
+	img := RenderMap(hub.game, hub.game.GetPlayer(id), scale)
+	hub.gameMu.RUnlock()
+
+	w.Header().Set("Content-Type", "image/png")
+	if err := png.Encode(w, img); err != nil {
+		log.Printf("Error encoding map image: %v", err)
+	}
+}
+
+// writeJSON writes a response encoded beforehand, so the game lock it was
+// built under is not held while the client reads it
+func writeJSON(w http.ResponseWriter, data []byte, err error) {
+	if err != nil {
+		http.Error(w, "Failed to serialize response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+// handleGetRules returns the unit, building, terrain and resource
+// definitions in play. Costs are given at the "speed" parameter's game
+// speed, or else the speed of the game in progress.
+func (s *Server) handleGetRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	speed := r.URL.Query().Get("speed")
+	if speed == "" && s.game != nil {
+		speed = s.game.Config.Speed
+	}
+	if _, ok := game.SpeedCostPercent[speed]; speed != "" && !ok {
+		http.Error(w, "Unknown speed: "+speed, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(RulesToDTO(speed))
 }
 
 // handleSaveGame saves the current game state to a file
@@ -163,25 +605,18 @@ func (s *Server) handleSaveGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	state := GameStateToDTO(s.game)
-
-	// Generate filename with timestamp
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	filename := fmt.Sprintf("save_%s.json", timestamp)
-	savePath := filepath.Join(s.savesPath, filename)
-
-	// Write to file
-	data, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": false,
-			"error":   "Failed to serialize game state",
-		})
-		return
+	// The body may name the slot to save to
+	var req struct {
+		Slot string `json:"slot"`
+	}
+	if r.ContentLength != 0 {
+		json.NewDecoder(r.Body).Decode(&req)
 	}
 
-	if err := os.WriteFile(savePath, data, 0644); err != nil {
+	s.hub.gameMu.RLock()
+	filename, err := s.saveSlot(s.game, req.Slot)
+	s.hub.gameMu.RUnlock()
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -190,13 +625,13 @@ func (s *Server) handleSaveGame(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Game saved to: %s", savePath)
+	log.Printf("Game saved to: %s", filename)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":  true,
 		"filename": filename,
-		"path":     savePath,
+		"path":     filepath.Join(s.savesPath, filename),
 	})
 }
 
@@ -207,7 +642,7 @@ func (s *Server) handleListSaves(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, err := os.ReadDir(s.savesPath)
+	saves, err := s.listSaves()
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
@@ -217,32 +652,163 @@ func (s *Server) handleListSaves(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type SaveInfo struct {
-		Filename string `json:"filename"`
-		Modified string `json:"modified"`
-		Size     int64  `json:"size"`
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"saves":   saves,
+	})
+}
+
+// handleExportGame downloads a save file, or the current game when no
+// filename is given
+func (s *Server) handleExportGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	saves := make([]SaveInfo, 0)
+	var data []byte
+	filename := r.URL.Query().Get("filename")
+	if filename != "" {
+		savePath, err := s.saveFilePath(filename)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if data, err = os.ReadFile(savePath); err != nil {
+			http.Error(w, "Save file not found", http.StatusNotFound)
+			return
+		}
+	} else {
+		if s.game == nil {
+			http.Error(w, "No game in progress", http.StatusNotFound)
+			return
+		}
+		var err error
+		s.hub.gameMu.RLock()
+		data, err = json.MarshalIndent(SaveToDTO(s.game), "", "  ")
+		s.hub.gameMu.RUnlock()
+		if err != nil {
+			http.Error(w, "Failed to serialize game state", http.StatusInternalServerError)
+			return
+		}
+		filename = fmt.Sprintf("save_%s.json", time.Now().Format("2006-01-02_15-04-05"))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(data)
+}
+
+// handleImportGame stores an uploaded save file, from this or another
+// server, in the saves directory once it has been checked
+func (s *Server) handleImportGame(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fail := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   message,
+		})
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxSaveFileSize))
+	if err != nil {
+		fail(fmt.Sprintf("Failed to read upload: %v", err))
+		return
+	}
+	save, err := ParseSave(data)
+	if err != nil {
+		fail(fmt.Sprintf("Invalid save file: %v", err))
+		return
+	}
+
+	// The save joins the other saves of its game
+	filename := fmt.Sprintf("%s/import_%s.json", save.ID, time.Now().Format("2006-01-02_15-04-05"))
+	savePath, err := s.saveFilePath(filename)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
+		fail(fmt.Sprintf("Failed to write save file: %v", err))
+		return
+	}
+	if err := os.WriteFile(savePath, data, 0644); err != nil {
+		fail(fmt.Sprintf("Failed to write save file: %v", err))
+		return
+	}
+
+	log.Printf("Save imported to: %s", savePath)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"filename": filename,
+	})
+}
+
+// handleListScenarios lists the scenarios a new game can be started with
+func (s *Server) handleListScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type ScenarioInfo struct {
+		ID          string `json:"id"` // Value for the scenario field of a new game
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// A missing directory just means there are no scenarios
+	files, _ := os.ReadDir(s.ScenariosPath)
+	scenarios := make([]ScenarioInfo, 0)
 	for _, file := range files {
 		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
 			continue
 		}
-		info, err := file.Info()
+		id := strings.TrimSuffix(file.Name(), ".json")
+		scenario, err := s.loadScenario(id)
 		if err != nil {
+			log.Printf("Skipping scenario %s: %v", file.Name(), err)
 			continue
 		}
-		saves = append(saves, SaveInfo{
-			Filename: file.Name(),
-			Modified: info.ModTime().Format("2006-01-02 15:04:05"),
-			Size:     info.Size(),
-		})
+		scenarios = append(scenarios, ScenarioInfo{ID: id, Name: scenario.Name, Description: scenario.Description})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   true,
+		"scenarios": scenarios,
+	})
+}
+
+// handleListLocales returns the languages games can be played in
+func (s *Server) handleListLocales(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type LocaleInfo struct {
+		Locale string `json:"locale"` // Value for the locale field of a new game
+		Name   string `json:"name"`
+	}
+
+	locales := make([]LocaleInfo, 0)
+	for _, p := range locale.Available() {
+		locales = append(locales, LocaleInfo{Locale: p.Locale, Name: p.Name})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"saves":   saves,
+		"locales": locales,
 	})
 }
 
@@ -267,7 +833,15 @@ func (s *Server) handleLoadGame(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Read save file
-	savePath := filepath.Join(s.savesPath, req.Filename)
+	savePath, err := s.saveFilePath(req.Filename)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
 	data, err := os.ReadFile(savePath)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -279,8 +853,8 @@ func (s *Server) handleLoadGame(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse save data
-	var saveData GameStateMessage
-	if err := json.Unmarshal(data, &saveData); err != nil {
+	saveData, err := ParseSave(data)
+	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": false,
@@ -290,15 +864,12 @@ func (s *Server) handleLoadGame(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Convert DTO to game state
-	s.game = DTOToGameState(&saveData)
+	s.game = DTOToGameState(saveData)
 
 	// Create new hub for WebSocket connections
-	if s.hub != nil {
-		// Close existing hub connections
-		s.hub.Close()
+	if err := s.startHub(nil); err != nil {
+		log.Printf("Error storing async game: %v", err)
 	}
-	s.hub = NewHub(s.game)
-	go s.hub.Run()
 
 	log.Printf("Game loaded from: %s", savePath)
 
@@ -310,17 +881,24 @@ func (s *Server) handleLoadGame(w http.ResponseWriter, r *http.Request) {
 
 // handleWebSocket handles WebSocket upgrade requests
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	if s.hub == nil {
-		http.Error(w, "No game in progress", http.StatusBadRequest)
-		return
+	if hub := s.requestHub(w, r); hub != nil {
+		hub.HandleWebSocket(w, r)
 	}
-
-	s.hub.HandleWebSocket(w, r)
 }
 
-// corsMiddleware adds CORS headers to responses
-func corsMiddleware(next http.Handler) http.Handler {
+// corsMiddleware adds CORS headers to responses, or with LocalOnly turns
+// away cross-origin requests
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.LocalOnly {
+			if !sameOrigin(r) {
+				http.Error(w, "Cross-origin requests are not allowed", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
@@ -334,18 +912,40 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// staticFiles returns the files of the browser client
+func (s *Server) staticFiles() fs.FS {
+	if s.staticPath == "" {
+		return web.Files
+	}
+	return os.DirFS(s.staticPath)
+}
+
 // Run starts the HTTP server
 func (s *Server) Run(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves HTTP on a listener until it is closed
+func (s *Server) Serve(listener net.Listener) error {
 	handler := s.SetupRoutes()
 
 	// Get absolute path for static files
-	absPath, err := filepath.Abs(s.staticPath)
-	if err != nil {
+	if s.staticPath == "" {
+		log.Printf("Serving the built-in static files")
+	} else if absPath, err := filepath.Abs(s.staticPath); err != nil {
 		log.Printf("Warning: could not resolve static path: %v", err)
 	} else {
 		log.Printf("Serving static files from: %s", absPath)
 	}
 
-	log.Printf("Starting server at %s", addr)
-	return http.ListenAndServe(addr, handler)
+	if s.Retention > 0 {
+		go s.collectGarbageEvery(GarbageCollectionInterval)
+	}
+
+	log.Printf("Starting server at %s", listener.Addr())
+	return http.Serve(listener, handler)
 }
@@ -0,0 +1,440 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStaticFiles checks that the built-in client is served without a web
+// directory, and that one given overrides it
+func TestStaticFiles(t *testing.T) {
+	get := func(s *Server, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	s := &Server{}
+	if w := get(s, "/"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<html") {
+		t.Errorf("built-in index answered %d", w.Code)
+	}
+	if w := get(s, "/js/main.js"); w.Code != http.StatusOK {
+		t.Errorf("built-in script answered %d", w.Code)
+	}
+	if w := get(s, "/embed.go"); w.Code != http.StatusNotFound {
+		t.Errorf("embedding source answered %d, want 404", w.Code)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>dev</html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s = &Server{staticPath: dir}
+	if w := get(s, "/"); w.Body.String() != "<html>dev</html>" {
+		t.Errorf("index from %s was %q", dir, w.Body.String())
+	}
+	if w := get(s, "/js/main.js"); w.Code != http.StatusNotFound {
+		t.Errorf("script missing from %s answered %d, want 404", dir, w.Code)
+	}
+}
+
+// TestLocalOnly checks that a local server turns away pages of other
+// origins and requests naming another host, and that other servers allow
+// them
+func TestLocalOnly(t *testing.T) {
+	get := func(s *Server, host, origin string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4000}))
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, r)
+		return w
+	}
+
+	s := &Server{LocalOnly: true}
+	tests := []struct {
+		host, origin string
+		want         int
+	}{
+		{"127.0.0.1:4000", "", http.StatusOK},
+		{"127.0.0.1:4000", "http://127.0.0.1:4000", http.StatusOK},
+		{"localhost:4000", "http://localhost:4000", http.StatusOK},
+		{"127.0.0.1:4000", "http://127.0.0.1:4001", http.StatusForbidden},
+		{"127.0.0.1:4000", "https://evil.example", http.StatusForbidden},
+		{"127.0.0.1:4000", "null", http.StatusForbidden},
+		// A site whose name was made to resolve to this machine
+		{"evil.example:4000", "", http.StatusForbidden},
+		{"evil.example:4000", "http://evil.example:4000", http.StatusForbidden},
+		{"127.0.0.1:4001", "", http.StatusForbidden},
+		{"127.0.0.1", "", http.StatusForbidden},
+	}
+	for _, c := range tests {
+		w := get(s, c.host, c.origin)
+		if w.Code != c.want {
+			t.Errorf("host %q with origin %q answered %d, want %d", c.host, c.origin, w.Code, c.want)
+		}
+		if allowed := w.Header().Get("Access-Control-Allow-Origin"); allowed != "" {
+			t.Errorf("host %q with origin %q was allowed %q", c.host, c.origin, allowed)
+		}
+	}
+
+	s = &Server{}
+	if w := get(s, "evil.example:4000", "https://evil.example"); w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("open server answered %d allowing %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+// TestWaitIdle checks that a client of a game the engine plays keeps the
+// server from being idle, and that the server is once the client leaves
+func TestWaitIdle(t *testing.T) {
+	prev := IdleCheckInterval
+	IdleCheckInterval = time.Millisecond
+	defer func() { IdleCheckInterval = prev }()
+
+	s := &Server{GamesPath: t.TempDir()}
+	e, err := newEngine("a", s.hubStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.engine = e
+	defer e.close()
+	hub, err := e.start(newEngineGame(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{hub: hub, send: make(chan []byte, 256), playerID: "alice"}
+	hub.mu.Lock()
+	hub.clients[client] = true
+	hub.mu.Unlock()
+
+	idle := make(chan struct{})
+	go func() {
+		s.WaitIdle(20 * time.Millisecond)
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		t.Fatal("server idle with a client connected")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	hub.mu.Lock()
+	delete(hub.clients, client)
+	hub.mu.Unlock()
+	select {
+	case <-idle:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server not idle once the client left")
+	}
+}
+
+// TestGameLog founds a city and checks that everyone may read of it in the
+// game log, as JSON and as text, while a player's private entries are
+// theirs alone
+func TestGameLog(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{hub: c.hub, game: c.hub.game}
+	g := s.game
+	for _, u := range g.GetPlayer("alice").Units {
+		if u.CanFoundCity() {
+			if result := c.hub.submit("alice", &game.FoundCityAction{SettlerID: u.ID, CityName: "Gamma"}); !result.Applied() {
+				t.Fatal(result.Err)
+			}
+		}
+	}
+	g.GameLog = append(g.GameLog, game.LogEntry{Turn: 1, PlayerID: "bob", Private: true, Text: "Beta completed Warrior"})
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	entries := func(target string) []game.LogEntry {
+		var entries []game.LogEntry
+		if err := json.Unmarshal(get(target).Body.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		return entries
+	}
+
+	want := game.LogEntry{Turn: 1, PlayerID: "alice", Text: "alice founded Gamma"}
+	if got := entries("/api/game/log"); len(got) != 1 || got[0] != want {
+		t.Errorf("game log %+v, want %+v", got, want)
+	}
+	if got := entries("/api/game/log?player=alice"); len(got) != 1 {
+		t.Errorf("alice read %+v of the game log, want only the city founded", got)
+	}
+	if got := entries("/api/game/log?player=bob"); len(got) != 2 || got[1].Text != "Beta completed Warrior" {
+		t.Errorf("bob read %+v of the game log, want his own entry too", got)
+	}
+	if text := get("/api/game/log?player=bob&format=text").Body.String(); text != "Turn 1: alice founded Gamma\nTurn 1: Beta completed Warrior\n" {
+		t.Errorf("game log as text %q", text)
+	}
+	if w := get("/api/game/log?player=carol"); w.Code != http.StatusNotFound {
+		t.Errorf("game log of an unknown player answered %d, want 404", w.Code)
+	}
+}
+
+// TestGameEvents founds a city and checks that the events endpoint shows it
+// in full to the player who founded it and to no one else
+func TestGameEvents(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{hub: c.hub, game: c.hub.game}
+	for _, u := range s.game.GetPlayer("alice").Units {
+		if u.CanFoundCity() {
+			if result := c.hub.submit("alice", &game.FoundCityAction{SettlerID: u.ID, CityName: "Gamma"}); !result.Applied() {
+				t.Fatal(result.Err)
+			}
+		}
+	}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	events := func(target string) []game.Event {
+		var body struct {
+			Events []game.Event `json:"events"`
+		}
+		if err := json.Unmarshal(get(target).Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body.Events
+	}
+
+	if got := events("/api/game/events?player=alice"); len(got) != 1 || got[0].Result == nil || string(got[0].Data) == "null" {
+		t.Errorf("alice read events %+v, want the city founded in full", got)
+	}
+	for _, target := range []string{"/api/game/events", "/api/game/events?player=bob"} {
+		if got := events(target); len(got) != 1 || got[0].Type != "found_city" || got[0].Hash == "" || got[0].Result != nil || string(got[0].Data) != "null" {
+			t.Errorf("%s read events %+v, want the city founded without its details", target, got)
+		}
+	}
+	if w := get("/api/game/events?player=carol"); w.Code != http.StatusNotFound {
+		t.Errorf("events of an unknown player answered %d, want 404", w.Code)
+	}
+}
+
+// TestStats checks that before the game is over a player reads only their
+// own standing, and everyone reads every standing once it is
+func TestStats(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{hub: c.hub, game: c.hub.game}
+	for _, id := range []string{"alice", "bob"} {
+		if result := c.hub.submit(id, &game.EndTurnAction{}); !result.Applied() {
+			t.Fatal(result.Err)
+		}
+	}
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	standings := func(target string) map[string]int {
+		var stats StatsMessage
+		if err := json.Unmarshal(get(target).Body.Bytes(), &stats); err != nil {
+			t.Fatal(err)
+		}
+		if len(stats.History) == 0 {
+			t.Fatalf("%s read no history", target)
+		}
+		counts := make(map[string]int)
+		for _, turn := range stats.History {
+			for _, p := range turn.Players {
+				counts[p.PlayerID]++
+			}
+		}
+		return counts
+	}
+
+	turns := len(s.game.History)
+	if got := standings("/api/game/stats?player=alice"); len(got) != 1 || got["alice"] != turns {
+		t.Errorf("alice read standings %v mid-game, want their own on %d turns", got, turns)
+	}
+	if got := standings("/api/game/stats?player=bob"); len(got) != 1 || got["bob"] != turns {
+		t.Errorf("bob read standings %v mid-game, want their own on %d turns", got, turns)
+	}
+	if got := standings("/api/game/stats"); len(got) != 0 {
+		t.Errorf("a client naming no player read standings %v mid-game, want none", got)
+	}
+	if w := get("/api/game/stats?player=carol"); w.Code != http.StatusNotFound {
+		t.Errorf("stats of an unknown player answered %d, want 404", w.Code)
+	}
+
+	s.game.Phase = game.PhaseGameOver
+	if got := standings("/api/game/stats"); got["alice"] != turns || got["bob"] != turns {
+		t.Errorf("read standings %v once the game is over, want both players' on %d turns", got, turns)
+	}
+}
+
+// TestTimeline checks that the timeline is refused before the game is
+// over and served once it is
+func TestTimeline(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{hub: c.hub, game: c.hub.game}
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/game/timeline", nil))
+		return w
+	}
+
+	if w := get(); w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "turns") {
+		t.Errorf("timeline mid-game answered %d with %q, want 403", w.Code, w.Body.String())
+	}
+
+	s.game.Phase = game.PhaseGameOver
+	w := get()
+	var timeline TimelineMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &timeline); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("timeline once the game is over answered %d: %v", w.Code, err)
+	}
+	if len(timeline.Players) != 2 || len(timeline.Turns) != len(s.game.History) {
+		t.Errorf("timeline of %d players and %d turns, want 2 and %d", len(timeline.Players), len(timeline.Turns), len(s.game.History))
+	}
+}
+
+// TestMapImage fetches the map as PNG and checks its size at each scale,
+// and that a player's view hides what they have not explored
+func TestMapImage(t *testing.T) {
+	h := newTestClient(t, "alice").hub
+	s := &Server{hub: h, game: h.game}
+	m := s.game.Map
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.SetupRoutes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+	decode := func(target string) image.Image {
+		t.Helper()
+		w := get(target)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("%s answered %d with %s", target, w.Code, w.Header().Get("Content-Type"))
+		}
+		img, err := png.Decode(w.Body)
+		if err != nil {
+			t.Fatalf("%s: %v", target, err)
+		}
+		return img
+	}
+
+	tests := []struct {
+		target string
+		scale  int
+	}{
+		{"/api/game/map.png", DefaultMapImageScale},
+		{"/api/game/map.png?scale=1", 1},
+		{fmt.Sprintf("/api/game/map.png?scale=%d&player=alice", MaxMapImageScale), MaxMapImageScale},
+	}
+	for _, c := range tests {
+		size := decode(c.target).Bounds().Size()
+		if size.X != m.Width*c.scale || size.Y != m.Height*c.scale {
+			t.Errorf("%s is %v, want %dx%d", c.target, size, m.Width*c.scale, m.Height*c.scale)
+		}
+	}
+
+	// Tiles alice has not explored are black in her view alone
+	alice := s.game.GetPlayer("alice")
+	full, view := decode("/api/game/map.png?scale=1"), decode("/api/game/map.png?scale=1&player=alice")
+	hidden := 0
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			if s.game.IsExplored(alice, x, y) {
+				continue
+			}
+			hidden++
+			if r, g, b, _ := view.At(x, y).RGBA(); r|g|b != 0 {
+				t.Fatalf("(%d, %d) is unexplored but shown to alice", x, y)
+			}
+			if r, g, b, _ := full.At(x, y).RGBA(); r|g|b == 0 {
+				t.Fatalf("(%d, %d) is black on the full map", x, y)
+			}
+		}
+	}
+	if hidden == 0 {
+		t.Error("alice has explored the whole map, nothing is hidden")
+	}
+
+	for _, target := range []string{"/api/game/map.png?scale=0", fmt.Sprintf("/api/game/map.png?scale=%d", MaxMapImageScale+1), "/api/game/map.png?player=carol"} {
+		if w := get(target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s answered %d, want 400", target, w.Code)
+		}
+	}
+}
+
+// TestRESTWhileAITurns plays a turn of an AI while the REST endpoints are
+// read over and over. Run with -race: they must read the game under its
+// lock.
+func TestRESTWhileAITurns(t *testing.T) {
+	b := gametest.New(t,
+		"~~~~~~~~",
+		"~ggphfg~",
+		"~gpgghg~",
+		"~ggfgpg~",
+		"~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerAI)
+	b.Unit("alice", game.UnitWarrior, 3, 2)
+	b.Unit("bob", game.UnitArcher, 4, 2)
+	b.Unit("bob", game.UnitSettler, 5, 3)
+	b.City("alice", "Alpha", 1, 1, 2)
+	b.City("bob", "Beta", 6, 2, 1)
+	h := NewHub(b.Start())
+	go h.Run()
+	defer h.Close()
+	s := &Server{hub: h, game: h.game, savesPath: t.TempDir()}
+	routes := s.SetupRoutes()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		targets := []string{
+			"/api/game", "/api/game/events?player=bob", "/api/game/stats?player=alice",
+			"/api/game/combatlog?player=alice", "/api/game/log", "/api/game/map.png?player=alice", "/api/game/export",
+		}
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			target := targets[i%len(targets)]
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("%s answered %d", target, w.Code)
+				return
+			}
+		}
+	}()
+
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	h.ProcessAITurns()
+	close(stop)
+	<-done
+
+	if !h.game.IsCurrentPlayerTurn("alice") {
+		t.Errorf("turn of %s after the AI's, want alice's", h.game.TurnOrder.Current)
+	}
+}
@@ -0,0 +1,409 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver
+)
+
+// A server may keep the history of its games in a SQLite database: every
+// action played, and a snapshot of the game as each turn began. Any
+// earlier turn can then be loaded again, and after a crash the game is
+// picked up from the start of the turn it was in rather than from the last
+// save. Snapshots leave out the event log, which is rebuilt from the
+// actions up to the snapshot.
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS games (
+	id         TEXT PRIMARY KEY,
+	base       BLOB,              -- Base snapshot of the event log
+	over       INTEGER NOT NULL,  -- 1 once the game has a winner
+	updated_at INTEGER NOT NULL   -- Unix seconds
+);
+CREATE TABLE IF NOT EXISTS actions (
+	game_id TEXT NOT NULL,
+	seq     INTEGER NOT NULL,
+	turn    INTEGER NOT NULL,
+	event   BLOB NOT NULL,        -- The game.Event the action was recorded as
+	PRIMARY KEY (game_id, seq)
+);
+CREATE TABLE IF NOT EXISTS snapshots (
+	game_id  TEXT NOT NULL,
+	turn     INTEGER NOT NULL,
+	seq      INTEGER NOT NULL,    -- Last action before the snapshot
+	saved_at INTEGER NOT NULL,    -- Unix seconds
+	state    BLOB NOT NULL,       -- Save file without its event log
+	PRIMARY KEY (game_id, turn)
+);
+`
+
+// SQLStore records the actions and turn snapshots of games in SQLite
+type SQLStore struct {
+	db *sql.DB
+
+	mu       sync.Mutex
+	recorded map[string]recorded // By game ID
+}
+
+// recorded is how far a game has been recorded
+type recorded struct {
+	seq  uint64 // Last action stored
+	turn int    // Last turn snapshotted
+}
+
+// TurnInfo describes a turn snapshot that can be loaded
+type TurnInfo struct {
+	Turn    int    `json:"turn"`
+	Seq     uint64 `json:"seq"`
+	SavedAt string `json:"saved_at"`
+}
+
+// ErrNoSnapshot is returned for a turn no snapshot was taken of
+var ErrNoSnapshot = errors.New("no snapshot of that turn")
+
+// OpenSQLStore opens the SQLite database at path, creating it if needed
+func OpenSQLStore(path string) (*SQLStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite takes one writer at a time; one connection avoids busy errors
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqlSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating tables in %s: %w", path, err)
+	}
+	return &SQLStore{db: db, recorded: make(map[string]recorded)}, nil
+}
+
+// Close closes the database
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// Record stores the actions of a game played since it was last recorded,
+// and a snapshot when its turn has none yet. A game with fewer actions
+// than were recorded has been taken back to an earlier point, and what was
+// recorded after that point is dropped. Callers must read-hold the game's
+// lock.
+func (s *SQLStore) Record(g *game.GameState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last, ok := s.recorded[g.ID]
+	if ok && last.seq == g.Seq && last.turn == g.CurrentTurn {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !ok || g.Seq < last.seq {
+		if last, err = rewind(tx, g); err != nil {
+			return err
+		}
+	}
+
+	eventLog := g.EventLog()
+	_, err = tx.Exec(`INSERT INTO games (id, base, over, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET base = excluded.base, over = excluded.over, updated_at = excluded.updated_at`,
+		g.ID, []byte(eventLog.Base), g.Phase == game.PhaseGameOver, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	for _, e := range g.EventsSince(last.seq) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO actions (game_id, seq, turn, event) VALUES (?, ?, ?, ?)`,
+			g.ID, e.Seq, e.Turn, data); err != nil {
+			return err
+		}
+	}
+
+	if g.CurrentTurn > last.turn {
+		state := SaveToDTO(g)
+		state.EventLog = nil
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO snapshots (game_id, turn, seq, saved_at, state) VALUES (?, ?, ?, ?, ?)`,
+			g.ID, g.CurrentTurn, g.Seq, time.Now().Unix(), data); err != nil {
+			return err
+		}
+		last.turn = g.CurrentTurn
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	last.seq = g.Seq
+	s.recorded[g.ID] = last
+	return nil
+}
+
+// rewind drops what was recorded of a game after the point it is at, and
+// returns how far it is then recorded
+func rewind(tx *sql.Tx, g *game.GameState) (recorded, error) {
+	if _, err := tx.Exec(`DELETE FROM actions WHERE game_id = ? AND seq > ?`, g.ID, g.Seq); err != nil {
+		return recorded{}, err
+	}
+	if _, err := tx.Exec(`DELETE FROM snapshots WHERE game_id = ? AND (seq > ? OR turn > ?)`, g.ID, g.Seq, g.CurrentTurn); err != nil {
+		return recorded{}, err
+	}
+
+	var last recorded
+	err := tx.QueryRow(`SELECT COALESCE(MAX(seq), 0) FROM actions WHERE game_id = ?`, g.ID).Scan(&last.seq)
+	if err != nil {
+		return recorded{}, err
+	}
+	err = tx.QueryRow(`SELECT COALESCE(MAX(turn), 0) FROM snapshots WHERE game_id = ?`, g.ID).Scan(&last.turn)
+	return last, err
+}
+
+// Turns lists the snapshots taken of a game, earliest first
+func (s *SQLStore) Turns(gameID string) ([]TurnInfo, error) {
+	rows, err := s.db.Query(`SELECT turn, seq, saved_at FROM snapshots WHERE game_id = ? ORDER BY turn`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	turns := make([]TurnInfo, 0)
+	for rows.Next() {
+		var t TurnInfo
+		var savedAt int64
+		if err := rows.Scan(&t.Turn, &t.Seq, &savedAt); err != nil {
+			return nil, err
+		}
+		t.SavedAt = time.Unix(savedAt, 0).Format("2006-01-02 15:04:05")
+		turns = append(turns, t)
+	}
+	return turns, rows.Err()
+}
+
+// LoadTurn returns a game as it was when a turn began, with its event log
+// up to then
+func (s *SQLStore) LoadTurn(gameID string, turn int) (*GameStateMessage, error) {
+	var data []byte
+	var seq uint64
+	err := s.db.QueryRow(`SELECT state, seq FROM snapshots WHERE game_id = ? AND turn = ?`, gameID, turn).Scan(&data, &seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSnapshot
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state GameStateMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid snapshot of turn %d: %w", turn, err)
+	}
+
+	var eventLog game.EventLog
+	if err := s.db.QueryRow(`SELECT base FROM games WHERE id = ?`, gameID).Scan(&data); err != nil {
+		return nil, err
+	}
+	eventLog.Base = data
+	rows, err := s.db.Query(`SELECT event FROM actions WHERE game_id = ? AND seq <= ? ORDER BY seq`, gameID, seq)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	eventLog.Events = make([]game.Event, 0)
+	for rows.Next() {
+		var e game.Event
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("invalid action in turn %d: %w", turn, err)
+		}
+		eventLog.Events = append(eventLog.Events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(eventLog.Base) > 0 {
+		state.EventLog = &eventLog
+	}
+	return &state, nil
+}
+
+// Latest returns the ID and last snapshotted turn of the game recorded
+// most recently that is not over, or "" if there is none
+func (s *SQLStore) Latest() (string, int, error) {
+	var id string
+	var turn int
+	err := s.db.QueryRow(`SELECT g.id, MAX(s.turn) FROM games g JOIN snapshots s ON s.game_id = g.id
+		WHERE g.over = 0 GROUP BY g.id ORDER BY g.updated_at DESC, g.rowid DESC LIMIT 1`).Scan(&id, &turn)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, nil
+	}
+	return id, turn, err
+}
+
+// Delete removes everything recorded of a game
+func (s *SQLStore) Delete(gameID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.recorded, gameID)
+	for _, table := range []string{"actions", "snapshots"} {
+		if _, err := s.db.Exec(`DELETE FROM `+table+` WHERE game_id = ?`, gameID); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.Exec(`DELETE FROM games WHERE id = ?`, gameID)
+	return err
+}
+
+// Prune removes the games last recorded before a time, except one, and
+// returns their IDs
+func (s *SQLStore) Prune(before time.Time, keep string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT id FROM games WHERE updated_at < ? AND id != ?`, before.Unix(), keep)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := s.Delete(id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// record stores the hub's game in its SQL store, when it has one
+func (h *Hub) record() {
+	if h.store == nil {
+		return
+	}
+	h.gameMu.RLock()
+	err := h.store.Record(h.game)
+	h.gameMu.RUnlock()
+	if err != nil {
+		log.Printf("Error recording game history: %v", err)
+	}
+}
+
+// RecoverGame picks up the game recorded most recently that is not over,
+// from the start of the turn it was in. It reports whether there was one.
+func (s *Server) RecoverGame() (bool, error) {
+	if s.Store == nil {
+		return false, nil
+	}
+	id, turn, err := s.Store.Latest()
+	if err != nil || id == "" {
+		return false, err
+	}
+	state, err := s.Store.LoadTurn(id, turn)
+	if err != nil {
+		return false, err
+	}
+
+	s.game = DTOToGameState(state)
+	return true, s.startHub(nil)
+}
+
+// handleListTurns lists the turns of the current game that can be loaded
+func (s *Server) handleListTurns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if s.Store == nil || s.game == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"turns":   []TurnInfo{},
+		})
+		return
+	}
+
+	turns, err := s.Store.Turns(s.game.ID)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Failed to list turns: %v", err),
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"turns":   turns,
+	})
+}
+
+// handleLoadTurn takes the current game back to the start of an earlier
+// turn. What was played after it is dropped from the history.
+func (s *Server) handleLoadTurn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Turn int `json:"turn"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "Invalid request",
+		})
+		return
+	}
+	if s.Store == nil || s.game == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "The server keeps no turn history",
+		})
+		return
+	}
+
+	state, err := s.Store.LoadTurn(s.game.ID, req.Turn)
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   fmt.Sprintf("Failed to load turn %d: %v", req.Turn, err),
+		})
+		return
+	}
+
+	s.game = DTOToGameState(state)
+	if err := s.startHub(nil); err != nil {
+		log.Printf("Error storing async game: %v", err)
+	}
+	log.Printf("Game %s taken back to turn %d", s.game.ID, req.Turn)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
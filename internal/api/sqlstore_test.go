@@ -0,0 +1,77 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// TestSQLStoreTurns records a game turn by turn, loads an earlier turn
+// with its event log, and drops the turns after it once the loaded game is
+// recorded
+func TestSQLStoreTurns(t *testing.T) {
+	store, err := OpenSQLStore(filepath.Join(t.TempDir(), "games.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	g := newTestClient(t, "alice").hub.game
+	record := func() {
+		t.Helper()
+		if err := store.Record(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	endTurn := func(playerID string) {
+		t.Helper()
+		if _, err := g.Apply(playerID, &game.EndTurnAction{}); err != nil {
+			t.Fatal(err)
+		}
+		record()
+	}
+
+	record()
+	endTurn("alice")
+	endTurn("bob")
+	endTurn("alice")
+
+	turns, err := store.Turns(g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turns) != 2 || turns[0].Turn != 1 || turns[1].Turn != 2 || turns[1].Seq != 2 {
+		t.Fatalf("turns %+v, want turns 1 and 2", turns)
+	}
+	if id, turn, err := store.Latest(); err != nil || id != g.ID || turn != 2 {
+		t.Errorf("latest game %q turn %d, %v", id, turn, err)
+	}
+
+	state, err := store.LoadTurn(g.ID, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Turn != 2 || state.EventLog == nil || len(state.EventLog.Events) != 2 {
+		t.Fatalf("turn 2 loaded at turn %d with event log %+v", state.Turn, state.EventLog)
+	}
+
+	state, err = store.LoadTurn(g.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g = DTOToGameState(state)
+	if g.CurrentTurn != 1 || g.Seq != 0 {
+		t.Fatalf("turn 1 loaded at turn %d, seq %d", g.CurrentTurn, g.Seq)
+	}
+	record()
+	if _, err := store.LoadTurn(g.ID, 2); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("turn 2 still loads after going back to turn 1: %v", err)
+	}
+
+	endTurn("alice")
+	endTurn("bob")
+	if turns, _ := store.Turns(g.ID); len(turns) != 2 || turns[1].Seq != 2 {
+		t.Errorf("turns %+v after playing turn 1 again", turns)
+	}
+}
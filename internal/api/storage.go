@@ -0,0 +1,269 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Each game keeps what is stored of it in a directory of its own under the
+// saves directory, named by the game's ID:
+//
+//	<slot>.json   save slots, named by the player or by the time of saving
+//	replay.json   the game's event log, to replay it; rewritten every turn
+//	stats.json    every player's standing on each turn; rewritten every turn
+//	timeline.json the stats cut down for drawing a time-lapse; rewritten
+//	              every turn
+//
+// Saves from before games had directories stay loose in the saves
+// directory. Games nothing was written for in Retention are removed.
+const (
+	ReplayFile   = "replay.json"
+	StatsFile    = "stats.json"
+	TimelineFile = "timeline.json"
+
+	// DefaultRetention is how long the directory of a finished or
+	// abandoned game is kept
+	DefaultRetention = 30 * 24 * time.Hour
+
+	// GarbageCollectionInterval is how often old games are looked for
+	GarbageCollectionInterval = time.Hour
+)
+
+// slotName matches the save slot names players may choose
+var slotName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,40}$`)
+
+// SaveInfo describes a save file
+type SaveInfo struct {
+	Filename string `json:"filename"`          // Relative to the saves directory
+	GameID   string `json:"game_id,omitempty"` // Empty for saves from before per-game directories
+	Slot     string `json:"slot"`
+	Modified string `json:"modified"`
+	Size     int64  `json:"size"`
+
+	modified time.Time
+}
+
+// validFileName reports whether a name can be used in the saves directory
+// without leaving it
+func validFileName(name string) bool {
+	return name != "" && name == filepath.Base(name) && !strings.HasPrefix(name, ".")
+}
+
+// saveSlot writes a game to a save slot in its directory, along with its
+// replay and stats, and returns the save's file name relative to the
+// saves directory. An empty slot is named by the time.
+func (s *Server) saveSlot(g *game.GameState, slot string) (string, error) {
+	if slot == "" {
+		slot = "save_" + time.Now().Format("2006-01-02_15-04-05")
+	}
+	if !slotName.MatchString(slot) || slices.Contains([]string{ReplayFile, StatsFile, TimelineFile}, slot+".json") {
+		return "", fmt.Errorf("invalid save slot %q", slot)
+	}
+
+	data, err := json.MarshalIndent(SaveToDTO(g), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize game state")
+	}
+	if err := writeHistory(s.savesPath, g); err != nil {
+		return "", err
+	}
+	filename := filepath.Join(g.ID, slot+".json")
+	if err := writeFileAtomic(filepath.Join(s.savesPath, filename), data); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(filename), nil
+}
+
+// writeHistory writes a game's replay, stats and timeline to its
+// directory under savesPath
+func writeHistory(savesPath string, g *game.GameState) error {
+	if !validFileName(g.ID) {
+		return fmt.Errorf("invalid game ID %q", g.ID)
+	}
+	dir := filepath.Join(savesPath, g.ID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	replay, err := json.Marshal(g.EventLog())
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, ReplayFile), replay); err != nil {
+		return err
+	}
+	stats, err := json.Marshal(StatsToDTO(g))
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, StatsFile), stats); err != nil {
+		return err
+	}
+	timeline, err := json.Marshal(TimelineToDTO(g))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, TimelineFile), timeline)
+}
+
+// writeHistory writes the hub's game's replay and stats, when the hub
+// keeps them
+func (h *Hub) writeHistory() {
+	if h.savesPath == "" {
+		return
+	}
+	h.gameMu.RLock()
+	err := writeHistory(h.savesPath, h.game)
+	h.gameMu.RUnlock()
+	if err != nil {
+		log.Printf("Error writing game history: %v", err)
+	}
+}
+
+// writeFileAtomic writes a file through a temporary one, so a crash never
+// leaves half of it
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// listSaves returns the save files of every game, newest first
+func (s *Server) listSaves() ([]SaveInfo, error) {
+	entries, err := os.ReadDir(s.savesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	saves := make([]SaveInfo, 0)
+	add := func(gameID string, entry os.DirEntry) {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" || gameID != "" && (name == ReplayFile || name == StatsFile || name == TimelineFile) {
+			return
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return
+		}
+		saves = append(saves, SaveInfo{
+			Filename: filepath.ToSlash(filepath.Join(gameID, name)),
+			GameID:   gameID,
+			Slot:     strings.TrimSuffix(name, ".json"),
+			Modified: info.ModTime().Format("2006-01-02 15:04:05"),
+			Size:     info.Size(),
+			modified: info.ModTime(),
+		})
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			add("", entry)
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(s.savesPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			add(entry.Name(), file)
+		}
+	}
+
+	sort.SliceStable(saves, func(i, j int) bool { return saves[i].modified.After(saves[j].modified) })
+	return saves, nil
+}
+
+// CollectGarbage removes the directories of games nothing was written for
+// in the retention period, their async records and recorded histories,
+// except the game in progress. It returns the IDs of the games removed.
+func (s *Server) CollectGarbage(now time.Time) []string {
+	if s.Retention <= 0 {
+		return nil
+	}
+	current := ""
+	if s.game != nil {
+		current = s.game.ID
+	}
+
+	var removed []string
+	entries, _ := os.ReadDir(s.savesPath)
+	for _, entry := range entries {
+		id := entry.Name()
+		if !entry.IsDir() || id == current {
+			continue
+		}
+		dir := filepath.Join(s.savesPath, id)
+		if now.Sub(lastWritten(dir)) < s.Retention {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("Removing old game %s: %v", id, err)
+			continue
+		}
+		removed = append(removed, id)
+	}
+
+	records, _ := filepath.Glob(filepath.Join(s.GamesPath, "*.json"))
+	for _, path := range records {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		info, err := os.Stat(path)
+		if err != nil || id == current || now.Sub(info.ModTime()) < s.Retention {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Removing old async game %s: %v", id, err)
+			continue
+		}
+		if !slices.Contains(removed, id) {
+			removed = append(removed, id)
+		}
+	}
+
+	if s.Store != nil {
+		pruned, err := s.Store.Prune(now.Add(-s.Retention), current)
+		if err != nil {
+			log.Printf("Removing old game histories: %v", err)
+		}
+		for _, id := range pruned {
+			if !slices.Contains(removed, id) {
+				removed = append(removed, id)
+			}
+		}
+	}
+
+	if len(removed) > 0 {
+		log.Printf("Removed %d games untouched for %v", len(removed), s.Retention)
+	}
+	return removed
+}
+
+// lastWritten returns when a file in a directory was last written
+func lastWritten(dir string) time.Time {
+	var last time.Time
+	files, _ := os.ReadDir(dir)
+	for _, file := range files {
+		if info, err := file.Info(); err == nil && info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last
+}
+
+// collectGarbageEvery removes old games now and at every interval
+func (s *Server) collectGarbageEvery(interval time.Duration) {
+	for {
+		s.CollectGarbage(time.Now())
+		time.Sleep(interval)
+	}
+}
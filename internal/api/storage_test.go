@@ -0,0 +1,58 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestGameStorage saves a game to a slot of its own directory, finds it
+// among the saves, and removes another game's directory once it has been
+// left untouched for the retention period
+func TestGameStorage(t *testing.T) {
+	c := newTestClient(t, "alice")
+	s := &Server{game: c.hub.game, savesPath: t.TempDir(), GamesPath: t.TempDir(), Retention: time.Hour}
+
+	filename, err := s.saveSlot(s.game, "before-war")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := s.game.ID + "/before-war.json"; filename != want {
+		t.Errorf("saved to %s, want %s", filename, want)
+	}
+	for _, name := range []string{ReplayFile, StatsFile, TimelineFile} {
+		if _, err := os.Stat(filepath.Join(s.savesPath, s.game.ID, name)); err != nil {
+			t.Errorf("no %s written: %v", name, err)
+		}
+	}
+	if _, err := s.saveSlot(s.game, "../escape"); err == nil {
+		t.Error("saved to a slot outside the game's directory")
+	}
+
+	saves, err := s.listSaves()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saves) != 1 || saves[0].Filename != filename || saves[0].GameID != s.game.ID {
+		t.Fatalf("saves %+v, want only %s", saves, filename)
+	}
+	if path, err := s.saveFilePath(filename); err != nil || path != filepath.Join(s.savesPath, filename) {
+		t.Errorf("path of %s is %s, %v", filename, path, err)
+	}
+
+	old := filepath.Join(s.savesPath, "abandoned")
+	if err := os.Mkdir(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old, "save.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	removed := s.CollectGarbage(time.Now().Add(2 * time.Hour))
+	if len(removed) != 1 || removed[0] != "abandoned" {
+		t.Errorf("removed %v, want only the abandoned game", removed)
+	}
+	if _, err := os.Stat(filepath.Join(s.savesPath, s.game.ID)); err != nil {
+		t.Errorf("the game in progress was removed: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"slices"
+	"strings"
+)
+
+// The timeline is the stats history cut down to what a time-lapse of the
+// game needs, for tools drawing one: the map's terrain once, then for each
+// turn the tiles that changed hands and every player's units, cities and
+// territory. Players are referred to by their index in Players, and lists
+// of numbers follow the same order.
+
+// TimelineMessage is a game's timeline
+type TimelineMessage struct {
+	GameID  string           `json:"game_id"`
+	Width   int              `json:"width"`
+	Height  int              `json:"height"`
+	Terrain string           `json:"terrain"` // A digit per tile row by row, the terrain as it is now: 0 ocean to 6 forest
+	Players []StatsPlayerDTO `json:"players"`
+	Turns   []TimelineTurn   `json:"turns"`
+}
+
+// TimelineTurn is the game as a turn began
+type TimelineTurn struct {
+	Turn int `json:"turn"`
+
+	// Borders are the tiles that changed hands since the turn before, as
+	// [x, y, player], with -1 for a tile no longer claimed
+	Borders [][3]int `json:"borders,omitempty"`
+
+	Units     []int `json:"units"`
+	Cities    []int `json:"cities"`
+	Territory []int `json:"territory"`
+	Score     []int `json:"score"`
+}
+
+// TimelineToDTO converts a game's stats history to its timeline
+func TimelineToDTO(g *game.GameState) TimelineMessage {
+	stats := StatsToDTO(g)
+	msg := TimelineMessage{
+		GameID:  g.ID,
+		Players: stats.Players,
+		Turns:   make([]TimelineTurn, len(stats.History)),
+	}
+	if g.Map != nil {
+		msg.Width, msg.Height = g.Map.Width, g.Map.Height
+		var terrain strings.Builder
+		for _, tile := range g.Map.Tiles {
+			terrain.WriteByte('0' + byte(tile.Terrain))
+		}
+		msg.Terrain = terrain.String()
+	}
+
+	index := func(playerID string) int {
+		return slices.IndexFunc(msg.Players, func(p StatsPlayerDTO) bool { return p.ID == playerID })
+	}
+	for i, entry := range stats.History {
+		turn := TimelineTurn{
+			Turn:      entry.Turn,
+			Units:     make([]int, len(msg.Players)),
+			Cities:    make([]int, len(msg.Players)),
+			Territory: make([]int, len(msg.Players)),
+			Score:     make([]int, len(msg.Players)),
+		}
+		for _, change := range entry.Borders {
+			turn.Borders = append(turn.Borders, [3]int{change.X, change.Y, index(change.Owner)})
+		}
+		for _, p := range entry.Players {
+			if j := index(p.PlayerID); j >= 0 {
+				turn.Units[j], turn.Cities[j], turn.Territory[j], turn.Score[j] = p.Units, p.Cities, p.Territory, p.Score
+			}
+		}
+		msg.Turns[i] = turn
+	}
+	return msg
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"civilization/internal/game"
+	"encoding/json"
+	"slices"
+)
+
+// A client may subscribe to the rectangle of the map it shows. The game
+// states it is then sent leave out the map's tiles and other players'
+// units outside the rectangle. Each is followed by a viewport_tiles update
+// with the tiles in the rectangle that changed since the client last saw
+// them, and a coarse minimap of the whole map when that changed, and by a
+// viewport_activity update counting, per player, the units that moved
+// outside the rectangle.
+const (
+	MaxViewportSize  = 128 // Widest and tallest rectangle a client may subscribe to
+	MinimapBlockSize = 8   // Tiles per side of a cell of the coarse minimap
+)
+
+// Updates sent to clients subscribed to a viewport
+const (
+	UpdateViewportTiles    = "viewport_tiles"
+	UpdateViewportActivity = "viewport_activity"
+)
+
+// ViewportMessage is sent by a client to subscribe to a rectangle of the
+// map, or with no width or height to end its subscription
+type ViewportMessage struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ViewportTilesMessage carries the tiles of a client's viewport that
+// changed since it last saw them
+type ViewportTilesMessage struct {
+	Viewport ViewportMessage `json:"viewport"` // The rectangle, as clamped to the map
+	Tiles    []TileDTO       `json:"tiles"`
+	Minimap  *MinimapDTO     `json:"minimap,omitempty"` // Set when it changed
+}
+
+// MinimapDTO is a coarse picture of the whole map, one cell for each
+// block of MinimapBlockSize by MinimapBlockSize tiles
+type MinimapDTO struct {
+	BlockSize int      `json:"block_size"`
+	Width     int      `json:"width"`   // In cells
+	Height    int      `json:"height"`  // In cells
+	Terrain   []string `json:"terrain"` // Commonest terrain of each cell, row by row
+	Owners    []string `json:"owners"`  // Player owning most of each cell's tiles, or ""
+}
+
+// ActivityDTO counts one player's units that moved outside a client's
+// viewport since its last game state
+type ActivityDTO struct {
+	PlayerID string `json:"player_id"`
+	Moved    int    `json:"moved,omitempty"`
+	Appeared int    `json:"appeared,omitempty"` // Built, or first seen
+	Gone     int    `json:"gone,omitempty"`     // Lost, or disbanded
+}
+
+// viewport is the hub's record of a client's subscription and of what it
+// has been sent since
+type viewport struct {
+	ViewportMessage
+	tiles   map[int]TileDTO     // The tiles in the rectangle as last sent
+	units   map[string]sighting // Other players' units where last sent
+	minimap *MinimapDTO         // As last sent
+}
+
+// sighting is where a unit of another player was last seen
+type sighting struct {
+	owner string
+	x, y  int
+}
+
+// contains reports whether a tile is in the viewport
+func (v *viewport) contains(x, y int) bool {
+	return x >= v.X && x < v.X+v.Width && y >= v.Y && y < v.Y+v.Height
+}
+
+// clampViewport fits a rectangle to the map and to MaxViewportSize
+func clampViewport(rect ViewportMessage, m *game.GameMap) ViewportMessage {
+	rect.X = max(0, min(rect.X, m.Width-1))
+	rect.Y = max(0, min(rect.Y, m.Height-1))
+	rect.Width = min(rect.Width, MaxViewportSize, m.Width-rect.X)
+	rect.Height = min(rect.Height, MaxViewportSize, m.Height-rect.Y)
+	return rect
+}
+
+// messages returns what a subscribed client is sent for a game state: the
+// state cut down to its viewport, the tiles that changed and the activity
+// outside. Callers must read-hold the game lock.
+func (v *viewport) messages(g *game.GameState, playerID string, state GameStateMessage) [][]byte {
+	messages := [][]byte{encodeMessage(MsgTypeGameState, v.cut(playerID, state))}
+
+	tiles := v.changedTiles(g.Map, hiddenResources(g, playerID))
+	update := ViewportTilesMessage{Viewport: v.ViewportMessage, Tiles: tiles}
+	if minimap := MinimapToDTO(g.Map); v.minimap == nil || !minimap.equal(v.minimap) {
+		update.Minimap = minimap
+		v.minimap = minimap
+	}
+	if len(update.Tiles) > 0 || update.Minimap != nil {
+		messages = append(messages, encodeUpdate(UpdateViewportTiles, update))
+	}
+
+	if activity := v.activity(playerID, state); len(activity) > 0 {
+		messages = append(messages, encodeUpdate(UpdateViewportActivity, activity))
+	}
+	return messages
+}
+
+// cut leaves out of a game state the map's tiles and other players' units
+// outside the viewport
+func (v *viewport) cut(playerID string, state GameStateMessage) GameStateMessage {
+	state.Map.Tiles = nil
+	state.Map.ChunkSize = MapChunkSize
+	state.Players = slices.Clone(state.Players)
+	for i, p := range state.Players {
+		if p.ID == playerID {
+			continue
+		}
+		state.Players[i].Units = slices.DeleteFunc(slices.Clone(p.Units), func(u UnitDTO) bool {
+			return !v.contains(u.X, u.Y)
+		})
+	}
+	return state
+}
+
+// changedTiles returns the tiles in the viewport that changed since they
+// were last sent, without the hidden resources, and remembers them as sent
+func (v *viewport) changedTiles(m *game.GameMap, hidden map[string]bool) []TileDTO {
+	for i := range v.tiles {
+		if !v.contains(i%m.Width, i/m.Width) {
+			delete(v.tiles, i)
+		}
+	}
+
+	var changed []TileDTO
+	for y := v.Y; y < v.Y+v.Height; y++ {
+		for x := v.X; x < v.X+v.Width; x++ {
+			i := m.Index(x, y)
+			tile := TileToDTO(&m.Tiles[i])
+			if hidden[tile.Resource] {
+				tile.Resource = ""
+			}
+			if last, ok := v.tiles[i]; !ok || last != tile {
+				changed = append(changed, tile)
+				v.tiles[i] = tile
+			}
+		}
+	}
+	return changed
+}
+
+// activity counts, per player, the units that moved, appeared or were
+// gone outside the viewport since the last game state. Nothing is counted
+// for the first one.
+func (v *viewport) activity(playerID string, state GameStateMessage) []ActivityDTO {
+	first := v.units == nil
+	seen := make(map[string]sighting)
+	var activity []ActivityDTO
+	for _, p := range state.Players {
+		if p.ID == playerID {
+			continue
+		}
+		a := ActivityDTO{PlayerID: p.ID}
+		for _, u := range p.Units {
+			seen[u.ID] = sighting{owner: p.ID, x: u.X, y: u.Y}
+			if v.contains(u.X, u.Y) {
+				continue
+			}
+			last, known := v.units[u.ID]
+			switch {
+			case !known:
+				a.Appeared++
+			case last.x != u.X || last.y != u.Y:
+				a.Moved++
+			}
+		}
+		for id, last := range v.units {
+			if _, ok := seen[id]; !ok && last.owner == p.ID && !v.contains(last.x, last.y) {
+				a.Gone++
+			}
+		}
+		if a.Moved+a.Appeared+a.Gone > 0 {
+			activity = append(activity, a)
+		}
+	}
+
+	v.units = seen
+	if first {
+		return nil
+	}
+	return activity
+}
+
+// MinimapToDTO draws the coarse minimap of a map
+func MinimapToDTO(m *game.GameMap) *MinimapDTO {
+	dto := &MinimapDTO{
+		BlockSize: MinimapBlockSize,
+		Width:     (m.Width + MinimapBlockSize - 1) / MinimapBlockSize,
+		Height:    (m.Height + MinimapBlockSize - 1) / MinimapBlockSize,
+	}
+	dto.Terrain = make([]string, 0, dto.Width*dto.Height)
+	dto.Owners = make([]string, 0, dto.Width*dto.Height)
+
+	terrain := make(map[game.TerrainType]int)
+	owners := make(map[string]int)
+	for by := 0; by < dto.Height; by++ {
+		for bx := 0; bx < dto.Width; bx++ {
+			clear(terrain)
+			clear(owners)
+			for y := by * MinimapBlockSize; y < min((by+1)*MinimapBlockSize, m.Height); y++ {
+				for x := bx * MinimapBlockSize; x < min((bx+1)*MinimapBlockSize, m.Width); x++ {
+					tile := m.GetTile(x, y)
+					terrain[tile.Terrain]++
+					owners[tile.Owner]++
+				}
+			}
+			dto.Terrain = append(dto.Terrain, commonest(terrain).String())
+			dto.Owners = append(dto.Owners, commonest(owners))
+		}
+	}
+	return dto
+}
+
+// commonest returns the key counted most often, the least key on a tie so
+// that the answer does not hang on map order
+func commonest[K game.TerrainType | string](counts map[K]int) K {
+	var best K
+	bestCount := -1
+	for k, n := range counts {
+		if n > bestCount || n == bestCount && k < best {
+			best, bestCount = k, n
+		}
+	}
+	return best
+}
+
+// equal reports whether two minimaps are the same
+func (m *MinimapDTO) equal(other *MinimapDTO) bool {
+	return m.Width == other.Width && m.Height == other.Height &&
+		slices.Equal(m.Terrain, other.Terrain) && slices.Equal(m.Owners, other.Owners)
+}
+
+// encodeMessage encodes a message to send over WebSocket
+func encodeMessage(msgType MessageType, v interface{}) []byte {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		payload = []byte("null")
+	}
+	data, _ := json.Marshal(WSMessage{Type: msgType, Payload: payload})
+	return data
+}
+
+// encodeUpdate encodes an incremental state update to send over WebSocket
+func encodeUpdate(updateType string, entity interface{}) []byte {
+	return encodeMessage(MsgTypeUpdate, UpdateMessage{UpdateType: updateType, Entity: entity})
+}
+
+// subscribe sets the viewport a client is subscribed to, or ends its
+// subscription, and sends it the game state that goes with it
+func (h *Hub) subscribe(c *Client, rect ViewportMessage) {
+	h.gameMu.RLock()
+	state := ClientStateToDTO(h.game)
+
+	var messages [][]byte
+	h.mu.Lock()
+	if rect.Width <= 0 || rect.Height <= 0 {
+		c.view = nil
+		messages = [][]byte{encodeMessage(MsgTypeGameState, playerState(h.game, c.playerID, state))}
+	} else {
+		if c.view == nil {
+			c.view = &viewport{tiles: make(map[int]TileDTO)}
+		}
+		c.view.ViewportMessage = clampViewport(rect, h.game.Map)
+		messages = c.view.messages(h.game, c.playerID, state)
+	}
+	h.mu.Unlock()
+	h.gameMu.RUnlock()
+
+	h.queue(outbound{perClient: map[*Client][][]byte{c: messages}, state: true})
+}
+
+// clientMessages returns what each client that is not sent the game state
+// as it is is sent instead: those subscribed to a viewport, and those whose
+// player cannot see every resource. Callers must read-hold the game lock.
+func (h *Hub) clientMessages(state GameStateMessage) map[*Client][][]byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var perClient map[*Client][][]byte
+	byPlayer := make(map[string][]byte)
+	for client := range h.clients {
+		var messages [][]byte
+		if client.view != nil {
+			messages = client.view.messages(h.game, client.playerID, state)
+		} else if hiddenResources(h.game, client.playerID) != nil {
+			data, ok := byPlayer[client.playerID]
+			if !ok {
+				data = encodeMessage(MsgTypeGameState, playerState(h.game, client.playerID, state))
+				byPlayer[client.playerID] = data
+			}
+			messages = [][]byte{data}
+		} else {
+			continue
+		}
+
+		if perClient == nil {
+			perClient = make(map[*Client][][]byte)
+		}
+		perClient[client] = messages
+	}
+	return perClient
+}
+
+// handleViewport subscribes the client to a rectangle of the map
+func (c *Client) handleViewport(payload json.RawMessage) {
+	var msg ViewportMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		c.sendError(CodeInvalidMessage, err.Error())
+		return
+	}
+	c.hub.subscribe(c, msg)
+}
@@ -3,9 +3,13 @@ package api
 import (
 	"civilization/internal/ai"
 	"civilization/internal/game"
+	"civilization/internal/locale"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -22,32 +26,79 @@ var upgrader = websocket.Upgrader{
 
 // Hub manages WebSocket connections and game state
 type Hub struct {
-	game       *game.GameState
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
-	mu         sync.RWMutex
+	game          *game.GameState
+	clients       map[*Client]bool
+	broadcast     chan outbound
+	register      chan *Client
+	unregister    chan *Client
+	mu            sync.RWMutex
+	gameMu        sync.RWMutex // Held to change the game, read-held to read it
 	aiControllers map[string]*ai.Controller
+	async         *asyncGame       // Set for async games
+	savesPath     string           // Where the game's replay and stats are kept each turn, if set
+	store         *SQLStore        // Records the game's actions and turn snapshots, if set
+	preferences   *PreferenceStore // Keeps the preferences of clients' profiles, if set
+
+	// Host controls, guarded by mu
+	host      string          // Player who may pause, kick and change the turn timer
+	paused    bool            // No actions or AI turns while paused
+	kicked    map[string]bool // Players removed by the host
+	aiRunning bool            // ProcessAITurns is playing
+
+	failed string // Why the game failed, if it did; guarded by mu
+
+	published []game.BusEvent // Events of the action being applied, guarded by gameMu
+
+	done chan struct{} // Closed once the hub is closed, stopping its loop
 }
 
 // Client represents a WebSocket client
 type Client struct {
 	hub      *Hub
-	conn     *websocket.Conn
+	conn     wsConn
 	send     chan []byte
 	playerID string
+	profile  string    // Key its player's preferences are kept by, if it gave one
+	view     *viewport // Set while subscribed to a viewport, guarded by the hub's mu
+	out      outbox    // Messages held while the client is slow
+}
+
+// outbound is a message for the hub's loop to send to every client.
+// Clients in perClient are sent their own messages instead, and the others
+// data unless it is nil. With state set, data and the first of each
+// client's own messages are full game states.
+type outbound struct {
+	data      []byte
+	perClient map[*Client][][]byte
+	state     bool
+}
+
+// wsConn is the connection a client talks over. *websocket.Conn implements
+// it; tests give clients their own.
+type wsConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	NextWriter(messageType int) (io.WriteCloser, error)
+	SetReadLimit(limit int64)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	Close() error
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub(g *game.GameState) *Hub {
 	h := &Hub{
-		game:       g,
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		game:          g,
+		clients:       make(map[*Client]bool),
+		broadcast:     make(chan outbound, 256),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
 		aiControllers: make(map[string]*ai.Controller),
+		host:          firstHuman(g),
+		kicked:        make(map[string]bool),
+		done:          make(chan struct{}),
 	}
 
 	// Create AI controllers for AI players
@@ -56,12 +107,16 @@ func NewHub(g *game.GameState) *Hub {
 			h.aiControllers[player.ID] = ai.NewController(g, player.ID)
 		}
 	}
+	g.Bus().Subscribe(h.collect)
 
 	return h
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run() {
+	ticker := time.NewTicker(SlowClientCheckInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case client := <-h.register:
@@ -69,46 +124,86 @@ func (h *Hub) Run() {
 			h.clients[client] = true
 			h.mu.Unlock()
 
-			// Send initial game state
+			// Tell the client who it plays and who hosts, then send
+			// initial game state
+			h.sendWelcome(client)
+			h.sendHostState(client)
+			h.sendPreferences(client)
 			h.sendGameState(client)
+			h.SendTurnStatus()
+			if h.failure() != "" {
+				client.sendError(CodeGameFailed, "")
+			}
 
 		case client := <-h.unregister:
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
-				close(client.send)
+				client.closeSend()
 			}
 			h.mu.Unlock()
 
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+				messages, ok := message.perClient[client]
+				if !ok && message.data != nil {
+					messages = [][]byte{message.data}
+				}
+				for i, data := range messages {
+					client.enqueue(data, message.state && i == 0)
 				}
 			}
 			h.mu.RUnlock()
+
+		case now := <-ticker.C:
+			h.catchUpClients(now)
+
+		case <-h.done:
+			return
 		}
 	}
 }
 
-// Close closes all client connections
+// queue hands a message to the hub's loop to send, dropping it once the
+// hub is closed
+func (h *Hub) queue(message outbound) {
+	select {
+	case h.broadcast <- message:
+	case <-h.done:
+	}
+}
+
+// Close closes all client connections and stops the hub's loop
 func (h *Hub) Close() {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	if h.async != nil {
+		close(h.async.done)
+	}
+	close(h.done)
+
 	for client := range h.clients {
 		client.conn.Close()
-		close(client.send)
+		client.closeSend()
 		delete(h.clients, client)
 	}
 }
 
+// sendWelcome tells a client which player it plays
+func (h *Hub) sendWelcome(client *Client) {
+	payload, _ := json.Marshal(WelcomeMessage{PlayerID: client.playerID})
+	data, _ := json.Marshal(WSMessage{
+		Type:    MsgTypeWelcome,
+		Payload: payload,
+	})
+	client.enqueue(data, false)
+}
+
 // sendGameState sends the full game state to a client
 func (h *Hub) sendGameState(client *Client) {
+	h.gameMu.RLock()
 	// Log player units before conversion
 	for _, p := range h.game.Players {
 		log.Printf("Player %s has %d units before DTO conversion", p.Name, len(p.Units))
@@ -116,8 +211,8 @@ func (h *Hub) sendGameState(client *Client) {
 			log.Printf("  Unit: %s type=%d at (%d,%d)", u.ID, u.Type, u.X, u.Y)
 		}
 	}
-
-	state := GameStateToDTO(h.game)
+	state := playerState(h.game, client.playerID, ClientStateToDTO(h.game))
+	h.gameMu.RUnlock()
 
 	// Log player units after conversion
 	for _, p := range state.Players {
@@ -140,17 +235,22 @@ func (h *Hub) sendGameState(client *Client) {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
+	client.enqueue(data, true)
+}
 
-	select {
-	case client.send <- data:
-	default:
-		log.Println("Client send buffer full")
-	}
+// pack returns the language pack of the game
+func (h *Hub) pack() *locale.Pack {
+	return locale.Get(h.game.Config.Locale)
 }
 
-// BroadcastGameState sends the game state to all clients
+// BroadcastGameState sends the game state to all clients, cut down to
+// their viewport for those subscribed to one and without the resources
+// their player cannot see
 func (h *Hub) BroadcastGameState() {
-	state := GameStateToDTO(h.game)
+	h.gameMu.RLock()
+	state := ClientStateToDTO(h.game)
+	perClient := h.clientMessages(state)
+	h.gameMu.RUnlock()
 	payload, err := json.Marshal(state)
 	if err != nil {
 		log.Printf("Error marshaling game state: %v", err)
@@ -168,11 +268,100 @@ func (h *Hub) BroadcastGameState() {
 		return
 	}
 
-	h.broadcast <- data
+	h.queue(outbound{data: data, perClient: perClient, state: true})
+}
+
+// BroadcastEvent sends the event an action was recorded as to all clients,
+// each as their player may see it
+func (h *Hub) BroadcastEvent(result ActionResult) {
+	event := result.Event
+	perClient := make(map[*Client][][]byte)
+	byPlayer := make(map[string][]byte)
+	h.mu.RLock()
+	for client := range h.clients {
+		data, ok := byPlayer[client.playerID]
+		if !ok {
+			data = encodeMessage(MsgTypeEvent, playerEvent(result, client.playerID))
+			byPlayer[client.playerID] = data
+		}
+		perClient[client] = [][]byte{data}
+	}
+	h.mu.RUnlock()
+	h.queue(outbound{perClient: perClient})
+
+	if len(event.Borders) > 0 {
+		h.BroadcastUpdate(UpdateBorders, event.Borders)
+	}
+	for _, promotion := range event.Promotions {
+		h.BroadcastUpdate(UpdateUnitPromoted, promotion)
+	}
+
+	// What a player explores is theirs to know
+	for playerID, tiles := range event.Revealed {
+		h.sendUpdate(playerID, UpdateReveal, tiles)
+	}
+}
+
+// playerEvent returns the message of an event as a player is sent it, with
+// the hash of their own units alone
+func playerEvent(result ActionResult, playerID string) EventMessage {
+	msg := EventMessage{Event: eventFor(*result.Event, playerID)}
+	if hash, ok := result.UnitHashes[playerID]; ok {
+		msg.UnitHashes = map[string]string{playerID: hash}
+	}
+	return msg
+}
+
+// eventFor returns an event as a player may see it. The player who took the
+// action sees all of it; the others only where it leaves the game, its
+// sequence number, turn and hash, and who took what kind of action, not
+// what it was aimed at or what it changed.
+func eventFor(event game.Event, playerID string) game.Event {
+	if event.PlayerID == playerID {
+		return event
+	}
+	return game.Event{Seq: event.Seq, Turn: event.Turn, PlayerID: event.PlayerID, Type: event.Type, Hash: event.Hash}
+}
+
+// BroadcastUpdate sends an incremental state update to all clients
+func (h *Hub) BroadcastUpdate(updateType string, entity interface{}) {
+	payload, err := json.Marshal(UpdateMessage{
+		UpdateType: updateType,
+		Entity:     entity,
+	})
+	if err != nil {
+		log.Printf("Error marshaling update: %v", err)
+		return
+	}
+
+	data, _ := json.Marshal(WSMessage{
+		Type:    MsgTypeUpdate,
+		Payload: payload,
+	})
+	h.queue(outbound{data: data})
+}
+
+// sendUpdate sends an incremental state update to the clients of one player
+func (h *Hub) sendUpdate(playerID, updateType string, entity interface{}) {
+	payload, err := json.Marshal(UpdateMessage{
+		UpdateType: updateType,
+		Entity:     entity,
+	})
+	if err != nil {
+		log.Printf("Error marshaling update: %v", err)
+		return
+	}
+
+	data, _ := json.Marshal(WSMessage{
+		Type:    MsgTypeUpdate,
+		Payload: payload,
+	})
+	h.sendToPlayer(playerID, data)
 }
 
 // BroadcastTurnChange notifies clients of a turn change
 func (h *Hub) BroadcastTurnChange() {
+	h.gameMu.RLock()
 	currentPlayer := h.game.GetCurrentPlayer()
 	msg := TurnChangeMessage{
 		Turn:          h.game.CurrentTurn,
@@ -180,6 +369,7 @@ func (h *Hub) BroadcastTurnChange() {
 		PlayerName:    currentPlayer.Name,
 		Phase:         h.game.Phase.String(),
 	}
+	h.gameMu.RUnlock()
 
 	payload, _ := json.Marshal(msg)
 	wsMsg := WSMessage{
@@ -188,11 +378,11 @@ func (h *Hub) BroadcastTurnChange() {
 	}
 
 	data, _ := json.Marshal(wsMsg)
-	h.broadcast <- data
+	h.queue(outbound{data: data})
 }
 
 // BroadcastError sends an error to all clients
-func (h *Hub) BroadcastError(code, message string) {
+func (h *Hub) BroadcastError(code ErrorCode, message string) {
 	errMsg := ErrorMessage{
 		Code:    code,
 		Message: message,
@@ -205,13 +395,42 @@ func (h *Hub) BroadcastError(code, message string) {
 	}
 
 	data, _ := json.Marshal(wsMsg)
-	h.broadcast <- data
+	h.queue(outbound{data: data})
 }
 
-// ProcessAITurns processes all AI turns
+// ProcessAITurns processes all AI turns. It stops between players while
+// the game is paused, and carries on when the host resumes it. A panic in
+// an AI's turn fails the game.
 func (h *Hub) ProcessAITurns() {
-	for h.game.Phase == game.PhaseAITurn {
+	h.mu.Lock()
+	if h.aiRunning {
+		h.mu.Unlock()
+		return
+	}
+	h.aiRunning = true
+	h.mu.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			h.mu.Lock()
+			h.aiRunning = false
+			h.mu.Unlock()
+			h.fail("AI turns", r, debug.Stack())
+		}
+	}()
+
+	for h.phase() == game.PhaseAITurn {
+		h.mu.Lock()
+		if h.paused || h.failed != "" {
+			h.aiRunning = false
+			h.mu.Unlock()
+			return
+		}
+		h.mu.Unlock()
+
+		h.gameMu.RLock()
 		currentPlayer := h.game.GetCurrentPlayer()
+		h.gameMu.RUnlock()
 		if currentPlayer == nil {
 			break
 		}
@@ -219,7 +438,9 @@ func (h *Hub) ProcessAITurns() {
 		controller := h.aiControllers[currentPlayer.ID]
 		if controller == nil {
 			// No AI controller, just end turn
-			h.game.EndTurn()
+			if result := h.submit(currentPlayer.ID, &game.EndTurnAction{}); result.Applied() {
+				h.BroadcastEvent(result)
+			}
 			continue
 		}
 
@@ -227,10 +448,9 @@ func (h *Hub) ProcessAITurns() {
 		time.Sleep(100 * time.Millisecond)
 
 		// Execute AI actions
-		actions := controller.TakeTurn()
-		for _, action := range actions {
-			if err := action.Validate(h.game, currentPlayer.ID); err == nil {
-				action.Execute(h.game)
+		for _, action := range h.planAITurn(controller) {
+			if result := h.submit(currentPlayer.ID, action); result.Applied() {
+				h.BroadcastEvent(result)
 			}
 		}
 
@@ -238,8 +458,149 @@ func (h *Hub) ProcessAITurns() {
 		h.BroadcastGameState()
 	}
 
+	h.mu.Lock()
+	h.aiRunning = false
+	h.mu.Unlock()
+
 	// Notify turn change after AI turns complete
 	h.BroadcastTurnChange()
+	h.SendTurnSummary()
+	h.SendAdvice()
+	h.SendTurnStatus()
+	h.turnChanged()
+}
+
+// phase returns the phase the game is in
+func (h *Hub) phase() game.GamePhase {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	return h.game.Phase
+}
+
+// knowsPlayer reports whether a player plays in the game; an empty ID
+// stands for none in particular. The players are set as the game is made,
+// so this needs no game lock.
+func (h *Hub) knowsPlayer(playerID string) bool {
+	return playerID == "" || h.game.GetPlayer(playerID) != nil
+}
+
+// playersToMove returns the players now playing, when the game is in a
+// phase players move in
+func (h *Hub) playersToMove() []*game.Player {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	if h.game.Phase != game.PhasePlayerTurn && h.game.Phase != game.PhaseSimultaneous {
+		return nil
+	}
+	return h.game.PlayersToMove()
+}
+
+// playersOnTurn returns the players whose turn it is to act: those now
+// playing who have not yet ended their turn or submitted their orders
+func (h *Hub) playersOnTurn() []*game.Player {
+	h.gameMu.RLock()
+	defer h.gameMu.RUnlock()
+	players := make([]*game.Player, 0)
+	for _, p := range h.game.PlayersToMove() {
+		if h.game.IsCurrentPlayerTurn(p.ID) {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// planAITurn has an AI player's controller decide its actions for the
+// turn. The controller plans on a copy of the game, so nothing it does
+// while it thinks can touch the game clients are reading; only the
+// actions it returns change the game, applied as any player's are.
+func (h *Hub) planAITurn(controller *ai.Controller) []game.Action {
+	h.gameMu.RLock()
+	g := h.game.Clone()
+	h.gameMu.RUnlock()
+
+	defer func(live *game.GameState) { controller.Game = live }(controller.Game)
+	controller.Game = g
+	return controller.TakeTurn()
+}
+
+// SendTurnSummary sends the players now playing the summary of what
+// happened since their last turn
+func (h *Hub) SendTurnSummary() {
+	for _, player := range h.playersToMove() {
+		h.gameMu.Lock()
+		report := h.game.TakeTurnReport(player.ID)
+		h.gameMu.Unlock()
+		if report == nil {
+			continue
+		}
+
+		payload, err := json.Marshal(TurnReportToDTO(report))
+		if err != nil {
+			log.Printf("Error marshaling turn summary: %v", err)
+			return
+		}
+
+		data, _ := json.Marshal(WSMessage{
+			Type:    MsgTypeTurnSummary,
+			Payload: payload,
+		})
+
+		h.sendToPlayer(player.ID, data)
+	}
+}
+
+// SendAdvice sends the players now playing the advisor's hints, in games
+// with the advisor
+func (h *Hub) SendAdvice() {
+	if !h.game.Config.Advisor {
+		return
+	}
+
+	for _, player := range h.playersToMove() {
+		h.gameMu.RLock()
+		hints := h.game.Advise(player.ID)
+		turn := h.game.CurrentTurn
+		h.gameMu.RUnlock()
+		if len(hints) == 0 {
+			continue
+		}
+		h.sendToPlayer(player.ID, encodeMessage(MsgTypeAdvice, AdviceMessage{PlayerID: player.ID, Turn: turn, Hints: hints}))
+	}
+}
+
+// SendTurnStatus tells the players now playing which units and cities are
+// still waiting for orders
+func (h *Hub) SendTurnStatus() {
+	for _, player := range h.playersToMove() {
+		h.gameMu.RLock()
+		status := h.game.TurnStatus(player.ID)
+		h.gameMu.RUnlock()
+
+		payload, err := json.Marshal(status)
+		if err != nil {
+			log.Printf("Error marshaling turn status: %v", err)
+			return
+		}
+
+		data, _ := json.Marshal(WSMessage{
+			Type:    MsgTypeTurnStatus,
+			Payload: payload,
+		})
+
+		h.sendToPlayer(player.ID, data)
+	}
+}
+
+// sendToPlayer sends a message to every client playing as the given player
+func (h *Hub) sendToPlayer(playerID string, data []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		if client.playerID != playerID {
+			continue
+		}
+		client.enqueue(data, false)
+	}
 }
 
 // HandleWebSocket handles WebSocket upgrade requests
@@ -249,39 +610,83 @@ func (h *Hub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
+	h.seat(conn, clientProfile(r.URL.Query().Get(ProfileParam))).serve()
+}
 
-	// Get the human player ID
-	humanPlayer := h.game.GetHumanPlayer()
-	playerID := ""
-	if humanPlayer != nil {
-		playerID = humanPlayer.ID
+// seat registers a client talking over conn, in the first free human seat,
+// with the profile its preferences are kept by
+func (h *Hub) seat(conn wsConn, profile string) *Client {
+	client := &Client{
+		hub:     h,
+		conn:    conn,
+		send:    make(chan []byte, SendBufferSize),
+		profile: profile,
 	}
 
-	client := &Client{
-		hub:      h,
-		conn:     conn,
-		send:     make(chan []byte, 256),
-		playerID: playerID,
+	// Take a free human seat now, so a second connection cannot be given
+	// the same one before this client is registered
+	h.mu.Lock()
+	client.playerID = h.freeSeat()
+	h.clients[client] = true
+	h.mu.Unlock()
+
+	select {
+	case h.register <- client:
+	case <-h.done:
+		// The hub closed as the client came; it is hung up on
+		h.mu.Lock()
+		delete(h.clients, client)
+		h.mu.Unlock()
+		conn.Close()
+		client.closeSend()
 	}
+	return client
+}
+
+// serve starts the client's read and write goroutines
+func (c *Client) serve() {
+	go c.writePump()
+	go c.readPump()
+}
 
-	h.register <- client
+// freeSeat returns the first human player no client is playing, or the
+// first human player when every seat is taken. Seats of kicked players are
+// never given out. Callers must hold h.mu.
+func (h *Hub) freeSeat() string {
+	taken := make(map[string]bool)
+	for client := range h.clients {
+		taken[client.playerID] = true
+	}
 
-	// Start read and write goroutines
-	go client.writePump()
-	go client.readPump()
+	first := ""
+	for _, p := range h.game.Players {
+		if p.Type != game.PlayerHuman || h.kicked[p.ID] {
+			continue
+		}
+		if !taken[p.ID] {
+			return p.ID
+		}
+		if first == "" {
+			first = p.ID
+		}
+	}
+	return first
 }
 
 // readPump reads messages from the WebSocket connection
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
 		c.conn.Close()
 	}()
 
 	c.conn.SetReadLimit(512 * 1024)
-	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.conn.SetReadDeadline(time.Now().Add(PongTimeout))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		c.conn.SetReadDeadline(time.Now().Add(PongTimeout))
 		return nil
 	})
 
@@ -300,7 +705,7 @@ func (c *Client) readPump() {
 
 // writePump writes messages to the WebSocket connection
 func (c *Client) writePump() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(PingInterval)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -309,7 +714,7 @@ func (c *Client) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
 			if !ok {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -326,7 +731,7 @@ func (c *Client) writePump() {
 			}
 
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -336,15 +741,36 @@ func (c *Client) writePump() {
 
 // handleMessage processes incoming WebSocket messages
 func (c *Client) handleMessage(data []byte) {
+	defer c.hub.recoverPanic("message from " + c.playerID)
+
 	var msg WSMessage
 	if err := json.Unmarshal(data, &msg); err != nil {
-		log.Printf("Error unmarshaling message: %v", err)
+		c.sendError(CodeInvalidMessage, err.Error())
+		return
+	}
+
+	// A kicked client may still be hanging up
+	if c.hub.isKicked(c.playerID) {
 		return
 	}
 
 	switch msg.Type {
 	case MsgTypeAction:
 		c.handleAction(msg.Payload)
+	case MsgTypeQuery:
+		c.handleQuery(msg.Payload)
+	case MsgTypeSetNotify:
+		c.handleSetNotify(msg.Payload)
+	case MsgTypeViewport:
+		c.handleViewport(msg.Payload)
+	case MsgTypeResync:
+		c.handleResync(msg.Payload)
+	case MsgTypeSetPreferences:
+		c.handleSetPreferences(msg.Payload)
+	case MsgTypePause, MsgTypeResume, MsgTypeKick, MsgTypeSetTurnTimer, MsgTypeTransferHost:
+		c.handleHostCommand(msg.Type, msg.Payload)
+	default:
+		c.sendError(CodeUnknownMessage, string(msg.Type))
 	}
 }
 
@@ -352,137 +778,96 @@ func (c *Client) handleMessage(data []byte) {
 func (c *Client) handleAction(payload json.RawMessage) {
 	var actionMsg ActionMessage
 	if err := json.Unmarshal(payload, &actionMsg); err != nil {
-		log.Printf("Error unmarshaling action: %v", err)
+		c.sendError(CodeInvalidMessage, err.Error())
 		return
 	}
 
-	// Verify it's the player's turn
-	if !c.hub.game.IsCurrentPlayerTurn(c.playerID) {
-		c.sendError("not_your_turn", "It is not your turn")
+	action, err := game.DecodeAction(actionMsg.ActionType, actionMsg.Data)
+	if err != nil {
+		if errors.Is(err, game.ErrUnknownAction) {
+			c.sendError(CodeUnknownAction, actionMsg.ActionType)
+		} else {
+			c.sendError(CodeInvalidPayload, err.Error())
+		}
+		return
+	}
+	// Host actions come as host commands, which check who sends them
+	if game.IsHostAction(action) {
+		c.sendError(CodeUnknownAction, actionMsg.ActionType)
 		return
 	}
 
-	var action game.Action
-
-	switch actionMsg.ActionType {
-	case "move":
-		var data struct {
-			UnitID string `json:"unit_id"`
-			ToX    int    `json:"to_x"`
-			ToY    int    `json:"to_y"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.MoveUnitAction{
-			UnitID: data.UnitID,
-			ToX:    data.ToX,
-			ToY:    data.ToY,
-		}
-
-	case "attack":
-		var data struct {
-			AttackerID string `json:"attacker_id"`
-			TargetX    int    `json:"target_x"`
-			TargetY    int    `json:"target_y"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.AttackAction{
-			AttackerID: data.AttackerID,
-			TargetX:    data.TargetX,
-			TargetY:    data.TargetY,
-		}
-
-	case "found_city":
-		var data struct {
-			SettlerID string `json:"settler_id"`
-			CityName  string `json:"city_name"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.FoundCityAction{
-			SettlerID: data.SettlerID,
-			CityName:  data.CityName,
-		}
-
-	case "set_production":
-		var data struct {
-			CityID string         `json:"city_id"`
-			BuildItem struct {
-				IsUnit   bool   `json:"is_unit"`
-				UnitType int    `json:"unit_type,omitempty"`
-				Building int    `json:"building,omitempty"`
-			} `json:"build_item"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.SetProductionAction{
-			CityID: data.CityID,
-			BuildItem: game.BuildItem{
-				IsUnit:   data.BuildItem.IsUnit,
-				UnitType: game.UnitType(data.BuildItem.UnitType),
-				Building: game.BuildingType(data.BuildItem.Building),
-			},
-		}
-
-	case "fortify":
-		var data struct {
-			UnitID string `json:"unit_id"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.FortifyAction{
-			UnitID: data.UnitID,
-		}
-
-	case "skip":
-		var data struct {
-			UnitID string `json:"unit_id"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.SkipUnitAction{
-			UnitID: data.UnitID,
-		}
-
-	case "build_road":
-		var data struct {
-			UnitID string `json:"unit_id"`
-		}
-		json.Unmarshal(actionMsg.Data, &data)
-		action = &game.BuildRoadAction{
-			UnitID: data.UnitID,
+	// Check the pause and turn, validate, execute and record the action in
+	// one go
+	result := c.hub.submit(c.playerID, action)
+	if !result.Applied() {
+		c.sendErrorMessage(ActionErrorToDTO(result.Err, c.hub.pack()))
+		if errors.Is(result.Err, game.ErrProductionRequired) {
+			c.hub.SendTurnStatus()
 		}
+		return
+	}
+	event := result.Event
 
-	case "end_turn":
-		action = &game.EndTurnAction{}
+	c.hub.BroadcastEvent(result)
 
-	default:
-		c.sendError("unknown_action", "Unknown action type: "+actionMsg.ActionType)
-		return
+	// Broadcast updated state, unless the units that changed said it all
+	if !onlyUnits(result) {
+		c.hub.BroadcastGameState()
 	}
 
-	// Validate and execute action
-	if err := action.Validate(c.hub.game, c.playerID); err != nil {
-		c.sendError("invalid_action", err.Error())
-		return
+	// If it's now AI turn, process AI turns
+	c.hub.gameMu.RLock()
+	phase := c.hub.game.Phase
+	submitted := c.hub.game.OrdersSubmitted(c.playerID)
+	c.hub.gameMu.RUnlock()
+	if phase == game.PhaseAITurn {
+		go c.hub.ProcessAITurns()
+	} else {
+		// Submitting orders ends the turn once the phase has resolved
+		resolved := event.Type == "submit_orders" && !submitted
+		if event.Type == "end_turn" || resolved {
+			c.hub.SendTurnSummary()
+			c.hub.SendAdvice()
+		}
+		c.hub.SendTurnStatus()
+		c.hub.turnChanged()
 	}
+}
 
-	if err := action.Execute(c.hub.game); err != nil {
-		c.sendError("action_failed", err.Error())
+// handleSetNotify sets where this client's player is told it is their turn
+func (c *Client) handleSetNotify(payload json.RawMessage) {
+	var msg SetNotifyMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		c.sendError(CodeInvalidNotify, "")
 		return
 	}
 
-	// Broadcast updated state
-	c.hub.BroadcastGameState()
-
-	// If it's now AI turn, process AI turns
-	if c.hub.game.Phase == game.PhaseAITurn {
-		go c.hub.ProcessAITurns()
+	if err := c.hub.setNotify(c.playerID, msg.Target); err != nil {
+		c.sendError(CodeInvalidNotify, err.Error())
 	}
 }
 
 // sendError sends an error message to this client
-func (c *Client) sendError(code, message string) {
-	errMsg := ErrorMessage{
+// The message is the code's text in the game's language, followed by the
+// detail if there is one.
+func (c *Client) sendError(code ErrorCode, detail string) {
+	message, ok := c.hub.pack().Lookup("error." + string(code))
+	switch {
+	case !ok:
+		message = detail
+	case detail != "":
+		message += ": " + detail
+	}
+
+	c.sendErrorMessage(ErrorMessage{
 		Code:    code,
 		Message: message,
-	}
+	})
+}
 
+// sendErrorMessage sends an error message with details to this client
+func (c *Client) sendErrorMessage(errMsg ErrorMessage) {
 	payload, _ := json.Marshal(errMsg)
 	wsMsg := WSMessage{
 		Type:    MsgTypeError,
@@ -490,10 +875,5 @@ func (c *Client) sendError(code, message string) {
 	}
 
 	data, _ := json.Marshal(wsMsg)
-
-	select {
-	case c.send <- data:
-	default:
-		log.Println("Client send buffer full")
-	}
+	c.enqueue(data, false)
 }
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"civilization/internal/game"
+	"civilization/internal/gametest"
+	"encoding/json"
+	"testing"
+)
+
+// isActionErrorCode reports whether handleAction may answer with a code
+func isActionErrorCode(code ErrorCode) bool {
+	switch code {
+	case CodeInvalidMessage, CodeInvalidPayload, CodeGamePaused:
+		return true
+	}
+	for _, c := range actionErrorCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestClient returns a client playing alice or bob in a small game
+// between the two, not yet registered with its hub
+func newTestClient(tb testing.TB, playerID string) *Client {
+	b := gametest.New(tb,
+		"~~~~~~~~",
+		"~ggphfg~",
+		"~gpgghg~",
+		"~ggfgpg~",
+		"~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("alice", game.UnitSettler, 3, 3)
+	b.Unit("bob", game.UnitArcher, 4, 2)
+	b.City("alice", "Alpha", 1, 1, 2)
+	b.City("bob", "Beta", 6, 2, 1)
+
+	return &Client{
+		hub:      NewHub(b.Start()),
+		send:     make(chan []byte, 256),
+		playerID: playerID,
+	}
+}
+
+// sent takes the messages of one type from a test client's send channel
+// and decodes their payloads, in order. Messages of other types are left
+// in the channel.
+func sent[T any](tb testing.TB, c *Client, msgType MessageType) []T {
+	tb.Helper()
+	var payloads []T
+	var others [][]byte
+	for len(c.send) > 0 {
+		data := <-c.send
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			tb.Fatalf("client was sent invalid JSON: %v", err)
+		}
+		if msg.Type != msgType {
+			others = append(others, data)
+			continue
+		}
+		var payload T
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			tb.Fatalf("client was sent an invalid %s message: %v", msgType, err)
+		}
+		payloads = append(payloads, payload)
+	}
+	for _, data := range others {
+		c.send <- data
+	}
+	return payloads
+}
+
+// rawUpdate is an update message with its entity left undecoded
+type rawUpdate struct {
+	UpdateType string          `json:"update_type"`
+	Entity     json.RawMessage `json:"entity"`
+}
+
+// byType returns the entities of updates by update type
+func byType(updates []rawUpdate) map[string]json.RawMessage {
+	entities := make(map[string]json.RawMessage)
+	for _, u := range updates {
+		entities[u.UpdateType] = u.Entity
+	}
+	return entities
+}
+
+// FuzzHandleAction feeds action payloads to a client. Every payload must
+// either be applied as one event or be refused with a known error code and
+// leave the game untouched.
+func FuzzHandleAction(f *testing.F) {
+	for _, payload := range []string{
+		`{"action_type":"move","data":{"unit_id":"u1","to_x":3,"to_y":2}}`,
+		`{"action_type":"attack","data":{"attacker_id":"u1","target_x":4,"target_y":2}}`,
+		`{"action_type":"found_city","data":{"settler_id":"u2","city_name":"Delta"}}`,
+		`{"action_type":"set_production","data":{"city_id":"Alpha","build_item":{"is_unit":true,"unit_type":99}}}`,
+		`{"action_type":"move","data":{"unit_id":"u3","to_x":-1,"to_y":1e9}}`,
+		`{"action_type":"end_turn","data":null}`,
+		`{"action_type":"teleport","data":{}}`,
+		`{"action_type":"move","data":"u1"}`,
+		`{"action_type":7}`,
+		`[]`,
+		``,
+	} {
+		f.Add([]byte(payload), false)
+	}
+	f.Add([]byte(`{"action_type":"end_turn","data":{}}`), true)
+
+	f.Fuzz(func(t *testing.T, payload []byte, asBob bool) {
+		playerID := "alice"
+		if asBob {
+			playerID = "bob"
+		}
+		c := newTestClient(t, playerID)
+		g := c.hub.game
+		before := gametest.Snapshot(t, g)
+		seq := g.Seq
+
+		c.handleAction(payload)
+
+		errs := sent[ErrorMessage](t, c, MsgTypeError)
+		for _, e := range errs {
+			if !isActionErrorCode(e.Code) {
+				t.Errorf("unknown error code %q: %s", e.Code, e.Message)
+			}
+		}
+		switch {
+		case len(errs) > 0 && !bytes.Equal(gametest.Snapshot(t, g), before):
+			t.Fatalf("refused action %s (%s) changed the game", payload, errs[0].Code)
+		case len(errs) == 0 && g.Seq != seq+1:
+			t.Fatalf("action %s was neither applied nor refused", payload)
+		}
+		if err := g.CheckInvariants(); err != nil {
+			t.Fatalf("after %s: %v", payload, err)
+		}
+	})
+}
+
+// deliver hands a client the messages the next queued broadcast has for
+// it, as the hub's loop would
+func deliver(h *Hub, c *Client) {
+	for _, data := range (<-h.broadcast).perClient[c] {
+		c.send <- data
+	}
+}
+
+// TestViewportSubscription subscribes alice to the west of the map: she
+// is sent its tiles and none of bob's units east of it, then only a count
+// of them when they move
+func TestViewportSubscription(t *testing.T) {
+	c := newTestClient(t, "alice")
+	h := c.hub
+	h.clients[c] = true
+
+	c.handleViewport(json.RawMessage(`{"x": 0, "y": 0, "width": 4, "height": 99}`))
+	deliver(h, c)
+
+	states := sent[GameStateMessage](t, c, MsgTypeGameState)
+	if len(states) != 1 {
+		t.Fatalf("client was sent %d game states, want 1", len(states))
+	}
+	state := states[0]
+	updates := byType(sent[rawUpdate](t, c, MsgTypeUpdate))
+	if state.Map.Tiles != nil || state.Players[1].Units == nil || len(state.Players[1].Units) != 0 {
+		t.Errorf("state has %d tiles and bob's units %v, want none", len(state.Map.Tiles), state.Players[1].Units)
+	}
+	var tiles ViewportTilesMessage
+	if err := json.Unmarshal(updates[UpdateViewportTiles], &tiles); err != nil {
+		t.Fatal(err)
+	}
+	if want := (ViewportMessage{Width: 4, Height: 5}); tiles.Viewport != want || len(tiles.Tiles) != 20 || tiles.Minimap == nil {
+		t.Errorf("sent %d tiles of %+v, minimap %v; want 20 tiles of %+v and a minimap", len(tiles.Tiles), tiles.Viewport, tiles.Minimap != nil, want)
+	}
+
+	h.game.GetUnit("u3").X = 5
+	h.BroadcastGameState()
+	deliver(h, c)
+	updates = byType(sent[rawUpdate](t, c, MsgTypeUpdate))
+	if _, ok := updates[UpdateViewportTiles]; ok {
+		t.Error("unchanged tiles were sent again")
+	}
+	var activity []ActivityDTO
+	if err := json.Unmarshal(updates[UpdateViewportActivity], &activity); err != nil {
+		t.Fatal(err)
+	}
+	if want := []ActivityDTO{{PlayerID: "bob", Moved: 1}}; len(activity) != 1 || activity[0] != want[0] {
+		t.Errorf("activity %+v, want %+v", activity, want)
+	}
+}
+
+// TestAITurnWhileReading plays a hard AI's turn, weighing an attack, while
+// a client is sent the game over and over. Run with -race: the AI must only
+// read the game while it plans, and change it through its actions alone.
+func TestAITurnWhileReading(t *testing.T) {
+	b := gametest.New(t,
+		"~~~~~~~~",
+		"~ggphfg~",
+		"~gpgghg~",
+		"~ggfgpg~",
+		"~~~~~~~~",
+	)
+	b.Config(func(config *game.GameConfig) { config.AIDifficulty = game.DifficultyHard })
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerAI)
+	b.Unit("alice", game.UnitWarrior, 3, 2)
+	b.Unit("bob", game.UnitArcher, 4, 2)
+	b.Unit("bob", game.UnitSettler, 5, 3)
+	b.City("alice", "Alpha", 1, 1, 2)
+	b.City("bob", "Beta", 6, 2, 1)
+	h := NewHub(b.Start())
+	go h.Run()
+	defer h.Close()
+	c := &Client{hub: h, send: make(chan []byte, 256), playerID: "alice"}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			h.sendGameState(c)
+			sent[GameStateMessage](t, c, MsgTypeGameState)
+		}
+	}()
+
+	if result := h.submit("alice", &game.EndTurnAction{}); !result.Applied() {
+		t.Fatal(result.Err)
+	}
+	h.ProcessAITurns()
+	close(stop)
+	<-done
+
+	if !h.game.IsCurrentPlayerTurn("alice") {
+		t.Errorf("turn of %s after the AI's, want alice's", h.game.TurnOrder.Current)
+	}
+	if len(h.game.EventsSince(1)) < 2 {
+		t.Error("the AI ended its turn without acting")
+	}
+}
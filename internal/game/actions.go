@@ -1,11 +1,18 @@
 package game
 
-import "errors"
-
-// Action represents a player action that can be validated and executed
+import (
+	"civilization/internal/game/rules"
+	"strings"
+)
+
+// Action represents a player action that can be validated and executed.
+// Validate is where an action is refused: Execute may only fail on checks
+// it makes before it changes anything, such as finding again what Validate
+// found, since Apply does not undo what a failed Execute changed.
 type Action interface {
+	Type() string
 	Validate(g *GameState, playerID string) error
-	Execute(g *GameState) error
+	Execute(g *GameState) (*Result, error)
 }
 
 // MoveUnitAction moves a unit to a new position
@@ -15,45 +22,42 @@ type MoveUnitAction struct {
 	ToY    int    `json:"to_y"`
 }
 
+// Type returns the action type name
+func (a *MoveUnitAction) Type() string {
+	return "move"
+}
+
 // Validate checks if the move is valid
 func (a *MoveUnitAction) Validate(g *GameState, playerID string) error {
-	unit := g.GetUnit(a.UnitID)
-	if unit == nil {
-		return ErrUnitNotFound
-	}
-
-	if unit.OwnerID != playerID {
-		return ErrNotYourUnit
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
 	}
 
 	if !unit.CanMove() {
-		return ErrNoMovementLeft
+		return noMovement(unit, 1)
 	}
 
 	if !g.IsValidMove(unit, a.ToX, a.ToY) {
-		return ErrInvalidMove
+		return g.moveError(unit, a.ToX, a.ToY)
 	}
 
 	return nil
 }
 
 // Execute performs the move
-func (a *MoveUnitAction) Execute(g *GameState) error {
+func (a *MoveUnitAction) Execute(g *GameState) (*Result, error) {
 	unit := g.GetUnit(a.UnitID)
 	if unit == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
-	cost := g.GetMovementCost(unit.X, unit.Y, a.ToX, a.ToY)
-	unit.X = a.ToX
-	unit.Y = a.ToY
-	unit.MovementLeft -= cost
-	if unit.MovementLeft < 0 {
-		unit.MovementLeft = 0
-	}
-	unit.IsFortified = false
+	result := &Result{}
+	g.stepUnit(unit, a.ToX, a.ToY, result)
+	unit.ClearOrders()         // Manual orders cancel automation
+	g.leaveGroup(unit, result) // A unit moving on its own leaves its stack
 
-	return nil
+	return result, nil
 }
 
 // AttackAction initiates combat between units
@@ -63,109 +67,163 @@ type AttackAction struct {
 	TargetY    int    `json:"target_y"`
 }
 
+// Type returns the action type name
+func (a *AttackAction) Type() string {
+	return "attack"
+}
+
 // Validate checks if the attack is valid
 func (a *AttackAction) Validate(g *GameState, playerID string) error {
-	attacker := g.GetUnit(a.AttackerID)
-	if attacker == nil {
-		return ErrUnitNotFound
+	attacker, err := g.ownUnit(playerID, a.AttackerID)
+	if err != nil {
+		return err
 	}
 
-	if attacker.OwnerID != playerID {
-		return ErrNotYourUnit
+	if !attacker.CanMove() {
+		return noMovement(attacker, 1)
 	}
 
-	if !attacker.CanMove() {
-		return ErrNoMovementLeft
+	if attacker.IsNuclear() {
+		return unitError(ErrNuclearOnly, attacker.ID)
 	}
 
 	// Check adjacency
-	dx := abs(a.TargetX - attacker.X)
-	dy := abs(a.TargetY - attacker.Y)
-	if dx > 1 || dy > 1 || (dx == 0 && dy == 0) {
-		return ErrInvalidTarget
-	}
-
-	// Check for enemies at target
-	enemies := g.GetEnemyUnitsAt(a.TargetX, a.TargetY, playerID)
-	if len(enemies) == 0 {
-		// Check for enemy city
-		city := g.GetCityAt(a.TargetX, a.TargetY)
-		if city == nil || city.OwnerID == playerID {
-			return ErrInvalidTarget
-		}
+	if !rules.Adjacent(attacker.X, attacker.Y, a.TargetX, a.TargetY) {
+		return unitError(ErrInvalidTarget, attacker.ID).at(a.TargetX, a.TargetY)
+	}
+
+	if !g.isAttackTarget(playerID, a.TargetX, a.TargetY) {
+		return unitError(ErrInvalidTarget, attacker.ID).at(a.TargetX, a.TargetY)
 	}
 
 	return nil
 }
 
+// isAttackTarget reports whether a player's units would attack the tile
+// at (x, y): it holds enemy units or an enemy city
+func (g *GameState) isAttackTarget(playerID string, x, y int) bool {
+	if len(g.GetEnemyUnitsAt(x, y, playerID)) > 0 {
+		return true
+	}
+	city := g.GetCityAt(x, y)
+	return city != nil && city.OwnerID != playerID
+}
+
 // Execute performs the attack
-func (a *AttackAction) Execute(g *GameState) error {
+func (a *AttackAction) Execute(g *GameState) (*Result, error) {
 	attacker := g.GetUnit(a.AttackerID)
 	if attacker == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
-	// Find defender (first enemy unit at location)
-	enemies := g.GetEnemyUnitsAt(a.TargetX, a.TargetY, attacker.OwnerID)
-
-	var defender *Unit
-	if len(enemies) > 0 {
-		// Attack the best defender
-		defender = getBestDefender(enemies, g.Map.GetTile(a.TargetX, a.TargetY), g.GetCityAt(a.TargetX, a.TargetY) != nil)
-	}
+	res := &Result{}
+	res.unit(attacker.ID)
+	movement := attacker.MovementLeft
+	attacker.ClearOrders()
+	attacker.Unfortify() // Attacking gives up the fortified position
+	defender, tile, city := g.attackTarget(attacker, a.TargetX, a.TargetY)
 
 	if defender == nil {
-		// No units, but we validated there's a city - just capture it
-		city := g.GetCityAt(a.TargetX, a.TargetY)
+		// No units, but we validated there's a city - storm its defenses
 		if city != nil {
-			g.TransferCity(city, attacker.OwnerID)
-			// Move attacker to city
-			attacker.X = a.TargetX
-			attacker.Y = a.TargetY
-			attacker.MovementLeft = 0
+			g.assaultCity(attacker, city, res)
 		}
-		return nil
+		res.spendMovement(movement - attacker.MovementLeft)
+		return res, nil
 	}
 
 	// Resolve combat
-	tile := g.Map.GetTile(a.TargetX, a.TargetY)
-	city := g.GetCityAt(a.TargetX, a.TargetY)
-	hasWalls := city != nil && city.HasWalls()
+	hasWalls := city != nil && city.WallsStanding()
+	hitChance := CalculateOdds(attacker, defender, tile, city != nil, defender.IsFortified, hasWalls)
+	entry := CombatLogEntry{
+		X:            a.TargetX,
+		Y:            a.TargetY,
+		AttackerID:   attacker.OwnerID,
+		AttackerUnit: attacker.Type,
+		DefenderID:   defender.OwnerID,
+		DefenderUnit: defender.Type,
+		Odds:         combatWinChance(hitChance, hitsToDestroy(defender.Health), hitsAfterStrike(attacker.Health, firstStrike(defender, city != nil, hasWalls))),
+	}
 
-	result := ResolveCombat(attacker, defender, tile, city != nil, defender.IsFortified, hasWalls)
+	result := ResolveCombat(g.rand(), attacker, defender, tile, city != nil, defender.IsFortified, hasWalls, g.Config.WithdrawPercent())
+	g.notePromotions(result, attacker, defender)
 
 	// Apply results
+	var capturedCity *City
+	stackLost := 0
 	if result.AttackerDestroyed {
-		g.RemoveUnit(attacker.ID)
+		g.removeUnit(attacker, res)
 	} else {
-		attacker.Health = BaseHealthPoints - result.AttackerDamage
-		attacker.MovementLeft = 0
+		attacker.TakeDamage(result.AttackerDamage)
+		attacker.spendAttack()
 	}
 
 	if result.DefenderDestroyed {
-		g.RemoveUnit(defender.ID)
+		g.removeUnit(defender, res)
+
+		// Outside a city the whole stack falls with its best defender
+		if city == nil {
+			for _, u := range g.GetEnemyUnitsAt(a.TargetX, a.TargetY, attacker.OwnerID) {
+				g.removeUnit(u, res)
+				stackLost++
+			}
+		}
 
-		// If attacker won and is still alive, move to target location
-		if result.AttackerWon && !result.AttackerDestroyed {
+		// If attacker won and is still alive, move to target location once
+		// nothing is left to hold it: no other defenders, and for a city,
+		// no defense points either
+		remainingDefenders := g.GetEnemyUnitsAt(a.TargetX, a.TargetY, attacker.OwnerID)
+		canEnter := len(remainingDefenders) == 0 && (city == nil || city.DefenseLeft() == 0)
+		if result.AttackerWon && !result.AttackerDestroyed && canEnter {
+			g.leaveGroup(attacker, res)
+			moved := UnitMoved{UnitID: attacker.ID, PlayerID: attacker.OwnerID, FromX: attacker.X, FromY: attacker.Y, ToX: a.TargetX, ToY: a.TargetY}
 			attacker.X = a.TargetX
 			attacker.Y = a.TargetY
+			g.revealUnit(attacker)
+			g.publish(moved)
 
-			// Check if city is now undefended
-			remainingDefenders := g.GetEnemyUnitsAt(a.TargetX, a.TargetY, attacker.OwnerID)
-			if len(remainingDefenders) == 0 && city != nil {
-				// Capture the city
+			if city != nil {
+				// Capture the city, plundered before it is halved
+				entry.Plunder = g.captureCity(city, attacker.OwnerID, res).Plunder
 				city.Population = city.Population / 2
 				if city.Population < 1 {
 					city.Population = 1
 				}
-				g.TransferCity(city, attacker.OwnerID)
+				capturedCity = city
 			}
 		}
 	} else {
-		defender.Health = BaseHealthPoints - result.DefenderDamage
+		defender.TakeDamage(result.DefenderDamage)
+		res.unit(defender.ID)
 	}
 
-	return nil
+	g.reportCombat(attacker, defender, a.TargetX, a.TargetY, result, stackLost, capturedCity)
+
+	entry.AttackerWon = result.AttackerWon
+	entry.AttackerLost = result.AttackerDestroyed
+	entry.DefenderLost = result.DefenderDestroyed
+	entry.Withdrew = result.AttackerWithdrew || result.DefenderWithdrew
+	entry.CityCaptured = cityName(capturedCity)
+	g.logCombat(entry)
+
+	res.spendMovement(movement - attacker.MovementLeft)
+	return res, nil
+}
+
+// attackTarget returns the unit that would defend a tile against the
+// attacker, along with the tile and the city on it, if any
+func (g *GameState) attackTarget(attacker *Unit, x, y int) (*Unit, *Tile, *City) {
+	tile := g.Map.GetTile(x, y)
+	city := g.GetCityAt(x, y)
+
+	var defender *Unit
+	enemies := g.GetEnemyUnitsAt(x, y, attacker.OwnerID)
+	if len(enemies) > 0 && tile != nil {
+		// Attack the best defender
+		defender = getBestDefender(enemies, tile, city != nil)
+	}
+
+	return defender, tile, city
 }
 
 // getBestDefender returns the unit with the highest effective defense
@@ -190,75 +248,100 @@ type FoundCityAction struct {
 	CityName  string `json:"city_name"`
 }
 
+// Type returns the action type name
+func (a *FoundCityAction) Type() string {
+	return "found_city"
+}
+
 // Validate checks if a city can be founded
 func (a *FoundCityAction) Validate(g *GameState, playerID string) error {
-	unit := g.GetUnit(a.SettlerID)
-	if unit == nil {
-		return ErrUnitNotFound
-	}
-
-	if unit.OwnerID != playerID {
-		return ErrNotYourUnit
+	unit, err := g.ownUnit(playerID, a.SettlerID)
+	if err != nil {
+		return err
 	}
 
 	if !unit.CanFoundCity() {
-		return ErrCannotFoundCity
+		return unitError(ErrCannotFoundCity, unit.ID)
 	}
 
-	// Check if there's already a city here
-	if g.GetCityAt(unit.X, unit.Y) != nil {
-		return ErrCannotFoundCity
+	if e := g.checkCitySite(unit.X, unit.Y); e != nil {
+		e.UnitID = unit.ID
+		return e
 	}
 
-	// Check if terrain is suitable (not water, not mountains)
-	tile := g.Map.GetTile(unit.X, unit.Y)
-	if tile == nil || tile.IsWater() || tile.Terrain == TerrainMountains {
-		return ErrCannotFoundCity
+	// Without a name the game makes one up
+	if strings.TrimSpace(a.CityName) != "" {
+		if e := g.checkCityName(a.CityName, ""); e != nil {
+			e.UnitID = unit.ID
+			return e
+		}
 	}
 
 	return nil
 }
 
 // Execute founds the city
-func (a *FoundCityAction) Execute(g *GameState) error {
+func (a *FoundCityAction) Execute(g *GameState) (*Result, error) {
 	unit := g.GetUnit(a.SettlerID)
 	if unit == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
 	player := g.GetPlayer(unit.OwnerID)
 	if player == nil {
-		return ErrPlayerNotFound
-	}
-
-	// Create the city
-	cityName := a.CityName
-	if cityName == "" {
-		cityName = generateCityName(player, len(player.Cities))
+		return nil, ErrPlayerNotFound
 	}
 
-	city := NewCity(cityName, player.ID, unit.X, unit.Y)
-	player.AddCity(city)
+	result := &Result{}
+	g.foundCity(player, a.CityName, unit.X, unit.Y, result)
 
 	// Remove the settler
-	g.RemoveUnit(unit.ID)
+	g.removeUnit(unit, result)
 
-	return nil
+	return result, nil
 }
 
-// generateCityName generates a default city name
-func generateCityName(player *Player, cityIndex int) string {
-	// Simple naming: Player name + city number
-	suffixes := []string{"burg", "ville", "ton", "polis", "heim", "grad"}
-	suffix := suffixes[cityIndex%len(suffixes)]
-	return player.Name[:min(4, len(player.Name))] + suffix
+// checkCitySite explains why no city can be founded at (x, y): the terrain
+// cannot hold one, or a city stands there or too near. It returns nil if
+// one can.
+func (g *GameState) checkCitySite(x, y int) *ActionError {
+	tile := g.Map.GetTile(x, y)
+	if tile == nil || !tile.CanHoldCity() {
+		return (&ActionError{Err: ErrCannotFoundCity}).at(x, y).on(tile)
+	}
+	for _, player := range g.Players {
+		for _, city := range player.Cities {
+			if rules.TooCloseForCity(x, y, city.X, city.Y) {
+				e := (&ActionError{Err: ErrCannotFoundCity}).at(x, y)
+				e.CityID = city.ID
+				return e
+			}
+		}
+	}
+	return nil
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// foundCity founds a city of the player's at (x, y), naming it if name is
+// blank, and records it in result
+func (g *GameState) foundCity(player *Player, name string, x, y int, result *Result) *City {
+	cityName := strings.TrimSpace(name)
+	if cityName == "" {
+		cityName = g.generateCityName(player)
 	}
-	return b
+
+	city := NewCity(cityName, player.ID, x, y)
+	city.ID = g.newID()
+	player.AddCity(city)
+	g.foundCapital(player, city)
+	player.updateCorruption()
+	g.publish(CityFounded{CityID: city.ID, PlayerID: player.ID, Name: city.Name, X: city.X, Y: city.Y})
+	g.Map.SetRoad(city.X, city.Y)       // Cities stand on a road
+	g.reveal(player, city.X, city.Y, 2) // City radius
+	g.reportResources(player, city)
+	result.city(city.ID)
+	result.player(player.ID)
+	result.tile(g.Map, city.X, city.Y)
+	return city
 }
 
 // SetProductionAction changes what a city is building
@@ -267,34 +350,65 @@ type SetProductionAction struct {
 	BuildItem BuildItem `json:"build_item"`
 }
 
+// Type returns the action type name
+func (a *SetProductionAction) Type() string {
+	return "set_production"
+}
+
 // Validate checks if the production can be set
 func (a *SetProductionAction) Validate(g *GameState, playerID string) error {
-	city := g.GetCity(a.CityID)
-	if city == nil {
-		return ErrCityNotFound
-	}
-
-	if city.OwnerID != playerID {
-		return ErrNotYourCity
+	city, err := g.ownCity(playerID, a.CityID)
+	if err != nil {
+		return err
 	}
 
 	// Check if building already exists
 	if !a.BuildItem.IsUnit && city.HasBuilding(a.BuildItem.Building) {
-		return errors.New("building already exists")
+		e := cityError(ErrBuildingExists, city.ID)
+		e.Item = a.BuildItem.Building.String()
+		return e
+	}
+
+	// Wonders are unique in the world
+	if !a.BuildItem.IsUnit && a.BuildItem.Building.IsWonder() && g.WonderBuilt(a.BuildItem.Building) {
+		e := cityError(ErrWonderBuilt, city.ID)
+		e.Item = a.BuildItem.Building.String()
+		return e
+	}
+
+	if !a.BuildItem.IsUnit && a.BuildItem.Building == BuildingHarbor && !g.IsCoastal(city) {
+		e := cityError(ErrNotCoastal, city.ID)
+		e.Item = a.BuildItem.Building.String()
+		return e
+	}
+
+	if a.BuildItem.IsUnit && UnitTemplates[a.BuildItem.UnitType].IsKing {
+		e := cityError(ErrNotBuildable, city.ID)
+		e.Item = a.BuildItem.UnitType.String()
+		return e
+	}
+
+	if a.BuildItem.IsUnit {
+		wonder := UnitTemplates[a.BuildItem.UnitType].RequiresWonder
+		if wonder != BuildingNone && !g.WonderBuilt(wonder) {
+			e := cityError(ErrRequiresWonder, city.ID)
+			e.Item = wonder.String()
+			return e
+		}
 	}
 
 	return nil
 }
 
 // Execute sets the production
-func (a *SetProductionAction) Execute(g *GameState) error {
+func (a *SetProductionAction) Execute(g *GameState) (*Result, error) {
 	city := g.GetCity(a.CityID)
 	if city == nil {
-		return ErrCityNotFound
+		return nil, ErrCityNotFound
 	}
 
 	city.SetProduction(a.BuildItem)
-	return nil
+	return &Result{Cities: []string{city.ID}}, nil
 }
 
 // FortifyAction puts a unit into fortified mode
@@ -302,64 +416,101 @@ type FortifyAction struct {
 	UnitID string `json:"unit_id"`
 }
 
+// Type returns the action type name
+func (a *FortifyAction) Type() string {
+	return "fortify"
+}
+
 // Validate checks if the unit can fortify
 func (a *FortifyAction) Validate(g *GameState, playerID string) error {
-	unit := g.GetUnit(a.UnitID)
-	if unit == nil {
-		return ErrUnitNotFound
-	}
-
-	if unit.OwnerID != playerID {
-		return ErrNotYourUnit
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
 	}
 
 	// Can't fortify settlers
 	if unit.CanFoundCity() {
-		return errors.New("settlers cannot fortify")
+		return unitError(ErrCannotFortify, unit.ID)
 	}
 
 	return nil
 }
 
 // Execute fortifies the unit
-func (a *FortifyAction) Execute(g *GameState) error {
+func (a *FortifyAction) Execute(g *GameState) (*Result, error) {
 	unit := g.GetUnit(a.UnitID)
 	if unit == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
 	unit.Fortify()
-	return nil
+	return &Result{Units: []string{unit.ID}}, nil
 }
 
-// SkipUnitAction skips the unit's turn
-type SkipUnitAction struct {
+// WakeAction takes a unit out of fortification or a standing order so it
+// waits for orders again
+type WakeAction struct {
 	UnitID string `json:"unit_id"`
 }
 
-// Validate checks if the action is valid
-func (a *SkipUnitAction) Validate(g *GameState, playerID string) error {
-	unit := g.GetUnit(a.UnitID)
-	if unit == nil {
-		return ErrUnitNotFound
+// Type returns the action type name
+func (a *WakeAction) Type() string {
+	return "wake"
+}
+
+// Validate checks if the unit is asleep
+func (a *WakeAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
 	}
 
-	if unit.OwnerID != playerID {
-		return ErrNotYourUnit
+	if !unit.IsFortified && unit.Mode == ModeNone {
+		return unitError(ErrAlreadyAwake, unit.ID)
 	}
 
 	return nil
 }
 
+// Execute wakes the unit
+func (a *WakeAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	unit.Unfortify()
+	unit.ClearOrders()
+	return &Result{Units: []string{unit.ID}}, nil
+}
+
+// SkipUnitAction skips the unit's turn
+type SkipUnitAction struct {
+	UnitID string `json:"unit_id"`
+}
+
+// Type returns the action type name
+func (a *SkipUnitAction) Type() string {
+	return "skip"
+}
+
+// Validate checks if the action is valid
+func (a *SkipUnitAction) Validate(g *GameState, playerID string) error {
+	_, err := g.ownUnit(playerID, a.UnitID)
+	return err
+}
+
 // Execute skips the unit
-func (a *SkipUnitAction) Execute(g *GameState) error {
+func (a *SkipUnitAction) Execute(g *GameState) (*Result, error) {
 	unit := g.GetUnit(a.UnitID)
 	if unit == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
+	result := &Result{Units: []string{unit.ID}}
+	result.spendMovement(unit.MovementLeft)
 	unit.MovementLeft = 0
-	return nil
+	return result, nil
 }
 
 // BuildRoadAction builds a road on the current tile
@@ -367,81 +518,102 @@ type BuildRoadAction struct {
 	UnitID string `json:"unit_id"`
 }
 
+// Type returns the action type name
+func (a *BuildRoadAction) Type() string {
+	return "build_road"
+}
+
 // Validate checks if a road can be built
 func (a *BuildRoadAction) Validate(g *GameState, playerID string) error {
-	unit := g.GetUnit(a.UnitID)
-	if unit == nil {
-		return ErrUnitNotFound
-	}
-
-	if unit.OwnerID != playerID {
-		return ErrNotYourUnit
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
 	}
 
 	// Only settlers can build roads
 	if !unit.CanFoundCity() {
-		return errors.New("only settlers can build roads")
+		return unitError(ErrCannotBuildRoad, unit.ID)
 	}
 
 	// Must have movement left
 	if unit.MovementLeft <= 0 {
-		return ErrNoMovementLeft
+		return noMovement(unit, 1)
 	}
 
 	// Check if there's already a road here
 	tile := g.Map.GetTile(unit.X, unit.Y)
 	if tile == nil {
-		return errors.New("invalid tile")
+		return unitError(ErrInvalidTile, unit.ID).at(unit.X, unit.Y)
 	}
 
 	if tile.HasRoad {
-		return errors.New("road already exists")
+		return unitError(ErrRoadExists, unit.ID).at(unit.X, unit.Y)
 	}
 
 	// Can't build roads on water or mountains
 	if tile.IsWater() || tile.Terrain == TerrainMountains {
-		return errors.New("cannot build road here")
+		return unitError(ErrCannotBuildRoadHere, unit.ID).at(unit.X, unit.Y).on(tile)
 	}
 
 	return nil
 }
 
 // Execute builds the road
-func (a *BuildRoadAction) Execute(g *GameState) error {
+func (a *BuildRoadAction) Execute(g *GameState) (*Result, error) {
 	unit := g.GetUnit(a.UnitID)
 	if unit == nil {
-		return ErrUnitNotFound
+		return nil, ErrUnitNotFound
 	}
 
 	tile := g.Map.GetTile(unit.X, unit.Y)
 	if tile == nil {
-		return errors.New("invalid tile")
+		return nil, ErrInvalidTile
 	}
 
-	tile.HasRoad = true
+	result := &Result{Units: []string{unit.ID}}
+	g.Map.SetRoad(tile.X, tile.Y)
+	result.tile(g.Map, tile.X, tile.Y)
 	// Building a road uses all movement
+	result.spendMovement(unit.MovementLeft)
 	unit.MovementLeft = 0
+	unit.ClearOrders()
 
-	return nil
+	return result, nil
 }
 
 // EndTurnAction ends the current player's turn
 type EndTurnAction struct{}
 
+// Type returns the action type name
+func (a *EndTurnAction) Type() string {
+	return "end_turn"
+}
+
 // Validate checks if the turn can be ended
 func (a *EndTurnAction) Validate(g *GameState, playerID string) error {
 	if g.Phase == PhaseGameOver {
 		return ErrGameOver
 	}
 
+	if g.Phase == PhaseSimultaneous {
+		return ErrSubmitOrders
+	}
+
 	if !g.IsCurrentPlayerTurn(playerID) {
 		return ErrNotYourTurn
 	}
 
+	if !g.TurnStatus(playerID).CanEndTurn {
+		return ErrProductionRequired
+	}
+
 	return nil
 }
 
 // Execute ends the turn
-func (a *EndTurnAction) Execute(g *GameState) error {
-	return g.EndTurn()
+func (a *EndTurnAction) Execute(g *GameState) (*Result, error) {
+	if err := g.EndTurn(); err != nil {
+		return nil, err
+	}
+	return &Result{Whole: true}, nil
 }
@@ -0,0 +1,181 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"strconv"
+	"strings"
+)
+
+// With an advanced start each player begins with a budget of points to
+// spend before their first turn ends: on cities, founded within reach of
+// their units and cities, on units placed in their cities and on
+// technologies. Units and technologies cost points as they would shields
+// and science at the game's speed. Points left when the player's first
+// turn ends are lost.
+
+// Advanced start purchases
+const (
+	BuyCity = "city"
+	BuyUnit = "unit"
+	BuyTech = "tech"
+)
+
+// BuyStartAction spends advanced start points on a city at (X, Y), a unit
+// placed in the city at (X, Y) or the next technology
+type BuyStartAction struct {
+	PlayerID string   `json:"player_id"`
+	Item     string   `json:"item"`
+	X        int      `json:"x,omitempty"`
+	Y        int      `json:"y,omitempty"`
+	UnitType UnitType `json:"unit_type,omitempty"`
+	CityName string   `json:"city_name,omitempty"` // Blank for the game to make one up
+}
+
+// Type returns the action type name
+func (a *BuyStartAction) Type() string {
+	return "buy_start"
+}
+
+// Validate checks that the player has the points for the purchase and that
+// it can be made where they ask
+func (a *BuyStartAction) Validate(g *GameState, playerID string) error {
+	if a.PlayerID != playerID {
+		return ErrNotYourPoints
+	}
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return ErrPlayerNotFound
+	}
+	if player.StartPoints <= 0 {
+		return ErrNoStartPoints
+	}
+
+	cost, err := g.startCost(player, a)
+	if err != nil {
+		return err
+	}
+	if cost > player.StartPoints {
+		return &ActionError{Err: ErrNotEnoughPoints, Item: strconv.Itoa(cost)}
+	}
+	return nil
+}
+
+// Execute makes the purchase and spends the points
+func (a *BuyStartAction) Execute(g *GameState) (*Result, error) {
+	player := g.GetPlayer(a.PlayerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	cost, err := g.startCost(player, a)
+	if err != nil {
+		return nil, err
+	}
+	player.StartPoints -= cost
+	result := &Result{Players: []string{player.ID}, Spent: Spending{StartPoints: cost}}
+
+	switch a.Item {
+	case BuyCity:
+		g.foundCity(player, a.CityName, a.X, a.Y, result)
+	case BuyUnit:
+		unit := NewUnit(a.UnitType, player.ID, a.X, a.Y)
+		unit.ID = g.newID()
+		g.train(player, unit)
+		player.AddUnit(unit)
+		result.unit(unit.ID)
+	case BuyTech:
+		before := player.Science
+		player.Science = ScaleCost(TechCost[nextTech(g, player)], g.Config.Speed)
+		for _, tech := range g.discoveries(player, before) {
+			g.publish(TechDiscovered{PlayerID: player.ID, Tech: tech})
+		}
+	}
+	return result, nil
+}
+
+// startCost returns the points a purchase costs the player, or why it
+// cannot be made
+func (g *GameState) startCost(player *Player, a *BuyStartAction) (int, error) {
+	switch a.Item {
+	case BuyCity:
+		if e := g.checkCitySite(a.X, a.Y); e != nil {
+			return 0, e
+		}
+		if !g.withinStartReach(player, a.X, a.Y) {
+			return 0, (&ActionError{Err: ErrOutOfStartReach}).at(a.X, a.Y)
+		}
+		if len(g.GetEnemyUnitsAt(a.X, a.Y, player.ID)) > 0 {
+			return 0, (&ActionError{Err: ErrCannotFoundCity}).at(a.X, a.Y)
+		}
+		if strings.TrimSpace(a.CityName) != "" {
+			if e := g.checkCityName(a.CityName, ""); e != nil {
+				return 0, e
+			}
+		}
+		return AdvancedStartCityCost, nil
+
+	case BuyUnit:
+		city := g.GetCityAt(a.X, a.Y)
+		if city == nil || city.OwnerID != player.ID {
+			return 0, (&ActionError{Err: ErrNotYourCity}).at(a.X, a.Y)
+		}
+		template, ok := UnitTemplates[a.UnitType]
+		if !ok {
+			return 0, &ActionError{Err: ErrUnknownPurchase, Item: strconv.Itoa(int(a.UnitType))}
+		}
+		if template.IsKing {
+			e := cityError(ErrNotBuildable, city.ID)
+			e.Item = template.Name
+			return 0, e
+		}
+		if template.RequiresWonder != BuildingNone && !g.WonderBuilt(template.RequiresWonder) {
+			e := cityError(ErrRequiresWonder, city.ID)
+			e.Item = template.RequiresWonder.String()
+			return 0, e
+		}
+		if template.IsNaval && !g.IsCoastal(city) {
+			e := cityError(ErrNotCoastal, city.ID)
+			e.Item = template.Name
+			return 0, e
+		}
+		return ScaleCost(template.Cost, g.Config.Speed), nil
+
+	case BuyTech:
+		tech := nextTech(g, player)
+		if tech == TechNone {
+			return 0, ErrAllTechsKnown
+		}
+		needed := ScaleCost(TechCost[tech], g.Config.Speed) - player.Science
+		return (needed + SciencePerStartPoint - 1) / SciencePerStartPoint, nil
+	}
+	return 0, &ActionError{Err: ErrUnknownPurchase, Item: a.Item}
+}
+
+// withinStartReach reports whether (x, y) lies within AdvancedStartReach
+// of one of the player's units or cities
+func (g *GameState) withinStartReach(player *Player, x, y int) bool {
+	near := func(px, py int) bool {
+		return rules.Distance(px, py, x, y) <= AdvancedStartReach
+	}
+	for _, unit := range player.Units {
+		if near(unit.X, unit.Y) {
+			return true
+		}
+	}
+	for _, city := range player.Cities {
+		if near(city.X, city.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextTech returns the cheapest technology the player does not know, or
+// TechNone
+func nextTech(g *GameState, player *Player) Technology {
+	for _, tech := range Technologies {
+		if !g.Knows(player, tech) {
+			return tech
+		}
+	}
+	return TechNone
+}
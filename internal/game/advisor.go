@@ -0,0 +1,146 @@
+package game
+
+import (
+	"civilization/internal/locale"
+	"slices"
+)
+
+// The advisor looks over a human player's empire as their turn begins and
+// points out what needs them most: an enemy closing in on a city, a city in
+// disorder or with nothing to build, and a settler standing near a good
+// place for a city. Hints are in the game's language, most pressing first.
+
+// Kinds of hints
+const (
+	HintEnemyNear    = "enemy_near"    // An enemy unit approaches a city
+	HintDisorder     = "disorder"      // A city is in disorder
+	HintNoProduction = "no_production" // A city has nothing to build
+	HintCitySite     = "city_site"     // A settler stands near a good city site
+)
+
+// Priorities of hints, the most pressing highest
+const (
+	PriorityLow    = 1
+	PriorityMedium = 2
+	PriorityHigh   = 3
+)
+
+// Hint is a piece of advice for a player
+type Hint struct {
+	Kind     string `json:"kind"`
+	Priority int    `json:"priority"`
+	Message  string `json:"message"` // In the game's language
+	CityID   string `json:"city_id,omitempty"`
+	UnitID   string `json:"unit_id,omitempty"`
+	X        int    `json:"x"` // Tile the hint is about
+	Y        int    `json:"y"`
+}
+
+// Advise returns the advisor's hints for a player, the most pressing first.
+// Games without the advisor, and AI players, get none.
+func (g *GameState) Advise(playerID string) []Hint {
+	player := g.GetPlayer(playerID)
+	if !g.Config.Advisor || player == nil || player.Type != PlayerHuman {
+		return nil
+	}
+
+	pack := locale.Get(g.Config.Locale)
+	var hints []Hint
+	for _, city := range player.Cities {
+		if enemy := g.enemyNearCity(city); enemy != nil {
+			hints = append(hints, Hint{
+				Kind:     HintEnemyNear,
+				Priority: PriorityHigh,
+				Message:  pack.Text("advice.enemy_near", "unit", enemy.Template().Name, "city", city.Name),
+				CityID:   city.ID,
+				UnitID:   enemy.ID,
+				X:        enemy.X,
+				Y:        enemy.Y,
+			})
+		}
+		if city.Disorder {
+			hints = append(hints, Hint{
+				Kind:     HintDisorder,
+				Priority: PriorityHigh,
+				Message:  pack.Text("advice.disorder", "city", city.Name),
+				CityID:   city.ID,
+				X:        city.X,
+				Y:        city.Y,
+			})
+		}
+		if city.CurrentBuild == nil {
+			hints = append(hints, Hint{
+				Kind:     HintNoProduction,
+				Priority: PriorityMedium,
+				Message:  pack.Text("advice.no_production", "city", city.Name),
+				CityID:   city.ID,
+				X:        city.X,
+				Y:        city.Y,
+			})
+		}
+	}
+
+	for _, unit := range player.Units {
+		if !unit.CanFoundCity() || !unit.NeedsOrders() {
+			continue
+		}
+		if site, resource, ok := g.citySiteNear(player, unit); ok {
+			hints = append(hints, Hint{
+				Kind:     HintCitySite,
+				Priority: PriorityLow,
+				Message:  pack.Text("advice.city_site", "resource", resource.String()),
+				UnitID:   unit.ID,
+				X:        site.X,
+				Y:        site.Y,
+			})
+		}
+	}
+
+	slices.SortStableFunc(hints, func(a, b Hint) int { return b.Priority - a.Priority })
+	return hints
+}
+
+// enemyNearCity returns the closest enemy unit able to attack within
+// AdvisorThreatDistance of a city, or nil if there is none
+func (g *GameState) enemyNearCity(city *City) *Unit {
+	var closest *Unit
+	best := AdvisorThreatDistance + 1
+	for _, p := range g.Players {
+		if p.ID == city.OwnerID {
+			continue
+		}
+		for _, u := range p.Units {
+			if u.Template().Attack == 0 {
+				continue
+			}
+			if d := (Coord{u.X, u.Y}).Distance(Coord{city.X, city.Y}); d < best {
+				closest, best = u, d
+			}
+		}
+	}
+	return closest
+}
+
+// citySiteNear finds the closest tile within AdvisorSiteReach of a settler,
+// explored by its owner, where a city can be founded next to a resource
+// they can see. It returns the tile and the resource.
+func (g *GameState) citySiteNear(player *Player, unit *Unit) (Coord, ResourceType, bool) {
+	from := Coord{unit.X, unit.Y}
+	var site Coord
+	var resource ResourceType
+	best := AdvisorSiteReach + 1
+	for c := range from.Within(AdvisorSiteReach) {
+		d := c.Distance(from)
+		if d >= best || !g.IsExplored(player, c.X, c.Y) || g.checkCitySite(c.X, c.Y) != nil {
+			continue
+		}
+		for n := range c.Within(1) {
+			tile := g.Map.GetTile(n.X, n.Y)
+			if tile != nil && tile.Resource != ResourceNone && g.IsExplored(player, n.X, n.Y) && g.SeesResource(player, tile.Resource) {
+				site, resource, best = c, tile.Resource, d
+				break
+			}
+		}
+	}
+	return site, resource, best <= AdvisorSiteReach
+}
@@ -0,0 +1,380 @@
+package game
+
+// UnitMode is a standing order a unit carries out on its own at the start
+// of each of its owner's turns
+type UnitMode int
+
+const (
+	ModeNone      UnitMode = iota
+	ModeSentry             // Wait until an enemy comes near
+	ModeExplore            // Move toward unexplored tiles
+	ModeWork               // Build roads around the player's cities
+	ModePatrol             // Cycle between waypoints
+	ModeTerraform          // Work on the job of the unit's tile, see TerraformAction
+)
+
+// String returns the string representation of a unit mode
+func (m UnitMode) String() string {
+	switch m {
+	case ModeNone:
+		return "none"
+	case ModeSentry:
+		return "sentry"
+	case ModeExplore:
+		return "explore"
+	case ModeWork:
+		return "work"
+	case ModePatrol:
+		return "patrol"
+	case ModeTerraform:
+		return "terraform"
+	default:
+		return "unknown"
+	}
+}
+
+// Reasons an automated unit stops and asks for orders
+const (
+	WakeEnemySighted  = "enemy_sighted"
+	WakeExploreDone   = "nothing_to_explore"
+	WakeNoWork        = "no_work"
+	WakePatrolBlocked = "patrol_blocked"
+	WakeJobDone       = "job_done"
+)
+
+// Waypoint is a map position on a unit's route
+type Waypoint = Coord
+
+// SetUnitModeAction gives a unit a standing order, or cancels it with ModeNone
+type SetUnitModeAction struct {
+	UnitID string   `json:"unit_id"`
+	Mode   UnitMode `json:"mode"`
+}
+
+// Type returns the action type name
+func (a *SetUnitModeAction) Type() string {
+	return "set_mode"
+}
+
+// Validate checks if the unit can take the order
+func (a *SetUnitModeAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	switch a.Mode {
+	case ModeNone, ModeSentry, ModeExplore:
+	case ModePatrol:
+		return unitError(ErrUsePatrol, unit.ID)
+	case ModeWork:
+		if !unit.CanBuildRoad() {
+			return unitError(ErrCannotBuildRoad, unit.ID)
+		}
+	default:
+		return unitError(ErrUnknownMode, unit.ID)
+	}
+
+	return nil
+}
+
+// Execute sets the mode and lets automated units act right away
+func (a *SetUnitModeAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	result := &Result{}
+	result.unit(unit.ID)
+	unit.ClearOrders()
+	unit.Mode = a.Mode
+	if a.Mode != ModeNone {
+		unit.Unfortify()
+	}
+
+	if a.Mode == ModeExplore || a.Mode == ModeWork {
+		g.leaveGroup(unit, result)
+		g.runUnitMode(unit, result)
+	}
+
+	return result, nil
+}
+
+// processUnitModes carries out the standing orders of a player's units at
+// the start of their turn
+func (g *GameState) processUnitModes(player *Player) {
+	for _, unit := range player.Units {
+		switch unit.Mode {
+		case ModeSentry:
+			if g.enemyNear(unit, SentryWakeRange) {
+				g.wakeUnit(unit, WakeEnemySighted)
+			}
+		case ModeExplore, ModeWork, ModePatrol:
+			g.runUnitMode(unit, nil)
+		case ModeTerraform:
+			g.workJob(unit, nil)
+		}
+	}
+}
+
+// runUnitMode spends a unit's movement following its standing order,
+// recording what it changes in result
+func (g *GameState) runUnitMode(unit *Unit, result *Result) {
+	player := g.GetPlayer(unit.OwnerID)
+	if player == nil {
+		return
+	}
+
+	switch unit.Mode {
+	case ModeExplore:
+		for unit.MovementLeft > 0 {
+			x, y, ok := g.automationStep(unit, func(x, y int) bool {
+				return g.hasUnexploredNear(player, unit, x, y)
+			})
+			if !ok {
+				g.wakeUnit(unit, WakeExploreDone)
+				return
+			}
+			g.stepUnit(unit, x, y, result)
+		}
+
+	case ModeWork:
+		worked := g.cityTileSet(player)
+		needsRoad := func(x, y int) bool {
+			return worked[g.Map.Index(x, y)] && canBuildRoadOn(g.Map.GetTile(x, y))
+		}
+
+		for unit.MovementLeft > 0 {
+			if needsRoad(unit.X, unit.Y) {
+				g.Map.SetRoad(unit.X, unit.Y)
+				result.tile(g.Map, unit.X, unit.Y)
+				result.spendMovement(unit.MovementLeft)
+				unit.MovementLeft = 0
+				return
+			}
+
+			x, y, ok := g.automationStep(unit, needsRoad)
+			if !ok {
+				g.wakeUnit(unit, WakeNoWork)
+				return
+			}
+			g.stepUnit(unit, x, y, result)
+		}
+
+	case ModePatrol:
+		for unit.MovementLeft > 0 && len(unit.Patrol) > 0 {
+			// Like a sentry, a patrol stops for orders once an enemy is near
+			if g.enemyNear(unit, SentryWakeRange) {
+				g.wakeUnit(unit, WakeEnemySighted)
+				return
+			}
+
+			target := unit.Patrol[unit.PatrolIndex]
+			if unit.X == target.X && unit.Y == target.Y {
+				unit.PatrolIndex = (unit.PatrolIndex + 1) % len(unit.Patrol)
+				continue
+			}
+
+			x, y, ok := g.automationStep(unit, func(x, y int) bool {
+				return x == target.X && y == target.Y
+			})
+			if !ok {
+				g.wakeUnit(unit, WakePatrolBlocked)
+				return
+			}
+			g.stepUnit(unit, x, y, result)
+		}
+	}
+}
+
+// wakeUnit cancels a unit's standing order and tells its owner why
+func (g *GameState) wakeUnit(unit *Unit, reason string) {
+	unit.ClearOrders()
+	g.reportUnitNotice(unit, reason)
+}
+
+// enemyNear reports whether any enemy unit is within the given distance
+func (g *GameState) enemyNear(unit *Unit, distance int) bool {
+	for _, p := range g.Players {
+		if p.ID == unit.OwnerID {
+			continue
+		}
+		for _, u := range p.Units {
+			if (Coord{u.X, u.Y}).Distance(Coord{unit.X, unit.Y}) <= distance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasUnexploredNear reports whether a unit standing at (x, y) would see
+// tiles the player has not explored yet
+func (g *GameState) hasUnexploredNear(player *Player, unit *Unit, x, y int) bool {
+	for _, i := range g.visibleFrom(unit, x, y) {
+		if !g.IsExplored(player, i%g.Map.Width, i/g.Map.Width) {
+			return true
+		}
+	}
+	return false
+}
+
+// cityTileSet returns the indices of all tiles worked by a player's cities
+func (g *GameState) cityTileSet(player *Player) map[int]bool {
+	tiles := make(map[int]bool)
+	for _, city := range player.Cities {
+		for _, tile := range g.GetCityTiles(city) {
+			tiles[g.Map.Index(tile.X, tile.Y)] = true
+		}
+	}
+	return tiles
+}
+
+// canBuildRoadOn reports whether a road can be built on a tile
+func canBuildRoadOn(tile *Tile) bool {
+	return tile != nil && !tile.HasRoad && !tile.IsWater() && tile.Terrain != TerrainMountains
+}
+
+// automationStep finds the closest tile satisfying goal with a breadth-first
+// search and returns the first step toward it. Tiles held by other players
+// are avoided.
+func (g *GameState) automationStep(unit *Unit, goal func(x, y int) bool) (int, int, bool) {
+	start := g.Map.Index(unit.X, unit.Y)
+	parent := map[int]int{start: start}
+	queue := []int{start}
+	naval := unit.Template().IsNaval
+	foreign := g.foreignTileSet(unit.OwnerID)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		here := g.Map.CoordOf(current)
+
+		if current != start && goal(here.X, here.Y) {
+			// Walk back to the tile next to the unit
+			for parent[current] != start {
+				current = parent[current]
+			}
+			step := g.Map.CoordOf(current)
+			return step.X, step.Y, true
+		}
+
+		for _, n := range here.Neighbors() {
+			if !g.Map.Contains(n) || n.Distance(Coord{unit.X, unit.Y}) > MaxAutomationDistance {
+				continue
+			}
+			next := g.Map.Index(n.X, n.Y)
+			if _, seen := parent[next]; seen {
+				continue
+			}
+			if !g.Map.GetTileUnsafe(n.X, n.Y).CanEnter(naval) || foreign[next] {
+				continue
+			}
+			parent[next] = current
+			queue = append(queue, next)
+		}
+	}
+
+	return 0, 0, false
+}
+
+// foreignTileSet returns the indices of tiles holding another player's
+// units or cities
+func (g *GameState) foreignTileSet(playerID string) map[int]bool {
+	tiles := make(map[int]bool)
+	for _, p := range g.Players {
+		if p.ID == playerID {
+			continue
+		}
+		for _, u := range p.Units {
+			tiles[g.Map.Index(u.X, u.Y)] = true
+		}
+		for _, c := range p.Cities {
+			tiles[g.Map.Index(c.X, c.Y)] = true
+		}
+	}
+	return tiles
+}
+
+// stepUnit moves a unit to an adjacent tile, paying the movement cost, and
+// records it in result
+func (g *GameState) stepUnit(unit *Unit, x, y int, result *Result) {
+	cost := g.GetMovementCost(unit.X, unit.Y, x, y)
+	result.unit(unit.ID)
+	result.spendMovement(min(cost, unit.MovementLeft))
+	moved := UnitMoved{UnitID: unit.ID, PlayerID: unit.OwnerID, FromX: unit.X, FromY: unit.Y, ToX: x, ToY: y, GroupID: unit.GroupID}
+	unit.X = x
+	unit.Y = y
+	unit.MovementLeft -= cost
+	if unit.MovementLeft < 0 {
+		unit.MovementLeft = 0
+	}
+	unit.IsFortified = false
+	unit.Acted = true
+	g.revealUnit(unit)
+	g.publish(moved)
+}
+
+// PatrolAction sends a unit cycling between waypoints every turn. The route
+// starts with the first waypoint and returns to it after the last. The unit
+// stops and asks for orders when an enemy comes within SentryWakeRange.
+type PatrolAction struct {
+	UnitID    string     `json:"unit_id"`
+	Waypoints []Waypoint `json:"waypoints"`
+}
+
+// Type returns the action type name
+func (a *PatrolAction) Type() string {
+	return "patrol"
+}
+
+// Validate checks that the unit can follow the route
+func (a *PatrolAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Waypoints) < 2 {
+		return unitError(ErrPatrolTooShort, unit.ID)
+	}
+
+	naval := unit.Template().IsNaval
+	for i, wp := range a.Waypoints {
+		tile := g.Map.GetTile(wp.X, wp.Y)
+		if tile == nil {
+			return unitError(ErrInvalidTile, unit.ID).at(wp.X, wp.Y)
+		}
+		if !tile.CanEnter(naval) {
+			return unitError(ErrWaypointUnreachable, unit.ID).at(wp.X, wp.Y).on(tile)
+		}
+
+		// Consecutive waypoints must differ or the route would never advance
+		next := a.Waypoints[(i+1)%len(a.Waypoints)]
+		if next == wp {
+			return unitError(ErrRepeatedWaypoint, unit.ID).at(wp.X, wp.Y)
+		}
+	}
+
+	return nil
+}
+
+// Execute starts the patrol and moves the unit right away
+func (a *PatrolAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	result := &Result{}
+	result.unit(unit.ID)
+	unit.ClearOrders()
+	unit.Unfortify()
+	unit.Mode = ModePatrol
+	unit.Patrol = append([]Waypoint(nil), a.Waypoints...)
+	g.leaveGroup(unit, result)
+	g.runUnitMode(unit, result)
+
+	return result, nil
+}
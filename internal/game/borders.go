@@ -0,0 +1,84 @@
+package game
+
+// Every tile within CityRadius of a city is claimed by the closest city,
+// which makes it its owner's territory and is the one city that works it.
+// So cities whose radii overlap share the tiles between them rather than
+// each counting them, and no city works land inside another player's
+// borders.
+
+// BorderChange is a tile that changed hands when borders moved
+type BorderChange struct {
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Owner string `json:"owner"` // "" when the tile is no longer claimed
+}
+
+// TerritoryOwner returns the ID of the player whose territory (x, y) is in,
+// or "" for unclaimed land
+func (g *GameState) TerritoryOwner(x, y int) string {
+	tile := g.Map.GetTile(x, y)
+	if tile == nil {
+		return ""
+	}
+	return tile.Owner
+}
+
+// claims works out which city claims each tile, indexed like Map.Tiles,
+// nil for unclaimed tiles. A tile is claimed by the closest city within
+// CityRadius; on a tie the earlier player, then the earlier city, keeps it.
+func (g *GameState) claims() []*City {
+	claimants := make([]*City, len(g.Map.Tiles))
+	best := make([]int, len(g.Map.Tiles))
+	for i := range best {
+		best[i] = CityRadius + 1
+	}
+
+	for _, p := range g.Players {
+		for _, c := range p.Cities {
+			center := Coord{c.X, c.Y}
+			for tile := range center.Within(CityRadius) {
+				if !g.Map.Contains(tile) {
+					continue
+				}
+				d := center.Distance(tile)
+				i := g.Map.Index(tile.X, tile.Y)
+				if d < best[i] {
+					best[i] = d
+					claimants[i] = c
+				}
+			}
+		}
+	}
+	return claimants
+}
+
+// UpdateBorders gives every tile to the player whose territory it is now
+// in, and to the city that works it, and returns the tiles that changed
+// hands. It runs after every action and when a game is loaded, so tile
+// owners always follow the cities.
+func (g *GameState) UpdateBorders() []BorderChange {
+	if g.Map == nil {
+		return nil
+	}
+
+	var changes []BorderChange
+	for i, city := range g.claims() {
+		tile := &g.Map.Tiles[i]
+		owner, worker := claimedBy(city)
+		tile.WorkedBy = worker
+		if tile.Owner != owner {
+			tile.Owner = owner
+			changes = append(changes, BorderChange{X: tile.X, Y: tile.Y, Owner: owner})
+		}
+	}
+	return changes
+}
+
+// claimedBy returns the owner of a tile a city claims and the city that
+// works it, "" for both when no city claims it
+func claimedBy(city *City) (owner, worker string) {
+	if city == nil {
+		return "", ""
+	}
+	return city.OwnerID, city.ID
+}
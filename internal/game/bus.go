@@ -0,0 +1,172 @@
+package game
+
+import "sync"
+
+// A game tells what happens in it, as it happens, to the subscribers of
+// its bus. They are called by the goroutine changing the game, in the
+// middle of the change, so they must only take note of what they are told
+// and not look at the game or block. Clones of a game, which the AI plays
+// out moves on, have no subscribers.
+
+// BusEvent is something that happened in a game: a UnitMoved,
+// CityFounded, CombatResolved, ProductionCompleted, CityGrew,
+// CelebrationChanged, TechDiscovered, KingLost, TurnEnded or Logged
+type BusEvent interface {
+	busEvent()
+}
+
+// UnitMoved is published when a unit steps from one tile onto another
+type UnitMoved struct {
+	UnitID   string
+	PlayerID string
+	FromX    int
+	FromY    int
+	ToX      int
+	ToY      int
+	GroupID  string // Group the unit was in as it moved, if any
+}
+
+// CityFounded is published when a settler founds a city
+type CityFounded struct {
+	CityID   string
+	PlayerID string
+	Name     string
+	X        int
+	Y        int
+}
+
+// CombatResolved is published when a battle is over, with its entry in
+// the combat log
+type CombatResolved struct {
+	CombatLogEntry
+}
+
+// ProductionCompleted is published when a city finishes building a unit
+// or building at the end of its owner's turn
+type ProductionCompleted struct {
+	CityID   string
+	PlayerID string
+	CityName string
+	Item     string
+	Building BuildingType // BuildingNone when the item is a unit
+	UnitID   string       // Set when the item is a unit
+}
+
+// CityGrew is published when a city grows at the end of its owner's turn
+type CityGrew struct {
+	CityID      string
+	PlayerID    string
+	CityName    string
+	Population  int
+	GranaryFood int // Food its granary kept for the next growth
+}
+
+// CelebrationChanged is published when a city starts or stops celebrating
+type CelebrationChanged struct {
+	CityID      string
+	PlayerID    string
+	CityName    string
+	Population  int
+	Celebrating bool
+}
+
+// TechDiscovered is published when the science a player gathered at the
+// end of their turn brought them to know a technology
+type TechDiscovered struct {
+	PlayerID string
+	Tech     Technology
+}
+
+// KingLost is published when a player in a regicide game loses their King,
+// and with it the game
+type KingLost struct {
+	PlayerID string
+	UnitID   string
+	X, Y     int
+}
+
+// TurnEnded is published when a player's turn has ended and their cities
+// have worked it
+type TurnEnded struct {
+	PlayerID string
+	Turn     int
+}
+
+// Logged is published when an entry is written in the game log
+type Logged struct {
+	LogEntry
+}
+
+func (UnitMoved) busEvent()           {}
+func (CityFounded) busEvent()         {}
+func (CombatResolved) busEvent()      {}
+func (ProductionCompleted) busEvent() {}
+func (CityGrew) busEvent()            {}
+func (CelebrationChanged) busEvent()  {}
+func (TechDiscovered) busEvent()      {}
+func (KingLost) busEvent()            {}
+func (TurnEnded) busEvent()           {}
+func (Logged) busEvent()              {}
+
+// EventBus passes the events of a game on to its subscribers
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[int]func(BusEvent)
+	next        int
+}
+
+// Subscribe calls fn with every event published from now on, until the
+// returned function is called
+func (b *EventBus) Subscribe(fn func(BusEvent)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[int]func(BusEvent))
+	}
+	id := b.next
+	b.next++
+	b.subscribers[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers, id)
+	}
+}
+
+// Publish tells the subscribers of an event. A nil bus has none.
+func (b *EventBus) Publish(e BusEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	subscribers := make([]func(BusEvent), 0, len(b.subscribers))
+	for _, fn := range b.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	b.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
+// Bus returns the game's event bus. The first call creates it, so it must
+// not race with play.
+func (g *GameState) Bus() *EventBus {
+	if g.bus == nil {
+		g.bus = &EventBus{}
+	}
+	return g.bus
+}
+
+// publish tells the game's subscribers of an event, if it has any, notes
+// it for the result of the action being applied and writes it in the game
+// log
+func (g *GameState) publish(e BusEvent) {
+	if g.published != nil {
+		g.published = append(g.published, e)
+	}
+	g.bus.Publish(e)
+	g.chronicle(e)
+}
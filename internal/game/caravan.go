@@ -0,0 +1,63 @@
+package game
+
+// A caravan carries shields to a wonder: given up in a city of its owner's
+// that is building one, it adds its own production cost to the wonder.
+// The shields it brings are only good for wonders, so a city that turns
+// to building anything else loses them.
+
+// HelpWonderAction gives up a caravan to the wonder its city is building
+type HelpWonderAction struct {
+	UnitID string `json:"unit_id"`
+}
+
+// Type returns the action type name
+func (a *HelpWonderAction) Type() string {
+	return "help_wonder"
+}
+
+// Validate checks that the unit is a caravan in a city of its owner's that
+// is building a wonder
+func (a *HelpWonderAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if !unit.HelpsWonder() {
+		return unitError(ErrCannotHelpWonder, unit.ID)
+	}
+
+	if !unit.CanMove() {
+		return noMovement(unit, 1)
+	}
+
+	city := g.GetCityAt(unit.X, unit.Y)
+	if city == nil || city.OwnerID != playerID {
+		return unitError(ErrNoWonderHere, unit.ID).at(unit.X, unit.Y)
+	}
+	if build := city.CurrentBuild; build == nil || build.IsUnit || !build.Building.IsWonder() {
+		e := unitError(ErrNoWonderHere, unit.ID).at(unit.X, unit.Y)
+		e.CityID = city.ID
+		return e
+	}
+
+	return nil
+}
+
+// Execute gives up the caravan and adds its cost to the city's wonder
+func (a *HelpWonderAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+	city := g.GetCityAt(unit.X, unit.Y)
+	if city == nil {
+		return nil, ErrCityNotFound
+	}
+
+	shields := ScaleCost(unit.Template().Cost, g.Config.Speed)
+	city.HelpWonder(shields)
+	result := &Result{Cities: []string{city.ID}, Spent: Spending{Shields: shields}}
+	g.removeUnit(unit, result)
+	return result, nil
+}
@@ -12,6 +12,9 @@ const (
 	BuildingWalls
 	BuildingMarketplace
 	BuildingLibrary
+	BuildingManhattanProject
+	BuildingHarbor
+	BuildingPalace
 )
 
 // String returns the string representation of a building type
@@ -27,6 +30,12 @@ func (b BuildingType) String() string {
 		return "Marketplace"
 	case BuildingLibrary:
 		return "Library"
+	case BuildingManhattanProject:
+		return "Manhattan Project"
+	case BuildingHarbor:
+		return "Harbor"
+	case BuildingPalace:
+		return "Palace"
 	default:
 		return "None"
 	}
@@ -39,6 +48,15 @@ var BuildingCosts = map[BuildingType]int{
 	BuildingWalls:       80,
 	BuildingMarketplace: 80,
 	BuildingLibrary:     80,
+	BuildingHarbor:      60,
+	BuildingPalace:      100,
+
+	BuildingManhattanProject: 300,
+}
+
+// IsWonder reports whether only one city in the world can have the building
+func (b BuildingType) IsWonder() bool {
+	return b == BuildingManhattanProject
 }
 
 // BuildItem represents what a city is currently building
@@ -48,7 +66,8 @@ type BuildItem struct {
 	Building BuildingType `json:"building,omitempty"`
 }
 
-// Cost returns the production cost of the build item
+// Cost returns the production cost of the build item at standard speed.
+// CostAt gives the cost at a game's speed.
 func (b *BuildItem) Cost() int {
 	if b.IsUnit {
 		return UnitTemplates[b.UnitType].Cost
@@ -56,6 +75,11 @@ func (b *BuildItem) Cost() int {
 	return BuildingCosts[b.Building]
 }
 
+// CostAt returns the production cost of the build item at a game speed
+func (b *BuildItem) CostAt(speed string) int {
+	return ScaleCost(b.Cost(), speed)
+}
+
 // Name returns the name of what's being built
 func (b *BuildItem) Name() string {
 	if b.IsUnit {
@@ -76,6 +100,22 @@ type City struct {
 	Production   int                   `json:"production"`
 	Buildings    map[BuildingType]bool `json:"buildings"`
 	CurrentBuild *BuildItem            `json:"current_build,omitempty"`
+	Damage       int                   `json:"damage,omitempty"`      // Damage taken by the city's defenses
+	Struck       bool                  `json:"struck,omitempty"`      // City used its ranged strike this turn
+	Disorder     bool                  `json:"disorder,omitempty"`    // Too unhappy to produce or trade, see Happiness
+	Celebrating  bool                  `json:"celebrating,omitempty"` // Happy enough to earn more trade, see Happiness
+
+	// Percent of trade lost to corruption and of shields to waste, for
+	// the city's distance from the capital
+	Corruption int `json:"corruption,omitempty"`
+	Waste      int `json:"waste,omitempty"`
+	// Percent more shields the city makes from its owner's handicap
+	Bonus int `json:"bonus,omitempty"`
+	// Player whose first city this was, whoever holds it now
+	OriginalCapital string `json:"original_capital,omitempty"`
+
+	// Shields of Production that caravans brought, good only for a wonder
+	WonderShields int `json:"wonder_shields,omitempty"`
 }
 
 // NewCity creates a new city at the specified location
@@ -93,9 +133,10 @@ func NewCity(name, ownerID string, x, y int) *City {
 	}
 }
 
-// FoodNeededForGrowth returns the food required to grow to the next population level
-func (c *City) FoodNeededForGrowth() int {
-	return BaseFoodForGrowth + c.Population*FoodPerPopForGrowth
+// FoodNeededForGrowth returns the food required to grow to the next
+// population level at a game speed
+func (c *City) FoodNeededForGrowth(speed string) int {
+	return ScaleCost(BaseFoodForGrowth+c.Population*FoodPerPopForGrowth, speed)
 }
 
 // FoodConsumed returns the food consumed by the city's population per turn
@@ -108,6 +149,9 @@ func (c *City) CalculateFoodPerTurn(tiles []*Tile) int {
 	produced := 0
 	for _, tile := range tiles {
 		produced += tile.FoodYield()
+		if tile.IsWater() && c.HasBuilding(BuildingHarbor) {
+			produced += HarborFoodBonus
+		}
 	}
 	// Add city center tile bonus
 	produced += 2
@@ -115,15 +159,26 @@ func (c *City) CalculateFoodPerTurn(tiles []*Tile) int {
 	return produced - c.FoodConsumed()
 }
 
-// CalculateProductionPerTurn calculates shields produced per turn
+// CalculateProductionPerTurn calculates shields produced per turn, less
+// waste and with the owner's handicap bonus, rounded up, none in disorder
 func (c *City) CalculateProductionPerTurn(tiles []*Tile) int {
+	if c.Disorder {
+		return 0
+	}
+	produced := c.grossProduction(tiles)
+	produced -= produced * c.Waste / 100
+	return produced + (produced*c.Bonus+99)/100
+}
+
+// grossProduction returns the shields of the city's tiles and its center
+// before waste
+func (c *City) grossProduction(tiles []*Tile) int {
 	produced := 0
 	for _, tile := range tiles {
 		produced += tile.ProductionYield()
 	}
 	// Add city center production
 	produced += 1
-
 	return produced
 }
 
@@ -142,6 +197,38 @@ func (c *City) HasWalls() bool {
 	return c.HasBuilding(BuildingWalls)
 }
 
+// MaxDefense returns the defense points of an undamaged city. Larger
+// cities and walls hold out longer.
+func (c *City) MaxDefense() int {
+	defense := CityBaseDefense + c.Population*CityDefensePerPop
+	if c.HasWalls() {
+		defense += CityWallsHealth
+	}
+	return defense
+}
+
+// DefenseLeft returns the defense points the city has left. The city can
+// only be captured once they reach zero.
+func (c *City) DefenseLeft() int {
+	left := c.MaxDefense() - c.Damage
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
+
+// WallsStanding checks if the city has walls that are not battered down.
+// Walls take the first CityWallsHealth points of damage.
+func (c *City) WallsStanding() bool {
+	return c.HasWalls() && c.Damage < CityWallsHealth
+}
+
+// Strength returns the city's own combat strength, used when it is
+// assaulted without defenders and for its ranged strike
+func (c *City) Strength() int {
+	return CityBaseStrength + c.Population/CityStrengthPopDivisor
+}
+
 // HasBarracks checks if the city has barracks
 func (c *City) HasBarracks() bool {
 	return c.HasBuilding(BuildingBarracks)
@@ -152,20 +239,32 @@ func (c *City) HasGranary() bool {
 	return c.HasBuilding(BuildingGranary)
 }
 
-// SetProduction sets what the city should build
+// SetProduction sets what the city should build. Shields caravans
+// brought are lost unless it is a wonder.
 func (c *City) SetProduction(item BuildItem) {
 	c.CurrentBuild = &item
+	if item.IsUnit || !item.Building.IsWonder() {
+		c.Production = max(0, c.Production-c.WonderShields)
+		c.WonderShields = 0
+	}
 }
 
 // ClearProduction clears the current production
 func (c *City) ClearProduction() {
 	c.CurrentBuild = nil
 	c.Production = 0
+	c.WonderShields = 0
+}
+
+// HelpWonder adds the shields a caravan brings to the wonder being built
+func (c *City) HelpWonder(shields int) {
+	c.Production += shields
+	c.WonderShields += shields
 }
 
 // ProcessTurn handles end-of-turn processing for the city
 // Returns a new unit if one was produced, nil otherwise
-func (c *City) ProcessTurn(tiles []*Tile) (*Unit, BuildingType) {
+func (c *City) ProcessTurn(tiles []*Tile, speed string) (*Unit, BuildingType) {
 	// Process food
 	foodNet := c.CalculateFoodPerTurn(tiles)
 	c.FoodStore += foodNet
@@ -180,15 +279,22 @@ func (c *City) ProcessTurn(tiles []*Tile) (*Unit, BuildingType) {
 	}
 
 	// Check for growth
-	if c.FoodStore >= c.FoodNeededForGrowth() {
+	if c.FoodStore >= c.FoodNeededForGrowth(speed) {
 		c.Population++
 		if c.HasGranary() {
-			c.FoodStore = c.FoodNeededForGrowth() * GranaryFoodRetention / 100
+			c.FoodStore = c.FoodNeededForGrowth(speed) * GranaryFoodRetention / 100
 		} else {
 			c.FoodStore = 0
 		}
 	}
 
+	// Repair damaged defenses and reload the ranged strike
+	c.Damage -= CityRepairPerTurn
+	if c.Damage < 0 {
+		c.Damage = 0
+	}
+	c.Struck = false
+
 	// Process production
 	var newUnit *Unit
 	var newBuilding BuildingType
@@ -197,12 +303,12 @@ func (c *City) ProcessTurn(tiles []*Tile) (*Unit, BuildingType) {
 		shields := c.CalculateProductionPerTurn(tiles)
 		c.Production += shields
 
-		if c.Production >= c.CurrentBuild.Cost() {
+		if c.Production >= c.CurrentBuild.CostAt(speed) {
 			if c.CurrentBuild.IsUnit {
 				// Create new unit
 				newUnit = NewUnit(c.CurrentBuild.UnitType, c.OwnerID, c.X, c.Y)
 				if c.HasBarracks() {
-					newUnit.IsVeteran = true
+					newUnit.promote(VeteranFromBarracks)
 				}
 			} else {
 				// Add building
@@ -210,6 +316,7 @@ func (c *City) ProcessTurn(tiles []*Tile) (*Unit, BuildingType) {
 				newBuilding = c.CurrentBuild.Building
 			}
 			c.Production = 0
+			c.WonderShields = 0
 			// Keep the same production item (auto-repeat for units)
 			if !c.CurrentBuild.IsUnit {
 				c.CurrentBuild = nil
@@ -221,17 +328,17 @@ func (c *City) ProcessTurn(tiles []*Tile) (*Unit, BuildingType) {
 }
 
 // TurnsUntilGrowth returns estimated turns until population growth
-func (c *City) TurnsUntilGrowth(tiles []*Tile) int {
+func (c *City) TurnsUntilGrowth(tiles []*Tile, speed string) int {
 	netFood := c.CalculateFoodPerTurn(tiles)
 	if netFood <= 0 {
 		return -1 // Never
 	}
-	needed := c.FoodNeededForGrowth() - c.FoodStore
+	needed := c.FoodNeededForGrowth(speed) - c.FoodStore
 	return (needed + netFood - 1) / netFood
 }
 
 // TurnsUntilComplete returns estimated turns until current production completes
-func (c *City) TurnsUntilComplete(tiles []*Tile) int {
+func (c *City) TurnsUntilComplete(tiles []*Tile, speed string) int {
 	if c.CurrentBuild == nil {
 		return -1
 	}
@@ -239,7 +346,7 @@ func (c *City) TurnsUntilComplete(tiles []*Tile) int {
 	if shields <= 0 {
 		return -1
 	}
-	needed := c.CurrentBuild.Cost() - c.Production
+	needed := c.CurrentBuild.CostAt(speed) - c.Production
 	return (needed + shields - 1) / shields
 }
 
@@ -0,0 +1,94 @@
+package game
+
+import (
+	"civilization/internal/locale"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxCityNameLength is the longest name a player can give a city, in characters
+const MaxCityNameLength = 24
+
+// generateCityName names a player's next city. Names are taken in order
+// from the historical list of the player's civilization, then from the
+// lists of the other civilizations, skipping any already used in the game.
+// When every list is used up the player's capital name is numbered.
+func (g *GameState) generateCityName(player *Player) string {
+	lists := locale.Get(g.Config.Locale).Cities()
+	civ := player.Civilization % len(lists)
+
+	for i := range lists {
+		for _, name := range lists[(civ+i)%len(lists)] {
+			if !g.cityNameTaken(name, "") {
+				return name
+			}
+		}
+	}
+
+	base := lists[civ][0]
+	for n := 2; ; n++ {
+		name := fmt.Sprintf("%s %d", base, n)
+		if !g.cityNameTaken(name, "") {
+			return name
+		}
+	}
+}
+
+// cityNameTaken reports whether a city other than the one with exceptID
+// already has a name. Names differing only in case count as the same.
+func (g *GameState) cityNameTaken(name, exceptID string) bool {
+	for _, p := range g.Players {
+		for _, c := range p.Cities {
+			if c.ID != exceptID && strings.EqualFold(c.Name, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCityName refuses names a city cannot be given: empty, too long or
+// used by another city
+func (g *GameState) checkCityName(name, cityID string) *ActionError {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxCityNameLength {
+		return &ActionError{Err: ErrInvalidCityName, CityID: cityID, Item: name}
+	}
+	if g.cityNameTaken(name, cityID) {
+		return &ActionError{Err: ErrCityNameTaken, CityID: cityID, Item: name}
+	}
+	return nil
+}
+
+// RenameCityAction gives a city a new name
+type RenameCityAction struct {
+	CityID string `json:"city_id"`
+	Name   string `json:"name"`
+}
+
+// Type returns the action type name
+func (a *RenameCityAction) Type() string {
+	return "rename_city"
+}
+
+// Validate checks if the city can take the name
+func (a *RenameCityAction) Validate(g *GameState, playerID string) error {
+	if _, err := g.ownCity(playerID, a.CityID); err != nil {
+		return err
+	}
+	if err := g.checkCityName(a.Name, a.CityID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Execute renames the city
+func (a *RenameCityAction) Execute(g *GameState) (*Result, error) {
+	city := g.GetCity(a.CityID)
+	if city == nil {
+		return nil, ErrCityNotFound
+	}
+	city.Name = strings.TrimSpace(a.Name)
+	return &Result{Cities: []string{city.ID}}, nil
+}
@@ -0,0 +1,131 @@
+package game
+
+import (
+	"encoding/json"
+	"maps"
+	"math/rand/v2"
+	"slices"
+)
+
+// Clone returns a copy of the game that can be played without touching the
+// original: map, players, units, cities and the state of the random stream
+// are all copied. The event log is shared, since events are only ever
+// appended; the copy's is cut to its length, so what either appends goes
+// to a log of its own.
+func (g *GameState) Clone() *GameState {
+	c := *g
+	c.bus = nil
+
+	c.Map = g.Map.Clone()
+	c.Players = make([]*Player, len(g.Players))
+	for i, p := range g.Players {
+		c.Players[i] = p.Clone()
+	}
+	if g.Winner != nil {
+		c.Winner = c.GetPlayer(g.Winner.ID)
+	}
+
+	c.TurnOrder = g.TurnOrder.clone()
+	c.Events = g.Events[:len(g.Events):len(g.Events)]
+	c.Orders = slices.Clone(g.Orders)
+	c.Submitted = slices.Clone(g.Submitted)
+	c.History = slices.Clone(g.History)
+	if n := len(c.History); n > 0 {
+		// Captures are added to the turn under way
+		c.History[n-1].Captures = slices.Clone(c.History[n-1].Captures)
+	}
+	c.Offers = slices.Clone(g.Offers)
+	c.LuxuryTrades = slices.Clone(g.LuxuryTrades)
+	c.CombatLog = slices.Clone(g.CombatLog)
+	c.GameLog = slices.Clone(g.GameLog)
+	c.randomEvents = slices.Clone(g.randomEvents)
+	if g.Scenario != nil {
+		c.Scenario = g.Scenario.clone()
+	}
+
+	if g.rngSource != nil {
+		source := *g.rngSource
+		c.rngSource = &source
+		c.rng = rand.New(c.rngSource)
+	}
+
+	if g.reports != nil {
+		c.reports = make(map[string]*TurnReport, len(g.reports))
+		for id, report := range g.reports {
+			c.reports[id] = report.clone()
+		}
+	}
+
+	return &c
+}
+
+// Clone returns a copy of the map
+func (m *GameMap) Clone() *GameMap {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	c.Tiles = slices.Clone(m.Tiles)
+	if m.Rivers != nil {
+		c.Rivers = make([]River, len(m.Rivers))
+	}
+	for i, river := range m.Rivers {
+		c.Rivers[i].Points = slices.Clone(river.Points)
+		if river.Delta != nil {
+			c.Rivers[i].Delta = make([][]RiverPoint, len(river.Delta))
+			for j, branch := range river.Delta {
+				c.Rivers[i].Delta[j] = slices.Clone(branch)
+			}
+		}
+	}
+	return &c
+}
+
+// Clone returns a copy of the player with copies of their units and cities
+func (p *Player) Clone() *Player {
+	c := *p
+	c.Units = make([]*Unit, len(p.Units))
+	for i, unit := range p.Units {
+		u := *unit
+		u.Patrol = slices.Clone(unit.Patrol)
+		c.Units[i] = &u
+	}
+	c.Cities = make([]*City, len(p.Cities))
+	for i, city := range p.Cities {
+		cc := *city
+		cc.Buildings = maps.Clone(city.Buildings)
+		if city.CurrentBuild != nil {
+			build := *city.CurrentBuild
+			cc.CurrentBuild = &build
+		}
+		c.Cities[i] = &cc
+	}
+	c.Explored = slices.Clone(p.Explored)
+	c.SharedVision = slices.Clone(p.SharedVision)
+	return &c
+}
+
+// clone returns a copy of the scenario, whose triggers record when they fire
+func (s *Scenario) clone() *Scenario {
+	c := *s
+	c.Triggers = make([]*Trigger, len(s.Triggers))
+	for i, trigger := range s.Triggers {
+		t := *trigger
+		if trigger.Otherwise != nil {
+			otherwise := *trigger.Otherwise
+			t.Otherwise = &otherwise
+		}
+		c.Triggers[i] = &t
+	}
+	return &c
+}
+
+// clone returns a copy of a pending turn report
+func (r *TurnReport) clone() *TurnReport {
+	// Reports are small and rarely pending, a round trip keeps this in
+	// step with the report's fields
+	data, _ := json.Marshal(r)
+	c := &TurnReport{}
+	json.Unmarshal(data, c)
+	return c
+}
@@ -0,0 +1,42 @@
+package game
+
+// Ocean tiles next to land are coastal. Only a city with a Harbor works
+// ocean tiles, and then only coastal ones; the open sea is never worked.
+
+// MarkCoast marks the ocean tiles next to land as coastal. Map generation
+// calls it once the terrain is final, and loading a map calls it again, so
+// the flags always follow the terrain.
+func (gm *GameMap) MarkCoast() {
+	for i := range gm.Tiles {
+		tile := &gm.Tiles[i]
+		tile.Coastal = false
+		if !tile.IsWater() {
+			continue
+		}
+		for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+			if !n.IsWater() {
+				tile.Coastal = true
+				break
+			}
+		}
+	}
+}
+
+// IsCoastal reports whether a city stands next to the ocean and so can
+// build a Harbor
+func (g *GameState) IsCoastal(city *City) bool {
+	for _, n := range g.Map.GetNeighbors(city.X, city.Y) {
+		if n.IsWater() {
+			return true
+		}
+	}
+	return false
+}
+
+// canWork reports whether a city can work a tile within its radius
+func canWork(city *City, tile *Tile) bool {
+	if !tile.IsWater() {
+		return true
+	}
+	return tile.Coastal && city.HasBuilding(BuildingHarbor)
+}
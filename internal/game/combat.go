@@ -1,7 +1,9 @@
 package game
 
 import (
-	"math/rand"
+	"civilization/internal/game/rules"
+	"math"
+	"math/rand/v2"
 )
 
 // CombatResult holds the outcome of a combat
@@ -11,13 +13,61 @@ type CombatResult struct {
 	DefenderDamage    int  `json:"defender_damage"`
 	AttackerDestroyed bool `json:"attacker_destroyed"`
 	DefenderDestroyed bool `json:"defender_destroyed"`
-	AttackerVeteran   bool `json:"attacker_veteran"` // Did attacker become veteran
-	DefenderVeteran   bool `json:"defender_veteran"` // Did defender become veteran
+	AttackerVeteran   bool `json:"attacker_veteran"`            // Did attacker become veteran
+	DefenderVeteran   bool `json:"defender_veteran"`            // Did defender become veteran
+	AttackerWithdrew  bool `json:"attacker_withdrew,omitempty"` // Attacker withdrew instead of being destroyed
+	DefenderWithdrew  bool `json:"defender_withdrew,omitempty"` // Defender withdrew instead of being destroyed
+}
+
+// WithdrawPercent returns the percent chance a unit faster than its
+// opponent withdraws from a battle it is losing, 0 if units never do
+func (c GameConfig) WithdrawPercent() int {
+	if !c.Withdraw {
+		return 0
+	}
+	if c.WithdrawChance > 0 {
+		return c.WithdrawChance
+	}
+	return DefaultWithdrawChance
+}
+
+// withdraws rolls whether a unit about to be destroyed withdraws from the
+// battle instead. Only units faster than their opponent can.
+func withdraws(rng *rand.Rand, loser, winner *Unit, chance int) bool {
+	if chance <= 0 || loser.Template().Movement <= winner.Template().Movement {
+		return false
+	}
+	return rng.IntN(100) < chance
+}
+
+// UnitPromotion records a unit made a veteran by winning a battle
+type UnitPromotion struct {
+	UnitID  string `json:"unit_id"`
+	OwnerID string `json:"owner_id"`
+}
+
+// notePromotions records the units a battle made veterans, for the event
+// being applied, and gives the winner any honorific it has earned.
+// defender is nil when a city fought alone.
+func (g *GameState) notePromotions(result CombatResult, attacker, defender *Unit) {
+	if result.AttackerWon {
+		g.honor(attacker)
+	} else if defender != nil {
+		g.honor(defender)
+	}
+	if result.AttackerVeteran {
+		g.promotions = append(g.promotions, UnitPromotion{UnitID: attacker.ID, OwnerID: attacker.OwnerID})
+	}
+	if result.DefenderVeteran && defender != nil {
+		g.promotions = append(g.promotions, UnitPromotion{UnitID: defender.ID, OwnerID: defender.OwnerID})
+	}
 }
 
 // ResolveCombat resolves combat between an attacker and defender
-// This uses a multi-round system similar to Civ1
-func ResolveCombat(attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool) CombatResult {
+// This uses a multi-round system similar to Civ1. A unit faster than its
+// opponent has withdrawChance percent to withdraw, with the health it has
+// left, from the round that would destroy it.
+func ResolveCombat(rng *rand.Rand, attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool, withdrawChance int) CombatResult {
 	result := CombatResult{}
 
 	// Calculate effective strengths
@@ -41,41 +91,52 @@ func ResolveCombat(attacker, defender *Unit, tile *Tile, inCity bool, fortified
 		defenseStrength = 1
 	}
 
-	// Multi-round combat
-	attackHP := BaseHealthPoints
-	defendHP := BaseHealthPoints
+	// Multi-round combat, starting from each unit's current health once a
+	// defender behind walls has struck first
+	attackHP := attacker.Health - firstStrike(defender, inCity, hasWalls)
+	defendHP := defender.Health
 
 	total := attackStrength + defenseStrength
 	attackerHitChance := float64(attackStrength) / float64(total)
 
 	// Combat rounds until one unit reaches 0 HP
 	for attackHP > 0 && defendHP > 0 {
-		if rand.Float64() < attackerHitChance {
+		if rng.Float64() < attackerHitChance {
 			// Attacker scores a hit
+			if defendHP <= DamagePerRound && withdraws(rng, defender, attacker, withdrawChance) {
+				result.DefenderWithdrew = true
+				break
+			}
 			defendHP -= DamagePerRound
 		} else {
 			// Defender scores a hit
+			if attackHP <= DamagePerRound && withdraws(rng, attacker, defender, withdrawChance) {
+				result.AttackerWithdrew = true
+				break
+			}
 			attackHP -= DamagePerRound
 		}
 	}
 
 	// Determine winner
-	result.AttackerWon = attackHP > 0
-	result.AttackerDamage = BaseHealthPoints - attackHP
-	result.DefenderDamage = BaseHealthPoints - defendHP
+	result.AttackerWon = attackHP > 0 && !result.AttackerWithdrew
+	result.AttackerDamage = attacker.Health - attackHP
+	result.DefenderDamage = defender.Health - defendHP
 	result.AttackerDestroyed = attackHP <= 0
 	result.DefenderDestroyed = defendHP <= 0
 
 	// Veteran promotion for winner (50% chance)
-	if result.AttackerWon && !attacker.IsVeteran {
-		if rand.Float64() < 0.5 {
+	if result.AttackerWon {
+		attacker.XP++
+		if !attacker.IsVeteran && rng.Float64() < 0.5 {
 			result.AttackerVeteran = true
-			attacker.IsVeteran = true
+			attacker.promote(VeteranFromCombat)
 		}
-	} else if !result.AttackerWon && !defender.IsVeteran {
-		if rand.Float64() < 0.5 {
+	} else {
+		defender.XP++
+		if !defender.IsVeteran && rng.Float64() < 0.5 {
 			result.DefenderVeteran = true
-			defender.IsVeteran = true
+			defender.promote(VeteranFromCombat)
 		}
 	}
 
@@ -84,7 +145,7 @@ func ResolveCombat(attacker, defender *Unit, tile *Tile, inCity bool, fortified
 
 // ResolveCombatSimple uses a simplified single-roll combat system
 // This is faster but less dramatic than the multi-round system
-func ResolveCombatSimple(attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool) CombatResult {
+func ResolveCombatSimple(rng *rand.Rand, attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool) CombatResult {
 	result := CombatResult{}
 
 	// Calculate effective strengths
@@ -109,31 +170,95 @@ func ResolveCombatSimple(attacker, defender *Unit, tile *Tile, inCity bool, fort
 	attackerChance := float64(attackStrength) / float64(total)
 
 	// Single roll determines winner
-	if rand.Float64() < attackerChance {
+	if rng.Float64() < attackerChance {
 		result.AttackerWon = true
 		result.DefenderDestroyed = true
-		result.DefenderDamage = BaseHealthPoints
+		result.DefenderDamage = defender.Health
 
 		// Veteran promotion
-		if !attacker.IsVeteran && rand.Float64() < 0.5 {
+		attacker.XP++
+		if !attacker.IsVeteran && rng.Float64() < 0.5 {
 			result.AttackerVeteran = true
-			attacker.IsVeteran = true
+			attacker.promote(VeteranFromCombat)
 		}
 	} else {
 		result.AttackerWon = false
 		result.AttackerDestroyed = true
-		result.AttackerDamage = BaseHealthPoints
+		result.AttackerDamage = attacker.Health
 
 		// Veteran promotion
-		if !defender.IsVeteran && rand.Float64() < 0.5 {
+		defender.XP++
+		if !defender.IsVeteran && rng.Float64() < 0.5 {
 			result.DefenderVeteran = true
-			defender.IsVeteran = true
+			defender.promote(VeteranFromCombat)
+		}
+	}
+
+	return result
+}
+
+// firstStrike returns the damage a defender deals an attacker before the
+// battle: that of its template when it defends behind city walls
+func firstStrike(defender *Unit, inCity bool, hasWalls bool) int {
+	if !inCity || !hasWalls {
+		return 0
+	}
+	return defender.Template().FirstStrike
+}
+
+// ResolveCityAssault resolves an attack on a city with no defending units.
+// The city fights with its own strength and loses defense points instead
+// of health; it falls when they reach zero.
+func ResolveCityAssault(rng *rand.Rand, attacker *Unit, city *City) CombatResult {
+	result := CombatResult{}
+
+	hitChance := cityAssaultHitChance(attacker, city)
+	attackHP := attacker.Health
+	defendHP := city.DefenseLeft()
+
+	for attackHP > 0 && defendHP > 0 {
+		if rng.Float64() < hitChance {
+			defendHP -= DamagePerRound
+		} else {
+			attackHP -= DamagePerRound
+		}
+	}
+	if defendHP < 0 {
+		defendHP = 0
+	}
+
+	result.AttackerWon = attackHP > 0
+	result.AttackerDamage = attacker.Health - attackHP
+	result.DefenderDamage = city.DefenseLeft() - defendHP
+	result.AttackerDestroyed = attackHP <= 0
+	result.DefenderDestroyed = defendHP <= 0
+
+	if result.AttackerWon {
+		attacker.XP++
+		if !attacker.IsVeteran && rng.Float64() < 0.5 {
+			result.AttackerVeteran = true
+			attacker.promote(VeteranFromCombat)
 		}
 	}
 
 	return result
 }
 
+// cityAssaultHitChance returns the attacker's chance to win a round against
+// a city's own defenses. Walls double the city's strength except against
+// siege units.
+func cityAssaultHitChance(attacker *Unit, city *City) float64 {
+	attack := attacker.EffectiveAttack()
+	if attack < 1 {
+		attack = 1
+	}
+	strength := city.Strength()
+	if city.WallsStanding() && !attacker.IsSiegeUnit() {
+		strength *= CityWallsMultiplier
+	}
+	return float64(attack) / float64(attack+strength)
+}
+
 // CalculateOdds returns the attacker's win probability (0.0 to 1.0)
 func CalculateOdds(attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool) float64 {
 	attackStrength := attacker.EffectiveAttack()
@@ -154,28 +279,149 @@ func CalculateOdds(attacker, defender *Unit, tile *Tile, inCity bool, fortified
 	return float64(attackStrength) / float64(total)
 }
 
-// SimulateCombat runs multiple simulations and returns win percentage
-func SimulateCombat(attacker, defender *Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool, simulations int) float64 {
+// SimulateCombat runs multiple simulations and returns win percentage. The
+// units are taken as values and each battle is fought by fresh copies, so
+// the game's own units are never touched and may be read meanwhile.
+func SimulateCombat(rng *rand.Rand, attacker, defender Unit, tile *Tile, inCity bool, fortified bool, hasWalls bool, withdrawChance int, simulations int) float64 {
 	wins := 0
-
-	// Save original veteran status
-	attackerVet := attacker.IsVeteran
-	defenderVet := defender.IsVeteran
-
 	for i := 0; i < simulations; i++ {
-		// Reset veteran status for simulation
-		attacker.IsVeteran = attackerVet
-		defender.IsVeteran = defenderVet
-
-		result := ResolveCombat(attacker, defender, tile, inCity, fortified, hasWalls)
+		a, d := attacker, defender
+		result := ResolveCombat(rng, &a, &d, tile, inCity, fortified, hasWalls, withdrawChance)
 		if result.AttackerWon {
 			wins++
 		}
 	}
 
-	// Restore original status
-	attacker.IsVeteran = attackerVet
-	defender.IsVeteran = defenderVet
-
 	return float64(wins) / float64(simulations)
 }
+
+// CombatModifier is one factor applied to a side's combat strength
+type CombatModifier struct {
+	Side    string `json:"side"` // "attacker" or "defender"
+	Name    string `json:"name"`
+	Percent int    `json:"percent"` // Strength change, e.g. 50 for +50%
+}
+
+// CombatOdds describes the expected outcome of an attack before it is made
+type CombatOdds struct {
+	Attacker        *Unit
+	Defender        *Unit // nil when the target is an undefended city
+	AttackStrength  int
+	DefenseStrength int
+	HitChance       float64 // Chance the attacker wins a single round
+	WinChance       float64 // Chance the attacker wins the whole combat
+	FirstStrike     int     // Damage the defender deals before the battle
+	Modifiers       []CombatModifier
+}
+
+// PreviewAttack computes the odds of an attack without resolving it
+func (g *GameState) PreviewAttack(playerID, attackerID string, targetX, targetY int) (*CombatOdds, error) {
+	attacker := g.GetUnit(attackerID)
+	if attacker == nil {
+		return nil, ErrUnitNotFound
+	}
+	if attacker.OwnerID != playerID {
+		return nil, ErrNotYourUnit
+	}
+
+	if !rules.Adjacent(attacker.X, attacker.Y, targetX, targetY) {
+		return nil, ErrInvalidTarget
+	}
+
+	defender, tile, city := g.attackTarget(attacker, targetX, targetY)
+	if defender == nil && (city == nil || city.OwnerID == playerID) {
+		return nil, ErrInvalidTarget
+	}
+
+	odds := &CombatOdds{
+		Attacker:  attacker,
+		Defender:  defender,
+		Modifiers: make([]CombatModifier, 0),
+	}
+
+	if defender == nil {
+		// Nothing defends the city, the attacker fights its defenses
+		odds.AttackStrength = attacker.EffectiveAttack()
+		odds.DefenseStrength = city.Strength()
+		if city.DefenseLeft() == 0 {
+			odds.HitChance = 1
+			odds.WinChance = 1
+			return odds, nil
+		}
+		if city.WallsStanding() && !attacker.IsSiegeUnit() {
+			odds.DefenseStrength *= CityWallsMultiplier
+			odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "defender", Name: "walls", Percent: (CityWallsMultiplier - 1) * 100})
+		}
+		odds.HitChance = cityAssaultHitChance(attacker, city)
+		odds.WinChance = combatWinChance(odds.HitChance, hitsToDestroy(city.DefenseLeft()), hitsToDestroy(attacker.Health))
+		return odds, nil
+	}
+
+	inCity := city != nil
+	hasWalls := inCity && city.WallsStanding()
+
+	if attacker.IsVeteran {
+		odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "attacker", Name: "veteran", Percent: VeteranBonus})
+	}
+	if defender.IsVeteran {
+		odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "defender", Name: "veteran", Percent: VeteranBonus})
+	}
+	if bonus := TerrainDefenseBonus[tile.Terrain]; bonus != 1.0 {
+		odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "defender", Name: "terrain", Percent: int((bonus - 1.0) * 100)})
+	}
+	if defender.IsFortified {
+		odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "defender", Name: "fortified", Percent: FortificationBonus})
+	}
+	if hasWalls && !attacker.IsSiegeUnit() {
+		odds.Modifiers = append(odds.Modifiers, CombatModifier{Side: "defender", Name: "walls", Percent: (CityWallsMultiplier - 1) * 100})
+	}
+
+	odds.AttackStrength = attacker.EffectiveAttack()
+	odds.DefenseStrength = defender.EffectiveDefense(tile.Terrain, inCity, defender.IsFortified)
+	if hasWalls && !attacker.IsSiegeUnit() {
+		odds.DefenseStrength *= CityWallsMultiplier
+	}
+	odds.HitChance = CalculateOdds(attacker, defender, tile, inCity, defender.IsFortified, hasWalls)
+	odds.FirstStrike = firstStrike(defender, inCity, hasWalls)
+	odds.WinChance = combatWinChance(odds.HitChance, hitsToDestroy(defender.Health), hitsAfterStrike(attacker.Health, odds.FirstStrike))
+
+	return odds, nil
+}
+
+// hitsToDestroy returns how many combat rounds a unit with the given health
+// can lose before it is destroyed
+func hitsToDestroy(health int) int {
+	if health < 1 {
+		return 1
+	}
+	return (health + DamagePerRound - 1) / DamagePerRound
+}
+
+// hitsAfterStrike returns how many combat rounds an attacker can lose once
+// a first strike has hit it, 0 if the strike destroyed it
+func hitsAfterStrike(health, strike int) int {
+	if strike > 0 && health <= strike {
+		return 0
+	}
+	return hitsToDestroy(health - strike)
+}
+
+// combatWinChance returns the probability that the attacker wins the
+// multi-round combat of ResolveCombat given its chance to win each round
+// and the rounds each side must win to destroy the other
+func combatWinChance(hitChance float64, hits, defenderHits int) float64 {
+	miss := 1 - hitChance
+
+	// The attacker must land all its hits before taking defenderHits itself:
+	// sum over k defender hits of C(hits-1+k, k) * p^hits * q^k
+	chance := 0.0
+	coefficient := 1.0
+	pHits := math.Pow(hitChance, float64(hits))
+	for k := 0; k < defenderHits; k++ {
+		if k > 0 {
+			coefficient = coefficient * float64(hits-1+k) / float64(k)
+		}
+		chance += coefficient * pHits * math.Pow(miss, float64(k))
+	}
+	return chance
+}
@@ -0,0 +1,44 @@
+package game
+
+// CombatLogEntry records a battle so players can review what happened
+// while others moved
+type CombatLogEntry struct {
+	Turn         int      `json:"turn"`
+	X            int      `json:"x"`
+	Y            int      `json:"y"`
+	AttackerID   string   `json:"attacker_id"`
+	AttackerUnit UnitType `json:"attacker_unit"`
+	DefenderID   string   `json:"defender_id"`
+	DefenderUnit UnitType `json:"defender_unit"`
+	Undefended   bool     `json:"undefended,omitempty"` // A city fought alone, DefenderUnit is unset
+	Odds         float64  `json:"odds"`                 // Attacker's chance to win, as the battle began
+	AttackerWon  bool     `json:"attacker_won"`
+	AttackerLost bool     `json:"attacker_lost,omitempty"` // Attacking unit destroyed
+	DefenderLost bool     `json:"defender_lost,omitempty"` // Defending unit destroyed
+	Withdrew     bool     `json:"withdrew,omitempty"`      // The losing unit withdrew instead of being destroyed
+	CityCaptured string   `json:"city_captured,omitempty"`
+	Plunder      int      `json:"plunder,omitempty"` // Gold taken from the captured city
+}
+
+// logCombat adds a battle to the combat log, dropping the oldest once the
+// log holds CombatLogSize
+func (g *GameState) logCombat(entry CombatLogEntry) {
+	entry.Turn = g.CurrentTurn
+	g.CombatLog = append(g.CombatLog, entry)
+	if extra := len(g.CombatLog) - CombatLogSize; extra > 0 {
+		g.CombatLog = append(g.CombatLog[:0], g.CombatLog[extra:]...)
+	}
+	g.publish(CombatResolved{entry})
+}
+
+// CombatLogFor returns the battles in the combat log a player fought in,
+// attacking or defending
+func (g *GameState) CombatLogFor(playerID string) []CombatLogEntry {
+	entries := make([]CombatLogEntry, 0)
+	for _, e := range g.CombatLog {
+		if e.AttackerID == playerID || e.DefenderID == playerID {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
@@ -12,6 +12,8 @@ const (
 	BaseFoodForGrowth      = 10 // Base food needed for growth
 	FoodPerPopForGrowth    = 10 // Additional food per population level
 	GranaryFoodRetention   = 50 // Percentage of food kept after growth with granary
+	HarborFoodBonus        = 1  // Extra food from each ocean tile a city with a harbor works
+	CityRadius             = 2  // Tiles a city works and claims around itself
 
 	// Combat constants
 	BaseHealthPoints       = 100
@@ -19,15 +21,123 @@ const (
 	VeteranBonus           = 50 // Percentage bonus for veterans
 	FortificationBonus     = 50 // Percentage bonus for fortified units
 	CityWallsMultiplier    = 2  // Defense multiplier for city walls
+	CityWallsHealth        = 100 // Defense points walls add, lost first
+	CombatLogSize          = 50 // Battles the game remembers for players to review
+	GameLogSize            = 500 // Entries of the game log the game remembers
+	AttackMovementCost     = 1 // Movement a hit-and-run unit spends on an attack
+	DefaultWithdrawChance  = 50 // Percent chance a faster unit withdraws instead of being destroyed
+
+	// City defense constants
+	CityBaseDefense        = 40 // Defense points of a city before population and walls
+	CityDefensePerPop      = 10 // Defense points per population
+	CityRepairPerTurn      = 20 // Defense points a city repairs each turn
+	CityBaseStrength       = 1  // Strength of a size 1 or 2 city
+	CityStrengthPopDivisor = 3  // Population per extra point of strength
+
+	// Healing constants (health restored at the end of the owner's turn)
+	HealInCity             = 30 // In a friendly city, barracks heal fully
+	HealFortified          = 20 // Fortified in friendly territory
+	HealInField            = 10 // Elsewhere outside enemy territory
+
+	// Siege constants
+	BombardRange           = 2 // Tiles a siege unit can bombard across
+	BombardRounds          = 3 // Rounds fired per bombardment
+	BombardCooldown        = 2 // Turns before a unit can bombard again
+	BombardMinHealth       = 20 // Bombardment never takes a unit below this
+
+	// Nuclear constants
+	NukeRadius             = 1  // Tiles around the target a detonation reaches
+	NukePopulationLoss     = 50 // Percentage of population a city hit loses
+	FalloutYieldDivisor    = 2  // Fallout divides every yield of a tile
+
+	// Random event constants
+	DefaultRandomEventChance = 5  // Percent chance of an event per city and turn
+	PlagueMinPopulation      = 3  // Smaller cities are spared by plague
+	PlagueLoss               = 1  // Population a plague kills
+	HarvestFoodBonus         = 50 // Percentage of the growth requirement a harvest adds
+	EruptionRadius           = 1  // Tiles around a volcano an eruption reaches
+	EruptionDamage           = 60 // Health an eruption takes from each unit it reaches
+	EruptionLoss             = 1  // Population an eruption kills in each city it reaches
+	FloodFoodLoss            = 50 // Percentage of a city's food store a flood spoils
 
 	// Production constants
 	BaseProductionPerTurn  = 1
 
+	// Road constants
+	RoadMovementCost       = 1 // Moving from one road tile to another
+	TradeRouteGold         = 2 // Gold a city joined to its capital by road earns each turn
+
+	// Economy constants
+	BaseCityTrade          = 2  // Trade of a city's center, besides its tiles
+	DefaultTaxRate         = 50 // Percent of trade collected as gold, the rest going to science
+	TaxRateStep            = 10 // Tax rates are set in steps of this many percent
+	MarketplaceBonus       = 50 // Percent more gold a city with a marketplace collects
+	LibraryBonus           = 50 // Percent more science a city with a library gathers
+	BaseFreeUnits          = 4  // Units every player supports without upkeep
+	FreeUnitsPerCity       = 2  // Further units supported for each city
+	UnitUpkeepGold         = 1  // Gold each unit beyond those supported costs per turn
+
+	// Happiness constants
+	ContentCitizens        = 4  // Citizens of each city content without luxuries
+	LuxuryHappiness        = 1  // Citizens each distinct luxury makes content in every city
+	LuxuryDealTurns        = 20 // Turns a luxury given in a deal is shared
+	CelebrationSize        = 3  // Citizens a city needs before it can celebrate
+	CelebrationTrade       = 1  // Trade added to each worked tile with trade in a celebrating city
+
+	// Plunder constants
+	DefaultPlunderPercent  = 100 // Percent of a captured city's worth its conqueror takes
+	MaxPlunderPercent      = 200 // Most a game can be set to take
+	PlunderPerCitizen      = 10  // Gold a captured city is worth for each citizen
+	PlunderPerBuilding     = 20  // Gold a captured city is worth for each building
+
+	// Advisor constants
+	AdvisorThreatDistance  = 3 // Tiles from a city an enemy unit is pointed out
+	AdvisorSiteReach       = 3 // Tiles from an idle settler city sites are looked for
+
+	// Advanced start constants
+	MaxAdvancedStart       = 2000 // Most points a game can give each player
+	AdvancedStartCityCost  = 100  // Points a city costs
+	AdvancedStartReach     = 6    // Tiles from a player's units and cities they may buy a city
+	SciencePerStartPoint   = 2    // Science a point buys
+
+	// Capital constants
+	CorruptionPerTile      = 3  // Percent of a city's trade lost for each tile from the capital
+	MaxCorruption          = 50 // Most trade a city loses, and what every city loses without a capital
+	WastePerTile           = 2  // Percent of a city's shields lost for each tile from the capital
+	MaxWaste               = 40 // Most shields a city loses, and what every city loses without a capital
+
+	// Terrain job constants (turns of work each takes)
+	ClearForestTurns       = 3
+	PlantForestTurns       = 4
+	MineHillsTurns         = 3
+	MineSpecialTurns       = 5  // A mine on a resource off the hills
+	ClearForestShields     = 10 // Production the timber of a cleared forest gives the nearest city
+
+	// Unit automation constants
+	SightRadius            = 1  // Tiles a unit sees around itself, unless its type sees further
+	MountainSightBonus     = 1  // Extra sight of a unit standing on mountains
+	SentryWakeRange        = 2  // Enemy distance that wakes a sentry
+	MaxAutomationDistance  = 30 // How far automated units look for a target
+
+	// Score constants
+	ScorePerCitizen        = 1 // Points for each citizen
+	ScorePerWonder         = 5 // Points for each wonder built
+
 	// Starting resources
 	StartingGold           = 0
 	StartingUnits          = 2 // 1 Settler + 1 Warrior
 )
 
+// SpeedCostPercent scales production costs and the food cities need to
+// grow at each game speed, as a percentage of the standard speed's.
+// Technology costs scale the same way.
+var SpeedCostPercent = map[string]int{
+	SpeedQuick:    67,
+	SpeedStandard: 100,
+	SpeedEpic:     150,
+	SpeedMarathon: 300,
+}
+
 // TerrainMovementCost defines movement points needed to enter terrain
 var TerrainMovementCost = map[TerrainType]int{
 	TerrainOcean:     1, // Only for naval units
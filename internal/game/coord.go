@@ -0,0 +1,171 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"fmt"
+	"iter"
+)
+
+// Positions on the map are Coords, and the steps between them Directions.
+// How far apart positions are and which are next to each other comes from
+// the rules package, and which positions surround one from the Directions
+// here, so the shape of the map is decided in one place: a map that wraps
+// around or is made of hexes changes these and nothing else.
+
+// Coord is a position on the map
+type Coord struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// At returns the position (x, y)
+func At(x, y int) Coord {
+	return Coord{x, y}
+}
+
+// String returns the position as "(x,y)"
+func (c Coord) String() string {
+	return fmt.Sprintf("(%d,%d)", c.X, c.Y)
+}
+
+// Distance returns how many moves apart two positions are
+func (c Coord) Distance(o Coord) int {
+	return rules.Distance(c.X, c.Y, o.X, o.Y)
+}
+
+// Adjacent reports whether two positions are next to each other, diagonally
+// included
+func (c Coord) Adjacent(o Coord) bool {
+	return rules.Adjacent(c.X, c.Y, o.X, o.Y)
+}
+
+// Step returns the position one step from c in a direction
+func (c Coord) Step(d Direction) Coord {
+	dx, dy := d.Offset()
+	return Coord{c.X + dx, c.Y + dy}
+}
+
+// Neighbors returns the positions next to c, in the order of Directions.
+// Some may lie off the map.
+func (c Coord) Neighbors() []Coord {
+	neighbors := make([]Coord, len(Directions))
+	for i, d := range Directions {
+		neighbors[i] = c.Step(d)
+	}
+	return neighbors
+}
+
+// DirectionTo returns the direction of a position next to c, and false if
+// it is not next to c
+func (c Coord) DirectionTo(o Coord) (Direction, bool) {
+	for _, d := range Directions {
+		if c.Step(d) == o {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Within yields the positions at most radius moves from c, c included,
+// row by row from the top left. Some may lie off the map.
+func (c Coord) Within(radius int) iter.Seq[Coord] {
+	return func(yield func(Coord) bool) {
+		for y := c.Y - radius; y <= c.Y+radius; y++ {
+			for x := c.X - radius; x <= c.X+radius; x++ {
+				if !yield(Coord{x, y}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Direction is a step from a tile to one next to it
+type Direction int
+
+// The directions, row by row from the top left
+const (
+	NorthWest Direction = iota
+	North
+	NorthEast
+	West
+	East
+	SouthWest
+	South
+	SouthEast
+)
+
+// Directions are the steps to every tile next to another, row by row from
+// the top left
+var Directions = []Direction{NorthWest, North, NorthEast, West, East, SouthWest, South, SouthEast}
+
+// CardinalDirections are the steps to the tiles sharing an edge with
+// another: north, south, east and west
+var CardinalDirections = []Direction{North, South, East, West}
+
+var directionOffsets = [...][2]int{
+	NorthWest: {-1, -1},
+	North:     {0, -1},
+	NorthEast: {1, -1},
+	West:      {-1, 0},
+	East:      {1, 0},
+	SouthWest: {-1, 1},
+	South:     {0, 1},
+	SouthEast: {1, 1},
+}
+
+var directionNames = [...]string{
+	NorthWest: "northwest",
+	North:     "north",
+	NorthEast: "northeast",
+	West:      "west",
+	East:      "east",
+	SouthWest: "southwest",
+	South:     "south",
+	SouthEast: "southeast",
+}
+
+// Offset returns how far a step in the direction goes along each axis
+func (d Direction) Offset() (dx, dy int) {
+	return directionOffsets[d][0], directionOffsets[d][1]
+}
+
+// Opposite returns the direction a step in d is undone by
+func (d Direction) Opposite() Direction {
+	return SouthEast - d
+}
+
+// String returns the name of the direction
+func (d Direction) String() string {
+	return directionNames[d]
+}
+
+// Coord returns where the unit stands
+func (u *Unit) Coord() Coord {
+	return Coord{u.X, u.Y}
+}
+
+// Coord returns where the city stands
+func (c *City) Coord() Coord {
+	return Coord{c.X, c.Y}
+}
+
+// Coord returns the tile's position
+func (t *Tile) Coord() Coord {
+	return Coord{t.X, t.Y}
+}
+
+// Contains reports whether a position lies on the map
+func (gm *GameMap) Contains(c Coord) bool {
+	return gm.IsValidCoord(c.X, c.Y)
+}
+
+// TileAt returns the tile at a position, or nil off the map
+func (gm *GameMap) TileAt(c Coord) *Tile {
+	return gm.GetTile(c.X, c.Y)
+}
+
+// CoordOf returns the position of the tile at an index into Tiles
+func (gm *GameMap) CoordOf(index int) Coord {
+	return Coord{index % gm.Width, index / gm.Width}
+}
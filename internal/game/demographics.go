@@ -0,0 +1,124 @@
+package game
+
+import (
+	"slices"
+	"sort"
+)
+
+// Demographic categories
+const (
+	DemographicPopulation = "population" // Citizens in all cities
+	DemographicLandArea   = "land_area"  // Land tiles within the player's borders
+	DemographicMilitary   = "military"   // Military strength
+	DemographicGNP        = "gnp"        // Trade of the tiles cities work
+	DemographicLiteracy   = "literacy"   // Technologies known
+)
+
+// Demographic is where a player stands in one category. Only the player's
+// own value is given; other civilizations are ranked, and those the
+// player has not met stay anonymous.
+type Demographic struct {
+	Category string            `json:"category"`
+	Value    int               `json:"value"`
+	Rank     int               `json:"rank"`
+	Ranks    []DemographicRank `json:"ranks"` // Every civilization still in the game, best first
+}
+
+// DemographicRank is one civilization's place in a category
+type DemographicRank struct {
+	PlayerID string `json:"player_id,omitempty"` // Empty for civilizations not yet met
+	Rank     int    `json:"rank"`
+}
+
+// Demographics ranks the civilizations still in the game in each
+// category, as the given player may see them
+func (g *GameState) Demographics(playerID string) ([]Demographic, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+
+	alive := make([]*Player, 0, len(g.Players))
+	for _, p := range g.Players {
+		if p.IsAlive || p == player {
+			alive = append(alive, p)
+		}
+	}
+
+	land := g.landArea()
+	values := map[string]func(p *Player) int{
+		DemographicPopulation: (*Player).TotalPopulation,
+		DemographicLandArea:   func(p *Player) int { return land[p.ID] },
+		DemographicMilitary:   (*Player).MilitaryStrength,
+		DemographicGNP:        g.gnp,
+		DemographicLiteracy:   g.TechCount,
+	}
+
+	categories := []string{DemographicPopulation, DemographicLandArea, DemographicMilitary, DemographicGNP, DemographicLiteracy}
+	demographics := make([]Demographic, 0, len(categories))
+	for _, category := range categories {
+		value := values[category]
+		scores := make(map[string]int, len(alive))
+		for _, p := range alive {
+			scores[p.ID] = value(p)
+		}
+
+		d := Demographic{Category: category, Value: scores[player.ID], Ranks: make([]DemographicRank, 0, len(alive))}
+		for _, p := range alive {
+			// Tied civilizations share a rank
+			rank := 1
+			for _, other := range alive {
+				if scores[other.ID] > scores[p.ID] {
+					rank++
+				}
+			}
+
+			if p == player {
+				d.Rank = rank
+			}
+			entry := DemographicRank{Rank: rank}
+			if p == player || g.hasMet(player, p) {
+				entry.PlayerID = p.ID
+			}
+			d.Ranks = append(d.Ranks, entry)
+		}
+		sort.SliceStable(d.Ranks, func(i, j int) bool { return d.Ranks[i].Rank < d.Ranks[j].Rank })
+
+		demographics = append(demographics, d)
+	}
+
+	return demographics, nil
+}
+
+// landArea counts the land tiles within each player's borders
+func (g *GameState) landArea() map[string]int {
+	area := make(map[string]int)
+	for i := range g.Map.Tiles {
+		tile := &g.Map.Tiles[i]
+		if tile.IsWater() {
+			continue
+		}
+		if owner := g.TerritoryOwner(tile.X, tile.Y); owner != "" {
+			area[owner]++
+		}
+	}
+	return area
+}
+
+// gnp returns the trade of every tile the player's cities work
+func (g *GameState) gnp(p *Player) int {
+	trade := 0
+	for _, city := range p.Cities {
+		for _, tile := range g.GetCityTiles(city) {
+			trade += tile.TradeYield()
+		}
+	}
+	return trade
+}
+
+// hasMet reports whether a player has ever had any of another's cities or
+// units in sight. Having explored the tile one stands on long before is
+// not enough.
+func (g *GameState) hasMet(player, other *Player) bool {
+	return slices.Contains(player.Met, other.ID)
+}
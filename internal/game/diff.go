@@ -0,0 +1,201 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Diffing two states of a game shows where they part, such as a saved game
+// and the same game replayed from its event log, which should match field
+// for field. Players, units, cities and tiles are compared field by field
+// as they are saved, so fields added to them are compared too.
+
+// Difference is a field that differs between two states of a game, or a
+// player, unit or city only one of them has
+type Difference struct {
+	Kind  string `json:"kind"`            // "game", "player", "unit", "city" or "tile"
+	ID    string `json:"id,omitempty"`    // Player, unit or city ID, or a tile's "x,y"
+	Field string `json:"field,omitempty"` // Empty when only one state has the thing
+	A     string `json:"a"`               // The field as saved in the first state, empty if missing
+	B     string `json:"b"`               // The field as saved in the second state, empty if missing
+}
+
+// maxDiffValue is how much of a value String shows
+const maxDiffValue = 60
+
+func (d Difference) String() string {
+	what := d.Kind
+	if d.ID != "" {
+		what += " " + d.ID
+	}
+	if d.Field != "" {
+		what += " " + d.Field
+	}
+	return fmt.Sprintf("%s: %s -> %s", what, shortValue(d.A), shortValue(d.B))
+}
+
+// shortValue returns a value cut down to maxDiffValue
+func shortValue(v string) string {
+	switch {
+	case v == "":
+		return "(none)"
+	case len(v) > maxDiffValue:
+		return fmt.Sprintf("%s... (%d bytes)", v[:maxDiffValue], len(v))
+	}
+	return v
+}
+
+// Diff returns the differences between two states of a game: in the game's
+// own fields, its players, their units and cities, and its tiles
+func Diff(a, b *GameState) []Difference {
+	var diffs []Difference
+
+	// The game's own fields, leaving out what is compared piece by piece
+	// and the event log, which is not part of the state
+	diffs = diffFields(diffs, "game", "", a, b, "map", "players", "winner", "events")
+	if winnerID(a) != winnerID(b) {
+		diffs = append(diffs, Difference{Kind: "game", Field: "winner", A: winnerID(a), B: winnerID(b)})
+	}
+
+	// Players, then their units and cities
+	playersA, playersB := make(map[string]*Player), make(map[string]*Player)
+	unitsA, unitsB := newDiffIndex(), newDiffIndex()
+	citiesA, citiesB := newDiffIndex(), newDiffIndex()
+	index := func(g *GameState, players map[string]*Player, units, cities *diffIndex) {
+		for _, p := range g.Players {
+			players[p.ID] = p
+			for _, u := range p.Units {
+				units.add(u.ID, u, fmt.Sprintf("%s of %s at (%d, %d)", u.Type, u.OwnerID, u.X, u.Y))
+			}
+			for _, c := range p.Cities {
+				cities.add(c.ID, c, fmt.Sprintf("%s of %s at (%d, %d)", c.Name, c.OwnerID, c.X, c.Y))
+			}
+		}
+	}
+	index(a, playersA, unitsA, citiesA)
+	index(b, playersB, unitsB, citiesB)
+
+	for _, p := range a.Players {
+		if other := playersB[p.ID]; other != nil {
+			diffs = diffFields(diffs, "player", p.ID, p, other, "units", "cities")
+		} else {
+			diffs = append(diffs, Difference{Kind: "player", ID: p.ID, A: p.Name})
+		}
+	}
+	for _, p := range b.Players {
+		if playersA[p.ID] == nil {
+			diffs = append(diffs, Difference{Kind: "player", ID: p.ID, B: p.Name})
+		}
+	}
+
+	diffs = diffIndexes(diffs, "unit", unitsA, unitsB)
+	diffs = diffIndexes(diffs, "city", citiesA, citiesB)
+
+	// Tiles, where the maps are the same size
+	if a.Map == nil || b.Map == nil {
+		return diffs
+	}
+	if a.Map.Width != b.Map.Width || a.Map.Height != b.Map.Height {
+		size := func(m *GameMap) string { return fmt.Sprintf("%dx%d", m.Width, m.Height) }
+		return append(diffs, Difference{Kind: "game", Field: "map", A: size(a.Map), B: size(b.Map)})
+	}
+	for i := range a.Map.Tiles {
+		tileA, tileB := &a.Map.Tiles[i], &b.Map.Tiles[i]
+		diffs = diffFields(diffs, "tile", fmt.Sprintf("%d,%d", tileA.X, tileA.Y), tileA, tileB)
+	}
+	if !bytes.Equal(saved(a.Map.Rivers), saved(b.Map.Rivers)) {
+		diffs = append(diffs, Difference{Kind: "game", Field: "rivers", A: string(saved(a.Map.Rivers)), B: string(saved(b.Map.Rivers))})
+	}
+	return diffs
+}
+
+// winnerID returns the ID of a game's winner, or "" if it has none
+func winnerID(g *GameState) string {
+	if g.Winner == nil {
+		return ""
+	}
+	return g.Winner.ID
+}
+
+// diffIndex holds the units or cities of a state by ID, in the order
+// they were found, with how to describe each where only one state has it
+type diffIndex struct {
+	ids       []string
+	byID      map[string]interface{}
+	described map[string]string
+}
+
+func newDiffIndex() *diffIndex {
+	return &diffIndex{byID: make(map[string]interface{}), described: make(map[string]string)}
+}
+
+func (x *diffIndex) add(id string, v interface{}, description string) {
+	x.ids = append(x.ids, id)
+	x.byID[id] = v
+	x.described[id] = description
+}
+
+// diffIndexes appends the differences between the units or cities of two
+// states
+func diffIndexes(diffs []Difference, kind string, a, b *diffIndex) []Difference {
+	for _, id := range a.ids {
+		if other, ok := b.byID[id]; ok {
+			diffs = diffFields(diffs, kind, id, a.byID[id], other)
+		} else {
+			diffs = append(diffs, Difference{Kind: kind, ID: id, A: a.described[id]})
+		}
+	}
+	for _, id := range b.ids {
+		if _, ok := a.byID[id]; !ok {
+			diffs = append(diffs, Difference{Kind: kind, ID: id, B: b.described[id]})
+		}
+	}
+	return diffs
+}
+
+// diffFields appends the fields of two values that differ as saved, in
+// field name order, leaving out those skipped
+func diffFields(diffs []Difference, kind, id string, a, b interface{}, skip ...string) []Difference {
+	fieldsA, fieldsB := savedFields(a), savedFields(b)
+	for _, name := range skip {
+		delete(fieldsA, name)
+		delete(fieldsB, name)
+	}
+
+	names := make([]string, 0, len(fieldsA))
+	for name := range fieldsA {
+		names = append(names, name)
+	}
+	for name := range fieldsB {
+		if _, ok := fieldsA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !bytes.Equal(fieldsA[name], fieldsB[name]) {
+			diffs = append(diffs, Difference{Kind: kind, ID: id, Field: name, A: string(fieldsA[name]), B: string(fieldsB[name])})
+		}
+	}
+	return diffs
+}
+
+// saved returns a value as it is saved
+func saved(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return []byte(fmt.Sprintf("(%v)", err))
+	}
+	return data
+}
+
+// savedFields returns the fields of a value as it is saved, by name.
+// Fields left out when empty are missing.
+func savedFields(v interface{}) map[string]json.RawMessage {
+	fields := make(map[string]json.RawMessage)
+	json.Unmarshal(saved(v), &fields)
+	return fields
+}
@@ -0,0 +1,216 @@
+package game
+
+import "slices"
+
+// Deals players can offer one another
+const (
+	DealMapTrade     = "map_trade"     // Both players learn every tile the other has explored
+	DealSharedVision = "shared_vision" // As map trade, and from then on each sees what the other sees
+	DealLuxury       = "luxury"        // The offering player shares a luxury for LuxuryDealTurns turns
+)
+
+// DealOffer is a deal one player has offered another, standing until the
+// other accepts or declines it
+type DealOffer struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Deal     string `json:"deal"`
+	Resource string `json:"resource,omitempty"` // The luxury of a luxury deal
+}
+
+// offer returns the index of an offer, or -1
+func (g *GameState) offer(o DealOffer) int {
+	return slices.Index(g.Offers, o)
+}
+
+// SharesVision reports whether two players have a shared vision pact
+func (p *Player) SharesVision(playerID string) bool {
+	return slices.Contains(p.SharedVision, playerID)
+}
+
+// checkDeal refuses a deal between players it cannot be made between, and
+// a luxury the offering player has no copy of to spare
+func (g *GameState) checkDeal(o DealOffer) (from, to *Player, err error) {
+	if o.Deal != DealMapTrade && o.Deal != DealSharedVision && o.Deal != DealLuxury {
+		return nil, nil, &ActionError{Err: ErrUnknownDeal, Item: o.Deal}
+	}
+	from, to = g.GetPlayer(o.From), g.GetPlayer(o.To)
+	if from == nil || to == nil {
+		return nil, nil, ErrPlayerNotFound
+	}
+	if from == to || !from.IsAlive || !to.IsAlive {
+		return nil, nil, &ActionError{Err: ErrInvalidPartner, Item: to.Name}
+	}
+	if o.Deal == DealLuxury {
+		resource, ok := ResourceTypeByName(o.Resource)
+		if !ok || !resource.IsLuxury() {
+			return nil, nil, &ActionError{Err: ErrNotLuxury, Item: o.Resource}
+		}
+		if !g.spareLuxury(from, resource) {
+			return nil, nil, &ActionError{Err: ErrNoSpareLuxury, Item: o.Resource}
+		}
+	}
+	return from, to, nil
+}
+
+// mergeExplored teaches a player every tile another has explored
+func (g *GameState) mergeExplored(player, from *Player) {
+	for i := range g.Map.Tiles {
+		if i/8 < len(from.Explored) && from.Explored[i/8]&(1<<(i%8)) != 0 {
+			g.explore(player, i)
+		}
+	}
+}
+
+// ProposeDealAction offers another player a deal
+type ProposeDealAction struct {
+	DealOffer
+}
+
+// Type returns the action type name
+func (a *ProposeDealAction) Type() string {
+	return "propose_deal"
+}
+
+// Validate checks if the deal can be offered
+func (a *ProposeDealAction) Validate(g *GameState, playerID string) error {
+	if a.From != playerID {
+		return ErrNotYourDeal
+	}
+	_, to, err := g.checkDeal(a.DealOffer)
+	if err != nil {
+		return err
+	}
+	if g.offer(a.DealOffer) >= 0 {
+		return &ActionError{Err: ErrDealOffered, Item: a.Deal}
+	}
+	if a.Deal == DealSharedVision && to.SharesVision(a.From) {
+		return &ActionError{Err: ErrAlreadySharing, Item: to.Name}
+	}
+	return nil
+}
+
+// Execute records the offer for the other player to answer
+func (a *ProposeDealAction) Execute(g *GameState) (*Result, error) {
+	g.Offers = append(g.Offers, a.DealOffer)
+	return &Result{Players: []string{a.From, a.To}}, nil
+}
+
+// AcceptDealAction accepts a deal another player offered
+type AcceptDealAction struct {
+	DealOffer
+}
+
+// Type returns the action type name
+func (a *AcceptDealAction) Type() string {
+	return "accept_deal"
+}
+
+// Validate checks that the deal was offered and can still be made
+func (a *AcceptDealAction) Validate(g *GameState, playerID string) error {
+	if a.To != playerID {
+		return ErrNotYourDeal
+	}
+	if _, _, err := g.checkDeal(a.DealOffer); err != nil {
+		return err
+	}
+	if g.offer(a.DealOffer) < 0 {
+		return &ActionError{Err: ErrNoOffer, Item: a.Deal}
+	}
+	return nil
+}
+
+// Execute carries out the deal: a luxury is shared, or both players'
+// explored tiles are merged, and a shared vision pact joins them from then
+// on
+func (a *AcceptDealAction) Execute(g *GameState) (*Result, error) {
+	from, to, err := g.checkDeal(a.DealOffer)
+	if err != nil {
+		return nil, err
+	}
+	if i := g.offer(a.DealOffer); i >= 0 {
+		g.Offers = slices.Delete(g.Offers, i, i+1)
+	}
+	result := &Result{Players: []string{from.ID, to.ID}}
+
+	if a.Deal == DealLuxury {
+		resource, _ := ResourceTypeByName(a.Resource)
+		g.LuxuryTrades = append(g.LuxuryTrades, LuxuryTrade{From: from.ID, To: to.ID, Resource: resource, Until: g.CurrentTurn + LuxuryDealTurns})
+		return result, nil
+	}
+	if a.Deal == DealSharedVision && !from.SharesVision(to.ID) {
+		from.SharedVision = append(from.SharedVision, to.ID)
+		to.SharedVision = append(to.SharedVision, from.ID)
+	}
+	g.mergeExplored(from, to)
+	g.mergeExplored(to, from)
+	return result, nil
+}
+
+// DeclineDealAction turns down a deal offered to the player, or withdraws
+// one the player offered
+type DeclineDealAction struct {
+	DealOffer
+}
+
+// Type returns the action type name
+func (a *DeclineDealAction) Type() string {
+	return "decline_deal"
+}
+
+// Validate checks that the offer stands and is the player's to answer or
+// withdraw
+func (a *DeclineDealAction) Validate(g *GameState, playerID string) error {
+	if a.From != playerID && a.To != playerID {
+		return ErrNotYourDeal
+	}
+	if g.offer(a.DealOffer) < 0 {
+		return &ActionError{Err: ErrNoOffer, Item: a.Deal}
+	}
+	return nil
+}
+
+// Execute removes the offer
+func (a *DeclineDealAction) Execute(g *GameState) (*Result, error) {
+	if i := g.offer(a.DealOffer); i >= 0 {
+		g.Offers = slices.Delete(g.Offers, i, i+1)
+	}
+	return &Result{Players: []string{a.From, a.To}}, nil
+}
+
+// CancelPactAction ends a shared vision pact. What either player learned
+// while it lasted stays explored.
+type CancelPactAction struct {
+	PlayerID  string `json:"player_id"`
+	PartnerID string `json:"partner_id"`
+}
+
+// Type returns the action type name
+func (a *CancelPactAction) Type() string {
+	return "cancel_pact"
+}
+
+// Validate checks that the players share vision
+func (a *CancelPactAction) Validate(g *GameState, playerID string) error {
+	if a.PlayerID != playerID {
+		return ErrNotYourDeal
+	}
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return ErrPlayerNotFound
+	}
+	if !player.SharesVision(a.PartnerID) {
+		return ErrNotSharing
+	}
+	return nil
+}
+
+// Execute ends the pact for both players
+func (a *CancelPactAction) Execute(g *GameState) (*Result, error) {
+	for _, pair := range [][2]string{{a.PlayerID, a.PartnerID}, {a.PartnerID, a.PlayerID}} {
+		if player := g.GetPlayer(pair[0]); player != nil {
+			player.SharedVision = slices.DeleteFunc(player.SharedVision, func(id string) bool { return id == pair[1] })
+		}
+	}
+	return &Result{Players: []string{a.PlayerID, a.PartnerID}}, nil
+}
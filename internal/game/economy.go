@@ -0,0 +1,219 @@
+package game
+
+import "strconv"
+
+// At the end of each of their turns a player's cities collect the trade of
+// their tiles. The player's tax rate takes a share of it as gold and the
+// rest goes to science, which brings technologies; a marketplace adds to a
+// city's gold and a library to its science. Cities joined to the capital by road earn trade route
+// gold on top. Buildings cost their upkeep in gold, and so do units beyond
+// those the player supports for free. A treasury that cannot pay sells
+// buildings, and then disbands units, until it can.
+
+// BuildingUpkeep is the gold each building costs its city per turn.
+// Wonders and the Palace cost nothing.
+var BuildingUpkeep = map[BuildingType]int{
+	BuildingBarracks:    1,
+	BuildingGranary:     1,
+	BuildingWalls:       1,
+	BuildingMarketplace: 1,
+	BuildingLibrary:     1,
+	BuildingHarbor:      1,
+}
+
+// Budget is what a player's treasury takes in and pays out in a turn
+type Budget struct {
+	TaxRate        int `json:"tax_rate"`
+	Trade          int `json:"trade"`            // Trade of every city
+	TaxGold        int `json:"tax_gold"`         // Gold taxed from the trade, with marketplaces
+	Science        int `json:"science"`          // Science from the trade, with libraries
+	TradeRouteGold int `json:"trade_route_gold"` // Earned by cities joined to the capital by road
+	BuildingUpkeep int `json:"building_upkeep"`
+	UnitUpkeep     int `json:"unit_upkeep"`
+	Net            int `json:"net"` // Change of the treasury
+}
+
+// SoldReport describes a building sold because its upkeep went unpaid
+type SoldReport struct {
+	CityID   string `json:"city_id"`
+	CityName string `json:"city_name"`
+	Item     string `json:"item"`
+}
+
+// EconomyReport accounts for a player's economy at the end of their turn
+type EconomyReport struct {
+	Budget
+	Gold           int          `json:"gold"`     // Treasury after the turn
+	Research       int          `json:"research"` // Science gathered in all
+	BuildingsSold  []SoldReport `json:"buildings_sold"`
+	UnitsDisbanded []UnitNotice `json:"units_disbanded"`
+	UnitsHealed    int          `json:"units_healed"`
+	Discoveries    []string     `json:"discoveries"` // Technologies the science brought
+}
+
+// CalculateTradePerTurn calculates the trade of the city's tiles and its
+// center less corruption, none in disorder
+func (c *City) CalculateTradePerTurn(tiles []*Tile) int {
+	if c.Disorder {
+		return 0
+	}
+	trade := c.grossTrade(tiles)
+	return trade - trade*c.Corruption/100
+}
+
+// grossTrade returns the trade of the city's tiles and its center before
+// corruption, with what a celebration adds
+func (c *City) grossTrade(tiles []*Tile) int {
+	trade := BaseCityTrade
+	for _, tile := range tiles {
+		yield := tile.TradeYield()
+		if yield > 0 && c.Celebrating {
+			yield += CelebrationTrade
+		}
+		trade += yield
+	}
+	return trade
+}
+
+// Upkeep returns the gold the city's buildings cost per turn
+func (c *City) Upkeep() int {
+	upkeep := 0
+	for building, built := range c.Buildings {
+		if built {
+			upkeep += BuildingUpkeep[building]
+		}
+	}
+	return upkeep
+}
+
+// FreeUnits returns how many units a player supports without upkeep
+func (p *Player) FreeUnits() int {
+	return BaseFreeUnits + FreeUnitsPerCity*len(p.Cities)
+}
+
+// ValidTaxRate reports whether a tax rate can be set
+func ValidTaxRate(rate int) bool {
+	return rate >= 0 && rate <= 100 && rate%TaxRateStep == 0
+}
+
+// Budget returns what a player's treasury would take in and pay out at the
+// end of their turn at a tax rate, as the empire stands
+func (g *GameState) Budget(player *Player, taxRate int) Budget {
+	b := Budget{TaxRate: taxRate}
+	capital := player.Capital()
+	for _, city := range player.Cities {
+		trade := city.CalculateTradePerTurn(g.GetCityTiles(city))
+		gold := trade * taxRate / 100
+		science := trade - gold
+		if city.HasBuilding(BuildingMarketplace) {
+			gold += gold * MarketplaceBonus / 100
+		}
+		if city.HasBuilding(BuildingLibrary) {
+			science += science * LibraryBonus / 100
+		}
+
+		b.Trade += trade
+		b.TaxGold += gold
+		b.Science += science
+		if city != capital && g.ConnectedToCapital(city) {
+			b.TradeRouteGold += TradeRouteGold
+		}
+		b.BuildingUpkeep += city.Upkeep()
+	}
+	if unsupported := len(player.Units) - player.FreeUnits(); unsupported > 0 {
+		b.UnitUpkeep = unsupported * UnitUpkeepGold
+	}
+
+	b.Net = b.TaxGold + b.TradeRouteGold - b.BuildingUpkeep - b.UnitUpkeep
+	return b
+}
+
+// runEconomy collects a player's taxes, science and trade routes and pays
+// their upkeep. What cannot be paid is made up by selling buildings and
+// then disbanding units.
+func (g *GameState) runEconomy(player *Player) EconomyReport {
+	r := EconomyReport{
+		Budget:         g.Budget(player, player.TaxRate),
+		BuildingsSold:  make([]SoldReport, 0),
+		UnitsDisbanded: make([]UnitNotice, 0),
+		Discoveries:    make([]string, 0),
+	}
+	player.Gold += r.Net
+	science := player.Science
+	player.Science += r.Science
+	for _, tech := range g.discoveries(player, science) {
+		r.Discoveries = append(r.Discoveries, tech.String())
+		g.publish(TechDiscovered{PlayerID: player.ID, Tech: tech})
+	}
+
+	// Buildings are sold from the last city founded, the dearest to keep
+	// first
+	for i := len(player.Cities) - 1; i >= 0 && player.Gold < 0; i-- {
+		city := player.Cities[i]
+		for building := BuildingHarbor; building > BuildingNone && player.Gold < 0; building-- {
+			if !city.HasBuilding(building) || BuildingUpkeep[building] == 0 {
+				continue
+			}
+			delete(city.Buildings, building)
+			player.Gold += BuildingUpkeep[building]
+			r.BuildingsSold = append(r.BuildingsSold, SoldReport{CityID: city.ID, CityName: city.Name, Item: building.String()})
+		}
+	}
+
+	// Then the newest units beyond those supported for free are disbanded,
+	// never a King
+	for player.Gold < 0 && len(player.Units) > player.FreeUnits() {
+		unit := player.Units[len(player.Units)-1]
+		if unit.IsKing() {
+			if len(player.Units) == 1 {
+				break
+			}
+			unit = player.Units[len(player.Units)-2]
+		}
+		g.RemoveUnit(unit.ID)
+		player.Gold += UnitUpkeepGold
+		r.UnitsDisbanded = append(r.UnitsDisbanded, UnitNotice{UnitID: unit.ID, UnitType: unit.Type, X: unit.X, Y: unit.Y})
+	}
+	if player.Gold < 0 {
+		player.Gold = 0
+	}
+
+	r.Gold = player.Gold
+	r.Research = player.Science
+	return r
+}
+
+// SetTaxRateAction sets the share of a player's trade collected as gold
+type SetTaxRateAction struct {
+	PlayerID string `json:"player_id"`
+	TaxRate  int    `json:"tax_rate"`
+}
+
+// Type returns the action type name
+func (a *SetTaxRateAction) Type() string {
+	return "set_tax_rate"
+}
+
+// Validate checks that the rate is one that can be set
+func (a *SetTaxRateAction) Validate(g *GameState, playerID string) error {
+	if a.PlayerID != playerID {
+		return ErrNotYourTaxes
+	}
+	if g.GetPlayer(playerID) == nil {
+		return ErrPlayerNotFound
+	}
+	if !ValidTaxRate(a.TaxRate) {
+		return &ActionError{Err: ErrInvalidTaxRate, Item: strconv.Itoa(a.TaxRate)}
+	}
+	return nil
+}
+
+// Execute sets the rate
+func (a *SetTaxRateAction) Execute(g *GameState) (*Result, error) {
+	player := g.GetPlayer(a.PlayerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	player.TaxRate = a.TaxRate
+	return &Result{Players: []string{player.ID}}, nil
+}
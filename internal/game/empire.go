@@ -0,0 +1,135 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Columns the empire overview can be sorted by
+const (
+	EmpireSortName       = "name"
+	EmpireSortSize       = "size"
+	EmpireSortFood       = "food"
+	EmpireSortProduction = "production" // What the city builds, by name
+	EmpireSortTurns      = "turns"      // Turns left to finish it, cities building nothing last
+	EmpireSortHappiness  = "happiness"  // Content citizens less unhappy ones
+	EmpireSortGarrison   = "garrison"
+)
+
+// CityOverview is a city as the empire overview lists it
+type CityOverview struct {
+	CityID      string `json:"city_id"`
+	CityName    string `json:"city_name"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	Population  int    `json:"population"`
+	FoodSurplus int    `json:"food_surplus"` // Food left over each turn, negative when starving
+	Production  string `json:"production"`   // What the city builds, "" for nothing
+	TurnsLeft   int    `json:"turns_left"`   // Turns to finish it, -1 for never
+	Content     int    `json:"content"`
+	Unhappy     int    `json:"unhappy"`
+	Disorder    bool   `json:"disorder,omitempty"`
+	Celebrating bool   `json:"celebrating,omitempty"`
+	Garrison    int    `json:"garrison"`          // Units in the city
+	Strength    int    `json:"garrison_strength"` // Their defense in the city, behind its walls
+}
+
+// EmpireOverview lists a player's cities, sorted by a column, "" for the
+// order they were founded in. Ties keep that order.
+func (g *GameState) EmpireOverview(playerID, sortBy string, descending bool) ([]CityOverview, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	happiness, err := g.Happiness(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	cities := make([]CityOverview, len(player.Cities))
+	for i, city := range player.Cities {
+		tiles := g.GetCityTiles(city)
+		c := CityOverview{
+			CityID:      city.ID,
+			CityName:    city.Name,
+			X:           city.X,
+			Y:           city.Y,
+			Population:  city.Population,
+			FoodSurplus: city.CalculateFoodPerTurn(tiles),
+			TurnsLeft:   city.TurnsUntilComplete(tiles, g.Config.Speed),
+			Content:     happiness.Cities[i].Content,
+			Unhappy:     happiness.Cities[i].Unhappy,
+			Disorder:    city.Disorder,
+			Celebrating: city.Celebrating,
+		}
+		if city.CurrentBuild != nil {
+			c.Production = city.CurrentBuild.Name()
+		}
+		c.Garrison, c.Strength = g.garrison(player, city)
+		cities[i] = c
+	}
+
+	if sortBy == "" {
+		return cities, nil
+	}
+	key, err := empireSortKey(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(cities, func(i, j int) bool {
+		if descending {
+			return key(&cities[j], &cities[i]) < 0
+		}
+		return key(&cities[i], &cities[j]) < 0
+	})
+	return cities, nil
+}
+
+// empireSortKey returns how to compare cities by a column
+func empireSortKey(sortBy string) (func(a, b *CityOverview) int, error) {
+	byInt := func(value func(c *CityOverview) int) func(a, b *CityOverview) int {
+		return func(a, b *CityOverview) int { return value(a) - value(b) }
+	}
+	switch sortBy {
+	case EmpireSortName:
+		return func(a, b *CityOverview) int { return strings.Compare(a.CityName, b.CityName) }, nil
+	case EmpireSortSize:
+		return byInt(func(c *CityOverview) int { return c.Population }), nil
+	case EmpireSortFood:
+		return byInt(func(c *CityOverview) int { return c.FoodSurplus }), nil
+	case EmpireSortProduction:
+		return func(a, b *CityOverview) int { return strings.Compare(a.Production, b.Production) }, nil
+	case EmpireSortTurns:
+		return byInt(func(c *CityOverview) int {
+			if c.TurnsLeft < 0 {
+				return math.MaxInt32
+			}
+			return c.TurnsLeft
+		}), nil
+	case EmpireSortHappiness:
+		return byInt(func(c *CityOverview) int { return c.Content - c.Unhappy }), nil
+	case EmpireSortGarrison:
+		return byInt(func(c *CityOverview) int { return c.Strength }), nil
+	}
+	return nil, fmt.Errorf("unknown column %q", sortBy)
+}
+
+// garrison counts a player's units in a city and their defense there,
+// doubled behind standing walls
+func (g *GameState) garrison(player *Player, city *City) (units, strength int) {
+	tile := g.Map.GetTile(city.X, city.Y)
+	for _, u := range g.GetUnitsAt(city.X, city.Y) {
+		if u.OwnerID != player.ID {
+			continue
+		}
+		defense := u.EffectiveDefense(tile.Terrain, true, false)
+		if city.WallsStanding() {
+			defense *= CityWallsMultiplier
+		}
+		units++
+		strength += defense
+	}
+	return units, strength
+}
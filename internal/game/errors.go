@@ -0,0 +1,110 @@
+package game
+
+import "civilization/internal/game/rules"
+
+// ActionError explains why an action was refused, with the details a
+// client needs to say so in its own words. It wraps one of the common
+// errors, so errors.Is sees through it.
+type ActionError struct {
+	Err error
+
+	UnitID  string // Unit that cannot act
+	CityID  string // City concerned
+	GroupID string // Group concerned
+	Tile    *Coord // Tile the action was aimed at
+	Terrain string // Terrain in the way
+	Item    string // Building, wonder or order kind concerned
+
+	// Movement the unit has left and would need, set when it falls short
+	MovementLeft     int
+	MovementRequired int
+}
+
+// Error returns the message of the wrapped error, naming the item concerned
+func (e *ActionError) Error() string {
+	if e.Item != "" {
+		return e.Err.Error() + ": " + e.Item
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// at sets the tile the action was aimed at
+func (e *ActionError) at(x, y int) *ActionError {
+	e.Tile = &Coord{X: x, Y: y}
+	return e
+}
+
+// on sets the terrain in the way
+func (e *ActionError) on(tile *Tile) *ActionError {
+	if tile != nil {
+		e.Terrain = tile.Terrain.String()
+	}
+	return e
+}
+
+// unitError refuses an action because of a unit
+func unitError(err error, unitID string) *ActionError {
+	return &ActionError{Err: err, UnitID: unitID}
+}
+
+// cityError refuses an action because of a city
+func cityError(err error, cityID string) *ActionError {
+	return &ActionError{Err: err, CityID: cityID}
+}
+
+// noMovement refuses an action a unit has no movement left for
+func noMovement(unit *Unit, required int) *ActionError {
+	return &ActionError{
+		Err:              ErrNoMovementLeft,
+		UnitID:           unit.ID,
+		MovementLeft:     unit.MovementLeft,
+		MovementRequired: required,
+	}
+}
+
+// ownUnit returns a unit of the player for an action to use
+func (g *GameState) ownUnit(playerID, unitID string) (*Unit, error) {
+	unit := g.GetUnit(unitID)
+	if unit == nil {
+		return nil, unitError(ErrUnitNotFound, unitID)
+	}
+	if unit.OwnerID != playerID {
+		return nil, unitError(ErrNotYourUnit, unitID)
+	}
+	return unit, nil
+}
+
+// ownCity returns a city of the player for an action to use
+func (g *GameState) ownCity(playerID, cityID string) (*City, error) {
+	city := g.GetCity(cityID)
+	if city == nil {
+		return nil, cityError(ErrCityNotFound, cityID)
+	}
+	if city.OwnerID != playerID {
+		return nil, cityError(ErrNotYourCity, cityID)
+	}
+	return city, nil
+}
+
+// moveError explains why IsValidMove refuses a move
+func (g *GameState) moveError(unit *Unit, toX, toY int) error {
+	e := unitError(ErrInvalidMove, unit.ID).at(toX, toY)
+
+	tile := g.Map.GetTile(toX, toY)
+	if tile == nil || !rules.Adjacent(unit.X, unit.Y, toX, toY) {
+		return e
+	}
+
+	if !tile.CanEnter(unit.Template().IsNaval) {
+		return e.on(tile)
+	}
+	if unit.MovementLeft <= 0 {
+		return noMovement(unit, g.GetMovementCost(unit.X, unit.Y, toX, toY))
+	}
+	return e
+}
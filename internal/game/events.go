@@ -0,0 +1,346 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownAction is returned when an action type name is not registered
+var ErrUnknownAction = errors.New("unknown action type")
+
+// ErrDesync is wrapped by the errors of replays that do not lead to the
+// state the game was in
+var ErrDesync = errors.New("replay out of sync")
+
+// DesyncError is returned by a replay that led to a state different from
+// the one recorded: an event's replay hashed differently from the state it
+// was recorded with
+type DesyncError struct {
+	Seq  uint64 // The first event replayed differently
+	Want string // Hash of the state the event was recorded with
+	Got  string // Hash of the state its replay led to
+
+	// Game is the game replayed to the end regardless, in the state the
+	// replay led to, which differs from the one recorded
+	Game *GameState
+}
+
+func (e *DesyncError) Error() string {
+	return fmt.Sprintf("%v at event %d: state hashes %s, recorded as %s", ErrDesync, e.Seq, e.Got, e.Want)
+}
+
+// Unwrap returns ErrDesync
+func (e *DesyncError) Unwrap() error {
+	return ErrDesync
+}
+
+// Event records a single action that was applied to the game state.
+// Replaying the events of a log in order on top of its base snapshot
+// reproduces the game exactly.
+type Event struct {
+	Seq      uint64          `json:"seq"`
+	Turn     int             `json:"turn"`
+	PlayerID string          `json:"player_id"`
+	Type     string          `json:"type"`
+	Data     json.RawMessage `json:"data"`
+
+	// RandomEvents lists the random events the action set off. Replaying
+	// the action sets them off again; they are kept so the log reads as a
+	// record of the game.
+	RandomEvents []RandomEvent `json:"random_events,omitempty"`
+
+	// Borders lists the tiles that changed hands after the action
+	Borders []BorderChange `json:"borders,omitempty"`
+
+	// Promotions lists the units the action's battles made veterans
+	Promotions []UnitPromotion `json:"promotions,omitempty"`
+
+	// Result lists what the action changed and spent
+	Result *Result `json:"result,omitempty"`
+
+	// Hash is the hash of the game's state after the action, which a
+	// replay of the event must lead to as well. Events from before
+	// states were hashed have none.
+	Hash string `json:"hash,omitempty"`
+
+	// Revealed lists the tiles each player explored through the action, by
+	// index into Map.Tiles. It is only for telling the players; like
+	// Explored it is not shown to others, so it is not serialized.
+	Revealed map[string][]int `json:"-"`
+}
+
+// EventLog is a base snapshot plus the events applied after it
+type EventLog struct {
+	Base   json.RawMessage `json:"base"`
+	Events []Event         `json:"events"`
+}
+
+// actionFactories maps action type names to constructors
+var actionFactories = map[string]func() Action{
+	"move":              func() Action { return &MoveUnitAction{} },
+	"attack":            func() Action { return &AttackAction{} },
+	"found_city":        func() Action { return &FoundCityAction{} },
+	"set_production":    func() Action { return &SetProductionAction{} },
+	"rename_city":       func() Action { return &RenameCityAction{} },
+	"rename_unit":       func() Action { return &RenameUnitAction{} },
+	"fortify":           func() Action { return &FortifyAction{} },
+	"wake":              func() Action { return &WakeAction{} },
+	"skip":              func() Action { return &SkipUnitAction{} },
+	"build_road":        func() Action { return &BuildRoadAction{} },
+	"terraform":         func() Action { return &TerraformAction{} },
+	"set_tax_rate":      func() Action { return &SetTaxRateAction{} },
+	"end_turn":          func() Action { return &EndTurnAction{} },
+	"set_mode":          func() Action { return &SetUnitModeAction{} },
+	"patrol":            func() Action { return &PatrolAction{} },
+	"create_group":      func() Action { return &CreateGroupAction{} },
+	"add_to_group":      func() Action { return &AddToGroupAction{} },
+	"remove_from_group": func() Action { return &RemoveFromGroupAction{} },
+	"move_group":        func() Action { return &MoveGroupAction{} },
+	"bombard":           func() Action { return &BombardAction{} },
+	"city_strike":       func() Action { return &CityStrikeAction{} },
+	"nuke":              func() Action { return &NukeAction{} },
+	"plan_order":        func() Action { return &PlanOrderAction{} },
+	"cancel_orders":     func() Action { return &CancelOrdersAction{} },
+	"submit_orders":     func() Action { return &SubmitOrdersAction{} },
+	"propose_deal":      func() Action { return &ProposeDealAction{} },
+	"accept_deal":       func() Action { return &AcceptDealAction{} },
+	"decline_deal":      func() Action { return &DeclineDealAction{} },
+	"cancel_pact":       func() Action { return &CancelPactAction{} },
+	"help_wonder":       func() Action { return &HelpWonderAction{} },
+	"buy_start":         func() Action { return &BuyStartAction{} },
+	"set_turn_timer":    func() Action { return &SetTurnTimerAction{} },
+}
+
+// DecodeAction builds an action from its type name and JSON payload
+func DecodeAction(actionType string, data json.RawMessage) (Action, error) {
+	factory, ok := actionFactories[actionType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAction, actionType)
+	}
+
+	action := factory()
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, action); err != nil {
+			return nil, fmt.Errorf("invalid %s payload: %w", actionType, err)
+		}
+	}
+	return action, nil
+}
+
+// Apply validates and executes an action on behalf of a player and appends
+// it to the event log. Once the game has started, all state changes must go
+// through Apply so that the log stays a complete record of the game.
+func (g *GameState) Apply(playerID string, action Action) (*Event, error) {
+	if g.Phase == PhaseSimultaneous && plannedActionTypes[action.Type()] {
+		return nil, ErrPlanOrders
+	}
+
+	if err := action.Validate(g, playerID); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+
+	event := Event{
+		Seq:      g.Seq + 1,
+		Turn:     g.CurrentTurn,
+		PlayerID: playerID,
+		Type:     action.Type(),
+		Data:     data,
+	}
+
+	// Each event gets its own random stream so replays are deterministic
+	// without having to serialize generator state
+	g.seedRand(event.Seq)
+	g.randomEvents = nil
+	g.revealed = make(map[string][]int)
+	g.promotions = nil
+	g.published = make([]BusEvent, 0)
+	phase := g.Phase
+	var before string
+	if CheckInvariantsAfterActions {
+		before = g.Hash()
+	}
+
+	result, err := action.Execute(g)
+	event.Revealed, g.revealed = g.revealed, nil
+	published := g.published
+	g.published = nil
+	if err != nil {
+		// Nothing undoes a failed Execute, so it must fail before it
+		// changes the game
+		if CheckInvariantsAfterActions && g.Hash() != before {
+			panic(fmt.Sprintf("%s by %s failed after changing the game: %v", event.Type, playerID, err))
+		}
+		return nil, err
+	}
+	event.RandomEvents = g.randomEvents
+	g.randomEvents = nil
+	event.Promotions, g.promotions = g.promotions, nil
+	event.Borders = g.UpdateBorders()
+	g.recordContacts()
+
+	// Random events and the game ending reach further than the action
+	if len(event.RandomEvents) > 0 || g.Phase != phase {
+		result.Whole = true
+	}
+	result.Events = published
+	event.Result = result
+
+	g.Seq = event.Seq
+	event.Hash = g.Hash()
+	g.Events = append(g.Events, event)
+
+	if CheckInvariantsAfterActions {
+		if err := g.CheckInvariants(); err != nil {
+			panic(fmt.Sprintf("event %d (%s by %s): %v", event.Seq, event.Type, playerID, err))
+		}
+	}
+
+	return &g.Events[len(g.Events)-1], nil
+}
+
+// EventsSince returns the events with a sequence number greater than seq
+func (g *GameState) EventsSince(seq uint64) []Event {
+	for i, e := range g.Events {
+		if e.Seq > seq {
+			return g.Events[i:]
+		}
+	}
+	return nil
+}
+
+// Checkpoint makes the current state the base of the event log and clears
+// the recorded events. It is called when the game starts.
+func (g *GameState) Checkpoint() error {
+	events := g.Events
+	g.Events = nil
+	base, err := json.Marshal(g)
+	g.Events = events
+	if err != nil {
+		return err
+	}
+
+	g.base = base
+	g.Events = make([]Event, 0)
+	return nil
+}
+
+// EventLog returns the base snapshot and all events applied since
+func (g *GameState) EventLog() *EventLog {
+	return &EventLog{
+		Base:   g.base,
+		Events: g.Events,
+	}
+}
+
+// RestoreEventLog attaches a previously saved event log to the game
+func (g *GameState) RestoreEventLog(log *EventLog) {
+	g.base = log.Base
+	g.Events = log.Events
+	if g.Events == nil {
+		g.Events = make([]Event, 0)
+	}
+}
+
+// Replay rebuilds a game from an event log, applying events up to and
+// including sequence number upTo. Each event's state is checked against
+// the hash it was recorded with; a replay leaving the game as it was
+// played fails with a *DesyncError.
+func Replay(log *EventLog, upTo uint64) (*GameState, error) {
+	if len(log.Base) == 0 {
+		return nil, errors.New("event log has no base snapshot")
+	}
+
+	g := &GameState{}
+	if err := json.Unmarshal(log.Base, g); err != nil {
+		return nil, fmt.Errorf("invalid base snapshot: %w", err)
+	}
+	g.relinkWinner()
+	g.upgradeTurnOrder(log.Base)
+	g.UpdateBorders() // Snapshots taken before tiles had owners
+	g.RestoreEventLog(&EventLog{Base: log.Base})
+
+	var desync *DesyncError
+	for _, e := range log.Events {
+		if e.Seq > upTo {
+			break
+		}
+
+		action, err := DecodeAction(e.Type, e.Data)
+		if err != nil {
+			return nil, fmt.Errorf("replay event %d: %w", e.Seq, err)
+		}
+		replayed, err := g.Apply(e.PlayerID, action)
+		if err != nil {
+			return nil, fmt.Errorf("replay event %d: %w", e.Seq, err)
+		}
+		if desync == nil && e.Hash != "" && replayed.Hash != e.Hash {
+			desync = &DesyncError{Seq: e.Seq, Want: e.Hash, Got: replayed.Hash}
+		}
+	}
+
+	if desync != nil {
+		desync.Game = g
+		return nil, desync
+	}
+	return g, nil
+}
+
+// Undo returns a copy of the game with the last event reverted
+func (g *GameState) Undo() (*GameState, error) {
+	if len(g.Events) == 0 {
+		return nil, errors.New("nothing to undo")
+	}
+	return Replay(g.EventLog(), g.Seq-1)
+}
+
+// seedRand starts the random stream for an event
+func (g *GameState) seedRand(seq uint64) {
+	g.rngSource = rand.NewPCG(uint64(g.Seed), seq)
+	g.rng = rand.New(g.rngSource)
+}
+
+// rand returns the random source for the action being executed
+func (g *GameState) rand() *rand.Rand {
+	if g.rng == nil {
+		g.seedRand(g.Seq)
+	}
+	return g.rng
+}
+
+// randReader adapts a random stream to io.Reader for ID generation
+type randReader struct {
+	rng *rand.Rand
+}
+
+// Read fills p with random bytes
+func (r randReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r.rng.Uint32())
+	}
+	return len(p), nil
+}
+
+// newID generates an entity ID from the game's random source so replayed
+// events recreate the same units and cities
+func (g *GameState) newID() string {
+	return uuid.Must(uuid.NewRandomFromReader(randReader{g.rand()})).String()
+}
+
+// relinkWinner points Winner back at the matching player after decoding
+func (g *GameState) relinkWinner() {
+	if g.Winner == nil {
+		return
+	}
+	if p := g.GetPlayer(g.Winner.ID); p != nil {
+		g.Winner = p
+	}
+}
@@ -0,0 +1,208 @@
+package game
+
+import "slices"
+
+// IsExplored reports whether the player has seen the tile at (x, y)
+func (g *GameState) IsExplored(player *Player, x, y int) bool {
+	if !g.Map.IsValidCoord(x, y) {
+		return false
+	}
+	i := g.Map.Index(x, y)
+	if i/8 >= len(player.Explored) {
+		return false
+	}
+	return player.Explored[i/8]&(1<<(i%8)) != 0
+}
+
+// explore marks the tile with index i as explored by the player, noting
+// it as newly revealed to them if they had not seen it
+func (g *GameState) explore(player *Player, i int) {
+	size := (g.Map.Width*g.Map.Height + 7) / 8
+	if len(player.Explored) != size {
+		player.Explored = make([]byte, size)
+	}
+
+	if player.Explored[i/8]&(1<<(i%8)) != 0 {
+		return
+	}
+	player.Explored[i/8] |= 1 << (i % 8)
+	if g.revealed != nil {
+		g.revealed[player.ID] = append(g.revealed[player.ID], i)
+	}
+}
+
+// reveal marks all tiles within radius of (x, y) as explored by the player
+// and by the players they share vision with
+func (g *GameState) reveal(player *Player, x, y, radius int) {
+	tiles := make([]int, 0, (2*radius+1)*(2*radius+1))
+	for c := range (Coord{x, y}).Within(radius) {
+		if g.Map.Contains(c) {
+			tiles = append(tiles, g.Map.Index(c.X, c.Y))
+		}
+	}
+	g.show(player, tiles)
+}
+
+// show marks tiles, by index, as explored by the player and by the players
+// they share vision with
+func (g *GameState) show(player *Player, tiles []int) {
+	viewers := []*Player{player}
+	for _, id := range player.SharedVision {
+		if partner := g.GetPlayer(id); partner != nil {
+			viewers = append(viewers, partner)
+		}
+	}
+
+	for _, i := range tiles {
+		for _, viewer := range viewers {
+			g.explore(viewer, i)
+		}
+	}
+}
+
+// Sight returns how many tiles around itself a unit standing at (x, y)
+// sees: its type's sight, and MountainSightBonus more from a mountain
+func (g *GameState) Sight(unit *Unit, x, y int) int {
+	sight := unit.Template().Sight
+	if tile := g.Map.GetTile(x, y); tile != nil && tile.Terrain == TerrainMountains {
+		sight += MountainSightBonus
+	}
+	return sight
+}
+
+// lineOfSight reports whether a unit at (x, y) can see (toX, toY). Forest
+// between the two blocks the view; the tiles next to a unit are always in
+// sight.
+func (g *GameState) lineOfSight(x, y, toX, toY int) bool {
+	dx, dy := toX-x, toY-y
+	steps := max(abs(dx), abs(dy))
+	for k := 1; k < steps; k++ {
+		tile := g.Map.GetTile(x+roundDiv(dx*k, steps), y+roundDiv(dy*k, steps))
+		if tile != nil && tile.Terrain == TerrainForest {
+			return false
+		}
+	}
+	return true
+}
+
+// roundDiv divides a by b > 0, rounding halves away from zero
+func roundDiv(a, b int) int {
+	if a < 0 {
+		return -((-a*2 + b) / (2 * b))
+	}
+	return (a*2 + b) / (2 * b)
+}
+
+// visibleFrom returns the indexes of the tiles a unit would see standing
+// at (x, y)
+func (g *GameState) visibleFrom(unit *Unit, x, y int) []int {
+	sight := g.Sight(unit, x, y)
+	tiles := make([]int, 0, (2*sight+1)*(2*sight+1))
+	for c := range (Coord{x, y}).Within(sight) {
+		if g.Map.Contains(c) && g.lineOfSight(x, y, c.X, c.Y) {
+			tiles = append(tiles, g.Map.Index(c.X, c.Y))
+		}
+	}
+	return tiles
+}
+
+// InSight reports whether the player, or a player sharing vision with
+// them, sees the tile at (x, y) now: from one of their units, or from
+// within two tiles of one of their cities
+func (g *GameState) InSight(player *Player, x, y int) bool {
+	to := Coord{x, y}
+	for _, p := range g.Players {
+		if p != player && !player.SharesVision(p.ID) {
+			continue
+		}
+		for _, city := range p.Cities {
+			if to.Distance(Coord{city.X, city.Y}) <= 2 {
+				return true
+			}
+		}
+		for _, unit := range p.Units {
+			if to.Distance(Coord{unit.X, unit.Y}) <= g.Sight(unit, unit.X, unit.Y) && g.lineOfSight(unit.X, unit.Y, x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sightOf returns the indexes of the tiles the player, or a player sharing
+// vision with them, sees now, as InSight decides
+func (g *GameState) sightOf(player *Player) map[int]bool {
+	seen := make(map[int]bool)
+	for _, p := range g.Players {
+		if p != player && !player.SharesVision(p.ID) {
+			continue
+		}
+		for _, city := range p.Cities {
+			for c := range city.Coord().Within(2) {
+				if g.Map.Contains(c) {
+					seen[g.Map.Index(c.X, c.Y)] = true
+				}
+			}
+		}
+		for _, unit := range p.Units {
+			for _, i := range g.visibleFrom(unit, unit.X, unit.Y) {
+				seen[i] = true
+			}
+		}
+	}
+	return seen
+}
+
+// recordContacts notes every player meeting the others whose units or
+// cities they see now for the first time
+func (g *GameState) recordContacts() {
+	for _, player := range g.Players {
+		var seen map[int]bool // Found once someone is left to meet
+		for _, other := range g.Players {
+			if other == player || slices.Contains(player.Met, other.ID) {
+				continue
+			}
+			if seen == nil {
+				seen = g.sightOf(player)
+			}
+			if g.seesAnyOf(seen, other) {
+				player.Met = append(player.Met, other.ID)
+			}
+		}
+	}
+}
+
+// seesAnyOf reports whether any of a player's units or cities stands on
+// the tiles seen
+func (g *GameState) seesAnyOf(seen map[int]bool, player *Player) bool {
+	for _, city := range player.Cities {
+		if seen[g.Map.Index(city.X, city.Y)] {
+			return true
+		}
+	}
+	for _, unit := range player.Units {
+		if seen[g.Map.Index(unit.X, unit.Y)] {
+			return true
+		}
+	}
+	return false
+}
+
+// revealUnit marks the tiles a unit sees as explored by its owner
+func (g *GameState) revealUnit(unit *Unit) {
+	if player := g.GetPlayer(unit.OwnerID); player != nil {
+		g.show(player, g.visibleFrom(unit, unit.X, unit.Y))
+	}
+}
+
+// revealAll marks what every player's units and cities can currently see
+func (g *GameState) revealAll() {
+	for _, player := range g.Players {
+		for _, unit := range player.Units {
+			g.revealUnit(unit)
+		}
+		for _, city := range player.Cities {
+			g.reveal(player, city.X, city.Y, 2)
+		}
+	}
+}
@@ -1,24 +1,90 @@
 package game
 
 import (
+	"civilization/internal/game/rules"
+	"civilization/internal/locale"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
 	"github.com/google/uuid"
 )
 
 // Common errors
 var (
-	ErrGameNotStarted    = errors.New("game has not started")
-	ErrNotYourTurn       = errors.New("it is not your turn")
-	ErrPlayerNotFound    = errors.New("player not found")
-	ErrUnitNotFound      = errors.New("unit not found")
-	ErrCityNotFound      = errors.New("city not found")
-	ErrNotYourUnit       = errors.New("unit does not belong to you")
-	ErrNotYourCity       = errors.New("city does not belong to you")
-	ErrNoMovementLeft    = errors.New("unit has no movement left")
-	ErrInvalidMove       = errors.New("invalid move destination")
-	ErrCannotFoundCity   = errors.New("cannot found city here")
-	ErrInvalidTarget     = errors.New("invalid attack target")
-	ErrGameOver          = errors.New("game is over")
+	ErrGameNotStarted      = errors.New("game has not started")
+	ErrNotYourTurn         = errors.New("it is not your turn")
+	ErrPlayerNotFound      = errors.New("player not found")
+	ErrUnitNotFound        = errors.New("unit not found")
+	ErrCityNotFound        = errors.New("city not found")
+	ErrNotYourUnit         = errors.New("unit does not belong to you")
+	ErrNotYourCity         = errors.New("city does not belong to you")
+	ErrNoMovementLeft      = errors.New("unit has no movement left")
+	ErrInvalidMove         = errors.New("invalid move destination")
+	ErrCannotFoundCity     = errors.New("cannot found city here")
+	ErrInvalidTarget       = errors.New("invalid attack target")
+	ErrGameOver            = errors.New("game is over")
+	ErrProductionRequired  = errors.New("all cities need a production order")
+	ErrGroupNotFound       = errors.New("group not found")
+	ErrPlanOrders          = errors.New("units move and fight through planned orders in the simultaneous phase")
+	ErrOrdersSubmitted     = errors.New("orders already submitted this turn")
+	ErrNotSimultaneous     = errors.New("orders are only given in the simultaneous phase")
+	ErrSubmitOrders        = errors.New("submit orders to end the simultaneous phase")
+	ErrNotYourOrders       = errors.New("players can only submit their own orders")
+	ErrUnknownOrder        = errors.New("unknown order kind")
+	ErrNuclearOnly         = errors.New("nuclear units can only detonate")
+	ErrNotNuclear          = errors.New("unit is not a nuclear weapon")
+	ErrCannotBombard       = errors.New("unit cannot bombard")
+	ErrReloading           = errors.New("unit must reload before bombarding again")
+	ErrCityStruck          = errors.New("city has already struck this turn")
+	ErrBuildingExists      = errors.New("building already exists")
+	ErrWonderBuilt         = errors.New("wonder already built")
+	ErrRequiresWonder      = errors.New("requires a wonder")
+	ErrCannotHelpWonder    = errors.New("unit cannot help build wonders")
+	ErrNoWonderHere        = errors.New("no city of yours is building a wonder here")
+	ErrCannotFortify       = errors.New("settlers cannot fortify")
+	ErrAlreadyAwake        = errors.New("unit is already awake")
+	ErrCannotBuildRoad     = errors.New("unit cannot build roads")
+	ErrRoadExists          = errors.New("road already exists")
+	ErrCannotBuildRoadHere = errors.New("cannot build road here")
+	ErrInvalidTile         = errors.New("invalid tile")
+	ErrUnknownMode         = errors.New("unknown unit mode")
+	ErrUsePatrol           = errors.New("use a patrol action to set a route")
+	ErrPatrolTooShort      = errors.New("a patrol needs at least two waypoints")
+	ErrWaypointUnreachable = errors.New("unit cannot reach waypoint")
+	ErrRepeatedWaypoint    = errors.New("consecutive waypoints must differ")
+	ErrGroupTooSmall       = errors.New("a group needs at least two units")
+	ErrUnitListedTwice     = errors.New("unit listed twice")
+	ErrNotStacked          = errors.New("grouped units must share a tile")
+	ErrAlreadyInGroup      = errors.New("unit is already in the group")
+	ErrInvalidCityName     = errors.New("invalid city name")
+	ErrCityNameTaken       = errors.New("city name already taken")
+	ErrInvalidUnitName     = errors.New("invalid unit name")
+	ErrNotCoastal          = errors.New("requires a coastal city")
+	ErrUnknownDeal         = errors.New("unknown deal")
+	ErrNotYourDeal         = errors.New("players can only make deals of their own")
+	ErrInvalidPartner      = errors.New("cannot make deals with that player")
+	ErrDealOffered         = errors.New("deal already offered")
+	ErrNoOffer             = errors.New("deal was not offered")
+	ErrAlreadySharing      = errors.New("vision already shared")
+	ErrNotSharing          = errors.New("vision is not shared")
+	ErrNotLuxury           = errors.New("resource is not a luxury")
+	ErrNoSpareLuxury       = errors.New("no copy of the luxury to spare")
+	ErrCannotTerraform     = errors.New("unit cannot work the land")
+	ErrUnknownJob          = errors.New("unknown job")
+	ErrCannotWorkHere      = errors.New("job cannot be done here")
+	ErrInvalidTaxRate      = errors.New("tax rate must be from 0 to 100 in steps of 10")
+	ErrNotYourTaxes        = errors.New("players can only set their own tax rate")
+	ErrNotYourPoints       = errors.New("players can only spend their own start points")
+	ErrNoStartPoints       = errors.New("no advanced start points left")
+	ErrNotEnoughPoints     = errors.New("not enough advanced start points")
+	ErrUnknownPurchase     = errors.New("unknown advanced start purchase")
+	ErrOutOfStartReach     = errors.New("too far from your units and cities")
+	ErrAllTechsKnown       = errors.New("no technology left to learn")
+	ErrNotBuildable        = errors.New("unit cannot be built")
+	ErrNegativeTimer       = errors.New("the turn timer cannot be negative")
+	ErrUnknownPolicy       = errors.New("unknown inactivity policy")
 )
 
 // GamePhase represents the current phase of the game
@@ -29,6 +95,7 @@ const (
 	PhasePlayerTurn
 	PhaseAITurn
 	PhaseGameOver
+	PhaseSimultaneous
 )
 
 // String returns the string representation of a game phase
@@ -42,6 +109,8 @@ func (p GamePhase) String() string {
 		return "ai_turn"
 	case PhaseGameOver:
 		return "game_over"
+	case PhaseSimultaneous:
+		return "simultaneous"
 	default:
 		return "unknown"
 	}
@@ -52,9 +121,100 @@ type GameConfig struct {
 	MapWidth    int    `json:"map_width"`
 	MapHeight   int    `json:"map_height"`
 	Seed        int64  `json:"seed"`
-	PlayerCount int    `json:"player_count"` // Total players including human
+	PlayerCount int    `json:"player_count"` // Total players including humans
 	PlayerName  string `json:"player_name"`
 	MapType     string `json:"map_type"` // "random" or "earth"
+
+	// TrueStarts places each civilization on an earth map where it began
+	// in history
+	TrueStarts bool `json:"true_starts,omitempty"`
+
+	// HumanPlayers is how many of the players are human, 0 for one.
+	// SimultaneousTurns has them plan their moves in one shared phase.
+	HumanPlayers      int  `json:"human_players,omitempty"`
+	SimultaneousTurns bool `json:"simultaneous_turns"`
+
+	// ProductionRequired blocks ending a turn while a city has nothing to build
+	ProductionRequired bool `json:"production_required"`
+
+	// RandomEvents turns on plagues, good harvests, earthquakes, volcanic
+	// eruptions and floods.
+	// RandomEventChance is the percent chance per city and turn, 0 for
+	// DefaultRandomEventChance.
+	RandomEvents      bool `json:"random_events"`
+	RandomEventChance int  `json:"random_event_chance,omitempty"`
+
+	// PlunderPercent scales the gold taken from captured cities, 0 for
+	// DefaultPlunderPercent
+	PlunderPercent int `json:"plunder_percent,omitempty"`
+
+	// Withdraw lets a unit faster than its opponent withdraw from a battle
+	// it is losing instead of being destroyed.
+	// WithdrawChance is the percent chance it does, 0 for
+	// DefaultWithdrawChance.
+	Withdraw       bool `json:"withdraw,omitempty"`
+	WithdrawChance int  `json:"withdraw_chance,omitempty"`
+
+	// AdvancedStart is the points each player has to spend on cities,
+	// units and technologies in their first turn, 0 for none
+	AdvancedStart int `json:"advanced_start,omitempty"`
+
+	// Regicide gives every player a King, and puts out of the game those
+	// who lose it
+	Regicide bool `json:"regicide,omitempty"`
+
+	// Advisor sends human players hints as their turns begin
+	Advisor bool `json:"advisor,omitempty"`
+
+	// Scenario names the scenario file the game was started with
+	Scenario string `json:"scenario,omitempty"`
+
+	// Async games are kept in storage between turns and humans are
+	// notified when it is their turn. A human who takes longer than
+	// TurnTimeout minutes has the InactivityPolicy applied.
+	Async            bool   `json:"async"`
+	TurnTimeout      int    `json:"turn_timeout,omitempty"`
+	InactivityPolicy string `json:"inactivity_policy,omitempty"`
+
+	// AIDifficulty picks the brain of the AI players and their
+	// handicaps, "" for normal
+	AIDifficulty string `json:"ai_difficulty,omitempty"`
+
+	// Speed scales what cities pay to build and grow, "" for standard
+	Speed string `json:"speed,omitempty"`
+
+	// Locale picks the language pack civilization and city names and the
+	// server's messages come from, "" for English
+	Locale string `json:"locale,omitempty"`
+}
+
+// Inactivity policies for async games
+const (
+	InactivitySkip = "skip" // The turn is ended for the player
+	InactivityAI   = "ai"   // An AI plays the turn for the player
+)
+
+// AI difficulties
+const (
+	DifficultyNormal = "normal" // Rule-based decisions
+	DifficultyHard   = "hard"   // Decisions weighed by looking ahead on copies of the game
+)
+
+// Game speeds, see SpeedCostPercent
+const (
+	SpeedQuick    = "quick"
+	SpeedStandard = "standard"
+	SpeedEpic     = "epic"
+	SpeedMarathon = "marathon"
+)
+
+// ScaleCost turns a cost at standard speed into the cost at a game speed
+func ScaleCost(cost int, speed string) int {
+	percent, ok := SpeedCostPercent[speed]
+	if !ok {
+		return cost
+	}
+	return max(1, (cost*percent+50)/100)
 }
 
 // DefaultGameConfig returns a default game configuration
@@ -70,36 +230,78 @@ func DefaultGameConfig() GameConfig {
 
 // GameState represents the entire state of a game
 type GameState struct {
-	ID            string    `json:"id"`
-	Map           *GameMap  `json:"map"`
-	Players       []*Player `json:"players"`
-	CurrentTurn   int       `json:"current_turn"`
-	CurrentPlayer int       `json:"current_player"` // Index into Players
-	Phase         GamePhase `json:"phase"`
-	Winner        *Player   `json:"winner,omitempty"`
+	ID           string           `json:"id"`
+	Map          *GameMap         `json:"map"`
+	Players      []*Player        `json:"players"`
+	CurrentTurn  int              `json:"current_turn"`
+	TurnOrder    TurnOrder        `json:"turn_order"`
+	Phase        GamePhase        `json:"phase"`
+	Winner       *Player          `json:"winner,omitempty"`
+	Seed         int64            `json:"seed"`
+	Config       GameConfig       `json:"config"`
+	Seq          uint64           `json:"seq"`    // Sequence number of the last applied event
+	Events       []Event          `json:"events"` // Actions applied since the base snapshot
+	Scenario     *Scenario        `json:"scenario,omitempty"`
+	Orders       []Order          `json:"orders,omitempty"`        // Orders planned in the simultaneous phase
+	Submitted    []string         `json:"submitted,omitempty"`     // Players who submitted their orders this phase
+	History      []TurnStats      `json:"history,omitempty"`       // Standings as each turn began
+	Offers       []DealOffer      `json:"offers,omitempty"`        // Deals offered and not yet answered
+	LuxuryTrades []LuxuryTrade    `json:"luxury_trades,omitempty"` // Luxuries shared in deals
+	CombatLog    []CombatLogEntry `json:"combat_log,omitempty"`    // The last CombatLogSize battles, oldest first
+	GameLog      []LogEntry       `json:"game_log,omitempty"`      // The last GameLogSize entries of the game log, oldest first
+
+	base      []byte                 // Snapshot the event log is relative to
+	rng       *rand.Rand             // Random source of the event being applied
+	rngSource *rand.PCG              // State of rng, kept so clones can copy it
+	reports   map[string]*TurnReport // Turn summaries not yet sent, by player
+
+	randomEvents []RandomEvent    // Random events set off by the event being applied
+	revealed     map[string][]int // Tiles newly explored in the event being applied, by player
+	promotions   []UnitPromotion  // Units made veterans in the event being applied
+	published    []BusEvent       // Bus events published by the event being applied, nil between events
+
+	bus *EventBus // Where what happens in the game is told, nil without subscribers
 }
 
 // NewGame creates a new game with the given configuration
 // Note: Map generation is handled separately by mapgen package
 func NewGame(config GameConfig) *GameState {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	g := &GameState{
-		ID:            uuid.New().String(),
-		CurrentTurn:   1,
-		CurrentPlayer: 0,
-		Phase:         PhaseSetup,
+		ID:          uuid.New().String(),
+		CurrentTurn: 1,
+		Phase:       PhaseSetup,
+		Seed:        seed,
+		Config:      config,
+		Events:      make([]Event, 0),
 	}
 
+	g.Config.Seed = seed
+
 	// Create players
 	g.Players = make([]*Player, config.PlayerCount)
 
-	// First player is human
+	// First players are human
+	humans := config.HumanPlayers
+	if humans < 1 {
+		humans = 1
+	}
 	g.Players[0] = NewPlayer(config.PlayerName, PlayerHuman, 0)
+	for i := 1; i < humans && i < config.PlayerCount; i++ {
+		g.Players[i] = NewPlayer(fmt.Sprintf("%s %d", config.PlayerName, i+1), PlayerHuman, i)
+	}
 
 	// Rest are AI
-	for i := 1; i < config.PlayerCount; i++ {
-		name := CivilizationNames[i%len(CivilizationNames)]
+	names := locale.Get(config.Locale).Civilizations()
+	for i := humans; i < config.PlayerCount; i++ {
+		name := names[i%len(names)]
 		g.Players[i] = NewPlayer(name, PlayerAI, i)
 	}
+	g.TurnOrder = NewTurnOrder(g.Players)
 
 	return g
 }
@@ -109,19 +311,39 @@ func (g *GameState) SetMap(gm *GameMap) {
 	g.Map = gm
 }
 
-// Start begins the game
+// Start begins the game and takes the base snapshot for the event log
 func (g *GameState) Start() {
 	g.Phase = PhasePlayerTurn
 	g.CurrentTurn = 1
-	g.CurrentPlayer = 0
+	g.TurnOrder = NewTurnOrder(g.Players)
+	if g.simultaneous() {
+		g.beginSimultaneousPhase()
+	}
+	for _, player := range g.Players {
+		player.StartPoints = g.Config.AdvancedStart
+	}
+	g.grantFreeUnits()
+	for _, player := range g.Players {
+		g.updateBonuses(player)
+	}
+	if g.Config.Regicide {
+		g.crownKings()
+	}
+	g.revealAll()
+	g.recordContacts()
+	g.Map.MarkCoast()
+	g.Map.UpdateRoads()
+	g.UpdateBorders()
+	g.recordStats()
+	g.Checkpoint()
 }
 
 // GetCurrentPlayer returns the player whose turn it is
 func (g *GameState) GetCurrentPlayer() *Player {
-	if g.CurrentPlayer >= 0 && g.CurrentPlayer < len(g.Players) {
-		return g.Players[g.CurrentPlayer]
+	if g.TurnOrder.Current == "" {
+		return nil
 	}
-	return nil
+	return g.GetPlayer(g.TurnOrder.Current)
 }
 
 // GetPlayer returns a player by ID
@@ -216,15 +438,19 @@ func (g *GameState) GetEnemyUnitsAt(x, y int, playerID string) []*Unit {
 
 // IsValidMove checks if a unit can move to the destination
 func (g *GameState) IsValidMove(unit *Unit, toX, toY int) bool {
+	return g.isValidStep(unit, unit.X, unit.Y, unit.MovementLeft, toX, toY)
+}
+
+// isValidStep checks if a unit standing at (fromX, fromY) with movement
+// left could move to the destination
+func (g *GameState) isValidStep(unit *Unit, fromX, fromY, movementLeft, toX, toY int) bool {
 	// Check bounds
 	if !g.Map.IsValidCoord(toX, toY) {
 		return false
 	}
 
 	// Check adjacency (can only move one tile at a time)
-	dx := abs(toX - unit.X)
-	dy := abs(toY - unit.Y)
-	if dx > 1 || dy > 1 || (dx == 0 && dy == 0) {
+	if !rules.Adjacent(fromX, fromY, toX, toY) {
 		return false
 	}
 
@@ -234,22 +460,16 @@ func (g *GameState) IsValidMove(unit *Unit, toX, toY int) bool {
 		return false
 	}
 
-	// Land units can't enter water (unless naval)
-	template := unit.Template()
-	if !template.IsNaval && tile.IsWater() {
-		return false
-	}
-
-	// Naval units can't enter land
-	if template.IsNaval && !tile.IsWater() {
+	// Land units stay on land and fords, naval units on water
+	if !tile.CanEnter(unit.Template().IsNaval) {
 		return false
 	}
 
 	// Check movement cost
-	cost := tile.MovementCost()
-	if unit.MovementLeft < cost {
+	cost := g.GetMovementCost(fromX, fromY, toX, toY)
+	if movementLeft < cost {
 		// Allow move if unit has any movement left (minimum 1 move per turn)
-		if unit.MovementLeft <= 0 {
+		if movementLeft <= 0 {
 			return false
 		}
 	}
@@ -257,18 +477,31 @@ func (g *GameState) IsValidMove(unit *Unit, toX, toY int) bool {
 	return true
 }
 
-// GetMovementCost returns the movement cost to move between tiles
+// GetMovementCost returns the movement cost to move between tiles. Only a
+// move from one road tile to another follows the road.
 func (g *GameState) GetMovementCost(fromX, fromY, toX, toY int) int {
 	tile := g.Map.GetTile(toX, toY)
 	if tile == nil {
 		return 999
 	}
+	if from := g.Map.GetTile(fromX, fromY); from != nil && from.HasRoad && tile.HasRoad {
+		return RoadMovementCost
+	}
 	return tile.MovementCost()
 }
 
-// GetCityTiles returns the tiles that a city can work
+// GetCityTiles returns the tiles that a city can work: those within its
+// radius allotted to it rather than to a closer city, less any ocean it
+// cannot work
 func (g *GameState) GetCityTiles(city *City) []*Tile {
-	return g.Map.GetCityRadius(city.X, city.Y)
+	radius := g.Map.GetCityRadius(city.X, city.Y)
+	tiles := radius[:0]
+	for _, tile := range radius {
+		if tile.WorkedBy == city.ID && canWork(city, tile) {
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
 }
 
 // EndTurn processes the end of the current player's turn
@@ -282,42 +515,141 @@ func (g *GameState) EndTurn() error {
 		return ErrPlayerNotFound
 	}
 
+	g.endPlayerTurn(player)
+	g.passTurn()
+	return nil
+}
+
+// endPlayerTurn runs a player's cities, heals their units and rolls their
+// random events
+func (g *GameState) endPlayerTurn(player *Player) {
+	report := g.report(player.ID)
+	player.StartPoints = 0 // Points not spent in the first turn are lost
+
+	// Cities too unhappy to work the turn sit it out, and those that grow
+	// too unhappy are put in disorder for the next. Celebrating cities
+	// earn more trade for the turn.
+	g.updateDisorder(player)
+	defer g.updateDisorder(player)
+	player.updateCorruption()
+	g.updateBonuses(player)
+	if report != nil {
+		for _, city := range player.Cities {
+			cityReport := CityReport{CityID: city.ID, CityName: city.Name, Population: city.Population}
+			if city.Disorder {
+				report.CitiesInDisorder = append(report.CitiesInDisorder, cityReport)
+			}
+			if city.Celebrating {
+				report.CitiesCelebrating = append(report.CitiesCelebrating, cityReport)
+			}
+		}
+	}
+
 	// Process all cities
 	for _, city := range player.Cities {
 		tiles := g.GetCityTiles(city)
-		newUnit, _ := city.ProcessTurn(tiles)
+		population := city.Population
+		// Another city may have finished the wonder first. The shields
+		// carry over to whatever the city builds next, those caravans
+		// brought only to another wonder.
+		if build := city.CurrentBuild; build != nil && !build.IsUnit && build.Building.IsWonder() && g.WonderBuilt(build.Building) {
+			city.CurrentBuild = nil
+		}
+
+		var item string
+		if city.CurrentBuild != nil {
+			item = city.CurrentBuild.Name()
+		}
+
+		newUnit, newBuilding := city.ProcessTurn(tiles, g.Config.Speed)
+		if newBuilding == BuildingPalace {
+			player.movePalace(city)
+		}
+		completed := ProductionCompleted{CityID: city.ID, PlayerID: player.ID, CityName: city.Name, Item: item, Building: newBuilding}
 		if newUnit != nil {
+			newUnit.ID = g.newID()
+			g.train(player, newUnit)
 			player.AddUnit(newUnit)
+			completed.UnitID = newUnit.ID
+		}
+		if newUnit != nil || newBuilding != BuildingNone {
+			g.publish(completed)
+		}
+
+		// A granary keeps the food of a growing city back, which is all
+		// it has in store
+		cityReport := CityReport{CityID: city.ID, CityName: city.Name, Population: city.Population}
+		if city.Population > population {
+			if city.HasGranary() {
+				cityReport.Granary = city.FoodStore
+			}
+			g.publish(CityGrew{CityID: city.ID, PlayerID: player.ID, CityName: city.Name, Population: city.Population, GranaryFood: cityReport.Granary})
+		}
+
+		if report == nil {
+			continue
+		}
+		if city.Population > population {
+			report.CitiesGrown = append(report.CitiesGrown, cityReport)
+		} else if city.Population < population {
+			report.CitiesStarved = append(report.CitiesStarved, cityReport)
+		}
+		if newUnit != nil || newBuilding != BuildingNone {
+			report.Completed = append(report.Completed, CompletedReport{CityID: city.ID, CityName: city.Name, Item: item, UnitID: completed.UnitID})
 		}
 	}
 
-	// Check for victory
+	economy := g.runEconomy(player)
+	economy.UnitsHealed = g.healUnits(player)
+	if report != nil {
+		report.TradeGold += economy.TradeRouteGold
+		report.Economy = &economy
+	}
+
+	g.rollRandomEvents(player)
+	g.publish(TurnEnded{PlayerID: player.ID, Turn: g.CurrentTurn})
+}
+
+// passTurn moves play on to the next player unless the game has been won
+func (g *GameState) passTurn() {
 	if g.checkVictory() {
-		return nil
+		g.recordStats()
+		return
 	}
 
-	// Advance to next player
+	// Advance to next player. Scenario triggers are checked and standings
+	// recorded once per round, as it begins.
+	turn := g.CurrentTurn
 	g.advanceToNextPlayer()
-
-	return nil
+	if g.CurrentTurn != turn {
+		g.expireLuxuryTrades()
+		g.evaluateTriggers()
+		g.recordStats()
+	}
 }
 
 // advanceToNextPlayer moves to the next player's turn
 func (g *GameState) advanceToNextPlayer() {
 	for {
-		g.CurrentPlayer++
-		if g.CurrentPlayer >= len(g.Players) {
-			g.CurrentPlayer = 0
+		if g.TurnOrder.advance() {
 			g.CurrentTurn++
 
 			// Reset all units' movement at the start of each round
 			for _, p := range g.Players {
 				p.ResetUnitsMovement()
 			}
+
+			// Humans playing simultaneously start each round together
+			if g.simultaneous() {
+				g.beginSimultaneousPhase()
+				return
+			}
 		}
 
-		// Skip eliminated players
-		if g.Players[g.CurrentPlayer].IsAlive {
+		// Skip eliminated players, and humans who have played in the
+		// simultaneous phase
+		next := g.GetCurrentPlayer()
+		if next != nil && next.IsAlive && !(g.simultaneous() && next.Type == PlayerHuman) {
 			break
 		}
 
@@ -336,11 +668,14 @@ func (g *GameState) advanceToNextPlayer() {
 	}
 
 	// Set phase based on player type
-	if g.Players[g.CurrentPlayer].Type == PlayerAI {
+	current := g.GetCurrentPlayer()
+	if current.Type == PlayerAI {
 		g.Phase = PhaseAITurn
 	} else {
 		g.Phase = PhasePlayerTurn
 	}
+
+	g.processUnitModes(current)
 }
 
 // checkVictory checks if any player has won
@@ -366,11 +701,23 @@ func (g *GameState) checkVictory() bool {
 		return true
 	}
 
+	// Holding every civilization's original capital wins too
+	if conqueror := g.capitalConqueror(); conqueror != nil {
+		g.Winner = conqueror
+		g.Phase = PhaseGameOver
+		return true
+	}
+
 	return false
 }
 
-// IsCurrentPlayerTurn checks if it's the given player's turn
+// IsCurrentPlayerTurn checks if it's the given player's turn. In the
+// simultaneous phase it is the turn of every human still planning.
 func (g *GameState) IsCurrentPlayerTurn(playerID string) bool {
+	if g.Phase == PhaseSimultaneous {
+		p := g.GetPlayer(playerID)
+		return p != nil && p.Type == PlayerHuman && p.IsAlive && !g.OrdersSubmitted(playerID)
+	}
 	current := g.GetCurrentPlayer()
 	return current != nil && current.ID == playerID
 }
@@ -380,24 +727,53 @@ func (g *GameState) RemoveUnit(unitID string) {
 	for _, p := range g.Players {
 		if u := p.GetUnit(unitID); u != nil {
 			p.RemoveUnit(unitID)
+			p.pruneGroup(u.GroupID)
 			p.CheckAlive()
+			if u.IsKing() {
+				g.loseKing(p, u)
+			}
 			return
 		}
 	}
 }
 
-// TransferCity transfers a city to a new owner
+// removeUnit removes a unit from the game and records it in result, with
+// the unit its group is left with and, if it loses the game with the unit,
+// its owner
+func (g *GameState) removeUnit(unit *Unit, result *Result) {
+	if members := g.GroupUnits(unit.GroupID); len(members) == 2 {
+		for _, u := range members {
+			if u != unit {
+				result.unit(u.ID)
+			}
+		}
+	}
+	owner := g.GetPlayer(unit.OwnerID)
+	lost := owner != nil && (!owner.IsAlive || owner.Defeated)
+
+	g.RemoveUnit(unit.ID)
+	result.removed(unit.ID)
+	if owner != nil && (!owner.IsAlive || owner.Defeated) != lost {
+		result.player(owner.ID)
+	}
+}
+
+// TransferCity transfers a city to a new owner. A Palace does not survive
+// the change.
 func (g *GameState) TransferCity(city *City, newOwnerID string) {
 	oldOwner := g.GetPlayer(city.OwnerID)
 	newOwner := g.GetPlayer(newOwnerID)
+	delete(city.Buildings, BuildingPalace)
 
 	if oldOwner != nil {
 		oldOwner.RemoveCity(city.ID)
 		oldOwner.CheckAlive()
+		oldOwner.updateCorruption()
 	}
 
 	if newOwner != nil {
 		newOwner.AddCity(city)
+		newOwner.updateCorruption()
 	}
 }
 
@@ -409,7 +785,7 @@ func abs(x int) int {
 	return x
 }
 
-// GetHumanPlayer returns the human player (first player)
+// GetHumanPlayer returns the first human player
 func (g *GameState) GetHumanPlayer() *Player {
 	for _, p := range g.Players {
 		if p.Type == PlayerHuman {
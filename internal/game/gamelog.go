@@ -0,0 +1,98 @@
+package game
+
+import (
+	"civilization/internal/locale"
+	"strconv"
+)
+
+// The game log tells what happened in the game in words, turn by turn, in
+// the game's language: cities founded and captured, battles and Kings
+// lost for everyone to read, and what a player's cities build and how they
+// grow and celebrate, and what the player discovers, for that player
+// alone. It is written from the events the game publishes, and each entry
+// is published in turn as Logged.
+
+// LogEntry is a line of the game log
+type LogEntry struct {
+	Turn     int    `json:"turn"`
+	PlayerID string `json:"player_id,omitempty"` // Player the entry is about
+	Private  bool   `json:"private,omitempty"`   // Only PlayerID may read it
+	Text     string `json:"text"`                // In the game's language
+}
+
+// Line returns the entry as a line of text headed by its turn, in a
+// language
+func (e LogEntry) Line(lang string) string {
+	return locale.Get(lang).Text("log.line", "turn", strconv.Itoa(e.Turn), "text", e.Text)
+}
+
+// GameLogFor returns the entries of the game log a player may read
+func (g *GameState) GameLogFor(playerID string) []LogEntry {
+	entries := make([]LogEntry, 0)
+	for _, e := range g.GameLog {
+		if !e.Private || e.PlayerID == playerID {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// chronicle writes an event in the game log, if it is worth telling
+func (g *GameState) chronicle(e BusEvent) {
+	pack := locale.Get(g.Config.Locale)
+	name := func(playerID string) string {
+		if p := g.GetPlayer(playerID); p != nil {
+			return p.Name
+		}
+		return playerID
+	}
+
+	var entry LogEntry
+	switch e := e.(type) {
+	case CityFounded:
+		entry = LogEntry{PlayerID: e.PlayerID, Text: pack.Text("log.city_founded", "player", name(e.PlayerID), "city", e.Name)}
+	case CombatResolved:
+		entry = LogEntry{PlayerID: e.AttackerID, Text: combatText(pack, e.CombatLogEntry, name)}
+	case ProductionCompleted:
+		entry = LogEntry{PlayerID: e.PlayerID, Private: true, Text: pack.Text("log.completed", "city", e.CityName, "item", e.Item)}
+	case CityGrew:
+		entry = LogEntry{PlayerID: e.PlayerID, Private: true, Text: pack.Text("log.city_grew", "city", e.CityName, "population", strconv.Itoa(e.Population))}
+	case CelebrationChanged:
+		key := "log.celebration_ended"
+		if e.Celebrating {
+			key = "log.celebration_started"
+		}
+		entry = LogEntry{PlayerID: e.PlayerID, Private: true, Text: pack.Text(key, "city", e.CityName)}
+	case TechDiscovered:
+		entry = LogEntry{PlayerID: e.PlayerID, Private: true, Text: pack.Text("log.tech_discovered", "player", name(e.PlayerID), "tech", e.Tech.String())}
+	case KingLost:
+		entry = LogEntry{PlayerID: e.PlayerID, Text: pack.Text("log.king_lost", "player", name(e.PlayerID))}
+	default:
+		return
+	}
+
+	entry.Turn = g.CurrentTurn
+	g.GameLog = append(g.GameLog, entry)
+	if extra := len(g.GameLog) - GameLogSize; extra > 0 {
+		g.GameLog = append(g.GameLog[:0], g.GameLog[extra:]...)
+	}
+	g.publish(Logged{entry})
+}
+
+// combatText tells of a battle in words
+func combatText(pack *locale.Pack, e CombatLogEntry, name func(string) string) string {
+	attacker, defender := name(e.AttackerID), name(e.DefenderID)
+	unit := e.AttackerUnit.String()
+	defending := e.DefenderUnit.String()
+	if e.Undefended {
+		defending = pack.Text("log.city")
+	}
+	switch {
+	case e.CityCaptured != "":
+		return pack.Text("log.city_captured", "player", attacker, "unit", unit, "city", e.CityCaptured, "defender", defender)
+	case e.AttackerWon:
+		return pack.Text("log.attack_won", "player", attacker, "unit", unit, "defender", defender, "defender_unit", defending)
+	default:
+		return pack.Text("log.attack_lost", "player", attacker, "unit", unit, "defender", defender, "defender_unit", defending)
+	}
+}
@@ -0,0 +1,314 @@
+package game
+
+import "civilization/internal/game/rules"
+
+// Groups let a player move several units on the same tile as one stack.
+// Membership is stored on the units themselves; a group exists as long as
+// at least two of its units are alive and still share a tile.
+
+// GroupUnits returns the player's units belonging to a group
+func (p *Player) GroupUnits(groupID string) []*Unit {
+	units := make([]*Unit, 0)
+	if groupID == "" {
+		return units
+	}
+	for _, u := range p.Units {
+		if u.GroupID == groupID {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// pruneGroup disbands a group that is down to its last member, and
+// returns that member, or nil if the group stands
+func (p *Player) pruneGroup(groupID string) *Unit {
+	members := p.GroupUnits(groupID)
+	if len(members) == 1 {
+		members[0].GroupID = ""
+		return members[0]
+	}
+	return nil
+}
+
+// leaveGroup takes a unit out of its group, disbanding the group if only
+// one unit would be left in it, and records both in result
+func (g *GameState) leaveGroup(unit *Unit, result *Result) {
+	groupID := unit.GroupID
+	if groupID == "" {
+		return
+	}
+
+	unit.GroupID = ""
+	result.unit(unit.ID)
+	if player := g.GetPlayer(unit.OwnerID); player != nil {
+		if last := player.pruneGroup(groupID); last != nil {
+			result.unit(last.ID)
+		}
+	}
+}
+
+// GroupUnits returns the units belonging to a group, whoever owns it
+func (g *GameState) GroupUnits(groupID string) []*Unit {
+	for _, p := range g.Players {
+		if units := p.GroupUnits(groupID); len(units) > 0 {
+			return units
+		}
+	}
+	return make([]*Unit, 0)
+}
+
+// groupOf returns the members of a group owned by the player, or an error
+// if the player has no such group
+func (g *GameState) groupOf(playerID, groupID string) ([]*Unit, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+
+	members := player.GroupUnits(groupID)
+	if len(members) == 0 {
+		return nil, &ActionError{Err: ErrGroupNotFound, GroupID: groupID}
+	}
+	return members, nil
+}
+
+// CreateGroupAction forms a new group from units sharing a tile. Units that
+// already belong to a group leave it.
+type CreateGroupAction struct {
+	UnitIDs []string `json:"unit_ids"`
+}
+
+// Type returns the action type name
+func (a *CreateGroupAction) Type() string {
+	return "create_group"
+}
+
+// Validate checks that the units can be grouped
+func (a *CreateGroupAction) Validate(g *GameState, playerID string) error {
+	if len(a.UnitIDs) < 2 {
+		return &ActionError{Err: ErrGroupTooSmall}
+	}
+
+	var first *Unit
+	seen := make(map[string]bool)
+	for _, id := range a.UnitIDs {
+		if seen[id] {
+			return unitError(ErrUnitListedTwice, id)
+		}
+		seen[id] = true
+
+		unit, err := g.ownUnit(playerID, id)
+		if err != nil {
+			return err
+		}
+
+		if first == nil {
+			first = unit
+		} else if unit.X != first.X || unit.Y != first.Y {
+			return unitError(ErrNotStacked, unit.ID).at(first.X, first.Y)
+		}
+	}
+
+	return nil
+}
+
+// Execute forms the group
+func (a *CreateGroupAction) Execute(g *GameState) (*Result, error) {
+	units := make([]*Unit, len(a.UnitIDs))
+	for i, id := range a.UnitIDs {
+		if units[i] = g.GetUnit(id); units[i] == nil {
+			return nil, ErrUnitNotFound
+		}
+	}
+
+	result := &Result{}
+	groupID := g.newID()
+	for _, unit := range units {
+		g.leaveGroup(unit, result)
+		unit.GroupID = groupID
+		result.unit(unit.ID)
+	}
+
+	return result, nil
+}
+
+// AddToGroupAction adds a unit to an existing group on the same tile
+type AddToGroupAction struct {
+	GroupID string `json:"group_id"`
+	UnitID  string `json:"unit_id"`
+}
+
+// Type returns the action type name
+func (a *AddToGroupAction) Type() string {
+	return "add_to_group"
+}
+
+// Validate checks that the unit can join the group
+func (a *AddToGroupAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	members, err := g.groupOf(playerID, a.GroupID)
+	if err != nil {
+		return err
+	}
+
+	if unit.GroupID == a.GroupID {
+		e := unitError(ErrAlreadyInGroup, unit.ID)
+		e.GroupID = a.GroupID
+		return e
+	}
+
+	if unit.X != members[0].X || unit.Y != members[0].Y {
+		e := unitError(ErrNotStacked, unit.ID).at(members[0].X, members[0].Y)
+		e.GroupID = a.GroupID
+		return e
+	}
+
+	return nil
+}
+
+// Execute adds the unit to the group
+func (a *AddToGroupAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	result := &Result{}
+	g.leaveGroup(unit, result)
+	unit.GroupID = a.GroupID
+	result.unit(unit.ID)
+
+	return result, nil
+}
+
+// RemoveFromGroupAction takes a unit out of its group
+type RemoveFromGroupAction struct {
+	UnitID string `json:"unit_id"`
+}
+
+// Type returns the action type name
+func (a *RemoveFromGroupAction) Type() string {
+	return "remove_from_group"
+}
+
+// Validate checks that the unit is in a group
+func (a *RemoveFromGroupAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if unit.GroupID == "" {
+		return unitError(ErrGroupNotFound, unit.ID)
+	}
+
+	return nil
+}
+
+// Execute removes the unit from its group
+func (a *RemoveFromGroupAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	result := &Result{}
+	g.leaveGroup(unit, result)
+
+	return result, nil
+}
+
+// MoveGroupAction moves every member of a group along a path of adjacent
+// tiles. The group stops as soon as any member runs out of movement, so it
+// travels at the speed of its slowest unit.
+type MoveGroupAction struct {
+	GroupID string     `json:"group_id"`
+	Path    []Waypoint `json:"path"`
+}
+
+// Type returns the action type name
+func (a *MoveGroupAction) Type() string {
+	return "move_group"
+}
+
+// Validate checks that the group can take at least the first step
+func (a *MoveGroupAction) Validate(g *GameState, playerID string) error {
+	members, err := g.groupOf(playerID, a.GroupID)
+	if err != nil {
+		return err
+	}
+
+	if len(a.Path) == 0 {
+		return &ActionError{Err: ErrInvalidMove, GroupID: a.GroupID}
+	}
+
+	// Each step must be next to the one before it
+	x, y := members[0].X, members[0].Y
+	for _, step := range a.Path {
+		if !rules.Adjacent(x, y, step.X, step.Y) {
+			e := &ActionError{Err: ErrInvalidMove, GroupID: a.GroupID}
+			return e.at(step.X, step.Y)
+		}
+		x, y = step.X, step.Y
+	}
+
+	for _, unit := range members {
+		if !unit.CanMove() {
+			e := noMovement(unit, 1)
+			e.GroupID = a.GroupID
+			return e
+		}
+	}
+
+	if !g.canGroupStep(members, playerID, a.Path[0]) {
+		e := &ActionError{Err: ErrInvalidMove, GroupID: a.GroupID}
+		return e.at(a.Path[0].X, a.Path[0].Y).on(g.Map.GetTile(a.Path[0].X, a.Path[0].Y))
+	}
+
+	return nil
+}
+
+// Execute moves the group as far along the path as its slowest member allows
+func (a *MoveGroupAction) Execute(g *GameState) (*Result, error) {
+	members := g.GroupUnits(a.GroupID)
+	if len(members) == 0 {
+		return nil, ErrGroupNotFound
+	}
+
+	result := &Result{}
+	playerID := members[0].OwnerID
+	for _, step := range a.Path {
+		if !g.canGroupStep(members, playerID, step) {
+			break
+		}
+		for _, unit := range members {
+			g.stepUnit(unit, step.X, step.Y, result)
+			unit.ClearOrders() // Manual orders cancel automation
+		}
+	}
+
+	return result, nil
+}
+
+// canGroupStep reports whether every member of a group can move onto a tile
+// that holds no enemy units or cities
+func (g *GameState) canGroupStep(members []*Unit, playerID string, step Waypoint) bool {
+	if len(g.GetEnemyUnitsAt(step.X, step.Y, playerID)) > 0 {
+		return false
+	}
+	if city := g.GetCityAt(step.X, step.Y); city != nil && city.OwnerID != playerID {
+		return false
+	}
+
+	for _, unit := range members {
+		if !g.IsValidMove(unit, step.X, step.Y) {
+			return false
+		}
+	}
+	return true
+}
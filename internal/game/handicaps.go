@@ -0,0 +1,82 @@
+package game
+
+// AI players get handicaps by the game's AI difficulty: their cities make
+// more shields, they start with extra units, and their units are trained
+// as veterans. Human players get none, whatever the difficulty.
+
+// Handicap is what the AI players of a difficulty get over human players
+type Handicap struct {
+	ProductionPercent int        // Percent more shields AI cities make
+	FreeUnits         []UnitType // Units each AI player starts with besides everyone's
+	VeteranUnits      bool       // AI units are veterans from the start
+}
+
+// Handicaps are the handicaps of each AI difficulty
+var Handicaps = map[string]Handicap{
+	DifficultyNormal: {},
+	DifficultyHard: {
+		ProductionPercent: 20,
+		FreeUnits:         []UnitType{UnitWarrior},
+		VeteranUnits:      true,
+	},
+}
+
+// Difficulties returns the AI difficulties, easiest first
+func Difficulties() []string {
+	return []string{DifficultyNormal, DifficultyHard}
+}
+
+// Handicap returns what the player gets from the game's AI difficulty,
+// nothing for human players
+func (g *GameState) Handicap(player *Player) Handicap {
+	if player.Type != PlayerAI {
+		return Handicap{}
+	}
+	difficulty := g.Config.AIDifficulty
+	if difficulty == "" {
+		difficulty = DifficultyNormal
+	}
+	return Handicaps[difficulty]
+}
+
+// grantFreeUnits gives every AI player the free units of their handicap,
+// with their first unit or else in their first city
+func (g *GameState) grantFreeUnits() {
+	for _, player := range g.Players {
+		handicap := g.Handicap(player)
+		var x, y int
+		switch {
+		case len(handicap.FreeUnits) == 0:
+			continue
+		case len(player.Units) > 0:
+			x, y = player.Units[0].X, player.Units[0].Y
+		case len(player.Cities) > 0:
+			x, y = player.Cities[0].X, player.Cities[0].Y
+		default:
+			continue
+		}
+		for _, unitType := range handicap.FreeUnits {
+			unit := NewUnit(unitType, player.ID, x, y)
+			unit.ID = g.newID()
+			g.train(player, unit)
+			player.AddUnit(unit)
+		}
+	}
+}
+
+// train makes a new unit of the player's a veteran if their handicap
+// gives veterans
+func (g *GameState) train(player *Player, unit *Unit) {
+	if g.Handicap(player).VeteranUnits && !unit.IsVeteran {
+		unit.promote(VeteranFromHandicap)
+	}
+}
+
+// updateBonuses sets the extra shields the player's cities make from their
+// handicap
+func (g *GameState) updateBonuses(player *Player) {
+	bonus := g.Handicap(player).ProductionPercent
+	for _, city := range player.Cities {
+		city.Bonus = bonus
+	}
+}
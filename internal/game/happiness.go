@@ -0,0 +1,203 @@
+package game
+
+import (
+	"slices"
+	"sort"
+)
+
+// Up to ContentCitizens citizens of each city are content and the rest are
+// unhappy. Luxuries make more of them content: each distinct luxury a
+// player has adds LuxuryHappiness in every one of their cities. A player
+// has a luxury when a city of theirs that is the capital, or joined to it
+// by road, works a tile of it, or when another player shares one with them
+// in a deal. A city whose unhappy citizens outnumber its content ones is
+// in disorder, and produces no shields and no trade.
+//
+// A city of at least CelebrationSize citizens with none unhappy, where
+// luxuries keep at least half of them content, celebrates: each tile it
+// works that yields trade yields CelebrationTrade more. There are no
+// governments yet, so celebrations bring every player the same. Owners
+// are told as their cities start and stop celebrating.
+
+// Luxuries lists the resources that are luxuries
+var Luxuries = []ResourceType{ResourceGems, ResourceSilk, ResourceSpices, ResourceFurs}
+
+// IsLuxury reports whether a resource is a luxury
+func (r ResourceType) IsLuxury() bool {
+	return slices.Contains(Luxuries, r)
+}
+
+// LuxuryTrade is a luxury one player shares with another in a deal, until
+// the turn the deal ends
+type LuxuryTrade struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Resource ResourceType `json:"resource"`
+	Until    int          `json:"until"`
+}
+
+// Happiness accounts for the contentment of a player's cities
+type Happiness struct {
+	Luxuries []LuxurySupply  `json:"luxuries"` // Every luxury the player has or gives away
+	Cities   []CityHappiness `json:"cities"`
+}
+
+// LuxurySupply is where a player's copies of a luxury come from and go to
+type LuxurySupply struct {
+	Resource string   `json:"resource"`
+	Worked   int      `json:"worked"`             // Tiles of it worked by connected cities
+	Given    []string `json:"given,omitempty"`    // Players it is shared with
+	Received []string `json:"received,omitempty"` // Players sharing it
+	Has      bool     `json:"has"`                // Whether it makes the player's citizens content
+}
+
+// CityHappiness is how content a city's citizens are
+type CityHappiness struct {
+	CityID      string `json:"city_id"`
+	CityName    string `json:"city_name"`
+	Population  int    `json:"population"`
+	Base        int    `json:"base"`     // Citizens content without luxuries
+	Luxuries    int    `json:"luxuries"` // Citizens luxuries make content
+	Content     int    `json:"content"`
+	Unhappy     int    `json:"unhappy"`
+	Disorder    bool   `json:"disorder"`
+	Celebrating bool   `json:"celebrating"`
+}
+
+// workedLuxuries counts the tiles of each luxury a player's cities work
+// that are the capital or joined to it by road
+func (g *GameState) workedLuxuries(player *Player) map[ResourceType]int {
+	worked := make(map[ResourceType]int)
+	capital := player.Capital()
+	for _, city := range player.Cities {
+		if city != capital && !g.ConnectedToCapital(city) {
+			continue
+		}
+		for _, tile := range g.GetCityTiles(city) {
+			if tile.Resource.IsLuxury() {
+				worked[tile.Resource]++
+			}
+		}
+	}
+	return worked
+}
+
+// activeTrades returns the luxury trades still in force
+func (g *GameState) activeTrades() []LuxuryTrade {
+	var trades []LuxuryTrade
+	for _, t := range g.LuxuryTrades {
+		if t.Until > g.CurrentTurn {
+			trades = append(trades, t)
+		}
+	}
+	return trades
+}
+
+// expireLuxuryTrades forgets the luxury trades that have ended
+func (g *GameState) expireLuxuryTrades() {
+	g.LuxuryTrades = slices.DeleteFunc(g.LuxuryTrades, func(t LuxuryTrade) bool {
+		return t.Until <= g.CurrentTurn
+	})
+}
+
+// spareLuxury reports whether a player works more tiles of a luxury than
+// they share, so that they have a copy to give
+func (g *GameState) spareLuxury(player *Player, resource ResourceType) bool {
+	given := 0
+	for _, t := range g.activeTrades() {
+		if t.From == player.ID && t.Resource == resource {
+			given++
+		}
+	}
+	return g.workedLuxuries(player)[resource] > given
+}
+
+// Happiness accounts for a player's luxuries and the contentment of their
+// cities
+func (g *GameState) Happiness(playerID string) (*Happiness, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+
+	supplies := make(map[ResourceType]*LuxurySupply)
+	supply := func(r ResourceType) *LuxurySupply {
+		if supplies[r] == nil {
+			supplies[r] = &LuxurySupply{Resource: r.String()}
+		}
+		return supplies[r]
+	}
+	for r, n := range g.workedLuxuries(player) {
+		supply(r).Worked = n
+	}
+
+	// A luxury shared with the player is theirs only while the giver
+	// still works a copy for each player they share it with
+	worked := make(map[string]map[ResourceType]int)
+	trades := g.activeTrades()
+	for _, t := range trades {
+		switch playerID {
+		case t.From:
+			supply(t.Resource).Given = append(supply(t.Resource).Given, t.To)
+		case t.To:
+			if worked[t.From] == nil {
+				if from := g.GetPlayer(t.From); from != nil {
+					worked[t.From] = g.workedLuxuries(from)
+				}
+			}
+			given := 0
+			for _, other := range trades {
+				if other.From == t.From && other.Resource == t.Resource {
+					given++
+				}
+			}
+			if worked[t.From][t.Resource] >= given {
+				supply(t.Resource).Received = append(supply(t.Resource).Received, t.From)
+			}
+		}
+	}
+
+	h := &Happiness{Luxuries: make([]LuxurySupply, 0, len(supplies)), Cities: make([]CityHappiness, 0, len(player.Cities))}
+	luxuries := 0
+	for _, s := range supplies {
+		s.Has = s.Worked > len(s.Given) || len(s.Received) > 0
+		if s.Has {
+			luxuries++
+		}
+		h.Luxuries = append(h.Luxuries, *s)
+	}
+	sort.Slice(h.Luxuries, func(i, j int) bool { return h.Luxuries[i].Resource < h.Luxuries[j].Resource })
+
+	for _, city := range player.Cities {
+		c := CityHappiness{
+			CityID:     city.ID,
+			CityName:   city.Name,
+			Population: city.Population,
+			Base:       ContentCitizens,
+			Luxuries:   luxuries * LuxuryHappiness,
+		}
+		c.Content = min(city.Population, c.Base+c.Luxuries)
+		c.Unhappy = city.Population - c.Content
+		c.Disorder = c.Unhappy > c.Content
+		c.Celebrating = city.Population >= CelebrationSize && c.Unhappy == 0 && 2*c.Luxuries >= city.Population
+		h.Cities = append(h.Cities, c)
+	}
+	return h, nil
+}
+
+// updateDisorder puts the player's cities whose unhappy citizens
+// outnumber their content ones in disorder, and takes the rest out of it.
+// Cities happy enough start celebrating and the rest stop.
+func (g *GameState) updateDisorder(player *Player) {
+	h, err := g.Happiness(player.ID)
+	if err != nil {
+		return
+	}
+	for i, city := range player.Cities {
+		city.Disorder = h.Cities[i].Disorder
+		if celebrating := h.Cities[i].Celebrating; celebrating != city.Celebrating {
+			city.Celebrating = celebrating
+			g.publish(CelebrationChanged{CityID: city.ID, PlayerID: player.ID, CityName: city.Name, Population: city.Population, Celebrating: celebrating})
+		}
+	}
+}
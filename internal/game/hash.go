@@ -0,0 +1,68 @@
+package game
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+)
+
+// hashedState is a game as it is hashed: as saved, without the event log,
+// which records the hashes, and with the map's tiles hashed apart
+type hashedState struct {
+	*GameState
+	Map    *hashedMap `json:"map"`
+	Events []Event    `json:"events,omitempty"`
+}
+
+// hashedMap is a map as it is hashed, without its tiles
+type hashedMap struct {
+	*GameMap
+	Tiles []Tile `json:"tiles,omitempty"`
+}
+
+// Hash returns a fingerprint of the game's state: everything saved of it
+// but the event log. Games that play the same way hash the same, so two
+// copies of a game, such as a saved one and its replay, are in sync as long
+// as their hashes match.
+func (g *GameState) Hash() string {
+	h := fnv.New64a()
+	state := hashedState{GameState: g}
+	if g.Map != nil {
+		state.Map = &hashedMap{GameMap: g.Map}
+	}
+	if err := json.NewEncoder(h).Encode(state); err != nil {
+		return fmt.Sprintf("(%v)", err)
+	}
+
+	// Encoding the tiles as JSON would take most of the time hashing the
+	// game does, after every action
+	if g.Map != nil {
+		var buf []byte
+		for i := range g.Map.Tiles {
+			buf = appendTile(buf, &g.Map.Tiles[i])
+		}
+		h.Write(buf)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// appendTile appends every field of a tile to a hash's input. Fields added
+// to Tile must be added here too.
+func appendTile(buf []byte, t *Tile) []byte {
+	for _, n := range []int{t.X, t.Y, int(t.Terrain), int(t.Resource), t.JobProgress} {
+		buf = binary.AppendVarint(buf, int64(n))
+	}
+	var flags byte
+	for i, set := range []bool{t.HasRoad, t.HasMine, t.HasIrrigation, t.HasRiver, t.Fallout, t.Volcano, t.Coastal, t.Ford} {
+		if set {
+			flags |= 1 << i
+		}
+	}
+	buf = append(buf, flags)
+	for _, s := range []string{t.Owner, t.WorkedBy, t.Job} {
+		buf = binary.AppendUvarint(buf, uint64(len(s)))
+		buf = append(buf, s...)
+	}
+	return buf
+}
@@ -0,0 +1,49 @@
+package game
+
+// HealAmount returns the health a unit will regain at the end of its
+// owner's turn if it stays where it is. Units that moved or attacked during
+// the turn regain none.
+func (g *GameState) HealAmount(unit *Unit) int {
+	missing := BaseHealthPoints - unit.Health
+	if missing <= 0 || unit.Acted {
+		return 0
+	}
+
+	heal := 0
+	if city := g.GetCityAt(unit.X, unit.Y); city != nil && city.OwnerID == unit.OwnerID {
+		heal = HealInCity
+		if city.HasBarracks() {
+			heal = missing
+		}
+	} else {
+		switch g.TerritoryOwner(unit.X, unit.Y) {
+		case unit.OwnerID:
+			heal = HealInField
+			if unit.IsFortified {
+				heal = HealFortified
+			}
+		case "":
+			heal = HealInField
+		default:
+			// No healing in enemy territory
+		}
+	}
+
+	if heal > missing {
+		heal = missing
+	}
+	return heal
+}
+
+// healUnits restores health to a player's wounded units, and returns how
+// many healed
+func (g *GameState) healUnits(player *Player) int {
+	healed := 0
+	for _, unit := range player.Units {
+		if heal := g.HealAmount(unit); heal > 0 {
+			unit.Health += heal
+			healed++
+		}
+	}
+	return healed
+}
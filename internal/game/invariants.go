@@ -0,0 +1,181 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CheckInvariantsAfterActions makes Apply check the invariants after every
+// action and panic when one is broken, or when an action failed after it
+// changed the game. It is meant for debugging and fuzz tests, where corrupt
+// state should stop the game where it happened.
+var CheckInvariantsAfterActions = false
+
+// ErrInvariant is wrapped by every broken invariant CheckInvariants reports
+var ErrInvariant = errors.New("invariant broken")
+
+// CheckInvariants checks that the game state holds together: every unit
+// and city is on the map with an owner that has it, units stand on terrain
+// they can enter with health and movement in range, no two cities share a
+// tile, cities have citizens, tiles belong to the territory they are in,
+// shared vision pacts are mutual and IDs are unique. It returns every broken invariant joined into one error,
+// or nil.
+func (g *GameState) CheckInvariants() error {
+	var errs []error
+	broken := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvariant, fmt.Sprintf(format, args...)))
+	}
+
+	if g.Map == nil {
+		broken("game has no map")
+		return errors.Join(errs...)
+	}
+	if len(g.Map.Tiles) != g.Map.Width*g.Map.Height {
+		broken("map has %d tiles for %dx%d", len(g.Map.Tiles), g.Map.Width, g.Map.Height)
+	}
+	if g.Phase != PhaseSetup && g.Phase != PhaseGameOver && g.GetCurrentPlayer() == nil {
+		broken("current player %q is not in the game", g.TurnOrder.Current)
+	}
+
+	players := make(map[string]bool, len(g.Players))
+	for _, p := range g.Players {
+		if players[p.ID] {
+			broken("player ID %s is used twice", p.ID)
+		}
+		players[p.ID] = true
+	}
+	if len(g.TurnOrder.Seats) != len(g.Players) {
+		broken("turn order has %d seats for %d players", len(g.TurnOrder.Seats), len(g.Players))
+	}
+	for i, id := range g.TurnOrder.Seats {
+		if !players[id] || g.TurnOrder.Seat(id) != i {
+			broken("turn order seats %s, who is not in the game or seated twice", id)
+		}
+	}
+	for _, p := range g.Players {
+		for _, id := range p.SharedVision {
+			if partner := g.GetPlayer(id); partner == nil || !partner.SharesVision(p.ID) {
+				broken("player %s shares vision with %s, who does not share back", p.ID, id)
+			}
+		}
+	}
+	for _, o := range g.Offers {
+		if !players[o.From] || !players[o.To] {
+			broken("%s offered from %s to %s, who are not both in the game", o.Deal, o.From, o.To)
+		}
+	}
+	for _, t := range g.LuxuryTrades {
+		if !players[t.From] || !players[t.To] || !t.Resource.IsLuxury() {
+			broken("%s shared from %s to %s, which is not a luxury or not between players in the game", t.Resource, t.From, t.To)
+		}
+	}
+
+	units := make(map[string]bool)
+	cities := make(map[string]bool)
+	cityTiles := make(map[int]string)
+	originalCapitals := make(map[string]string)
+	for _, p := range g.Players {
+		palaces := 0
+		for _, city := range p.Cities {
+			if cities[city.ID] {
+				broken("city ID %s is used twice", city.ID)
+			}
+			cities[city.ID] = true
+
+			if city.OwnerID != p.ID {
+				broken("city %s of %s names %q as its owner", city.Name, p.ID, city.OwnerID)
+			}
+			if city.Population < 1 {
+				broken("city %s has population %d", city.Name, city.Population)
+			}
+			if city.WonderShields < 0 || city.WonderShields > city.Production {
+				broken("city %s has %d shields from caravans of %d", city.Name, city.WonderShields, city.Production)
+			}
+			if city.Corruption < 0 || city.Corruption > MaxCorruption || city.Waste < 0 || city.Waste > MaxWaste {
+				broken("city %s loses %d%% of its trade and %d%% of its shields", city.Name, city.Corruption, city.Waste)
+			}
+			if city.HasBuilding(BuildingPalace) {
+				palaces++
+			}
+			if id := city.OriginalCapital; id != "" {
+				if other, ok := originalCapitals[id]; ok {
+					broken("cities %s and %s are both the original capital of %s", other, city.Name, id)
+				}
+				originalCapitals[id] = city.Name
+			}
+
+			tile := g.Map.GetTile(city.X, city.Y)
+			if tile == nil {
+				broken("city %s is off the map at (%d, %d)", city.Name, city.X, city.Y)
+				continue
+			}
+			if tile.IsWater() {
+				broken("city %s is on water at (%d, %d)", city.Name, city.X, city.Y)
+			}
+			index := city.Y*g.Map.Width + city.X
+			if other, ok := cityTiles[index]; ok {
+				broken("cities %s and %s share (%d, %d)", other, city.Name, city.X, city.Y)
+			}
+			cityTiles[index] = city.Name
+		}
+		if palaces > 1 {
+			broken("player %s has %d palaces", p.ID, palaces)
+		}
+
+		kings := 0
+		for _, unit := range p.Units {
+			if unit.IsKing() {
+				kings++
+			}
+			if units[unit.ID] {
+				broken("unit ID %s is used twice", unit.ID)
+			}
+			units[unit.ID] = true
+
+			if unit.OwnerID != p.ID {
+				broken("unit %s of %s names %q as its owner", unit.ID, p.ID, unit.OwnerID)
+			}
+			if unit.MovementLeft < 0 {
+				broken("unit %s has %d movement left", unit.ID, unit.MovementLeft)
+			}
+			if unit.Health <= 0 || unit.Health > BaseHealthPoints {
+				broken("unit %s has health %d", unit.ID, unit.Health)
+			}
+
+			tile := g.Map.GetTile(unit.X, unit.Y)
+			if tile == nil {
+				broken("unit %s is off the map at (%d, %d)", unit.ID, unit.X, unit.Y)
+				continue
+			}
+			// Naval units may also wait in port
+			naval := unit.Template().IsNaval
+			if !naval && !tile.IsPassable() || naval && !tile.IsWater() && g.GetCityAt(unit.X, unit.Y) == nil {
+				broken("%s %s cannot stand on %s at (%d, %d)", unit.Type, unit.ID, tile.Terrain, unit.X, unit.Y)
+			}
+		}
+		if kings > 1 {
+			broken("player %s has %d kings", p.ID, kings)
+		}
+	}
+
+	for _, order := range g.Orders {
+		if !players[order.PlayerID] {
+			broken("order for unit %s names unknown player %q", order.UnitID, order.PlayerID)
+		}
+	}
+	if g.Winner != nil && !players[g.Winner.ID] {
+		broken("winner %s is not in the game", g.Winner.ID)
+	}
+
+	if len(g.Map.Tiles) == g.Map.Width*g.Map.Height {
+		for i, city := range g.claims() {
+			owner, worker := claimedBy(city)
+			if tile := g.Map.Tiles[i]; tile.Owner != owner || tile.WorkedBy != worker {
+				broken("tile (%d, %d) belongs to %q and city %q instead of %q and %q", tile.X, tile.Y, tile.Owner, tile.WorkedBy, owner, worker)
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
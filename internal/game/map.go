@@ -1,5 +1,7 @@
 package game
 
+import "encoding/json"
+
 // TerrainType represents different terrain types on the map
 type TerrainType int
 
@@ -44,7 +46,15 @@ type Tile struct {
 	HasRoad       bool         `json:"has_road"`
 	HasMine       bool         `json:"has_mine"`
 	HasIrrigation bool         `json:"has_irrigation"`
-	HasRiver      bool         `json:"has_river"` // Tile is adjacent to a river
+	HasRiver      bool         `json:"has_river"`              // Tile is adjacent to a river
+	Fallout       bool         `json:"fallout,omitempty"`      // Contaminated by a nuclear detonation
+	Volcano       bool         `json:"volcano,omitempty"`      // Mountain that may erupt, see EventEruption
+	Owner         string       `json:"owner,omitempty"`        // Player whose territory the tile is in
+	WorkedBy      string       `json:"worked_by,omitempty"`    // City that works the tile, see UpdateBorders
+	Coastal       bool         `json:"coastal,omitempty"`      // Ocean next to land, see MarkCoast
+	Ford          bool         `json:"ford,omitempty"`         // Ocean land units can wade across
+	Job           string       `json:"job,omitempty"`          // Terrain job under way, see TerraformAction
+	JobProgress   int          `json:"job_progress,omitempty"` // Turns of work done on Job
 }
 
 // RiverPoint represents a point along a river path
@@ -59,13 +69,10 @@ type River struct {
 	Delta  [][]RiverPoint `json:"delta,omitempty"` // Delta branches near the mouth
 }
 
-// MovementCost returns the movement cost to enter this tile
+// MovementCost returns the movement cost to enter this tile off road.
+// GameState.GetMovementCost gives the cost of a move, roads included.
 func (t *Tile) MovementCost() int {
-	cost := TerrainMovementCost[t.Terrain]
-	if t.HasRoad {
-		return 1 // Roads reduce movement to 1
-	}
-	return cost
+	return TerrainMovementCost[t.Terrain]
 }
 
 // FoodYield returns the food production of this tile
@@ -82,6 +89,9 @@ func (t *Tile) FoodYield() int {
 	if bonus, ok := ResourceBonuses[t.Resource]; ok {
 		yield += bonus.Food
 	}
+	if t.Fallout {
+		yield /= FalloutYieldDivisor
+	}
 	return yield
 }
 
@@ -95,6 +105,9 @@ func (t *Tile) ProductionYield() int {
 	if bonus, ok := ResourceBonuses[t.Resource]; ok {
 		yield += bonus.Production
 	}
+	if t.Fallout {
+		yield /= FalloutYieldDivisor
+	}
 	return yield
 }
 
@@ -108,6 +121,9 @@ func (t *Tile) TradeYield() int {
 	if bonus, ok := ResourceBonuses[t.Resource]; ok {
 		yield += bonus.Trade
 	}
+	if t.Fallout {
+		yield /= FalloutYieldDivisor
+	}
 	return yield
 }
 
@@ -116,9 +132,23 @@ func (t *Tile) DefenseBonus() float64 {
 	return TerrainDefenseBonus[t.Terrain]
 }
 
-// IsPassable returns whether land units can enter this tile
+// IsPassable returns whether land units can enter this tile: land, or a
+// ford across a channel
 func (t *Tile) IsPassable() bool {
-	return t.Terrain != TerrainOcean
+	return t.Terrain != TerrainOcean || t.Ford
+}
+
+// CanEnter reports whether a naval or a land unit can enter this tile
+func (t *Tile) CanEnter(naval bool) bool {
+	if naval {
+		return t.IsWater()
+	}
+	return t.IsPassable()
+}
+
+// CanHoldCity reports whether a city can stand on this tile's terrain
+func (t *Tile) CanHoldCity() bool {
+	return !t.IsWater() && t.Terrain != TerrainMountains
 }
 
 // IsWater returns whether this tile is water
@@ -127,12 +157,15 @@ func (t *Tile) IsWater() bool {
 }
 
 
-// GameMap represents the game world map
+// GameMap represents the game world map. Tiles are stored row by row in a
+// single slice; the tile at (x, y) is Tiles[y*Width+x].
 type GameMap struct {
-	Width  int      `json:"width"`
-	Height int      `json:"height"`
-	Tiles  [][]Tile `json:"tiles"`
-	Rivers []River  `json:"rivers"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Tiles  []Tile  `json:"tiles"`
+	Rivers []River `json:"rivers"`
+
+	roads []int32 // Road network of each tile, -1 off road; see UpdateRoads
 }
 
 // NewGameMap creates a new empty game map
@@ -140,13 +173,12 @@ func NewGameMap(width, height int) *GameMap {
 	gm := &GameMap{
 		Width:  width,
 		Height: height,
-		Tiles:  make([][]Tile, height),
+		Tiles:  make([]Tile, width*height),
 	}
 
 	for y := 0; y < height; y++ {
-		gm.Tiles[y] = make([]Tile, width)
 		for x := 0; x < width; x++ {
-			gm.Tiles[y][x] = Tile{
+			gm.Tiles[y*width+x] = Tile{
 				X:       x,
 				Y:       y,
 				Terrain: TerrainGrassland, // Default terrain
@@ -157,18 +189,40 @@ func NewGameMap(width, height int) *GameMap {
 	return gm
 }
 
+// UnmarshalJSON loads a map, marks its coast and builds its road network
+func (gm *GameMap) UnmarshalJSON(data []byte) error {
+	type plain GameMap // Without this method, so decoding does not recurse
+	if err := json.Unmarshal(data, (*plain)(gm)); err != nil {
+		return err
+	}
+	gm.MarkCoast()
+	gm.UpdateRoads()
+	return nil
+}
+
+// Index returns the position of the tile at (x, y) in Tiles
+func (gm *GameMap) Index(x, y int) int {
+	return y*gm.Width + x
+}
+
 // GetTile returns the tile at the given coordinates
 func (gm *GameMap) GetTile(x, y int) *Tile {
 	if x < 0 || x >= gm.Width || y < 0 || y >= gm.Height {
 		return nil
 	}
-	return &gm.Tiles[y][x]
+	return &gm.Tiles[y*gm.Width+x]
+}
+
+// GetTileUnsafe returns the tile at the given coordinates without checking
+// bounds. Only use it in hot loops where the coordinates are known to be valid.
+func (gm *GameMap) GetTileUnsafe(x, y int) *Tile {
+	return &gm.Tiles[y*gm.Width+x]
 }
 
 // SetTerrain sets the terrain type at the given coordinates
 func (gm *GameMap) SetTerrain(x, y int, terrain TerrainType) {
 	if x >= 0 && x < gm.Width && y >= 0 && y < gm.Height {
-		gm.Tiles[y][x].Terrain = terrain
+		gm.Tiles[y*gm.Width+x].Terrain = terrain
 	}
 }
 
@@ -179,16 +233,9 @@ func (gm *GameMap) IsValidCoord(x, y int) bool {
 
 // GetNeighbors returns all adjacent tiles (8-directional)
 func (gm *GameMap) GetNeighbors(x, y int) []*Tile {
-	neighbors := make([]*Tile, 0, 8)
-	directions := [][2]int{
-		{-1, -1}, {0, -1}, {1, -1},
-		{-1, 0}, {1, 0},
-		{-1, 1}, {0, 1}, {1, 1},
-	}
-
-	for _, d := range directions {
-		nx, ny := x+d[0], y+d[1]
-		if tile := gm.GetTile(nx, ny); tile != nil {
+	neighbors := make([]*Tile, 0, len(Directions))
+	for _, d := range Directions {
+		if tile := gm.TileAt(Coord{x, y}.Step(d)); tile != nil {
 			neighbors = append(neighbors, tile)
 		}
 	}
@@ -198,17 +245,9 @@ func (gm *GameMap) GetNeighbors(x, y int) []*Tile {
 
 // GetCardinalNeighbors returns adjacent tiles (4-directional: N, S, E, W)
 func (gm *GameMap) GetCardinalNeighbors(x, y int) []*Tile {
-	neighbors := make([]*Tile, 0, 4)
-	directions := [][2]int{
-		{0, -1}, // North
-		{0, 1},  // South
-		{1, 0},  // East
-		{-1, 0}, // West
-	}
-
-	for _, d := range directions {
-		nx, ny := x+d[0], y+d[1]
-		if tile := gm.GetTile(nx, ny); tile != nil {
+	neighbors := make([]*Tile, 0, len(CardinalDirections))
+	for _, d := range CardinalDirections {
+		if tile := gm.TileAt(Coord{x, y}.Step(d)); tile != nil {
 			neighbors = append(neighbors, tile)
 		}
 	}
@@ -216,17 +255,17 @@ func (gm *GameMap) GetCardinalNeighbors(x, y int) []*Tile {
 	return neighbors
 }
 
-// GetTilesInRadius returns all tiles within a given radius (Manhattan distance)
+// GetTilesInRadius returns all tiles within a given radius, leaving out the
+// center
 func (gm *GameMap) GetTilesInRadius(x, y, radius int) []*Tile {
 	tiles := make([]*Tile, 0)
-	for dy := -radius; dy <= radius; dy++ {
-		for dx := -radius; dx <= radius; dx++ {
-			if dx == 0 && dy == 0 {
-				continue
-			}
-			if tile := gm.GetTile(x+dx, y+dy); tile != nil {
-				tiles = append(tiles, tile)
-			}
+	center := Coord{x, y}
+	for c := range center.Within(radius) {
+		if c == center {
+			continue
+		}
+		if tile := gm.TileAt(c); tile != nil {
+			tiles = append(tiles, tile)
 		}
 	}
 	return tiles
@@ -234,5 +273,5 @@ func (gm *GameMap) GetTilesInRadius(x, y, radius int) []*Tile {
 
 // GetCityRadius returns tiles that a city at (x,y) would work (radius 2)
 func (gm *GameMap) GetCityRadius(x, y int) []*Tile {
-	return gm.GetTilesInRadius(x, y, 2)
+	return gm.GetTilesInRadius(x, y, CityRadius)
 }
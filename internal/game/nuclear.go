@@ -0,0 +1,125 @@
+package game
+
+// WonderBuilt reports whether any city in the world has the wonder
+func (g *GameState) WonderBuilt(wonder BuildingType) bool {
+	for _, p := range g.Players {
+		for _, city := range p.Cities {
+			if city.HasBuilding(wonder) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NuclearAggressors returns the players other than playerID that have
+// detonated a nuclear weapon
+func (g *GameState) NuclearAggressors(playerID string) []*Player {
+	aggressors := make([]*Player, 0)
+	for _, p := range g.Players {
+		if p.ID != playerID && p.IsAlive && p.NuclearStrikes > 0 {
+			aggressors = append(aggressors, p)
+		}
+	}
+	return aggressors
+}
+
+// NukeAction detonates a nuclear unit over any tile on the map. Every unit
+// within NukeRadius of the target is destroyed, whoever owns it, cities
+// lose NukePopulationLoss percent of their population and the land is left
+// with fallout.
+type NukeAction struct {
+	UnitID  string `json:"unit_id"`
+	TargetX int    `json:"target_x"`
+	TargetY int    `json:"target_y"`
+}
+
+// NukeResult holds what a detonation destroyed
+type NukeResult struct {
+	UnitsDestroyed []*Unit
+	CitiesHit      []*City
+}
+
+// Type returns the action type name
+func (a *NukeAction) Type() string {
+	return "nuke"
+}
+
+// Validate checks if the detonation is valid
+func (a *NukeAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if !unit.IsNuclear() {
+		return unitError(ErrNotNuclear, unit.ID)
+	}
+
+	if !unit.CanMove() {
+		return noMovement(unit, 1)
+	}
+
+	if !g.Map.IsValidCoord(a.TargetX, a.TargetY) {
+		return unitError(ErrInvalidTarget, unit.ID).at(a.TargetX, a.TargetY)
+	}
+
+	return nil
+}
+
+// Execute spends the unit and detonates it over the target
+func (a *NukeAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	g.RemoveUnit(unit.ID)
+	if player := g.GetPlayer(unit.OwnerID); player != nil {
+		player.NuclearStrikes++
+	}
+
+	result := g.detonate(a.TargetX, a.TargetY)
+	g.reportDetonation(unit, a.TargetX, a.TargetY, result)
+
+	// Fallout, the cities hit and the units lost are too many to list
+	return &Result{Removed: []string{unit.ID}, Whole: true}, nil
+}
+
+// detonate applies a nuclear blast centered on (x, y)
+func (g *GameState) detonate(x, y int) NukeResult {
+	var result NukeResult
+	inBlast := func(tx, ty int) bool {
+		return (Coord{tx, ty}).Distance(Coord{x, y}) <= NukeRadius
+	}
+
+	for _, p := range g.Players {
+		for _, u := range p.Units {
+			if inBlast(u.X, u.Y) {
+				result.UnitsDestroyed = append(result.UnitsDestroyed, u)
+			}
+		}
+		for _, city := range p.Cities {
+			if !inBlast(city.X, city.Y) {
+				continue
+			}
+			city.Population -= city.Population * NukePopulationLoss / 100
+			if city.Population < 1 {
+				city.Population = 1
+			}
+			result.CitiesHit = append(result.CitiesHit, city)
+		}
+	}
+
+	for _, u := range result.UnitsDestroyed {
+		g.RemoveUnit(u.ID)
+	}
+
+	for c := range (Coord{x, y}).Within(NukeRadius) {
+		if tile := g.Map.TileAt(c); tile != nil && !tile.IsWater() {
+			tile.Fallout = true
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,121 @@
+package game
+
+import "civilization/internal/game/rules"
+
+// A player's capital is the city with their Palace. Their first city is
+// founded with one, and a Palace built in another city moves the capital
+// there. The farther a city lies from the capital, the more of its trade
+// is lost to corruption and of its shields to waste, so that far-flung
+// empires gain less from each new city. There are no governments yet, so
+// every player loses at the same rates. A Palace does not survive its
+// city's capture, and until its former owner builds a new one every city
+// of theirs loses the most. A player who holds the first city of every
+// civilization in the game has conquered the world.
+
+// corruption returns the percent of a city's trade lost to corruption and
+// of its shields lost to waste for its distance from its owner's capital
+func (p *Player) corruption(city *City) (int, int) {
+	capital := p.Capital()
+	if capital == nil {
+		return MaxCorruption, MaxWaste
+	}
+	distance := rules.Distance(city.X, city.Y, capital.X, capital.Y)
+	return min(MaxCorruption, distance*CorruptionPerTile), min(MaxWaste, distance*WastePerTile)
+}
+
+// updateCorruption sets the corruption and waste of each of a player's
+// cities
+func (p *Player) updateCorruption() {
+	for _, city := range p.Cities {
+		city.Corruption, city.Waste = p.corruption(city)
+	}
+}
+
+// UpdateCorruption sets the corruption and waste of every city, as games
+// from before they were kept separately need
+func (g *GameState) UpdateCorruption() {
+	for _, p := range g.Players {
+		p.updateCorruption()
+	}
+}
+
+// Losses returns the trade a city loses to corruption and the shields it
+// loses to waste each turn. A city in disorder has nothing to lose.
+func (g *GameState) Losses(city *City) (trade, shields int) {
+	if city.Disorder {
+		return 0, 0
+	}
+	tiles := g.GetCityTiles(city)
+	return city.grossTrade(tiles) * city.Corruption / 100, city.grossProduction(tiles) * city.Waste / 100
+}
+
+// movePalace makes a city its owner's capital, taking the Palace from the
+// city that had it
+func (p *Player) movePalace(capital *City) {
+	for _, city := range p.Cities {
+		if city != capital {
+			delete(city.Buildings, BuildingPalace)
+		}
+	}
+	capital.AddBuilding(BuildingPalace)
+	p.updateCorruption()
+}
+
+// foundCapital makes a player's first city their capital. The first city
+// they ever found is their civilization's original capital.
+func (g *GameState) foundCapital(player *Player, city *City) {
+	if len(player.Cities) != 1 {
+		return
+	}
+	city.AddBuilding(BuildingPalace)
+	if g.OriginalCapital(player.ID) == nil {
+		city.OriginalCapital = player.ID
+	}
+}
+
+// OriginalCapital returns the first city a player founded, whoever holds
+// it now, or nil if they never founded one
+func (g *GameState) OriginalCapital(playerID string) *City {
+	for _, p := range g.Players {
+		for _, city := range p.Cities {
+			if city.OriginalCapital == playerID {
+				return city
+			}
+		}
+	}
+	return nil
+}
+
+// capitalConqueror returns the player who holds the original capital of
+// every player in the game, or nil. Until every player has founded a city
+// no one can.
+func (g *GameState) capitalConqueror() *Player {
+	var holder string
+	for _, p := range g.Players {
+		capital := g.OriginalCapital(p.ID)
+		if capital == nil || holder != "" && capital.OwnerID != holder {
+			return nil
+		}
+		holder = capital.OwnerID
+	}
+	if len(g.Players) < 2 {
+		return nil
+	}
+	return g.GetPlayer(holder)
+}
+
+// GrantPalaces gives each player with cities but no capital a Palace in
+// their oldest city, as games from before palaces had it. With no record
+// of their first city, that city becomes their original capital too.
+func (g *GameState) GrantPalaces() {
+	for _, p := range g.Players {
+		if len(p.Cities) == 0 || p.Capital() != nil {
+			continue
+		}
+		p.Cities[0].AddBuilding(BuildingPalace)
+		if g.OriginalCapital(p.ID) == nil {
+			p.Cities[0].OriginalCapital = p.ID
+		}
+		p.updateCorruption()
+	}
+}
@@ -17,10 +17,37 @@ type Player struct {
 	Type    PlayerType `json:"type"`
 	Color   string     `json:"color"` // Hex color for UI
 	Gold    int        `json:"gold"`
-	Science int        `json:"science"`
+	Science int        `json:"science"`  // Gathered in all
+	TaxRate int        `json:"tax_rate"` // Percent of trade collected as gold
 	Units   []*Unit    `json:"units"`
 	Cities  []*City    `json:"cities"`
 	IsAlive bool       `json:"is_alive"`
+
+	// Civilization indexes the civilization the player leads in the
+	// language packs' lists, which its city names come from
+	Civilization int `json:"civilization"`
+
+	// NuclearStrikes counts the nuclear weapons the player has detonated.
+	// Other players hold any use against them.
+	NuclearStrikes int `json:"nuclear_strikes,omitempty"`
+
+	// StartPoints are what the player has left to spend on an advanced
+	// start, until their first turn ends
+	StartPoints int `json:"start_points,omitempty"`
+
+	// Defeated is set when a scenario puts the player out of the game
+	Defeated bool `json:"defeated,omitempty"`
+
+	// Explored is a bitset of map tiles the player has seen, indexed like Map.Tiles
+	Explored []byte `json:"explored,omitempty"`
+
+	// SharedVision lists the players the player has a shared vision pact
+	// with. Pacts are mutual, so each lists the other.
+	SharedVision []string `json:"shared_vision,omitempty"`
+
+	// Met lists the players whose units or cities the player has had in
+	// sight, in the order they were first seen
+	Met []string `json:"met,omitempty"`
 }
 
 // PlayerColors defines available colors for players
@@ -35,31 +62,21 @@ var PlayerColors = []string{
 	"#800080", // Purple
 }
 
-// CivilizationNames defines default civilization names
-var CivilizationNames = []string{
-	"Romans",
-	"Egyptians",
-	"Greeks",
-	"Babylonians",
-	"Germans",
-	"Russians",
-	"Chinese",
-	"Americans",
-}
-
 // NewPlayer creates a new player
 func NewPlayer(name string, playerType PlayerType, colorIndex int) *Player {
 	color := PlayerColors[colorIndex%len(PlayerColors)]
 	return &Player{
-		ID:      uuid.New().String(),
-		Name:    name,
-		Type:    playerType,
-		Color:   color,
-		Gold:    StartingGold,
-		Science: 0,
-		Units:   make([]*Unit, 0),
-		Cities:  make([]*City, 0),
-		IsAlive: true,
+		ID:           uuid.New().String(),
+		Name:         name,
+		Type:         playerType,
+		Color:        color,
+		Gold:         StartingGold,
+		Science:      0,
+		TaxRate:      DefaultTaxRate,
+		Units:        make([]*Unit, 0),
+		Cities:       make([]*City, 0),
+		IsAlive:      true,
+		Civilization: colorIndex,
 	}
 }
 
@@ -183,11 +200,19 @@ func (p *Player) ResetUnitsMovement() {
 	for _, u := range p.Units {
 		u.ResetMovement()
 		// Unfortify doesn't happen automatically
+		if u.Cooldown > 0 {
+			u.Cooldown--
+		}
 	}
 }
 
 // CheckAlive updates the IsAlive status based on remaining cities/settlers
 func (p *Player) CheckAlive() {
+	if p.Defeated {
+		p.IsAlive = false
+		return
+	}
+
 	// Player is alive if they have any cities
 	if len(p.Cities) > 0 {
 		p.IsAlive = true
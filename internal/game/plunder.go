@@ -0,0 +1,52 @@
+package game
+
+// A captured city is sacked: its conqueror takes gold for each of its
+// citizens and buildings, the Palace and wonders included, scaled by the
+// game's plunder percent, and the former owner's treasury loses as much
+// as it holds of that. Each capture is recorded in the statistics of the
+// turn it happened on.
+
+// CityCapture is a city taken in battle and the gold plundered from it
+type CityCapture struct {
+	CityID   string `json:"city_id"`
+	CityName string `json:"city_name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Plunder  int    `json:"plunder"`
+}
+
+// plunderPercent returns the percent of a captured city's worth its
+// conqueror takes
+func (c GameConfig) plunderPercent() int {
+	if c.PlunderPercent > 0 {
+		return c.PlunderPercent
+	}
+	return DefaultPlunderPercent
+}
+
+// Plunder returns the gold a city would yield to its conqueror
+func (g *GameState) Plunder(city *City) int {
+	worth := city.Population*PlunderPerCitizen + len(city.Buildings)*PlunderPerBuilding
+	return worth * g.Config.plunderPercent() / 100
+}
+
+// captureCity hands a city to its conqueror, who plunders it first, and
+// records the city and both players in result
+func (g *GameState) captureCity(city *City, conquerorID string, result *Result) CityCapture {
+	result.city(city.ID)
+	result.player(city.OwnerID)
+	result.player(conquerorID)
+	capture := CityCapture{CityID: city.ID, CityName: city.Name, From: city.OwnerID, To: conquerorID, Plunder: g.Plunder(city)}
+	if conqueror := g.GetPlayer(conquerorID); conqueror != nil {
+		conqueror.Gold += capture.Plunder
+	}
+	if former := g.GetPlayer(city.OwnerID); former != nil {
+		former.Gold = max(0, former.Gold-capture.Plunder)
+	}
+	g.TransferCity(city, conquerorID)
+
+	if n := len(g.History); n > 0 && g.History[n-1].Turn == g.CurrentTurn {
+		g.History[n-1].Captures = append(g.History[n-1].Captures, capture)
+	}
+	return capture
+}
@@ -0,0 +1,212 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"sort"
+)
+
+// Kinds of random event
+const (
+	EventPlague     = "plague"
+	EventHarvest    = "harvest"
+	EventEarthquake = "earthquake"
+	EventEruption   = "eruption"
+	EventFlood      = "flood"
+)
+
+// RandomEvent describes a random event that struck one of a player's cities
+type RandomEvent struct {
+	Kind       string       `json:"kind"`
+	Turn       int          `json:"turn"`
+	PlayerID   string       `json:"player_id"`
+	CityID     string       `json:"city_id"`
+	CityName   string       `json:"city_name"`
+	Population int          `json:"population,omitempty"` // City size after a plague
+	Food       int          `json:"food,omitempty"`       // Food added by a harvest
+	Building   BuildingType `json:"building,omitempty"`   // Building an earthquake destroyed
+
+	// An eruption is of the volcano at (X, Y). Its lava turns the land
+	// around into hills and it kills or wounds the units there. A flood
+	// washes away the irrigation along the river.
+	X            int `json:"x,omitempty"`
+	Y            int `json:"y,omitempty"`
+	UnitsLost    int `json:"units_lost,omitempty"`    // Units an eruption killed
+	TilesChanged int `json:"tiles_changed,omitempty"` // Tiles buried in lava or washed out
+}
+
+// randomEventChance returns the percent chance of an event per city and turn
+func (c GameConfig) randomEventChance() int {
+	if c.RandomEventChance > 0 {
+		return c.RandomEventChance
+	}
+	return DefaultRandomEventChance
+}
+
+// rollRandomEvents gives each of a player's cities a chance of a random
+// event when they are enabled. Events are recorded on the event being
+// applied and in the player's report.
+func (g *GameState) rollRandomEvents(player *Player) {
+	if !g.Config.RandomEvents {
+		return
+	}
+
+	chance := g.Config.randomEventChance()
+	for _, city := range player.Cities {
+		if g.rand().IntN(100) >= chance {
+			continue
+		}
+
+		// Only pick among events that would do something to this city
+		kinds := []string{EventHarvest}
+		if city.Population >= PlagueMinPopulation {
+			kinds = append(kinds, EventPlague)
+		}
+		if len(g.destructibleBuildings(city)) > 0 {
+			kinds = append(kinds, EventEarthquake)
+		}
+		volcano := g.nearestVolcano(city)
+		if volcano != nil {
+			kinds = append(kinds, EventEruption)
+		}
+		if g.floodable(city) {
+			kinds = append(kinds, EventFlood)
+		}
+
+		event := RandomEvent{
+			Kind:     kinds[g.rand().IntN(len(kinds))],
+			Turn:     g.CurrentTurn,
+			PlayerID: player.ID,
+			CityID:   city.ID,
+			CityName: city.Name,
+		}
+
+		switch event.Kind {
+		case EventPlague:
+			city.Population -= PlagueLoss
+			if city.Population < 1 {
+				city.Population = 1
+			}
+			event.Population = city.Population
+		case EventHarvest:
+			event.Food = city.FoodNeededForGrowth(g.Config.Speed) * HarvestFoodBonus / 100
+			city.FoodStore += event.Food
+		case EventEarthquake:
+			buildings := g.destructibleBuildings(city)
+			event.Building = buildings[g.rand().IntN(len(buildings))]
+			delete(city.Buildings, event.Building)
+		case EventEruption:
+			event.X, event.Y = volcano.X, volcano.Y
+			event.UnitsLost, event.TilesChanged = g.erupt(volcano)
+			event.Population = city.Population
+		case EventFlood:
+			event.Food, event.TilesChanged = g.flood(city)
+		}
+
+		g.randomEvents = append(g.randomEvents, event)
+		if r := g.report(player.ID); r != nil {
+			r.RandomEvents = append(r.RandomEvents, event)
+		}
+	}
+}
+
+// nearestVolcano returns the volcano nearest a city within its radius, or
+// nil if there is none
+func (g *GameState) nearestVolcano(city *City) *Tile {
+	var nearest *Tile
+	best := CityRadius + 1
+	for _, tile := range g.Map.GetCityRadius(city.X, city.Y) {
+		if d := rules.Distance(tile.X, tile.Y, city.X, city.Y); tile.Volcano && d < best {
+			nearest, best = tile, d
+		}
+	}
+	return nearest
+}
+
+// erupt lets a volcano erupt. Units within EruptionRadius take
+// EruptionDamage and those it kills are lost, cities lose EruptionLoss
+// population, and the open land is buried in lava that cools to hills,
+// irrigation and terrain jobs with it. It returns the units lost and the
+// tiles buried.
+func (g *GameState) erupt(volcano *Tile) (unitsLost, tilesChanged int) {
+	inReach := func(x, y int) bool {
+		return rules.Distance(x, y, volcano.X, volcano.Y) <= EruptionRadius
+	}
+
+	var killed []string
+	for _, p := range g.Players {
+		for _, u := range p.Units {
+			if !inReach(u.X, u.Y) {
+				continue
+			}
+			u.TakeDamage(EruptionDamage)
+			if !u.IsAlive() {
+				killed = append(killed, u.ID)
+			}
+		}
+		for _, city := range p.Cities {
+			if inReach(city.X, city.Y) {
+				city.Population = max(1, city.Population-EruptionLoss)
+			}
+		}
+	}
+	for _, id := range killed {
+		g.RemoveUnit(id)
+	}
+
+	for _, tile := range g.Map.GetTilesInRadius(volcano.X, volcano.Y, EruptionRadius) {
+		if tile == volcano || tile.IsWater() || tile.Terrain == TerrainMountains || g.GetCityAt(tile.X, tile.Y) != nil {
+			continue
+		}
+		tile.Terrain = TerrainHills
+		tile.HasIrrigation = false
+		tile.Job, tile.JobProgress = "", 0
+		tilesChanged++
+	}
+	return len(killed), tilesChanged
+}
+
+// floodable reports whether a flood would do anything to a city on a
+// river: take food from its store or wash away irrigation
+func (g *GameState) floodable(city *City) bool {
+	center := g.Map.GetTile(city.X, city.Y)
+	if center == nil || !center.HasRiver {
+		return false
+	}
+	if city.FoodStore*FloodFoodLoss/100 > 0 {
+		return true
+	}
+	for _, tile := range g.GetCityTiles(city) {
+		if tile.HasRiver && tile.HasIrrigation {
+			return true
+		}
+	}
+	return false
+}
+
+// flood floods the river through a city. It spoils FloodFoodLoss percent
+// of the food in store and washes away the irrigation of the river tiles
+// the city works. It returns the food lost and the tiles washed out.
+func (g *GameState) flood(city *City) (food, tilesChanged int) {
+	food = city.FoodStore * FloodFoodLoss / 100
+	city.FoodStore -= food
+	for _, tile := range g.GetCityTiles(city) {
+		if tile.HasRiver && tile.HasIrrigation {
+			tile.HasIrrigation = false
+			tilesChanged++
+		}
+	}
+	return food, tilesChanged
+}
+
+// destructibleBuildings returns the buildings of a city an earthquake can
+// destroy, in a stable order. Wonders and the Palace survive.
+func (g *GameState) destructibleBuildings(city *City) []BuildingType {
+	buildings := make([]BuildingType, 0, len(city.Buildings))
+	for b, built := range city.Buildings {
+		if built && !b.IsWonder() && b != BuildingPalace {
+			buildings = append(buildings, b)
+		}
+	}
+	sort.Slice(buildings, func(i, j int) bool { return buildings[i] < buildings[j] })
+	return buildings
+}
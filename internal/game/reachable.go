@@ -0,0 +1,81 @@
+package game
+
+// ReachableTile is a tile a unit can move to or attack this turn
+type ReachableTile struct {
+	X            int  `json:"x"`
+	Y            int  `json:"y"`
+	Cost         int  `json:"cost"`          // Movement spent getting there, or next to it to attack
+	MovementLeft int  `json:"movement_left"` // Left on arrival, or before attacking
+	Attack       bool `json:"attack,omitempty"`
+}
+
+// ReachableTiles lists the tiles one of a player's units can reach this
+// turn, one move after another, the cheapest way, in the order they are
+// found. Tiles holding enemy units or cities are attacked rather than
+// entered, so the unit does not move on through them; nuclear units, which
+// only detonate, attack nothing.
+func (g *GameState) ReachableTiles(playerID, unitID string) ([]ReachableTile, error) {
+	unit, err := g.ownUnit(playerID, unitID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := g.Map.Index(unit.X, unit.Y)
+	left := map[int]int{start: unit.MovementLeft} // Most movement left on reaching each tile
+	order := make([]int, 0)
+	attacks := make(map[int]int) // Most movement left next to each tile attacked
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		here := g.Map.CoordOf(current)
+		movement := left[current]
+		if movement <= 0 {
+			continue
+		}
+
+		for _, n := range here.Neighbors() {
+			if !g.Map.Contains(n) {
+				continue
+			}
+			next := g.Map.Index(n.X, n.Y)
+			if g.isAttackTarget(playerID, n.X, n.Y) {
+				best, seen := attacks[next]
+				if unit.IsNuclear() || (seen && movement <= best) {
+					continue
+				}
+				if !seen {
+					order = append(order, next)
+				}
+				attacks[next] = movement
+				continue
+			}
+			if !g.isValidStep(unit, here.X, here.Y, movement, n.X, n.Y) {
+				continue
+			}
+
+			after := max(movement-g.GetMovementCost(here.X, here.Y, n.X, n.Y), 0)
+			best, seen := left[next]
+			if seen && after <= best {
+				continue
+			}
+			if !seen {
+				order = append(order, next)
+			}
+			left[next] = after
+			queue = append(queue, next)
+		}
+	}
+
+	tiles := make([]ReachableTile, len(order))
+	for i, index := range order {
+		c := g.Map.CoordOf(index)
+		movement, attack := attacks[index]
+		if !attack {
+			movement = left[index]
+		}
+		tiles[i] = ReachableTile{X: c.X, Y: c.Y, Cost: unit.MovementLeft - movement, MovementLeft: movement, Attack: attack}
+	}
+	return tiles, nil
+}
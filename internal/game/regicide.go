@@ -0,0 +1,53 @@
+package game
+
+// In a regicide game every player starts with a King. A player whose King
+// is lost, in battle or any other way, is out of the game whatever cities
+// and units they have left; these stand as they are for others to take.
+// Kings cannot be built or bought, so a lost King is never replaced.
+
+// King returns the player's King, or nil
+func (p *Player) King() *Unit {
+	for _, u := range p.Units {
+		if u.IsKing() {
+			return u
+		}
+	}
+	return nil
+}
+
+// crownKings gives every player without one a King, in their capital or
+// else with their first unit
+func (g *GameState) crownKings() {
+	for _, player := range g.Players {
+		if player.King() != nil {
+			continue
+		}
+		var x, y int
+		switch capital := player.Capital(); {
+		case capital != nil:
+			x, y = capital.X, capital.Y
+		case len(player.Cities) > 0:
+			x, y = player.Cities[0].X, player.Cities[0].Y
+		case len(player.Units) > 0:
+			x, y = player.Units[0].X, player.Units[0].Y
+		default:
+			continue
+		}
+		king := NewUnit(UnitKing, player.ID, x, y)
+		king.ID = g.newID()
+		player.AddUnit(king)
+	}
+}
+
+// loseKing puts a player whose King was lost out of the game
+func (g *GameState) loseKing(player *Player, king *Unit) {
+	if !g.Config.Regicide || player.Defeated {
+		return
+	}
+	player.Defeated = true
+	player.CheckAlive()
+	g.publish(KingLost{PlayerID: player.ID, UnitID: king.ID, X: king.X, Y: king.Y})
+
+	// The last King standing wins at once
+	g.checkVictory()
+}
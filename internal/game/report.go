@@ -0,0 +1,319 @@
+package game
+
+// CityReport describes what happened to a city at the end of a turn
+type CityReport struct {
+	CityID     string `json:"city_id"`
+	CityName   string `json:"city_name"`
+	Population int    `json:"population"`
+	Granary    int    `json:"granary,omitempty"` // Food a granary kept as the city grew
+}
+
+// CompletedReport describes an item a city finished building
+type CompletedReport struct {
+	CityID   string `json:"city_id"`
+	CityName string `json:"city_name"`
+	Item     string `json:"item"`
+	UnitID   string `json:"unit_id,omitempty"` // Set when the item is a unit
+}
+
+// CombatReport describes an attack made against the player
+type CombatReport struct {
+	Turn         int      `json:"turn"`
+	AttackerID   string   `json:"attacker_id"`
+	AttackerName string   `json:"attacker_name"` // Attacking player's name
+	AttackerUnit UnitType `json:"attacker_unit"`
+	AttackerCity string   `json:"attacker_city,omitempty"` // City that fired a strike, AttackerUnit is unset
+	DefenderUnit UnitType `json:"defender_unit"`
+	Undefended   bool     `json:"undefended"` // No unit defended, DefenderUnit is unset
+	X            int      `json:"x"`
+	Y            int      `json:"y"`
+	DefenderWon  bool     `json:"defender_won"`
+	UnitLost     bool     `json:"unit_lost"`
+	Withdrew     bool     `json:"withdrew,omitempty"`    // The losing unit withdrew instead of being destroyed
+	StackLost    int      `json:"stack_lost,omitempty"`  // Other units destroyed with the defender
+	CityLost     string   `json:"city_lost,omitempty"`   // Name of a captured city
+	Bombard      bool     `json:"bombard,omitempty"`     // Ranged attack, nothing could strike back
+	Damage       int      `json:"damage,omitempty"`      // Health the defender lost to a ranged attack
+	CityDamage   int      `json:"city_damage,omitempty"` // Defense points the city lost
+}
+
+// DetonationReport describes a nuclear detonation anywhere in the world.
+// Every player hears of it; UnitsLost and CitiesHit cover their own losses.
+type DetonationReport struct {
+	Turn         int          `json:"turn"`
+	AttackerID   string       `json:"attacker_id"`
+	AttackerName string       `json:"attacker_name"`
+	X            int          `json:"x"`
+	Y            int          `json:"y"`
+	UnitsLost    int          `json:"units_lost"`
+	CitiesHit    []CityReport `json:"cities_hit"` // Population is after the blast
+}
+
+// ResourceReport describes a resource newly within reach of a city
+type ResourceReport struct {
+	Resource ResourceType `json:"resource"`
+	X        int          `json:"x"`
+	Y        int          `json:"y"`
+	CityName string       `json:"city_name"`
+}
+
+// UnitNotice describes an automated unit that stopped and needs orders
+type UnitNotice struct {
+	UnitID   string   `json:"unit_id"`
+	UnitType UnitType `json:"unit_type"`
+	X        int      `json:"x"`
+	Y        int      `json:"y"`
+	Reason   string   `json:"reason"`
+}
+
+// TurnReport summarizes everything that happened to a player between the
+// end of their turn and the start of their next one
+type TurnReport struct {
+	PlayerID            string             `json:"player_id"`
+	Turn                int                `json:"turn"` // Turn the report starts at
+	CitiesGrown         []CityReport       `json:"cities_grown"`
+	CitiesStarved       []CityReport       `json:"cities_starved"`
+	CitiesInDisorder    []CityReport       `json:"cities_in_disorder"`
+	CitiesCelebrating   []CityReport       `json:"cities_celebrating"`
+	Completed           []CompletedReport  `json:"completed"`
+	CombatsAgainst      []CombatReport     `json:"combats_against"`
+	ResourcesDiscovered []ResourceReport   `json:"resources_discovered"`
+	UnitsWoken          []UnitNotice       `json:"units_woken"`
+	Detonations         []DetonationReport `json:"detonations"`
+	RandomEvents        []RandomEvent      `json:"random_events"`
+	ScenarioNotices     []ScenarioNotice   `json:"scenario_notices"`
+	TradeGold           int                `json:"trade_gold"`        // Earned by cities joined to the capital by road
+	Economy             *EconomyReport     `json:"economy,omitempty"` // The last turn's taxes, science and upkeep
+}
+
+// newTurnReport creates an empty report for a player
+func newTurnReport(playerID string, turn int) *TurnReport {
+	return &TurnReport{
+		PlayerID:            playerID,
+		Turn:                turn,
+		CitiesGrown:         make([]CityReport, 0),
+		CitiesStarved:       make([]CityReport, 0),
+		CitiesInDisorder:    make([]CityReport, 0),
+		CitiesCelebrating:   make([]CityReport, 0),
+		Completed:           make([]CompletedReport, 0),
+		CombatsAgainst:      make([]CombatReport, 0),
+		ResourcesDiscovered: make([]ResourceReport, 0),
+		UnitsWoken:          make([]UnitNotice, 0),
+		Detonations:         make([]DetonationReport, 0),
+		RandomEvents:        make([]RandomEvent, 0),
+		ScenarioNotices:     make([]ScenarioNotice, 0),
+	}
+}
+
+// TakeTurnReport returns the report collected for a player since it was
+// last taken and starts a new one. Reports are only kept for human players.
+func (g *GameState) TakeTurnReport(playerID string) *TurnReport {
+	r := g.report(playerID)
+	if r != nil {
+		delete(g.reports, playerID)
+	}
+	return r
+}
+
+// report returns the report being collected for a player, or nil for
+// players that nobody reads reports for
+func (g *GameState) report(playerID string) *TurnReport {
+	player := g.GetPlayer(playerID)
+	if player == nil || player.Type != PlayerHuman {
+		return nil
+	}
+
+	if g.reports == nil {
+		g.reports = make(map[string]*TurnReport)
+	}
+	r, ok := g.reports[playerID]
+	if !ok {
+		r = newTurnReport(playerID, g.CurrentTurn)
+		g.reports[playerID] = r
+	}
+	return r
+}
+
+// reportCombat records an attack in the defending player's report
+func (g *GameState) reportCombat(attacker, defender *Unit, x, y int, result CombatResult, stackLost int, capturedCity *City) {
+	r := g.report(defender.OwnerID)
+	if r == nil {
+		return
+	}
+
+	r.CombatsAgainst = append(r.CombatsAgainst, CombatReport{
+		Turn:         g.CurrentTurn,
+		AttackerID:   attacker.OwnerID,
+		AttackerName: g.playerName(attacker.OwnerID),
+		AttackerUnit: attacker.Type,
+		DefenderUnit: defender.Type,
+		X:            x,
+		Y:            y,
+		DefenderWon:  !result.AttackerWon,
+		UnitLost:     result.DefenderDestroyed,
+		Withdrew:     result.AttackerWithdrew || result.DefenderWithdrew,
+		StackLost:    stackLost,
+		CityLost:     cityName(capturedCity),
+	})
+}
+
+// reportCityAssault records an assault on a city no unit defended
+func (g *GameState) reportCityAssault(attacker *Unit, city *City, previousOwnerID string, result CombatResult) {
+	r := g.report(previousOwnerID)
+	if r == nil {
+		return
+	}
+
+	entry := CombatReport{
+		Turn:         g.CurrentTurn,
+		AttackerID:   attacker.OwnerID,
+		AttackerName: g.playerName(attacker.OwnerID),
+		AttackerUnit: attacker.Type,
+		Undefended:   true,
+		X:            city.X,
+		Y:            city.Y,
+		DefenderWon:  !result.AttackerWon,
+		CityDamage:   result.DefenderDamage,
+	}
+	if result.AttackerWon {
+		entry.CityLost = city.Name
+	}
+	r.CombatsAgainst = append(r.CombatsAgainst, entry)
+}
+
+// reportBombard records a bombardment by a unit, or a strike by a city, in
+// the target owner's report
+func (g *GameState) reportBombard(attacker *Unit, attackerCity *City, defender *Unit, city *City, x, y int, result BombardResult) {
+	ownerID := ""
+	if defender != nil {
+		ownerID = defender.OwnerID
+	} else if city != nil {
+		ownerID = city.OwnerID
+	}
+
+	r := g.report(ownerID)
+	if r == nil {
+		return
+	}
+
+	entry := CombatReport{
+		Turn:        g.CurrentTurn,
+		Undefended:  defender == nil,
+		X:           x,
+		Y:           y,
+		DefenderWon: result.Hits == 0,
+		Bombard:     true,
+		Damage:      result.DefenderHurt,
+		CityDamage:  result.CityDamage,
+	}
+	if attacker != nil {
+		entry.AttackerID = attacker.OwnerID
+		entry.AttackerUnit = attacker.Type
+	} else {
+		entry.AttackerID = attackerCity.OwnerID
+		entry.AttackerCity = attackerCity.Name
+	}
+	entry.AttackerName = g.playerName(entry.AttackerID)
+	if defender != nil {
+		entry.DefenderUnit = defender.Type
+	}
+	r.CombatsAgainst = append(r.CombatsAgainst, entry)
+}
+
+// reportDetonation records a nuclear detonation in every other player's report
+func (g *GameState) reportDetonation(missile *Unit, x, y int, result NukeResult) {
+	for _, p := range g.Players {
+		if p.ID == missile.OwnerID {
+			continue
+		}
+		r := g.report(p.ID)
+		if r == nil {
+			continue
+		}
+
+		entry := DetonationReport{
+			Turn:         g.CurrentTurn,
+			AttackerID:   missile.OwnerID,
+			AttackerName: g.playerName(missile.OwnerID),
+			X:            x,
+			Y:            y,
+			CitiesHit:    make([]CityReport, 0),
+		}
+		for _, u := range result.UnitsDestroyed {
+			if u.OwnerID == p.ID {
+				entry.UnitsLost++
+			}
+		}
+		for _, city := range result.CitiesHit {
+			if city.OwnerID == p.ID {
+				entry.CitiesHit = append(entry.CitiesHit, CityReport{CityID: city.ID, CityName: city.Name, Population: city.Population})
+			}
+		}
+		r.Detonations = append(r.Detonations, entry)
+	}
+}
+
+// reportResources records the resources a newly founded city brings within
+// reach that none of the player's other cities already covered, leaving
+// out those the player cannot see yet
+func (g *GameState) reportResources(player *Player, city *City) {
+	r := g.report(player.ID)
+	if r == nil {
+		return
+	}
+
+	covered := make(map[*Tile]bool)
+	for _, other := range player.Cities {
+		if other.ID == city.ID {
+			continue
+		}
+		for _, tile := range g.GetCityTiles(other) {
+			covered[tile] = true
+		}
+	}
+
+	// The new city works no tiles until borders are next updated
+	for _, tile := range g.Map.GetCityRadius(city.X, city.Y) {
+		if tile.Resource == ResourceNone || covered[tile] || !g.SeesResource(player, tile.Resource) {
+			continue
+		}
+		r.ResourcesDiscovered = append(r.ResourcesDiscovered, ResourceReport{
+			Resource: tile.Resource,
+			X:        tile.X,
+			Y:        tile.Y,
+			CityName: city.Name,
+		})
+	}
+}
+
+// reportUnitNotice records that an automated unit needs orders
+func (g *GameState) reportUnitNotice(unit *Unit, reason string) {
+	r := g.report(unit.OwnerID)
+	if r == nil {
+		return
+	}
+
+	r.UnitsWoken = append(r.UnitsWoken, UnitNotice{
+		UnitID:   unit.ID,
+		UnitType: unit.Type,
+		X:        unit.X,
+		Y:        unit.Y,
+		Reason:   reason,
+	})
+}
+
+// playerName returns the name of a player, or "" if not found
+func (g *GameState) playerName(playerID string) string {
+	if p := g.GetPlayer(playerID); p != nil {
+		return p.Name
+	}
+	return ""
+}
+
+// cityName returns the name of a city, or "" for nil
+func cityName(city *City) string {
+	if city == nil {
+		return ""
+	}
+	return city.Name
+}
@@ -0,0 +1,85 @@
+package game
+
+// The game has no research for players to direct yet, but the science
+// they gather carries them through the technologies in order of cost: a
+// player knows a technology once the science they have gathered in all
+// reaches its cost at the game's speed. Strategic resources lie hidden
+// from a player until they know the technology that reveals them.
+
+// Technology is an advance in knowledge
+type Technology int
+
+const (
+	TechNone Technology = iota
+	TechBronzeWorking
+	TechRefining
+	TechNuclearFission
+)
+
+// Technologies lists the technologies in order of cost
+var Technologies = []Technology{TechBronzeWorking, TechRefining, TechNuclearFission}
+
+// String returns the technology's name
+func (t Technology) String() string {
+	switch t {
+	case TechBronzeWorking:
+		return "Bronze Working"
+	case TechRefining:
+		return "Refining"
+	case TechNuclearFission:
+		return "Nuclear Fission"
+	default:
+		return "None"
+	}
+}
+
+// TechCost is the science a player gathers to know each technology at
+// standard speed
+var TechCost = map[Technology]int{
+	TechBronzeWorking:  60,
+	TechRefining:       1200,
+	TechNuclearFission: 3000,
+}
+
+// RevealedBy is the technology a player must know to see each strategic
+// resource. Other resources are always seen.
+var RevealedBy = map[ResourceType]Technology{
+	ResourceIron:    TechBronzeWorking,
+	ResourceOil:     TechRefining,
+	ResourceUranium: TechNuclearFission,
+}
+
+// Knows reports whether a player knows a technology
+func (g *GameState) Knows(player *Player, tech Technology) bool {
+	return tech == TechNone || player.Science >= ScaleCost(TechCost[tech], g.Config.Speed)
+}
+
+// TechCount returns how many technologies a player knows
+func (g *GameState) TechCount(player *Player) int {
+	count := 0
+	for _, tech := range Technologies {
+		if g.Knows(player, tech) {
+			count++
+		}
+	}
+	return count
+}
+
+// SeesResource reports whether a player can see a resource. With no
+// player, as for spectators and saves, every resource is seen.
+func (g *GameState) SeesResource(player *Player, resource ResourceType) bool {
+	return player == nil || g.Knows(player, RevealedBy[resource])
+}
+
+// discoveries returns the technologies a player came to know when their
+// science rose from before to what it is now
+func (g *GameState) discoveries(player *Player, before int) []Technology {
+	var learned []Technology
+	for _, tech := range Technologies {
+		cost := ScaleCost(TechCost[tech], g.Config.Speed)
+		if before < cost && player.Science >= cost {
+			learned = append(learned, tech)
+		}
+	}
+	return learned
+}
@@ -0,0 +1,87 @@
+package game
+
+import "slices"
+
+// Executing an action returns a Result of what it did: the units, cities,
+// players and tiles it changed, the units it removed and what it spent.
+// Apply adds the bus events published meanwhile and keeps the result with
+// the action's event, so the log records the effects of each action and
+// the API can tell clients of them without sending the whole game. Turn
+// ends and random events change too much to list, and mark the result as
+// changing the whole game instead.
+
+// Result is what an action did to the game
+type Result struct {
+	Units   []string `json:"units,omitempty"`   // Units changed or created
+	Removed []string `json:"removed,omitempty"` // Units removed from the game
+	Cities  []string `json:"cities,omitempty"`  // Cities changed or founded
+	Players []string `json:"players,omitempty"` // Players whose treasury, research, diplomacy or standing changed
+	Tiles   []int    `json:"tiles,omitempty"`   // Tiles changed, by index into Map.Tiles
+	Spent   Spending `json:"spent,omitzero"`
+
+	// Whole is set when the action changed more of the game than it
+	// lists, as ending a turn does
+	Whole bool `json:"whole,omitempty"`
+
+	// Events are what the game published as the action was applied. Like
+	// the bus they are for telling, not for the record.
+	Events []BusEvent `json:"-"`
+}
+
+// Spending is what an action cost
+type Spending struct {
+	Movement    int `json:"movement,omitempty"`     // Movement points used by units
+	Gold        int `json:"gold,omitempty"`         // Gold paid
+	Shields     int `json:"shields,omitempty"`      // Shields given to production
+	StartPoints int `json:"start_points,omitempty"` // Advanced start points spent
+}
+
+// The recording methods are safe on a nil Result, which records nothing,
+// so helpers shared with the end of the turn can record as they go.
+
+// unit records a unit as changed
+func (r *Result) unit(id string) {
+	if r != nil && !slices.Contains(r.Units, id) && !slices.Contains(r.Removed, id) {
+		r.Units = append(r.Units, id)
+	}
+}
+
+// removed records a unit as removed from the game
+func (r *Result) removed(id string) {
+	if r == nil || slices.Contains(r.Removed, id) {
+		return
+	}
+	r.Units = slices.DeleteFunc(r.Units, func(u string) bool { return u == id })
+	r.Removed = append(r.Removed, id)
+}
+
+// city records a city as changed
+func (r *Result) city(id string) {
+	if r != nil && !slices.Contains(r.Cities, id) {
+		r.Cities = append(r.Cities, id)
+	}
+}
+
+// player records a player as changed
+func (r *Result) player(id string) {
+	if r != nil && id != "" && !slices.Contains(r.Players, id) {
+		r.Players = append(r.Players, id)
+	}
+}
+
+// tile records the tile at (x, y) as changed
+func (r *Result) tile(m *GameMap, x, y int) {
+	if r == nil {
+		return
+	}
+	if i := m.Index(x, y); i >= 0 && !slices.Contains(r.Tiles, i) {
+		r.Tiles = append(r.Tiles, i)
+	}
+}
+
+// spendMovement records movement points a unit used
+func (r *Result) spendMovement(points int) {
+	if r != nil && points > 0 {
+		r.Spent.Movement += points
+	}
+}
@@ -0,0 +1,88 @@
+package game
+
+// Roads form a network: a unit moving between two road tiles pays
+// RoadMovementCost whatever the terrain, and cities joined to their
+// owner's capital by road earn TradeRouteGold each turn. The network is
+// cached on the map as the connected component of every road tile, and
+// rebuilt whenever a road is laid or the map is loaded.
+
+// SetRoad lays a road on a tile and rebuilds the road network. Roads must
+// be laid through it, not by setting Tile.HasRoad, for the network to see
+// them.
+func (gm *GameMap) SetRoad(x, y int) {
+	tile := gm.GetTile(x, y)
+	if tile == nil || tile.HasRoad {
+		return
+	}
+	tile.HasRoad = true
+	gm.UpdateRoads()
+}
+
+// UpdateRoads rebuilds the road network from the tiles' roads. Road tiles
+// are joined to the road tiles around them, diagonals included, as units
+// move.
+func (gm *GameMap) UpdateRoads() {
+	network := make([]int32, len(gm.Tiles))
+	for i := range network {
+		network[i] = -1
+	}
+
+	var next int32
+	queue := make([]int, 0)
+	for start := range gm.Tiles {
+		if !gm.Tiles[start].HasRoad || network[start] >= 0 {
+			continue
+		}
+
+		network[start] = next
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			tile := &gm.Tiles[queue[0]]
+			queue = queue[1:]
+			for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+				i := gm.Index(n.X, n.Y)
+				if n.HasRoad && network[i] < 0 {
+					network[i] = next
+					queue = append(queue, i)
+				}
+			}
+		}
+		next++
+	}
+
+	gm.roads = network
+}
+
+// RoadsConnect reports whether roads lead from one tile to the other
+func (gm *GameMap) RoadsConnect(fromX, fromY, toX, toY int) bool {
+	if !gm.IsValidCoord(fromX, fromY) || !gm.IsValidCoord(toX, toY) || gm.roads == nil {
+		return false
+	}
+	from := gm.roads[gm.Index(fromX, fromY)]
+	return from >= 0 && from == gm.roads[gm.Index(toX, toY)]
+}
+
+// Capital returns the city a player's trade routes lead to: the one with
+// their Palace, or nil when they have none
+func (p *Player) Capital() *City {
+	for _, city := range p.Cities {
+		if city.HasBuilding(BuildingPalace) {
+			return city
+		}
+	}
+	return nil
+}
+
+// ConnectedToCapital reports whether roads join a city to its owner's
+// capital. The capital counts as connected to itself.
+func (g *GameState) ConnectedToCapital(city *City) bool {
+	owner := g.GetPlayer(city.OwnerID)
+	if owner == nil {
+		return false
+	}
+	capital := owner.Capital()
+	if capital == nil {
+		return false
+	}
+	return capital.ID == city.ID || g.Map.RoadsConnect(capital.X, capital.Y, city.X, city.Y)
+}
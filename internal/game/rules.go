@@ -0,0 +1,364 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Rules is a set of unit, building, terrain and resource definitions
+// loaded from a rules directory to mod the game. Entries are matched by
+// name and replace the built-in definition; a unit with a new name adds a
+// unit type.
+type Rules struct {
+	Units     []UnitRule     `json:"units,omitempty"`
+	Buildings []BuildingRule `json:"buildings,omitempty"`
+	Terrain   []TerrainRule  `json:"terrain,omitempty"`
+	Resources []ResourceRule `json:"resources,omitempty"`
+}
+
+// UnitRule defines a unit type
+type UnitRule struct {
+	Name           string `json:"name"`
+	Attack         int    `json:"attack"`
+	Defense        int    `json:"defense"`
+	Movement       int    `json:"movement"`
+	Sight          int    `json:"sight,omitempty"` // 0 for SightRadius
+	Cost           int    `json:"cost"`
+	Naval          bool   `json:"naval,omitempty"`
+	FoundsCities   bool   `json:"founds_cities,omitempty"`
+	BuildsRoads    bool   `json:"builds_roads,omitempty"`
+	Siege          bool   `json:"siege,omitempty"`
+	Nuclear        bool   `json:"nuclear,omitempty"`
+	HitAndRun      bool   `json:"hit_and_run,omitempty"`
+	FirstStrike    int    `json:"first_strike,omitempty"`
+	HelpsWonder    bool   `json:"helps_wonder,omitempty"`
+	King           bool   `json:"king,omitempty"`
+	RequiresWonder string `json:"requires_wonder,omitempty"`
+}
+
+// BuildingRule sets the cost of a building. What buildings do is part of
+// the game itself, so only existing buildings can be changed.
+type BuildingRule struct {
+	Name string `json:"name"`
+	Cost int    `json:"cost"`
+}
+
+// TerrainRule sets the movement, defense and yields of a terrain type
+type TerrainRule struct {
+	Name         string  `json:"name"`
+	MovementCost int     `json:"movement_cost"`
+	DefenseBonus float64 `json:"defense_bonus"`
+	Food         int     `json:"food"`
+	Production   int     `json:"production"`
+}
+
+// ResourceRule sets the yield bonus of a resource and where it is placed
+type ResourceRule struct {
+	Name       string   `json:"name"`
+	Food       int      `json:"food"`
+	Production int      `json:"production"`
+	Trade      int      `json:"trade"`
+	Terrain    []string `json:"terrain"`
+}
+
+// LoadRules reads every .json file in dir, in name order, into one set of
+// rules. Later files override earlier ones.
+func LoadRules(dir string) (*Rules, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no rules files in %s", dir)
+	}
+	sort.Strings(files)
+
+	rules := &Rules{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var part Rules
+		decoder := json.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&part)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+
+		rules.Units = append(rules.Units, part.Units...)
+		rules.Buildings = append(rules.Buildings, part.Buildings...)
+		rules.Terrain = append(rules.Terrain, part.Terrain...)
+		rules.Resources = append(rules.Resources, part.Resources...)
+	}
+
+	return rules, nil
+}
+
+// Validate checks that every definition is complete and only refers to
+// things that exist
+func (r *Rules) Validate() error {
+	for _, u := range r.Units {
+		switch {
+		case u.Name == "":
+			return fmt.Errorf("unit without a name")
+		case u.Attack < 0 || u.Defense < 0:
+			return fmt.Errorf("unit %q: attack and defense must not be negative", u.Name)
+		case u.Movement < 1:
+			return fmt.Errorf("unit %q: movement must be at least 1", u.Name)
+		case u.Sight < 0:
+			return fmt.Errorf("unit %q: sight must not be negative", u.Name)
+		case u.Cost < 1 && !u.King:
+			return fmt.Errorf("unit %q: cost must be at least 1", u.Name)
+		}
+		if u.RequiresWonder != "" {
+			wonder, ok := BuildingTypeByName(u.RequiresWonder)
+			if !ok || !wonder.IsWonder() {
+				return fmt.Errorf("unit %q: %q is not a wonder", u.Name, u.RequiresWonder)
+			}
+		}
+	}
+
+	for _, b := range r.Buildings {
+		if _, ok := BuildingTypeByName(b.Name); !ok {
+			return fmt.Errorf("unknown building %q", b.Name)
+		}
+		if b.Cost < 1 {
+			return fmt.Errorf("building %q: cost must be at least 1", b.Name)
+		}
+	}
+
+	for _, t := range r.Terrain {
+		switch _, ok := TerrainTypeByName(t.Name); {
+		case !ok:
+			return fmt.Errorf("unknown terrain %q", t.Name)
+		case t.MovementCost < 1:
+			return fmt.Errorf("terrain %q: movement cost must be at least 1", t.Name)
+		case t.DefenseBonus <= 0:
+			return fmt.Errorf("terrain %q: defense bonus must be positive", t.Name)
+		case t.Food < 0 || t.Production < 0:
+			return fmt.Errorf("terrain %q: yields must not be negative", t.Name)
+		}
+	}
+
+	for _, res := range r.Resources {
+		switch _, ok := ResourceTypeByName(res.Name); {
+		case !ok:
+			return fmt.Errorf("unknown resource %q", res.Name)
+		case res.Food < 0 || res.Production < 0 || res.Trade < 0:
+			return fmt.Errorf("resource %q: yields must not be negative", res.Name)
+		case len(res.Terrain) == 0:
+			return fmt.Errorf("resource %q: needs at least one terrain", res.Name)
+		}
+		for _, name := range res.Terrain {
+			if _, ok := TerrainTypeByName(name); !ok {
+				return fmt.Errorf("resource %q: unknown terrain %q", res.Name, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Apply validates the rules and replaces the matching definitions. It must
+// be called at startup, before any game is created.
+func (r *Rules) Apply() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	for _, u := range r.Units {
+		unitType, ok := UnitTypeByName(u.Name)
+		if !ok {
+			unitType = nextUnitType()
+		}
+		template := UnitTemplate{
+			Type:         unitType,
+			Name:         u.Name,
+			Attack:       u.Attack,
+			Defense:      u.Defense,
+			Movement:     u.Movement,
+			Sight:        u.Sight,
+			Cost:         u.Cost,
+			IsNaval:      u.Naval,
+			CanFoundCity: u.FoundsCities,
+			CanBuildRoad: u.BuildsRoads,
+			IsSiege:      u.Siege,
+			IsNuclear:    u.Nuclear,
+			HitAndRun:    u.HitAndRun,
+			FirstStrike:  u.FirstStrike,
+			HelpsWonder:  u.HelpsWonder,
+			IsKing:       u.King,
+		}
+		if template.Sight == 0 {
+			template.Sight = SightRadius
+		}
+		if u.RequiresWonder != "" {
+			template.RequiresWonder, _ = BuildingTypeByName(u.RequiresWonder)
+		}
+		UnitTemplates[unitType] = template
+	}
+
+	for _, b := range r.Buildings {
+		building, _ := BuildingTypeByName(b.Name)
+		BuildingCosts[building] = b.Cost
+	}
+
+	for _, t := range r.Terrain {
+		terrain, _ := TerrainTypeByName(t.Name)
+		TerrainMovementCost[terrain] = t.MovementCost
+		TerrainDefenseBonus[terrain] = t.DefenseBonus
+		TerrainFoodYield[terrain] = t.Food
+		TerrainProductionYield[terrain] = t.Production
+	}
+
+	for _, res := range r.Resources {
+		resource, _ := ResourceTypeByName(res.Name)
+		ResourceBonuses[resource] = ResourceBonus{Food: res.Food, Production: res.Production, Trade: res.Trade}
+		terrains := make([]TerrainType, len(res.Terrain))
+		for i, name := range res.Terrain {
+			terrains[i], _ = TerrainTypeByName(name)
+		}
+		ValidTerrainForResource[resource] = terrains
+	}
+
+	return nil
+}
+
+// CurrentRules returns the definitions in play, in the same form rules
+// files use. It is a starting point for writing a mod.
+func CurrentRules() *Rules {
+	rules := &Rules{}
+
+	for _, t := range UnitTypes() {
+		rules.Units = append(rules.Units, UnitTemplates[t].Rule())
+	}
+
+	for _, b := range BuildingTypes() {
+		rules.Buildings = append(rules.Buildings, BuildingRule{Name: b.String(), Cost: BuildingCosts[b]})
+	}
+
+	for t := TerrainOcean; t <= TerrainForest; t++ {
+		rules.Terrain = append(rules.Terrain, TerrainRule{
+			Name:         t.String(),
+			MovementCost: TerrainMovementCost[t],
+			DefenseBonus: TerrainDefenseBonus[t],
+			Food:         TerrainFoodYield[t],
+			Production:   TerrainProductionYield[t],
+		})
+	}
+
+	for r := ResourceOil; r <= ResourceFurs; r++ {
+		bonus := ResourceBonuses[r]
+		rule := ResourceRule{Name: r.String(), Food: bonus.Food, Production: bonus.Production, Trade: bonus.Trade}
+		for _, t := range ValidTerrainForResource[r] {
+			rule.Terrain = append(rule.Terrain, t.String())
+		}
+		rules.Resources = append(rules.Resources, rule)
+	}
+
+	return rules
+}
+
+// Rule returns the rules file definition of a unit template
+func (t UnitTemplate) Rule() UnitRule {
+	rule := UnitRule{
+		Name:         t.Name,
+		Attack:       t.Attack,
+		Defense:      t.Defense,
+		Movement:     t.Movement,
+		Sight:        t.Sight,
+		Cost:         t.Cost,
+		Naval:        t.IsNaval,
+		FoundsCities: t.CanFoundCity,
+		BuildsRoads:  t.CanBuildRoad,
+		Siege:        t.IsSiege,
+		Nuclear:      t.IsNuclear,
+		HitAndRun:    t.HitAndRun,
+		FirstStrike:  t.FirstStrike,
+		HelpsWonder:  t.HelpsWonder,
+		King:         t.IsKing,
+	}
+	if t.RequiresWonder != BuildingNone {
+		rule.RequiresWonder = t.RequiresWonder.String()
+	}
+	return rule
+}
+
+// UnitTypes returns all unit types in order
+func UnitTypes() []UnitType {
+	types := make([]UnitType, 0, len(UnitTemplates))
+	for t := range UnitTemplates {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// BuildingTypes returns all building types in order
+func BuildingTypes() []BuildingType {
+	types := make([]BuildingType, 0, len(BuildingCosts))
+	for b := range BuildingCosts {
+		types = append(types, b)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// nextUnitType returns an unused unit type for a unit added by a mod
+func nextUnitType() UnitType {
+	next := UnitType(0)
+	for t := range UnitTemplates {
+		if t >= next {
+			next = t + 1
+		}
+	}
+	return next
+}
+
+// UnitTypeByName finds a unit type by its template name, ignoring case
+func UnitTypeByName(name string) (UnitType, bool) {
+	for t, template := range UnitTemplates {
+		if strings.EqualFold(template.Name, name) {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// BuildingTypeByName finds a building type by name
+func BuildingTypeByName(name string) (BuildingType, bool) {
+	for b := range BuildingCosts {
+		if b.String() == name {
+			return b, true
+		}
+	}
+	return BuildingNone, false
+}
+
+// TerrainTypeByName finds a terrain type by name
+func TerrainTypeByName(name string) (TerrainType, bool) {
+	for t := TerrainOcean; t <= TerrainForest; t++ {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// ResourceTypeByName finds a resource type by name
+func ResourceTypeByName(name string) (ResourceType, bool) {
+	for r := ResourceOil; r <= ResourceFurs; r++ {
+		if r.String() == name {
+			return r, true
+		}
+	}
+	return ResourceNone, false
+}
@@ -0,0 +1,34 @@
+// Package rules holds the rules of play that the game checks actions
+// against and the AI plans by, so that the AI never proposes what the game
+// would refuse. It depends on nothing else in the game.
+package rules
+
+// MinCityDistance is how near to each other cities may be founded: no
+// city may stand next to another
+const MinCityDistance = 2
+
+// Distance returns how many moves apart two tiles are. Units move
+// diagonally as well as straight, so it is the larger of the distances
+// along each axis.
+func Distance(x1, y1, x2, y2 int) int {
+	return max(abs(x2-x1), abs(y2-y1))
+}
+
+// Adjacent reports whether two tiles are next to each other, diagonally
+// included: the tiles a unit can move to or attack in one step
+func Adjacent(x1, y1, x2, y2 int) bool {
+	return Distance(x1, y1, x2, y2) == 1
+}
+
+// TooCloseForCity reports whether a city founded at (x, y) would stand too
+// near a city at (cityX, cityY)
+func TooCloseForCity(x, y, cityX, cityY int) bool {
+	return Distance(x, y, cityX, cityY) < MinCityDistance
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
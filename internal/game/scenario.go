@@ -0,0 +1,282 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Scenario condition types
+const (
+	ConditionHoldsCity   = "holds_city"   // Player holds the named city
+	ConditionCityCount   = "city_count"   // Player has at least Count cities
+	ConditionEliminated  = "eliminated"   // Player is out of the game
+	ConditionTurnReached = "turn_reached" // Turn Count has begun
+)
+
+// Scenario outcome types
+const (
+	OutcomeVictory = "victory" // Player wins and the game ends
+	OutcomeDefeat  = "defeat"  // Player is out of the game
+	OutcomeEvent   = "event"   // Message to every player, optionally with gold
+)
+
+// ScenarioPlayerHuman refers to the human player in a scenario file
+const ScenarioPlayerHuman = "human"
+
+// Scenario is a set of triggers loaded from a scenario file. Triggers are
+// checked in order as each turn begins.
+type Scenario struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Triggers    []*Trigger `json:"triggers"`
+}
+
+// Trigger fires its outcome the first time its condition holds. With
+// ByTurn set the condition must hold by that turn, or Otherwise fires.
+type Trigger struct {
+	ID        string    `json:"id"`
+	Condition Condition `json:"condition"`
+	ByTurn    int       `json:"by_turn,omitempty"`
+	Outcome   Outcome   `json:"outcome"`
+	Otherwise *Outcome  `json:"otherwise,omitempty"`
+	Fired     bool      `json:"fired,omitempty"`
+}
+
+// Condition is what a trigger waits for. Players are given by name, or
+// ScenarioPlayerHuman for the human player.
+type Condition struct {
+	Type   string `json:"type"`
+	Player string `json:"player,omitempty"`
+	City   string `json:"city,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+// Outcome is what happens when a trigger fires
+type Outcome struct {
+	Type    string `json:"type"`
+	Player  string `json:"player,omitempty"`
+	Message string `json:"message,omitempty"`
+	Gold    int    `json:"gold,omitempty"`
+}
+
+// ScenarioNotice describes a scenario trigger that fired
+type ScenarioNotice struct {
+	Turn      int    `json:"turn"`
+	TriggerID string `json:"trigger_id"`
+	Outcome   string `json:"outcome"`
+	PlayerID  string `json:"player_id,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// LoadScenario reads and validates a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Scenario
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Validate checks that every trigger is complete
+func (s *Scenario) Validate() error {
+	ids := make(map[string]bool)
+	for i, t := range s.Triggers {
+		if t == nil {
+			return fmt.Errorf("trigger %d is empty", i)
+		}
+		if t.ID == "" || ids[t.ID] {
+			return fmt.Errorf("trigger %d needs a unique id", i)
+		}
+		ids[t.ID] = true
+
+		c := t.Condition
+		switch c.Type {
+		case ConditionHoldsCity:
+			if c.Player == "" || c.City == "" {
+				return fmt.Errorf("trigger %q: %s needs a player and a city", t.ID, c.Type)
+			}
+		case ConditionCityCount:
+			if c.Player == "" || c.Count < 1 {
+				return fmt.Errorf("trigger %q: %s needs a player and a count", t.ID, c.Type)
+			}
+		case ConditionEliminated:
+			if c.Player == "" {
+				return fmt.Errorf("trigger %q: %s needs a player", t.ID, c.Type)
+			}
+		case ConditionTurnReached:
+			if c.Count < 1 {
+				return fmt.Errorf("trigger %q: %s needs a turn count", t.ID, c.Type)
+			}
+		default:
+			return fmt.Errorf("trigger %q: unknown condition %q", t.ID, c.Type)
+		}
+
+		if err := t.Outcome.validate(); err != nil {
+			return fmt.Errorf("trigger %q: %w", t.ID, err)
+		}
+		if t.Otherwise != nil {
+			if t.ByTurn < 1 {
+				return fmt.Errorf("trigger %q: otherwise needs by_turn", t.ID)
+			}
+			if err := t.Otherwise.validate(); err != nil {
+				return fmt.Errorf("trigger %q: otherwise: %w", t.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks that an outcome is complete
+func (o *Outcome) validate() error {
+	switch o.Type {
+	case OutcomeVictory, OutcomeDefeat:
+		if o.Player == "" {
+			return fmt.Errorf("%s needs a player", o.Type)
+		}
+	case OutcomeEvent:
+		if o.Message == "" {
+			return fmt.Errorf("event needs a message")
+		}
+		if o.Gold != 0 && o.Player == "" {
+			return fmt.Errorf("event gold needs a player")
+		}
+	default:
+		return fmt.Errorf("unknown outcome %q", o.Type)
+	}
+	return nil
+}
+
+// SetScenario attaches a scenario to a game that has not started, checking
+// that the players it names are in the game
+func (g *GameState) SetScenario(s *Scenario) error {
+	for _, t := range s.Triggers {
+		refs := []string{t.Condition.Player, t.Outcome.Player}
+		if t.Otherwise != nil {
+			refs = append(refs, t.Otherwise.Player)
+		}
+		for _, ref := range refs {
+			if ref != "" && g.scenarioPlayer(ref) == nil {
+				return fmt.Errorf("trigger %q: no player %q in this game", t.ID, ref)
+			}
+		}
+	}
+
+	g.Scenario = s
+	return nil
+}
+
+// scenarioPlayer finds the player a scenario refers to by name
+func (g *GameState) scenarioPlayer(ref string) *Player {
+	for _, p := range g.Players {
+		if p.Name == ref || (ref == ScenarioPlayerHuman && p.Type == PlayerHuman) {
+			return p
+		}
+	}
+	return nil
+}
+
+// evaluateTriggers fires the scenario triggers whose time has come. It is
+// called as each turn begins.
+func (g *GameState) evaluateTriggers() {
+	if g.Scenario == nil {
+		return
+	}
+
+	for _, t := range g.Scenario.Triggers {
+		if t.Fired || g.Phase == PhaseGameOver {
+			continue
+		}
+
+		if g.conditionHolds(t.Condition) {
+			t.Fired = true
+			g.fireOutcome(t, t.Outcome)
+		} else if t.ByTurn > 0 && g.CurrentTurn >= t.ByTurn {
+			t.Fired = true
+			if t.Otherwise != nil {
+				g.fireOutcome(t, *t.Otherwise)
+			}
+		}
+	}
+}
+
+// humanAlive reports whether any human player is still in the game
+func (g *GameState) humanAlive() bool {
+	for _, p := range g.Players {
+		if p.Type == PlayerHuman && p.IsAlive {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionHolds checks a trigger condition against the current state
+func (g *GameState) conditionHolds(c Condition) bool {
+	if c.Type == ConditionTurnReached {
+		return g.CurrentTurn >= c.Count
+	}
+
+	player := g.scenarioPlayer(c.Player)
+	if player == nil {
+		return false
+	}
+
+	switch c.Type {
+	case ConditionHoldsCity:
+		for _, city := range player.Cities {
+			if city.Name == c.City {
+				return true
+			}
+		}
+	case ConditionCityCount:
+		return len(player.Cities) >= c.Count
+	case ConditionEliminated:
+		return !player.IsAlive
+	}
+	return false
+}
+
+// fireOutcome carries out an outcome and tells every human player
+func (g *GameState) fireOutcome(t *Trigger, o Outcome) {
+	player := g.scenarioPlayer(o.Player)
+
+	switch o.Type {
+	case OutcomeVictory:
+		g.Winner = player
+		g.Phase = PhaseGameOver
+	case OutcomeDefeat:
+		player.Defeated = true
+		player.CheckAlive()
+		// Nobody is left to play once every human is out
+		if !g.checkVictory() && !g.humanAlive() {
+			g.Phase = PhaseGameOver
+		}
+	case OutcomeEvent:
+		if player != nil {
+			player.Gold += o.Gold
+		}
+	}
+
+	notice := ScenarioNotice{
+		Turn:      g.CurrentTurn,
+		TriggerID: t.ID,
+		Outcome:   o.Type,
+		Message:   o.Message,
+	}
+	if player != nil {
+		notice.PlayerID = player.ID
+	}
+	for _, p := range g.Players {
+		if r := g.report(p.ID); r != nil {
+			r.ScenarioNotices = append(r.ScenarioNotices, notice)
+		}
+	}
+}
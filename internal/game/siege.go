@@ -0,0 +1,257 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"math/rand/v2"
+)
+
+// BombardAction lets a siege unit fire on a tile up to BombardRange away.
+// Bombardment wears down the best defender and the city defenses without a
+// counterattack, but never destroys a unit outright.
+type BombardAction struct {
+	UnitID  string `json:"unit_id"`
+	TargetX int    `json:"target_x"`
+	TargetY int    `json:"target_y"`
+}
+
+// BombardResult holds the outcome of a bombardment or city strike
+type BombardResult struct {
+	Hits         int `json:"hits"`
+	DefenderHurt int `json:"defender_hurt"` // Health taken from the defender
+	CityDamage   int `json:"city_damage"`   // Damage dealt to the city defenses
+}
+
+// Type returns the action type name
+func (a *BombardAction) Type() string {
+	return "bombard"
+}
+
+// Validate checks if the bombardment is valid
+func (a *BombardAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if !unit.IsSiegeUnit() {
+		return unitError(ErrCannotBombard, unit.ID)
+	}
+
+	if !unit.CanMove() {
+		return noMovement(unit, 1)
+	}
+
+	if unit.Cooldown > 0 {
+		return unitError(ErrReloading, unit.ID)
+	}
+
+	// Check range
+	if d := rules.Distance(unit.X, unit.Y, a.TargetX, a.TargetY); d > BombardRange || d == 0 {
+		return unitError(ErrInvalidTarget, unit.ID).at(a.TargetX, a.TargetY)
+	}
+
+	// Check for enemies or an enemy city at target
+	if len(g.GetEnemyUnitsAt(a.TargetX, a.TargetY, playerID)) == 0 {
+		city := g.GetCityAt(a.TargetX, a.TargetY)
+		if city == nil || city.OwnerID == playerID {
+			return unitError(ErrInvalidTarget, unit.ID).at(a.TargetX, a.TargetY)
+		}
+	}
+
+	return nil
+}
+
+// Execute fires the bombardment
+func (a *BombardAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	res := &Result{Units: []string{unit.ID}}
+	res.spendMovement(unit.MovementLeft)
+	unit.ClearOrders()
+	unit.MovementLeft = 0
+	unit.Acted = true
+	unit.Cooldown = BombardCooldown
+
+	defender, tile, city := g.attackTarget(unit, a.TargetX, a.TargetY)
+	result := ResolveBombard(g.rand(), unit, defender, tile, city)
+	if defender != nil {
+		res.unit(defender.ID)
+	}
+	if city != nil {
+		res.city(city.ID)
+	}
+
+	g.reportBombard(unit, nil, defender, city, a.TargetX, a.TargetY, result)
+
+	return res, nil
+}
+
+// ResolveBombard fires BombardRounds at a tile. Each round that lands
+// damages the defender, if any, and the defenses of a city on the tile.
+func ResolveBombard(rng *rand.Rand, attacker, defender *Unit, tile *Tile, city *City) BombardResult {
+	// Walls do not protect against siege units, only the defender's own
+	// strength does. An empty city cannot dodge.
+	hitChance := 1.0
+	if defender != nil {
+		hitChance = CalculateOdds(attacker, defender, tile, city != nil, defender.IsFortified, false)
+	}
+
+	result := BombardResult{Hits: rangedHits(rng, hitChance)}
+	result.DefenderHurt = rangedDamage(defender, result.Hits)
+
+	if city != nil {
+		damage := result.Hits * DamagePerRound
+		if damage > city.DefenseLeft() {
+			damage = city.DefenseLeft()
+		}
+		city.Damage += damage
+		result.CityDamage = damage
+	}
+
+	return result
+}
+
+// rangedHits rolls BombardRounds shots and returns how many landed
+func rangedHits(rng *rand.Rand, hitChance float64) int {
+	hits := 0
+	for i := 0; i < BombardRounds; i++ {
+		if rng.Float64() < hitChance {
+			hits++
+		}
+	}
+	return hits
+}
+
+// rangedDamage applies the damage of a ranged attack to a unit, leaving it
+// at least BombardMinHealth, and returns the health it lost
+func rangedDamage(unit *Unit, hits int) int {
+	if unit == nil {
+		return 0
+	}
+
+	damage := hits * DamagePerRound
+	if unit.Health-damage < BombardMinHealth {
+		damage = unit.Health - BombardMinHealth
+	}
+	if damage <= 0 {
+		return 0
+	}
+	unit.TakeDamage(damage)
+	return damage
+}
+
+// CityStrikeAction lets a city fire on an adjacent enemy once per turn.
+// Like a bombardment it cannot be answered and never destroys a unit.
+type CityStrikeAction struct {
+	CityID  string `json:"city_id"`
+	TargetX int    `json:"target_x"`
+	TargetY int    `json:"target_y"`
+}
+
+// Type returns the action type name
+func (a *CityStrikeAction) Type() string {
+	return "city_strike"
+}
+
+// Validate checks if the city can strike the target
+func (a *CityStrikeAction) Validate(g *GameState, playerID string) error {
+	city, err := g.ownCity(playerID, a.CityID)
+	if err != nil {
+		return err
+	}
+
+	if city.Struck {
+		return cityError(ErrCityStruck, city.ID)
+	}
+
+	// Check adjacency
+	if !rules.Adjacent(city.X, city.Y, a.TargetX, a.TargetY) {
+		return cityError(ErrInvalidTarget, city.ID).at(a.TargetX, a.TargetY)
+	}
+
+	if len(g.GetEnemyUnitsAt(a.TargetX, a.TargetY, playerID)) == 0 {
+		return cityError(ErrInvalidTarget, city.ID).at(a.TargetX, a.TargetY)
+	}
+
+	return nil
+}
+
+// Execute fires the city's strike at the best defender on the target tile
+func (a *CityStrikeAction) Execute(g *GameState) (*Result, error) {
+	city := g.GetCity(a.CityID)
+	if city == nil {
+		return nil, ErrCityNotFound
+	}
+
+	tile := g.Map.GetTile(a.TargetX, a.TargetY)
+	enemies := g.GetEnemyUnitsAt(a.TargetX, a.TargetY, city.OwnerID)
+	if tile == nil || len(enemies) == 0 {
+		return nil, ErrInvalidTarget
+	}
+
+	inCity := g.GetCityAt(a.TargetX, a.TargetY) != nil
+	defender := getBestDefender(enemies, tile, inCity)
+	strength := city.Strength()
+	defense := defender.EffectiveDefense(tile.Terrain, inCity, defender.IsFortified)
+	hitChance := float64(strength) / float64(strength+defense)
+
+	result := BombardResult{Hits: rangedHits(g.rand(), hitChance)}
+	result.DefenderHurt = rangedDamage(defender, result.Hits)
+	city.Struck = true
+
+	g.reportBombard(nil, city, defender, nil, a.TargetX, a.TargetY, result)
+
+	return &Result{Units: []string{defender.ID}, Cities: []string{city.ID}}, nil
+}
+
+// assaultCity attacks a city with no units left to defend it. The attacker
+// fights the city itself and captures it once its defenses are broken.
+func (g *GameState) assaultCity(attacker *Unit, city *City, res *Result) {
+	previousOwnerID := city.OwnerID
+	entry := CombatLogEntry{
+		X:            city.X,
+		Y:            city.Y,
+		AttackerID:   attacker.OwnerID,
+		AttackerUnit: attacker.Type,
+		DefenderID:   city.OwnerID,
+		Undefended:   true,
+		Odds:         1,
+	}
+
+	result := CombatResult{AttackerWon: true}
+	if city.DefenseLeft() > 0 {
+		entry.Odds = combatWinChance(cityAssaultHitChance(attacker, city), hitsToDestroy(city.DefenseLeft()), hitsToDestroy(attacker.Health))
+		result = ResolveCityAssault(g.rand(), attacker, city)
+		g.notePromotions(result, attacker, nil)
+		city.Damage += result.DefenderDamage
+		res.city(city.ID)
+	}
+
+	if result.AttackerDestroyed {
+		g.removeUnit(attacker, res)
+	} else {
+		attacker.TakeDamage(result.AttackerDamage)
+		attacker.spendAttack()
+	}
+
+	if result.AttackerWon {
+		entry.Plunder = g.captureCity(city, attacker.OwnerID, res).Plunder
+		// Move attacker to city
+		g.leaveGroup(attacker, res)
+		attacker.X = city.X
+		attacker.Y = city.Y
+		g.revealUnit(attacker)
+	}
+
+	g.reportCityAssault(attacker, city, previousOwnerID, result)
+
+	entry.AttackerWon = result.AttackerWon
+	entry.AttackerLost = result.AttackerDestroyed
+	if result.AttackerWon {
+		entry.CityCaptured = city.Name
+	}
+	g.logCombat(entry)
+}
@@ -0,0 +1,170 @@
+package game
+
+import "math/rand/v2"
+
+// Simulation trial counts
+const (
+	DefaultSimulationTrials = 20
+	MaxSimulationTrials     = 200
+)
+
+// SimulationResult is what came of playing actions on copies of the game.
+// Only units and cities the player can see are reported, and only those
+// the actions changed in at least one trial.
+type SimulationResult struct {
+	Trials    int             `json:"trials"`
+	Completed float64         `json:"completed"` // Share of trials in which every action could be carried out
+	Units     []SimulatedUnit `json:"units"`
+	Cities    []SimulatedCity `json:"cities"`
+}
+
+// SimulatedUnit is how a unit fared across the trials
+type SimulatedUnit struct {
+	UnitID    string  `json:"unit_id"`
+	OwnerID   string  `json:"owner_id"`
+	Health    int     `json:"health"`     // Health before the actions
+	Survived  float64 `json:"survived"`   // Share of trials the unit survived
+	EndHealth float64 `json:"end_health"` // Average health of the unit when it survived
+	Promoted  float64 `json:"promoted"`   // Share of trials the unit became a veteran
+}
+
+// SimulatedCity is how a city fared across the trials
+type SimulatedCity struct {
+	CityID        string  `json:"city_id"`
+	Name          string  `json:"name"`
+	OwnerID       string  `json:"owner_id"`
+	Population    int     `json:"population"`     // Population before the actions
+	Captured      float64 `json:"captured"`       // Share of trials the city changed hands
+	Destroyed     float64 `json:"destroyed"`      // Share of trials the city was razed
+	EndPopulation float64 `json:"end_population"` // Average population when the city stood
+}
+
+// Simulate plays actions for a player on copies of the game and reports
+// the outcome, leaving the game itself untouched. Each trial plays with a
+// seed of its own, drawn from the game's seed and sequence number, so a
+// simulation shows the odds of an outcome rather than foretelling the one
+// the real actions will have, and simulating the same game again comes out
+// the same. An action the game refuses before any trial has been played is
+// returned as an error.
+func (g *GameState) Simulate(playerID string, actions []Action, trials int) (*SimulationResult, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	if trials <= 0 {
+		trials = DefaultSimulationTrials
+	}
+	trials = min(trials, MaxSimulationTrials)
+
+	// Everything the player can see now, before the actions
+	units := make([]*Unit, 0)
+	cities := make([]*City, 0)
+	for _, p := range g.Players {
+		for _, unit := range p.Units {
+			if p == player || g.InSight(player, unit.X, unit.Y) {
+				units = append(units, unit)
+			}
+		}
+		for _, city := range p.Cities {
+			if p == player || g.InSight(player, city.X, city.Y) {
+				cities = append(cities, city)
+			}
+		}
+	}
+
+	survived := make([]int, len(units))
+	endHealth := make([]int, len(units))
+	promoted := make([]int, len(units))
+	captured := make([]int, len(cities))
+	destroyed := make([]int, len(cities))
+	endPopulation := make([]int, len(cities))
+	completed := 0
+
+	seeds := rand.New(rand.NewPCG(uint64(g.Seed), g.Seq))
+	for trial := 0; trial < trials; trial++ {
+		sim := g.Clone()
+		sim.Seed = seeds.Int64()
+
+		done := true
+		for _, action := range actions {
+			if _, err := sim.Apply(playerID, action); err != nil {
+				if trial == 0 && sim.Seq == g.Seq {
+					// Nothing random has happened yet, every trial would fail
+					return nil, err
+				}
+				done = false
+				break
+			}
+		}
+		if done {
+			completed++
+		}
+
+		for i, unit := range units {
+			if u := sim.GetUnit(unit.ID); u != nil {
+				survived[i]++
+				endHealth[i] += u.Health
+				if u.IsVeteran && !unit.IsVeteran {
+					promoted[i]++
+				}
+			}
+		}
+		for i, city := range cities {
+			c := sim.GetCity(city.ID)
+			switch {
+			case c == nil:
+				destroyed[i]++
+			case c.OwnerID != city.OwnerID:
+				captured[i]++
+			}
+			if c != nil {
+				endPopulation[i] += c.Population
+			}
+		}
+	}
+
+	result := &SimulationResult{
+		Trials:    trials,
+		Completed: float64(completed) / float64(trials),
+		Units:     make([]SimulatedUnit, 0),
+		Cities:    make([]SimulatedCity, 0),
+	}
+	share := func(n int) float64 { return float64(n) / float64(trials) }
+	average := func(total, n int) float64 {
+		if n == 0 {
+			return 0
+		}
+		return float64(total) / float64(n)
+	}
+
+	for i, unit := range units {
+		if survived[i] == trials && endHealth[i] == unit.Health*trials && promoted[i] == 0 {
+			continue
+		}
+		result.Units = append(result.Units, SimulatedUnit{
+			UnitID:    unit.ID,
+			OwnerID:   unit.OwnerID,
+			Health:    unit.Health,
+			Survived:  share(survived[i]),
+			EndHealth: average(endHealth[i], survived[i]),
+			Promoted:  share(promoted[i]),
+		})
+	}
+	for i, city := range cities {
+		stood := trials - destroyed[i]
+		if captured[i] == 0 && destroyed[i] == 0 && endPopulation[i] == city.Population*trials {
+			continue
+		}
+		result.Cities = append(result.Cities, SimulatedCity{
+			CityID:        city.ID,
+			Name:          city.Name,
+			OwnerID:       city.OwnerID,
+			Population:    city.Population,
+			Captured:      share(captured[i]),
+			Destroyed:     share(destroyed[i]),
+			EndPopulation: average(endPopulation[i], stood),
+		})
+	}
+
+	return result, nil
+}
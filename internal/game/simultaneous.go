@@ -0,0 +1,425 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"sort"
+)
+
+// Kinds of planned order
+const (
+	OrderMove   = "move"
+	OrderAttack = "attack"
+)
+
+// Reasons a planned order is dropped. The unit is reported as awaiting
+// orders, like an automated unit that stops.
+const (
+	OrderCollided = "order_collided" // A unit of another player moved to the same tile
+	OrderBlocked  = "order_blocked"  // The tile held another player's unit or city
+	OrderFailed   = "order_failed"   // The order no longer made sense, e.g. the target left
+)
+
+// plannedActionTypes are the actions that, in the simultaneous phase, are
+// only carried out through planned orders
+var plannedActionTypes = map[string]bool{
+	"move":        true,
+	"attack":      true,
+	"move_group":  true,
+	"bombard":     true,
+	"city_strike": true,
+	"nuke":        true,
+}
+
+// Order is a move or attack a human player plans in the simultaneous
+// phase. Orders are carried out together once every human has submitted.
+type Order struct {
+	PlayerID  string `json:"player_id"`
+	UnitID    string `json:"unit_id"`
+	Kind      string `json:"kind"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	MovesLeft int    `json:"moves_left"` // Unit movement left once the order is carried out
+}
+
+// simultaneous reports whether the human players share one phase each turn
+func (g *GameState) simultaneous() bool {
+	return g.Config.SimultaneousTurns && g.humanAlive()
+}
+
+// OrdersSubmitted reports whether a player has submitted their orders in
+// the current simultaneous phase
+func (g *GameState) OrdersSubmitted(playerID string) bool {
+	for _, id := range g.Submitted {
+		if id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// PlayerOrders returns the orders a player has planned, in the order they
+// were given
+func (g *GameState) PlayerOrders(playerID string) []Order {
+	orders := make([]Order, 0)
+	for _, o := range g.Orders {
+		if o.PlayerID == playerID {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// PlayersToMove returns the players who are playing now: the current
+// player, or in the simultaneous phase every human still in the game
+func (g *GameState) PlayersToMove() []*Player {
+	players := make([]*Player, 0)
+	switch g.Phase {
+	case PhaseSimultaneous:
+		for _, p := range g.Players {
+			if p.Type == PlayerHuman && p.IsAlive {
+				players = append(players, p)
+			}
+		}
+	case PhasePlayerTurn, PhaseAITurn:
+		if p := g.GetCurrentPlayer(); p != nil {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// beginSimultaneousPhase starts a turn in which every human plays at once
+func (g *GameState) beginSimultaneousPhase() {
+	g.Phase = PhaseSimultaneous
+	g.Orders = nil
+	g.Submitted = nil
+
+	// The current player is kept on the first human to seat
+	g.TurnOrder.Current = ""
+	for _, id := range g.TurnOrder.Seats {
+		if p := g.GetPlayer(id); p != nil && p.Type == PlayerHuman && p.IsAlive {
+			g.TurnOrder.Current = id
+			break
+		}
+	}
+	for _, p := range g.Players {
+		if p.Type == PlayerHuman && p.IsAlive {
+			g.processUnitModes(p)
+		}
+	}
+}
+
+// plannedUnit returns a copy of a unit as its planned orders would leave it
+func (g *GameState) plannedUnit(unit *Unit) Unit {
+	planned := *unit
+	for _, o := range g.Orders {
+		if o.UnitID != unit.ID {
+			continue
+		}
+		switch o.Kind {
+		case OrderMove:
+			planned.MovementLeft -= g.GetMovementCost(planned.X, planned.Y, o.X, o.Y)
+			if planned.MovementLeft < 0 {
+				planned.MovementLeft = 0
+			}
+			planned.X, planned.Y = o.X, o.Y
+		case OrderAttack:
+			planned.spendAttack()
+		}
+	}
+	return planned
+}
+
+// PlanOrderAction adds a move or attack to a unit's planned orders. Each
+// order follows on from where the unit's earlier orders leave it.
+type PlanOrderAction struct {
+	UnitID string `json:"unit_id"`
+	Kind   string `json:"kind"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+}
+
+// Type returns the action type name
+func (a *PlanOrderAction) Type() string {
+	return "plan_order"
+}
+
+// Validate checks the order against where the unit's earlier orders leave it
+func (a *PlanOrderAction) Validate(g *GameState, playerID string) error {
+	if g.Phase != PhaseSimultaneous {
+		return ErrNotSimultaneous
+	}
+
+	if !g.IsCurrentPlayerTurn(playerID) {
+		return ErrOrdersSubmitted
+	}
+
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	planned := g.plannedUnit(unit)
+	if !planned.CanMove() {
+		return noMovement(&planned, 1)
+	}
+
+	switch a.Kind {
+	case OrderMove:
+		if !g.IsValidMove(&planned, a.X, a.Y) {
+			return g.moveError(&planned, a.X, a.Y)
+		}
+	case OrderAttack:
+		if unit.IsNuclear() {
+			return unitError(ErrNuclearOnly, unit.ID)
+		}
+		if !rules.Adjacent(planned.X, planned.Y, a.X, a.Y) {
+			return unitError(ErrInvalidTarget, unit.ID).at(a.X, a.Y)
+		}
+		// The target must hold an enemy now. Should it be gone by the time
+		// the order is carried out, the attack is dropped.
+		city := g.GetCityAt(a.X, a.Y)
+		if len(g.GetEnemyUnitsAt(a.X, a.Y, playerID)) == 0 && (city == nil || city.OwnerID == playerID) {
+			return unitError(ErrInvalidTarget, unit.ID).at(a.X, a.Y)
+		}
+	default:
+		e := unitError(ErrUnknownOrder, unit.ID)
+		e.Item = a.Kind
+		return e
+	}
+
+	return nil
+}
+
+// Execute adds the order
+func (a *PlanOrderAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+
+	unit.ClearOrders() // Planned orders cancel automation
+	g.Orders = append(g.Orders, Order{
+		PlayerID: unit.OwnerID,
+		UnitID:   unit.ID,
+		Kind:     a.Kind,
+		X:        a.X,
+		Y:        a.Y,
+	})
+
+	planned := g.plannedUnit(unit)
+	g.Orders[len(g.Orders)-1].MovesLeft = planned.MovementLeft
+
+	// The orders are the game's, not the unit's
+	return &Result{Units: []string{unit.ID}, Whole: true}, nil
+}
+
+// CancelOrdersAction drops all planned orders of a unit
+type CancelOrdersAction struct {
+	UnitID string `json:"unit_id"`
+}
+
+// Type returns the action type name
+func (a *CancelOrdersAction) Type() string {
+	return "cancel_orders"
+}
+
+// Validate checks that the unit's orders can still be changed
+func (a *CancelOrdersAction) Validate(g *GameState, playerID string) error {
+	if g.Phase != PhaseSimultaneous {
+		return ErrNotSimultaneous
+	}
+
+	if !g.IsCurrentPlayerTurn(playerID) {
+		return ErrOrdersSubmitted
+	}
+
+	_, err := g.ownUnit(playerID, a.UnitID)
+	return err
+}
+
+// Execute drops the orders
+func (a *CancelOrdersAction) Execute(g *GameState) (*Result, error) {
+	orders := g.Orders[:0]
+	for _, o := range g.Orders {
+		if o.UnitID != a.UnitID {
+			orders = append(orders, o)
+		}
+	}
+	g.Orders = orders
+	return &Result{Units: []string{a.UnitID}, Whole: true}, nil
+}
+
+// SubmitOrdersAction ends a human player's part of the simultaneous phase.
+// Once every human has submitted, the orders are carried out and the turn
+// moves on.
+type SubmitOrdersAction struct {
+	PlayerID string `json:"player_id"`
+}
+
+// Type returns the action type name
+func (a *SubmitOrdersAction) Type() string {
+	return "submit_orders"
+}
+
+// Validate checks that the player is still planning
+func (a *SubmitOrdersAction) Validate(g *GameState, playerID string) error {
+	if g.Phase == PhaseGameOver {
+		return ErrGameOver
+	}
+
+	if g.Phase != PhaseSimultaneous {
+		return ErrNotSimultaneous
+	}
+
+	if a.PlayerID != playerID {
+		return ErrNotYourOrders
+	}
+
+	if !g.IsCurrentPlayerTurn(playerID) {
+		return ErrOrdersSubmitted
+	}
+
+	if !g.TurnStatus(playerID).CanEndTurn {
+		return ErrProductionRequired
+	}
+
+	return nil
+}
+
+// Execute records the submission and ends the phase once it is the last
+func (a *SubmitOrdersAction) Execute(g *GameState) (*Result, error) {
+	g.Submitted = append(g.Submitted, a.PlayerID)
+	for _, p := range g.Players {
+		if p.Type == PlayerHuman && p.IsAlive && !g.OrdersSubmitted(p.ID) {
+			return &Result{Players: []string{a.PlayerID}}, nil
+		}
+	}
+
+	g.resolveOrders()
+	for _, p := range g.Players {
+		if p.Type == PlayerHuman && p.IsAlive {
+			g.endPlayerTurn(p)
+		}
+	}
+
+	// AI players follow in seat order
+	g.TurnOrder.Current = ""
+	g.passTurn()
+	return &Result{Whole: true}, nil
+}
+
+// resolveOrders carries out the planned orders of every human player. Units
+// act in steps: every unit's first order, then every unit's second order
+// and so on. In each step the moves happen first, all checked against where
+// units stood as the step began, and then the attacks are made.
+//
+// A move is blocked when its tile holds another player's unit or city, and
+// when units of different players move to the same tile; none of them
+// move. Attacks are made in seat order, starting with a different player
+// each turn so nobody always strikes first. A unit whose order is blocked
+// or fails keeps what movement it has left and its later orders are
+// dropped.
+func (g *GameState) resolveOrders() {
+	steps := make([][]Order, 0)
+	count := make(map[string]int)
+	for _, o := range g.Orders {
+		n := count[o.UnitID]
+		count[o.UnitID]++
+		if n == len(steps) {
+			steps = append(steps, nil)
+		}
+		steps[n] = append(steps[n], o)
+	}
+
+	dropped := make(map[string]bool)
+	for _, step := range steps {
+		g.resolveMoves(step, dropped)
+		g.resolveAttacks(step, dropped)
+	}
+
+	g.Orders = nil
+}
+
+// resolveMoves carries out the moves of one step
+func (g *GameState) resolveMoves(step []Order, dropped map[string]bool) {
+	// Players moving into each tile
+	entering := make(map[Coord]map[string]bool)
+	for _, o := range step {
+		if o.Kind != OrderMove || dropped[o.UnitID] {
+			continue
+		}
+		key := Coord{o.X, o.Y}
+		if entering[key] == nil {
+			entering[key] = make(map[string]bool)
+		}
+		entering[key][o.PlayerID] = true
+	}
+
+	// Decide every move before any unit leaves its tile
+	moves := make([]*MoveUnitAction, 0)
+	for _, o := range step {
+		if o.Kind != OrderMove || dropped[o.UnitID] {
+			continue
+		}
+		unit := g.GetUnit(o.UnitID)
+		if unit == nil {
+			continue
+		}
+
+		city := g.GetCityAt(o.X, o.Y)
+		move := &MoveUnitAction{UnitID: o.UnitID, ToX: o.X, ToY: o.Y}
+		switch {
+		case len(entering[Coord{o.X, o.Y}]) > 1:
+			g.dropOrders(unit, OrderCollided, dropped)
+		case len(g.GetEnemyUnitsAt(o.X, o.Y, o.PlayerID)) > 0 || (city != nil && city.OwnerID != o.PlayerID):
+			g.dropOrders(unit, OrderBlocked, dropped)
+		case move.Validate(g, o.PlayerID) != nil:
+			g.dropOrders(unit, OrderFailed, dropped)
+		default:
+			moves = append(moves, move)
+		}
+	}
+
+	for _, move := range moves {
+		move.Execute(g)
+	}
+}
+
+// resolveAttacks carries out the attacks of one step
+func (g *GameState) resolveAttacks(step []Order, dropped map[string]bool) {
+	attacks := make([]Order, 0)
+	for _, o := range step {
+		if o.Kind == OrderAttack && !dropped[o.UnitID] {
+			attacks = append(attacks, o)
+		}
+	}
+
+	n := len(g.Players)
+	first := g.CurrentTurn % n
+	seat := func(playerID string) int {
+		return (g.GetPlayerIndex(playerID) - first + n) % n
+	}
+	sort.SliceStable(attacks, func(i, j int) bool {
+		return seat(attacks[i].PlayerID) < seat(attacks[j].PlayerID)
+	})
+
+	for _, o := range attacks {
+		unit := g.GetUnit(o.UnitID)
+		if unit == nil {
+			continue // Lost earlier in the step
+		}
+		attack := &AttackAction{AttackerID: o.UnitID, TargetX: o.X, TargetY: o.Y}
+		if attack.Validate(g, o.PlayerID) != nil {
+			g.dropOrders(unit, OrderFailed, dropped)
+			continue
+		}
+		attack.Execute(g)
+	}
+}
+
+// dropOrders stops a unit carrying out its remaining orders
+func (g *GameState) dropOrders(unit *Unit, reason string, dropped map[string]bool) {
+	dropped[unit.ID] = true
+	g.reportUnitNotice(unit, reason)
+}
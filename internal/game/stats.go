@@ -0,0 +1,111 @@
+package game
+
+// TurnStats is every player's standing as a turn began. The last entry of
+// a finished game is the final standing instead.
+type TurnStats struct {
+	Turn     int           `json:"turn"`
+	Players  []PlayerStats `json:"players"`
+	Captures []CityCapture `json:"captures,omitempty"` // Cities taken during the turn
+
+	// Borders are the tiles that changed hands since the entry before, so
+	// the entries up to one add up to the borders as it was recorded
+	Borders []BorderChange `json:"borders,omitempty"`
+}
+
+// PlayerStats is one player's standing on a turn
+type PlayerStats struct {
+	PlayerID   string `json:"player_id"`
+	Score      int    `json:"score"`
+	Gold       int    `json:"gold"`
+	Cities     int    `json:"cities"`
+	Military   int    `json:"military"`
+	Population int    `json:"population"`
+	Units      int    `json:"units"`
+	Territory  int    `json:"territory"` // Tiles within the player's borders
+	Techs      int    `json:"techs"`     // Technologies known
+}
+
+// Score returns the player's score: points for every citizen and wonder
+func (p *Player) Score() int {
+	score := 0
+	for _, city := range p.Cities {
+		score += city.Population * ScorePerCitizen
+		for building := range city.Buildings {
+			if building.IsWonder() {
+				score += ScorePerWonder
+			}
+		}
+	}
+	return score
+}
+
+// Stats returns the player's standing now
+func (p *Player) Stats() PlayerStats {
+	return PlayerStats{
+		PlayerID:   p.ID,
+		Score:      p.Score(),
+		Gold:       p.Gold,
+		Cities:     p.CityCount(),
+		Military:   p.MilitaryStrength(),
+		Population: p.TotalPopulation(),
+		Units:      len(p.Units),
+	}
+}
+
+// recordStats adds every player's standing to the history, replacing any
+// entry already recorded this turn but keeping its captures
+func (g *GameState) recordStats() {
+	territory := g.territories()
+	stats := TurnStats{Turn: g.CurrentTurn, Players: make([]PlayerStats, len(g.Players))}
+	for i, p := range g.Players {
+		stats.Players[i] = p.Stats()
+		stats.Players[i].Territory = territory[p.ID]
+		stats.Players[i].Techs = g.TechCount(p)
+	}
+
+	if n := len(g.History); n > 0 && g.History[n-1].Turn == g.CurrentTurn {
+		stats.Captures = g.History[n-1].Captures
+		stats.Borders = g.bordersSince(g.History[:n-1])
+		g.History[n-1] = stats
+		return
+	}
+	stats.Borders = g.bordersSince(g.History)
+	g.History = append(g.History, stats)
+}
+
+// territories returns the number of tiles within each player's borders
+func (g *GameState) territories() map[string]int {
+	counts := make(map[string]int)
+	if g.Map == nil {
+		return counts
+	}
+	for i := range g.Map.Tiles {
+		if owner := g.Map.Tiles[i].Owner; owner != "" {
+			counts[owner]++
+		}
+	}
+	return counts
+}
+
+// bordersSince returns the tiles whose owners differ from the borders the
+// history's entries add up to
+func (g *GameState) bordersSince(history []TurnStats) []BorderChange {
+	if g.Map == nil {
+		return nil
+	}
+	recorded := make(map[Coord]string)
+	for _, stats := range history {
+		for _, change := range stats.Borders {
+			recorded[At(change.X, change.Y)] = change.Owner
+		}
+	}
+
+	var changes []BorderChange
+	for i := range g.Map.Tiles {
+		tile := &g.Map.Tiles[i]
+		if recorded[tile.Coord()] != tile.Owner {
+			changes = append(changes, BorderChange{X: tile.X, Y: tile.Y, Owner: tile.Owner})
+		}
+	}
+	return changes
+}
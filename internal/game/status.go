@@ -0,0 +1,62 @@
+package game
+
+// TurnStatus tracks what still needs the player's attention this turn
+type TurnStatus struct {
+	PlayerID                string   `json:"player_id"`
+	IdleUnits               []string `json:"idle_units"`                // Units that can still move and have no standing order
+	CitiesWithoutProduction []string `json:"cities_without_production"` // Cities with no build order
+	ProductionRequired      bool     `json:"production_required"`
+	CanEndTurn              bool     `json:"can_end_turn"`
+
+	// In the simultaneous phase, the orders the player has planned and
+	// whether they have been submitted
+	Orders          []Order `json:"orders,omitempty"`
+	OrdersSubmitted bool    `json:"orders_submitted,omitempty"`
+}
+
+// TurnStatus returns the units and cities of a player that are still
+// waiting for orders
+func (g *GameState) TurnStatus(playerID string) TurnStatus {
+	status := TurnStatus{
+		PlayerID:                playerID,
+		IdleUnits:               make([]string, 0),
+		CitiesWithoutProduction: make([]string, 0),
+		ProductionRequired:      g.Config.ProductionRequired,
+		CanEndTurn:              true,
+	}
+
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return status
+	}
+
+	for _, unit := range player.Units {
+		// Units with planned orders are idle only if they have movement
+		// to spare
+		if g.Phase == PhaseSimultaneous {
+			planned := g.plannedUnit(unit)
+			unit = &planned
+		}
+		if unit.NeedsOrders() {
+			status.IdleUnits = append(status.IdleUnits, unit.ID)
+		}
+	}
+
+	if g.Phase == PhaseSimultaneous {
+		status.Orders = g.PlayerOrders(playerID)
+		status.OrdersSubmitted = g.OrdersSubmitted(playerID)
+	}
+
+	for _, city := range player.Cities {
+		if city.CurrentBuild == nil {
+			status.CitiesWithoutProduction = append(status.CitiesWithoutProduction, city.ID)
+		}
+	}
+
+	// Only human players are held up, AI players always manage their cities
+	if g.Config.ProductionRequired && player.Type == PlayerHuman && len(status.CitiesWithoutProduction) > 0 {
+		status.CanEndTurn = false
+	}
+
+	return status
+}
@@ -0,0 +1,179 @@
+package game
+
+import (
+	"civilization/internal/game/rules"
+	"slices"
+)
+
+// Jobs a worker can do on the land it stands on. A job takes several
+// turns; the work done so far is kept on the tile, so a worker called
+// away can come back, or another take over, without starting again.
+const (
+	JobClearForest = "clear_forest" // Forest to grassland, the timber going to the nearest city
+	JobPlantForest = "plant_forest" // Grassland or plains to forest
+	JobMine        = "mine"         // A mine on hills, or on a resource worth mining elsewhere
+)
+
+// JobTurns returns how many turns of work a job takes on the tile, or 0
+// when it cannot be done there
+func (t *Tile) JobTurns(job string) int {
+	switch job {
+	case JobClearForest:
+		if t.Terrain == TerrainForest {
+			return ClearForestTurns
+		}
+	case JobPlantForest:
+		if t.Terrain == TerrainGrassland || t.Terrain == TerrainPlains {
+			return PlantForestTurns
+		}
+	case JobMine:
+		switch {
+		case t.HasMine || t.IsWater():
+		case t.Terrain == TerrainHills:
+			return MineHillsTurns
+		case ResourceBonuses[t.Resource].Production > 0:
+			return MineSpecialTurns
+		}
+	}
+	return 0
+}
+
+// isJob reports whether a job is one workers know
+func isJob(job string) bool {
+	return job == JobClearForest || job == JobPlantForest || job == JobMine
+}
+
+// TerraformAction sets a worker to a job on its tile. The worker carries on
+// at the start of each of its owner's turns until the job is done.
+type TerraformAction struct {
+	UnitID string `json:"unit_id"`
+	Job    string `json:"job"`
+}
+
+// Type returns the action type name
+func (a *TerraformAction) Type() string {
+	return "terraform"
+}
+
+// Validate checks if the unit can do the job where it stands
+func (a *TerraformAction) Validate(g *GameState, playerID string) error {
+	unit, err := g.ownUnit(playerID, a.UnitID)
+	if err != nil {
+		return err
+	}
+
+	if !unit.CanBuildRoad() {
+		return unitError(ErrCannotTerraform, unit.ID)
+	}
+	if !isJob(a.Job) {
+		return &ActionError{Err: ErrUnknownJob, UnitID: unit.ID, Item: a.Job}
+	}
+	if unit.MovementLeft <= 0 {
+		return noMovement(unit, 1)
+	}
+
+	tile := g.Map.GetTile(unit.X, unit.Y)
+	if tile == nil {
+		return unitError(ErrInvalidTile, unit.ID).at(unit.X, unit.Y)
+	}
+	if tile.JobTurns(a.Job) == 0 {
+		e := unitError(ErrCannotWorkHere, unit.ID).at(unit.X, unit.Y).on(tile)
+		e.Item = a.Job
+		return e
+	}
+
+	return nil
+}
+
+// Execute starts the job, or picks up the work already done on it, and
+// does the first turn of it
+func (a *TerraformAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+	tile := g.Map.GetTile(unit.X, unit.Y)
+	if tile == nil {
+		return nil, ErrInvalidTile
+	}
+
+	result := &Result{Units: []string{unit.ID}}
+	if tile.Job != a.Job {
+		tile.Job = a.Job
+		tile.JobProgress = 0
+	}
+	unit.ClearOrders()
+	unit.Unfortify()
+	g.leaveGroup(unit, result)
+	unit.Mode = ModeTerraform
+	g.workJob(unit, result)
+
+	return result, nil
+}
+
+// workJob spends a worker's turn on the job of its tile, finishing the job
+// once enough turns of work have gone into it, and records what it changes
+// in result
+func (g *GameState) workJob(unit *Unit, result *Result) {
+	tile := g.Map.GetTile(unit.X, unit.Y)
+	if tile == nil || tile.JobTurns(tile.Job) == 0 {
+		// Another worker finished the job
+		g.wakeUnit(unit, WakeJobDone)
+		return
+	}
+
+	result.tile(g.Map, tile.X, tile.Y)
+	result.spendMovement(unit.MovementLeft)
+	unit.MovementLeft = 0
+	tile.JobProgress++
+	if tile.JobProgress < tile.JobTurns(tile.Job) {
+		return
+	}
+
+	switch tile.Job {
+	case JobClearForest:
+		g.setTerrain(tile, TerrainGrassland)
+		if city := g.nearestCity(unit.OwnerID, tile.X, tile.Y); city != nil {
+			city.Production += ClearForestShields
+			result.city(city.ID)
+		}
+	case JobPlantForest:
+		g.setTerrain(tile, TerrainForest)
+	case JobMine:
+		tile.HasMine = true
+	}
+	tile.Job = ""
+	tile.JobProgress = 0
+	g.wakeUnit(unit, WakeJobDone)
+}
+
+// setTerrain changes a tile's terrain. Irrigation and resources the new
+// terrain cannot have are lost.
+func (g *GameState) setTerrain(tile *Tile, terrain TerrainType) {
+	tile.Terrain = terrain
+	if terrain != TerrainGrassland && terrain != TerrainPlains {
+		tile.HasIrrigation = false
+	}
+	if tile.Resource != ResourceNone && !slices.Contains(ValidTerrainForResource[tile.Resource], terrain) {
+		tile.Resource = ResourceNone
+	}
+}
+
+// nearestCity returns the player's city closest to a tile, or nil when
+// they have none
+func (g *GameState) nearestCity(playerID string, x, y int) *City {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil
+	}
+
+	var nearest *City
+	best := 0
+	for _, city := range player.Cities {
+		d := rules.Distance(city.X, city.Y, x, y)
+		if nearest == nil || d < best {
+			nearest, best = city, d
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,121 @@
+package game
+
+// TileInfo is what a player can learn of a tile they click on
+type TileInfo struct {
+	Tile         *Tile // Without a resource the player cannot see
+	Food         int   // Yields with the tile's improvements
+	Production   int
+	Trade        int
+	DefenseBonus float64 // Multiplies the defense of units on the tile
+	MovementCost int     // To enter the tile: from the selected unit, roads counted, or else off road
+	OwnerID      string  // Player whose territory the tile is in, or ""
+	City         *City   // City standing on the tile, or nil
+	WorkedBy     *City   // City working the tile, or nil
+	Units        []*Unit // Units on the tile the player can see
+	Actions      []string
+}
+
+// TileInfo describes the tile at (x, y) as a player sees it, with the
+// actions a unit of theirs, if unitID is set, could take aimed at it. Tiles
+// the player has not explored are refused. A resource the player does not
+// know the technology to see is left out, and its yields with it.
+func (g *GameState) TileInfo(playerID string, x, y int, unitID string) (*TileInfo, error) {
+	player := g.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	tile := g.Map.GetTile(x, y)
+	if tile == nil || !g.IsExplored(player, x, y) {
+		return nil, ErrInvalidTile
+	}
+	var unit *Unit
+	if unitID != "" {
+		var err error
+		if unit, err = g.ownUnit(playerID, unitID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !g.SeesResource(player, tile.Resource) {
+		seen := *tile
+		seen.Resource = ResourceNone
+		tile = &seen
+	}
+
+	info := &TileInfo{
+		Tile:         tile,
+		Food:         tile.FoodYield(),
+		Production:   tile.ProductionYield(),
+		Trade:        tile.TradeYield(),
+		DefenseBonus: tile.DefenseBonus(),
+		MovementCost: tile.MovementCost(),
+		OwnerID:      g.TerritoryOwner(x, y),
+		City:         g.GetCityAt(x, y),
+		WorkedBy:     g.GetCity(tile.WorkedBy),
+		Units:        make([]*Unit, 0),
+		Actions:      make([]string, 0),
+	}
+	if info.WorkedBy != nil && tile.IsWater() && info.WorkedBy.HasBuilding(BuildingHarbor) {
+		info.Food += HarborFoodBonus
+	}
+
+	seen := g.InSight(player, x, y)
+	for _, u := range g.GetUnitsAt(x, y) {
+		if u.OwnerID == playerID || seen {
+			info.Units = append(info.Units, u)
+		}
+	}
+
+	if unit != nil {
+		if unit.X != x || unit.Y != y {
+			info.MovementCost = g.GetMovementCost(unit.X, unit.Y, x, y)
+		}
+		info.Actions = g.unitActionsAt(playerID, unit, x, y)
+	}
+	return info, nil
+}
+
+// unitActionsAt lists the types of the actions a player's unit could take
+// now aimed at (x, y): moving or attacking there from next to it, or, on
+// its own tile, what it can do where it stands
+func (g *GameState) unitActionsAt(playerID string, unit *Unit, x, y int) []string {
+	actions := make([]string, 0)
+	if !g.IsCurrentPlayerTurn(playerID) {
+		return actions
+	}
+
+	var candidates []Action
+	if unit.X == x && unit.Y == y {
+		candidates = []Action{
+			&FoundCityAction{SettlerID: unit.ID},
+			&BuildRoadAction{UnitID: unit.ID},
+			&TerraformAction{UnitID: unit.ID, Job: JobClearForest},
+			&TerraformAction{UnitID: unit.ID, Job: JobPlantForest},
+			&TerraformAction{UnitID: unit.ID, Job: JobMine},
+			&HelpWonderAction{UnitID: unit.ID},
+			&FortifyAction{UnitID: unit.ID},
+			&WakeAction{UnitID: unit.ID},
+			&SkipUnitAction{UnitID: unit.ID},
+		}
+	} else {
+		candidates = []Action{
+			&MoveUnitAction{UnitID: unit.ID, ToX: x, ToY: y},
+			&AttackAction{AttackerID: unit.ID, TargetX: x, TargetY: y},
+			&BombardAction{UnitID: unit.ID, TargetX: x, TargetY: y},
+			&NukeAction{UnitID: unit.ID, TargetX: x, TargetY: y},
+		}
+	}
+
+	for _, action := range candidates {
+		if g.Phase == PhaseSimultaneous && plannedActionTypes[action.Type()] {
+			continue
+		}
+		if len(actions) > 0 && actions[len(actions)-1] == action.Type() {
+			continue // Another job of the same action
+		}
+		if action.Validate(g, playerID) == nil {
+			actions = append(actions, action.Type())
+		}
+	}
+	return actions
+}
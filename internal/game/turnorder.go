@@ -0,0 +1,68 @@
+package game
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// TurnOrder is the order players take their turns in, and whose turn it
+// is, by player ID. It does not depend on where players are listed, so it
+// holds however saves order them. Eliminated players keep their seats and
+// are passed over. In the simultaneous phase the current player is the
+// first living human, as every human is to move.
+type TurnOrder struct {
+	Seats   []string `json:"seats"`             // Player IDs in the order they play
+	Current string   `json:"current,omitempty"` // Player whose turn it is, "" before the first seat
+}
+
+// NewTurnOrder seats players in the order given, the first to move
+func NewTurnOrder(players []*Player) TurnOrder {
+	order := TurnOrder{Seats: make([]string, len(players))}
+	for i, p := range players {
+		order.Seats[i] = p.ID
+	}
+	if len(order.Seats) > 0 {
+		order.Current = order.Seats[0]
+	}
+	return order
+}
+
+// Seat returns the seat of a player, or -1 if they have none
+func (o TurnOrder) Seat(playerID string) int {
+	return slices.Index(o.Seats, playerID)
+}
+
+// advance moves the turn to the next seat, and reports whether that began
+// a new round. From before the first seat it moves to the first.
+func (o *TurnOrder) advance() (newRound bool) {
+	seat := o.Seat(o.Current) + 1
+	if seat >= len(o.Seats) {
+		seat = 0
+		newRound = o.Current != ""
+	}
+	o.Current = o.Seats[seat]
+	return newRound
+}
+
+// clone returns a copy of the turn order
+func (o TurnOrder) clone() TurnOrder {
+	o.Seats = slices.Clone(o.Seats)
+	return o
+}
+
+// upgradeTurnOrder seats the players of a game snapshotted before turn
+// orders were kept, when the current player was an index into Players
+func (g *GameState) upgradeTurnOrder(snapshot []byte) {
+	if len(g.TurnOrder.Seats) > 0 {
+		return
+	}
+	var legacy struct {
+		CurrentPlayer int `json:"current_player"`
+	}
+	json.Unmarshal(snapshot, &legacy)
+
+	g.TurnOrder = NewTurnOrder(g.Players)
+	if legacy.CurrentPlayer >= 0 && legacy.CurrentPlayer < len(g.Players) {
+		g.TurnOrder.Current = g.Players[legacy.CurrentPlayer].ID
+	}
+}
@@ -0,0 +1,54 @@
+package game
+
+// hostActionTypes are the actions that change how the game is run rather
+// than play it. The server lets the game's host alone take them, whether
+// or not it is their turn.
+var hostActionTypes = map[string]bool{
+	"set_turn_timer": true,
+}
+
+// IsHostAction reports whether an action changes how the game is run, and
+// is taken by the host rather than played on a turn
+func IsHostAction(action Action) bool {
+	return hostActionTypes[action.Type()]
+}
+
+// SetTurnTimerAction changes the turn time limit of an async game, and the
+// inactivity policy applied to players who run out of it. Like any other
+// action it is recorded in the event log, so replays keep the setting;
+// the server keeps the timer running in async games alone.
+type SetTurnTimerAction struct {
+	Minutes int    `json:"minutes"`
+	Policy  string `json:"policy,omitempty"` // Empty keeps the current policy
+}
+
+// Type returns the action type name
+func (a *SetTurnTimerAction) Type() string {
+	return "set_turn_timer"
+}
+
+// Validate checks that the limit and policy are ones that can be set
+func (a *SetTurnTimerAction) Validate(g *GameState, playerID string) error {
+	if a.Minutes < 0 {
+		return ErrNegativeTimer
+	}
+	switch a.Policy {
+	case "", InactivitySkip, InactivityAI:
+	default:
+		return &ActionError{Err: ErrUnknownPolicy, Item: a.Policy}
+	}
+	return nil
+}
+
+// Execute sets the limit and policy. A time limit needs a policy to apply,
+// so one set without any skips the turns of players who run out of time.
+func (a *SetTurnTimerAction) Execute(g *GameState) (*Result, error) {
+	g.Config.TurnTimeout = a.Minutes
+	if a.Policy != "" {
+		g.Config.InactivityPolicy = a.Policy
+	}
+	if a.Minutes > 0 && g.Config.InactivityPolicy == "" {
+		g.Config.InactivityPolicy = InactivitySkip
+	}
+	return &Result{}, nil
+}
@@ -12,26 +12,18 @@ const (
 	UnitArcher
 	UnitHorseman
 	UnitCatapult
+	UnitNuclear
+	UnitScout
+	UnitCaravan
+	UnitKing
 )
 
-// String returns the string representation of a unit type
+// String returns the name of a unit type, which rules files may change
 func (u UnitType) String() string {
-	switch u {
-	case UnitSettler:
-		return "Settler"
-	case UnitWarrior:
-		return "Warrior"
-	case UnitPhalanx:
-		return "Phalanx"
-	case UnitArcher:
-		return "Archer"
-	case UnitHorseman:
-		return "Horseman"
-	case UnitCatapult:
-		return "Catapult"
-	default:
-		return "Unknown"
+	if template, ok := UnitTemplates[u]; ok {
+		return template.Name
 	}
+	return "Unknown"
 }
 
 // UnitTemplate defines the base stats for a unit type
@@ -41,11 +33,19 @@ type UnitTemplate struct {
 	Attack       int
 	Defense      int
 	Movement     int
-	Cost         int  // Production cost
+	Sight        int // Tiles the unit sees around itself
+	Cost         int // Production cost
 	IsNaval      bool
 	CanFoundCity bool
 	CanBuildRoad bool
 	IsSiege      bool // Can bypass city walls
+	IsNuclear    bool // Detonates anywhere on the map instead of fighting
+	HitAndRun    bool // Keeps the rest of its movement after attacking
+	FirstStrike  int  // Damage dealt to an attacker before the battle, defending behind city walls
+	HelpsWonder  bool // Can be given up in a city to add its cost to a wonder
+	IsKing       bool // Its owner's ruler in regicide games, never built
+
+	RequiresWonder BuildingType // Wonder that must exist somewhere in the world
 }
 
 // UnitTemplates contains all unit type definitions
@@ -56,6 +56,7 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       0,
 		Defense:      1,
 		Movement:     1,
+		Sight:        SightRadius,
 		Cost:         40,
 		IsNaval:      false,
 		CanFoundCity: true,
@@ -68,6 +69,7 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       1,
 		Defense:      1,
 		Movement:     1,
+		Sight:        SightRadius,
 		Cost:         10,
 		IsNaval:      false,
 		CanFoundCity: false,
@@ -80,6 +82,7 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       1,
 		Defense:      2,
 		Movement:     1,
+		Sight:        SightRadius,
 		Cost:         20,
 		IsNaval:      false,
 		CanFoundCity: false,
@@ -92,11 +95,13 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       2,
 		Defense:      1,
 		Movement:     1,
+		Sight:        SightRadius,
 		Cost:         20,
 		IsNaval:      false,
 		CanFoundCity: false,
 		CanBuildRoad: false,
 		IsSiege:      false,
+		FirstStrike:  20,
 	},
 	UnitHorseman: {
 		Type:         UnitHorseman,
@@ -104,11 +109,13 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       2,
 		Defense:      1,
 		Movement:     2,
+		Sight:        SightRadius,
 		Cost:         20,
 		IsNaval:      false,
 		CanFoundCity: false,
 		CanBuildRoad: false,
 		IsSiege:      false,
+		HitAndRun:    true,
 	},
 	UnitCatapult: {
 		Type:         UnitCatapult,
@@ -116,27 +123,108 @@ var UnitTemplates = map[UnitType]UnitTemplate{
 		Attack:       6,
 		Defense:      1,
 		Movement:     1,
+		Sight:        SightRadius,
 		Cost:         40,
 		IsNaval:      false,
 		CanFoundCity: false,
 		CanBuildRoad: false,
 		IsSiege:      true,
 	},
+	UnitNuclear: {
+		Type:         UnitNuclear,
+		Name:         "Nuclear",
+		Attack:       0,
+		Defense:      1,
+		Movement:     1,
+		Sight:        SightRadius,
+		Cost:         160,
+		IsNaval:      false,
+		CanFoundCity: false,
+		CanBuildRoad: false,
+		IsSiege:      false,
+		IsNuclear:    true,
+
+		RequiresWonder: BuildingManhattanProject,
+	},
+	UnitScout: {
+		Type:         UnitScout,
+		Name:         "Scout",
+		Attack:       0,
+		Defense:      1,
+		Movement:     2,
+		Sight:        2,
+		Cost:         20,
+		IsNaval:      false,
+		CanFoundCity: false,
+		CanBuildRoad: false,
+		IsSiege:      false,
+	},
+	UnitCaravan: {
+		Type:         UnitCaravan,
+		Name:         "Caravan",
+		Attack:       0,
+		Defense:      1,
+		Movement:     1,
+		Sight:        SightRadius,
+		Cost:         50,
+		IsNaval:      false,
+		CanFoundCity: false,
+		CanBuildRoad: false,
+		IsSiege:      false,
+		HelpsWonder:  true,
+	},
+	UnitKing: {
+		Type:         UnitKing,
+		Name:         "King",
+		Attack:       0,
+		Defense:      1,
+		Movement:     1,
+		Sight:        SightRadius,
+		Cost:         0,
+		IsNaval:      false,
+		CanFoundCity: false,
+		CanBuildRoad: false,
+		IsSiege:      false,
+		IsKing:       true,
+	},
 }
 
 // Unit represents a single unit in the game
 type Unit struct {
-	ID           string   `json:"id"`
-	Type         UnitType `json:"type"`
-	OwnerID      string   `json:"owner_id"`
-	X            int      `json:"x"`
-	Y            int      `json:"y"`
-	MovementLeft int      `json:"movement_left"`
-	Health       int      `json:"health"`
-	IsVeteran    bool     `json:"is_veteran"`
-	IsFortified  bool     `json:"is_fortified"`
+	ID           string     `json:"id"`
+	Type         UnitType   `json:"type"`
+	OwnerID      string     `json:"owner_id"`
+	X            int        `json:"x"`
+	Y            int        `json:"y"`
+	MovementLeft int        `json:"movement_left"`
+	Health       int        `json:"health"`
+	IsVeteran    bool       `json:"is_veteran"`
+	IsFortified  bool       `json:"is_fortified"`
+	Mode         UnitMode   `json:"mode"`                   // Standing order processed at turn start
+	Patrol       []Waypoint `json:"patrol,omitempty"`       // Route followed in ModePatrol
+	PatrolIndex  int        `json:"patrol_index,omitempty"` // Waypoint currently headed for
+	GroupID      string     `json:"group_id,omitempty"`     // Stack the unit moves with
+	Cooldown     int        `json:"cooldown,omitempty"`     // Turns until the unit can bombard again
+	Acted        bool       `json:"acted,omitempty"`        // Moved or attacked this turn, so it does not heal
+
+	// Where the unit's veteran status came from, if it is a veteran, and
+	// the battles it has won
+	VeteranOrigin string `json:"veteran_origin,omitempty"`
+	XP            int    `json:"xp,omitempty"`
+
+	// The name its owner gave the unit, and the title its battles won have
+	// earned it
+	Name      string `json:"name,omitempty"`
+	Honorific string `json:"honorific,omitempty"`
 }
 
+// Where a unit's veteran status came from
+const (
+	VeteranFromBarracks = "barracks" // Trained in a city with barracks
+	VeteranFromCombat   = "combat"   // Promoted for winning a battle
+	VeteranFromHandicap = "handicap" // Given by its AI owner's difficulty, see Handicap
+)
+
 // NewUnit creates a new unit at the specified location
 func NewUnit(unitType UnitType, ownerID string, x, y int) *Unit {
 	template := UnitTemplates[unitType]
@@ -153,6 +241,12 @@ func NewUnit(unitType UnitType, ownerID string, x, y int) *Unit {
 	}
 }
 
+// promote makes the unit a veteran
+func (u *Unit) promote(origin string) {
+	u.IsVeteran = true
+	u.VeteranOrigin = origin
+}
+
 // Template returns the unit template for this unit
 func (u *Unit) Template() UnitTemplate {
 	return UnitTemplates[u.Type]
@@ -191,20 +285,45 @@ func (u *Unit) EffectiveDefense(terrain TerrainType, inCity bool, fortified bool
 	return defense
 }
 
-// CanMove returns whether the unit has movement points left
+// CanMove returns whether the unit has movement points left. Fortified
+// units can still move; moving gives up the fortified position.
 func (u *Unit) CanMove() bool {
-	return u.MovementLeft > 0 && !u.IsFortified
+	return u.MovementLeft > 0
 }
 
-// ResetMovement resets movement points to full
+// NeedsOrders returns whether the unit can move and is neither fortified
+// nor following a standing order
+func (u *Unit) NeedsOrders() bool {
+	return u.CanMove() && !u.IsFortified && u.Mode == ModeNone
+}
+
+// ResetMovement resets movement points to full for a new turn
 func (u *Unit) ResetMovement() {
 	u.MovementLeft = u.Template().Movement
+	u.Acted = false
 }
 
-// Fortify puts the unit in fortified mode
+// Fortify puts the unit in fortified mode. Fortifying takes the rest of the
+// turn, and the defense bonus lasts until the unit moves or attacks.
 func (u *Unit) Fortify() {
 	u.IsFortified = true
 	u.MovementLeft = 0
+	u.ClearOrders()
+}
+
+// spendAttack uses up the movement an attack costs: AttackMovementCost for
+// hit-and-run units, which may move or attack again with what is left, and
+// all of it for the rest
+func (u *Unit) spendAttack() {
+	u.Acted = true
+	if !u.Template().HitAndRun {
+		u.MovementLeft = 0
+		return
+	}
+	u.MovementLeft -= AttackMovementCost
+	if u.MovementLeft < 0 {
+		u.MovementLeft = 0
+	}
 }
 
 // Unfortify removes fortified status
@@ -212,6 +331,13 @@ func (u *Unit) Unfortify() {
 	u.IsFortified = false
 }
 
+// ClearOrders cancels the unit's standing order
+func (u *Unit) ClearOrders() {
+	u.Mode = ModeNone
+	u.Patrol = nil
+	u.PatrolIndex = 0
+}
+
 // TakeDamage reduces unit health
 func (u *Unit) TakeDamage(damage int) {
 	u.Health -= damage
@@ -239,3 +365,18 @@ func (u *Unit) CanBuildRoad() bool {
 func (u *Unit) IsSiegeUnit() bool {
 	return u.Template().IsSiege
 }
+
+// IsNuclear returns whether this unit is a nuclear missile
+func (u *Unit) IsNuclear() bool {
+	return u.Template().IsNuclear
+}
+
+// HelpsWonder returns whether this unit can be added to a wonder
+func (u *Unit) HelpsWonder() bool {
+	return u.Template().HelpsWonder
+}
+
+// IsKing returns whether this unit is its owner's King
+func (u *Unit) IsKing() bool {
+	return u.Template().IsKing
+}
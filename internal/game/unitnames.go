@@ -0,0 +1,65 @@
+package game
+
+import (
+	"civilization/internal/locale"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxUnitNameLength is the longest name a player can give a unit, in characters
+const MaxUnitNameLength = 24
+
+// honorifics are the titles units earn by winning battles, from the fewest
+// battles won to the most. Titles are messages of the game's language pack.
+var honorifics = []struct {
+	wins int
+	key  string
+}{
+	{3, "honorific.brave"},
+	{6, "honorific.valiant"},
+	{10, "honorific.invincible"},
+}
+
+// honor gives a unit the highest honorific its battles won have earned,
+// in the game's language
+func (g *GameState) honor(u *Unit) {
+	for i := len(honorifics) - 1; i >= 0; i-- {
+		if u.XP >= honorifics[i].wins {
+			u.Honorific = locale.Get(g.Config.Locale).Text(honorifics[i].key)
+			return
+		}
+	}
+}
+
+// RenameUnitAction gives a unit a name of its own, or with an empty name
+// takes it away
+type RenameUnitAction struct {
+	UnitID string `json:"unit_id"`
+	Name   string `json:"name"`
+}
+
+// Type returns the action type name
+func (a *RenameUnitAction) Type() string {
+	return "rename_unit"
+}
+
+// Validate checks if the unit can take the name
+func (a *RenameUnitAction) Validate(g *GameState, playerID string) error {
+	if _, err := g.ownUnit(playerID, a.UnitID); err != nil {
+		return err
+	}
+	if name := strings.TrimSpace(a.Name); utf8.RuneCountInString(name) > MaxUnitNameLength {
+		return &ActionError{Err: ErrInvalidUnitName, UnitID: a.UnitID, Item: name}
+	}
+	return nil
+}
+
+// Execute renames the unit
+func (a *RenameUnitAction) Execute(g *GameState) (*Result, error) {
+	unit := g.GetUnit(a.UnitID)
+	if unit == nil {
+		return nil, ErrUnitNotFound
+	}
+	unit.Name = strings.TrimSpace(a.Name)
+	return &Result{Units: []string{unit.ID}}, nil
+}
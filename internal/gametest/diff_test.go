@@ -0,0 +1,48 @@
+package gametest_test
+
+import (
+	"civilization/internal/game"
+	. "civilization/internal/gametest"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	before := g.Clone()
+	if diffs := game.Diff(before, g); len(diffs) != 0 {
+		t.Fatalf("a game differs from its copy: %v", diffs)
+	}
+	replayed, err := game.Replay(g.EventLog(), g.Seq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := game.Diff(g, replayed); len(diffs) != 0 {
+		t.Fatalf("a game differs from its replay: %v", diffs)
+	}
+
+	g.GetUnit("u1").X = 3
+	g.GetCity("Beta").Population = 2
+	b.Unit("bob", game.UnitWarrior, 6, 2)
+	want := map[game.Difference]bool{
+		{Kind: "unit", ID: "u1", Field: "x", A: "2", B: "3"}:                          true,
+		{Kind: "city", ID: g.GetCity("Beta").ID, Field: "population", A: "1", B: "2"}: true,
+	}
+	var added int
+	for _, d := range game.Diff(before, g) {
+		switch {
+		case want[d]:
+			delete(want, d)
+		case d.Kind == "unit" && d.A == "" && d.B != "":
+			added++
+		}
+	}
+	if len(want) > 0 || added != 1 {
+		t.Errorf("missed %v and found %d units added, want 1", want, added)
+	}
+}
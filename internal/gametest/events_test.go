@@ -0,0 +1,126 @@
+package gametest_test
+
+import (
+	"bytes"
+	"civilization/internal/game"
+	. "civilization/internal/gametest"
+	"errors"
+	"testing"
+)
+
+// errBroken is what the actions of the tests below fail with
+var errBroken = errors.New("broken action")
+
+// failingAction passes validation and fails to execute, having first
+// fortified a unit if harm is set
+type failingAction struct {
+	UnitID string
+	Harm   bool
+}
+
+func (a *failingAction) Type() string                               { return "fortify" }
+func (a *failingAction) Validate(g *game.GameState, _ string) error { return nil }
+func (a *failingAction) Execute(g *game.GameState) (*game.Result, error) {
+	if a.Harm {
+		g.GetUnit(a.UnitID).IsFortified = true
+	}
+	return nil, errBroken
+}
+
+// TestEventLog plays a few actions and checks that each is recorded with
+// the next sequence number and its turn, and that the log replays and
+// undoes them
+func TestEventLog(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("bob", game.UnitWarrior, 7, 3)
+	b.City("alice", "Alpha", 2, 3, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+	Run(t, g,
+		Do("alice", &game.FortifyAction{UnitID: "u1"}),
+		EndTurn("alice"),
+		EndTurn("bob"),
+		Do("alice", &game.WakeAction{UnitID: "u1"}),
+	)
+
+	if g.Seq != 4 || len(g.Events) != 4 {
+		t.Fatalf("sequence %d with %d events, want 4 of each", g.Seq, len(g.Events))
+	}
+	for i, e := range g.Events {
+		if e.Seq != uint64(i+1) || e.Hash == "" {
+			t.Errorf("event %d recorded as %d with hash %q", i+1, e.Seq, e.Hash)
+		}
+	}
+	if last := g.Events[3]; last.Turn != 2 || last.PlayerID != "alice" || last.Type != "wake" {
+		t.Errorf("last event %+v, want alice waking her warrior on turn 2", last)
+	}
+	if since := g.EventsSince(2); len(since) != 2 || since[0].Seq != 3 {
+		t.Errorf("events since 2 are %d starting at %d, want 3 and 4", len(since), since[0].Seq)
+	}
+	AssertReplays(t, g)
+
+	undone, err := g.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone.Seq != 3 || !undone.GetUnit("u1").IsFortified {
+		t.Errorf("undo left sequence %d with the warrior fortified %v, want 3 and fortified", undone.Seq, undone.GetUnit("u1").IsFortified)
+	}
+}
+
+// TestFailedExecute checks that an action failing to execute is not
+// recorded, and that in debug mode one failing after it changed the game
+// stops it
+func TestFailedExecute(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	g := b.Start()
+
+	before := Snapshot(t, g)
+	if _, err := g.Apply("alice", &failingAction{UnitID: "u1"}); !errors.Is(err, errBroken) {
+		t.Fatalf("failing action returned %v", err)
+	}
+	if g.Seq != 0 || len(g.Events) != 0 || !bytes.Equal(Snapshot(t, g), before) {
+		t.Errorf("failed action left sequence %d with %d events", g.Seq, len(g.Events))
+	}
+
+	defer func(check bool) { game.CheckInvariantsAfterActions = check }(game.CheckInvariantsAfterActions)
+	game.CheckInvariantsAfterActions = true
+	if _, err := g.Apply("alice", &failingAction{UnitID: "u1"}); !errors.Is(err, errBroken) {
+		t.Errorf("failing action returned %v in debug mode", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("an action failing after it changed the game did not stop it")
+		}
+	}()
+	g.Apply("alice", &failingAction{UnitID: "u1", Harm: true})
+}
+
+// TestCloneSharesEvents checks that a copy of a game shares its event log
+// without either seeing what the other appends to it
+func TestCloneSharesEvents(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("alice", game.UnitWarrior, 3, 2)
+	g := b.Start()
+	Run(t, g, Do("alice", &game.FortifyAction{UnitID: "u1"}))
+
+	c := g.Clone()
+	if len(c.Events) != 1 || &c.Events[0] != &g.Events[0] {
+		t.Fatal("the copy does not share the event log")
+	}
+	Run(t, c, Do("alice", &game.FortifyAction{UnitID: "u2"}))
+	Run(t, g, Do("alice", &game.SkipUnitAction{UnitID: "u2"}))
+
+	if g.Events[1].Type != "skip" || c.Events[1].Type != "fortify" {
+		t.Errorf("the game recorded %s and its copy %s, want skip and fortify", g.Events[1].Type, c.Events[1].Type)
+	}
+	AssertReplays(t, g)
+	AssertReplays(t, c)
+}
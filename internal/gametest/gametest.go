@@ -0,0 +1,215 @@
+// Package gametest builds small scripted games for testing the game rules.
+// A test lays out a map as rows of characters, places players, units and
+// cities with fixed IDs, plays a script of actions and compares the state
+// the game ends in against a golden file in testdata.
+package gametest
+
+import (
+	"civilization/internal/game"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Seed is the random seed of scripted games unless a test sets another
+const Seed = 1
+
+// Island is a small map with room for two players, for tests that need no
+// particular layout
+var Island = []string{
+	"~~~~~~~~~~",
+	"~ggppggfg~",
+	"~gghfggpg~",
+	"~ggpggphg~",
+	"~ggfgpmgg~",
+	"~~~~~~~~~~",
+}
+
+// terrainChars maps the characters of a map layout to terrain
+var terrainChars = map[rune]game.TerrainType{
+	'~': game.TerrainOcean,
+	'g': game.TerrainGrassland,
+	'p': game.TerrainPlains,
+	'd': game.TerrainDesert,
+	'h': game.TerrainHills,
+	'm': game.TerrainMountains,
+	'f': game.TerrainForest,
+}
+
+// Builder sets up a scripted game before it starts
+type Builder struct {
+	tb    testing.TB
+	game  *game.GameState
+	units int
+}
+
+// New lays out a map, one string per row: ~ ocean, g grassland, p plains,
+// d desert, h hills, m mountains and f forest
+func New(tb testing.TB, rows ...string) *Builder {
+	tb.Helper()
+	if len(rows) == 0 {
+		tb.Fatal("gametest: empty map")
+	}
+
+	width, height := len(rows[0]), len(rows)
+	m := game.NewGameMap(width, height)
+	for y, row := range rows {
+		if len(row) != width {
+			tb.Fatalf("gametest: row %d is %d tiles wide, expected %d", y, len(row), width)
+		}
+		for x, c := range row {
+			terrain, ok := terrainChars[c]
+			if !ok {
+				tb.Fatalf("gametest: unknown terrain %q at (%d, %d)", c, x, y)
+			}
+			m.GetTileUnsafe(x, y).Terrain = terrain
+		}
+	}
+
+	config := game.DefaultGameConfig()
+	config.Seed = Seed
+	config.MapWidth = width
+	config.MapHeight = height
+	config.PlayerCount = 1
+
+	g := game.NewGame(config)
+	g.ID = "test"
+	g.Players = g.Players[:0]
+	g.SetMap(m)
+	return &Builder{tb: tb, game: g}
+}
+
+// Config changes the configuration of the game, e.g. to set another seed
+func (b *Builder) Config(change func(config *game.GameConfig)) *Builder {
+	change(&b.game.Config)
+	b.game.Seed = b.game.Config.Seed
+	return b
+}
+
+// Player adds a player with the given ID, which is also their name
+func (b *Builder) Player(id string, playerType game.PlayerType) *game.Player {
+	p := game.NewPlayer(id, playerType, len(b.game.Players))
+	p.ID = id
+	b.game.Players = append(b.game.Players, p)
+	b.game.Config.PlayerCount = len(b.game.Players)
+	return p
+}
+
+// Unit places a unit of a player. Units are numbered in the order they are
+// placed: u1, u2 and so on.
+func (b *Builder) Unit(playerID string, unitType game.UnitType, x, y int) *game.Unit {
+	b.tb.Helper()
+	p := b.player(playerID)
+	b.units++
+	u := game.NewUnit(unitType, p.ID, x, y)
+	u.ID = fmt.Sprintf("u%d", b.units)
+	p.AddUnit(u)
+	return u
+}
+
+// City places a city of a player, whose ID is its name, on a road as
+// founded cities are. A player's first city is their capital.
+func (b *Builder) City(playerID, name string, x, y, population int) *game.City {
+	b.tb.Helper()
+	p := b.player(playerID)
+	c := game.NewCity(name, p.ID, x, y)
+	c.ID = name
+	c.Population = population
+	if len(p.Cities) == 0 {
+		c.AddBuilding(game.BuildingPalace)
+		c.OriginalCapital = p.ID
+	}
+	p.AddCity(c)
+	b.game.Map.SetRoad(x, y)
+	return c
+}
+
+// Tile returns a tile of the map to change before the game starts
+func (b *Builder) Tile(x, y int) *game.Tile {
+	b.tb.Helper()
+	tile := b.game.Map.GetTile(x, y)
+	if tile == nil {
+		b.tb.Fatalf("gametest: no tile at (%d, %d)", x, y)
+	}
+	return tile
+}
+
+// Scenario loads the scenario file at path and attaches it to the game
+func (b *Builder) Scenario(path string) *game.Scenario {
+	b.tb.Helper()
+	s, err := game.LoadScenario(path)
+	if err != nil {
+		b.tb.Fatalf("gametest: %v", err)
+	}
+	if err := b.game.SetScenario(s); err != nil {
+		b.tb.Fatalf("gametest: %v", err)
+	}
+	return s
+}
+
+// Start starts the game and returns it
+func (b *Builder) Start() *game.GameState {
+	b.tb.Helper()
+	if len(b.game.Players) == 0 {
+		b.tb.Fatal("gametest: no players")
+	}
+	b.game.Start()
+	return b.game
+}
+
+// player returns a player that has been added
+func (b *Builder) player(id string) *game.Player {
+	b.tb.Helper()
+	p := b.game.GetPlayer(id)
+	if p == nil {
+		b.tb.Fatalf("gametest: no player %q", id)
+	}
+	return p
+}
+
+// Step is one action of a script, taken by a player
+type Step struct {
+	Player string
+	Action game.Action
+	Err    error // Error the action must fail with, nil if it must succeed
+}
+
+// Do is a step that must succeed
+func Do(playerID string, action game.Action) Step {
+	return Step{Player: playerID, Action: action}
+}
+
+// Fail is a step that must fail with err and leave the game unchanged
+func Fail(playerID string, action game.Action, err error) Step {
+	return Step{Player: playerID, Action: action, Err: err}
+}
+
+// EndTurn is a step ending a player's turn
+func EndTurn(playerID string) Step {
+	return Do(playerID, &game.EndTurnAction{})
+}
+
+// Run plays a script. Like a client, a player can only act on their turn.
+// The game's invariants are checked after every step.
+func Run(tb testing.TB, g *game.GameState, steps ...Step) {
+	tb.Helper()
+	for i, step := range steps {
+		if !g.IsCurrentPlayerTurn(step.Player) {
+			tb.Fatalf("step %d (%s): it is not %s's turn", i+1, step.Action.Type(), step.Player)
+		}
+
+		before := Snapshot(tb, g)
+		_, err := g.Apply(step.Player, step.Action)
+		switch {
+		case step.Err == nil && err != nil:
+			tb.Fatalf("step %d (%s): %v", i+1, step.Action.Type(), err)
+		case step.Err != nil && !errors.Is(err, step.Err):
+			tb.Fatalf("step %d (%s): got error %v, want %v", i+1, step.Action.Type(), err, step.Err)
+		case step.Err != nil && string(Snapshot(tb, g)) != string(before):
+			tb.Fatalf("step %d (%s): the refused action changed the game", i+1, step.Action.Type())
+		}
+		if err := g.CheckInvariants(); err != nil {
+			tb.Fatalf("step %d (%s): %v", i+1, step.Action.Type(), err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gametest
+
+import (
+	"bytes"
+	"civilization/internal/game"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites golden files with the state the games end in:
+//
+//	go test ./internal/gametest -update
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// Snapshot returns the full state of a game as indented JSON
+func Snapshot(tb testing.TB, g *game.GameState) []byte {
+	tb.Helper()
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		tb.Fatalf("gametest: snapshot: %v", err)
+	}
+	return append(data, '\n')
+}
+
+// AssertGolden compares the state of a game with testdata/<name>.golden.json
+func AssertGolden(tb testing.TB, name string, g *game.GameState) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden.json")
+	got := Snapshot(tb, g)
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("gametest: %v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("gametest: state differs from %s at line %d (run with -update if the change is intended)", path, diffLine(got, want))
+	}
+}
+
+// AssertReplays checks that replaying the game's event log rebuilds the
+// game exactly
+func AssertReplays(tb testing.TB, g *game.GameState) {
+	tb.Helper()
+	replayed, err := game.Replay(g.EventLog(), g.Seq)
+	if err != nil {
+		tb.Fatalf("gametest: replay: %v", err)
+	}
+	if !bytes.Equal(Snapshot(tb, replayed), Snapshot(tb, g)) {
+		tb.Errorf("gametest: replaying the event log gives a different game")
+	}
+}
+
+// diffLine returns the first line at which two snapshots differ
+func diffLine(a, b []byte) int {
+	line := 1
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return line
+		}
+		if a[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package gametest_test
+
+import (
+	"civilization/internal/game"
+	. "civilization/internal/gametest"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestHashCoversTiles checks that a change to any field of a tile changes
+// the game's hash, as tiles are hashed field by field
+func TestHashCoversTiles(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	g := b.Start()
+	hash := g.Hash()
+
+	tile := reflect.ValueOf(&g.Map.Tiles[0]).Elem()
+	for i := 0; i < tile.NumField(); i++ {
+		field := tile.Field(i)
+		saved := reflect.New(field.Type()).Elem()
+		saved.Set(field)
+		switch field.Kind() {
+		case reflect.Int:
+			field.SetInt(field.Int() + 1)
+		case reflect.Bool:
+			field.SetBool(!field.Bool())
+		case reflect.String:
+			field.SetString(field.String() + "x")
+		default:
+			t.Fatalf("tile field %s is a %s, which the test cannot change", tile.Type().Field(i).Name, field.Kind())
+		}
+		if g.Hash() == hash {
+			t.Errorf("changing tile field %s leaves the hash as it was", tile.Type().Field(i).Name)
+		}
+		field.Set(saved)
+	}
+	if g.Hash() != hash {
+		t.Error("the hash changed with the game as it was")
+	}
+}
+
+// TestReplayDesync checks that a replay leading to another state than an
+// event was recorded with says where it parted from the game
+func TestReplayDesync(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	g := b.Start()
+	Run(t, g,
+		Do("alice", &game.FortifyAction{UnitID: "u1"}),
+		Do("alice", &game.EndTurnAction{}),
+	)
+	AssertReplays(t, g)
+
+	log := g.EventLog()
+	events := append([]game.Event(nil), log.Events...)
+	events[0].Hash = "0000000000000000"
+	_, err := game.Replay(&game.EventLog{Base: log.Base, Events: events}, g.Seq)
+	var desync *game.DesyncError
+	if !errors.As(err, &desync) || !errors.Is(err, game.ErrDesync) {
+		t.Fatalf("replay with a wrong hash returned %v, want a desync", err)
+	}
+	if desync.Seq != events[0].Seq || desync.Got != g.Events[0].Hash || desync.Game.Seq != g.Seq {
+		t.Errorf("desync at event %d hashing %s, replayed to %d", desync.Seq, desync.Got, desync.Game.Seq)
+	}
+}
@@ -0,0 +1,93 @@
+package gametest_test
+
+import (
+	"bufio"
+	"bytes"
+	"civilization/internal/game"
+	. "civilization/internal/gametest"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInvariantsCatchCorruption(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.City("alice", "Alpha", 2, 2, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	if err := g.CheckInvariants(); err != nil {
+		t.Fatalf("fresh game: %v", err)
+	}
+
+	corruptions := map[string]func(){
+		"unit at sea":       func() { g.GetUnit("u1").X = 0 },
+		"unit off the map":  func() { g.GetUnit("u1").Y = -1 },
+		"negative movement": func() { g.GetUnit("u1").MovementLeft = -1 },
+		"dead unit":         func() { g.GetUnit("u1").Health = 0 },
+		"unknown owner":     func() { g.GetUnit("u1").OwnerID = "carol" },
+		"empty city":        func() { g.GetCity("Beta").Population = 0 },
+		"shared tile":       func() { g.GetCity("Beta").X = 2 },
+		"duplicate unit ID": func() { g.GetPlayer("bob").AddUnit(&game.Unit{ID: "u1", X: 7, Y: 2, Health: 100}) },
+	}
+	for name, corrupt := range corruptions {
+		t.Run(name, func(t *testing.T) {
+			saved := g.Clone()
+			corrupt()
+			if err := g.CheckInvariants(); !errors.Is(err, game.ErrInvariant) {
+				t.Errorf("got %v, want a broken invariant", err)
+			}
+			*g = *saved
+		})
+	}
+}
+
+// FuzzActions plays scripts of actions, one "type payload" per line, for
+// whoever is to move, and checks the game still holds together after each
+func FuzzActions(f *testing.F) {
+	f.Add([]byte(`move {"unit_id":"u1","to_x":3,"to_y":2}
+attack {"attacker_id":"u1","target_x":4,"target_y":2}
+end_turn {}`))
+	f.Add([]byte(`found_city {"settler_id":"u2","city_name":"Delta"}
+fortify {"unit_id":"u1"}
+end_turn {}
+attack {"attacker_id":"u3","target_x":3,"target_y":2}
+end_turn {}`))
+	f.Add([]byte(`set_production {"city_id":"Alpha","build_item":{"is_unit":true,"unit_type":3}}
+build_road {"unit_id":"u2"}
+set_mode {"unit_id":"u1","mode":"sentry"}
+end_turn {}
+end_turn {}`))
+
+	f.Fuzz(func(t *testing.T, script []byte) {
+		b := New(t, Island...)
+		b.Player("alice", game.PlayerHuman)
+		b.Player("bob", game.PlayerHuman)
+		b.Unit("alice", game.UnitArcher, 2, 2)
+		b.Unit("alice", game.UnitSettler, 3, 3)
+		b.Unit("bob", game.UnitHorseman, 4, 2)
+		b.City("alice", "Alpha", 1, 1, 2)
+		b.City("bob", "Beta", 7, 2, 3)
+		g := b.Start()
+
+		lines := bufio.NewScanner(bytes.NewReader(script))
+		for n := 0; lines.Scan() && n < 50; n++ {
+			actionType, payload, _ := strings.Cut(lines.Text(), " ")
+			action, err := game.DecodeAction(actionType, []byte(payload))
+			if err != nil {
+				continue
+			}
+			player := g.GetCurrentPlayer()
+			if player == nil {
+				return
+			}
+			g.Apply(player.ID, action)
+			if err := g.CheckInvariants(); err != nil {
+				t.Fatalf("after %s %s: %v", actionType, payload, err)
+			}
+		}
+	})
+}
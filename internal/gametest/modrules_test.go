@@ -0,0 +1,124 @@
+package gametest_test
+
+import (
+	"civilization/internal/game"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLoadRules reads rules directories and checks what they load into, or
+// why they are refused
+func TestLoadRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string   // Part of the error, "" if the directory loads
+		units []string // The units loaded as name/attack, in order
+	}{
+		{"empty directory", map[string]string{"notes.txt": "not rules"}, "no rules files", nil},
+		{"one file", map[string]string{
+			"units.json": `{"units": [{"name": "Legion", "attack": 4, "defense": 2, "movement": 1, "cost": 40}]}`,
+		}, "", []string{"Legion/4"}},
+		// Files are read in name order, so when the rules are applied the
+		// Warrior of the later file replaces the earlier one
+		{"files in name order", map[string]string{
+			"20-late.json":  `{"units": [{"name": "Warrior", "attack": 2, "defense": 1, "movement": 1, "cost": 10}]}`,
+			"10-early.json": `{"units": [{"name": "Warrior", "attack": 1, "defense": 1, "movement": 1, "cost": 10}, {"name": "Legion", "attack": 4, "defense": 2, "movement": 1, "cost": 40}]}`,
+			"readme.md":     "ignored",
+		}, "", []string{"Warrior/1", "Legion/4", "Warrior/2"}},
+		{"unknown field", map[string]string{
+			"units.json": `{"units": [{"name": "Legion", "strength": 4}]}`,
+		}, "units.json", nil},
+		{"broken JSON", map[string]string{"a.json": `{}`, "b.json": `{"units": [`}, "b.json", nil},
+	}
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			rules, err := game.LoadRules(dir)
+			switch {
+			case c.want == "" && err != nil:
+				t.Fatalf("rules refused: %v", err)
+			case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+				t.Fatalf("got error %v, want one saying %q", err, c.want)
+			case c.want != "":
+				return
+			}
+
+			names := make([]string, len(rules.Units))
+			for i, u := range rules.Units {
+				names[i] = fmt.Sprintf("%s/%d", u.Name, u.Attack)
+			}
+			if strings.Join(names, ",") != strings.Join(c.units, ",") {
+				t.Errorf("loaded units %v, want %v", names, c.units)
+			}
+		})
+	}
+
+}
+
+// TestValidateRules breaks a valid set of rules one way at a time and checks
+// that each is refused with the reason
+func TestValidateRules(t *testing.T) {
+	valid := func() *game.Rules {
+		return &game.Rules{
+			Units: []game.UnitRule{
+				{Name: "Legion", Attack: 4, Defense: 2, Movement: 1, Cost: 40},
+				{Name: "Bomb", Attack: 99, Movement: 16, Cost: 160, Nuclear: true, RequiresWonder: game.BuildingManhattanProject.String()},
+				{Name: "King", Movement: 1, King: true},
+			},
+			Buildings: []game.BuildingRule{{Name: game.BuildingWalls.String(), Cost: 60}},
+			Terrain:   []game.TerrainRule{{Name: game.TerrainHills.String(), MovementCost: 2, DefenseBonus: 1.5, Food: 1}},
+			Resources: []game.ResourceRule{{Name: game.ResourceIron.String(), Production: 1, Terrain: []string{game.TerrainHills.String()}}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *game.Rules)
+		want   string // Part of the error, "" if the rules are valid
+	}{
+		{"valid", func(r *game.Rules) {}, ""},
+		{"unit without a name", func(r *game.Rules) { r.Units[0].Name = "" }, "unit without a name"},
+		{"negative attack", func(r *game.Rules) { r.Units[0].Attack = -1 }, "must not be negative"},
+		{"negative defense", func(r *game.Rules) { r.Units[0].Defense = -1 }, "must not be negative"},
+		{"no movement", func(r *game.Rules) { r.Units[0].Movement = 0 }, "movement must be at least 1"},
+		{"negative sight", func(r *game.Rules) { r.Units[0].Sight = -1 }, "sight must not be negative"},
+		{"free unit", func(r *game.Rules) { r.Units[0].Cost = 0 }, "cost must be at least 1"},
+		{"unknown wonder", func(r *game.Rules) { r.Units[1].RequiresWonder = "Colossus" }, "is not a wonder"},
+		{"building as wonder", func(r *game.Rules) { r.Units[1].RequiresWonder = game.BuildingWalls.String() }, "is not a wonder"},
+		{"unknown building", func(r *game.Rules) { r.Buildings[0].Name = "Moat" }, "unknown building"},
+		{"free building", func(r *game.Rules) { r.Buildings[0].Cost = 0 }, "cost must be at least 1"},
+		{"unknown terrain", func(r *game.Rules) { r.Terrain[0].Name = "Lava" }, "unknown terrain"},
+		{"free movement", func(r *game.Rules) { r.Terrain[0].MovementCost = 0 }, "movement cost must be at least 1"},
+		{"no defense bonus", func(r *game.Rules) { r.Terrain[0].DefenseBonus = 0 }, "defense bonus must be positive"},
+		{"negative terrain food", func(r *game.Rules) { r.Terrain[0].Food = -1 }, "yields must not be negative"},
+		{"negative terrain production", func(r *game.Rules) { r.Terrain[0].Production = -1 }, "yields must not be negative"},
+		{"unknown resource", func(r *game.Rules) { r.Resources[0].Name = "mithril" }, "unknown resource"},
+		{"negative resource trade", func(r *game.Rules) { r.Resources[0].Trade = -1 }, "yields must not be negative"},
+		{"resource without terrain", func(r *game.Rules) { r.Resources[0].Terrain = nil }, "needs at least one terrain"},
+		{"resource on unknown terrain", func(r *game.Rules) { r.Resources[0].Terrain = []string{"Lava"} }, `unknown terrain "Lava"`},
+	}
+	for _, c := range tests {
+		t.Run(c.name, func(t *testing.T) {
+			rules := valid()
+			c.change(rules)
+
+			err := rules.Validate()
+			switch {
+			case c.want == "" && err != nil:
+				t.Errorf("rules refused: %v", err)
+			case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+				t.Errorf("got error %v, want one saying %q", err, c.want)
+			}
+		})
+	}
+}
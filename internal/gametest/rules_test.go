@@ -0,0 +1,2265 @@
+package gametest_test
+
+import (
+	"bytes"
+	"civilization/internal/game"
+	. "civilization/internal/gametest"
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// endRound ends the turn of every player, in seat order
+func endRound(players ...string) []Step {
+	steps := make([]Step, len(players))
+	for i, p := range players {
+		steps[i] = EndTurn(p)
+	}
+	return steps
+}
+
+// rounds repeats endRound n times
+func rounds(n int, players ...string) []Step {
+	var steps []Step
+	for i := 0; i < n; i++ {
+		steps = append(steps, endRound(players...)...)
+	}
+	return steps
+}
+
+func TestFoundCityAndProduce(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 2, 2)
+	b.Unit("alice", game.UnitWarrior, 2, 3)
+	b.Unit("bob", game.UnitWarrior, 7, 3)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.FoundCityAction{SettlerID: "u1", CityName: "Alpha"}),
+		Do("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 2, ToY: 2}),
+	)
+	city := g.GetPlayer("alice").Cities[0]
+	Run(t, g, Do("alice", &game.SetProductionAction{
+		CityID:    city.ID,
+		BuildItem: game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior},
+	}))
+	Run(t, g, rounds(12, "alice", "bob")...)
+
+	AssertGolden(t, "found_city_and_produce", g)
+	AssertReplays(t, g)
+}
+
+func TestCombat(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitArcher, 3, 2)
+	b.Unit("alice", game.UnitHorseman, 3, 3)
+	b.Unit("bob", game.UnitWarrior, 4, 2)
+	b.Unit("bob", game.UnitPhalanx, 4, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 2}),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 4, TargetY: 3}),
+		EndTurn("alice"),
+		Do("bob", &game.FortifyAction{UnitID: "u4"}),
+		EndTurn("bob"),
+	)
+
+	if len(g.CombatLog) != 2 {
+		t.Fatalf("the combat log holds %d battles, want 2", len(g.CombatLog))
+	}
+	for _, e := range g.CombatLog {
+		if e.AttackerID != "alice" || e.DefenderID != "bob" || e.Odds <= 0 || e.Odds >= 1 {
+			t.Errorf("logged battle %+v, want alice attacking bob at odds between 0 and 1", e)
+		}
+	}
+	if got := len(g.CombatLogFor("bob")); got != 2 {
+		t.Errorf("bob fought in %d logged battles, want 2", got)
+	}
+
+	AssertGolden(t, "combat", g)
+	AssertReplays(t, g)
+}
+
+func TestCaptureCity(t *testing.T) {
+	b := New(t, Island...)
+	alice := b.Player("alice", game.PlayerHuman)
+	bob := b.Player("bob", game.PlayerHuman)
+	bob.Gold = 30
+	b.Unit("alice", game.UnitHorseman, 5, 2)
+	b.Unit("alice", game.UnitArcher, 5, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Gamma", 7, 4, 1) // Bob's capital stands
+	beta := b.City("bob", "Beta", 6, 2, 3)
+	beta.AddBuilding(game.BuildingBarracks)
+	b.Unit("bob", game.UnitWarrior, 7, 4)
+	g := b.Start()
+	aliceGold := alice.Gold
+
+	// The horseman wears the defenses down and the archer takes the city,
+	// sacking it for its 3 citizens and its barracks
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 2}),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 6, TargetY: 2}),
+	)
+	plunder := 3*game.PlunderPerCitizen + game.PlunderPerBuilding
+	if alice.Gold != aliceGold+plunder || bob.Gold != 0 {
+		t.Errorf("alice has %d gold and bob %d, want %d and none", alice.Gold, bob.Gold, aliceGold+plunder)
+	}
+	want := []game.CityCapture{{CityID: beta.ID, CityName: "Beta", From: "bob", To: "alice", Plunder: plunder}}
+	if captures := g.History[len(g.History)-1].Captures; !slices.Equal(captures, want) {
+		t.Errorf("captures %+v, want %+v", captures, want)
+	}
+	Run(t, g,
+		EndTurn("alice"),
+		EndTurn("bob"),
+	)
+
+	AssertGolden(t, "capture_city", g)
+	AssertReplays(t, g)
+}
+
+func TestRefusedActions(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.Unit("alice", game.UnitSettler, 6, 4)
+	b.Unit("bob", game.UnitWarrior, 8, 1)
+	b.City("bob", "Beta", 8, 2, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Fail("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 0, ToY: 1}, game.ErrInvalidMove),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 3, ToY: 1}, game.ErrInvalidMove),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u3", ToX: 7, ToY: 1}, game.ErrNotYourUnit),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u9", ToX: 2, ToY: 1}, game.ErrUnitNotFound),
+		Fail("alice", &game.AttackAction{AttackerID: "u1", TargetX: 2, TargetY: 2}, game.ErrInvalidTarget),
+		Fail("alice", &game.FoundCityAction{SettlerID: "u2"}, game.ErrCannotFoundCity),
+		Fail("alice", &game.FoundCityAction{SettlerID: "u1"}, game.ErrCannotFoundCity),
+		Fail("alice", &game.SetProductionAction{CityID: "Beta"}, game.ErrNotYourCity),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 2, ToY: 1}),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 3, ToY: 1}, game.ErrNoMovementLeft),
+		EndTurn("alice"),
+	)
+
+	AssertGolden(t, "refused_actions", g)
+	AssertReplays(t, g)
+}
+
+func TestCityNames(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 2, 2)
+	b.Unit("alice", game.UnitSettler, 4, 3)
+	b.City("bob", "Rome", 7, 2, 1)
+	g := b.Start()
+
+	// Alice leads the Romans, whose capital name bob's city already has
+	Run(t, g,
+		Fail("alice", &game.FoundCityAction{SettlerID: "u2", CityName: "rome"}, game.ErrCityNameTaken),
+		Do("alice", &game.FoundCityAction{SettlerID: "u1"}),
+		Do("alice", &game.FoundCityAction{SettlerID: "u2"}),
+	)
+	cities := g.GetPlayer("alice").Cities
+	if cities[0].Name != "Caesarea" || cities[1].Name != "Carthage" {
+		t.Fatalf("generated names %q and %q, want Caesarea and Carthage", cities[0].Name, cities[1].Name)
+	}
+
+	Run(t, g,
+		Fail("alice", &game.RenameCityAction{CityID: "Rome", Name: "Roma"}, game.ErrNotYourCity),
+		Fail("alice", &game.RenameCityAction{CityID: cities[0].ID, Name: "  "}, game.ErrInvalidCityName),
+		Fail("alice", &game.RenameCityAction{CityID: cities[0].ID, Name: "Carthage"}, game.ErrCityNameTaken),
+		Do("alice", &game.RenameCityAction{CityID: cities[1].ID, Name: "Carthago Nova"}),
+		Do("alice", &game.RenameCityAction{CityID: cities[0].ID, Name: "Carthage"}),
+		EndTurn("alice"),
+	)
+
+	AssertGolden(t, "city_names", g)
+	AssertReplays(t, g)
+}
+
+func TestUnitNames(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitCatapult, 3, 2).XP = 2
+	b.Unit("bob", game.UnitSettler, 4, 2)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Fail("alice", &game.RenameUnitAction{UnitID: "u2", Name: "Old Faithful"}, game.ErrNotYourUnit),
+		Fail("alice", &game.RenameUnitAction{UnitID: "u1", Name: "A Name Far Too Long For Any Unit"}, game.ErrInvalidUnitName),
+		Do("alice", &game.RenameUnitAction{UnitID: "u1", Name: " Old Faithful "}),
+	)
+	if name := g.GetUnit("u1").Name; name != "Old Faithful" {
+		t.Fatalf("unit named %q, want Old Faithful", name)
+	}
+
+	// The catapult's third battle won earns it an honorific
+	Run(t, g, Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 2}))
+	if catapult := g.GetUnit("u1"); catapult == nil || catapult.XP != 3 || catapult.Honorific != "the Brave" {
+		t.Fatalf("the catapult is %+v after winning its third battle, want it titled the Brave", catapult)
+	}
+
+	AssertGolden(t, "unit_names", g)
+	AssertReplays(t, g)
+}
+
+func TestCitiesApart(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 3, 2)
+	b.Unit("alice", game.UnitSettler, 6, 3)
+	b.City("alice", "Alpha", 2, 2, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	// No city may stand next to another, whoever owns it
+	Run(t, g,
+		Fail("alice", &game.FoundCityAction{SettlerID: "u1"}, game.ErrCannotFoundCity),
+		Fail("alice", &game.FoundCityAction{SettlerID: "u2"}, game.ErrCannotFoundCity),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 4, ToY: 2}),
+		Do("alice", &game.FoundCityAction{SettlerID: "u1"}),
+		EndTurn("alice"),
+	)
+
+	AssertGolden(t, "cities_apart", g)
+	AssertReplays(t, g)
+}
+
+func TestRoads(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("alice", "Gamma", 5, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	for x := 2; x <= 4; x++ {
+		b.Tile(x, 1).HasRoad = true
+	}
+	b.Tile(3, 2).HasRoad = true // Hills
+	b.Unit("alice", game.UnitHorseman, 2, 3)
+	b.Unit("alice", game.UnitHorseman, 2, 1)
+	g := b.Start()
+
+	// Stepping onto a road from open ground pays for the hills; along the
+	// road every step costs one
+	Run(t, g,
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 3, ToY: 2}),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 3, ToY: 1}, game.ErrNoMovementLeft),
+		Do("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 3, ToY: 1}),
+		Do("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 4, ToY: 1}),
+		Fail("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 5, ToY: 1}, game.ErrNoMovementLeft),
+		EndTurn("alice"),
+		EndTurn("bob"),
+	)
+
+	// Gamma is joined to the capital, Beta is bob's only city
+	alice, bob := g.GetPlayer("alice"), g.GetPlayer("bob")
+	if gold := g.Budget(alice, alice.TaxRate).TradeRouteGold; gold != game.TradeRouteGold {
+		t.Errorf("alice's trade routes earn %d gold, want %d from Gamma's", gold, game.TradeRouteGold)
+	}
+	if gold := g.Budget(bob, bob.TaxRate).TradeRouteGold; gold != 0 {
+		t.Errorf("bob's trade routes earn %d gold, want 0", gold)
+	}
+
+	AssertGolden(t, "roads", g)
+	AssertReplays(t, g)
+}
+
+func TestHarbor(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 1, 1, 1)
+	b.City("alice", "Inland", 5, 3, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	water := func() int {
+		n := 0
+		for _, tile := range g.GetCityTiles(alpha) {
+			if tile.IsWater() {
+				n++
+			}
+		}
+		return n
+	}
+	if n := water(); n != 0 {
+		t.Fatalf("Alpha works %d ocean tiles without a harbor", n)
+	}
+
+	harbor := game.BuildItem{Building: game.BuildingHarbor}
+	Run(t, g,
+		Fail("alice", &game.SetProductionAction{CityID: "Inland", BuildItem: harbor}, game.ErrNotCoastal),
+		Do("alice", &game.SetProductionAction{CityID: "Alpha", BuildItem: harbor}),
+	)
+	Run(t, g, rounds(15, "alice", "bob")...)
+
+	if !alpha.HasBuilding(game.BuildingHarbor) {
+		t.Fatal("Alpha has not built its harbor")
+	}
+	// Alpha's radius reaches 7 coastal ocean tiles
+	if n := water(); n != 7 {
+		t.Errorf("Alpha works %d ocean tiles with a harbor, want 7", n)
+	}
+
+	AssertGolden(t, "harbor", g)
+	AssertReplays(t, g)
+}
+
+func TestFords(t *testing.T) {
+	b := New(t,
+		"~~~~~~~~~",
+		"~ggg~ggg~",
+		"~ggg~ggg~",
+		"~ggg~ggg~",
+		"~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Tile(4, 2).Ford = true
+	b.Unit("alice", game.UnitWarrior, 3, 2)
+	b.Unit("alice", game.UnitSettler, 3, 1)
+	b.City("bob", "Beta", 6, 2, 1)
+	g := b.Start()
+
+	// Land units wade across the ford but not the open channel, and no
+	// city or road can be put on the water
+	Run(t, g,
+		Fail("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 4, ToY: 1}, game.ErrInvalidMove),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 4, ToY: 2}),
+		Do("alice", &game.MoveUnitAction{UnitID: "u2", ToX: 4, ToY: 2}),
+		Fail("alice", &game.FoundCityAction{SettlerID: "u2"}, game.ErrCannotFoundCity),
+		EndTurn("alice"),
+		EndTurn("bob"),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 5, ToY: 2}),
+	)
+
+	AssertGolden(t, "fords", g)
+	AssertReplays(t, g)
+}
+
+func TestDeals(t *testing.T) {
+	b := New(t,
+		"~~~~~~~~~~~~~~",
+		"~gggggggggggg~",
+		"~gggggggggggg~",
+		"~gggggggggggg~",
+		"~~~~~~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.City("alice", "Alpha", 2, 2, 1)
+	b.City("bob", "Beta", 11, 2, 1)
+	b.Unit("alice", game.UnitHorseman, 4, 2)
+	g := b.Start()
+	alice, bob := g.GetPlayer("alice"), g.GetPlayer("bob")
+
+	mapTrade := game.DealOffer{From: "alice", To: "bob", Deal: game.DealMapTrade}
+	vision := game.DealOffer{From: "alice", To: "bob", Deal: game.DealSharedVision}
+	Run(t, g,
+		Fail("alice", &game.ProposeDealAction{DealOffer: game.DealOffer{From: "alice", To: "alice", Deal: game.DealMapTrade}}, game.ErrInvalidPartner),
+		Fail("alice", &game.ProposeDealAction{DealOffer: game.DealOffer{From: "alice", To: "bob", Deal: "tribute"}}, game.ErrUnknownDeal),
+		Fail("alice", &game.ProposeDealAction{DealOffer: game.DealOffer{From: "bob", To: "alice", Deal: game.DealMapTrade}}, game.ErrNotYourDeal),
+		Do("alice", &game.ProposeDealAction{DealOffer: mapTrade}),
+		Fail("alice", &game.ProposeDealAction{DealOffer: mapTrade}, game.ErrDealOffered),
+		Fail("alice", &game.AcceptDealAction{DealOffer: mapTrade}, game.ErrNotYourDeal),
+		EndTurn("alice"),
+		Do("bob", &game.AcceptDealAction{DealOffer: mapTrade}),
+		Fail("bob", &game.AcceptDealAction{DealOffer: mapTrade}, game.ErrNoOffer),
+	)
+
+	// The trade merged what both had explored, once
+	if !g.IsExplored(bob, 2, 2) || !g.IsExplored(alice, 11, 2) {
+		t.Error("map trade did not merge the explored tiles")
+	}
+	if g.IsExplored(bob, 7, 2) {
+		t.Error("bob explored a tile neither player has seen")
+	}
+
+	Run(t, g,
+		EndTurn("bob"),
+		Do("alice", &game.ProposeDealAction{DealOffer: vision}),
+		EndTurn("alice"),
+		Do("bob", &game.AcceptDealAction{DealOffer: vision}),
+		EndTurn("bob"),
+		Fail("alice", &game.ProposeDealAction{DealOffer: vision}, game.ErrAlreadySharing),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 5, ToY: 2}),
+	)
+
+	// Under the pact bob sees what alice's units see, and is told so
+	event, err := g.Apply("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 6, ToY: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g.IsExplored(bob, 7, 2) {
+		t.Error("bob does not see what alice's horseman sees")
+	}
+	if len(event.Revealed["bob"]) == 0 {
+		t.Error("the move revealed no tiles to bob")
+	}
+
+	Run(t, g,
+		Do("alice", &game.CancelPactAction{PlayerID: "alice", PartnerID: "bob"}),
+		Fail("alice", &game.CancelPactAction{PlayerID: "alice", PartnerID: "bob"}, game.ErrNotSharing),
+		EndTurn("alice"),
+		EndTurn("bob"),
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 7, ToY: 2}),
+	)
+	if g.IsExplored(bob, 8, 1) {
+		t.Error("bob still sees through alice's units after the pact ended")
+	}
+
+	AssertGolden(t, "deals", g)
+	AssertReplays(t, g)
+}
+
+func TestLineOfSight(t *testing.T) {
+	b := New(t,
+		"~~~~~~~~~~~~~~",
+		"~gggggggggggg~",
+		"~ggfgggggmggg~",
+		"~gggggggggggg~",
+		"~~~~~~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitScout, 1, 2)
+	b.Unit("alice", game.UnitWarrior, 9, 2)
+	b.City("bob", "Beta", 12, 1, 1)
+	g := b.Start()
+	alice := g.GetPlayer("alice")
+
+	for _, c := range []struct {
+		x, y int
+		seen bool
+		why  string
+	}{
+		{3, 2, true, "the forest next to the scout"},
+		{4, 2, false, "the tile behind the forest"},
+		{3, 3, true, "two tiles from the scout past open ground"},
+		{11, 2, true, "two tiles from the warrior on the mountain"},
+		{6, 2, false, "three tiles from either unit"},
+	} {
+		if g.IsExplored(alice, c.x, c.y) != c.seen {
+			t.Errorf("alice sees %s (%d,%d): %v, want %v", c.why, c.x, c.y, !c.seen, c.seen)
+		}
+	}
+
+	// Forest still blocks the view at an angle; once level with the forest
+	// the scout sees past it
+	Run(t, g,
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 2, ToY: 3}),
+	)
+	if g.IsExplored(alice, 4, 2) {
+		t.Error("the scout sees through the forest at an angle")
+	}
+	Run(t, g,
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 3, ToY: 3}),
+	)
+	if !g.IsExplored(alice, 4, 2) {
+		t.Error("the scout does not see the tile behind the forest once past it")
+	}
+
+	AssertGolden(t, "line_of_sight", g)
+	AssertReplays(t, g)
+}
+
+func TestVeterans(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	city := b.City("alice", "Alpha", 2, 2, 1)
+	city.Buildings[game.BuildingBarracks] = true
+	city.CurrentBuild = &game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior}
+	b.Unit("alice", game.UnitHorseman, 5, 3)
+	b.Unit("bob", game.UnitWarrior, 6, 3)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	Run(t, g, Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 3}))
+	event := g.Events[len(g.Events)-1]
+	for _, u := range []*game.Unit{g.GetUnit("u1"), g.GetUnit("u2")} {
+		if u == nil {
+			continue
+		}
+		promoted := len(event.Promotions) > 0 && event.Promotions[0].UnitID == u.ID
+		if u.IsVeteran != promoted {
+			t.Errorf("%s is a veteran: %v, but the event promoted it: %v", u.ID, u.IsVeteran, promoted)
+		}
+		if promoted && u.VeteranOrigin != game.VeteranFromCombat {
+			t.Errorf("%s became a veteran from %q, want %q", u.ID, u.VeteranOrigin, game.VeteranFromCombat)
+		}
+	}
+
+	Run(t, g, rounds(10, "alice", "bob")...)
+	for _, u := range g.GetPlayer("alice").Units {
+		if u.Type == game.UnitWarrior && u.VeteranOrigin != game.VeteranFromBarracks {
+			t.Errorf("%s trained with barracks is a veteran from %q", u.ID, u.VeteranOrigin)
+		}
+	}
+
+	AssertGolden(t, "veterans", g)
+	AssertReplays(t, g)
+}
+
+func TestHitAndRun(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Config(func(config *game.GameConfig) { config.Seed = 2 }) // Every attacker survives
+	b.Unit("alice", game.UnitHorseman, 3, 2)
+	b.Unit("alice", game.UnitArcher, 3, 3)
+	b.Unit("bob", game.UnitSettler, 4, 2)
+	b.Unit("bob", game.UnitSettler, 4, 3)
+	b.Unit("bob", game.UnitSettler, 4, 4)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	// The horseman attacks twice on its two movement points, the archer
+	// attacks once and is done
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 2}),
+	)
+	if horseman := g.GetUnit("u1"); horseman == nil || horseman.MovementLeft != 1 {
+		t.Fatalf("the horseman has %+v after attacking, want 1 movement left", horseman)
+	}
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 3}),
+		Fail("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 4}, game.ErrNoMovementLeft),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 4, TargetY: 4}),
+		Fail("alice", &game.AttackAction{AttackerID: "u2", TargetX: 4, TargetY: 4}, game.ErrNoMovementLeft),
+	)
+
+	AssertGolden(t, "hit_and_run", g)
+	AssertReplays(t, g)
+}
+
+func TestWithdraw(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Config(func(config *game.GameConfig) {
+		config.Withdraw = true
+		config.WithdrawChance = 100
+	})
+	b.Unit("alice", game.UnitHorseman, 2, 2)
+	b.Unit("alice", game.UnitCatapult, 4, 3)
+	b.Unit("bob", game.UnitPhalanx, 3, 2).IsFortified = true
+	b.Unit("bob", game.UnitHorseman, 5, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	// The horseman loses to the phalanx fortified on the hills, and bob's
+	// horseman to the catapult, but both are faster than their opponent
+	// and withdraw
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 3, TargetY: 2}),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 5, TargetY: 3}),
+	)
+	for _, id := range []string{"u1", "u4"} {
+		if u := g.GetUnit(id); u == nil || u.Health == game.BaseHealthPoints {
+			t.Errorf("%s is %+v after losing, want it withdrawn with damage", id, u)
+		}
+	}
+	if catapult := g.GetUnit("u2"); catapult == nil || catapult.X != 4 {
+		t.Errorf("the catapult is %+v, want it kept out of the tile bob's horseman withdrew in", catapult)
+	}
+	for _, entry := range g.CombatLog {
+		if !entry.Withdrew || entry.AttackerLost || entry.DefenderLost {
+			t.Errorf("battle %+v, want the loser withdrawn", entry)
+		}
+	}
+
+	AssertGolden(t, "withdraw", g)
+	AssertReplays(t, g)
+}
+
+func TestFirstStrike(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitHorseman, 3, 3).Health = 20
+	b.Unit("alice", game.UnitHorseman, 3, 2)
+	b.Unit("bob", game.UnitArcher, 4, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 4, 3, 1).AddBuilding(game.BuildingWalls)
+	g := b.Start()
+
+	// Behind the walls the archer strikes first, destroying the wounded
+	// horseman before it can fight
+	odds, err := g.PreviewAttack("alice", "u1", 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if odds.FirstStrike != 20 || odds.WinChance != 0 {
+		t.Fatalf("attack odds %+v, want a first strike of 20 the horseman cannot win against", odds)
+	}
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 3}),
+	)
+	if g.GetUnit("u1") != nil || g.GetUnit("u3").Health != game.BaseHealthPoints {
+		t.Errorf("the wounded horseman survived or hurt the archer")
+	}
+
+	odds, err = g.PreviewAttack("alice", "u2", 4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if odds.FirstStrike != 20 || odds.WinChance == 0 {
+		t.Errorf("attack odds %+v, want a first strike of 20 the horseman can survive", odds)
+	}
+
+	AssertGolden(t, "first_strike", g)
+	AssertReplays(t, g)
+}
+
+func TestTerraform(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 3, 4) // Forest
+	b.Unit("alice", game.UnitSettler, 3, 2) // Hills
+	b.Unit("alice", game.UnitWarrior, 3, 4)
+	b.City("alice", "Alpha", 2, 3, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Fail("alice", &game.TerraformAction{UnitID: "u1", Job: "irrigate"}, game.ErrUnknownJob),
+		Fail("alice", &game.TerraformAction{UnitID: "u1", Job: game.JobMine}, game.ErrCannotWorkHere),
+		Fail("alice", &game.TerraformAction{UnitID: "u3", Job: game.JobClearForest}, game.ErrCannotTerraform),
+		Do("alice", &game.TerraformAction{UnitID: "u1", Job: game.JobClearForest}),
+		Do("alice", &game.TerraformAction{UnitID: "u2", Job: game.JobMine}),
+	)
+	if tile := g.Map.GetTile(3, 4); tile.Job != game.JobClearForest || tile.JobProgress != 1 {
+		t.Fatalf("the forest has job %q at %d turns, want clear_forest at 1", tile.Job, tile.JobProgress)
+	}
+
+	// Work goes on at the start of each of alice's turns; two more finish
+	// both jobs
+	production := g.GetCity("Alpha").Production
+	Run(t, g, endRound("alice", "bob")...)
+	if tile := g.Map.GetTile(3, 2); tile.HasMine || tile.JobProgress != 2 {
+		t.Errorf("the mine has %d turns of work after 2 turns (mined: %v), want 2", tile.JobProgress, tile.HasMine)
+	}
+	Run(t, g, endRound("alice", "bob")...)
+	if tile := g.Map.GetTile(3, 4); tile.Terrain != game.TerrainGrassland || tile.Job != "" {
+		t.Errorf("the cleared forest is %v with job %q, want grassland and no job", tile.Terrain, tile.Job)
+	}
+	if got := g.GetCity("Alpha").Production - production; got < game.ClearForestShields {
+		t.Errorf("Alpha gained %d production while the forest was cleared, want at least %d", got, game.ClearForestShields)
+	}
+	if g.GetUnit("u1").Mode != game.ModeNone {
+		t.Error("the worker did not stop when the forest was cleared")
+	}
+	if !g.Map.GetTile(3, 2).HasMine {
+		t.Error("the hills have no mine after 3 turns of work")
+	}
+
+	AssertGolden(t, "terraform", g)
+	AssertReplays(t, g)
+}
+
+func TestGameSpeed(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.Speed = game.SpeedMarathon })
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 1)
+	alpha.CurrentBuild = &game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior}
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	warrior := game.UnitTemplates[game.UnitWarrior].Cost
+	if got := alpha.CurrentBuild.CostAt(g.Config.Speed); got != 3*warrior {
+		t.Errorf("a warrior costs %d at marathon speed, want %d", got, 3*warrior)
+	}
+	if got, want := alpha.FoodNeededForGrowth(g.Config.Speed), 3*alpha.FoodNeededForGrowth(game.SpeedStandard); got != want {
+		t.Errorf("Alpha needs %d food to grow at marathon speed, want %d", got, want)
+	}
+
+	// The warrior is not done once the standard cost is paid
+	for alpha.Production < warrior {
+		Run(t, g, endRound("alice", "bob")...)
+	}
+	if n := len(g.GetPlayer("alice").Units); n != 0 {
+		t.Errorf("alice has %d units once Alpha paid a warrior's standard cost, want 0", n)
+	}
+
+	AssertGolden(t, "game_speed", g)
+	AssertReplays(t, g)
+}
+
+// TestEconomy checks that the end of a turn collects taxes and science
+// from trade at the player's tax rate, pays upkeep, and sells buildings
+// and then disbands units when the treasury cannot pay
+func TestEconomy(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 1)
+	alpha.AddBuilding(game.BuildingMarketplace)
+	beta := b.City("bob", "Beta", 7, 2, 1)
+	beta.AddBuilding(game.BuildingBarracks)
+	beta.AddBuilding(game.BuildingGranary)
+	beta.AddBuilding(game.BuildingWalls)
+	for i := 0; i < 8; i++ {
+		b.Unit("bob", game.UnitWarrior, 7, 3)
+	}
+	g := b.Start()
+	alice, bob := g.GetPlayer("alice"), g.GetPlayer("bob")
+
+	Run(t, g,
+		Fail("alice", &game.SetTaxRateAction{PlayerID: "alice", TaxRate: 55}, game.ErrInvalidTaxRate),
+		Fail("alice", &game.SetTaxRateAction{PlayerID: "bob", TaxRate: 0}, game.ErrNotYourTaxes),
+		Do("alice", &game.SetTaxRateAction{PlayerID: "alice", TaxRate: 100}),
+	)
+
+	// At full tax all of Alpha's trade is gold, half again with its
+	// marketplace
+	budget := g.Budget(alice, alice.TaxRate)
+	if want := budget.Trade + budget.Trade*game.MarketplaceBonus/100; budget.TaxGold != want || budget.Science != 0 {
+		t.Errorf("alice taxes %d gold and %d science of %d trade, want %d gold and none", budget.TaxGold, budget.Science, budget.Trade, want)
+	}
+	if budget.BuildingUpkeep != game.BuildingUpkeep[game.BuildingMarketplace] {
+		t.Errorf("alice pays %d upkeep, want the marketplace's", budget.BuildingUpkeep)
+	}
+	Run(t, g, EndTurn("alice"))
+	if alice.Gold != budget.Net {
+		t.Errorf("alice has %d gold, want %d", alice.Gold, budget.Net)
+	}
+	report := g.TakeTurnReport("alice")
+	if report == nil || report.Economy == nil || report.Economy.Budget != budget {
+		t.Errorf("alice's report has economy %+v, want %+v", report.Economy, budget)
+	}
+
+	// Bob's treasury is empty, so what he cannot pay is sold off: the
+	// walls and granary first, then the warriors beyond those supported
+	bobBudget := g.Budget(bob, bob.TaxRate)
+	if bobBudget.UnitUpkeep != (8-bob.FreeUnits())*game.UnitUpkeepGold {
+		t.Errorf("bob pays %d upkeep for 8 warriors, want %d", bobBudget.UnitUpkeep, (8-bob.FreeUnits())*game.UnitUpkeepGold)
+	}
+	Run(t, g, EndTurn("bob"))
+	economy := g.TakeTurnReport("bob").Economy
+	if bob.Gold != 0 || bobBudget.Net >= 0 {
+		t.Fatalf("bob has %d gold after a budget of %d, want a deficit leaving none", bob.Gold, bobBudget.Net)
+	}
+	if len(economy.BuildingsSold) != 3 || economy.BuildingsSold[0].Item != "Walls" || beta.HasBarracks() {
+		t.Errorf("Beta sold %+v, want its walls, granary and barracks", economy.BuildingsSold)
+	}
+	if len(economy.UnitsDisbanded) == 0 || len(bob.Units) != 8-len(economy.UnitsDisbanded) {
+		t.Errorf("bob disbanded %d warriors and has %d", len(economy.UnitsDisbanded), len(bob.Units))
+	}
+
+	AssertGolden(t, "economy", g)
+	AssertReplays(t, g)
+}
+
+// TestTileAllocation checks that cities whose radii overlap each work a
+// tile between them once, the closest city first, and that no city works
+// a tile in another player's territory
+func TestTileAllocation(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 1)
+	gamma := b.City("alice", "Gamma", 4, 2, 1)
+	beta := b.City("bob", "Beta", 6, 3, 1)
+	g := b.Start()
+
+	worked := make(map[*game.Tile]*game.City)
+	for _, city := range []*game.City{alpha, gamma, beta} {
+		for _, tile := range g.GetCityTiles(city) {
+			if other := worked[tile]; other != nil {
+				t.Errorf("(%d, %d) is worked by %s and %s", tile.X, tile.Y, other.Name, city.Name)
+			}
+			worked[tile] = city
+			if tile.Owner != city.OwnerID {
+				t.Errorf("%s works (%d, %d) in %s's territory", city.Name, tile.X, tile.Y, tile.Owner)
+			}
+		}
+	}
+
+	// Alpha is as close to (3, 2) as Gamma and was founded first; Gamma is
+	// closer to (5, 2) than Beta, and as close to (5, 3), which alice
+	// claims as the earlier player
+	for _, want := range []struct {
+		x, y int
+		city *game.City
+	}{{3, 2, alpha}, {1, 2, alpha}, {5, 2, gamma}, {5, 3, gamma}, {7, 3, beta}} {
+		if city := worked[g.Map.GetTile(want.x, want.y)]; city != want.city {
+			t.Errorf("(%d, %d) is worked by %v, want %s", want.x, want.y, city, want.city.Name)
+		}
+	}
+
+	Run(t, g, endRound("alice", "bob")...)
+	AssertGolden(t, "tile_allocation", g)
+	AssertReplays(t, g)
+}
+
+// awaitEvent ends turns in seat order until a random event of a kind
+// strikes the player whose turn ended, and returns it
+func awaitEvent(t *testing.T, g *game.GameState, kind string) game.RandomEvent {
+	t.Helper()
+	for i := 0; i < 40; i++ {
+		player := g.TurnOrder.Current
+		Run(t, g, EndTurn(player))
+		if r := g.TakeTurnReport(player); r != nil {
+			for _, e := range r.RandomEvents {
+				if e.Kind == kind {
+					return e
+				}
+			}
+		}
+	}
+	t.Fatalf("no %s in 40 turns", kind)
+	return game.RandomEvent{}
+}
+
+// TestEruption checks that a volcano by a city erupts, burying the land
+// around it in hills, killing the wounded units there and wounding the
+// rest, and costing the city population
+func TestEruption(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) {
+		config.RandomEvents = true
+		config.RandomEventChance = 100
+	})
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 2)
+	b.City("bob", "Beta", 7, 2, 1)
+	volcano := b.Tile(3, 3)
+	volcano.Terrain = game.TerrainMountains
+	volcano.Volcano = true
+	b.Tile(2, 3).HasIrrigation = true
+	wounded := b.Unit("alice", game.UnitWarrior, 4, 3)
+	wounded.Health = game.EruptionDamage - game.HealInField // Heals before the volcano erupts
+	healthy := b.Unit("alice", game.UnitWarrior, 4, 4)
+	distant := b.Unit("alice", game.UnitWarrior, 5, 3)
+	g := b.Start()
+
+	event := awaitEvent(t, g, game.EventEruption)
+	if event.X != 3 || event.Y != 3 || event.CityID != alpha.ID {
+		t.Errorf("eruption %+v, want the volcano at (3, 3) by Alpha", event)
+	}
+	if event.UnitsLost != 1 || g.GetUnit(wounded.ID) != nil {
+		t.Errorf("eruption killed %d units, want the wounded warrior", event.UnitsLost)
+	}
+	if healthy.Health >= game.BaseHealthPoints || distant.Health != game.BaseHealthPoints {
+		t.Errorf("warriors have %d and %d health after the eruption, want the first wounded and the second unhurt", healthy.Health, distant.Health)
+	}
+	if alpha.Population != 1 || event.Population != 1 {
+		t.Errorf("Alpha is size %d after the eruption, want 1", alpha.Population)
+	}
+
+	// All the land around but the city and the volcano cools to hills
+	if event.TilesChanged != 7 {
+		t.Errorf("eruption buried %d tiles, want 7", event.TilesChanged)
+	}
+	for _, tile := range g.Map.GetTilesInRadius(3, 3, 1) {
+		if tile == volcano || (tile.X == 2 && tile.Y == 2) {
+			continue
+		}
+		if tile.Terrain != game.TerrainHills || tile.HasIrrigation {
+			t.Errorf("(%d, %d) is %v after the eruption, want hills without irrigation", tile.X, tile.Y, tile.Terrain)
+		}
+	}
+
+	AssertGolden(t, "eruption", g)
+	AssertReplays(t, g)
+}
+
+// TestFlood checks that a river floods the city on it, spoiling its food
+// and washing away the irrigation of the river tiles it works
+func TestFlood(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) {
+		config.RandomEvents = true
+		config.RandomEventChance = 100
+	})
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.City("alice", "Alpha", 2, 2, 1)
+	beta := b.City("bob", "Beta", 7, 2, 1)
+	beta.FoodStore = 10
+	for _, pos := range [][2]int{{7, 2}, {8, 2}, {8, 3}} {
+		b.Tile(pos[0], pos[1]).HasRiver = true
+	}
+	b.Tile(8, 2).HasIrrigation = true
+	b.Tile(6, 2).HasIrrigation = true
+	g := b.Start()
+
+	event := awaitEvent(t, g, game.EventFlood)
+	if event.CityID != beta.ID || event.Food == 0 {
+		t.Errorf("flood %+v, want one spoiling Beta's food", event)
+	}
+	if event.TilesChanged != 1 || g.Map.GetTile(8, 2).HasIrrigation || !g.Map.GetTile(6, 2).HasIrrigation {
+		t.Errorf("flood washed out %d tiles, want the irrigation of the river tile alone", event.TilesChanged)
+	}
+
+	AssertGolden(t, "flood", g)
+	AssertReplays(t, g)
+}
+
+// TestRandomEventsDeterministic checks that random events are drawn from
+// the game's seed and sequence number alone: the same seed brings the same
+// events, and another seed other ones
+func TestRandomEventsDeterministic(t *testing.T) {
+	play := func(seed int64) (*game.GameState, []game.RandomEvent) {
+		b := New(t, Island...)
+		b.Config(func(config *game.GameConfig) {
+			config.Seed = seed
+			config.RandomEvents = true
+			config.RandomEventChance = 50
+		})
+		b.Player("alice", game.PlayerHuman)
+		b.Player("bob", game.PlayerHuman)
+		b.City("alice", "Alpha", 2, 2, 3)
+		b.City("bob", "Beta", 7, 2, 3)
+		g := b.Start()
+
+		var events []game.RandomEvent
+		for i := 0; i < 20; i++ {
+			player := g.TurnOrder.Current
+			Run(t, g, EndTurn(player))
+			if r := g.TakeTurnReport(player); r != nil {
+				events = append(events, r.RandomEvents...)
+			}
+		}
+		return g, events
+	}
+
+	g, events := play(Seed)
+	if len(events) == 0 {
+		t.Fatal("no random events struck in 20 turns")
+	}
+	again, eventsAgain := play(Seed)
+	if !reflect.DeepEqual(events, eventsAgain) || string(Snapshot(t, g)) != string(Snapshot(t, again)) {
+		t.Errorf("two games with seed %d differ, events %+v and %+v", Seed, events, eventsAgain)
+	}
+	if _, other := play(Seed + 1); reflect.DeepEqual(events, other) {
+		t.Errorf("seeds %d and %d brought the same events %+v", Seed, Seed+1, events)
+	}
+
+	AssertGolden(t, "random_events", g)
+	AssertReplays(t, g)
+}
+
+// TestResourceReveal checks that iron is hidden from a player until the
+// science they gather brings them Bronze Working
+func TestResourceReveal(t *testing.T) {
+	b := New(t, Island...)
+	alice := b.Player("alice", game.PlayerHuman)
+	alice.Science = game.TechCost[game.TechBronzeWorking] - 1
+	alice.TaxRate = 0
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 2, 2)
+	b.City("bob", "Beta", 7, 2, 1)
+	b.Tile(3, 2).Resource = game.ResourceIron
+	b.Tile(2, 3).Resource = game.ResourceWheat
+	g := b.Start()
+
+	var discovered []game.TechDiscovered
+	g.Bus().Subscribe(func(e game.BusEvent) {
+		if d, ok := e.(game.TechDiscovered); ok {
+			discovered = append(discovered, d)
+		}
+	})
+
+	if g.SeesResource(alice, game.ResourceIron) || !g.SeesResource(alice, game.ResourceWheat) {
+		t.Fatal("alice sees iron before Bronze Working, or not wheat")
+	}
+	Run(t, g, Do("alice", &game.FoundCityAction{SettlerID: "u1", CityName: "Alpha"}))
+	if found := g.TakeTurnReport("alice").ResourcesDiscovered; len(found) != 1 || found[0].Resource != game.ResourceWheat {
+		t.Errorf("Alpha found %+v, want the wheat and not the hidden iron", found)
+	}
+
+	Run(t, g, EndTurn("alice"))
+	if !g.Knows(alice, game.TechBronzeWorking) || !g.SeesResource(alice, game.ResourceIron) {
+		t.Error("alice does not see iron after gathering the science for Bronze Working")
+	}
+	if economy := g.TakeTurnReport("alice").Economy; len(economy.Discoveries) != 1 || economy.Discoveries[0] != "Bronze Working" {
+		t.Errorf("alice discovered %v, want Bronze Working", economy.Discoveries)
+	}
+	if want := (game.TechDiscovered{PlayerID: "alice", Tech: game.TechBronzeWorking}); len(discovered) != 1 || discovered[0] != want {
+		t.Errorf("published %+v, want %+v", discovered, want)
+	}
+
+	AssertGolden(t, "resource_reveal", g)
+	AssertReplays(t, g)
+}
+
+// TestLuxuries checks that a luxury a city works keeps a citizen content
+// in every city, that a city with more unhappy citizens than content ones
+// falls into disorder, and that a luxury shared in a deal changes hands
+// until the deal runs out
+func TestLuxuries(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.City("alice", "Alpha", 2, 2, 9)
+	beta := b.City("bob", "Beta", 7, 2, 9)
+	b.Tile(3, 2).Resource = game.ResourceSilk
+	g := b.Start()
+
+	cityHappiness := func(playerID string) game.CityHappiness {
+		t.Helper()
+		h, err := g.Happiness(playerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.Cities[0]
+	}
+	if c := cityHappiness("alice"); c.Content != game.ContentCitizens+game.LuxuryHappiness || c.Disorder {
+		t.Errorf("Alpha %+v, want the silk to keep a citizen more content", c)
+	}
+	if c := cityHappiness("bob"); c.Content != game.ContentCitizens || !c.Disorder {
+		t.Errorf("Beta %+v, want it in disorder without a luxury", c)
+	}
+
+	silk := game.DealOffer{From: "alice", To: "bob", Deal: game.DealLuxury, Resource: "silk"}
+	Run(t, g,
+		Fail("alice", &game.ProposeDealAction{DealOffer: game.DealOffer{From: "alice", To: "bob", Deal: game.DealLuxury, Resource: "wheat"}}, game.ErrNotLuxury),
+		Fail("alice", &game.ProposeDealAction{DealOffer: game.DealOffer{From: "alice", To: "bob", Deal: game.DealLuxury, Resource: "gems"}}, game.ErrNoSpareLuxury),
+		Do("alice", &game.ProposeDealAction{DealOffer: silk}),
+		EndTurn("alice"),
+		EndTurn("bob"),
+	)
+	if !beta.Disorder || beta.CalculateProductionPerTurn(g.GetCityTiles(beta)) != 0 {
+		t.Error("Beta is not in disorder, or produces in it")
+	}
+
+	Run(t, g,
+		EndTurn("alice"),
+		Do("bob", &game.AcceptDealAction{DealOffer: silk}),
+		EndTurn("bob"),
+		Fail("alice", &game.ProposeDealAction{DealOffer: silk}, game.ErrNoSpareLuxury),
+	)
+	if c := cityHappiness("bob"); c.Disorder || beta.Disorder {
+		t.Errorf("Beta %+v, want the silk alice shares to end its disorder", c)
+	}
+	if c := cityHappiness("alice"); !c.Disorder {
+		t.Errorf("Alpha %+v, want it in disorder with its silk given away", c)
+	}
+
+	Run(t, g, rounds(game.LuxuryDealTurns, "alice", "bob")...)
+	if len(g.LuxuryTrades) != 0 {
+		t.Errorf("luxury trades %+v, want the deal run out", g.LuxuryTrades)
+	}
+	if h, err := g.Happiness("alice"); err != nil || len(h.Luxuries) != 1 || !h.Luxuries[0].Has {
+		t.Errorf("alice has luxuries %+v, want the silk back", h.Luxuries)
+	}
+
+	AssertGolden(t, "luxuries", g)
+	AssertReplays(t, g)
+}
+
+// TestCelebration checks that a city whose luxuries keep half its
+// citizens content celebrates, earning more trade, and that its owner is
+// told as it starts and stops
+func TestCelebration(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 4)
+	b.City("bob", "Beta", 7, 2, 3)
+	b.Tile(3, 2).Resource = game.ResourceSilk
+	b.Tile(2, 3).Resource = game.ResourceGems
+	g := b.Start()
+
+	var changes []game.CelebrationChanged
+	g.Bus().Subscribe(func(e game.BusEvent) {
+		if c, ok := e.(game.CelebrationChanged); ok {
+			changes = append(changes, c)
+		}
+	})
+
+	tiles := g.GetCityTiles(alpha)
+	trade := alpha.CalculateTradePerTurn(tiles)
+	Run(t, g, EndTurn("alice"))
+	if !alpha.Celebrating || alpha.CalculateTradePerTurn(tiles) <= trade {
+		t.Error("Alpha is not celebrating, or earns no more trade for it")
+	}
+	if h, err := g.Happiness("bob"); err != nil || h.Cities[0].Celebrating {
+		t.Error("Beta celebrates without a luxury")
+	}
+
+	// With the silk given away, the gems are not enough
+	silk := game.DealOffer{From: "alice", To: "bob", Deal: game.DealLuxury, Resource: "silk"}
+	Run(t, g,
+		EndTurn("bob"),
+		Do("alice", &game.ProposeDealAction{DealOffer: silk}),
+		EndTurn("alice"),
+	)
+	if !alpha.Celebrating {
+		t.Error("Alpha stopped celebrating before the silk was given away")
+	}
+	Run(t, g,
+		Do("bob", &game.AcceptDealAction{DealOffer: silk}),
+		EndTurn("bob"),
+		EndTurn("alice"),
+	)
+	if alpha.Celebrating {
+		t.Error("Alpha still celebrates with its silk given away")
+	}
+	want := []game.CelebrationChanged{
+		{CityID: alpha.ID, PlayerID: "alice", CityName: "Alpha", Population: 4, Celebrating: true},
+		{CityID: alpha.ID, PlayerID: "alice", CityName: "Alpha", Population: 4, Celebrating: false},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("celebration changes %+v, want %+v", changes, want)
+	}
+
+	AssertGolden(t, "celebration", g)
+	AssertReplays(t, g)
+}
+
+// TestCaravans checks that a caravan given up in a city adds its cost to
+// the wonder there, and that the shields are lost when the city turns to
+// building something else
+func TestCaravans(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 2, 2, 3)
+	alpha.CurrentBuild = &game.BuildItem{Building: game.BuildingManhattanProject}
+	b.City("bob", "Beta", 7, 2, 1)
+	warrior := b.Unit("alice", game.UnitWarrior, 2, 2)
+	caravan := b.Unit("alice", game.UnitCaravan, 3, 2)
+	spare := b.Unit("alice", game.UnitCaravan, 2, 2)
+	g := b.Start()
+
+	Run(t, g,
+		Fail("alice", &game.HelpWonderAction{UnitID: warrior.ID}, game.ErrCannotHelpWonder),
+		Fail("alice", &game.HelpWonderAction{UnitID: caravan.ID}, game.ErrNoWonderHere),
+		Do("alice", &game.MoveUnitAction{UnitID: caravan.ID, ToX: 2, ToY: 2}),
+		EndTurn("alice"),
+		EndTurn("bob"),
+	)
+	produced := alpha.Production
+	Run(t, g, Do("alice", &game.HelpWonderAction{UnitID: caravan.ID}))
+	cost := game.UnitTemplates[game.UnitCaravan].Cost
+	if g.GetUnit(caravan.ID) != nil || alpha.Production != produced+cost || alpha.WonderShields != cost {
+		t.Errorf("Alpha has %d shields, %d from caravans, want %d and %d with the caravan gone", alpha.Production, alpha.WonderShields, produced+cost, cost)
+	}
+
+	Run(t, g,
+		Do("alice", &game.SetProductionAction{CityID: alpha.ID, BuildItem: game.BuildItem{Building: game.BuildingBarracks}}),
+		Fail("alice", &game.HelpWonderAction{UnitID: spare.ID}, game.ErrNoWonderHere),
+	)
+	if alpha.Production != produced || alpha.WonderShields != 0 {
+		t.Errorf("Alpha has %d shields after turning to barracks, want the %d it produced", alpha.Production, produced)
+	}
+
+	AssertGolden(t, "caravans", g)
+	AssertReplays(t, g)
+}
+
+// TestPalace checks that a city loses more of its trade and shields the
+// farther it is from the capital, that a Palace built elsewhere moves the
+// capital, and that taking every original capital wins the game
+func TestPalace(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	alpha := b.City("alice", "Alpha", 1, 1, 1)
+	delta := b.City("alice", "Delta", 8, 4, 1)
+	delta.CurrentBuild = &game.BuildItem{Building: game.BuildingPalace}
+	delta.Production = game.BuildingCosts[game.BuildingPalace] - 1
+	beta := b.City("bob", "Beta", 6, 2, 3)
+	b.City("bob", "Gamma", 3, 4, 1)
+	b.Unit("alice", game.UnitHorseman, 5, 2)
+	b.Unit("alice", game.UnitArcher, 5, 3)
+	g := b.Start()
+	alice, bob := g.GetPlayer("alice"), g.GetPlayer("bob")
+
+	if alice.Capital() != alpha || g.OriginalCapital("bob") != beta {
+		t.Fatal("the first cities are not the capitals")
+	}
+	Run(t, g, EndTurn("alice"))
+	if alice.Capital() != delta || alpha.HasBuilding(game.BuildingPalace) {
+		t.Error("the Palace built in Delta did not move the capital there")
+	}
+	if want := 7 * game.CorruptionPerTile; alpha.Corruption != want || delta.Corruption != 0 {
+		t.Errorf("Alpha and Delta lose %d%% and %d%% of their trade, want %d%% and none", alpha.Corruption, delta.Corruption, want)
+	}
+	if want := 7 * game.WastePerTile; alpha.Waste != want || delta.Waste != 0 {
+		t.Errorf("Alpha and Delta lose %d%% and %d%% of their shields, want %d%% and none", alpha.Waste, delta.Waste, want)
+	}
+	if g.OriginalCapital("alice") != alpha {
+		t.Error("moving the Palace changed the original capital")
+	}
+
+	// Taking Beta leaves bob without a capital, and alice holding both
+	Run(t, g,
+		EndTurn("bob"),
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 2}),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 6, TargetY: 2}),
+	)
+	if beta.OwnerID != "alice" || beta.HasBuilding(game.BuildingPalace) || bob.Capital() != nil {
+		t.Fatal("Beta was not taken, or kept its Palace")
+	}
+	if gamma := bob.Cities[0]; gamma.Corruption != game.MaxCorruption || gamma.Waste != game.MaxWaste {
+		t.Errorf("Gamma loses %d%% and %d%% without a capital, want %d%% and %d%%",
+			gamma.Corruption, gamma.Waste, game.MaxCorruption, game.MaxWaste)
+	}
+	Run(t, g, EndTurn("alice"))
+	if g.Winner != alice || g.Phase != game.PhaseGameOver {
+		t.Error("alice holds every original capital but has not won")
+	}
+
+	AssertGolden(t, "palace", g)
+	AssertReplays(t, g)
+}
+
+func TestAdvancedStart(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.AdvancedStart = 200 })
+	alice := b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	buy := func(item string, x, y int) *game.BuyStartAction {
+		return &game.BuyStartAction{PlayerID: "alice", Item: item, X: x, Y: y}
+	}
+	phalanx := buy(game.BuyUnit, 2, 2)
+	phalanx.UnitType = game.UnitPhalanx
+	Run(t, g,
+		Fail("alice", buy(game.BuyCity, 8, 4), game.ErrOutOfStartReach),
+		Fail("alice", &game.BuyStartAction{PlayerID: "bob", Item: game.BuyTech}, game.ErrNotYourPoints),
+		Do("alice", &game.BuyStartAction{PlayerID: "alice", Item: game.BuyCity, X: 2, Y: 2, CityName: "Alpha"}),
+		Do("alice", phalanx),
+		Fail("alice", buy(game.BuyUnit, 7, 2), game.ErrNotYourCity),
+		Do("alice", buy(game.BuyTech, 0, 0)),
+		Fail("alice", buy(game.BuyCity, 5, 4), game.ErrNotEnoughPoints),
+	)
+	if len(alice.Cities) != 1 || len(alice.Units) != 2 || !g.Knows(alice, game.TechBronzeWorking) {
+		t.Fatalf("alice has %d cities, %d units and %d science, want a city, a phalanx and Bronze Working",
+			len(alice.Cities), len(alice.Units), alice.Science)
+	}
+	if want := 200 - game.AdvancedStartCityCost - 20 - 30; alice.StartPoints != want {
+		t.Errorf("alice has %d start points left, want %d", alice.StartPoints, want)
+	}
+
+	// What is left is lost when the first turn ends
+	Run(t, g,
+		EndTurn("alice"),
+		EndTurn("bob"),
+		Fail("alice", buy(game.BuyTech, 0, 0), game.ErrNoStartPoints),
+	)
+
+	AssertGolden(t, "advanced_start", g)
+	AssertReplays(t, g)
+}
+
+func TestRegicide(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.Regicide = true })
+	alice := b.Player("alice", game.PlayerHuman)
+	bob := b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitArcher, 5, 2)
+	b.Unit("alice", game.UnitArcher, 5, 3)
+	b.Unit("bob", game.UnitKing, 6, 2) // Out of the city
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 2)
+	g := b.Start()
+	if king := alice.King(); king == nil || king.X != 1 || king.Y != 1 {
+		t.Fatalf("alice's king is %+v, want one crowned in Alpha", king)
+	}
+
+	// Kings are not built. The first archer wounds bob's King and the
+	// second kills it, putting bob out with a city still standing.
+	Run(t, g,
+		Fail("alice", &game.SetProductionAction{
+			CityID:    "Alpha",
+			BuildItem: game.BuildItem{IsUnit: true, UnitType: game.UnitKing},
+		}, game.ErrNotBuildable),
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 2}),
+	)
+	if bob.King() == nil {
+		t.Fatal("the first attack killed bob's King")
+	}
+	Run(t, g, Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 6, TargetY: 2}))
+	if bob.King() != nil || bob.IsAlive || len(bob.Cities) != 1 {
+		t.Fatalf("bob is alive %v with %d cities and King %+v, want bob out with Beta", bob.IsAlive, len(bob.Cities), bob.King())
+	}
+	if g.Phase != game.PhaseGameOver || g.Winner != alice {
+		t.Errorf("game is in %s won by %v, want alice to have won", g.Phase, g.Winner)
+	}
+
+	AssertGolden(t, "regicide", g)
+	AssertReplays(t, g)
+}
+
+func TestHandicaps(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.AIDifficulty = game.DifficultyHard })
+	alice := b.Player("alice", game.PlayerHuman)
+	bob := b.Player("bob", game.PlayerAI)
+	b.City("alice", "Alpha", 2, 2, 1)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	// Only the AI player gets the free veteran warrior and the bonus shields
+	if len(alice.Units) != 0 || len(bob.Units) != 1 || bob.Units[0].VeteranOrigin != game.VeteranFromHandicap {
+		t.Fatalf("alice has %d units and bob %+v, want bob alone to have a veteran warrior", len(alice.Units), bob.Units)
+	}
+	alpha, beta := alice.Cities[0], bob.Cities[0]
+	if alpha.Bonus != 0 || beta.Bonus != game.Handicaps[game.DifficultyHard].ProductionPercent {
+		t.Errorf("Alpha has a bonus of %d%% and Beta of %d%%, want Beta alone to have one", alpha.Bonus, beta.Bonus)
+	}
+	shields := func(city *game.City) int { return city.CalculateProductionPerTurn(g.GetCityTiles(city)) }
+	base := shields(beta)
+	beta.Bonus = 0
+	if plain := shields(beta); base <= plain {
+		t.Errorf("Beta makes %d shields with its bonus and %d without, want more with it", base, plain)
+	}
+	beta.Bonus = game.Handicaps[game.DifficultyHard].ProductionPercent
+
+	// Units the AI trains are veterans too
+	warriors := game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior}
+	Run(t, g,
+		Do("alice", &game.SetProductionAction{CityID: "Alpha", BuildItem: warriors}),
+		EndTurn("alice"),
+		Do("bob", &game.SetProductionAction{CityID: "Beta", BuildItem: warriors}),
+		EndTurn("bob"),
+	)
+	Run(t, g, rounds(4, "alice", "bob")...)
+	if len(bob.Units) < 2 || !bob.Units[1].IsVeteran || len(alice.Units) == 0 || alice.Units[0].IsVeteran {
+		t.Fatalf("alice has %+v and bob %+v, want bob's trained warrior alone to be a veteran", alice.Units, bob.Units)
+	}
+
+	AssertGolden(t, "handicaps", g)
+	AssertReplays(t, g)
+}
+
+func TestBorderHistory(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitSettler, 3, 2)
+	b.Unit("alice", game.UnitWarrior, 3, 3)
+	b.City("bob", "Beta", 7, 2, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.FoundCityAction{SettlerID: "u1", CityName: "Alpha"}),
+		EndTurn("alice"),
+		EndTurn("bob"),
+	)
+
+	// The turn's entry records Alpha's land changing hands, and the
+	// entries add up to the borders as they are
+	last := g.History[len(g.History)-1]
+	if !slices.ContainsFunc(last.Borders, func(c game.BorderChange) bool { return c.X == 3 && c.Y == 2 && c.Owner == "alice" }) {
+		t.Errorf("borders %+v of turn %d, want Alpha's tile given to alice", last.Borders, last.Turn)
+	}
+	owners := make(map[game.Coord]string)
+	for _, stats := range g.History {
+		for _, c := range stats.Borders {
+			owners[game.At(c.X, c.Y)] = c.Owner
+		}
+	}
+	territory := make(map[string]int)
+	for _, tile := range g.Map.Tiles {
+		if owners[tile.Coord()] != tile.Owner {
+			t.Errorf("history has %v owned by %q, want %q", tile.Coord(), owners[tile.Coord()], tile.Owner)
+		}
+		territory[tile.Owner]++
+	}
+	for _, p := range last.Players {
+		if p.Territory != territory[p.PlayerID] || p.Units != len(g.GetPlayer(p.PlayerID).Units) {
+			t.Errorf("%s has %d tiles and %d units recorded, want %d and %d", p.PlayerID, p.Territory, p.Units, territory[p.PlayerID], len(g.GetPlayer(p.PlayerID).Units))
+		}
+	}
+
+	AssertReplays(t, g)
+}
+
+// TestTechHistory checks that each turn's entry records the technologies
+// every player knows
+func TestTechHistory(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+	g.GetPlayer("alice").Science = game.ScaleCost(game.TechCost[game.TechRefining], g.Config.Speed)
+
+	Run(t, g, endRound("alice", "bob")...)
+	techs := make(map[string]int)
+	for _, p := range g.History[len(g.History)-1].Players {
+		techs[p.PlayerID] = p.Techs
+	}
+	if techs["alice"] != 2 || techs["bob"] != 0 {
+		t.Errorf("technologies recorded %v, want 2 for alice and none for bob", techs)
+	}
+}
+
+func TestAdvisor(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	settler := b.Unit("alice", game.UnitSettler, 5, 3)
+	warrior := b.Unit("bob", game.UnitWarrior, 3, 2)
+	alpha := b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 1, 1)
+	b.Tile(4, 4).Resource = game.ResourceWheat
+	g := b.Start()
+	alpha.Disorder = true
+
+	if hints := g.Advise("alice"); hints != nil {
+		t.Fatalf("advisor off, alice was given %+v", hints)
+	}
+
+	g.Config.Advisor = true
+	want := []game.Hint{
+		{Kind: game.HintEnemyNear, Priority: game.PriorityHigh, Message: "An enemy Warrior approaches Alpha", CityID: alpha.ID, UnitID: warrior.ID, X: 3, Y: 2},
+		{Kind: game.HintDisorder, Priority: game.PriorityHigh, Message: "Alpha is in disorder", CityID: alpha.ID, X: 1, Y: 1},
+		{Kind: game.HintNoProduction, Priority: game.PriorityMedium, Message: "Alpha has nothing to build", CityID: alpha.ID, X: 1, Y: 1},
+		{Kind: game.HintCitySite, Priority: game.PriorityLow, Message: "Found a city near the wheat", UnitID: settler.ID, X: 5, Y: 3},
+	}
+	if hints := g.Advise("alice"); !slices.Equal(hints, want) {
+		t.Errorf("alice was advised\n%+v\nwant\n%+v", hints, want)
+	}
+	if hints := g.Advise("bob"); len(hints) != 1 || hints[0].Kind != game.HintNoProduction {
+		t.Errorf("bob was advised %+v, want only to set Beta's production", hints)
+	}
+}
+
+func TestEmpireOverview(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.City("alice", "Alpha", 1, 1, 3).CurrentBuild = &game.BuildItem{IsUnit: true, UnitType: game.UnitWarrior}
+	b.City("alice", "Gamma", 5, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	cities, err := g.EmpireOverview("alice", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cities) != 2 || cities[0].CityName != "Alpha" || cities[1].CityName != "Gamma" {
+		t.Fatalf("alice's cities %+v, want Alpha and Gamma in the order founded", cities)
+	}
+	alpha := cities[0]
+	if alpha.Production != "Warrior" || alpha.TurnsLeft <= 0 || alpha.Garrison != 1 || alpha.Strength == 0 || alpha.Content != 3 {
+		t.Errorf("Alpha overviewed as %+v", alpha)
+	}
+	if gamma := cities[1]; gamma.Production != "" || gamma.TurnsLeft != -1 || gamma.Garrison != 0 {
+		t.Errorf("Gamma overviewed as %+v", gamma)
+	}
+
+	for _, sorted := range []struct {
+		column     string
+		descending bool
+		first      string
+	}{
+		{game.EmpireSortName, true, "Gamma"},
+		{game.EmpireSortSize, false, "Gamma"},
+		{game.EmpireSortSize, true, "Alpha"},
+		{game.EmpireSortTurns, false, "Alpha"}, // Cities building nothing last
+		{game.EmpireSortGarrison, true, "Alpha"},
+	} {
+		cities, err := g.EmpireOverview("alice", sorted.column, sorted.descending)
+		if err != nil || cities[0].CityName != sorted.first {
+			t.Errorf("sorted by %s (descending %v), %+v first (%v), want %s", sorted.column, sorted.descending, cities[0], err, sorted.first)
+		}
+	}
+	if _, err := g.EmpireOverview("alice", "gold", false); err == nil {
+		t.Error("sorting by an unknown column succeeded")
+	}
+}
+
+// TestTileInfo checks what a player learns of a tile: its yields and
+// defense, the units on it they can see, and what their selected unit
+// could do aimed at it
+func TestTileInfo(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("alice", game.UnitSettler, 2, 3)
+	b.Unit("bob", game.UnitWarrior, 3, 2)
+	b.Unit("bob", game.UnitWarrior, 8, 4)
+	b.Tile(3, 2).HasMine = true
+	b.Tile(3, 2).Resource = game.ResourceIron
+	g := b.Start()
+
+	hills, err := g.TileInfo("alice", 3, 2, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hills.Tile.Terrain != game.TerrainHills || hills.Production != hills.Tile.ProductionYield() || hills.DefenseBonus <= 1 {
+		t.Errorf("hills described as %+v", hills)
+	}
+	if hills.Tile.Resource != game.ResourceNone || g.Map.GetTile(3, 2).Resource != game.ResourceIron {
+		t.Errorf("iron described as %v before Bronze Working, want none", hills.Tile.Resource)
+	}
+	if len(hills.Units) != 1 || hills.Units[0].OwnerID != "bob" {
+		t.Errorf("units seen on the hills %v, want bob's warrior", hills.Units)
+	}
+	if !slices.Contains(hills.Actions, "attack") {
+		t.Errorf("warrior next to bob's could take %v, want attack among them", hills.Actions)
+	}
+
+	grass, err := g.TileInfo("alice", 2, 3, "u2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(grass.Actions, "found_city") || slices.Contains(grass.Actions, "move") || len(grass.Units) != 1 {
+		t.Errorf("settler's own tile described as %+v", grass)
+	}
+
+	if _, err := g.TileInfo("alice", 8, 4, ""); !errors.Is(err, game.ErrInvalidTile) {
+		t.Errorf("unexplored tile described (%v), want %v", err, game.ErrInvalidTile)
+	}
+	alice := g.GetPlayer("alice")
+	for i := range alice.Explored {
+		alice.Explored[i] = 0xff
+	}
+	far, err := g.TileInfo("alice", 8, 4, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(far.Units) != 0 || len(far.Actions) != 0 {
+		t.Errorf("explored tile out of sight described as %+v, want no units or actions", far)
+	}
+
+	if _, err := g.TileInfo("alice", 3, 2, "u3"); !errors.Is(err, game.ErrNotYourUnit) {
+		t.Errorf("tile described for bob's unit (%v), want %v", err, game.ErrNotYourUnit)
+	}
+	if bobs, err := g.TileInfo("bob", 2, 2, "u3"); err != nil || len(bobs.Actions) != 0 {
+		t.Errorf("bob's unit could take %v out of turn (%v), want none", bobs, err)
+	}
+
+	alice.Science = game.TechCost[game.TechBronzeWorking]
+	iron, err := g.TileInfo("alice", 3, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iron.Tile.Resource != game.ResourceIron || iron.Production != hills.Production+game.ResourceBonuses[game.ResourceIron].Production {
+		t.Errorf("hills described as %+v after Bronze Working, want the iron and its production", iron)
+	}
+}
+
+// TestReachableTiles checks where a horseman can go in a turn: two steps
+// over open land but one onto hills or forest, stopping at an enemy it
+// would attack rather than moving on through it
+func TestReachableTiles(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	horseman := b.Unit("alice", game.UnitHorseman, 2, 2)
+	b.Unit("bob", game.UnitWarrior, 3, 3)
+	g := b.Start()
+
+	tiles, err := g.ReachableTiles("alice", horseman.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reach := make(map[game.Coord]game.ReachableTile)
+	for _, tile := range tiles {
+		reach[game.At(tile.X, tile.Y)] = tile
+	}
+
+	for _, want := range []game.ReachableTile{
+		{X: 3, Y: 3, Cost: 0, MovementLeft: 2, Attack: true}, // Bob's warrior
+		{X: 2, Y: 1, Cost: 1, MovementLeft: 1},
+		{X: 3, Y: 2, Cost: 2, MovementLeft: 0}, // Hills
+		{X: 4, Y: 1, Cost: 2, MovementLeft: 0},
+		{X: 4, Y: 2, Cost: 2, MovementLeft: 0}, // Forest, with the move left
+	} {
+		if got, ok := reach[game.At(want.X, want.Y)]; !ok || got != want {
+			t.Errorf("(%d, %d) reached as %+v (%v), want %+v", want.X, want.Y, got, ok, want)
+		}
+	}
+	for _, c := range []game.Coord{game.At(2, 2), game.At(4, 3), game.At(2, 0)} {
+		if tile, ok := reach[c]; ok {
+			t.Errorf("%v reached as %+v", c, tile)
+		}
+	}
+	for _, c := range game.At(2, 2).Neighbors() {
+		if tile, ok := reach[c]; !tile.Attack && ok != g.IsValidMove(horseman, c.X, c.Y) {
+			t.Errorf("%v reached as %+v (%v), but IsValidMove says %v", c, tile, ok, !ok)
+		}
+	}
+
+	if _, err := g.ReachableTiles("alice", "u2"); !errors.Is(err, game.ErrNotYourUnit) {
+		t.Errorf("reach of bob's unit listed (%v), want %v", err, game.ErrNotYourUnit)
+	}
+}
+
+// TestSimultaneousCollision has alice and bob both order a warrior onto the
+// same tile: neither moves, and both are told their orders collided
+func TestSimultaneousCollision(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.SimultaneousTurns = true })
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 3, 2)
+	b.Unit("bob", game.UnitWarrior, 5, 2)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.PlanOrderAction{UnitID: "u1", Kind: game.OrderMove, X: 4, Y: 2}),
+		Do("bob", &game.PlanOrderAction{UnitID: "u2", Kind: game.OrderMove, X: 4, Y: 2}),
+		Do("alice", &game.SubmitOrdersAction{PlayerID: "alice"}),
+		Do("bob", &game.SubmitOrdersAction{PlayerID: "bob"}),
+	)
+
+	for id, at := range map[string]game.Coord{"u1": game.At(3, 2), "u2": game.At(5, 2)} {
+		if u := g.GetUnit(id); u.Coord() != at {
+			t.Errorf("%s moved to %v, want it held at %v", id, u.Coord(), at)
+		}
+	}
+	for _, player := range []string{"alice", "bob"} {
+		woken := g.TakeTurnReport(player).UnitsWoken
+		if len(woken) != 1 || woken[0].Reason != game.OrderCollided {
+			t.Errorf("%s was told %+v, want the order collided", player, woken)
+		}
+	}
+
+	AssertGolden(t, "simultaneous_collision", g)
+	AssertReplays(t, g)
+}
+
+// TestSimultaneousMoveAndAttack has alice attack a phalanx that bob orders
+// away in the same turn, and a warrior bob keeps in place: moves come
+// first, so only the warrior is fought
+func TestSimultaneousMoveAndAttack(t *testing.T) {
+	b := New(t, Island...)
+	b.Config(func(config *game.GameConfig) { config.SimultaneousTurns = true })
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitHorseman, 4, 3)
+	b.Unit("alice", game.UnitArcher, 4, 1)
+	b.Unit("bob", game.UnitPhalanx, 5, 3)
+	b.Unit("bob", game.UnitWarrior, 5, 1)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.PlanOrderAction{UnitID: "u1", Kind: game.OrderAttack, X: 5, Y: 3}),
+		Do("alice", &game.PlanOrderAction{UnitID: "u2", Kind: game.OrderAttack, X: 5, Y: 1}),
+		Do("bob", &game.PlanOrderAction{UnitID: "u3", Kind: game.OrderMove, X: 6, Y: 4}),
+		Do("alice", &game.SubmitOrdersAction{PlayerID: "alice"}),
+		Do("bob", &game.SubmitOrdersAction{PlayerID: "bob"}),
+	)
+
+	if u := g.GetUnit("u3"); u == nil || u.Coord() != game.At(6, 4) {
+		t.Errorf("phalanx %+v, want it moved away unharmed", u)
+	}
+	if u := g.GetUnit("u1"); u.MovementLeft != u.Template().Movement {
+		t.Errorf("horseman has %d movement left, want its attack dropped", u.MovementLeft)
+	}
+	if woken := g.TakeTurnReport("alice").UnitsWoken; len(woken) != 1 || woken[0].UnitID != "u1" || woken[0].Reason != game.OrderFailed {
+		t.Errorf("alice was told %+v, want the horseman's attack failed", woken)
+	}
+	if g.GetUnit("u2") != nil && g.GetUnit("u4") != nil {
+		t.Error("archer and warrior both stand, want them to have fought")
+	}
+
+	AssertGolden(t, "simultaneous_move_and_attack", g)
+	AssertReplays(t, g)
+}
+
+// TestSimulate simulates an attack twice and checks that both come out the
+// same, with the game itself left as it was
+func TestSimulate(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitArcher, 3, 2)
+	b.Unit("bob", game.UnitWarrior, 4, 2)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+	before := Snapshot(t, g)
+
+	attack := []game.Action{&game.AttackAction{AttackerID: "u1", TargetX: 4, TargetY: 2}}
+	first, err := g.Simulate("alice", attack, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := g.Simulate("alice", attack, 50)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("simulated %+v, then %+v", first, second)
+	}
+	if len(first.Units) != 2 || first.Completed != 1 {
+		t.Errorf("simulated %+v, want the attack made in every trial and both units reported", first)
+	}
+	if string(Snapshot(t, g)) != string(before) {
+		t.Error("simulation changed the game")
+	}
+}
+
+func TestBombard(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitCatapult, 2, 2)
+	b.Unit("alice", game.UnitWarrior, 2, 3)
+	b.Unit("bob", game.UnitWarrior, 4, 2).Health = game.BombardMinHealth + 10
+	b.City("alice", "Alpha", 1, 1, 1)
+	beta := b.City("bob", "Beta", 4, 3, 1)
+	beta.AddBuilding(game.BuildingWalls)
+	g := b.Start()
+
+	// The rounds that land would destroy the warrior, but it is left at the
+	// health floor
+	Run(t, g,
+		Fail("alice", &game.BombardAction{UnitID: "u2", TargetX: 4, TargetY: 2}, game.ErrCannotBombard),
+		Fail("alice", &game.BombardAction{UnitID: "u1", TargetX: 5, TargetY: 2}, game.ErrInvalidTarget),
+		Do("alice", &game.BombardAction{UnitID: "u1", TargetX: 4, TargetY: 2}),
+	)
+	if warrior := g.GetUnit("u3"); warrior == nil || warrior.Health != game.BombardMinHealth {
+		t.Fatalf("the bombarded warrior is %+v, want it alive with %d health", warrior, game.BombardMinHealth)
+	}
+	if u := g.GetUnit("u1"); u.Cooldown != game.BombardCooldown || u.MovementLeft != 0 {
+		t.Errorf("the catapult has cooldown %d and %d movement, want %d and none", u.Cooldown, u.MovementLeft, game.BombardCooldown)
+	}
+
+	// The catapult reloads for a turn, then every round lands on the empty
+	// city and the walls take the damage first
+	Run(t, g, endRound("alice", "bob")...)
+	Run(t, g, Fail("alice", &game.BombardAction{UnitID: "u1", TargetX: 4, TargetY: 3}, game.ErrReloading))
+	Run(t, g, endRound("alice", "bob")...)
+	Run(t, g, Do("alice", &game.BombardAction{UnitID: "u1", TargetX: 4, TargetY: 3}))
+	damage := game.BombardRounds * game.DamagePerRound
+	if beta.Damage != damage || beta.DefenseLeft() != beta.MaxDefense()-damage || !beta.WallsStanding() {
+		t.Errorf("Beta has %d damage and %d defense left, want %d and %d behind standing walls",
+			beta.Damage, beta.DefenseLeft(), damage, beta.MaxDefense()-damage)
+	}
+
+	// Walls do not spoil the aim: a defender behind them is hit as often
+	// as one in an open city
+	for seed := uint64(1); seed <= 20; seed++ {
+		hits := make([]int, 2)
+		for i, walls := range []bool{true, false} {
+			c := g.Clone()
+			city := c.GetCityAt(4, 3)
+			if !walls {
+				delete(city.Buildings, game.BuildingWalls)
+			}
+			result := game.ResolveBombard(rand.New(rand.NewPCG(seed, seed)), c.GetUnit("u1"), c.GetUnit("u3"), c.Map.GetTile(4, 3), city)
+			hits[i] = result.Hits
+		}
+		if hits[0] != hits[1] {
+			t.Fatalf("seed %d: %d rounds land behind walls and %d without", seed, hits[0], hits[1])
+		}
+	}
+
+	AssertGolden(t, "bombard", g)
+	AssertReplays(t, g)
+}
+
+func TestNuke(t *testing.T) {
+	b := New(t, Island...)
+	alice := b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitNuclear, 2, 2)
+	b.Unit("alice", game.UnitWarrior, 2, 3)
+	b.Unit("bob", game.UnitWarrior, 6, 2)
+	b.Unit("bob", game.UnitPhalanx, 7, 3)
+	b.Unit("bob", game.UnitWarrior, 8, 4)
+	b.City("alice", "Alpha", 1, 1, 1)
+	beta := b.City("bob", "Beta", 6, 2, 5)
+	g := b.Start()
+
+	// Only a nuclear unit can be detonated. The blast over Beta takes the
+	// units within NukeRadius and half its people but spares the warrior
+	// two tiles away.
+	Run(t, g,
+		Fail("alice", &game.NukeAction{UnitID: "u2", TargetX: 6, TargetY: 2}, game.ErrNotNuclear),
+		Do("alice", &game.NukeAction{UnitID: "u1", TargetX: 6, TargetY: 2}),
+	)
+	for _, id := range []string{"u1", "u3", "u4"} {
+		if g.GetUnit(id) != nil {
+			t.Errorf("%s survived the detonation", id)
+		}
+	}
+	if g.GetUnit("u2") == nil || g.GetUnit("u5") == nil {
+		t.Errorf("a unit outside the blast was destroyed")
+	}
+	if want := 5 - 5*game.NukePopulationLoss/100; beta.Population != want {
+		t.Errorf("Beta has %d citizens, want %d", beta.Population, want)
+	}
+	for y := 0; y < len(Island); y++ {
+		for x := 0; x < len(Island[y]); x++ {
+			tile := b.Tile(x, y)
+			want := x >= 5 && x <= 7 && y >= 1 && y <= 3
+			if tile.Fallout != want {
+				t.Errorf("fallout at (%d, %d) is %v, want %v", x, y, tile.Fallout, want)
+			}
+		}
+	}
+	if alice.NuclearStrikes != 1 {
+		t.Errorf("alice has %d nuclear strikes, want 1", alice.NuclearStrikes)
+	}
+	if aggressors := g.NuclearAggressors("bob"); len(aggressors) != 1 || aggressors[0] != alice {
+		t.Errorf("bob sees %d nuclear aggressors, want alice alone", len(aggressors))
+	}
+	Run(t, g, endRound("alice", "bob")...)
+
+	AssertGolden(t, "nuke", g)
+	AssertReplays(t, g)
+}
+
+func TestGroups(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("alice", game.UnitHorseman, 2, 2)
+	b.Unit("alice", game.UnitArcher, 5, 2)
+	b.Unit("bob", game.UnitWarrior, 6, 2).Health = 10
+	b.Unit("bob", game.UnitPhalanx, 6, 2).Health = 10
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	// The stack moves as one, and no further than the warrior can go
+	Run(t, g,
+		Fail("alice", &game.CreateGroupAction{UnitIDs: []string{"u1", "u3"}}, game.ErrNotStacked),
+		Do("alice", &game.CreateGroupAction{UnitIDs: []string{"u1", "u2"}}),
+	)
+	groupID := g.GetUnit("u1").GroupID
+	if groupID == "" || g.GetUnit("u2").GroupID != groupID {
+		t.Fatalf("the warrior and horseman are in groups %q and %q, want the same one", groupID, g.GetUnit("u2").GroupID)
+	}
+	Run(t, g, Do("alice", &game.MoveGroupAction{GroupID: groupID, Path: []game.Waypoint{{X: 3, Y: 3}, {X: 4, Y: 3}}}))
+	for _, id := range []string{"u1", "u2"} {
+		if u := g.GetUnit(id); u.X != 3 || u.Y != 3 {
+			t.Errorf("%s is at (%d, %d), want (3, 3) with its group", id, u.X, u.Y)
+		}
+	}
+	if horseman := g.GetUnit("u2"); horseman.MovementLeft != horseman.Template().Movement-1 {
+		t.Errorf("the horseman has %d movement left, want %d", horseman.MovementLeft, horseman.Template().Movement-1)
+	}
+
+	// Outside a city the whole stack falls with its best defender
+	Run(t, g, Do("alice", &game.AttackAction{AttackerID: "u3", TargetX: 6, TargetY: 2}))
+	if g.GetUnit("u4") != nil || g.GetUnit("u5") != nil {
+		t.Fatalf("part of bob's stack survived its best defender")
+	}
+	combats := g.TakeTurnReport("bob").CombatsAgainst
+	if len(combats) != 1 || combats[0].DefenderUnit != game.UnitPhalanx || combats[0].StackLost != 1 {
+		t.Errorf("bob's combat reports %+v, want the phalanx lost with 1 more unit", combats)
+	}
+	Run(t, g, endRound("alice", "bob")...)
+
+	AssertGolden(t, "groups", g)
+	AssertReplays(t, g)
+}
+
+func TestPatrol(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 1, 1)
+	b.Unit("bob", game.UnitWarrior, 6, 2)
+	b.City("alice", "Alpha", 1, 4, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	at := func(x, y int) {
+		t.Helper()
+		if u := g.GetUnit("u1"); u.X != x || u.Y != y {
+			t.Fatalf("turn %d: the patrol is at (%d, %d), want (%d, %d)", g.CurrentTurn, u.X, u.Y, x, y)
+		}
+	}
+
+	// The warrior walks to the far waypoint and back, a step a turn
+	Run(t, g, Do("alice", &game.PatrolAction{UnitID: "u1", Waypoints: []game.Waypoint{{X: 3, Y: 1}, {X: 1, Y: 1}}}))
+	at(2, 1)
+	for _, want := range []game.Coord{{X: 3, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 1}} {
+		Run(t, g, endRound("alice", "bob")...)
+		at(want.X, want.Y)
+	}
+
+	// Bob's warrior closes in, and once it is within SentryWakeRange the
+	// patrol stops where it stands
+	Run(t, g,
+		EndTurn("alice"),
+		Do("bob", &game.MoveUnitAction{UnitID: "u2", ToX: 5, ToY: 2}),
+		EndTurn("bob"),
+	)
+	at(2, 1)
+	Run(t, g,
+		EndTurn("alice"),
+		Do("bob", &game.MoveUnitAction{UnitID: "u2", ToX: 4, ToY: 1}),
+		EndTurn("bob"),
+	)
+	at(2, 1)
+	if u := g.GetUnit("u1"); u.Mode != game.ModeNone || len(u.Patrol) != 0 {
+		t.Errorf("the patrol is in mode %s with route %v, want its orders cleared", u.Mode, u.Patrol)
+	}
+	woken := g.TakeTurnReport("alice").UnitsWoken
+	if len(woken) != 1 || woken[0].UnitID != "u1" || woken[0].Reason != game.WakeEnemySighted {
+		t.Errorf("alice was told of woken units %+v, want the patrol seeing an enemy", woken)
+	}
+
+	AssertGolden(t, "patrol", g)
+	AssertReplays(t, g)
+}
+
+func TestSentry(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2)
+	b.Unit("bob", game.UnitWarrior, 6, 2)
+	b.City("alice", "Alpha", 1, 4, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	// The sentry sleeps through bob's first step and wakes once his warrior
+	// is within SentryWakeRange
+	Run(t, g,
+		Do("alice", &game.SetUnitModeAction{UnitID: "u1", Mode: game.ModeSentry}),
+		EndTurn("alice"),
+		Do("bob", &game.MoveUnitAction{UnitID: "u2", ToX: 5, ToY: 2}),
+		EndTurn("bob"),
+	)
+	if mode := g.GetUnit("u1").Mode; mode != game.ModeSentry {
+		t.Fatalf("the warrior is in mode %s with the enemy 3 tiles away, want sentry", mode)
+	}
+	Run(t, g,
+		EndTurn("alice"),
+		Do("bob", &game.MoveUnitAction{UnitID: "u2", ToX: 4, ToY: 3}),
+		EndTurn("bob"),
+	)
+	if mode := g.GetUnit("u1").Mode; mode != game.ModeNone {
+		t.Errorf("the warrior is in mode %s with the enemy 2 tiles away, want it awake", mode)
+	}
+	woken := g.TakeTurnReport("alice").UnitsWoken
+	if len(woken) != 1 || woken[0].UnitID != "u1" || woken[0].Reason != game.WakeEnemySighted {
+		t.Errorf("alice was told of woken units %+v, want the sentry seeing an enemy", woken)
+	}
+
+	AssertGolden(t, "sentry", g)
+	AssertReplays(t, g)
+}
+
+func TestAutoExplore(t *testing.T) {
+	b := New(t, Island...)
+	alice := b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitHorseman, 1, 4)
+	b.Unit("bob", game.UnitWarrior, 5, 3)
+	b.City("alice", "Alpha", 1, 4, 1)
+	b.City("bob", "Beta", 5, 3, 1)
+	g := b.Start()
+
+	// The horseman roams until every tile of the island has been seen, then
+	// asks for orders
+	Run(t, g, Do("alice", &game.SetUnitModeAction{UnitID: "u1", Mode: game.ModeExplore}))
+	Run(t, g, rounds(12, "alice", "bob")...)
+	if mode := g.GetUnit("u1").Mode; mode != game.ModeNone {
+		t.Fatalf("the horseman is still in mode %s", mode)
+	}
+	for y := range Island {
+		for x := range Island[y] {
+			if !g.IsExplored(alice, x, y) {
+				t.Errorf("(%d, %d) is unexplored", x, y)
+			}
+		}
+	}
+	woken := g.TakeTurnReport("alice").UnitsWoken
+	if len(woken) != 1 || woken[0].UnitID != "u1" || woken[0].Reason != game.WakeExploreDone {
+		t.Errorf("alice was told of woken units %+v, want the explorer done", woken)
+	}
+
+	AssertGolden(t, "auto_explore", g)
+	AssertReplays(t, g)
+}
+
+func TestCityDefense(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitCatapult, 5, 2)
+	b.Unit("alice", game.UnitWarrior, 7, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	beta := b.City("bob", "Beta", 6, 2, 4)
+	beta.AddBuilding(game.BuildingWalls)
+	b.City("bob", "Gamma", 8, 4, 1)
+	g := b.Start()
+
+	// Population and walls add to the defenses an empty city holds out with
+	if want := game.CityBaseDefense + 4*game.CityDefensePerPop + game.CityWallsHealth; beta.MaxDefense() != want {
+		t.Errorf("Beta has %d defense points, want %d", beta.MaxDefense(), want)
+	}
+	if want := game.CityBaseStrength + 4/game.CityStrengthPopDivisor; beta.Strength() != want {
+		t.Errorf("Beta has strength %d, want %d", beta.Strength(), want)
+	}
+
+	// A catapult assault wears them down but does not break them
+	Run(t, g, Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 2}))
+	if beta.OwnerID != "bob" || beta.Damage == 0 || beta.DefenseLeft() == 0 {
+		t.Fatalf("Beta belongs to %s with %d damage, want bob's city damaged but standing", beta.OwnerID, beta.Damage)
+	}
+	if entry := g.CombatLog[len(g.CombatLog)-1]; !entry.Undefended || entry.CityCaptured != "" {
+		t.Errorf("logged assault %+v, want an undefended city left standing", entry)
+	}
+
+	// Beta strikes the adjacent warrior once a turn, never destroying it
+	Run(t, g,
+		EndTurn("alice"),
+		Fail("bob", &game.CityStrikeAction{CityID: beta.ID, TargetX: 8, TargetY: 3}, game.ErrInvalidTarget),
+		Do("bob", &game.CityStrikeAction{CityID: beta.ID, TargetX: 7, TargetY: 3}),
+		Fail("bob", &game.CityStrikeAction{CityID: beta.ID, TargetX: 7, TargetY: 3}, game.ErrCityStruck),
+	)
+	strikes := g.TakeTurnReport("alice").CombatsAgainst
+	if len(strikes) != 1 || strikes[0].AttackerCity != "Beta" || !strikes[0].Bombard {
+		t.Fatalf("alice's combat reports %+v, want Beta's strike", strikes)
+	}
+	warrior := g.GetUnit("u2")
+	if warrior.Health != game.BaseHealthPoints-strikes[0].Damage || warrior.Health < game.BombardMinHealth {
+		t.Errorf("the warrior has %d health after losing %d, want %d and no less than %d",
+			warrior.Health, strikes[0].Damage, game.BaseHealthPoints-strikes[0].Damage, game.BombardMinHealth)
+	}
+	Run(t, g,
+		EndTurn("bob"),
+		EndTurn("alice"),
+		Do("bob", &game.CityStrikeAction{CityID: beta.ID, TargetX: 7, TargetY: 3}),
+		EndTurn("bob"),
+	)
+
+	AssertGolden(t, "city_defense", g)
+	AssertReplays(t, g)
+}
+
+// TestScenario plays the shipped Race for Five scenario until the
+// Egyptians fall and their treasury goes to the human player
+func TestScenario(t *testing.T) {
+	b := New(t, Island...)
+	alice := b.Player("alice", game.PlayerHuman)
+	b.Player("Egyptians", game.PlayerHuman)
+	b.Player("carol", game.PlayerHuman)
+	b.Unit("alice", game.UnitHorseman, 5, 2)
+	b.Unit("alice", game.UnitArcher, 5, 3)
+	b.Unit("carol", game.UnitWarrior, 8, 3)
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("Egyptians", "Thebes", 6, 2, 1)
+	b.City("carol", "Gamma", 8, 4, 1)
+	scenario := b.Scenario("../../scenarios/race-for-five.json")
+	g := b.Start()
+
+	if scenario.Name != "Race for Five" || len(scenario.Triggers) != 3 || g.Scenario != scenario {
+		t.Fatalf("loaded scenario %q with %d triggers, want Race for Five with 3 attached to the game", scenario.Name, len(scenario.Triggers))
+	}
+	for _, trigger := range scenario.Triggers {
+		if trigger.Fired {
+			t.Errorf("trigger %q fired before the game began", trigger.ID)
+		}
+	}
+
+	Run(t, g,
+		Do("alice", &game.AttackAction{AttackerID: "u1", TargetX: 6, TargetY: 2}),
+		Do("alice", &game.AttackAction{AttackerID: "u2", TargetX: 6, TargetY: 2}),
+	)
+	if g.GetPlayer("Egyptians").IsAlive {
+		t.Fatal("the Egyptians survived the loss of Thebes")
+	}
+	Run(t, g, EndTurn("alice"))
+	gold := alice.Gold
+	Run(t, g, EndTurn("carol"))
+
+	// The next round begins with the Egyptians' treasury paid to alice
+	fired := make(map[string]bool)
+	for _, trigger := range scenario.Triggers {
+		fired[trigger.ID] = trigger.Fired
+	}
+	if !fired["egypt-falls"] || fired["five-cities"] || fired["halfway"] {
+		t.Errorf("fired triggers %v, want egypt-falls alone", fired)
+	}
+	if alice.Gold != gold+100 || g.Phase == game.PhaseGameOver {
+		t.Errorf("alice went from %d to %d gold in phase %v, want 100 more and the game going on", gold, alice.Gold, g.Phase)
+	}
+	notices := g.TakeTurnReport("alice").ScenarioNotices
+	if len(notices) != 1 || notices[0].TriggerID != "egypt-falls" || notices[0].PlayerID != "alice" {
+		t.Errorf("alice was told of scenario events %+v, want egypt-falls", notices)
+	}
+
+	AssertGolden(t, "scenario", g)
+	AssertReplays(t, g)
+}
+
+func TestDemographics(t *testing.T) {
+	b := New(t,
+		"~~~~~~~~~~~~~~~~",
+		"~gggggggggggggg~",
+		"~gggggggggggggg~",
+		"~gggggggggggggg~",
+		"~~~~~~~~~~~~~~~~",
+	)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Player("carol", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 4, 2)
+	b.Unit("bob", game.UnitWarrior, 6, 2)
+	b.Unit("bob", game.UnitWarrior, 6, 3)
+	b.City("alice", "Alpha", 2, 2, 3)
+	b.City("bob", "Beta", 5, 2, 5)
+	b.City("carol", "Gamma", 13, 2, 3) // Far beyond alice's sight
+	g := b.Start()
+	g.GetPlayer("alice").Science = game.ScaleCost(game.TechCost[game.TechBronzeWorking], g.Config.Speed)
+	g.GetPlayer("bob").Science = game.ScaleCost(game.TechCost[game.TechRefining], g.Config.Speed)
+
+	if _, err := g.Demographics("dave"); !errors.Is(err, game.ErrPlayerNotFound) {
+		t.Errorf("got error %v for an unknown player, want ErrPlayerNotFound", err)
+	}
+	demographics, err := g.Demographics("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byCategory := make(map[string]game.Demographic)
+	for _, d := range demographics {
+		byCategory[d.Category] = d
+		if len(d.Ranks) != 3 {
+			t.Errorf("%s ranks %d civilizations, want 3", d.Category, len(d.Ranks))
+		}
+		for _, r := range d.Ranks {
+			if r.PlayerID == "carol" {
+				t.Errorf("%s names carol, whom alice has not met", d.Category)
+			}
+		}
+	}
+
+	// Alice and carol tie behind bob in population; only bob is named
+	population := byCategory[game.DemographicPopulation]
+	want := []game.DemographicRank{{PlayerID: "bob", Rank: 1}, {PlayerID: "alice", Rank: 2}, {Rank: 2}}
+	if population.Value != 3 || population.Rank != 2 || !slices.Equal(population.Ranks, want) {
+		t.Errorf("population %+v, want alice's 3 citizens ranked %v", population, want)
+	}
+
+	// Two warriors outrank one, and carol has none
+	military := byCategory[game.DemographicMilitary]
+	want = []game.DemographicRank{{PlayerID: "bob", Rank: 1}, {PlayerID: "alice", Rank: 2}, {Rank: 3}}
+	if military.Value != g.GetPlayer("alice").MilitaryStrength() || military.Rank != 2 || !slices.Equal(military.Ranks, want) {
+		t.Errorf("military %+v, want alice's warrior ranked %v", military, want)
+	}
+
+	// Bob knows two technologies, alice one and carol none
+	literacy := byCategory[game.DemographicLiteracy]
+	want = []game.DemographicRank{{PlayerID: "bob", Rank: 1}, {PlayerID: "alice", Rank: 2}, {Rank: 3}}
+	if literacy.Value != 1 || literacy.Rank != 2 || !slices.Equal(literacy.Ranks, want) {
+		t.Errorf("literacy %+v, want alice's technology ranked %v", literacy, want)
+	}
+}
+
+// TestFirstContact checks that a civilization is met once its units are
+// seen, not when they stand on land explored long before, and stays met
+// once out of sight again
+func TestFirstContact(t *testing.T) {
+	b := New(t,
+		"~~~~~~~~~~~~~~~~",
+		"~gggggggggggggg~",
+		"~gggggggggggggg~",
+		"~gggggggggggggg~",
+		"~~~~~~~~~~~~~~~~",
+	)
+	alice := b.Player("alice", game.PlayerHuman)
+	b.Player("carol", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 4, 2)
+	b.Unit("carol", game.UnitWarrior, 6, 2)
+	b.City("alice", "Alpha", 2, 2, 1)
+	b.City("carol", "Gamma", 13, 2, 1)
+
+	// Alice has explored the whole map long ago, but sees none of carol's
+	// now
+	alice.Explored = bytes.Repeat([]byte{0xff}, (16*5+7)/8)
+	g := b.Start()
+	named := func() bool {
+		demographics, err := g.Demographics("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slices.ContainsFunc(demographics[0].Ranks, func(r game.DemographicRank) bool { return r.PlayerID == "carol" })
+	}
+	if named() {
+		t.Fatal("carol is named before alice has seen any of theirs")
+	}
+
+	Run(t, g,
+		EndTurn("alice"),
+		Do("carol", &game.MoveUnitAction{UnitID: "u2", ToX: 5, ToY: 2}),
+	)
+	if !named() {
+		t.Fatal("carol is unnamed with their warrior in alice's sight")
+	}
+
+	Run(t, g,
+		EndTurn("carol"),
+		EndTurn("alice"),
+		Do("carol", &game.MoveUnitAction{UnitID: "u2", ToX: 6, ToY: 2}),
+	)
+	if !named() {
+		t.Error("carol is unnamed again once their warrior is out of sight")
+	}
+
+	AssertReplays(t, g)
+}
+
+// TestHealing wounds two of alice's warriors in her territory and checks
+// that the one that moves heals nothing that turn while the one that
+// fortifies heals, and that the first heals once it stays put
+func TestHealing(t *testing.T) {
+	b := New(t, Island...)
+	b.Player("alice", game.PlayerHuman)
+	b.Player("bob", game.PlayerHuman)
+	b.Unit("alice", game.UnitWarrior, 2, 2).Health = 50
+	b.Unit("alice", game.UnitWarrior, 3, 2).Health = 50
+	b.City("alice", "Alpha", 1, 1, 1)
+	b.City("bob", "Beta", 8, 4, 1)
+	g := b.Start()
+
+	Run(t, g,
+		Do("alice", &game.MoveUnitAction{UnitID: "u1", ToX: 2, ToY: 3}),
+		Do("alice", &game.FortifyAction{UnitID: "u2"}),
+	)
+	if heal := g.HealAmount(g.GetUnit("u1")); heal != 0 {
+		t.Errorf("the warrior that moved will heal %d, want none", heal)
+	}
+	Run(t, g, EndTurn("alice"))
+	if moved, fortified := g.GetUnit("u1").Health, g.GetUnit("u2").Health; moved != 50 || fortified != 50+game.HealFortified {
+		t.Errorf("warriors have %d and %d health, want the one that moved at 50 and the fortified one at %d", moved, fortified, 50+game.HealFortified)
+	}
+
+	Run(t, g, EndTurn("bob"), EndTurn("alice"))
+	if moved := g.GetUnit("u1").Health; moved != 50+game.HealInField {
+		t.Errorf("the warrior that stayed put has %d health, want %d", moved, 50+game.HealInField)
+	}
+
+	AssertGolden(t, "healing", g)
+	AssertReplays(t, g)
+}
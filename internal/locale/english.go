@@ -0,0 +1,145 @@
+package locale
+
+// english is the built-in pack every other pack falls back to
+var english = &Pack{
+	Locale: Default,
+	Name:   "English",
+
+	CivilizationNames: []string{
+		"Romans",
+		"Egyptians",
+		"Greeks",
+		"Babylonians",
+		"Germans",
+		"Russians",
+		"Chinese",
+		"Americans",
+	},
+	CityNames: [][]string{
+		{"Rome", "Caesarea", "Carthage", "Nicopolis", "Byzantium", "Brundisium", "Syracuse", "Antioch", "Palmyra", "Cyrene", "Gordion", "Tyrus", "Jerusalem", "Seleucia", "Ravenna", "Artaxata"},
+		{"Thebes", "Memphis", "Oryx", "Heliopolis", "Gaza", "Alexandria", "Byblos", "Cairo", "Coptos", "Edfu", "Pithom", "Busiris", "Athribis", "Mendes", "Tanis", "Abydos"},
+		{"Athens", "Sparta", "Corinth", "Delphi", "Eretria", "Pharsalos", "Argos", "Mycenae", "Herakleia", "Antipolis", "Knossos", "Troy", "Pergamon", "Miletos", "Rhodes", "Olympia"},
+		{"Babylon", "Ur", "Nineveh", "Ashur", "Ellipi", "Akkad", "Eridu", "Kish", "Nippur", "Shuruppak", "Zariqum", "Izibia", "Nimrud", "Arbela", "Zamua", "Sippar"},
+		{"Berlin", "Leipzig", "Hamburg", "Bremen", "Frankfurt", "Bonn", "Nuremberg", "Cologne", "Hannover", "Munich", "Stuttgart", "Heidelberg", "Salzburg", "Konigsberg", "Dortmund", "Brandenburg"},
+		{"Moscow", "Leningrad", "Kiev", "Minsk", "Smolensk", "Odessa", "Sevastopol", "Tblisi", "Sverdlovsk", "Yakutsk", "Vladivostok", "Novograd", "Krasnoyarsk", "Riga", "Rostov", "Astrakhan"},
+		{"Beijing", "Shanghai", "Canton", "Nanjing", "Tsingtao", "Hangchow", "Tientsin", "Tatung", "Macao", "Anshan", "Foochow", "Kaifeng", "Tangshan", "Chengtu", "Wuhan", "Sian"},
+		{"Washington", "New York", "Boston", "Philadelphia", "Atlanta", "Chicago", "Buffalo", "St. Louis", "Detroit", "New Orleans", "Baltimore", "Denver", "Cincinnati", "Dallas", "Los Angeles", "Las Vegas"},
+	},
+
+	Messages: map[string]string{
+		// Turn notifications
+		"notify.subject": "Your turn in YaC (turn {turn})",
+		"notify.body":    "{player}, it is your turn.",
+		"notify.map":     "Your map: {url}",
+
+		// Errors of messages and requests
+		"error.invalid_message":     "Invalid message",
+		"error.unknown_message":     "Unknown message type",
+		"error.invalid_query":       "Invalid query",
+		"error.unknown_query":       "Unknown query type",
+		"error.invalid_notify":      "Invalid notification request",
+		"error.invalid_preferences": "Invalid preferences",
+		"error.not_host":            "Only the host can do that",
+		"error.game_paused":         "The game is paused by the host",
+		"error.game_failed":         "The game stopped after a server error; it was saved for the administrator",
+
+		// Errors of actions
+		"error.unknown_action":         "Unknown action type",
+		"error.invalid_payload":        "Invalid action",
+		"error.game_not_started":       "The game has not started",
+		"error.game_over":              "The game is over",
+		"error.not_your_turn":          "It is not your turn",
+		"error.player_not_found":       "Player not found",
+		"error.unit_not_found":         "Unit not found",
+		"error.not_your_unit":          "That unit does not belong to you",
+		"error.city_not_found":         "City not found",
+		"error.not_your_city":          "That city does not belong to you",
+		"error.group_not_found":        "Group not found",
+		"error.no_movement_left":       "The unit has no movement left",
+		"error.invalid_move":           "The unit cannot move there",
+		"error.invalid_target":         "There is nothing to attack there",
+		"error.invalid_tile":           "That tile is off the map",
+		"error.cannot_found_city":      "A city cannot be founded here",
+		"error.production_required":    "All cities need a production order",
+		"error.building_exists":        "The city already has a {item}",
+		"error.wonder_built":           "The {item} has already been built",
+		"error.requires_wonder":        "Requires the {item}",
+		"error.cannot_help_wonder":     "Only caravans can help build wonders",
+		"error.no_wonder_here":         "No city of yours is building a wonder here",
+		"error.cannot_fortify":         "Settlers cannot fortify",
+		"error.already_awake":          "The unit is already awake",
+		"error.cannot_build_road":      "This unit cannot build roads",
+		"error.road_exists":            "There is already a road here",
+		"error.cannot_build_road_here": "A road cannot be built here",
+		"error.unknown_mode":           "Unknown unit mode",
+		"error.use_patrol":             "Use a patrol order to set a route",
+		"error.patrol_too_short":       "A patrol needs at least two waypoints",
+		"error.waypoint_unreachable":   "The unit cannot reach that waypoint",
+		"error.repeated_waypoint":      "Consecutive waypoints must differ",
+		"error.group_too_small":        "A group needs at least two units",
+		"error.unit_listed_twice":      "A unit is listed twice",
+		"error.not_stacked":            "Grouped units must share a tile",
+		"error.already_in_group":       "The unit is already in the group",
+		"error.invalid_city_name":      "City names must be 1 to 24 characters long",
+		"error.city_name_taken":        "There is already a city called {item}",
+		"error.invalid_unit_name":      "Unit names can be at most 24 characters long",
+		"error.not_coastal":            "Only a city on the coast can build a {item}",
+		"error.unknown_deal":           "Unknown deal {item}",
+		"error.not_your_deal":          "Players can only make deals of their own",
+		"error.invalid_partner":        "You cannot make deals with {item}",
+		"error.deal_offered":           "You have already offered that deal",
+		"error.no_offer":               "That deal was not offered",
+		"error.already_sharing":        "You already share vision with {item}",
+		"error.not_sharing":            "You do not share vision with that player",
+		"error.not_luxury":             "{item} is not a luxury",
+		"error.no_spare_luxury":        "You have no {item} to spare",
+		"error.cannot_terraform":       "The unit cannot work the land",
+		"error.unknown_job":            "Unknown job {item}",
+		"error.cannot_work_here":       "That job cannot be done on {terrain}",
+		"error.invalid_tax_rate":       "Tax rate {item}% must be from 0 to 100 in steps of 10",
+		"error.not_your_taxes":         "You can only set your own tax rate",
+		"error.not_your_points":        "You can only spend your own start points",
+		"error.no_start_points":        "You have no start points left to spend",
+		"error.not_enough_points":      "That costs {item} start points, more than you have left",
+		"error.unknown_purchase":       "Unknown purchase {item}",
+		"error.out_of_start_reach":     "Cities can only be bought near your units and cities",
+		"error.all_techs_known":        "There is no technology left to learn",
+		"error.not_buildable":          "A {item} cannot be built",
+		"error.nuclear_only":           "Nuclear units can only detonate",
+		"error.not_nuclear":            "The unit is not a nuclear weapon",
+		"error.cannot_bombard":         "The unit cannot bombard",
+		"error.reloading":              "The unit must reload before bombarding again",
+		"error.city_struck":            "The city has already struck this turn",
+		"error.plan_orders":            "Units move and fight through planned orders in the simultaneous phase",
+		"error.not_simultaneous":       "Orders are only given in the simultaneous phase",
+		"error.submit_orders":          "Submit orders to end the simultaneous phase",
+		"error.not_your_orders":        "Players can only submit their own orders",
+		"error.orders_submitted":       "Orders are already submitted this turn",
+		"error.unknown_order":          "Unknown order kind",
+
+		// Titles units earn by winning battles
+		"honorific.brave":      "the Brave",
+		"honorific.valiant":    "the Valiant",
+		"honorific.invincible": "the Invincible",
+
+		// The game log
+		"log.line":                "Turn {turn}: {text}",
+		"log.city_founded":        "{player} founded {city}",
+		"log.city_captured":       "{player}'s {unit} captured {city} from {defender}",
+		"log.attack_won":          "{player}'s {unit} defeated {defender}'s {defender_unit}",
+		"log.attack_lost":         "{player}'s {unit} was beaten back by {defender}'s {defender_unit}",
+		"log.city":                "city",
+		"log.completed":           "{city} completed {item}",
+		"log.city_grew":           "{city} grew to size {population}",
+		"log.celebration_started": "{city} celebrates We Love the King Day",
+		"log.celebration_ended":   "{city} stopped celebrating",
+		"log.tech_discovered":     "{player} discovered {tech}",
+		"log.king_lost":           "{player} lost their King",
+
+		// Hints of the advisor
+		"advice.enemy_near":    "An enemy {unit} approaches {city}",
+		"advice.disorder":      "{city} is in disorder",
+		"advice.no_production": "{city} has nothing to build",
+		"advice.city_site":     "Found a city near the {resource}",
+	},
+}
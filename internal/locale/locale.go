@@ -0,0 +1,161 @@
+// Package locale holds the language packs the names and messages the server
+// makes up come from: civilization and city names, error messages and turn
+// notifications. English is built in and fills in whatever another pack
+// leaves out.
+package locale
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Default is the locale of games that do not pick one
+const Default = "en"
+
+// Pack is the text of one language. Messages are keyed by what they say,
+// e.g. "error.no_movement_left", and may name values in braces, e.g.
+// "Requires the {item}".
+type Pack struct {
+	Locale string `json:"locale"` // Language code, e.g. "pl"
+	Name   string `json:"name"`   // Name of the language in that language
+
+	CivilizationNames []string          `json:"civilization_names,omitempty"`
+	CityNames         [][]string        `json:"city_names,omitempty"` // One list per civilization, in order
+	Messages          map[string]string `json:"messages,omitempty"`
+}
+
+var (
+	mu    sync.RWMutex
+	packs = map[string]*Pack{Default: english}
+)
+
+// Register adds a language pack, replacing any pack of the same locale
+func Register(p *Pack) error {
+	if p.Locale == "" {
+		return fmt.Errorf("language pack without a locale")
+	}
+	if p.Name == "" {
+		return fmt.Errorf("language pack %q: missing name", p.Locale)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	packs[p.Locale] = p
+	return nil
+}
+
+// Load registers every .json language pack in dir
+func Load(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var p Pack
+		if err := json.Unmarshal(data, &p); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+		if err := Register(&p); err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// Get returns the pack of a locale. A regional locale such as "pt-BR"
+// falls back to its language, and an unknown one to English.
+func Get(locale string) *Pack {
+	mu.RLock()
+	defer mu.RUnlock()
+	if p, ok := packs[locale]; ok {
+		return p
+	}
+	if lang, _, ok := strings.Cut(locale, "-"); ok {
+		if p, ok := packs[lang]; ok {
+			return p
+		}
+	}
+	return english
+}
+
+// Known reports whether a pack is registered for a locale
+func Known(locale string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := packs[locale]
+	return ok
+}
+
+// Available returns every registered pack, by locale
+func Available() []*Pack {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]*Pack, 0, len(packs))
+	for _, p := range packs {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Locale < list[j].Locale })
+	return list
+}
+
+// Civilizations returns the names of the civilizations AI players take
+func (p *Pack) Civilizations() []string {
+	if len(p.CivilizationNames) > 0 {
+		return p.CivilizationNames
+	}
+	return english.CivilizationNames
+}
+
+// Cities returns the city names of every civilization, in the order of
+// Civilizations
+func (p *Pack) Cities() [][]string {
+	if len(p.CityNames) > 0 {
+		return p.CityNames
+	}
+	return english.CityNames
+}
+
+// Lookup returns a message with the named values filled in, given as
+// name, value pairs. It reports false when neither the pack nor English
+// has the message.
+func (p *Pack) Lookup(key string, values ...string) (string, bool) {
+	text, ok := p.Messages[key]
+	if !ok {
+		if text, ok = english.Messages[key]; !ok {
+			return "", false
+		}
+	}
+	if len(values) > 0 {
+		text = strings.NewReplacer(braced(values)...).Replace(text)
+	}
+	return text, true
+}
+
+// Text returns a message with the named values filled in, or the key when
+// there is no such message
+func (p *Pack) Text(key string, values ...string) string {
+	if text, ok := p.Lookup(key, values...); ok {
+		return text
+	}
+	return key
+}
+
+// braced turns name, value pairs into "{name}", value pairs
+func braced(values []string) []string {
+	pairs := make([]string, 0, len(values))
+	for i := 0; i+1 < len(values); i += 2 {
+		pairs = append(pairs, "{"+values[i]+"}", values[i+1])
+	}
+	return pairs
+}
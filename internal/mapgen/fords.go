@@ -0,0 +1,91 @@
+package mapgen
+
+import (
+	"civilization/internal/game"
+	"sort"
+)
+
+// placeFords marks one crossing point in each one-tile channel that keeps
+// two land masses apart, so land units can walk between them before there
+// are ships. A channel tile is ocean with land on opposite sides.
+func (g *Generator) placeFords(gm *game.GameMap) {
+	mass := g.landMasses(gm)
+
+	// Candidate crossings for each pair of land masses
+	candidates := make(map[[2]int][]int)
+	for y := 0; y < g.config.Height; y++ {
+		for x := 0; x < g.config.Width; x++ {
+			if !gm.GetTileUnsafe(x, y).IsWater() {
+				continue
+			}
+			for _, d := range [][2]int{{1, 0}, {0, 1}} {
+				a, b := g.massAt(gm, mass, x-d[0], y-d[1]), g.massAt(gm, mass, x+d[0], y+d[1])
+				if a < 0 || b < 0 || a == b {
+					continue
+				}
+				if a > b {
+					a, b = b, a
+				}
+				pair := [2]int{a, b}
+				candidates[pair] = append(candidates[pair], gm.Index(x, y))
+				break
+			}
+		}
+	}
+
+	// Pairs are visited in order so the same seed places the same fords
+	pairs := make([][2]int, 0, len(candidates))
+	for pair := range candidates {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+
+	for _, pair := range pairs {
+		tiles := candidates[pair]
+		gm.Tiles[tiles[g.rng.Intn(len(tiles))]].Ford = true
+	}
+}
+
+// landMasses labels each land tile with the land mass it belongs to,
+// joining tiles that touch diagonally as units move. Water is -1.
+func (g *Generator) landMasses(gm *game.GameMap) []int {
+	mass := make([]int, len(gm.Tiles))
+	for i := range mass {
+		mass[i] = -1
+	}
+
+	next := 0
+	for start := range gm.Tiles {
+		if gm.Tiles[start].IsWater() || mass[start] >= 0 {
+			continue
+		}
+		mass[start] = next
+		queue := []int{start}
+		for len(queue) > 0 {
+			tile := &gm.Tiles[queue[0]]
+			queue = queue[1:]
+			for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+				i := gm.Index(n.X, n.Y)
+				if !n.IsWater() && mass[i] < 0 {
+					mass[i] = next
+					queue = append(queue, i)
+				}
+			}
+		}
+		next++
+	}
+	return mass
+}
+
+// massAt returns the land mass at (x, y), or -1 for water or off the map
+func (g *Generator) massAt(gm *game.GameMap, mass []int, x, y int) int {
+	if !gm.IsValidCoord(x, y) {
+		return -1
+	}
+	return mass[gm.Index(x, y)]
+}
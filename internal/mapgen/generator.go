@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"slices"
 	"time"
 )
 
@@ -16,6 +17,8 @@ type GeneratorConfig struct {
 	WaterLevel    float64 // 0.0 to 1.0, higher = more water
 	MountainLevel float64 // 0.0 to 1.0, higher = more mountains
 	MapType       string  // "random" or "earth"
+	TrueStarts    bool    // On earth maps, place civilizations at their EarthStarts
+	NavalPlay     bool    // Players can sail away, so may start on islands smaller than MinStartLand
 }
 
 // DefaultConfig returns a default generator configuration
@@ -74,9 +77,15 @@ func (g *Generator) Generate() *game.GameMap {
 	g.generateLakes(gm)           // Add lakes (small ocean clusters) on plains/grassland
 	g.addForests(gm)              // Add forests before rivers so rivers can avoid them
 	g.generateRivers(gm)          // Add rivers flowing from highlands to ocean (avoids forests)
+	if g.config.MapType == "earth" {
+		g.addNile(gm)
+	}
 	g.removeCoastalElevations(gm) // Hills/mountains cannot border ocean
 	g.ensurePlayability(gm)
 	g.placeResources(gm) // Add resources to tiles
+	g.placeVolcanoes(gm) // Mountains that may erupt
+	g.placeFords(gm)     // Let land units cross narrow channels
+	gm.MarkCoast()       // Ocean next to land can be worked from a harbor
 
 	return gm
 }
@@ -190,8 +199,8 @@ func (g *Generator) generateEarthLike(gm *game.GameMap) {
 	// Add terrain variety based on climate
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil || tile.Terrain == game.TerrainOcean {
+			tile := gm.GetTileUnsafe(x, y)
+			if tile.Terrain == game.TerrainOcean {
 				continue
 			}
 
@@ -403,12 +412,12 @@ func (g *Generator) applyIslandGradient(x, y int, elevation float64) float64 {
 // Forests can only border grassland or other forests
 func (g *Generator) addForests(gm *game.GameMap) {
 	// First pass: mark candidate tiles for forest
-	candidates := make(map[[2]int]bool)
+	candidates := make(map[game.Coord]bool)
 
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil || tile.Terrain != game.TerrainGrassland {
+			tile := gm.GetTileUnsafe(x, y)
+			if tile.Terrain != game.TerrainGrassland {
 				continue
 			}
 
@@ -431,19 +440,19 @@ func (g *Generator) addForests(gm *game.GameMap) {
 			forestValue := g.forestNoise.Noise2D(nx, ny)
 
 			if forestValue > 0.2 {
-				candidates[[2]int{x, y}] = true
+				candidates[game.At(x, y)] = true
 			}
 		}
 	}
 
 	// Second pass: place forests where they only touch grassland or other forest candidates
 	for coord := range candidates {
-		x, y := coord[0], coord[1]
+		x, y := coord.X, coord.Y
 		neighbors := gm.GetNeighbors(x, y)
 		valid := true
 		for _, n := range neighbors {
 			// Allow grassland or tiles that will become forest
-			isCandidate := candidates[[2]int{n.X, n.Y}]
+			isCandidate := candidates[n.Coord()]
 			if n.Terrain != game.TerrainGrassland && !isCandidate {
 				valid = false
 				break
@@ -459,8 +468,8 @@ func (g *Generator) addForests(gm *game.GameMap) {
 func (g *Generator) removeCoastalForests(gm *game.GameMap) {
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil || tile.Terrain != game.TerrainForest {
+			tile := gm.GetTileUnsafe(x, y)
+			if tile.Terrain != game.TerrainForest {
 				continue
 			}
 
@@ -481,10 +490,7 @@ func (g *Generator) removeCoastalForests(gm *game.GameMap) {
 func (g *Generator) removeCoastalElevations(gm *game.GameMap) {
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil {
-				continue
-			}
+			tile := gm.GetTileUnsafe(x, y)
 
 			// Only process hills and mountains
 			if tile.Terrain != game.TerrainHills && tile.Terrain != game.TerrainMountains {
@@ -511,14 +517,11 @@ func (g *Generator) removeCoastalElevations(gm *game.GameMap) {
 
 // smoothCoastlines removes single-tile ocean/land anomalies
 func (g *Generator) smoothCoastlines(gm *game.GameMap) {
-	changes := make(map[[2]int]game.TerrainType)
+	changes := make(map[game.Coord]game.TerrainType)
 
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil {
-				continue
-			}
+			tile := gm.GetTileUnsafe(x, y)
 
 			neighbors := gm.GetCardinalNeighbors(x, y)
 			if len(neighbors) < 4 {
@@ -534,19 +537,19 @@ func (g *Generator) smoothCoastlines(gm *game.GameMap) {
 
 			// Single water tile surrounded by land
 			if tile.IsWater() && waterCount == 0 {
-				changes[[2]int{x, y}] = game.TerrainGrassland
+				changes[game.At(x, y)] = game.TerrainGrassland
 			}
 
 			// Single land tile surrounded by water
 			if !tile.IsWater() && waterCount == 4 {
-				changes[[2]int{x, y}] = game.TerrainOcean
+				changes[game.At(x, y)] = game.TerrainOcean
 			}
 		}
 	}
 
 	// Apply changes
 	for coord, terrain := range changes {
-		gm.SetTerrain(coord[0], coord[1], terrain)
+		gm.SetTerrain(coord.X, coord.Y, terrain)
 	}
 }
 
@@ -555,7 +558,7 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 	log.Println("=== GENERATING LAKES ===")
 
 	// Find suitable locations for lakes (plains or grassland, far from ocean)
-	candidates := make([][2]int, 0)
+	candidates := make([]game.Coord, 0)
 
 	for y := 2; y < g.config.Height-2; y++ {
 		for x := 2; x < g.config.Width-2; x++ {
@@ -570,19 +573,18 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 
 			// Check that it's not too close to ocean (at least 3 tiles away)
 			nearOcean := false
-			for dy := -3; dy <= 3 && !nearOcean; dy++ {
-				for dx := -3; dx <= 3 && !nearOcean; dx++ {
-					neighbor := gm.GetTile(x+dx, y+dy)
-					if neighbor != nil && neighbor.Terrain == game.TerrainOcean {
-						nearOcean = true
-					}
+			for at := range game.At(x, y).Within(3) {
+				neighbor := gm.TileAt(at)
+				if neighbor != nil && neighbor.Terrain == game.TerrainOcean {
+					nearOcean = true
+					break
 				}
 			}
 			if nearOcean {
 				continue
 			}
 
-			candidates = append(candidates, [2]int{x, y})
+			candidates = append(candidates, game.At(x, y))
 		}
 	}
 
@@ -612,7 +614,7 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 
 	log.Printf("Generating %d lakes from %d candidates", numLakes, len(candidates))
 
-	usedTiles := make(map[[2]int]bool)
+	usedTiles := make(map[game.Coord]bool)
 	lakesCreated := 0
 
 	for _, pos := range candidates {
@@ -622,11 +624,10 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 
 		// Check if this position or nearby is already used
 		tooClose := false
-		for dy := -5; dy <= 5 && !tooClose; dy++ {
-			for dx := -5; dx <= 5 && !tooClose; dx++ {
-				if usedTiles[[2]int{pos[0] + dx, pos[1] + dy}] {
-					tooClose = true
-				}
+		for at := range pos.Within(5) {
+			if usedTiles[at] {
+				tooClose = true
+				break
 			}
 		}
 		if tooClose {
@@ -635,11 +636,11 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 
 		// Create lake (1-4 tiles)
 		lakeSize := 1 + g.rng.Intn(4)
-		lakeTiles := g.createLakeShape(gm, pos[0], pos[1], lakeSize)
+		lakeTiles := g.createLakeShape(gm, pos, lakeSize)
 
 		if len(lakeTiles) > 0 {
 			for _, lt := range lakeTiles {
-				gm.SetTerrain(lt[0], lt[1], game.TerrainOcean)
+				gm.SetTerrain(lt.X, lt.Y, game.TerrainOcean)
 				usedTiles[lt] = true
 			}
 			lakesCreated++
@@ -650,9 +651,9 @@ func (g *Generator) generateLakes(gm *game.GameMap) {
 }
 
 // createLakeShape creates an organic lake shape starting from center
-func (g *Generator) createLakeShape(gm *game.GameMap, centerX, centerY, size int) [][2]int {
-	lakeTiles := make([][2]int, 0)
-	lakeTiles = append(lakeTiles, [2]int{centerX, centerY})
+func (g *Generator) createLakeShape(gm *game.GameMap, center game.Coord, size int) []game.Coord {
+	lakeTiles := make([]game.Coord, 0)
+	lakeTiles = append(lakeTiles, center)
 
 	if size == 1 {
 		return lakeTiles
@@ -664,29 +665,22 @@ func (g *Generator) createLakeShape(gm *game.GameMap, centerX, centerY, size int
 		baseTile := lakeTiles[g.rng.Intn(len(lakeTiles))]
 
 		// Try to expand in a random cardinal direction
-		dirs := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+		dirs := []game.Direction{game.North, game.South, game.West, game.East}
 		g.rng.Shuffle(len(dirs), func(i, j int) {
 			dirs[i], dirs[j] = dirs[j], dirs[i]
 		})
 
 		expanded := false
 		for _, d := range dirs {
-			nx, ny := baseTile[0]+d[0], baseTile[1]+d[1]
+			next := baseTile.Step(d)
 
 			// Check if already in lake
-			alreadyInLake := false
-			for _, lt := range lakeTiles {
-				if lt[0] == nx && lt[1] == ny {
-					alreadyInLake = true
-					break
-				}
-			}
-			if alreadyInLake {
+			if slices.Contains(lakeTiles, next) {
 				continue
 			}
 
 			// Check if valid terrain (plains or grassland)
-			tile := gm.GetTile(nx, ny)
+			tile := gm.TileAt(next)
 			if tile == nil {
 				continue
 			}
@@ -694,7 +688,7 @@ func (g *Generator) createLakeShape(gm *game.GameMap, centerX, centerY, size int
 				continue
 			}
 
-			lakeTiles = append(lakeTiles, [2]int{nx, ny})
+			lakeTiles = append(lakeTiles, next)
 			expanded = true
 			break
 		}
@@ -709,29 +703,26 @@ func (g *Generator) createLakeShape(gm *game.GameMap, centerX, centerY, size int
 }
 
 // findContinents identifies all connected land masses using flood fill
-func (g *Generator) findContinents(gm *game.GameMap) [][][2]int {
-	visited := make([][]bool, g.config.Height)
-	for y := 0; y < g.config.Height; y++ {
-		visited[y] = make([]bool, g.config.Width)
-	}
+func (g *Generator) findContinents(gm *game.GameMap) [][]game.Coord {
+	visited := make([]bool, g.config.Width*g.config.Height)
 
-	continents := make([][][2]int, 0)
+	continents := make([][]game.Coord, 0)
 
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			if visited[y][x] {
+			if visited[gm.Index(x, y)] {
 				continue
 			}
-			tile := gm.GetTile(x, y)
-			if tile == nil || tile.Terrain == game.TerrainOcean {
-				visited[y][x] = true
+			tile := gm.GetTileUnsafe(x, y)
+			if tile.Terrain == game.TerrainOcean {
+				visited[gm.Index(x, y)] = true
 				continue
 			}
 
 			// Found unvisited land - flood fill to find continent
-			continent := make([][2]int, 0)
-			queue := [][2]int{{x, y}}
-			visited[y][x] = true
+			continent := make([]game.Coord, 0)
+			queue := []game.Coord{game.At(x, y)}
+			visited[gm.Index(x, y)] = true
 
 			for len(queue) > 0 {
 				curr := queue[0]
@@ -739,22 +730,23 @@ func (g *Generator) findContinents(gm *game.GameMap) [][][2]int {
 				continent = append(continent, curr)
 
 				// Check 4 cardinal directions
-				dirs := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+				dirs := []game.Direction{game.North, game.South, game.West, game.East}
 				for _, d := range dirs {
-					nx, ny := curr[0]+d[0], curr[1]+d[1]
-					if nx < 0 || nx >= g.config.Width || ny < 0 || ny >= g.config.Height {
+					next := curr.Step(d)
+					if !gm.Contains(next) {
 						continue
 					}
-					if visited[ny][nx] {
+					nx, ny := next.X, next.Y
+					if visited[gm.Index(nx, ny)] {
 						continue
 					}
-					nextTile := gm.GetTile(nx, ny)
-					if nextTile == nil || nextTile.Terrain == game.TerrainOcean {
-						visited[ny][nx] = true
+					nextTile := gm.GetTileUnsafe(nx, ny)
+					if nextTile.Terrain == game.TerrainOcean {
+						visited[gm.Index(nx, ny)] = true
 						continue
 					}
-					visited[ny][nx] = true
-					queue = append(queue, [2]int{nx, ny})
+					visited[gm.Index(nx, ny)] = true
+					queue = append(queue, next)
 				}
 			}
 
@@ -776,22 +768,22 @@ func (g *Generator) generateRivers(gm *game.GameMap) {
 	log.Printf("Found %d continents/islands", len(continents))
 
 	gm.Rivers = make([]game.River, 0)
-	usedSources := make(map[[2]int]bool)
+	usedSources := make(map[game.Coord]bool)
 
 	// For each continent, ensure at least one river
 	for ci, continent := range continents {
 		// Find sources on this continent (mountains preferred, then hills, then any high ground)
-		mountainSources := make([][2]int, 0)
-		hillSources := make([][2]int, 0)
-		anySources := make([][2]int, 0)
+		mountainSources := make([]game.Coord, 0)
+		hillSources := make([]game.Coord, 0)
+		anySources := make([]game.Coord, 0)
 
-		continentTiles := make(map[[2]int]bool)
+		continentTiles := make(map[game.Coord]bool)
 		for _, pos := range continent {
 			continentTiles[pos] = true
 		}
 
 		for _, pos := range continent {
-			tile := gm.GetTile(pos[0], pos[1])
+			tile := gm.TileAt(pos)
 			if tile == nil {
 				continue
 			}
@@ -845,7 +837,7 @@ func (g *Generator) generateRivers(gm *game.GameMap) {
 				continue
 			}
 
-			river := g.traceRiverPath(gm, src[0], src[1])
+			river := g.traceRiverPath(gm, src.X, src.Y)
 			if len(river.Points) > 3 {
 				if len(river.Points) > 8 {
 					g.addRiverDelta(gm, &river)
@@ -875,7 +867,7 @@ func (g *Generator) traceRiverPath(gm *game.GameMap, startX, startY int) game.Ri
 	py := float64(startY) + 0.3 + g.rng.Float64()*0.4
 	river.Points = append(river.Points, game.RiverPoint{X: px, Y: py})
 
-	visited := make(map[[2]int]bool)
+	visited := make(map[game.Coord]bool)
 	x, y := startX, startY
 	maxLength := 150
 
@@ -884,7 +876,7 @@ func (g *Generator) traceRiverPath(gm *game.GameMap, startX, startY int) game.Ri
 	prevDirX, prevDirY := 0.0, 0.0
 
 	for i := 0; i < maxLength; i++ {
-		visited[[2]int{x, y}] = true
+		visited[game.At(x, y)] = true
 		tile := gm.GetTile(x, y)
 		if tile == nil {
 			break
@@ -895,14 +887,15 @@ func (g *Generator) traceRiverPath(gm *game.GameMap, startX, startY int) game.Ri
 		bestScore := -1000.0
 
 		// Check all 8 directions for smoother paths
-		directions := [][2]int{
-			{0, -1}, {0, 1}, {1, 0}, {-1, 0},
-			{1, -1}, {1, 1}, {-1, -1}, {-1, 1},
+		directions := []game.Direction{
+			game.North, game.South, game.East, game.West,
+			game.NorthEast, game.SouthEast, game.NorthWest, game.SouthWest,
 		}
 
 		for _, d := range directions {
-			nx, ny := x+d[0], y+d[1]
-			if visited[[2]int{nx, ny}] {
+			dx, dy := d.Offset()
+			nx, ny := x+dx, y+dy
+			if visited[game.At(nx, ny)] {
 				continue
 			}
 			nextTile := gm.GetTile(nx, ny)
@@ -938,19 +931,19 @@ func (g *Generator) traceRiverPath(gm *game.GameMap, startX, startY int) game.Ri
 				score += g.rng.Float64() * 2
 
 				// Strong momentum - prefer continuing roughly same direction
-				dot := float64(d[0])*dirX + float64(d[1])*dirY
+				dot := float64(dx)*dirX + float64(dy)*dirY
 				score += dot * 4
 
 				// Penalize sharp turns (reverse direction)
 				if i > 0 {
-					reverseDot := float64(d[0])*(-prevDirX) + float64(d[1])*(-prevDirY)
+					reverseDot := float64(dx)*(-prevDirX) + float64(dy)*(-prevDirY)
 					if reverseDot > 0.5 {
 						score -= reverseDot * 8 // Heavy penalty for going backwards
 					}
 				}
 
 				// Prefer diagonal moves for smoother curves
-				if d[0] != 0 && d[1] != 0 {
+				if dx != 0 && dy != 0 {
 					score += 0.5
 				}
 			}
@@ -1044,22 +1037,23 @@ func (g *Generator) traceRiverPath(gm *game.GameMap, startX, startY int) game.Ri
 		lastTileX, lastTileY := int(lastPt.X), int(lastPt.Y)
 
 		// Check cardinal directions for ocean
-		cardinalDirs := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+		cardinalDirs := []game.Direction{game.North, game.South, game.West, game.East}
 		for _, d := range cardinalDirs {
-			adjTile := gm.GetTile(lastTileX+d[0], lastTileY+d[1])
+			adjTile := gm.TileAt(game.At(lastTileX, lastTileY).Step(d))
 			if adjTile != nil && adjTile.Terrain == game.TerrainOcean {
 				// Extend river to touch ocean edge
 				edgeX := lastPt.X
 				edgeY := lastPt.Y
 
-				if d[0] > 0 {
+				dx, dy := d.Offset()
+				if dx > 0 {
 					edgeX = float64(lastTileX) + 0.95 // Right edge
-				} else if d[0] < 0 {
+				} else if dx < 0 {
 					edgeX = float64(lastTileX) + 0.05 // Left edge
 				}
-				if d[1] > 0 {
+				if dy > 0 {
 					edgeY = float64(lastTileY) + 0.95 // Bottom edge
-				} else if d[1] < 0 {
+				} else if dy < 0 {
 					edgeY = float64(lastTileY) + 0.05 // Top edge
 				}
 
@@ -1139,26 +1133,20 @@ func (g *Generator) chaikinSubdivide(points []game.RiverPoint) []game.RiverPoint
 func (g *Generator) markRiverTiles(gm *game.GameMap, river game.River) {
 	for _, pt := range river.Points {
 		// Mark the tile containing this point and adjacent tiles
-		tx, ty := int(pt.X), int(pt.Y)
-		for dy := -1; dy <= 1; dy++ {
-			for dx := -1; dx <= 1; dx++ {
-				tile := gm.GetTile(tx+dx, ty+dy)
-				if tile != nil && tile.Terrain != game.TerrainOcean {
-					tile.HasRiver = true
-				}
+		for at := range game.At(int(pt.X), int(pt.Y)).Within(1) {
+			tile := gm.TileAt(at)
+			if tile != nil && tile.Terrain != game.TerrainOcean {
+				tile.HasRiver = true
 			}
 		}
 	}
 	// Also mark tiles near delta branches
 	for _, branch := range river.Delta {
 		for _, pt := range branch {
-			tx, ty := int(pt.X), int(pt.Y)
-			for dy := -1; dy <= 1; dy++ {
-				for dx := -1; dx <= 1; dx++ {
-					tile := gm.GetTile(tx+dx, ty+dy)
-					if tile != nil && tile.Terrain != game.TerrainOcean {
-						tile.HasRiver = true
-					}
+			for at := range game.At(int(pt.X), int(pt.Y)).Within(1) {
+				tile := gm.TileAt(at)
+				if tile != nil && tile.Terrain != game.TerrainOcean {
+					tile.HasRiver = true
 				}
 			}
 		}
@@ -1307,10 +1295,7 @@ func (g *Generator) placeResources(gm *game.GameMap) {
 
 	for y := 0; y < g.config.Height; y++ {
 		for x := 0; x < g.config.Width; x++ {
-			tile := gm.GetTile(x, y)
-			if tile == nil {
-				continue
-			}
+			tile := gm.GetTileUnsafe(x, y)
 
 			// Skip if random chance not met
 			if g.rng.Float64() > resourceChance {
@@ -1381,15 +1366,15 @@ func (g *Generator) ensurePlayability(gm *game.GameMap) {
 }
 
 // FindStartingPositions finds suitable starting locations for players
-func (g *Generator) FindStartingPositions(gm *game.GameMap, count int) [][2]int {
-	positions := make([][2]int, 0, count)
+func (g *Generator) FindStartingPositions(gm *game.GameMap, count int) []game.Coord {
+	positions := make([]game.Coord, 0, count)
 
 	// Find all candidate positions (good land tiles)
-	candidates := make([][2]int, 0)
+	candidates := make([]game.Coord, 0)
 	for y := 2; y < g.config.Height-2; y++ {
 		for x := 2; x < g.config.Width-2; x++ {
 			if g.isGoodStartPosition(gm, x, y) {
-				candidates = append(candidates, [2]int{x, y})
+				candidates = append(candidates, game.At(x, y))
 			}
 		}
 	}
@@ -1400,14 +1385,14 @@ func (g *Generator) FindStartingPositions(gm *game.GameMap, count int) [][2]int
 			for x := 0; x < g.config.Width; x++ {
 				tile := gm.GetTile(x, y)
 				if tile != nil && !tile.IsWater() && tile.Terrain != game.TerrainMountains {
-					candidates = append(candidates, [2]int{x, y})
+					candidates = append(candidates, game.At(x, y))
 				}
 			}
 		}
 	}
 
 	// Keep a copy of all candidates for fallback
-	allCandidates := make([][2]int, len(candidates))
+	allCandidates := make([]game.Coord, len(candidates))
 	copy(allCandidates, candidates)
 
 	// Select positions that are spread apart
@@ -1421,8 +1406,8 @@ func (g *Generator) FindStartingPositions(gm *game.GameMap, count int) [][2]int
 		// Check distance from existing positions
 		valid := true
 		for _, pos := range positions {
-			dist := math.Sqrt(float64((candidate[0]-pos[0])*(candidate[0]-pos[0]) +
-				(candidate[1]-pos[1])*(candidate[1]-pos[1])))
+			dist := math.Sqrt(float64((candidate.X-pos.X)*(candidate.X-pos.X) +
+				(candidate.Y-pos.Y)*(candidate.Y-pos.Y)))
 			if dist < minDistance {
 				valid = false
 				break
@@ -1449,14 +1434,7 @@ func (g *Generator) FindStartingPositions(gm *game.GameMap, count int) [][2]int
 				break
 			}
 			// Check if this position is already used
-			alreadyUsed := false
-			for _, pos := range positions {
-				if pos[0] == candidate[0] && pos[1] == candidate[1] {
-					alreadyUsed = true
-					break
-				}
-			}
-			if !alreadyUsed {
+			if !slices.Contains(positions, candidate) {
 				positions = append(positions, candidate)
 			}
 		}
@@ -1511,8 +1489,17 @@ func GenerateWithPlayers(config GeneratorConfig, players []*game.Player) *game.G
 	gen := NewGenerator(config)
 	gm := gen.Generate()
 
-	// Find starting positions
-	startPositions := gen.FindStartingPositions(gm, len(players))
+	// Find starting positions, where each civilization began on earth
+	// maps with true starts
+	var startPositions []game.Coord
+	if config.MapType == "earth" && config.TrueStarts {
+		startPositions = gen.TrueStartPositions(gm, players)
+	} else {
+		startPositions = gen.FindStartingPositions(gm, len(players))
+	}
+	if !config.NavalPlay {
+		gen.ensureEscape(gm, startPositions)
+	}
 	log.Printf("Found %d starting positions for %d players", len(startPositions), len(players))
 
 	// Place starting units for each player
@@ -1523,20 +1510,20 @@ func GenerateWithPlayers(config GeneratorConfig, players []*game.Player) *game.G
 		}
 
 		pos := startPositions[i]
-		log.Printf("Placing units for player %s at (%d, %d)", player.Name, pos[0], pos[1])
+		log.Printf("Placing units for player %s at (%d, %d)", player.Name, pos.X, pos.Y)
 
 		// Create starting settler
-		settler := game.NewUnit(game.UnitSettler, player.ID, pos[0], pos[1])
+		settler := game.NewUnit(game.UnitSettler, player.ID, pos.X, pos.Y)
 		player.AddUnit(settler)
 		log.Printf("Created settler %s for player %s", settler.ID, player.Name)
 
 		// Create starting warrior (offset by 1 tile)
-		warriorX := pos[0]
-		warriorY := pos[1]
-		if gm.IsValidCoord(pos[0]+1, pos[1]) {
-			tile := gm.GetTile(pos[0]+1, pos[1])
+		warriorX := pos.X
+		warriorY := pos.Y
+		if east := pos.Step(game.East); gm.Contains(east) {
+			tile := gm.TileAt(east)
 			if tile != nil && !tile.IsWater() && tile.Terrain != game.TerrainMountains {
-				warriorX = pos[0] + 1
+				warriorX = east.X
 			}
 		}
 
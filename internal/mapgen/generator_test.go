@@ -0,0 +1,36 @@
+package mapgen
+
+import (
+	"io"
+	"log"
+	"testing"
+)
+
+func BenchmarkGenerate200x200(b *testing.B) {
+	benchmarkGenerate(b, 200, 200)
+}
+
+func BenchmarkGenerate400x250(b *testing.B) {
+	benchmarkGenerate(b, 400, 250)
+}
+
+func benchmarkGenerate(b *testing.B, width, height int) {
+	// Generation logs heavily, keep benchmark output readable
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	config := GeneratorConfig{
+		Width:         width,
+		Height:        height,
+		Seed:          1,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		NewGenerator(config).Generate()
+	}
+}
@@ -0,0 +1,164 @@
+package mapgen
+
+import (
+	"civilization/internal/game"
+	"log"
+)
+
+// A player who starts on a small island cannot expand, and with no ships
+// in the rules cannot ever leave it. Once starting positions are chosen,
+// each one on land smaller than MinStartLand that land units can walk is
+// joined to a larger land mass by a land bridge, or if none is near
+// enough, moved to a larger land mass.
+
+// MinStartLand is the fewest tiles of walkable land a player may start on
+// when they cannot take to the sea
+const MinStartLand = 40
+
+// maxBridgeLength is the most ocean tiles a land bridge is carved across
+const maxBridgeLength = 6
+
+// HasNavalUnits reports whether the current rules have a naval unit, so
+// that players stranded on an island can sail away
+func HasNavalUnits() bool {
+	for _, t := range game.UnitTypes() {
+		if game.UnitTemplates[t].IsNaval {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureEscape makes sure no starting position is stranded on land smaller
+// than MinStartLand, carving land bridges or moving positions as needed.
+// It changes positions in place.
+func (g *Generator) ensureEscape(gm *game.GameMap, positions []game.Coord) {
+	carved := false
+	for i, pos := range positions {
+		regions, sizes := walkableRegions(gm)
+		region := regions[gm.Index(pos.X, pos.Y)]
+		if region < 0 || sizes[region] >= MinStartLand {
+			continue
+		}
+
+		if g.carveBridge(gm, regions, sizes, region) {
+			log.Printf("Carved a land bridge from the island start at %v", pos)
+			carved = true
+			continue
+		}
+		if moved, ok := g.reassignStart(gm, regions, sizes, positions, i); ok {
+			log.Printf("Moved the island start at %v to %v", pos, moved)
+			positions[i] = moved
+			continue
+		}
+		log.Printf("No land of %d tiles to take the island start at %v to", MinStartLand, pos)
+	}
+
+	// Carved land changes the coast
+	if carved {
+		gm.MarkCoast()
+	}
+}
+
+// walkableRegions labels each tile land units can enter with the region
+// they can walk to it in, fords included, and returns the size of each
+// region. Other tiles are -1.
+func walkableRegions(gm *game.GameMap) ([]int, []int) {
+	regions := make([]int, len(gm.Tiles))
+	for i := range regions {
+		regions[i] = -1
+	}
+
+	var sizes []int
+	for start := range gm.Tiles {
+		if !gm.Tiles[start].IsPassable() || regions[start] >= 0 {
+			continue
+		}
+		region := len(sizes)
+		sizes = append(sizes, 0)
+		regions[start] = region
+		queue := []int{start}
+		for len(queue) > 0 {
+			tile := &gm.Tiles[queue[0]]
+			queue = queue[1:]
+			sizes[region]++
+			for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+				i := gm.Index(n.X, n.Y)
+				if n.IsPassable() && regions[i] < 0 {
+					regions[i] = region
+					queue = append(queue, i)
+				}
+			}
+		}
+	}
+	return regions, sizes
+}
+
+// carveBridge turns the shortest run of ocean, no longer than
+// maxBridgeLength, from a region to one of at least MinStartLand tiles
+// into grassland. It reports whether there was one to carve.
+func (g *Generator) carveBridge(gm *game.GameMap, regions, sizes []int, region int) bool {
+	// Search outward across the ocean from every tile of the region
+	from := make([]int, len(gm.Tiles))
+	steps := make([]int, len(gm.Tiles))
+	for i := range from {
+		from[i] = -1
+	}
+	var queue []int
+	for i, r := range regions {
+		if r == region {
+			from[i] = i
+			queue = append(queue, i)
+		}
+	}
+
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		tile := &gm.Tiles[i]
+		for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+			next := gm.Index(n.X, n.Y)
+			if from[next] >= 0 {
+				continue
+			}
+			if r := regions[next]; r >= 0 {
+				if r == region || sizes[r] < MinStartLand {
+					continue
+				}
+				// Reached large land: carve the ocean on the way here
+				for at := i; regions[at] != region; at = from[at] {
+					gm.Tiles[at].Terrain = game.TerrainGrassland
+					gm.Tiles[at].Ford = false
+				}
+				return true
+			}
+			if steps[i] < maxBridgeLength {
+				from[next] = i
+				steps[next] = steps[i] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// reassignStart returns the good start position on land of at least
+// MinStartLand tiles nearest to the stranded start, keeping clear of the
+// other starts, or any open land tile there if no good one is free
+func (g *Generator) reassignStart(gm *game.GameMap, regions, sizes []int, positions []game.Coord, stranded int) (game.Coord, bool) {
+	others := make([]game.Coord, 0, len(positions)-1)
+	others = append(others, positions[:stranded]...)
+	others = append(others, positions[stranded+1:]...)
+	onLargeLand := func(suits func(gm *game.GameMap, x, y int) bool) func(gm *game.GameMap, x, y int) bool {
+		return func(gm *game.GameMap, x, y int) bool {
+			r := regions[gm.Index(x, y)]
+			return r >= 0 && sizes[r] >= MinStartLand && suits(gm, x, y)
+		}
+	}
+
+	x, y := float64(positions[stranded].X)+0.5, float64(positions[stranded].Y)+0.5
+	if pos, ok := g.nearestStart(gm, x, y, others, onLargeLand(g.isGoodStartPosition)); ok {
+		return pos, true
+	}
+	return g.nearestStart(gm, x, y, others, onLargeLand(isOpenLand))
+}
@@ -0,0 +1,72 @@
+package mapgen
+
+import (
+	"civilization/internal/game"
+	"io"
+	"log"
+	"testing"
+)
+
+// islandMap returns a map of ocean with a continent of 8x8 grassland in
+// the west and a one-tile island at x
+func islandMap(x int) *game.GameMap {
+	gm := game.NewGameMap(30, 12)
+	for i := range gm.Tiles {
+		tile := &gm.Tiles[i]
+		if !(tile.X >= 1 && tile.X <= 8 && tile.Y >= 2 && tile.Y <= 9) && !(tile.X == x && tile.Y == 5) {
+			tile.Terrain = game.TerrainOcean
+		}
+	}
+	gm.MarkCoast()
+	return gm
+}
+
+// TestEnsureEscape checks that a start on an island near a continent is
+// bridged to it, and that one far out at sea is moved onto it
+func TestEnsureEscape(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	walkable := func(gm *game.GameMap, pos game.Coord) int {
+		regions, sizes := walkableRegions(gm)
+		return sizes[regions[gm.Index(pos.X, pos.Y)]]
+	}
+	g := NewGenerator(GeneratorConfig{Width: 30, Height: 12, Seed: 1})
+
+	// Three tiles of ocean lie between the island and the continent
+	gm := islandMap(12)
+	positions := []game.Coord{game.At(12, 5), game.At(3, 5)}
+	g.ensureEscape(gm, positions)
+	if positions[0] != game.At(12, 5) || walkable(gm, positions[0]) < MinStartLand {
+		t.Errorf("island start %v has %d walkable tiles, want a bridge to the continent", positions[0], walkable(gm, positions[0]))
+	}
+	land := 0
+	for _, tile := range gm.Tiles {
+		if !tile.IsWater() {
+			land++
+		}
+		coastal := false
+		for _, n := range gm.GetNeighbors(tile.X, tile.Y) {
+			coastal = coastal || tile.IsWater() && !n.IsWater()
+		}
+		if tile.Coastal != coastal {
+			t.Errorf("(%d, %d) is coastal %v after the bridge, want %v", tile.X, tile.Y, tile.Coastal, coastal)
+		}
+	}
+	if land != 64+1+3 {
+		t.Errorf("%d land tiles after the bridge, want 3 carved", land)
+	}
+
+	// Too far for a bridge
+	gm = islandMap(25)
+	positions = []game.Coord{game.At(25, 5), game.At(2, 5)}
+	g.ensureEscape(gm, positions)
+	if moved := positions[0]; moved == game.At(25, 5) || walkable(gm, moved) < MinStartLand || crowded(moved, positions[1:]) {
+		t.Errorf("island start moved to %v, want onto the continent clear of %v", moved, positions[1])
+	}
+	if !gm.GetTile(20, 5).IsWater() {
+		t.Error("a bridge was carved across the open sea")
+	}
+
+}
@@ -0,0 +1,167 @@
+package mapgen
+
+import (
+	"civilization/internal/game"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// On earth maps each civilization can start where it began in history,
+// the Romans in Italy and the Egyptians on the Nile. Starts are given in
+// the same normalized coordinates as the continents, and a civilization
+// is placed on the good start position nearest its own.
+
+// TrueStart is where a civilization begins on an earth map
+type TrueStart struct {
+	Name string  // Where the start is
+	X    float64 // Normalized, 0 to 1 across the map
+	Y    float64
+}
+
+// EarthStarts holds the true start of each civilization, indexed like the
+// language packs' civilization lists
+var EarthStarts = []TrueStart{
+	{Name: "Italy", X: 0.46, Y: 0.24},         // Romans
+	{Name: "Nile", X: 0.53, Y: 0.37},          // Egyptians
+	{Name: "Greece", X: 0.51, Y: 0.27},        // Greeks
+	{Name: "Mesopotamia", X: 0.61, Y: 0.34},   // Babylonians
+	{Name: "Germany", X: 0.45, Y: 0.14},       // Germans
+	{Name: "Russia", X: 0.62, Y: 0.14},        // Russians
+	{Name: "China", X: 0.80, Y: 0.30},         // Chinese
+	{Name: "North America", X: 0.12, Y: 0.28}, // Americans
+}
+
+// nile is the course of the Nile from its source to the Mediterranean, in
+// normalized coordinates. It ends at the first ocean tile.
+var nile = [][2]float64{
+	{0.54, 0.55}, {0.545, 0.45}, {0.53, 0.38}, {0.525, 0.30},
+}
+
+// startSpacing is how near to one another, in tiles, starts may be placed
+// when they are put at true starts or moved off islands
+const startSpacing = 4
+
+// ErrNoTrueStart is returned when a civilization in the game has no true
+// start on the earth map
+var ErrNoTrueStart = errors.New("civilization has no true start")
+
+// CheckTrueStarts returns an error unless every player leads a
+// civilization with its own true start
+func CheckTrueStarts(players []*game.Player) error {
+	if len(players) > len(EarthStarts) {
+		return fmt.Errorf("%w: %d players but only %d true starts", ErrNoTrueStart, len(players), len(EarthStarts))
+	}
+	taken := make(map[int]string)
+	for _, p := range players {
+		if p.Civilization < 0 || p.Civilization >= len(EarthStarts) {
+			return fmt.Errorf("%w: %s leads civilization %d", ErrNoTrueStart, p.Name, p.Civilization)
+		}
+		if other, ok := taken[p.Civilization]; ok {
+			return fmt.Errorf("%w: %s and %s share %s", ErrNoTrueStart, other, p.Name, EarthStarts[p.Civilization].Name)
+		}
+		taken[p.Civilization] = p.Name
+	}
+	return nil
+}
+
+// TrueStartPositions returns the starting position of each player, at the
+// good start position nearest their civilization's true start, stopping
+// short if the map has no room for one. Players must have passed
+// CheckTrueStarts.
+func (g *Generator) TrueStartPositions(gm *game.GameMap, players []*game.Player) []game.Coord {
+	positions := make([]game.Coord, 0, len(players))
+	for _, p := range players {
+		start := EarthStarts[p.Civilization]
+		x := start.X * float64(g.config.Width)
+		y := start.Y * float64(g.config.Height)
+		pos, ok := g.nearestStart(gm, x, y, positions, g.isGoodStartPosition)
+		if !ok {
+			pos, ok = g.nearestStart(gm, x, y, positions, isOpenLand)
+		}
+		if !ok {
+			break
+		}
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+// nearestStart returns the tile nearest (x, y) that suits as a start and
+// keeps its distance from the positions already taken
+func (g *Generator) nearestStart(gm *game.GameMap, x, y float64, taken []game.Coord, suits func(gm *game.GameMap, x, y int) bool) (game.Coord, bool) {
+	var best game.Coord
+	bestDist := math.Inf(1)
+	for ty := 0; ty < g.config.Height; ty++ {
+		for tx := 0; tx < g.config.Width; tx++ {
+			dist := math.Hypot(float64(tx)+0.5-x, float64(ty)+0.5-y)
+			if dist >= bestDist || !suits(gm, tx, ty) || crowded(game.At(tx, ty), taken) {
+				continue
+			}
+			best, bestDist = game.At(tx, ty), dist
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
+
+// crowded reports whether a position is too near one already taken
+func crowded(at game.Coord, taken []game.Coord) bool {
+	for _, pos := range taken {
+		if pos.Distance(at) < startSpacing {
+			return true
+		}
+	}
+	return false
+}
+
+// isOpenLand reports whether a tile is land a settler could start on
+func isOpenLand(gm *game.GameMap, x, y int) bool {
+	tile := gm.GetTile(x, y)
+	return tile != nil && !tile.IsWater() && tile.Terrain != game.TerrainMountains
+}
+
+// addNile runs the Nile from its source to the first ocean tile on its
+// course. The land along it is fertile whatever the climate.
+func (g *Generator) addNile(gm *game.GameMap) {
+	w := float64(g.config.Width)
+	h := float64(g.config.Height)
+
+	river := game.River{Points: make([]game.RiverPoint, 0)}
+	visited := make(map[game.Coord]bool)
+	for i := 0; i+1 < len(nile); i++ {
+		x0, y0 := nile[i][0]*w, nile[i][1]*h
+		x1, y1 := nile[i+1][0]*w, nile[i+1][1]*h
+		steps := int(math.Ceil(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))))
+		for s := 0; s < steps; s++ {
+			t := float64(s) / float64(steps)
+			px, py := x0+(x1-x0)*t, y0+(y1-y0)*t
+			tile := gm.GetTile(int(px), int(py))
+			if tile == nil {
+				continue
+			}
+			river.Points = append(river.Points, game.RiverPoint{X: px, Y: py})
+			if tile.IsWater() {
+				// The mouth
+				g.finishNile(gm, river)
+				return
+			}
+			if key := tile.Coord(); !visited[key] {
+				visited[key] = true
+				if tile.Terrain == game.TerrainDesert || tile.Terrain == game.TerrainPlains {
+					tile.Terrain = game.TerrainGrassland
+				}
+			}
+		}
+	}
+	g.finishNile(gm, river)
+}
+
+// finishNile adds the Nile to the map, if it runs far enough to be a river
+func (g *Generator) finishNile(gm *game.GameMap, river game.River) {
+	if len(river.Points) <= 3 {
+		return
+	}
+	river.Points = g.smoothRiverPath(river.Points)
+	gm.Rivers = append(gm.Rivers, river)
+	g.markRiverTiles(gm, river)
+}
@@ -0,0 +1,43 @@
+package mapgen
+
+import (
+	"civilization/internal/game"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+// TestTrueStarts places every civilization near its true start on an
+// earth map, the Egyptians on the Nile, and refuses more civilizations
+// than there are starts
+func TestTrueStarts(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	g := game.NewGame(game.GameConfig{PlayerCount: len(EarthStarts), PlayerName: "Player"})
+	if err := CheckTrueStarts(g.Players); err != nil {
+		t.Fatal(err)
+	}
+	config := GeneratorConfig{Width: 160, Height: 80, Seed: 1, WaterLevel: 0.35, MountainLevel: 0.75, MapType: "earth", TrueStarts: true}
+	gm := GenerateWithPlayers(config, g.Players)
+
+	for _, p := range g.Players {
+		start := EarthStarts[p.Civilization]
+		settler := p.Units[0]
+		x, y := int(start.X*float64(config.Width)), int(start.Y*float64(config.Height))
+		if d := settler.Coord().Distance(game.At(x, y)); d > 8 {
+			t.Errorf("%s start at (%d, %d), %d tiles from %s", p.Name, settler.X, settler.Y, d, start.Name)
+		}
+	}
+	egyptians := g.Players[1]
+	if tile := gm.GetTile(egyptians.Units[0].X, egyptians.Units[0].Y); !tile.HasRiver {
+		t.Errorf("Egyptians start at (%d, %d), off the Nile", tile.X, tile.Y)
+	}
+
+	more := append(g.Players, game.NewPlayer("Extra", game.PlayerAI, len(EarthStarts)))
+	if err := CheckTrueStarts(more); !errors.Is(err, ErrNoTrueStart) {
+		t.Errorf("%d civilizations checked with %v, want %v", len(more), err, ErrNoTrueStart)
+	}
+}
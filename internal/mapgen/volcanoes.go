@@ -0,0 +1,34 @@
+package mapgen
+
+import "civilization/internal/game"
+
+// volcanoChance is the percent chance of a mountain being a volcano
+const volcanoChance = 4
+
+// volcanoSpacing is how near to one another, in tiles, volcanoes may be
+const volcanoSpacing = 6
+
+// placeVolcanoes makes a few mountains, spread apart, volcanoes that may
+// erupt once random events are on
+func (g *Generator) placeVolcanoes(gm *game.GameMap) {
+	var volcanoes []game.Coord
+	for y := 0; y < g.config.Height; y++ {
+		for x := 0; x < g.config.Width; x++ {
+			tile := gm.GetTileUnsafe(x, y)
+			if tile.Terrain != game.TerrainMountains || g.rng.Intn(100) >= volcanoChance {
+				continue
+			}
+			near := false
+			for _, v := range volcanoes {
+				if v.Distance(game.At(x, y)) < volcanoSpacing {
+					near = true
+					break
+				}
+			}
+			if !near {
+				tile.Volcano = true
+				volcanoes = append(volcanoes, game.At(x, y))
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Package tournament plays headless games between AI configurations and
+// scores how each fared
+package tournament
+
+import (
+	"civilization/internal/ai"
+	"civilization/internal/game"
+	"civilization/internal/mapgen"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entrant is an AI configuration taking part in the tournament
+type Entrant struct {
+	Name       string `json:"name"`
+	Difficulty string `json:"difficulty"`
+	Budget     string `json:"budget,omitempty"`  // Thinking time per turn of a hard AI
+	Weights    string `json:"weights,omitempty"` // File of the production weights, blank for the defaults
+
+	budget  time.Duration
+	weights *ai.ProductionWeights
+}
+
+// ParseEntrant reads an entrant given as difficulty[:budget][@weights],
+// e.g. "hard:200ms" or "normal@weights.json"
+func ParseEntrant(spec string) (Entrant, error) {
+	spec, weights, hasWeights := strings.Cut(spec, "@")
+	difficulty, budget, hasBudget := strings.Cut(spec, ":")
+	e := Entrant{Name: spec, Difficulty: difficulty}
+	if hasWeights {
+		e.Name += "@" + weights
+		e.Weights = weights
+		w, err := ai.LoadProductionWeights(weights)
+		if err != nil {
+			return e, fmt.Errorf("%s: %w", e.Name, err)
+		}
+		e.weights = w
+	}
+
+	switch difficulty {
+	case game.DifficultyNormal:
+		if hasBudget {
+			return e, fmt.Errorf("%s: only hard AIs take a thinking budget", e.Name)
+		}
+	case game.DifficultyHard:
+		e.budget = ai.DefaultLookaheadBudget
+		if hasBudget {
+			d, err := time.ParseDuration(budget)
+			if err != nil || d <= 0 {
+				return e, fmt.Errorf("%s: invalid thinking budget %q", e.Name, budget)
+			}
+			e.budget = d
+		}
+		e.Budget = e.budget.String()
+	default:
+		return e, fmt.Errorf("%s: unknown difficulty %q", e.Name, difficulty)
+	}
+	return e, nil
+}
+
+// controller creates the entrant's controller for a player
+func (e Entrant) controller(g *game.GameState, playerID string) *ai.Controller {
+	c := ai.NewController(g, playerID)
+	c.Lookahead = nil
+	if e.Difficulty == game.DifficultyHard {
+		c.Lookahead = ai.NewLookahead(e.budget)
+	}
+	c.Weights = e.weights
+	return c
+}
+
+// Settings are the games a tournament plays
+type Settings struct {
+	Games   int   `json:"games"`
+	Seed    int64 `json:"seed"` // Seed of the first game, each next game adds one
+	Players int   `json:"players"`
+	Turns   int   `json:"turns"` // Games without a winner by then are stalemates
+	Width   int   `json:"width"`
+	Height  int   `json:"height"`
+}
+
+// GameResult is how one game of the tournament ended
+type GameResult struct {
+	Game      int          `json:"game"`
+	Seed      int64        `json:"seed"`
+	Turns     int          `json:"turns"`
+	Winner    string       `json:"winner,omitempty"`    // Entrant that won, empty for a draw
+	Stalemate bool         `json:"stalemate,omitempty"` // Whether the game reached the turn limit
+	Seats     []SeatResult `json:"seats"`
+}
+
+// SeatResult is how one player of a game fared
+type SeatResult struct {
+	Seat       int       `json:"seat"`
+	Entrant    string    `json:"entrant"`
+	Won        bool      `json:"won"`
+	Alive      bool      `json:"alive"`
+	Score      int       `json:"score"`
+	Cities     int       `json:"cities"`
+	Population int       `json:"population"`
+	Military   int       `json:"military"`
+	Stalls     ai.Stalls `json:"stalls"` // Stalls the entrant's watchdog caught
+}
+
+// EntrantSummary aggregates an entrant's results over the tournament
+type EntrantSummary struct {
+	Entrant        string  `json:"entrant"`
+	Games          int     `json:"games"` // Games the entrant played in
+	Seats          int     `json:"seats"` // Players the entrant controlled
+	Wins           int     `json:"wins"`
+	WinRate        float64 `json:"win_rate"`         // Wins per game played in
+	AvgVictoryTurn float64 `json:"avg_victory_turn"` // Over the entrant's wins
+	AvgScore       float64 `json:"avg_score"`        // Final score per seat
+	SurvivalRate   float64 `json:"survival_rate"`    // Seats still alive at the end
+	Stalls         int     `json:"stalls"`           // Stalls caught over all seats
+}
+
+// Report is the outcome of a tournament
+type Report struct {
+	Settings   Settings         `json:"settings"`
+	Entrants   []Entrant        `json:"entrants"`
+	Summary    []EntrantSummary `json:"summary"`
+	Draws      int              `json:"draws"`
+	Stalemates int              `json:"stalemates"` // Draws at the turn limit
+	Games      []GameResult     `json:"games"`
+}
+
+// Run plays the tournament's games, at most parallel at a time. Entrants
+// take the seats in turn, starting one seat further along each game, so
+// every entrant gets its share of starting positions.
+func Run(settings Settings, entrants []Entrant, parallel int) *Report {
+	results := make([]GameResult, settings.Games)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(parallel, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = playGame(settings, entrants, i)
+			}
+		}()
+	}
+	for i := 0; i < settings.Games; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summarize(settings, entrants, results)
+}
+
+// seating returns the entrants taking the seats of a game: they take the
+// seats in turn, starting one seat further along each game
+func seating(entrants []Entrant, players, index int) []Entrant {
+	seats := make([]Entrant, players)
+	for i := range seats {
+		seats[i] = entrants[(i+index)%len(entrants)]
+	}
+	return seats
+}
+
+// playGame plays one headless game of the tournament
+func playGame(settings Settings, entrants []Entrant, index int) GameResult {
+	config := game.DefaultGameConfig()
+	config.Seed = settings.Seed + int64(index)
+	config.PlayerCount = settings.Players
+	config.MapWidth = settings.Width
+	config.MapHeight = settings.Height
+
+	g := game.NewGame(config)
+	g.SetMap(mapgen.GenerateWithPlayers(mapgen.GeneratorConfig{
+		Width:         config.MapWidth,
+		Height:        config.MapHeight,
+		Seed:          config.Seed,
+		WaterLevel:    0.35,
+		MountainLevel: 0.75,
+		NavalPlay:     mapgen.HasNavalUnits(),
+	}, g.Players))
+	g.Start()
+
+	seats := make(map[string]Entrant, len(g.Players))
+	controllers := make(map[string]*ai.Controller, len(g.Players))
+	for i, entrant := range seating(entrants, len(g.Players), index) {
+		p := g.Players[i]
+		seats[p.ID] = entrant
+		controllers[p.ID] = entrant.controller(g, p.ID)
+	}
+	ai.PlayTurnsWith(g, controllers, settings.Turns)
+
+	result := GameResult{Game: index + 1, Seed: config.Seed, Turns: g.CurrentTurn}
+	if g.Winner != nil {
+		result.Winner = seats[g.Winner.ID].Name
+	}
+	result.Stalemate = g.Winner == nil && g.CurrentTurn >= settings.Turns
+	for i, p := range g.Players {
+		result.Seats = append(result.Seats, SeatResult{
+			Seat:       i,
+			Entrant:    seats[p.ID].Name,
+			Won:        g.Winner != nil && g.Winner.ID == p.ID,
+			Alive:      p.IsAlive,
+			Score:      p.Score(),
+			Cities:     p.CityCount(),
+			Population: p.TotalPopulation(),
+			Military:   p.MilitaryStrength(),
+			Stalls:     controllers[p.ID].Stalls,
+		})
+	}
+	return result
+}
+
+// summarize aggregates the games' results by entrant
+func summarize(settings Settings, entrants []Entrant, games []GameResult) *Report {
+	report := &Report{Settings: settings, Entrants: entrants, Games: games}
+
+	// Entrants listed twice share a summary
+	index := make(map[string]int, len(entrants))
+	for _, e := range entrants {
+		if _, ok := index[e.Name]; !ok {
+			index[e.Name] = len(report.Summary)
+			report.Summary = append(report.Summary, EntrantSummary{Entrant: e.Name})
+		}
+	}
+
+	victoryTurns := make([]int, len(report.Summary))
+	scores := make([]int, len(report.Summary))
+	survived := make([]int, len(report.Summary))
+	for _, result := range games {
+		if result.Winner == "" {
+			report.Draws++
+		}
+		if result.Stalemate {
+			report.Stalemates++
+		}
+		played := make(map[int]bool)
+		for _, seat := range result.Seats {
+			i := index[seat.Entrant]
+			s := &report.Summary[i]
+			s.Seats++
+			s.Stalls += seat.Stalls.Total()
+			scores[i] += seat.Score
+			if seat.Alive {
+				survived[i]++
+			}
+			if seat.Won {
+				s.Wins++
+				victoryTurns[i] += result.Turns
+			}
+			played[i] = true
+		}
+		for i := range played {
+			report.Summary[i].Games++
+		}
+	}
+
+	for i := range report.Summary {
+		s := &report.Summary[i]
+		if s.Games > 0 {
+			s.WinRate = float64(s.Wins) / float64(s.Games)
+		}
+		if s.Wins > 0 {
+			s.AvgVictoryTurn = float64(victoryTurns[i]) / float64(s.Wins)
+		}
+		if s.Seats > 0 {
+			s.AvgScore = float64(scores[i]) / float64(s.Seats)
+			s.SurvivalRate = float64(survived[i]) / float64(s.Seats)
+		}
+	}
+	return report
+}
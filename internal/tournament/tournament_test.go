@@ -0,0 +1,174 @@
+package tournament
+
+import (
+	"civilization/internal/ai"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseEntrant reads entrant specs and checks what they configure, or
+// why they are refused
+func TestParseEntrant(t *testing.T) {
+	weights := filepath.Join(t.TempDir(), "weights.json")
+	if err := os.WriteFile(weights, []byte(`{"build_turn": -2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		spec   string
+		name   string
+		budget time.Duration
+		want   string // Part of the error, "" if the spec is valid
+	}{
+		{"normal", "normal", 0, ""},
+		{"hard", "hard", ai.DefaultLookaheadBudget, ""},
+		{"hard:200ms", "hard:200ms", 200 * time.Millisecond, ""},
+		{"normal@" + weights, "normal@" + weights, 0, ""},
+		{"normal:1s", "", 0, "only hard AIs"},
+		{"hard:soon", "", 0, "invalid thinking budget"},
+		{"hard:-1s", "", 0, "invalid thinking budget"},
+		{"easy", "", 0, "unknown difficulty"},
+		{"normal@missing.json", "", 0, "missing.json"},
+	}
+	for _, c := range tests {
+		t.Run(c.spec, func(t *testing.T) {
+			e, err := ParseEntrant(c.spec)
+			switch {
+			case c.want == "" && err != nil:
+				t.Fatalf("spec refused: %v", err)
+			case c.want != "" && (err == nil || !strings.Contains(err.Error(), c.want)):
+				t.Fatalf("got error %v, want one saying %q", err, c.want)
+			case c.want != "":
+				return
+			}
+			if e.Name != c.name || e.budget != c.budget {
+				t.Errorf("entrant %q with budget %s, want %q with %s", e.Name, e.budget, c.name, c.budget)
+			}
+			if (e.Weights != "") != (e.weights != nil) || (e.weights != nil && e.weights.BuildTurn != -2) {
+				t.Errorf("entrant weights %q loaded as %+v", e.Weights, e.weights)
+			}
+		})
+	}
+}
+
+// TestSeating checks that the entrants take the seats in turn, starting one
+// seat further along each game
+func TestSeating(t *testing.T) {
+	entrants := []Entrant{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	for index, want := range []string{"abca", "bcab", "cabc", "abca"} {
+		names := ""
+		for _, e := range seating(entrants, 4, index) {
+			names += e.Name
+		}
+		if names != want {
+			t.Errorf("game %d seats %s, want %s", index+1, names, want)
+		}
+	}
+}
+
+// TestSummarize aggregates hand-made results: a win, a stalemate and a
+// draw before the turn limit, with one entrant listed twice
+func TestSummarize(t *testing.T) {
+	entrants := []Entrant{{Name: "normal"}, {Name: "hard"}, {Name: "normal"}}
+	games := []GameResult{
+		{Game: 1, Turns: 80, Winner: "hard", Seats: []SeatResult{
+			{Entrant: "normal", Score: 10},
+			{Entrant: "hard", Won: true, Alive: true, Score: 50, Stalls: ai.Stalls{Oscillations: 2}},
+			{Entrant: "normal", Score: 20, Stalls: ai.Stalls{NoCities: 1}},
+		}},
+		{Game: 2, Turns: 200, Stalemate: true, Seats: []SeatResult{
+			{Entrant: "hard", Alive: true, Score: 30},
+			{Entrant: "normal", Alive: true, Score: 40},
+			{Entrant: "hard", Score: 10},
+		}},
+		{Game: 3, Turns: 120, Seats: []SeatResult{
+			{Entrant: "normal", Score: 0},
+			{Entrant: "normal", Score: 0},
+			{Entrant: "normal", Score: 0},
+		}},
+	}
+
+	report := summarize(Settings{Games: 3}, entrants, games)
+	if report.Draws != 2 || report.Stalemates != 1 {
+		t.Errorf("%d draws and %d stalemates, want 2 and 1", report.Draws, report.Stalemates)
+	}
+	want := []EntrantSummary{
+		{Entrant: "normal", Games: 3, Seats: 6, AvgScore: 70.0 / 6, SurvivalRate: 1.0 / 6, Stalls: 1},
+		{Entrant: "hard", Games: 2, Seats: 3, Wins: 1, WinRate: 0.5, AvgVictoryTurn: 80, AvgScore: 30, SurvivalRate: 2.0 / 3, Stalls: 2},
+	}
+	if len(report.Summary) != len(want) {
+		t.Fatalf("summary of %d entrants, want %d", len(report.Summary), len(want))
+	}
+	for i := range want {
+		if report.Summary[i] != want[i] {
+			t.Errorf("summary %+v, want %+v", report.Summary[i], want[i])
+		}
+	}
+}
+
+// TestRun plays a short tournament on a small map
+func TestRun(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	normal, err := ParseEntrant("normal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := Settings{Games: 2, Seed: 1, Players: 2, Turns: 5, Width: 20, Height: 12}
+	report := Run(settings, []Entrant{normal}, 2)
+
+	if len(report.Games) != 2 || len(report.Summary) != 1 {
+		t.Fatalf("%d games and %d summaries, want 2 and 1", len(report.Games), len(report.Summary))
+	}
+	for i, g := range report.Games {
+		if g.Game != i+1 || g.Seed != settings.Seed+int64(i) || len(g.Seats) != 2 {
+			t.Errorf("game %+v, want game %d with seed %d and 2 seats", g, i+1, settings.Seed+int64(i))
+		}
+		if g.Winner == "" && !g.Stalemate {
+			t.Errorf("game %d ended on turn %d without a winner or the turn limit", g.Game, g.Turns)
+		}
+	}
+	if s := report.Summary[0]; s.Games != 2 || s.Seats != 4 {
+		t.Errorf("summary %+v, want 2 games and 4 seats", s)
+	}
+}
+
+// TestStalemate plays games too short for anyone to win and checks that
+// reaching the turn limit is reported as a stalemate
+func TestStalemate(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	hard, err := ParseEntrant("hard:1ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	normal, err := ParseEntrant("normal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := Settings{Games: 2, Seed: 7, Players: 3, Turns: 3, Width: 20, Height: 12}
+	report := Run(settings, []Entrant{normal, hard}, 1)
+
+	if report.Draws != 2 || report.Stalemates != 2 {
+		t.Errorf("%d draws and %d stalemates, want both games stalemates", report.Draws, report.Stalemates)
+	}
+	for _, g := range report.Games {
+		if !g.Stalemate || g.Winner != "" || g.Turns != settings.Turns {
+			t.Errorf("game %d ended on turn %d, stalemate %v, won by %q, want a stalemate at turn %d", g.Game, g.Turns, g.Stalemate, g.Winner, settings.Turns)
+		}
+	}
+	for _, s := range report.Summary {
+		if s.Wins != 0 || s.WinRate != 0 {
+			t.Errorf("summary %+v, want no wins", s)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package client plays games on a game server over its HTTP and WebSocket
+// protocol. Bots, load testers and integration tests written in Go use it
+// to start games, take actions, ask queries and follow what happens
+// without writing the protocol's JSON by hand.
+//
+// A Client is one player's connection. It keeps the game as that player
+// sees it up to date as the server's messages arrive, and delivers them as
+// typed events to whoever reads Events.
+package client
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrClosed is returned once the connection to the server is closed
+var ErrClosed = errors.New("connection to the server closed")
+
+// ErrGameOver is returned by WaitTurn once the game is over
+var ErrGameOver = errors.New("the game is over")
+
+// Client is a connection to a game server, playing as the player the
+// server seats it as
+type Client struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex // Held to write to conn
+
+	mu        sync.RWMutex
+	playerID  string
+	state     *api.GameStateMessage
+	seq       uint64        // Last event the client was sent
+	resyncing bool          // Set from asking for the game afresh until it comes
+	resyncs   int           // Times the client asked for the game afresh
+	changed   chan struct{} // Closed when the state changes
+	queue     []Event       // Events waiting to be read, once subscribed
+	events    chan Event    // Set once Events is first called
+	queued    chan struct{} // Signalled when an event is queued
+	waiting   *request      // Request waiting for its reply
+	err       error         // Why the connection closed
+	done      chan struct{} // Closed when the connection closes
+
+	requestMu sync.Mutex // Held while a request waits, one at a time
+	requests  int        // Query request IDs handed out, guarded by requestMu
+}
+
+// request is an action or query waiting for the server's reply
+type request struct {
+	match func(Event) bool
+	reply chan Event
+}
+
+// Dial connects to the game server at serverURL, such as
+// "http://localhost:8080", and returns once the server has said which
+// player the client plays and sent it the game
+func Dial(ctx context.Context, serverURL string) (*Client, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	u.Path = "/ws"
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", u, err)
+	}
+	c := &Client{
+		conn:    conn,
+		changed: make(chan struct{}),
+		queued:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+
+	if err := c.wait(ctx, func() bool { return c.playerID != "" && c.state != nil }); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection to the server
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Err returns why the connection closed, or nil while it is open
+func (c *Client) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.err
+}
+
+// readLoop reads the server's messages until the connection closes
+func (c *Client) readLoop() {
+	var err error
+	for {
+		var data []byte
+		if _, data, err = c.conn.ReadMessage(); err != nil {
+			break
+		}
+		e, decodeErr := decode(data)
+		if decodeErr != nil {
+			err = decodeErr
+			break
+		}
+		c.receive(e)
+	}
+
+	c.mu.Lock()
+	c.err = fmt.Errorf("%w: %v", ErrClosed, err)
+	close(c.done)
+	c.mu.Unlock()
+	c.conn.Close()
+}
+
+// receive tracks an event in the state, answers the request waiting for
+// it and queues it for Events
+func (c *Client) receive(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.track(e)
+	if c.waiting != nil && c.waiting.match(e) {
+		c.waiting.reply <- e
+		c.waiting = nil
+	}
+	if c.events != nil {
+		c.queue = append(c.queue, e)
+		select {
+		case c.queued <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Events returns the channel the server's messages are delivered on as
+// events, in the order they arrived. Events arriving before the first call
+// are not delivered; later ones are kept until read. The channel is closed
+// once the connection closes and every event has been read.
+func (c *Client) Events() <-chan Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.events == nil {
+		c.events = make(chan Event)
+		go c.deliver()
+	}
+	return c.events
+}
+
+// deliver sends queued events on the events channel
+func (c *Client) deliver() {
+	defer close(c.events)
+	for {
+		c.mu.Lock()
+		queue := c.queue
+		c.queue = nil
+		c.mu.Unlock()
+
+		if len(queue) == 0 {
+			select {
+			case <-c.queued:
+				continue
+			case <-c.done:
+				c.mu.Lock()
+				empty := len(c.queue) == 0
+				c.mu.Unlock()
+				if empty {
+					return
+				}
+				continue
+			}
+		}
+		for _, e := range queue {
+			c.events <- e
+		}
+	}
+}
+
+// Send sends the server a message it sends no reply to, such as a
+// viewport or a host command
+func (c *Client) Send(msgType api.MessageType, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return c.write(api.WSMessage{Type: msgType, Payload: data})
+}
+
+// write sends a message to the server
+func (c *Client) write(msg api.WSMessage) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.WriteJSON(msg); err != nil {
+		if closed := c.Err(); closed != nil {
+			return closed
+		}
+		return err
+	}
+	return nil
+}
+
+// Do takes an action as the client's player and returns the event it was
+// recorded as, once the state shows what it did. An action the game
+// refuses returns a *ServerError.
+func (c *Client) Do(ctx context.Context, action game.Action) (*game.Event, error) {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(api.ActionMessage{ActionType: action.Type(), Data: data})
+	if err != nil {
+		return nil, err
+	}
+
+	playerID := c.PlayerID()
+	reply, err := c.request(ctx, api.WSMessage{Type: api.MsgTypeAction, Payload: payload}, func(e Event) bool {
+		applied, ok := e.(ActionApplied)
+		return ok && applied.PlayerID == playerID && applied.Type == action.Type()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event := reply.(ActionApplied).Event
+	if err := c.wait(ctx, func() bool { return c.state.Seq >= event.Seq }); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Query asks the server a query and decodes its answer into result. A
+// query the server cannot answer returns a *ServerError.
+func (c *Client) Query(ctx context.Context, queryType string, data, result any) error {
+	query, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	c.requestMu.Lock()
+	c.requests++
+	requestID := "q" + strconv.Itoa(c.requests)
+	c.requestMu.Unlock()
+
+	payload, err := json.Marshal(api.QueryMessage{QueryType: queryType, RequestID: requestID, Data: query})
+	if err != nil {
+		return err
+	}
+	reply, err := c.request(ctx, api.WSMessage{Type: api.MsgTypeQuery, Payload: payload}, func(e Event) bool {
+		answer, ok := e.(QueryResult)
+		return ok && answer.RequestID == requestID
+	})
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(reply.(QueryResult).Result, result)
+}
+
+// request sends a message and waits for the event matching it, or an
+// error from the server. Requests wait one at a time, since the server
+// does not say which request its errors answer.
+func (c *Client) request(ctx context.Context, msg api.WSMessage, match func(Event) bool) (Event, error) {
+	c.requestMu.Lock()
+	defer c.requestMu.Unlock()
+
+	req := &request{
+		match: func(e Event) bool {
+			_, isErr := e.(*ServerError)
+			return isErr || match(e)
+		},
+		reply: make(chan Event, 1),
+	}
+	c.mu.Lock()
+	c.waiting = req
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.waiting == req {
+			c.waiting = nil
+		}
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(msg); err != nil {
+		return nil, err
+	}
+	select {
+	case e := <-req.reply:
+		if serverErr, ok := e.(*ServerError); ok {
+			return nil, serverErr
+		}
+		return e, nil
+	case <-c.done:
+		return nil, c.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitTurn waits until the client's player may act, and returns at once
+// if they may now. It returns ErrGameOver once the game is over.
+func (c *Client) WaitTurn(ctx context.Context) error {
+	var over bool
+	err := c.wait(ctx, func() bool {
+		if !c.current() {
+			return false
+		}
+		over = c.state.Phase == game.PhaseGameOver.String()
+		return over || c.myTurn()
+	})
+	if err == nil && over {
+		return ErrGameOver
+	}
+	return err
+}
+
+// wait waits until ready, called with c.mu read-held, reports true
+func (c *Client) wait(ctx context.Context, ready func() bool) error {
+	for {
+		c.mu.RLock()
+		ok := ready()
+		changed := c.changed
+		c.mu.RUnlock()
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-c.done:
+			return c.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
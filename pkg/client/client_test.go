@@ -0,0 +1,148 @@
+package client
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestPlay starts a game for two people on a test server, and has them
+// fortify a unit, be refused an action, ask a query and hand the turn over
+func TestPlay(t *testing.T) {
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(prev)
+
+	t.Chdir(t.TempDir())
+	server := httptest.NewServer(api.NewServer("").SetupRoutes())
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A map this small may be all sea on some seeds, so the seed is fixed
+	config := game.DefaultGameConfig()
+	config.Seed = 1
+	config.MapWidth, config.MapHeight = 20, 20
+	config.PlayerCount, config.HumanPlayers = 2, 2
+	if _, err := NewGame(ctx, server.URL, config); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := Dial(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := Dial(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if first.PlayerID() == second.PlayerID() {
+		t.Fatalf("both clients seated as %s", first.PlayerID())
+	}
+	events := second.Events()
+
+	mover, waiter := first, second
+	if first.State().CurrentPlayer != first.PlayerID() {
+		mover, waiter = second, first
+	}
+	if err := mover.WaitTurn(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fortifying changes only the unit, which the state shows at once
+	var unit api.UnitDTO
+	for _, p := range mover.State().Players {
+		for _, u := range p.Units {
+			if p.ID == mover.PlayerID() && u.Type == "Warrior" {
+				unit = u
+			}
+		}
+	}
+	event, err := mover.Do(ctx, &game.FortifyAction{UnitID: unit.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "fortify" || event.PlayerID != mover.PlayerID() {
+		t.Errorf("fortifying was recorded as %s by %s", event.Type, event.PlayerID)
+	}
+	if fortified := findUnit(mover.State(), unit.ID); fortified == nil || !fortified.IsFortified {
+		t.Errorf("unit %s not fortified in the state: %+v", unit.ID, fortified)
+	}
+	if n := mover.Resyncs(); n != 0 {
+		t.Errorf("client in sync asked for the game afresh %d times", n)
+	}
+
+	// A client whose units stray from the game's is sent it afresh once an
+	// event's hash gives it away
+	var other api.UnitDTO
+	mover.mu.Lock()
+	mover.editPlayer(mover.playerID, func(p *api.PlayerDTO) {
+		p.Units = slices.Clone(p.Units)
+		for i := range p.Units {
+			if p.Units[i].ID != unit.ID {
+				other = p.Units[i]
+				p.Units[i].X++
+			}
+		}
+	})
+	mover.mu.Unlock()
+	if _, err := mover.Do(ctx, &game.WakeAction{UnitID: unit.ID}); err != nil {
+		t.Fatal(err)
+	}
+	err = mover.wait(ctx, func() bool {
+		resynced := findUnit(mover.state, other.ID)
+		return mover.resyncs == 1 && !mover.resyncing && resynced != nil && resynced.X == other.X
+	})
+	if err != nil {
+		t.Fatalf("client out of sync was not sent the game afresh: %v", err)
+	}
+
+	var serverErr *ServerError
+	if _, err := mover.Do(ctx, &game.FortifyAction{UnitID: "nobody"}); !errors.As(err, &serverErr) || serverErr.Code == "" {
+		t.Errorf("fortifying an unknown unit returned %v, want a server error with a code", err)
+	}
+
+	var status game.TurnStatus
+	if err := mover.Query(ctx, "turn_status", nil, &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.PlayerID != mover.PlayerID() {
+		t.Errorf("turn status of %s, want %s", status.PlayerID, mover.PlayerID())
+	}
+
+	if _, err := mover.Do(ctx, &game.EndTurnAction{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := waiter.WaitTurn(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second client was told of the fortifying as it happened
+	for e := range events {
+		if applied, ok := e.(ActionApplied); ok && applied.Type == "fortify" {
+			return
+		}
+	}
+	t.Error("second client was not told of the fortifying")
+}
+
+// findUnit returns a unit in a game state, or nil
+func findUnit(state *api.GameStateMessage, id string) *api.UnitDTO {
+	for _, p := range state.Players {
+		for i := range p.Units {
+			if p.Units[i].ID == id {
+				return &p.Units[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"encoding/json"
+	"fmt"
+)
+
+// Event is a message from the server: a Welcome, GameState, ActionApplied,
+// UnitMoved, UnitsChanged, CityFounded, Update, CombatResult, TurnChange,
+// TurnSummary, Advice, TurnStatus, HostState, QueryResult, ServerError or Message
+type Event interface {
+	event()
+}
+
+// Welcome tells the client which player it plays
+type Welcome struct{ api.WelcomeMessage }
+
+// GameState is the whole game as the client's player may see it
+type GameState struct{ *api.GameStateMessage }
+
+// ActionApplied is an action a player took, as recorded in the game's log:
+// all of it for the client's own player's actions, and for the others only
+// its sequence number, turn, type and hash. One that changed nothing but
+// units comes with the hash of the client's player's units after it.
+type ActionApplied struct {
+	game.Event
+	UnitHashes map[string]string
+}
+
+// UnitMoved is a unit that moved, as it is now
+type UnitMoved struct{ api.UnitMovedDTO }
+
+// UnitsChanged lists the units an action changed when they are all it
+// changed
+type UnitsChanged struct {
+	Units []api.UnitDTO
+}
+
+// CityFounded is a city that was founded
+type CityFounded struct{ api.CityDTO }
+
+// Update is an update the client does not decode. Decode decodes its
+// entity.
+type Update struct {
+	Type   string
+	Entity json.RawMessage
+}
+
+// CombatResult is a battle that was fought
+type CombatResult struct{ api.CombatResultMessage }
+
+// TurnChange tells of the next player to move once the AI players have
+// moved
+type TurnChange struct{ api.TurnChangeMessage }
+
+// TurnSummary is what happened to the client's player since their last
+// turn
+type TurnSummary struct{ api.TurnSummaryMessage }
+
+// Advice is the advisor's hints for the client's player as their turn
+// begins
+type Advice struct{ api.AdviceMessage }
+
+// TurnStatus lists the client's units and cities still waiting for orders
+type TurnStatus struct{ game.TurnStatus }
+
+// HostState tells who hosts the game and what the host has set
+type HostState struct{ api.HostStateMessage }
+
+// QueryResult answers a query
+type QueryResult struct {
+	QueryType string
+	RequestID string
+	Result    json.RawMessage
+}
+
+// ServerError is an error the server sent, such as an action being
+// refused
+type ServerError struct{ api.ErrorMessage }
+
+// Message is a message of a type the client does not decode
+type Message struct{ api.WSMessage }
+
+func (Welcome) event()       {}
+func (GameState) event()     {}
+func (ActionApplied) event() {}
+func (UnitMoved) event()     {}
+func (UnitsChanged) event()  {}
+func (CityFounded) event()   {}
+func (Update) event()        {}
+func (CombatResult) event()  {}
+func (TurnChange) event()    {}
+func (TurnSummary) event()   {}
+func (Advice) event()        {}
+func (TurnStatus) event()    {}
+func (HostState) event()     {}
+func (QueryResult) event()   {}
+func (*ServerError) event()  {}
+func (Message) event()       {}
+
+// Decode decodes the entity of the update into v
+func (u Update) Decode(v any) error {
+	return json.Unmarshal(u.Entity, v)
+}
+
+// Error returns the server's message, or the code if there is none
+func (e *ServerError) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+	return e.Message
+}
+
+// decode decodes a message from the server
+func decode(data []byte) (Event, error) {
+	var msg api.WSMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+
+	var e Event
+	var err error
+	switch msg.Type {
+	case api.MsgTypeWelcome:
+		var welcome Welcome
+		err = json.Unmarshal(msg.Payload, &welcome.WelcomeMessage)
+		e = welcome
+	case api.MsgTypeGameState:
+		state := GameState{new(api.GameStateMessage)}
+		err = json.Unmarshal(msg.Payload, state.GameStateMessage)
+		e = state
+	case api.MsgTypeEvent:
+		var applied api.EventMessage
+		err = json.Unmarshal(msg.Payload, &applied)
+		e = ActionApplied{Event: applied.Event, UnitHashes: applied.UnitHashes}
+	case api.MsgTypeUpdate:
+		return decodeUpdate(msg.Payload)
+	case api.MsgTypeCombatResult:
+		var combat CombatResult
+		err = json.Unmarshal(msg.Payload, &combat.CombatResultMessage)
+		e = combat
+	case api.MsgTypeTurnChange:
+		var change TurnChange
+		err = json.Unmarshal(msg.Payload, &change.TurnChangeMessage)
+		e = change
+	case api.MsgTypeTurnSummary:
+		var summary TurnSummary
+		err = json.Unmarshal(msg.Payload, &summary.TurnSummaryMessage)
+		e = summary
+	case api.MsgTypeAdvice:
+		var advice Advice
+		err = json.Unmarshal(msg.Payload, &advice.AdviceMessage)
+		e = advice
+	case api.MsgTypeTurnStatus:
+		var status TurnStatus
+		err = json.Unmarshal(msg.Payload, &status.TurnStatus)
+		e = status
+	case api.MsgTypeHostState:
+		var host HostState
+		err = json.Unmarshal(msg.Payload, &host.HostStateMessage)
+		e = host
+	case api.MsgTypeQueryResult:
+		var result struct {
+			QueryType string          `json:"query_type"`
+			RequestID string          `json:"request_id"`
+			Result    json.RawMessage `json:"result"`
+		}
+		err = json.Unmarshal(msg.Payload, &result)
+		e = QueryResult{QueryType: result.QueryType, RequestID: result.RequestID, Result: result.Result}
+	case api.MsgTypeError:
+		serverErr := new(ServerError)
+		err = json.Unmarshal(msg.Payload, &serverErr.ErrorMessage)
+		e = serverErr
+	default:
+		e = Message{msg}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s message: %w", msg.Type, err)
+	}
+	return e, nil
+}
+
+// decodeUpdate decodes the payload of an update message
+func decodeUpdate(payload json.RawMessage) (Event, error) {
+	var update struct {
+		UpdateType string          `json:"update_type"`
+		Entity     json.RawMessage `json:"entity"`
+	}
+	if err := json.Unmarshal(payload, &update); err != nil {
+		return nil, fmt.Errorf("decoding update: %w", err)
+	}
+
+	var e Event
+	var err error
+	switch update.UpdateType {
+	case api.UpdateUnitMoved:
+		var moved UnitMoved
+		err = json.Unmarshal(update.Entity, &moved.UnitMovedDTO)
+		e = moved
+	case api.UpdateUnitsChanged:
+		var changed UnitsChanged
+		err = json.Unmarshal(update.Entity, &changed.Units)
+		e = changed
+	case api.UpdateCityFounded:
+		var founded CityFounded
+		err = json.Unmarshal(update.Entity, &founded.CityDTO)
+		e = founded
+	default:
+		e = Update{Type: update.UpdateType, Entity: update.Entity}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s update: %w", update.UpdateType, err)
+	}
+	return e, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"bytes"
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewGame starts a new game on the server at serverURL, replacing the one
+// in progress, and returns it
+func NewGame(ctx context.Context, serverURL string, config game.GameConfig) (*api.GameStateMessage, error) {
+	body, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var state api.GameStateMessage
+	if err := call(ctx, http.MethodPost, serverURL+"/api/game/new", "", bytes.NewReader(body), &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetGame returns the game in progress on the server at serverURL
+func GetGame(ctx context.Context, serverURL string) (*api.GameStateMessage, error) {
+	var state api.GameStateMessage
+	if err := call(ctx, http.MethodGet, serverURL+"/api/game", "", nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Inspect takes the admin API's closer look at a game on the server at
+// serverURL, with the server's admin token
+func Inspect(ctx context.Context, serverURL, token, gameID string) (*api.AdminInspectDTO, error) {
+	var dto api.AdminInspectDTO
+	target := serverURL + "/api/admin/games/inspect?id=" + url.QueryEscape(gameID)
+	if err := call(ctx, http.MethodGet, target, token, nil, &dto); err != nil {
+		return nil, err
+	}
+	return &dto, nil
+}
+
+// call sends a request to the server's HTTP API, with the admin token if
+// one is given, and decodes the answer into result
+func call(ctx context.Context, method, target, token string, body io.Reader, result any) error {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, target, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"civilization/internal/api"
+	"civilization/internal/game"
+	"slices"
+)
+
+// The client keeps the game as its player sees it, from the game states
+// the server sends and the updates in between: units moving and changing,
+// cities being founded and tiles being explored. Every change makes a new
+// state and leaves the old one be, so a state returned by State may be
+// read while the client carries on.
+//
+// An action changing more than units is followed by a new game state; one
+// changing only units is told of by updates sent before its event. The
+// state is current once it has caught up with the last event the client
+// was sent.
+//
+// Events changing only units come with the hash of each player's units.
+// A client finding its own hash differently once the event's updates are
+// in asks the server for the game afresh, and goes by the game state it is
+// sent.
+
+// State returns the game as the client last heard of it. It must not be
+// modified. On maps sent in chunks the tiles are left out; the map_chunk
+// query fetches them.
+func (c *Client) State() *api.GameStateMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.state
+}
+
+// PlayerID returns the player the client plays
+func (c *Client) PlayerID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.playerID
+}
+
+// current reports whether the state has caught up with the last event.
+// Callers must hold c.mu.
+func (c *Client) current() bool {
+	return c.state != nil && c.state.Seq >= c.seq
+}
+
+// myTurn reports whether the client's player may act. Callers must hold
+// c.mu.
+func (c *Client) myTurn() bool {
+	switch c.state.Phase {
+	case game.PhaseSimultaneous.String():
+		return !slices.Contains(c.state.Submitted, c.playerID)
+	case game.PhasePlayerTurn.String():
+		return c.state.CurrentPlayer == c.playerID
+	}
+	return false
+}
+
+// track updates the state with an event. Callers must hold c.mu.
+func (c *Client) track(e Event) {
+	switch e := e.(type) {
+	case Welcome:
+		c.playerID = e.PlayerID
+	case GameState:
+		c.state = e.GameStateMessage
+		c.resyncing = false
+	case ActionApplied:
+		c.seq = max(c.seq, e.Seq)
+		// Only events that changed nothing but units, which no game state
+		// follows, come with unit hashes
+		if c.state != nil && e.UnitHashes != nil {
+			// Only a state that was current before the event holds what
+			// its hash was taken of
+			caughtUp := c.state.Seq+1 >= e.Seq
+			state := *c.state
+			state.Seq = max(state.Seq, e.Seq)
+			c.state = &state
+			if caughtUp {
+				c.verify(e)
+			}
+		}
+	case UnitMoved:
+		c.putUnit(e.Unit)
+	case UnitsChanged:
+		for _, unit := range e.Units {
+			c.putUnit(unit)
+		}
+	case CityFounded:
+		c.putCity(e.CityDTO)
+	case Update:
+		var tiles []int
+		if e.Type != api.UpdateReveal || e.Decode(&tiles) != nil {
+			return
+		}
+		c.editPlayer(c.playerID, func(p *api.PlayerDTO) {
+			p.Explored = explore(p.Explored, tiles)
+		})
+	case TurnChange:
+		if c.state != nil {
+			state := *c.state
+			state.Turn, state.CurrentPlayer, state.Phase = e.Turn, e.CurrentPlayer, e.Phase
+			c.state = &state
+		}
+	default:
+		return
+	}
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// verify checks the client's units against the hash an event was sent
+// with, and asks the server for the game afresh if they differ. Callers
+// must hold c.mu.
+func (c *Client) verify(e ActionApplied) {
+	want, ok := e.UnitHashes[c.playerID]
+	if !ok || c.resyncing {
+		return
+	}
+	var units []api.UnitDTO
+	for _, p := range c.state.Players {
+		if p.ID == c.playerID {
+			units = p.Units
+		}
+	}
+	hash := api.UnitsHash(units)
+	if hash == want {
+		return
+	}
+
+	c.resyncing = true
+	c.resyncs++
+	go c.Send(api.MsgTypeResync, api.ResyncMessage{Seq: e.Seq, UnitsHash: hash, Units: units})
+}
+
+// Resyncs returns how many times the client found itself out of sync with
+// the game and asked for it afresh
+func (c *Client) Resyncs() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.resyncs
+}
+
+// explore returns a copy of an explored bitset with tiles set
+func explore(explored []byte, tiles []int) []byte {
+	explored = slices.Clone(explored)
+	for _, i := range tiles {
+		if i < 0 {
+			continue
+		}
+		for i/8 >= len(explored) {
+			explored = append(explored, 0)
+		}
+		explored[i/8] |= 1 << (i % 8)
+	}
+	return explored
+}
+
+// putUnit replaces a unit in the state with how it is now. Callers must
+// hold c.mu.
+func (c *Client) putUnit(unit api.UnitDTO) {
+	c.editPlayer(unit.OwnerID, func(p *api.PlayerDTO) {
+		p.Units = slices.Clone(p.Units)
+		i := slices.IndexFunc(p.Units, func(u api.UnitDTO) bool { return u.ID == unit.ID })
+		if i < 0 {
+			p.Units = append(p.Units, unit)
+		} else {
+			p.Units[i] = unit
+		}
+	})
+}
+
+// putCity adds a city to the state, or replaces it. Callers must hold
+// c.mu.
+func (c *Client) putCity(city api.CityDTO) {
+	c.editPlayer(city.OwnerID, func(p *api.PlayerDTO) {
+		p.Cities = slices.Clone(p.Cities)
+		i := slices.IndexFunc(p.Cities, func(c api.CityDTO) bool { return c.ID == city.ID })
+		if i < 0 {
+			p.Cities = append(p.Cities, city)
+		} else {
+			p.Cities[i] = city
+		}
+	})
+}
+
+// editPlayer makes a new state with a player changed by edit. Callers
+// must hold c.mu.
+func (c *Client) editPlayer(playerID string, edit func(p *api.PlayerDTO)) {
+	if c.state == nil {
+		return
+	}
+	i := slices.IndexFunc(c.state.Players, func(p api.PlayerDTO) bool { return p.ID == playerID })
+	if i < 0 {
+		return
+	}
+	state := *c.state
+	state.Players = slices.Clone(state.Players)
+	edit(&state.Players[i])
+	c.state = &state
+}
@@ -0,0 +1,10 @@
+// Package web is the browser client, built into the server so that it
+// runs from a single binary.
+package web
+
+import "embed"
+
+// Files are the client's pages, styles, scripts and images
+//
+//go:embed index.html css js assets
+var Files embed.FS
@@ -7,9 +7,14 @@ const Config = {
     MAP_SIZES: {
         small: { width: 60, height: 40 },
         medium: { width: 80, height: 50 },
-        large: { width: 100, height: 60 }
+        large: { width: 100, height: 60 },
+        huge: { width: 400, height: 250 }
     },
 
+    // Widest and tallest viewport of a large map the server keeps us
+    // up to date on
+    MAX_VIEWPORT: 128,
+
     // Terrain colors (Classic Civ 1 style)
     TERRAIN_COLORS: {
         'Ocean': '#0040a0',
@@ -43,6 +48,25 @@ const Config = {
         CATAPULT: 5
     },
 
+    // Unit mode indices (matching server)
+    UNIT_MODES: {
+        NONE: 0,
+        SENTRY: 1,
+        EXPLORE: 2,
+        WORK: 3,
+        PATROL: 4,
+        TERRAFORM: 5
+    },
+
+    // Resources a mine can be dug on off the hills (matching server)
+    MINEABLE_RESOURCES: ['oil', 'coal', 'iron', 'uranium'],
+
+    // Tiles a siege unit can bombard across (matching server)
+    BOMBARD_RANGE: 2,
+
+    // How near to each other cities may be founded (matching server)
+    MIN_CITY_DISTANCE: 2,
+
     // Building type indices (matching server)
     BUILDING_TYPES: {
         NONE: 0,
@@ -61,14 +85,20 @@ const Config = {
             { type: 2, name: 'Phalanx', cost: 20 },
             { type: 3, name: 'Archer', cost: 20 },
             { type: 4, name: 'Horseman', cost: 20 },
-            { type: 5, name: 'Catapult', cost: 40 }
+            { type: 5, name: 'Catapult', cost: 40 },
+            { type: 6, name: 'Nuclear', cost: 160, requires: 'Manhattan Project' },
+            { type: 7, name: 'Scout', cost: 20 },
+            { type: 8, name: 'Caravan', cost: 50 }
         ],
         buildings: [
             { type: 1, name: 'Barracks', cost: 40 },
             { type: 2, name: 'Granary', cost: 60 },
             { type: 3, name: 'Walls', cost: 80 },
             { type: 4, name: 'Marketplace', cost: 80 },
-            { type: 5, name: 'Library', cost: 80 }
+            { type: 5, name: 'Library', cost: 80 },
+            { type: 6, name: 'Manhattan Project', cost: 300, wonder: true },
+            { type: 7, name: 'Harbor', cost: 60, coastal: true },
+            { type: 8, name: 'Palace', cost: 100 }
         ]
     },
 
@@ -87,6 +117,16 @@ const Config = {
         SAVE_GAME: '/api/game/save',
         LOAD_GAME: '/api/game/load',
         LIST_SAVES: '/api/game/saves',
+        EXPORT_GAME: '/api/game/export',
+        IMPORT_GAME: '/api/game/import',
+        TURNS: '/api/game/turns',
+        LOAD_TURN: '/api/game/turns/load',
+        STATS: '/api/game/stats',
+        GAME_LOG: '/api/game/log',
+        MAP_IMAGE: '/api/game/map.png',
+        RULES: '/api/rules',
+        SCENARIOS: '/api/scenarios',
+        LOCALES: '/api/locales',
         WEBSOCKET: `ws://${window.location.host}/ws`
     }
 };
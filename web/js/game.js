@@ -10,16 +10,58 @@ class GameState {
         this.myPlayerId = null;
         this.winner = null;
 
+        // Players who have submitted their orders in the simultaneous phase
+        this.submitted = [];
+
+        // Deals offered and not yet answered
+        this.offers = [];
+        this.happiness = null; // Our latest happiness, from a query
+
+        // Host settings, as sent by the server
+        this.hostId = null;
+        this.paused = false;
+        this.turnTimeout = 0;
+        this.inactivityPolicy = '';
+        this.kicked = [];
+
+        // Our preferences, as kept by the server for our profile
+        this.preferences = { auto_end_turn: false, combat_animations: true, muted_notices: [] };
+
         // Selection state
         this.selectedUnit = null;
         this.selectedCity = null;
 
         // Input mode
-        this.mode = 'normal'; // 'normal', 'move', 'attack'
+        this.mode = 'normal'; // 'normal', 'move', 'attack', 'bombard', 'nuke', 'patrol', 'city_strike'
+
+        // Route being picked in patrol mode
+        this.patrolWaypoints = [];
+
+        // Odds of attacking the hovered tile, as returned by the server
+        this.combatOdds = null;
+
+        // Tiles the selected unit can move to or attack, as returned by the server
+        this.reachable = null;
+
+        // Units and cities still waiting for orders, as tracked by the server
+        this.turnStatus = null;
+
+        // Bumped whenever tiles change, so the minimap knows to redraw them
+        this.mapVersion = 0;
+
+        // Rectangle of a streamed map we are subscribed to, and the coarse
+        // minimap the server sends with it
+        this.viewport = null;
+        this.coarseMinimap = null;
     }
 
     // Update state from server
     updateFromServer(data) {
+        // Orders planned last turn no longer apply
+        if (data.turn !== this.turn) {
+            this.turnStatus = null;
+        }
+
         this.id = data.id;
         this.turn = data.turn;
         this.currentPlayerId = data.current_player;
@@ -27,12 +69,18 @@ class GameState {
         this.map = this.processMap(data.map);
         this.players = data.players;
         this.winner = data.winner;
-
-        // Find my player (the human player)
-        const humanPlayer = this.players.find(p => p.is_human);
-        if (humanPlayer) {
-            this.myPlayerId = humanPlayer.id;
+        this.submitted = data.submitted || [];
+        this.offers = data.offers || [];
+
+        // Play the seat the server gave us, or else the first human player
+        if (!this.getMyPlayer()) {
+            const humanPlayer = this.players.find(p => p.is_human);
+            if (humanPlayer) {
+                this.myPlayerId = humanPlayer.id;
+            }
         }
+        this.markExplored();
+        this.applyPlannedOrders();
 
         // Clear selection if unit no longer exists
         if (this.selectedUnit) {
@@ -49,7 +97,7 @@ class GameState {
             const myPlayer = this.getMyPlayer();
             if (myPlayer && myPlayer.units.length > 0) {
                 // Prefer units that can still move
-                const activeUnit = myPlayer.units.find(u => u.movement_left > 0 && !u.is_fortified);
+                const activeUnit = myPlayer.units.find(u => this.needsOrders(u));
                 if (activeUnit) {
                     this.selectedUnit = activeUnit;
                 } else {
@@ -60,15 +108,147 @@ class GameState {
         }
     }
 
+    // Give tiles that changed hands their new owners
+    applyBorderChanges(changes) {
+        for (const change of changes) {
+            const tile = this.getTile(change.x, change.y);
+            if (tile) {
+                tile.owner = change.owner || undefined;
+            }
+        }
+        this.mapVersion++;
+    }
+
+    // Mark the tiles we have explored, from the bitset the server sends,
+    // in the whole map or in the given rectangle of it
+    markExplored(x0 = 0, y0 = 0, width = Infinity, height = Infinity) {
+        const myPlayer = this.getMyPlayer();
+        if (!this.map || !myPlayer || !myPlayer.explored) return;
+
+        const bits = this.exploredBits(myPlayer);
+        const endX = Math.min(this.map.width, x0 + width);
+        const endY = Math.min(this.map.height, y0 + height);
+        for (let y = y0; y < endY; y++) {
+            for (let x = x0; x < endX; x++) {
+                const i = y * this.map.width + x;
+                const tile = this.map.tiles[y][x];
+                if (tile && bits[i >> 3] & (1 << (i & 7))) {
+                    tile.explored = true;
+                }
+            }
+        }
+    }
+
+    // Decode a player's explored bitset, once per game state. Reveals are
+    // added to it, so chunks fetched later show them too.
+    exploredBits(player) {
+        if (!player.exploredBits) {
+            const bits = atob(player.explored || '');
+            player.exploredBits = new Uint8Array(Math.ceil(this.map.width * this.map.height / 8));
+            for (let i = 0; i < bits.length && i < player.exploredBits.length; i++) {
+                player.exploredBits[i] = bits.charCodeAt(i);
+            }
+        }
+        return player.exploredBits;
+    }
+
+    // Mark tiles newly explored by us or shown to us by a pact, given as
+    // indexes into the map's tiles
+    applyReveal(indexes) {
+        if (!this.map) return;
+        const myPlayer = this.getMyPlayer();
+        const bits = myPlayer ? this.exploredBits(myPlayer) : null;
+        this.mapVersion++;
+        for (const i of indexes) {
+            if (bits) {
+                bits[i >> 3] |= 1 << (i & 7);
+            }
+            const tile = this.getTile(i % this.map.width, Math.floor(i / this.map.width));
+            if (tile) {
+                tile.explored = true;
+            }
+        }
+    }
+
+    // Make a unit that won a battle a veteran
+    applyPromotion(promotion) {
+        const unit = this.getUnit(promotion.unit_id);
+        if (unit) {
+            unit.is_veteran = true;
+            unit.veteran_origin = 'combat';
+        }
+    }
+
+    // Move a unit to where the server says it went. A move sends no new
+    // game state when it changed nothing else.
+    applyUnitMoved(moved) {
+        this.applyUnit(moved.unit);
+    }
+
+    // Take on the units an action changed. An action that changed nothing
+    // but units sends no new game state.
+    applyUnitsChanged(units) {
+        for (const unit of units) {
+            this.applyUnit(unit);
+        }
+    }
+
+    // Replace a unit with the server's copy of it, adding it if new
+    applyUnit(changed) {
+        const unit = this.getUnit(changed.id);
+        if (unit) {
+            // The server leaves out the fields that are unset
+            for (const key of ['patrol', 'patrol_index', 'group_id', 'cooldown']) {
+                if (!(key in changed)) {
+                    delete unit[key];
+                }
+            }
+            Object.assign(unit, changed);
+            return;
+        }
+        const owner = this.players.find(p => p.id === changed.owner_id);
+        if (owner) {
+            owner.units.push(changed);
+        }
+    }
+
+    // Add a city founded since the last game state
+    applyCityFounded(city) {
+        const owner = this.players.find(p => p.id === city.owner_id);
+        if (owner && !this.getCity(city.id)) {
+            owner.cities.push(city);
+        }
+    }
+
     // Process map data into a 2D array for faster access
     processMap(mapData) {
         if (!mapData) return null;
-
-        const tiles = new Array(mapData.height);
-        for (let y = 0; y < mapData.height; y++) {
-            tiles[y] = new Array(mapData.width);
+        this.mapVersion++;
+
+        // Large maps come without their tiles, which are fetched chunk by
+        // chunk as they come into view, and again after every game state
+        // unless we are subscribed to a viewport, whose changed tiles the
+        // server sends. Until a chunk is fetched again the tiles we had of
+        // it are kept, so the view does not flicker.
+        const old = this.map;
+        if (mapData.chunk_size) {
+            const same = old && old.gameId === this.id && old.chunkSize === mapData.chunk_size;
+            if (!same) {
+                this.coarseMinimap = null;
+            }
+            return {
+                gameId: this.id,
+                width: mapData.width,
+                height: mapData.height,
+                tiles: same ? old.tiles : this.emptyTiles(mapData.width, mapData.height),
+                rivers: mapData.rivers || [],
+                chunkSize: mapData.chunk_size,
+                chunks: same && this.viewport ? old.chunks : new Map() // Chunk "x,y" to 'loading' or 'loaded'
+            };
         }
 
+        const tiles = this.emptyTiles(mapData.width, mapData.height);
+
         // Count tiles with rivers for debugging
         let riverTileCount = 0;
         for (const tile of mapData.tiles) {
@@ -88,11 +268,110 @@ class GameState {
         };
     }
 
+    emptyTiles(width, height) {
+        const tiles = new Array(height);
+        for (let y = 0; y < height; y++) {
+            tiles[y] = new Array(width);
+        }
+        return tiles;
+    }
+
+    // Chunks of a streamed map in the given tile range not yet fetched
+    // since the last game state. They are marked as loading, so each is
+    // asked for once.
+    chunksToLoad(range) {
+        const needed = [];
+        if (!this.map || !this.map.chunkSize) return needed;
+
+        const size = this.map.chunkSize;
+        for (let cy = Math.floor(range.startY / size); cy * size < range.endY; cy++) {
+            for (let cx = Math.floor(range.startX / size); cx * size < range.endX; cx++) {
+                const key = cx + ',' + cy;
+                const status = this.map.chunks.get(key);
+                if (status !== 'loaded' && status !== 'loading') {
+                    this.map.chunks.set(key, 'loading');
+                    needed.push({ x: cx, y: cy });
+                }
+            }
+        }
+        return needed;
+    }
+
+    // Mark the chunks of a streamed map inside a rectangle
+    markChunks(rect, status) {
+        const size = this.map.chunkSize;
+        for (let cy = Math.floor(rect.y / size); cy * size < rect.y + rect.height; cy++) {
+            for (let cx = Math.floor(rect.x / size); cx * size < rect.x + rect.width; cx++) {
+                this.map.chunks.set(cx + ',' + cy, status);
+            }
+        }
+    }
+
+    // Chunk-aligned rectangle around the given tile range to subscribe to,
+    // or null if it is the one we are subscribed to already
+    viewportFor(range) {
+        if (!this.map || !this.map.chunkSize) return null;
+
+        const size = this.map.chunkSize;
+        const x = Math.floor(range.startX / size) * size;
+        const y = Math.floor(range.startY / size) * size;
+        const rect = {
+            x: x,
+            y: y,
+            width: Math.min(Math.ceil(range.endX / size) * size, this.map.width) - x,
+            height: Math.min(Math.ceil(range.endY / size) * size, this.map.height) - y
+        };
+        const v = this.viewport;
+        if (v && v.x === rect.x && v.y === rect.y && v.width === rect.width && v.height === rect.height) {
+            return null;
+        }
+        return rect;
+    }
+
+    // Fill in the tiles of our viewport that changed, as the server sends
+    // them after subscribing and after each game state
+    applyViewportTiles(update) {
+        if (!this.map || !this.map.chunkSize) return;
+
+        for (const tile of update.tiles || []) {
+            this.map.tiles[tile.y][tile.x] = tile;
+        }
+        const rect = update.viewport;
+        this.markChunks(rect, 'loaded');
+        this.markExplored(rect.x, rect.y, rect.width, rect.height);
+        if (update.minimap) {
+            this.coarseMinimap = update.minimap;
+        }
+        this.mapVersion++;
+    }
+
+    // Replace tiles sent anew, such as those of newly revealed resources
+    applyTiles(tiles) {
+        if (!this.map) return;
+
+        for (const tile of tiles) {
+            this.map.tiles[tile.y][tile.x] = tile;
+        }
+        this.mapVersion++;
+    }
+
+    // Fill in the tiles of a chunk of a streamed map
+    applyMapChunk(chunk) {
+        if (!this.map || !this.map.chunkSize) return;
+
+        for (const tile of chunk.tiles) {
+            this.map.tiles[tile.y][tile.x] = tile;
+        }
+        this.map.chunks.set(chunk.chunk_x + ',' + chunk.chunk_y, 'loaded');
+        this.markExplored(chunk.x, chunk.y, chunk.width, chunk.height);
+        this.mapVersion++;
+    }
+
     // Get tile at coordinates
     getTile(x, y) {
         if (!this.map) return null;
         if (x < 0 || x >= this.map.width || y < 0 || y >= this.map.height) return null;
-        return this.map.tiles[y][x];
+        return this.map.tiles[y][x] || null;
     }
 
     // Get my player
@@ -100,11 +379,71 @@ class GameState {
         return this.players.find(p => p.id === this.myPlayerId);
     }
 
-    // Check if it's my turn
+    // Check if it's my turn. In the simultaneous phase every human plays
+    // until they submit their orders.
     isMyTurn() {
+        if (this.paused) {
+            return false;
+        }
+        if (this.isSimultaneous()) {
+            return !this.submitted.includes(this.myPlayerId);
+        }
         return this.currentPlayerId === this.myPlayerId && this.phase === 'player_turn';
     }
 
+    // Check if we host the game
+    isHost() {
+        return this.hostId !== null && this.hostId === this.myPlayerId;
+    }
+
+    // Update host settings from the server
+    updateHostState(data) {
+        this.hostId = data.host;
+        this.paused = data.paused;
+        this.turnTimeout = data.turn_timeout;
+        this.inactivityPolicy = data.inactivity_policy || '';
+        this.kicked = data.kicked || [];
+    }
+
+    // Check if the human players are planning their moves together
+    isSimultaneous() {
+        return this.phase === 'simultaneous';
+    }
+
+    // Show my units where their planned orders will leave them. A unit with
+    // orders keeps where it stands now in planned_from, so that the orders
+    // can be drawn and undone when they change.
+    applyPlannedOrders() {
+        const myPlayer = this.getMyPlayer();
+        if (!myPlayer) return;
+
+        const status = this.turnStatus;
+        const orders = (this.isSimultaneous() && status && status.player_id === this.myPlayerId && status.orders) || [];
+
+        for (const unit of myPlayer.units) {
+            if (unit.planned_from) {
+                unit.x = unit.planned_from.x;
+                unit.y = unit.planned_from.y;
+                unit.movement_left = unit.planned_from.movement_left;
+                delete unit.planned_from;
+                delete unit.planned_orders;
+            }
+
+            const unitOrders = orders.filter(o => o.unit_id === unit.id);
+            if (unitOrders.length === 0) continue;
+
+            unit.planned_from = { x: unit.x, y: unit.y, movement_left: unit.movement_left };
+            unit.planned_orders = unitOrders;
+            unitOrders.forEach(o => {
+                if (o.kind === 'move') {
+                    unit.x = o.x;
+                    unit.y = o.y;
+                }
+            });
+            unit.movement_left = unitOrders[unitOrders.length - 1].moves_left;
+        }
+    }
+
     // Get unit by ID
     getUnit(unitId) {
         for (const player of this.players) {
@@ -162,6 +501,12 @@ class GameState {
     }
 
     // Get enemy units at a position
+    // Whether any city in the world has the wonder
+    wonderBuilt(name) {
+        return this.players.some(player =>
+            player.cities.some(city => city.buildings && city.buildings.includes(name)));
+    }
+
     getEnemyUnitsAt(x, y) {
         const units = [];
         for (const player of this.players) {
@@ -211,6 +556,42 @@ class GameState {
     // Set input mode
     setMode(mode) {
         this.mode = mode;
+        if (mode !== 'patrol') {
+            this.patrolWaypoints = [];
+        }
+    }
+
+    // Start picking a patrol route from the selected unit's position
+    startPatrol() {
+        if (!this.selectedUnit) return;
+        this.setMode('patrol');
+        this.patrolWaypoints = [{ x: this.selectedUnit.x, y: this.selectedUnit.y }];
+    }
+
+    // Add a waypoint to the patrol route being picked
+    addPatrolWaypoint(x, y) {
+        const last = this.patrolWaypoints[this.patrolWaypoints.length - 1];
+        if (last && last.x === x && last.y === y) return;
+        this.patrolWaypoints.push({ x: x, y: y });
+    }
+
+    // Get combat odds for attacking a tile with the selected unit, if known
+    getCombatOdds(x, y) {
+        const odds = this.combatOdds;
+        if (!odds || !this.selectedUnit) return null;
+        if (odds.attacker_id !== this.selectedUnit.id) return null;
+        if (odds.target_x !== x || odds.target_y !== y) return null;
+        return odds;
+    }
+
+    // Get the tiles the selected unit can move to or attack this turn, if
+    // known for where it stands
+    getReachable() {
+        const reach = this.reachable;
+        const unit = this.selectedUnit;
+        if (!reach || !unit || reach.unit_id !== unit.id) return null;
+        if (reach.x !== unit.x || reach.y !== unit.y) return null;
+        return reach.tiles;
     }
 
     // Check if a tile is adjacent to selected unit
@@ -222,22 +603,71 @@ class GameState {
     }
 
     // Check if unit can move
+    // Fortified units can still move, moving gives up the fortified position
     canUnitMove(unit) {
-        return unit && unit.movement_left > 0 && !unit.is_fortified;
+        return unit && unit.movement_left > 0;
+    }
+
+    // Check if unit is fortified or following a standing order
+    isAsleep(unit) {
+        return unit.is_fortified || (unit.mode && unit.mode !== 'none');
+    }
+
+    // Check if unit is waiting for orders (can move and is not asleep)
+    needsOrders(unit) {
+        return this.canUnitMove(unit) && !this.isAsleep(unit);
+    }
+
+    // Check if any enemy unit is next to a position
+    hasAdjacentEnemy(x, y) {
+        for (let dy = -1; dy <= 1; dy++) {
+            for (let dx = -1; dx <= 1; dx++) {
+                if ((dx !== 0 || dy !== 0) && this.getEnemyUnitsAt(x + dx, y + dy).length > 0) {
+                    return true;
+                }
+            }
+        }
+        return false;
+    }
+
+    // Get my units in the same group as a unit
+    getGroupMembers(unit) {
+        const myPlayer = this.getMyPlayer();
+        if (!myPlayer || !unit || !unit.group_id) return [];
+        return myPlayer.units.filter(u => u.group_id === unit.group_id);
     }
 
     // Check if there are units that can still act
     hasActiveUnits() {
         const myPlayer = this.getMyPlayer();
         if (!myPlayer) return false;
-        return myPlayer.units.some(u => u.movement_left > 0 && !u.is_fortified);
+        return myPlayer.units.some(u => this.needsOrders(u));
     }
 
     // Get count of active units
     getActiveUnitsCount() {
+        // Prefer the server's view of which units still have orders to give
+        if (this.turnStatus && this.turnStatus.player_id === this.myPlayerId) {
+            return this.turnStatus.idle_units.length;
+        }
         const myPlayer = this.getMyPlayer();
         if (!myPlayer) return 0;
-        return myPlayer.units.filter(u => u.movement_left > 0 && !u.is_fortified).length;
+        return myPlayer.units.filter(u => this.needsOrders(u)).length;
+    }
+
+    // Check if a job can be done on a tile (mirrors Tile.JobTurns)
+    canDoJob(tile, job) {
+        if (!tile) return false;
+        switch (job) {
+            case 'clear_forest':
+                return tile.terrain === 'Forest';
+            case 'plant_forest':
+                return tile.terrain === 'Grassland' || tile.terrain === 'Plains';
+            case 'mine':
+                return !tile.has_mine && tile.terrain !== 'Ocean' &&
+                    (tile.terrain === 'Hills' || Config.MINEABLE_RESOURCES.includes(tile.resource));
+        }
+        return false;
     }
 
     // Check if selected unit can found city
@@ -250,12 +680,27 @@ class GameState {
         if (!tile) return false;
         if (tile.terrain === 'Ocean' || tile.terrain === 'Mountains') return false;
 
-        // Check for existing city
-        const city = this.getCityAt(this.selectedUnit.x, this.selectedUnit.y);
-        if (city) return false;
+        // Check for a city there or next to it
+        const reach = Config.MIN_CITY_DISTANCE - 1;
+        for (let dy = -reach; dy <= reach; dy++) {
+            for (let dx = -reach; dx <= reach; dx++) {
+                if (this.getCityAt(this.selectedUnit.x + dx, this.selectedUnit.y + dy)) return false;
+            }
+        }
 
         return true;
     }
+
+    // Check if the selected unit is a caravan in a city of ours building a
+    // wonder
+    canHelpWonder() {
+        const unit = this.selectedUnit;
+        if (!unit || !unit.can_help_wonder) return false;
+
+        const city = this.getCityAt(unit.x, unit.y);
+        if (!city || city.owner_id !== this.myPlayerId || !city.current_build) return false;
+        return Config.PRODUCTION_OPTIONS.buildings.some(b => b.wonder && b.name === city.current_build.name);
+    }
 }
 
 // Global game state
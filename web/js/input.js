@@ -53,8 +53,11 @@ class InputHandler {
             const world = renderer.screenToWorld(screenX, screenY);
             if (world.x >= 0 && world.x < gameState.map.width &&
                 world.y >= 0 && world.y < gameState.map.height) {
-                this.hoverTileX = world.x;
-                this.hoverTileY = world.y;
+                if (world.x !== this.hoverTileX || world.y !== this.hoverTileY) {
+                    this.hoverTileX = world.x;
+                    this.hoverTileY = world.y;
+                    this.requestCombatOdds(world.x, world.y);
+                }
             } else {
                 this.hoverTileX = -1;
                 this.hoverTileY = -1;
@@ -109,12 +112,32 @@ class InputHandler {
             case 'attack':
                 this.handleAttackClick(world.x, world.y);
                 break;
+            case 'bombard':
+                this.handleBombardClick(world.x, world.y);
+                break;
+            case 'nuke':
+                this.handleNukeClick(world.x, world.y);
+                break;
+            case 'city_strike':
+                this.handleCityStrikeClick(world.x, world.y);
+                break;
+            case 'patrol':
+                gameState.addPatrolWaypoint(world.x, world.y);
+                break;
             default:
                 this.handleNormalClick(world.x, world.y);
         }
     }
 
     handleNormalClick(x, y) {
+        // Ask the server about the tile, and what the selected unit could
+        // do aimed at it
+        const tile = gameState.getTile(x, y);
+        if (tile && tile.explored) {
+            const unit = gameState.selectedUnit;
+            gameSocket.queryTileInfo(x, y, unit && unit.owner_id === gameState.myPlayerId ? unit.id : '');
+        }
+
         // Check for my units at this location
         const myUnits = gameState.getMyUnitsAt(x, y);
         if (myUnits.length > 0) {
@@ -150,15 +173,15 @@ class InputHandler {
             return;
         }
 
-        // Check terrain
-        const tile = gameState.getTile(x, y);
-        if (!tile || tile.terrain === 'Ocean' || tile.terrain === 'Mountains') {
+        // Check the server lets the unit move there, once it has said where
+        const reachable = gameState.getReachable();
+        if (reachable && !reachable.some(t => t.x === x && t.y === y && !t.attack)) {
             gameState.setMode('normal');
             return;
         }
 
         // Send move action
-        gameSocket.moveUnit(gameState.selectedUnit.id, x, y);
+        gameSocket.moveUnitOrGroup(gameState.selectedUnit, x, y);
         gameState.setMode('normal');
     }
 
@@ -189,6 +212,67 @@ class InputHandler {
         gameState.setMode('normal');
     }
 
+    handleBombardClick(x, y) {
+        const unit = gameState.selectedUnit;
+        if (!unit) {
+            gameState.setMode('normal');
+            return;
+        }
+
+        // Check range
+        const dx = Math.abs(x - unit.x);
+        const dy = Math.abs(y - unit.y);
+        if (dx > Config.BOMBARD_RANGE || dy > Config.BOMBARD_RANGE || (dx === 0 && dy === 0)) {
+            gameState.setMode('normal');
+            return;
+        }
+
+        // Check for enemies
+        const enemies = gameState.getEnemyUnitsAt(x, y);
+        const enemyCity = gameState.getCityAt(x, y);
+        if (enemies.length > 0 || (enemyCity && enemyCity.owner_id !== gameState.myPlayerId)) {
+            gameSocket.bombard(unit.id, x, y);
+        }
+        gameState.setMode('normal');
+    }
+
+    handleNukeClick(x, y) {
+        const unit = gameState.selectedUnit;
+        if (unit && unit.can_nuke &&
+            confirm(`Detonate a nuclear weapon at (${x},${y})? Every unit nearby will be destroyed, including your own.`)) {
+            gameSocket.nuke(unit.id, x, y);
+        }
+        gameState.setMode('normal');
+    }
+
+    handleCityStrikeClick(x, y) {
+        const city = gameState.selectedCity;
+        if (city && Math.abs(x - city.x) <= 1 && Math.abs(y - city.y) <= 1 &&
+            gameState.getEnemyUnitsAt(x, y).length > 0) {
+            gameSocket.cityStrike(city.id, x, y);
+        }
+        gameState.setMode('normal');
+    }
+
+    // Ask the server for attack odds when hovering an enemy next to the selected unit
+    requestCombatOdds(x, y) {
+        const unit = gameState.selectedUnit;
+        if (!unit || !gameState.isMyTurn() || !gameState.isAdjacentToSelected(x, y)) {
+            return;
+        }
+        if (gameState.getCombatOdds(x, y)) {
+            return;
+        }
+
+        const enemies = gameState.getEnemyUnitsAt(x, y);
+        const enemyCity = gameState.getCityAt(x, y);
+        const hasEnemy = enemies.length > 0 || (enemyCity && enemyCity.owner_id !== gameState.myPlayerId);
+
+        if (hasEnemy) {
+            gameSocket.queryCombatOdds(unit.id, x, y);
+        }
+    }
+
     // Try to move or attack in a direction (like original Civ)
     tryMoveOrAttack(dx, dy) {
         if (!gameState.selectedUnit || !gameState.isMyTurn()) return false;
@@ -221,7 +305,7 @@ class InputHandler {
         }
 
         // Move the unit
-        gameSocket.moveUnit(unit.id, newX, newY);
+        gameSocket.moveUnitOrGroup(unit, newX, newY);
         return true;
     }
 
@@ -292,16 +376,14 @@ class InputHandler {
 
             case 'f':
             case 'F':
-                if (gameState.selectedUnit && !gameState.selectedUnit.can_found_city) {
-                    gameSocket.fortifyUnit(gameState.selectedUnit.id);
-                }
+                ui.toggleFortify();
                 break;
 
             case 'b':
             case 'B':
                 if (gameState.selectedUnit && gameState.canFoundCity()) {
-                    const name = prompt('Enter city name:', 'New City');
-                    if (name) {
+                    const name = prompt('Enter city name (leave empty for a historical one):', '');
+                    if (name !== null) {
                         gameSocket.foundCity(gameState.selectedUnit.id, name);
                     }
                 }
@@ -322,12 +404,60 @@ class InputHandler {
                 }
                 break;
 
+            case 'z':
+            case 'Z':
+                if (gameState.selectedUnit) {
+                    gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.SENTRY);
+                }
+                break;
+
+            case 'x':
+            case 'X':
+                if (gameState.selectedUnit) {
+                    gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.EXPLORE);
+                }
+                break;
+
+            case 'p':
+            case 'P':
+                ui.togglePatrol();
+                break;
+
+            case 'o':
+            case 'O':
+                ui.toggleBombard();
+                break;
+
+            case 'u':
+            case 'U':
+                ui.toggleNuke();
+                break;
+
+            case 'g':
+            case 'G':
+                ui.toggleGroup();
+                break;
+
+            case 'k':
+            case 'K':
+                if (gameState.selectedUnit && gameState.selectedUnit.can_found_city) {
+                    gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.WORK);
+                }
+                break;
+
             case 'Enter':
                 if (gameState.isMyTurn()) {
                     ui.tryEndTurn();
                 }
                 break;
 
+            case 'Backspace':
+                // Take back the planned orders of the selected unit
+                if (gameState.selectedUnit && gameState.selectedUnit.planned_orders && gameState.isMyTurn()) {
+                    gameSocket.cancelOrders(gameState.selectedUnit.id);
+                }
+                break;
+
             // Camera panning with WASD (always works)
             case 'w':
             case 'W':
@@ -383,7 +513,7 @@ class InputHandler {
         const myPlayer = gameState.getMyPlayer();
         if (!myPlayer || !myPlayer.units || myPlayer.units.length === 0) return;
 
-        const unitsWithMovement = myPlayer.units.filter(u => u.movement_left > 0 && !u.is_fortified);
+        const unitsWithMovement = myPlayer.units.filter(u => gameState.needsOrders(u));
         if (unitsWithMovement.length === 0) return;
 
         // Find current unit index
@@ -28,6 +28,11 @@ document.addEventListener('DOMContentLoaded', () => {
     // Set up WebSocket callbacks
     setupWebSocketCallbacks();
 
+    // Pick up unit and building definitions from the server
+    loadRules();
+    loadScenarios();
+    loadLocales();
+
     // Start render loop
     startRenderLoop();
 
@@ -47,6 +52,60 @@ window.addEventListener('load', () => {
     }
 });
 
+// Replace the built-in production options with the server's rules, which
+// a mod may have changed, with costs at the speed of the game in progress
+function loadRules() {
+    fetch(Config.API.RULES)
+        .then(response => response.json())
+        .then(rules => {
+            Config.PRODUCTION_OPTIONS = {
+                units: rules.units.map(u => ({
+                    type: u.type, name: u.name, cost: u.cost, requires: u.requires_wonder
+                })),
+                buildings: rules.buildings.map(b => ({
+                    type: b.type, name: b.name, cost: b.cost, wonder: b.wonder, coastal: b.coastal
+                }))
+            };
+        })
+        .catch(error => console.error('Error loading rules:', error));
+}
+
+// Offer the server's scenarios on the new game screen
+function loadScenarios() {
+    fetch(Config.API.SCENARIOS)
+        .then(response => response.json())
+        .then(data => {
+            const select = document.getElementById('scenario');
+            data.scenarios.forEach(s => {
+                const option = document.createElement('option');
+                option.value = s.id;
+                option.textContent = s.name;
+                option.title = s.description || '';
+                select.appendChild(option);
+            });
+        })
+        .catch(error => console.error('Error loading scenarios:', error));
+}
+
+// Offer the server's language packs on the new game screen
+function loadLocales() {
+    fetch(Config.API.LOCALES)
+        .then(response => response.json())
+        .then(data => {
+            const select = document.getElementById('locale');
+            data.locales.forEach(l => {
+                if (l.locale === 'en') {
+                    return; // Already offered
+                }
+                const option = document.createElement('option');
+                option.value = l.locale;
+                option.textContent = l.name;
+                select.appendChild(option);
+            });
+        })
+        .catch(error => console.error('Error loading locales:', error));
+}
+
 function setupWebSocketCallbacks() {
     gameSocket.onGameState((data) => {
         console.log('Game state received:', data);
@@ -62,6 +121,12 @@ function setupWebSocketCallbacks() {
 
         gameState.updateFromServer(data);
 
+        // Units may have moved or changed, refresh odds for the hovered tile
+        gameState.combatOdds = null;
+        if (inputHandler && inputHandler.hoverTileX >= 0) {
+            inputHandler.requestCombatOdds(inputHandler.hoverTileX, inputHandler.hoverTileY);
+        }
+
         // Center camera on first unit only on first load
         if (isFirstLoad && gameState.map) {
             const myPlayer = gameState.getMyPlayer();
@@ -75,10 +140,14 @@ function setupWebSocketCallbacks() {
                 renderer.centerOn(firstUnit.x, firstUnit.y);
             }
             isFirstLoad = false;
+
+            // Costs depend on the game's speed
+            loadRules();
         }
 
         ui.updateTopBar();
         ui.updateSelectionPanel();
+        ui.refreshDiplomacy();
 
         // Check for game over
         if (gameState.winner) {
@@ -86,6 +155,47 @@ function setupWebSocketCallbacks() {
         }
     });
 
+    gameSocket.onUpdate((update) => {
+        if (update.update_type === 'borders') {
+            gameState.applyBorderChanges(update.entity);
+        } else if (update.update_type === 'reveal') {
+            gameState.applyReveal(update.entity);
+        } else if (update.update_type === 'unit_promoted') {
+            gameState.applyPromotion(update.entity);
+            ui.updateSelectionPanel();
+        } else if (update.update_type === 'unit_moved') {
+            gameState.applyUnitMoved(update.entity);
+            ui.updateSelectionPanel();
+        } else if (update.update_type === 'units_changed') {
+            gameState.applyUnitsChanged(update.entity);
+            ui.updateSelectionPanel();
+        } else if (update.update_type === 'city_founded') {
+            gameState.applyCityFounded(update.entity);
+        } else if (update.update_type === 'production_completed') {
+            ui.showNotice(`${update.entity.item} completed in ${update.entity.city_name}`, 'cities');
+        } else if (update.update_type === 'city_grew') {
+            ui.showNotice(ui.grewText(update.entity), 'cities');
+        } else if (update.update_type === 'celebration_started') {
+            ui.showNotice(`${update.entity.city_name} celebrates We Love the King Day!`, 'cities');
+        } else if (update.update_type === 'celebration_ended') {
+            ui.showNotice(`${update.entity.city_name} has stopped celebrating`, 'cities');
+        } else if (update.update_type === 'king_lost') {
+            const loser = gameState.getPlayer(update.entity.player_id);
+            ui.showNotice(update.entity.player_id === gameState.myPlayerId ?
+                'Our King has fallen, and our civilization with it' :
+                `The King of ${loser ? loser.name : 'a rival'} has fallen`, 'combat');
+        } else if (update.update_type === 'resources_revealed') {
+            gameState.applyTiles(update.entity.tiles);
+            ui.showNotice(ui.revealedText(update.entity), 'research');
+        } else if (update.update_type === 'viewport_tiles') {
+            gameState.applyViewportTiles(update.entity);
+        } else if (update.update_type === 'log_entry') {
+            ui.addLogEntry(update.entity);
+        } else if (update.update_type === 'viewport_activity') {
+            ui.showActivity(update.entity);
+        }
+    });
+
     gameSocket.onTurnChange((data) => {
         console.log('Turn changed:', data);
         gameState.currentPlayerId = data.current_player;
@@ -96,7 +206,67 @@ function setupWebSocketCallbacks() {
 
     gameSocket.onCombatResult((data) => {
         console.log('Combat result:', data);
-        // Could add combat animation here
+        if (gameState.preferences.combat_animations) {
+            renderer.flashCombat(data.x, data.y);
+        }
+        if (data.city_captured && data.attacker_id === gameState.myPlayerId) {
+            ui.showNotice(`We captured ${data.city_captured} and plundered ${data.plunder || 0} gold`, 'combat');
+        } else if (data.city_captured && data.defender_id === gameState.myPlayerId) {
+            ui.showNotice(`${data.city_captured} was captured and plundered of ${data.plunder || 0} gold`, 'combat');
+        }
+    });
+
+    gameSocket.onQueryResult((data) => {
+        if (data.query_type === 'combat_odds') {
+            gameState.combatOdds = data.result;
+        } else if (data.query_type === 'demographics') {
+            ui.showDemographics(data.result);
+        } else if (data.query_type === 'happiness') {
+            gameState.happiness = data.result;
+            ui.refreshHappiness();
+            ui.refreshDiplomacy();
+        } else if (data.query_type === 'empire') {
+            ui.showEmpire(data.result);
+        } else if (data.query_type === 'reachable') {
+            gameState.reachable = data.result;
+        } else if (data.query_type === 'tile_info') {
+            ui.showTileInfo(data.result);
+        } else if (data.query_type === 'map_chunk') {
+            gameState.applyMapChunk(data.result);
+        }
+    });
+
+    gameSocket.onTurnSummary((summary) => {
+        ui.showTurnSummary(summary);
+    });
+
+    gameSocket.onAdvice((advice) => {
+        ui.showAdvice(advice);
+    });
+
+    gameSocket.onTurnStatus((status) => {
+        gameState.turnStatus = status;
+        gameState.applyPlannedOrders();
+        ui.updateTopBar();
+        ui.updateSelectionPanel();
+        autoEndTurn(status);
+    });
+
+    gameSocket.onWelcome((data) => {
+        gameState.myPlayerId = data.player_id;
+        ui.updateHostMenu();
+        ui.loadGameLog();
+    });
+
+    gameSocket.onHostState((data) => {
+        gameState.updateHostState(data);
+        ui.updateHostMenu();
+        ui.updateTopBar();
+    });
+
+    gameSocket.onPreferences((prefs) => {
+        gameState.preferences = prefs;
+        ui.refreshPreferences();
     });
 
     gameSocket.onError((error) => {
@@ -105,6 +275,8 @@ function setupWebSocketCallbacks() {
     });
 
     gameSocket.onConnect(() => {
+        // A new connection starts without a viewport
+        gameState.viewport = null;
         console.log('Connected to server');
     });
 
@@ -113,8 +285,42 @@ function setupWebSocketCallbacks() {
     });
 }
 
+// Keep the viewport of a streamed map subscribed to what is in view, and
+// fetch the chunks in view it does not cover
+function followViewport() {
+    if (!gameState.map || !gameState.map.chunkSize || !gameSocket.connected) return;
+
+    const range = renderer.getVisibleRange();
+    const rect = gameState.viewportFor(range);
+    if (rect) {
+        gameSocket.setViewport(rect.x, rect.y, rect.width, rect.height);
+        gameState.viewport = rect;
+        gameState.markChunks({
+            x: rect.x,
+            y: rect.y,
+            width: Math.min(rect.width, Config.MAX_VIEWPORT),
+            height: Math.min(rect.height, Config.MAX_VIEWPORT)
+        }, 'loading');
+    }
+    for (const chunk of gameState.chunksToLoad(range)) {
+        gameSocket.queryMapChunk(chunk.x, chunk.y);
+    }
+}
+
+// End our turn for us, if we asked to, once no unit or city waits for orders
+let autoEndedTurn = null;
+function autoEndTurn(status) {
+    if (!gameState.preferences.auto_end_turn || !gameState.isMyTurn() || gameState.paused) return;
+    if (status.player_id !== gameState.myPlayerId || !status.can_end_turn) return;
+    if (status.idle_units.length || status.cities_without_production.length) return;
+    if (autoEndedTurn === gameState.turn) return;
+    autoEndedTurn = gameState.turn;
+    gameSocket.endTurn();
+}
+
 function startRenderLoop() {
     function loop() {
+        followViewport();
         renderer.render();
         requestAnimationFrame(loop);
     }
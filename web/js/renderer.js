@@ -16,6 +16,9 @@ class Renderer {
         // Tile size
         this.tileSize = Config.TILE_SIZE;
 
+        // Battles being animated, each flashing on its tile for a moment
+        this.combatFlashes = [];
+
         // Resize handler
         this.resize();
         window.addEventListener('resize', () => this.resize());
@@ -55,8 +58,10 @@ class Renderer {
         }
 
         this.renderMap();
+        this.renderBorders();
         this.renderCities();
         this.renderUnits();
+        this.renderCombatFlashes();
         this.renderSelection();
         this.renderHoverTooltip();
         this.renderMinimap();
@@ -148,6 +153,24 @@ class Renderer {
                 if (tile.has_road) {
                     this.drawRoad(screen.x, screen.y, s, x, y);
                 }
+                if (tile.ford) {
+                    this.drawFord(screen.x, screen.y, s);
+                }
+                if (tile.has_mine) {
+                    this.drawMine(screen.x, screen.y, s);
+                }
+                if (tile.volcano) {
+                    this.drawVolcano(screen.x, screen.y, s);
+                }
+                if (tile.job && tile.job_turns > 0) {
+                    this.drawJobProgress(screen.x, screen.y, s, tile.job_progress / tile.job_turns);
+                }
+
+                // Nuclear fallout tints the land
+                if (tile.fallout) {
+                    this.ctx.fillStyle = 'rgba(120, 160, 40, 0.35)';
+                    this.ctx.fillRect(screen.x, screen.y, s, s);
+                }
             }
         }
 
@@ -2410,6 +2433,56 @@ class Renderer {
         ctx.fill();
     }
 
+    // Draw a ford - stepping stones across a shallow channel
+    drawFord(x, y, s) {
+        const ctx = this.ctx;
+        ctx.fillStyle = 'rgba(222, 206, 160, 0.55)';
+        ctx.fillRect(x, y + s * 0.3, s, s * 0.4);
+
+        ctx.fillStyle = '#8a7f70';
+        for (let i = 0; i < 4; i++) {
+            ctx.beginPath();
+            ctx.arc(x + s * (0.14 + i * 0.24), y + s * (i % 2 ? 0.56 : 0.44), s * 0.07, 0, Math.PI * 2);
+            ctx.fill();
+        }
+    }
+
+    // Draw a volcano - a glowing crater with a plume of smoke atop the peak
+    drawVolcano(x, y, s) {
+        const ctx = this.ctx;
+        ctx.fillStyle = 'rgba(90, 90, 90, 0.6)';
+        ctx.beginPath();
+        ctx.arc(x + s * 0.56, y + s * 0.14, s * 0.1, 0, Math.PI * 2);
+        ctx.arc(x + s * 0.66, y + s * 0.06, s * 0.08, 0, Math.PI * 2);
+        ctx.fill();
+
+        ctx.fillStyle = '#d9441c';
+        ctx.beginPath();
+        ctx.ellipse(x + s * 0.5, y + s * 0.28, s * 0.09, s * 0.04, 0, 0, Math.PI * 2);
+        ctx.fill();
+    }
+
+    // Draw a mine - a dark shaft entrance in the corner of the tile
+    drawMine(x, y, s) {
+        const ctx = this.ctx;
+        ctx.fillStyle = '#3a2f25';
+        ctx.beginPath();
+        ctx.arc(x + s * 0.78, y + s * 0.78, s * 0.12, Math.PI, 0);
+        ctx.lineTo(x + s * 0.9, y + s * 0.86);
+        ctx.lineTo(x + s * 0.66, y + s * 0.86);
+        ctx.closePath();
+        ctx.fill();
+    }
+
+    // Draw the progress of a terrain job as a bar along the bottom of the tile
+    drawJobProgress(x, y, s, fraction) {
+        const ctx = this.ctx;
+        ctx.fillStyle = 'rgba(0, 0, 0, 0.5)';
+        ctx.fillRect(x + s * 0.1, y + s * 0.9, s * 0.8, s * 0.06);
+        ctx.fillStyle = '#e0c040';
+        ctx.fillRect(x + s * 0.1, y + s * 0.9, s * 0.8 * Math.min(1, fraction), s * 0.06);
+    }
+
     // Draw road improvement - connects to neighboring roads
     drawRoad(x, y, s, tileX, tileY) {
         const ctx = this.ctx;
@@ -2500,6 +2573,55 @@ class Renderer {
     }
 
     // Render cities
+    // Draw national borders along tile edges where territory changes hands
+    renderBorders() {
+        const range = this.getVisibleRange();
+        const s = this.tileSize * this.camera.zoom;
+        const ctx = this.ctx;
+        const colors = this.playerColors();
+        const inset = Math.max(1, s * 0.06);
+
+        ctx.save();
+        ctx.lineWidth = Math.max(1.5, s * 0.06);
+        ctx.setLineDash([s * 0.16, s * 0.08]);
+
+        for (let y = range.startY; y < range.endY; y++) {
+            for (let x = range.startX; x < range.endX; x++) {
+                const tile = gameState.getTile(x, y);
+                if (!tile || !tile.owner) continue;
+
+                const screen = this.worldToScreen(x, y);
+                const left = screen.x + inset;
+                const top = screen.y + inset;
+                const right = screen.x + s - inset;
+                const bottom = screen.y + s - inset;
+                const differs = (nx, ny) => {
+                    const n = gameState.getTile(nx, ny);
+                    return !n || n.owner !== tile.owner;
+                };
+
+                ctx.strokeStyle = colors[tile.owner] || '#fff';
+                ctx.beginPath();
+                if (differs(x, y - 1)) { ctx.moveTo(left, top); ctx.lineTo(right, top); }
+                if (differs(x + 1, y)) { ctx.moveTo(right, top); ctx.lineTo(right, bottom); }
+                if (differs(x, y + 1)) { ctx.moveTo(left, bottom); ctx.lineTo(right, bottom); }
+                if (differs(x - 1, y)) { ctx.moveTo(left, top); ctx.lineTo(left, bottom); }
+                ctx.stroke();
+            }
+        }
+
+        ctx.restore();
+    }
+
+    // Colors of the players, by ID
+    playerColors() {
+        const colors = {};
+        for (const player of gameState.players) {
+            colors[player.id] = player.color;
+        }
+        return colors;
+    }
+
     renderCities() {
         const scaledTileSize = this.tileSize * this.camera.zoom;
 
@@ -2668,7 +2790,7 @@ class Renderer {
                 }
 
                 // "Active" label for units that can still act (my units with movement left)
-                if (player.id === gameState.myPlayerId && unit.movement_left > 0 && !unit.is_fortified) {
+                if (player.id === gameState.myPlayerId && gameState.needsOrders(unit)) {
                     const fontSize = Math.max(8, scaledTileSize * 0.18);
                     this.ctx.font = `bold ${fontSize}px sans-serif`;
                     this.ctx.textAlign = 'center';
@@ -2696,7 +2818,11 @@ class Renderer {
             'Phalanx': 'P',
             'Archer': 'A',
             'Horseman': 'H',
-            'Catapult': 'C'
+            'Catapult': 'C',
+            'Nuclear': 'N',
+            'Scout': 'Sc',
+            'Caravan': 'Cv',
+            'King': 'K'
         };
         return letters[unitType] || '?';
     }
@@ -3114,10 +3240,47 @@ class Renderer {
         ctx.stroke();
     }
 
+    // Animate a battle on a tile
+    flashCombat(x, y) {
+        this.combatFlashes.push({ x: x, y: y, start: performance.now() });
+    }
+
+    // Draw a burst over each tile fought on, fading out as it grows
+    renderCombatFlashes() {
+        const duration = 600;
+        const now = performance.now();
+        this.combatFlashes = this.combatFlashes.filter(f => now - f.start < duration);
+
+        const scaledTileSize = this.tileSize * this.camera.zoom;
+        this.combatFlashes.forEach(f => {
+            const progress = (now - f.start) / duration;
+            const screen = this.worldToScreen(f.x, f.y);
+            const cx = screen.x + scaledTileSize / 2;
+            const cy = screen.y + scaledTileSize / 2;
+
+            this.ctx.save();
+            this.ctx.globalAlpha = 1 - progress;
+            this.ctx.fillStyle = '#ffdd44';
+            this.ctx.strokeStyle = '#ff4400';
+            this.ctx.lineWidth = 3;
+            this.ctx.beginPath();
+            this.ctx.arc(cx, cy, scaledTileSize * (0.2 + 0.5 * progress), 0, Math.PI * 2);
+            this.ctx.fill();
+            this.ctx.stroke();
+            this.ctx.restore();
+        });
+    }
+
     // Render selection highlight
     renderSelection() {
         const scaledTileSize = this.tileSize * this.camera.zoom;
 
+        // Orders planned in the simultaneous phase
+        const myPlayer = gameState.getMyPlayer();
+        if (myPlayer && gameState.isSimultaneous()) {
+            myPlayer.units.forEach(unit => this.renderPlannedOrders(unit));
+        }
+
         // Selected unit
         if (gameState.selectedUnit) {
             const unit = gameState.selectedUnit;
@@ -3142,7 +3305,16 @@ class Renderer {
 
             // Show attack range when in attack mode
             if (gameState.mode === 'attack') {
-                this.renderAttackRange(unit);
+                this.renderAttackRange(unit, 1);
+            } else if (gameState.mode === 'bombard') {
+                this.renderAttackRange(unit, Config.BOMBARD_RANGE);
+            }
+
+            // Show the route being picked, or the unit's current patrol
+            if (gameState.mode === 'patrol') {
+                this.renderPatrolRoute(gameState.patrolWaypoints, false);
+            } else if (unit.mode === 'patrol' && unit.patrol) {
+                this.renderPatrolRoute(unit.patrol, true);
             }
         }
 
@@ -3159,6 +3331,11 @@ class Renderer {
                 scaledTileSize + 4,
                 scaledTileSize + 4
             );
+
+            // Show targets when picking a ranged strike
+            if (gameState.mode === 'city_strike') {
+                this.renderAttackRange(city, 1);
+            }
         }
     }
 
@@ -3282,6 +3459,15 @@ class Renderer {
             tooltipText += ' + ' + tile.resource;
         }
 
+        // Show attack odds for the selected unit
+        const odds = gameState.getCombatOdds(tileX, tileY);
+        if (odds) {
+            tooltipText += ` - ${Math.round(odds.win_chance * 100)}% chance to win`;
+            if (odds.first_strike) {
+                tooltipText += `, taking ${odds.first_strike} damage first`;
+            }
+        }
+
         // Get screen position of the tile
         const screen = this.worldToScreen(tileX, tileY);
         const scaledTileSize = this.tileSize * this.camera.zoom;
@@ -3315,40 +3501,42 @@ class Renderer {
         ctx.fillText(tooltipText, tooltipX, tooltipY);
     }
 
-    // Render movement range overlay
+    // Render movement range overlay: the tiles the server says the unit
+    // can reach this turn, with the movement it spends getting there, and
+    // in red those it would attack
     renderMovementRange(unit) {
+        const reachable = gameState.getReachable();
+        if (!reachable) return;
         const scaledTileSize = this.tileSize * this.camera.zoom;
 
-        for (let dy = -1; dy <= 1; dy++) {
-            for (let dx = -1; dx <= 1; dx++) {
-                if (dx === 0 && dy === 0) continue;
-
-                const x = unit.x + dx;
-                const y = unit.y + dy;
-
-                const tile = gameState.getTile(x, y);
-                if (!tile) continue;
-                if (tile.terrain === 'Ocean') continue;
-                if (tile.terrain === 'Mountains') continue;
+        reachable.forEach(t => {
+            const screen = this.worldToScreen(t.x, t.y);
 
-                const screen = this.worldToScreen(x, y);
+            this.ctx.fillStyle = t.attack ? 'rgba(255, 0, 0, 0.25)' : 'rgba(255, 255, 255, 0.25)';
+            this.ctx.fillRect(screen.x, screen.y, scaledTileSize, scaledTileSize);
 
-                this.ctx.fillStyle = 'rgba(255, 255, 255, 0.25)';
-                this.ctx.fillRect(screen.x, screen.y, scaledTileSize, scaledTileSize);
+            this.ctx.strokeStyle = t.attack ? 'rgba(255, 60, 60, 0.8)' : 'rgba(255, 255, 255, 0.6)';
+            this.ctx.lineWidth = 2;
+            this.ctx.strokeRect(screen.x, screen.y, scaledTileSize, scaledTileSize);
 
-                this.ctx.strokeStyle = 'rgba(255, 255, 255, 0.6)';
-                this.ctx.lineWidth = 2;
-                this.ctx.strokeRect(screen.x, screen.y, scaledTileSize, scaledTileSize);
+            if (!t.attack && scaledTileSize >= 24) {
+                this.ctx.save();
+                this.ctx.fillStyle = '#ffffff';
+                this.ctx.font = `${Math.floor(scaledTileSize / 4)}px sans-serif`;
+                this.ctx.textAlign = 'right';
+                this.ctx.textBaseline = 'bottom';
+                this.ctx.fillText(t.cost, screen.x + scaledTileSize - 3, screen.y + scaledTileSize - 2);
+                this.ctx.restore();
             }
-        }
+        });
     }
 
     // Render attack range overlay
-    renderAttackRange(unit) {
+    renderAttackRange(unit, range) {
         const scaledTileSize = this.tileSize * this.camera.zoom;
 
-        for (let dy = -1; dy <= 1; dy++) {
-            for (let dx = -1; dx <= 1; dx++) {
+        for (let dy = -range; dy <= range; dy++) {
+            for (let dx = -range; dx <= range; dx++) {
                 if (dx === 0 && dy === 0) continue;
 
                 const x = unit.x + dx;
@@ -3373,6 +3561,64 @@ class Renderer {
         }
     }
 
+    // Render a patrol route as waypoint markers joined by lines
+    renderPatrolRoute(waypoints, closed) {
+        if (!waypoints || waypoints.length === 0) return;
+
+        const scaledTileSize = this.tileSize * this.camera.zoom;
+        const half = scaledTileSize / 2;
+        const ctx = this.ctx;
+
+        ctx.strokeStyle = 'rgba(255, 255, 0, 0.8)';
+        ctx.lineWidth = 2;
+        ctx.setLineDash([6, 4]);
+        ctx.beginPath();
+        waypoints.forEach((wp, i) => {
+            const screen = this.worldToScreen(wp.x, wp.y);
+            if (i === 0) {
+                ctx.moveTo(screen.x + half, screen.y + half);
+            } else {
+                ctx.lineTo(screen.x + half, screen.y + half);
+            }
+        });
+        if (closed) {
+            ctx.closePath();
+        }
+        ctx.stroke();
+        ctx.setLineDash([]);
+
+        ctx.fillStyle = 'rgba(255, 255, 0, 0.9)';
+        waypoints.forEach(wp => {
+            const screen = this.worldToScreen(wp.x, wp.y);
+            ctx.beginPath();
+            ctx.arc(screen.x + half, screen.y + half, Math.max(3, scaledTileSize * 0.12), 0, Math.PI * 2);
+            ctx.fill();
+        });
+    }
+
+    // Render a unit's planned orders: its moves as a line from where it
+    // stands, and an attack as a red line to the target
+    renderPlannedOrders(unit) {
+        if (!unit.planned_orders) return;
+
+        const half = this.tileSize * this.camera.zoom / 2;
+        const ctx = this.ctx;
+        let from = this.worldToScreen(unit.planned_from.x, unit.planned_from.y);
+
+        ctx.lineWidth = 3;
+        unit.planned_orders.forEach(order => {
+            const to = this.worldToScreen(order.x, order.y);
+            ctx.strokeStyle = order.kind === 'attack' ? 'rgba(255, 60, 60, 0.9)' : 'rgba(255, 255, 255, 0.8)';
+            ctx.beginPath();
+            ctx.moveTo(from.x + half, from.y + half);
+            ctx.lineTo(to.x + half, to.y + half);
+            ctx.stroke();
+            if (order.kind === 'move') {
+                from = to;
+            }
+        });
+    }
+
     // Render minimap
     renderMinimap() {
         if (!gameState.map) return;
@@ -3393,31 +3639,19 @@ class Renderer {
         const offsetX = (width - gameState.map.width * scale) / 2;
         const offsetY = (height - gameState.map.height * scale) / 2;
 
-        // Draw terrain with classic Civ 1 minimap colors
-        const minimapColors = {
-            'Ocean': '#0040a0',
-            'Grassland': '#00a800',
-            'Plains': '#c8b040',
-            'Desert': '#e8d858',
-            'Hills': '#987850',
-            'Mountains': '#808080',
-            'Forest': '#006800'
-        };
-
-        for (let y = 0; y < gameState.map.height; y++) {
-            for (let x = 0; x < gameState.map.width; x++) {
-                const tile = gameState.getTile(x, y);
-                if (!tile) continue;
-
-                ctx.fillStyle = minimapColors[tile.terrain] || '#888';
-                ctx.fillRect(
-                    offsetX + x * scale,
-                    offsetY + y * scale,
-                    Math.ceil(scale),
-                    Math.ceil(scale)
-                );
-            }
-        }
+        // Terrain and territory only change with the tiles, so they are kept
+        // in a layer of one pixel per tile that is redrawn only then
+        if (!this.minimapLayer || this.minimapLayerVersion !== gameState.mapVersion) {
+            this.drawMinimapLayer();
+        }
+        ctx.imageSmoothingEnabled = false;
+        ctx.drawImage(
+            this.minimapLayer,
+            offsetX,
+            offsetY,
+            gameState.map.width * scale,
+            gameState.map.height * scale
+        );
 
         // Draw units as visible dots with white outline
         for (const player of gameState.players) {
@@ -3493,6 +3727,68 @@ class Renderer {
         this.minimapOffsetY = offsetY;
     }
 
+    // Draw the minimap's terrain, in classic Civ 1 minimap colors, with
+    // national territory shaded in its owner's color
+    drawMinimapLayer() {
+        const map = gameState.map;
+        if (!this.minimapLayer) {
+            this.minimapLayer = document.createElement('canvas');
+        }
+        this.minimapLayer.width = map.width;
+        this.minimapLayer.height = map.height;
+        this.minimapLayerVersion = gameState.mapVersion;
+
+        const minimapColors = {
+            'Ocean': '#0040a0',
+            'Grassland': '#00a800',
+            'Plains': '#c8b040',
+            'Desert': '#e8d858',
+            'Hills': '#987850',
+            'Mountains': '#808080',
+            'Forest': '#006800'
+        };
+        const rgb = (hex) => [1, 3, 5].map(i => parseInt(hex.slice(i, i + 2), 16));
+        const terrainRGB = {};
+        for (const terrain in minimapColors) {
+            terrainRGB[terrain] = rgb(minimapColors[terrain]);
+        }
+        const ownerRGB = {};
+        const colors = this.playerColors();
+        for (const id in colors) {
+            if (/^#[0-9a-f]{6}$/i.test(colors[id])) {
+                ownerRGB[id] = rgb(colors[id]);
+            }
+        }
+
+        // Tiles not fetched yet take the color of their cell of the coarse
+        // minimap, if the server sent one, or are left transparent
+        const coarse = gameState.coarseMinimap;
+        const layerCtx = this.minimapLayer.getContext('2d');
+        const image = layerCtx.createImageData(map.width, map.height);
+        for (let y = 0; y < map.height; y++) {
+            for (let x = 0; x < map.width; x++) {
+                let tile = gameState.getTile(x, y);
+                if (!tile && coarse) {
+                    const cell = Math.floor(y / coarse.block_size) * coarse.width + Math.floor(x / coarse.block_size);
+                    tile = { terrain: coarse.terrain[cell], owner: coarse.owners[cell] };
+                }
+                if (!tile) continue;
+
+                let color = terrainRGB[tile.terrain] || [0x88, 0x88, 0x88];
+                const owner = tile.owner && ownerRGB[tile.owner];
+                if (owner) {
+                    color = color.map((c, i) => (c + owner[i]) >> 1);
+                }
+                const p = (y * map.width + x) * 4;
+                image.data[p] = color[0];
+                image.data[p + 1] = color[1];
+                image.data[p + 2] = color[2];
+                image.data[p + 3] = 255;
+            }
+        }
+        layerCtx.putImageData(image, 0, 0);
+    }
+
     // Handle click on minimap - navigate to that location
     onMinimapClick(e) {
         if (!gameState.map) return;
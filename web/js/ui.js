@@ -7,9 +7,14 @@ class UI {
         this.turnNumber = document.getElementById('turn-number');
         this.currentPlayer = document.getElementById('current-player');
         this.goldDisplay = document.getElementById('gold-display');
+        this.scienceDisplay = document.getElementById('science-display');
+        this.taxRate = document.getElementById('tax-rate');
+        this.startPoints = document.getElementById('start-points');
+        this.buyTechBtn = document.getElementById('btn-buy-tech');
         this.endTurnBtn = document.getElementById('end-turn-btn');
         this.selectionInfo = document.getElementById('selection-info');
         this.unitActions = document.getElementById('unit-actions');
+        this.notices = document.getElementById('notices');
 
         // City modal
         this.cityModal = document.getElementById('city-modal');
@@ -19,6 +24,8 @@ class UI {
         this.cityFoodNeeded = document.getElementById('city-food-needed');
         this.cityProd = document.getElementById('city-prod');
         this.cityProdNeeded = document.getElementById('city-prod-needed');
+        this.cityDefense = document.getElementById('city-defense');
+        this.cityDefenseMax = document.getElementById('city-defense-max');
         this.cityBuildingList = document.getElementById('city-building-list');
         this.productionOptions = document.getElementById('production-options');
 
@@ -39,6 +46,22 @@ class UI {
             this.tryEndTurn();
         });
 
+        // Tax rate
+        this.taxRate.addEventListener('change', () => {
+            gameSocket.setTaxRate(parseInt(this.taxRate.value, 10));
+        });
+
+        // Advanced start purchases
+        this.buyTechBtn.addEventListener('click', () => {
+            gameSocket.buyStart('tech', 0, 0);
+        });
+        document.getElementById('btn-buy-city').addEventListener('click', () => {
+            const unit = gameState.selectedUnit;
+            if (unit) {
+                gameSocket.buyStart('city', unit.x, unit.y);
+            }
+        });
+
         // Unit action buttons
         document.getElementById('btn-move').addEventListener('click', () => {
             if (gameState.selectedUnit && gameState.canUnitMove(gameState.selectedUnit)) {
@@ -47,6 +70,7 @@ class UI {
                     gameState.setMode('select');
                 } else {
                     gameState.setMode('move');
+                    gameSocket.queryReachable(gameState.selectedUnit.id);
                 }
                 this.updateModeButtons();
             }
@@ -64,33 +88,108 @@ class UI {
             }
         });
 
+        document.getElementById('btn-bombard').addEventListener('click', () => {
+            this.toggleBombard();
+        });
+
+        document.getElementById('btn-nuke').addEventListener('click', () => {
+            this.toggleNuke();
+        });
+
         document.getElementById('btn-fortify').addEventListener('click', () => {
-            if (gameState.selectedUnit) {
-                gameSocket.fortifyUnit(gameState.selectedUnit.id);
-            }
+            this.toggleFortify();
         });
 
         document.getElementById('btn-found-city').addEventListener('click', () => {
             if (gameState.selectedUnit && gameState.canFoundCity()) {
-                const name = prompt('Enter city name:', 'New City');
-                if (name) {
+                const name = prompt('Enter city name (leave empty for a historical one):', '');
+                if (name !== null) {
                     gameSocket.foundCity(gameState.selectedUnit.id, name);
                 }
             }
         });
 
+        document.getElementById('btn-help-wonder').addEventListener('click', () => {
+            if (gameState.canHelpWonder()) {
+                gameSocket.helpWonder(gameState.selectedUnit.id);
+            }
+        });
+
         document.getElementById('btn-skip').addEventListener('click', () => {
             if (gameState.selectedUnit) {
                 gameSocket.skipUnit(gameState.selectedUnit.id);
             }
         });
 
+        document.getElementById('btn-sentry').addEventListener('click', () => {
+            if (gameState.selectedUnit) {
+                gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.SENTRY);
+            }
+        });
+
+        document.getElementById('btn-explore').addEventListener('click', () => {
+            if (gameState.selectedUnit) {
+                gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.EXPLORE);
+            }
+        });
+
+        document.getElementById('btn-patrol').addEventListener('click', () => {
+            this.togglePatrol();
+        });
+
+        document.getElementById('btn-group').addEventListener('click', () => {
+            this.toggleGroup();
+        });
+
+        document.getElementById('btn-auto-work').addEventListener('click', () => {
+            if (gameState.selectedUnit && gameState.selectedUnit.can_found_city) {
+                gameSocket.setUnitMode(gameState.selectedUnit.id, Config.UNIT_MODES.WORK);
+            }
+        });
+
         document.getElementById('btn-build-road').addEventListener('click', () => {
             if (gameState.selectedUnit && gameState.selectedUnit.can_found_city) {
                 gameSocket.buildRoad(gameState.selectedUnit.id);
             }
         });
 
+        document.querySelectorAll('.btn-job').forEach(btn => {
+            btn.addEventListener('click', () => {
+                if (gameState.selectedUnit && gameState.selectedUnit.can_found_city) {
+                    gameSocket.terraform(gameState.selectedUnit.id, btn.dataset.job);
+                }
+            });
+        });
+
+        // City ranged strike: pick an adjacent enemy on the map
+        document.getElementById('city-strike-btn').addEventListener('click', () => {
+            if (gameState.selectedCity) {
+                this.hideCityModal();
+                gameState.setMode('city_strike');
+            }
+        });
+
+        document.getElementById('btn-rename-unit').addEventListener('click', () => {
+            const unit = gameState.selectedUnit;
+            if (unit) {
+                const name = prompt('Rename unit (leave empty for none):', unit.name || '');
+                if (name !== null && name.trim() !== (unit.name || '')) {
+                    gameSocket.renameUnit(unit.id, name.trim());
+                }
+            }
+        });
+
+        document.getElementById('city-rename-btn').addEventListener('click', () => {
+            const city = gameState.selectedCity;
+            if (city) {
+                const name = prompt('Rename city:', city.name);
+                if (name && name.trim() !== city.name) {
+                    gameSocket.renameCity(city.id, name.trim());
+                    this.hideCityModal();
+                }
+            }
+        });
+
         // City modal close
         this.cityModal.querySelector('.close-btn').addEventListener('click', () => {
             this.hideCityModal();
@@ -117,6 +216,58 @@ class UI {
             this.saveGame();
         });
 
+        // Download the current game to move it to another machine
+        document.getElementById('menu-export').addEventListener('click', () => {
+            window.location.href = Config.API.EXPORT_GAME;
+        });
+
+        document.getElementById('import-save-btn').addEventListener('click', () => {
+            document.getElementById('import-save-file').click();
+        });
+
+        document.getElementById('import-save-file').addEventListener('change', (e) => {
+            const file = e.target.files[0];
+            e.target.value = '';
+            if (file) {
+                this.importSave(file);
+            }
+        });
+
+        document.getElementById('menu-notify').addEventListener('click', () => {
+            this.setTurnNotifications();
+        });
+
+        document.getElementById('menu-preferences').addEventListener('click', () => {
+            this.refreshPreferences();
+            document.getElementById('preferences-modal').classList.remove('hidden');
+        });
+
+        document.getElementById('menu-pause').addEventListener('click', () => {
+            if (gameState.paused) {
+                gameSocket.resumeGame();
+            } else {
+                gameSocket.pauseGame();
+            }
+        });
+
+        document.getElementById('menu-turn-timer').addEventListener('click', () => {
+            this.changeTurnTimer();
+        });
+
+        document.getElementById('menu-kick').addEventListener('click', () => {
+            const player = this.pickHumanPlayer('Kick which player? Their turns will be ended for them.');
+            if (player && confirm(`Kick ${player.name} from the game?`)) {
+                gameSocket.kickPlayer(player.id);
+            }
+        });
+
+        document.getElementById('menu-transfer-host').addEventListener('click', () => {
+            const player = this.pickHumanPlayer('Make which player the host?');
+            if (player) {
+                gameSocket.transferHost(player.id);
+            }
+        });
+
         document.getElementById('menu-quit').addEventListener('click', () => {
             if (confirm('Quit to main menu?')) {
                 this.showStartScreen();
@@ -145,6 +296,129 @@ class UI {
             this.showResourcesGallery();
         });
 
+        document.getElementById('menu-view-stats').addEventListener('click', () => {
+            this.showStats();
+        });
+
+        // The map as we have explored it, to save or share
+        document.getElementById('menu-view-map-image').addEventListener('click', () => {
+            window.open(`${Config.API.MAP_IMAGE}?scale=8&player=${encodeURIComponent(gameState.myPlayerId)}`, '_blank');
+        });
+
+        document.getElementById('menu-view-demographics').addEventListener('click', () => {
+            gameSocket.queryDemographics();
+        });
+
+        // The window opens at once and fills in when the answer comes
+        document.getElementById('menu-view-happiness').addEventListener('click', () => {
+            document.getElementById('happiness-modal').classList.remove('hidden');
+            gameSocket.queryHappiness();
+        });
+
+        // Clicking a column heading sorts by it, and again the other way
+        this.empireSort = { column: '', descending: false };
+        document.getElementById('menu-view-empire').addEventListener('click', () => {
+            document.getElementById('empire-modal').classList.remove('hidden');
+            gameSocket.queryEmpire(this.empireSort.column, this.empireSort.descending);
+        });
+
+        document.getElementById('empire-table').addEventListener('click', (e) => {
+            const heading = e.target.closest('th[data-sort]');
+            if (heading) {
+                const column = heading.dataset.sort;
+                const descending = this.empireSort.column === column && !this.empireSort.descending;
+                this.empireSort = { column: column, descending: descending };
+                gameSocket.queryEmpire(column, descending);
+            }
+        });
+
+        document.getElementById('empire-table').addEventListener('dblclick', (e) => {
+            const row = e.target.closest('tr[data-city]');
+            const city = row ? gameState.getCity(row.dataset.city) : null;
+            if (city) {
+                document.getElementById('empire-modal').classList.add('hidden');
+                gameState.selectCity(city);
+                this.showCityModal(city);
+            }
+        });
+
+        document.getElementById('empire-modal-close').addEventListener('click', () => {
+            document.getElementById('empire-modal').classList.add('hidden');
+        });
+
+        document.getElementById('happiness-modal-close').addEventListener('click', () => {
+            document.getElementById('happiness-modal').classList.add('hidden');
+        });
+
+        // Which luxuries we can spare comes with our happiness
+        document.getElementById('menu-view-diplomacy').addEventListener('click', () => {
+            this.showDiplomacy();
+            gameSocket.queryHappiness();
+        });
+
+        document.getElementById('diplomacy-modal-close').addEventListener('click', () => {
+            document.getElementById('diplomacy-modal').classList.add('hidden');
+        });
+
+        // Deal buttons name the player and deal they are for
+        document.getElementById('diplomacy-table').addEventListener('click', (e) => {
+            const button = e.target.closest('button');
+            if (!button) return;
+            const { action, player, deal } = button.dataset;
+            const resource = button.dataset.resource || undefined;
+            const me = gameState.myPlayerId;
+            switch (action) {
+                case 'propose':
+                    gameSocket.proposeDeal(player, deal, resource);
+                    break;
+                case 'accept':
+                    gameSocket.acceptDeal({ from: player, to: me, deal: deal, resource: resource });
+                    break;
+                case 'decline':
+                    gameSocket.declineDeal({ from: player, to: me, deal: deal, resource: resource });
+                    break;
+                case 'withdraw':
+                    gameSocket.declineDeal({ from: me, to: player, deal: deal, resource: resource });
+                    break;
+                case 'cancel':
+                    gameSocket.cancelPact(player);
+                    break;
+            }
+        });
+
+        document.getElementById('demographics-modal-close').addEventListener('click', () => {
+            document.getElementById('demographics-modal').classList.add('hidden');
+        });
+
+        document.getElementById('preferences-modal-close').addEventListener('click', () => {
+            document.getElementById('preferences-modal').classList.add('hidden');
+        });
+
+        document.querySelectorAll('#preferences-modal input[type=checkbox]').forEach(box => {
+            box.addEventListener('change', () => this.savePreferences());
+        });
+
+        document.getElementById('pref-profile-btn').addEventListener('click', () => {
+            const profile = document.getElementById('pref-profile').value.trim();
+            if (profile.length < 16) {
+                this.showError('A profile key is at least 16 characters long');
+                return;
+            }
+            gameSocket.setProfile(profile);
+        });
+
+        document.getElementById('game-over-stats-btn').addEventListener('click', () => {
+            this.showStats();
+        });
+
+        document.getElementById('stats-metric').addEventListener('change', () => {
+            this.drawStatsChart();
+        });
+
+        document.getElementById('stats-modal-close').addEventListener('click', () => {
+            document.getElementById('stats-modal').classList.add('hidden');
+        });
+
         document.getElementById('units-modal-close').addEventListener('click', () => {
             document.getElementById('units-modal').classList.add('hidden');
         });
@@ -153,6 +427,10 @@ class UI {
             document.getElementById('resources-modal').classList.add('hidden');
         });
 
+        document.getElementById('turn-summary-modal-close').addEventListener('click', () => {
+            document.getElementById('turn-summary-modal').classList.add('hidden');
+        });
+
         // Toolbar handlers
         document.getElementById('tb-new').addEventListener('click', () => {
             if (confirm('Start a new game? Current progress will be lost.')) {
@@ -189,8 +467,15 @@ class UI {
 
     // Save game to file
     saveGame() {
+        const slot = prompt('Save slot (letters, digits, - and _; empty to name it by the time):', '');
+        if (slot === null) return;
+
         fetch(Config.API.SAVE_GAME, {
-            method: 'POST'
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ slot: slot.trim() })
         })
         .then(response => response.json())
         .then(data => {
@@ -206,6 +491,76 @@ class UI {
         });
     }
 
+    // Ask where to be told it is our turn in an async game
+    setTurnNotifications() {
+        const target = prompt('Webhook URL or mailto: address to notify when it is your turn (empty to turn off):', '');
+        if (target !== null) {
+            gameSocket.setNotify(target.trim());
+        }
+    }
+
+    // Show our preferences in the Preferences dialog
+    refreshPreferences() {
+        const prefs = gameState.preferences;
+        document.getElementById('pref-auto-end-turn').checked = prefs.auto_end_turn;
+        document.getElementById('pref-combat-animations').checked = prefs.combat_animations;
+        document.querySelectorAll('.pref-notice').forEach(box => {
+            box.checked = !prefs.muted_notices.includes(box.value);
+        });
+        document.getElementById('pref-profile').value = gameSocket.profile();
+    }
+
+    // Keep the preferences set in the Preferences dialog on the server
+    savePreferences() {
+        const muted = [];
+        document.querySelectorAll('.pref-notice').forEach(box => {
+            if (!box.checked) {
+                muted.push(box.value);
+            }
+        });
+        gameSocket.setPreferences({
+            auto_end_turn: document.getElementById('pref-auto-end-turn').checked,
+            combat_animations: document.getElementById('pref-combat-animations').checked,
+            muted_notices: muted
+        });
+    }
+
+    // Show the Host menu to the host only
+    updateHostMenu() {
+        document.getElementById('menu-host').classList.toggle('hidden', !gameState.isHost());
+        document.getElementById('menu-pause').textContent = gameState.paused ? 'Resume Game' : 'Pause Game';
+    }
+
+    // Ask the host for another human player by name
+    pickHumanPlayer(question) {
+        const players = gameState.players.filter(p =>
+            p.is_human && p.id !== gameState.myPlayerId && !gameState.kicked.includes(p.id));
+        if (players.length === 0) {
+            this.showError('There are no other human players');
+            return null;
+        }
+
+        const name = prompt(`${question}\n${players.map(p => p.name).join(', ')}`, players[0].name);
+        if (name === null) return null;
+        const player = players.find(p => p.name.toLowerCase() === name.trim().toLowerCase());
+        if (!player) {
+            this.showError(`No player named ${name}`);
+        }
+        return player || null;
+    }
+
+    // Ask the host for a new turn time limit
+    changeTurnTimer() {
+        const answer = prompt('Turn time limit in minutes (0 for none):', String(gameState.turnTimeout));
+        if (answer === null) return;
+        const minutes = parseInt(answer, 10);
+        if (isNaN(minutes) || minutes < 0) {
+            this.showError('Enter a number of minutes');
+            return;
+        }
+        gameSocket.setTurnTimer(minutes, gameState.inactivityPolicy);
+    }
+
     // Open load game modal
     openSaveFile() {
         this.showLoadModal();
@@ -223,12 +578,16 @@ class UI {
                 if (data.success && data.saves && data.saves.length > 0) {
                     savesList.innerHTML = data.saves.map(save => `
                         <div class="save-item" data-filename="${save.filename}">
-                            <span class="save-name">${save.filename}</span>
+                            <span class="save-name">${save.slot}${save.game_id ? ` <small>${save.game_id.slice(0, 8)}</small>` : ''}</span>
                             <span class="save-date">${save.modified}</span>
+                            <a class="save-download" href="${Config.API.EXPORT_GAME}?filename=${encodeURIComponent(save.filename)}" title="Download">⬇</a>
                         </div>
                     `).join('');
 
                     // Add click handlers
+                    savesList.querySelectorAll('.save-download').forEach(link => {
+                        link.addEventListener('click', (e) => e.stopPropagation());
+                    });
                     savesList.querySelectorAll('.save-item').forEach(item => {
                         item.addEventListener('click', () => {
                             const filename = item.dataset.filename;
@@ -244,6 +603,7 @@ class UI {
                 savesList.innerHTML = '<p class="no-saves">Failed to load saves list</p>';
             });
 
+        this.showTurns();
         modal.classList.remove('hidden');
 
         // Setup close handler
@@ -252,6 +612,85 @@ class UI {
         };
     }
 
+    // List the turns of this game that can be gone back to, when the server
+    // records them
+    showTurns() {
+        const section = document.getElementById('turns-section');
+        const turnsList = document.getElementById('turns-list');
+
+        fetch(Config.API.TURNS)
+            .then(response => response.json())
+            .then(data => {
+                // The current turn's snapshot is where the turn started
+                const turns = (data.turns || []).reverse();
+                section.classList.toggle('hidden', !data.success || turns.length === 0);
+                turnsList.innerHTML = turns.map(t => `
+                    <div class="save-item" data-turn="${t.turn}">
+                        <span class="save-name">Turn ${t.turn}</span>
+                        <span class="save-date">${t.saved_at}</span>
+                    </div>
+                `).join('');
+                turnsList.querySelectorAll('.save-item').forEach(item => {
+                    item.addEventListener('click', () => {
+                        const turn = parseInt(item.dataset.turn, 10);
+                        if (confirm(`Go back to the start of turn ${turn}? Everything played since is lost.`)) {
+                            this.loadTurn(turn);
+                        }
+                    });
+                });
+            })
+            .catch(error => {
+                console.error('Error fetching turns:', error);
+                section.classList.add('hidden');
+            });
+    }
+
+    // Take the game back to the start of an earlier turn
+    loadTurn(turn) {
+        fetch(Config.API.LOAD_TURN, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: JSON.stringify({ turn: turn })
+        })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                this.rejoinLoadedGame();
+            } else {
+                alert('Failed to load turn: ' + (data.error || 'Unknown error'));
+            }
+        })
+        .catch(error => {
+            console.error('Error loading turn:', error);
+            alert('Failed to load turn.');
+        });
+    }
+
+    // Upload a save file from another machine and list it with the others
+    importSave(file) {
+        fetch(Config.API.IMPORT_GAME, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json'
+            },
+            body: file
+        })
+        .then(response => response.json())
+        .then(data => {
+            if (data.success) {
+                this.showLoadModal();
+            } else {
+                alert('Failed to import save: ' + (data.error || 'Unknown error'));
+            }
+        })
+        .catch(error => {
+            console.error('Error importing save:', error);
+            alert('Failed to import save.');
+        });
+    }
+
     // Load game by filename
     loadGameByFilename(filename) {
         fetch(Config.API.LOAD_GAME, {
@@ -264,13 +703,7 @@ class UI {
         .then(response => response.json())
         .then(data => {
             if (data.success) {
-                document.getElementById('load-modal').classList.add('hidden');
-                // Reset first load flag so camera centers on units
-                isFirstLoad = true;
-                // Disconnect and reconnect websocket
-                gameSocket.disconnect();
-                gameSocket.connect();
-                this.showGameScreen();
+                this.rejoinLoadedGame();
             } else {
                 alert('Failed to load game: ' + (data.error || 'Unknown error'));
             }
@@ -281,6 +714,17 @@ class UI {
         });
     }
 
+    // Connect to the game the server has just loaded
+    rejoinLoadedGame() {
+        document.getElementById('load-modal').classList.add('hidden');
+        // Reset first load flag so camera centers on units
+        isFirstLoad = true;
+        // Disconnect and reconnect websocket
+        gameSocket.disconnect();
+        gameSocket.connect();
+        this.showGameScreen();
+    }
+
     // Show units gallery modal
     showUnitsGallery() {
         const modal = document.getElementById('units-modal');
@@ -325,10 +769,399 @@ class UI {
         modal.classList.remove('hidden');
     }
 
+    // Show where we stand among the civilizations: our own figures, and
+    // only the places of the others
+    showDemographics(demographics) {
+        const labels = {
+            population: 'Population',
+            land_area: 'Land Area',
+            military: 'Military',
+            gnp: 'GNP',
+            literacy: 'Literacy'
+        };
+        const ordinal = n => {
+            const suffix = (n % 100 >= 11 && n % 100 <= 13) ? 'th' : ({ 1: 'st', 2: 'nd', 3: 'rd' }[n % 10] || 'th');
+            return `${n}${suffix}`;
+        };
+
+        const rows = demographics.map(d => {
+            const others = d.ranks
+                .filter(r => r.player_id !== gameState.myPlayerId)
+                .map(r => {
+                    const player = r.player_id ? gameState.getPlayer(r.player_id) : null;
+                    return `${ordinal(r.rank)} ${player ? player.name : 'Unknown'}`;
+                })
+                .join(', ');
+            return `
+                <tr>
+                    <td>${labels[d.category] || d.category}</td>
+                    <td>${d.value}</td>
+                    <td>${ordinal(d.rank)} of ${d.ranks.length}</td>
+                    <td>${others}</td>
+                </tr>
+            `;
+        }).join('');
+
+        document.getElementById('demographics-table').innerHTML = `
+            <tr><th></th><th>Value</th><th>Rank</th><th>Others</th></tr>
+            ${rows}
+        `;
+        document.getElementById('demographics-modal').classList.remove('hidden');
+    }
+
+    // List our cities as the server sorted them; double-clicking one opens it
+    showEmpire(cities) {
+        const columns = [
+            ['name', 'City'], ['size', 'Size'], ['food', 'Food'], ['production', 'Building'],
+            ['turns', 'Turns'], ['happiness', 'Content / Unhappy'], ['garrison', 'Garrison']
+        ];
+        const headings = columns.map(([column, label]) => {
+            const arrow = this.empireSort.column === column ? (this.empireSort.descending ? ' ▼' : ' ▲') : '';
+            return `<th data-sort="${column}">${label}${arrow}</th>`;
+        }).join('');
+
+        const rows = cities.map(c => {
+            const mood = c.disorder ? ' (disorder)' : c.celebrating ? ' (celebrating)' : '';
+            return `
+                <tr data-city="${c.city_id}">
+                    <td>${c.city_name}</td>
+                    <td>${c.population}</td>
+                    <td>${c.food_surplus > 0 ? '+' : ''}${c.food_surplus}</td>
+                    <td>${c.production || 'Nothing'}</td>
+                    <td>${c.turns_left < 0 ? '-' : c.turns_left}</td>
+                    <td>${c.content} / ${c.unhappy}${mood}</td>
+                    <td>${c.garrison} (${c.garrison_strength})</td>
+                </tr>
+            `;
+        }).join('');
+
+        document.getElementById('empire-table').innerHTML = `<tr>${headings}</tr>${rows}`;
+    }
+
+    // Describe the tile last clicked, as the server sees it for us
+    showTileInfo(info) {
+        const tile = info.tile;
+        const owner = gameState.getPlayer(info.owner_id);
+        const improvements = [
+            tile.has_road && 'road', tile.has_mine && 'mine',
+            tile.has_irrigation && 'irrigation', tile.has_river && 'river'
+        ].filter(Boolean);
+        const units = info.units.map(u => {
+            const player = gameState.getPlayer(u.owner_id);
+            return `${u.name || u.type}${player ? ` (${player.name})` : ''}`;
+        });
+
+        document.getElementById('tile-info').innerHTML = `
+            <p><strong>${tile.terrain}</strong>${tile.resource ? ` with ${tile.resource}` : ''} (${tile.x}, ${tile.y})</p>
+            ${improvements.length ? `<p><span class="stat-label">Has:</span> ${improvements.join(', ')}</p>` : ''}
+            <p><span class="stat-label">Yields:</span> ${info.food} food, ${info.production} shields, ${info.trade} trade</p>
+            <p><span class="stat-label">Defense:</span> x${info.defense_bonus} | <span class="stat-label">Move cost:</span> ${info.movement_cost}</p>
+            ${owner ? `<p><span class="stat-label">Territory:</span> ${owner.name}</p>` : ''}
+            ${info.city_name ? `<p><span class="stat-label">City:</span> ${info.city_name}</p>` : ''}
+            ${units.length ? `<p><span class="stat-label">Units:</span> ${units.join(', ')}</p>` : ''}
+            ${info.unit_id ? `<p><span class="stat-label">Selected unit can:</span> ${info.actions.length ? info.actions.join(', ') : 'nothing here'}</p>` : ''}
+        `;
+        document.getElementById('tile-info-panel').classList.remove('hidden');
+    }
+
+    // List the other players with the deals we can make with them and the
+    // offers standing between us
+    showDiplomacy() {
+        const me = gameState.getMyPlayer();
+        if (!me) return;
+
+        const dealNames = { map_trade: 'Trade maps', shared_vision: 'Share vision' };
+        const dealName = offer => offer.deal === 'luxury'
+            ? `Share ${offer.resource}`
+            : dealNames[offer.deal] || offer.deal;
+        const canAct = gameState.isMyTurn();
+        const button = (label, action, player, deal = '', resource = '') =>
+            `<button class="btn-unit" data-action="${action}" data-player="${player.id}" data-deal="${deal}" data-resource="${resource}"${canAct ? '' : ' disabled'}>${label}</button>`;
+        // The luxuries we work more of than we already give away
+        const spare = ((gameState.happiness && gameState.happiness.luxuries) || [])
+            .filter(l => l.worked > (l.given || []).length);
+
+        const rows = gameState.players
+            .filter(p => p.id !== me.id && p.is_alive)
+            .map(p => {
+                const sharing = (me.shared_vision || []).includes(p.id);
+                const offered = deal => gameState.offers.some(o => o.from === me.id && o.to === p.id && o.deal === deal);
+                const buttons = [];
+                for (const deal of ['map_trade', 'shared_vision']) {
+                    if (offered(deal)) {
+                        buttons.push(button(`Withdraw ${dealNames[deal].toLowerCase()}`, 'withdraw', p, deal));
+                    } else if (deal !== 'shared_vision' || !sharing) {
+                        buttons.push(button(dealNames[deal], 'propose', p, deal));
+                    }
+                }
+                for (const l of spare) {
+                    const offer = { deal: 'luxury', resource: l.resource };
+                    if (gameState.offers.some(o => o.from === me.id && o.to === p.id && o.deal === 'luxury' && o.resource === l.resource)) {
+                        buttons.push(button(`Withdraw ${dealName(offer).toLowerCase()}`, 'withdraw', p, 'luxury', l.resource));
+                    } else if (!(l.given || []).includes(p.id)) {
+                        buttons.push(button(dealName(offer), 'propose', p, 'luxury', l.resource));
+                    }
+                }
+                if (sharing) {
+                    buttons.push(button('End pact', 'cancel', p));
+                }
+                for (const offer of gameState.offers.filter(o => o.from === p.id && o.to === me.id)) {
+                    buttons.push(`${dealName(offer)}?`);
+                    buttons.push(button('Accept', 'accept', p, offer.deal, offer.resource));
+                    buttons.push(button('Decline', 'decline', p, offer.deal, offer.resource));
+                }
+                return `
+                    <tr>
+                        <td style="color: ${p.color}">${p.name}</td>
+                        <td>${sharing ? 'Shared vision' : ''}</td>
+                        <td>${buttons.join(' ')}</td>
+                    </tr>
+                `;
+            }).join('');
+
+        document.getElementById('diplomacy-table').innerHTML = `
+            <tr><th>Player</th><th>Pact</th><th>Deals</th></tr>
+            ${rows}
+        `;
+        document.getElementById('diplomacy-modal').classList.remove('hidden');
+    }
+
+    // Show how content our cities are: the luxuries we have, worked, shared
+    // and received, and each city's content and unhappy citizens
+    showHappiness(happiness) {
+        const names = ids => (ids || []).map(id => {
+            const player = gameState.getPlayer(id);
+            return player ? player.name : id;
+        }).join(', ');
+
+        const luxuries = (happiness.luxuries || []).map(l => `
+            <tr>
+                <td>${l.resource}</td>
+                <td>${l.worked}</td>
+                <td>${names(l.given)}</td>
+                <td>${names(l.received)}</td>
+                <td>${l.has ? 'Yes' : 'No'}</td>
+            </tr>
+        `).join('');
+        document.getElementById('happiness-luxuries').innerHTML = `
+            <tr><th>Luxury</th><th>Worked</th><th>Shared with</th><th>Shared by</th><th>Ours</th></tr>
+            ${luxuries || '<tr><td colspan="5">No luxuries</td></tr>'}
+        `;
+
+        const cities = (happiness.cities || []).map(c => `
+            <tr>
+                <td>${c.city_name}</td>
+                <td>${c.population}</td>
+                <td>${c.content}</td>
+                <td>${c.unhappy}</td>
+                <td>${c.disorder ? 'Disorder' : c.celebrating ? 'Celebrating' : ''}</td>
+            </tr>
+        `).join('');
+        document.getElementById('happiness-table').innerHTML = `
+            <tr><th>City</th><th>Size</th><th>Content</th><th>Unhappy</th><th></th></tr>
+            ${cities}
+        `;
+        document.getElementById('happiness-modal').classList.remove('hidden');
+    }
+
+    // Keep the happiness window in step with the latest answer while it is
+    // open
+    refreshHappiness() {
+        if (gameState.happiness && !document.getElementById('happiness-modal').classList.contains('hidden')) {
+            this.showHappiness(gameState.happiness);
+        }
+    }
+
+    // Keep the diplomacy window in step with the game while it is open
+    refreshDiplomacy() {
+        if (!document.getElementById('diplomacy-modal').classList.contains('hidden')) {
+            this.showDiplomacy();
+        }
+    }
+
+    // Fetch the per-turn history of every player's standing and graph it
+    showStats() {
+        fetch(`${Config.API.STATS}?player=${encodeURIComponent(gameState.myPlayerId)}`)
+            .then(response => response.json())
+            .then(data => {
+                this.stats = data;
+                document.getElementById('stats-modal').classList.remove('hidden');
+                this.drawStatsChart();
+            })
+            .catch(error => {
+                console.error('Error loading statistics:', error);
+                this.showError('Failed to load statistics');
+            });
+    }
+
+    // Draw one line per player for the chosen metric
+    drawStatsChart() {
+        if (!this.stats) return;
+
+        const metric = document.getElementById('stats-metric').value;
+        const canvas = document.getElementById('stats-chart');
+        const ctx = canvas.getContext('2d');
+        const history = this.stats.history;
+        const pad = 30;
+
+        ctx.clearRect(0, 0, canvas.width, canvas.height);
+        if (history.length === 0) return;
+
+        let max = 1;
+        history.forEach(turn => turn.players.forEach(p => {
+            max = Math.max(max, p[metric]);
+        }));
+        const firstTurn = history[0].turn;
+        const turns = Math.max(1, history[history.length - 1].turn - firstTurn);
+        const x = turn => pad + (turn - firstTurn) / turns * (canvas.width - 2 * pad);
+        const y = value => canvas.height - pad - value / max * (canvas.height - 2 * pad);
+
+        // Axes with the highest value and the turn range
+        ctx.strokeStyle = '#8b7355';
+        ctx.fillStyle = '#c4a35a';
+        ctx.font = '11px sans-serif';
+        ctx.beginPath();
+        ctx.moveTo(pad, pad);
+        ctx.lineTo(pad, canvas.height - pad);
+        ctx.lineTo(canvas.width - pad, canvas.height - pad);
+        ctx.stroke();
+        ctx.fillText(String(max), 4, pad);
+        ctx.fillText(`Turn ${firstTurn}`, pad, canvas.height - 10);
+        ctx.fillText(`Turn ${history[history.length - 1].turn}`, canvas.width - pad - 40, canvas.height - 10);
+
+        // Before the game is over only our own standing is sent
+        this.stats.players.forEach(player => {
+            if (!history.some(turn => turn.players.some(p => p.player_id === player.id))) return;
+            ctx.strokeStyle = player.color;
+            ctx.lineWidth = 2;
+            ctx.beginPath();
+            history.forEach((turn, j) => {
+                const standing = turn.players.find(p => p.player_id === player.id);
+                const value = standing ? standing[metric] : 0;
+                if (j === 0) {
+                    ctx.moveTo(x(turn.turn), y(value));
+                } else {
+                    ctx.lineTo(x(turn.turn), y(value));
+                }
+            });
+            ctx.stroke();
+        });
+        ctx.lineWidth = 1;
+
+        document.getElementById('stats-legend').innerHTML = this.stats.players.map(p => `
+            <span class="stats-legend-item">
+                <span class="stats-legend-swatch" style="background: ${p.color}"></span>${p.name}${p.is_alive ? '' : ' (destroyed)'}
+            </span>
+        `).join('');
+
+        // Cities taken, with the gold plundered from them
+        const name = id => (this.stats.players.find(p => p.id === id) || { name: id }).name;
+        document.getElementById('stats-captures').innerHTML = history.flatMap(turn => (turn.captures || []).map(c => `
+            <li>Turn ${turn.turn}: ${name(c.to)} captured ${c.city_name} from ${name(c.from)}, plundering ${c.plunder} gold</li>
+        `)).join('');
+
+        // What the AI players get at the game's difficulty
+        const handicap = this.stats.handicap;
+        const bonuses = [];
+        if (handicap.production_percent) bonuses.push(`+${handicap.production_percent}% production`);
+        if (handicap.free_units.length) bonuses.push(`free ${handicap.free_units.join(', ')}`);
+        if (handicap.combat_percent) bonuses.push(`veteran units (+${handicap.combat_percent}% in combat)`);
+        document.getElementById('stats-handicap').textContent =
+            `AI difficulty ${handicap.difficulty}: ${bonuses.length ? bonuses.join(', ') : 'no handicaps'}`;
+    }
+
+    // Start picking a patrol route, or send the route if one is being picked
+    togglePatrol() {
+        if (!gameState.selectedUnit) return;
+
+        if (gameState.mode !== 'patrol') {
+            gameState.startPatrol();
+        } else if (gameState.patrolWaypoints.length >= 2) {
+            gameSocket.patrolUnit(gameState.selectedUnit.id, gameState.patrolWaypoints);
+            gameState.setMode('normal');
+        } else {
+            gameState.setMode('normal');
+        }
+        this.updateModeButtons();
+    }
+
+    // Wake the selected unit if it is fortified or on orders, otherwise fortify it
+    toggleFortify() {
+        const unit = gameState.selectedUnit;
+        if (!unit) return;
+
+        if (gameState.isAsleep(unit)) {
+            gameSocket.wakeUnit(unit.id);
+        } else if (!unit.can_found_city) {
+            gameSocket.fortifyUnit(unit.id);
+        }
+    }
+
+    // Enter or leave bombard mode for the selected siege unit
+    toggleBombard() {
+        const unit = gameState.selectedUnit;
+        if (!unit || !unit.can_bombard || !gameState.canUnitMove(unit)) return;
+
+        if (unit.cooldown > 0) {
+            this.showError(`Reloading, can bombard again in ${unit.cooldown} turn${unit.cooldown > 1 ? 's' : ''}`);
+            return;
+        }
+
+        gameState.setMode(gameState.mode === 'bombard' ? 'select' : 'bombard');
+        this.updateModeButtons();
+    }
+
+    // Enter or leave targeting mode for the selected nuclear unit
+    toggleNuke() {
+        const unit = gameState.selectedUnit;
+        if (!unit || !unit.can_nuke || !gameState.canUnitMove(unit)) return;
+
+        gameState.setMode(gameState.mode === 'nuke' ? 'select' : 'nuke');
+        this.updateModeButtons();
+    }
+
+    // Take the selected unit out of its group, or group it with the other
+    // units on its tile
+    toggleGroup() {
+        const unit = gameState.selectedUnit;
+        if (!unit) return;
+
+        if (unit.group_id) {
+            gameSocket.removeFromGroup(unit.id);
+            return;
+        }
+
+        const others = gameState.getMyUnitsAt(unit.x, unit.y).filter(u => u.id !== unit.id);
+        if (others.length === 0) {
+            this.showError('No other units on this tile to group with');
+            return;
+        }
+
+        // Join a group already on the tile, otherwise stack everything here
+        const grouped = others.find(u => u.group_id);
+        if (grouped) {
+            gameSocket.addToGroup(grouped.group_id, unit.id);
+        } else {
+            gameSocket.createGroup([unit.id, ...others.map(u => u.id)]);
+        }
+    }
+
     // Try to end turn with confirmation if there are active units
     tryEndTurn() {
         if (!gameState.isMyTurn()) return;
 
+        // Production required mode: open the first city without orders
+        const status = gameState.turnStatus;
+        if (status && !status.can_end_turn) {
+            const city = gameState.getCity(status.cities_without_production[0]);
+            this.showError('All cities need a production order before ending the turn');
+            if (city) {
+                gameState.selectCity(city);
+                this.showCityModal(city);
+            }
+            return;
+        }
+
         const activeCount = gameState.getActiveUnitsCount();
         if (activeCount > 0) {
             const message = activeCount === 1
@@ -347,7 +1180,23 @@ class UI {
         const playerName = document.getElementById('player-name').value || 'Player';
         const mapSize = document.getElementById('map-size').value;
         const mapType = document.getElementById('map-type').value;
+        const trueStarts = mapType === 'earth' && document.getElementById('true-starts').value === 'true';
         const opponents = parseInt(document.getElementById('opponents').value);
+        const aiDifficulty = document.getElementById('ai-difficulty').value;
+        const speed = document.getElementById('game-speed').value;
+        const locale = document.getElementById('locale').value;
+        const humanPlayers = parseInt(document.getElementById('human-players').value);
+        const simultaneousTurns = document.getElementById('simultaneous-turns').value === 'true';
+        const productionRequired = document.getElementById('production-required').value === 'true';
+        const randomEvents = document.getElementById('random-events').value === 'true';
+        const plunderPercent = parseInt(document.getElementById('plunder-percent').value);
+        const withdraw = document.getElementById('withdraw').value === 'true';
+        const advancedStart = parseInt(document.getElementById('advanced-start').value);
+        const regicide = document.getElementById('regicide').value === 'true';
+        const advisor = document.getElementById('advisor').value === 'true';
+        const scenario = document.getElementById('scenario').value;
+        const asyncPolicy = document.getElementById('async-game').value;
+        const turnTimeout = parseInt(document.getElementById('turn-timeout').value);
 
         let size = Config.MAP_SIZES[mapSize];
 
@@ -359,10 +1208,27 @@ class UI {
         const config = {
             map_width: size.width,
             map_height: size.height,
-            player_count: opponents + 1,
+            player_count: opponents + humanPlayers,
+            human_players: humanPlayers,
+            simultaneous_turns: simultaneousTurns,
             player_name: playerName,
             map_type: mapType,
-            seed: 0
+            true_starts: trueStarts,
+            seed: 0,
+            production_required: productionRequired,
+            random_events: randomEvents,
+            plunder_percent: plunderPercent,
+            withdraw: withdraw,
+            advanced_start: advancedStart,
+            regicide: regicide,
+            advisor: advisor,
+            scenario: scenario,
+            async: asyncPolicy !== '',
+            inactivity_policy: asyncPolicy,
+            turn_timeout: turnTimeout,
+            ai_difficulty: aiDifficulty,
+            speed: speed,
+            locale: locale
         };
 
         // Create new game via API
@@ -373,7 +1239,12 @@ class UI {
             },
             body: JSON.stringify(config)
         })
-        .then(response => response.json())
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
         .then(data => {
             // Connect WebSocket
             gameSocket.connect();
@@ -381,7 +1252,7 @@ class UI {
         })
         .catch(error => {
             console.error('Error creating game:', error);
-            alert('Failed to create game. Please try again.');
+            alert(`Failed to create game: ${error.message}`);
         });
     }
 
@@ -406,20 +1277,51 @@ class UI {
     updateTopBar() {
         this.turnNumber.textContent = `Turn ${gameState.turn}`;
 
-        if (gameState.isMyTurn()) {
+        if (gameState.paused) {
+            this.currentPlayer.textContent = 'Paused by the host';
+            this.currentPlayer.style.color = '#ffcc00';
+            this.endTurnBtn.disabled = true;
+        } else if (gameState.isMyTurn()) {
             this.currentPlayer.textContent = 'Your Turn';
             this.currentPlayer.style.color = '#00ff00';
             this.endTurnBtn.disabled = false;
+
+            const status = gameState.turnStatus;
+            if (status && !status.can_end_turn) {
+                this.endTurnBtn.textContent = 'Set Production';
+            } else {
+                this.endTurnBtn.textContent = gameState.isSimultaneous() ? 'Submit Orders' : 'End Turn';
+            }
+        } else if (gameState.isSimultaneous()) {
+            this.currentPlayer.textContent = 'Waiting for other players...';
+            this.currentPlayer.style.color = '#ffcc00';
+            this.endTurnBtn.disabled = true;
+            this.endTurnBtn.textContent = 'Orders Submitted';
         } else {
             const currentPlayer = gameState.getPlayer(gameState.currentPlayerId);
             this.currentPlayer.textContent = currentPlayer ? `${currentPlayer.name}'s Turn` : 'Waiting...';
             this.currentPlayer.style.color = '#ff6666';
             this.endTurnBtn.disabled = true;
+            this.endTurnBtn.textContent = 'End Turn';
         }
 
         const myPlayer = gameState.getMyPlayer();
         if (myPlayer) {
-            this.goldDisplay.textContent = `Gold: ${myPlayer.gold}`;
+            const budget = myPlayer.budget;
+            const net = budget ? ` (${budget.net >= 0 ? '+' : ''}${budget.net})` : '';
+            this.goldDisplay.textContent = `Gold: ${myPlayer.gold}${net}`;
+            this.goldDisplay.title = budget ?
+                `Taxes ${budget.tax_gold}, trade routes ${budget.trade_route_gold}, ` +
+                `building upkeep ${budget.building_upkeep}, unit upkeep ${budget.unit_upkeep}` : '';
+            this.scienceDisplay.textContent = `Science: ${myPlayer.science}` + (budget ? ` (+${budget.science})` : '');
+            if (document.activeElement !== this.taxRate) {
+                this.taxRate.value = String(myPlayer.tax_rate);
+            }
+
+            const points = myPlayer.start_points || 0;
+            this.startPoints.textContent = `Start Points: ${points}`;
+            this.startPoints.classList.toggle('hidden', points === 0);
+            this.buyTechBtn.classList.toggle('hidden', points === 0);
         }
     }
 
@@ -430,13 +1332,18 @@ class UI {
             const isMine = unit.owner_id === gameState.myPlayerId;
 
             this.selectionInfo.innerHTML = `
-                <p><strong>${unit.type}</strong></p>
+                <p><strong>${unit.name || unit.type}${unit.honorific ? ` ${unit.honorific}` : ''}</strong>${unit.name ? ` (${unit.type})` : ''}</p>
                 <p><span class="stat-label">Owner:</span> ${owner ? owner.name : 'Unknown'}</p>
                 <p><span class="stat-label">Attack:</span> ${unit.attack} | <span class="stat-label">Defense:</span> ${unit.defense}</p>
                 <p><span class="stat-label">Movement:</span> ${unit.movement_left}</p>
-                <p><span class="stat-label">Health:</span> ${unit.health}%</p>
-                ${unit.is_veteran ? '<p>Veteran</p>' : ''}
+                <p><span class="stat-label">Health:</span> ${unit.health}/${unit.max_health}${unit.healing > 0 ? ` (+${unit.healing}/turn)` : ''}</p>
+                ${unit.is_veteran ? `<p>Veteran${unit.veteran_origin === 'barracks' ? ' (barracks)' : unit.veteran_origin === 'combat' ? ' (combat)' : unit.veteran_origin === 'handicap' ? ' (handicap)' : ''}</p>` : ''}
+                ${unit.xp > 0 ? `<p><span class="stat-label">Battles won:</span> ${unit.xp}</p>` : ''}
                 ${unit.is_fortified ? '<p>Fortified</p>' : ''}
+                ${unit.mode && unit.mode !== 'none' ? `<p><span class="stat-label">Orders:</span> ${unit.mode}</p>` : ''}
+                ${this.jobText(gameState.getTile(unit.x, unit.y))}
+                ${unit.cooldown > 0 ? `<p><span class="stat-label">Reloading:</span> ${unit.cooldown} turn${unit.cooldown > 1 ? 's' : ''}</p>` : ''}
+                ${unit.group_id && isMine ? `<p><span class="stat-label">Group:</span> ${gameState.getGroupMembers(unit).length} units</p>` : ''}
             `;
 
             // Show unit actions if it's my unit and my turn
@@ -446,13 +1353,26 @@ class UI {
                 // Show/hide found city button
                 const foundCityBtn = document.getElementById('btn-found-city');
                 const buildRoadBtn = document.getElementById('btn-build-road');
+                const autoWorkBtn = document.getElementById('btn-auto-work');
                 if (unit.can_found_city) {
                     foundCityBtn.classList.remove('hidden');
                     buildRoadBtn.classList.remove('hidden');
+                    autoWorkBtn.classList.remove('hidden');
                 } else {
                     foundCityBtn.classList.add('hidden');
                     buildRoadBtn.classList.add('hidden');
+                    autoWorkBtn.classList.add('hidden');
                 }
+                const tile = gameState.getTile(unit.x, unit.y);
+                document.querySelectorAll('.btn-job').forEach(btn => {
+                    btn.classList.toggle('hidden', !unit.can_found_city || !gameState.canDoJob(tile, btn.dataset.job));
+                });
+                document.getElementById('btn-bombard').classList.toggle('hidden', !unit.can_bombard);
+                document.getElementById('btn-nuke').classList.toggle('hidden', !unit.can_nuke);
+                document.getElementById('btn-help-wonder').classList.toggle('hidden', !unit.can_help_wonder);
+                const myPlayer = gameState.getMyPlayer();
+                document.getElementById('btn-buy-city').classList.toggle('hidden',
+                    !myPlayer || !myPlayer.start_points || gameState.getCityAt(unit.x, unit.y) !== null);
 
                 this.updateModeButtons();
             } else {
@@ -486,23 +1406,41 @@ class UI {
 
         moveBtn.classList.toggle('active', gameState.mode === 'move');
         attackBtn.classList.toggle('active', gameState.mode === 'attack');
+        document.getElementById('btn-bombard').classList.toggle('active', gameState.mode === 'bombard');
+        document.getElementById('btn-nuke').classList.toggle('active', gameState.mode === 'nuke');
+        document.getElementById('btn-patrol').classList.toggle('active', gameState.mode === 'patrol');
+        document.getElementById('btn-group').textContent =
+            gameState.selectedUnit && gameState.selectedUnit.group_id ? 'Ungroup' : 'Group';
 
         // Disable buttons if unit has no movement left
         const unit = gameState.selectedUnit;
         const hasMovement = unit && unit.movement_left > 0;
-        const canAct = hasMovement && !unit.is_fortified;
+        const canAct = hasMovement;
+        const asleep = unit && gameState.isAsleep(unit);
 
         moveBtn.disabled = !canAct;
         attackBtn.disabled = !canAct;
-        fortifyBtn.disabled = !canAct || unit.can_found_city; // Settlers can't fortify
+        document.getElementById('btn-bombard').disabled = !canAct || unit.cooldown > 0;
+        document.getElementById('btn-nuke').disabled = !canAct;
+        document.getElementById('btn-help-wonder').disabled = !canAct || !gameState.canHelpWonder();
+        fortifyBtn.textContent = asleep ? 'Wake' : 'Fortify';
+        fortifyBtn.disabled = !asleep && (!canAct || unit.can_found_city); // Settlers can't fortify
         skipBtn.disabled = !hasMovement;
 
         if (unit && unit.can_found_city) {
             foundCityBtn.disabled = !canAct || !gameState.canFoundCity();
             buildRoadBtn.disabled = !canAct;
+            document.querySelectorAll('.btn-job').forEach(btn => { btn.disabled = !canAct; });
         }
     }
 
+    // Describe the terrain job under way on a tile, if any
+    jobText(tile) {
+        if (!tile || !tile.job) return '';
+        const names = { clear_forest: 'Clearing forest', plant_forest: 'Planting forest', mine: 'Digging a mine' };
+        return `<p><span class="stat-label">${names[tile.job] || tile.job}:</span> ${tile.job_progress || 0}/${tile.job_turns} turns</p>`;
+    }
+
     showCityModal(city) {
         this.cityName.textContent = city.name;
         this.cityPop.textContent = city.population;
@@ -510,6 +1448,21 @@ class UI {
         this.cityFoodNeeded.textContent = city.food_needed;
         this.cityProd.textContent = city.production;
         this.cityProdNeeded.textContent = city.production_needed || 0;
+        this.cityDefense.textContent = Math.max(0, city.max_defense - (city.damage || 0));
+        this.cityDefenseMax.textContent = city.max_defense;
+        document.getElementById('city-connected').textContent = city.connected ? 'Yes' : 'No';
+        document.getElementById('city-corruption').textContent = city.corruption || 0;
+        document.getElementById('city-trade-lost').textContent = city.trade_lost || 0;
+        document.getElementById('city-waste').textContent = city.waste || 0;
+        document.getElementById('city-shields-lost').textContent = city.shields_lost || 0;
+
+        // Ranged strike, once per turn, when an enemy is next to my city
+        const strikeBtn = document.getElementById('city-strike-btn');
+        const canStrike = city.owner_id === gameState.myPlayerId && gameState.isMyTurn() && !city.struck;
+        strikeBtn.classList.toggle('hidden', !canStrike || !gameState.hasAdjacentEnemy(city.x, city.y));
+
+        const renameBtn = document.getElementById('city-rename-btn');
+        renameBtn.classList.toggle('hidden', city.owner_id !== gameState.myPlayerId || !gameState.isMyTurn());
 
         // Buildings list
         this.cityBuildingList.innerHTML = '';
@@ -531,6 +1484,10 @@ class UI {
         if (city.owner_id === gameState.myPlayerId) {
             // Units
             Config.PRODUCTION_OPTIONS.units.forEach(unit => {
+                if (unit.requires && !gameState.wonderBuilt(unit.requires)) {
+                    return; // Needs a wonder nobody has built yet
+                }
+
                 const btn = document.createElement('button');
                 btn.className = 'production-btn';
                 if (city.current_build && city.current_build.is_unit &&
@@ -553,6 +1510,12 @@ class UI {
                 if (city.buildings && city.buildings.includes(building.name)) {
                     return; // Already built
                 }
+                if (building.wonder && gameState.wonderBuilt(building.name)) {
+                    return; // Another city has the wonder
+                }
+                if (building.coastal && !city.coastal) {
+                    return; // Inland
+                }
 
                 const btn = document.createElement('button');
                 btn.className = 'production-btn';
@@ -572,6 +1535,32 @@ class UI {
             });
         }
 
+        // Units bought with start points appear in the city at once
+        const buyOptions = document.getElementById('buy-options');
+        const myPlayer = gameState.getMyPlayer();
+        const canBuy = city.owner_id === gameState.myPlayerId && myPlayer && myPlayer.start_points > 0;
+        buyOptions.innerHTML = '';
+        document.getElementById('city-buy').classList.toggle('hidden', !canBuy);
+        if (canBuy) {
+            Config.PRODUCTION_OPTIONS.units.forEach(unit => {
+                if (unit.requires && !gameState.wonderBuilt(unit.requires)) {
+                    return;
+                }
+
+                const btn = document.createElement('button');
+                btn.className = 'production-btn';
+                btn.innerHTML = `
+                    <div class="name">${unit.name}</div>
+                    <div class="cost">Points: ${unit.cost}</div>
+                `;
+                btn.addEventListener('click', () => {
+                    gameSocket.buyStart('unit', city.x, city.y, unit.type);
+                    this.hideCityModal();
+                });
+                buyOptions.appendChild(btn);
+            });
+        }
+
         this.cityModal.classList.remove('hidden');
     }
 
@@ -594,6 +1583,233 @@ class UI {
         this.gameOverModal.classList.add('hidden');
     }
 
+    // Fetch the entries of the game log we may read, replacing those shown
+    loadGameLog() {
+        const player = gameState.myPlayerId ? `?player=${encodeURIComponent(gameState.myPlayerId)}` : '';
+        fetch(Config.API.GAME_LOG + player)
+            .then(response => response.json())
+            .then(entries => {
+                document.getElementById('game-log').replaceChildren();
+                entries.forEach(entry => this.addLogEntry(entry));
+            })
+            .catch(error => console.error('Error loading the game log:', error));
+    }
+
+    // Add an entry to the game log, keeping the last hundred
+    addLogEntry(entry) {
+        const log = document.getElementById('game-log');
+        const item = document.createElement('li');
+        item.textContent = `Turn ${entry.turn}: ${entry.text}`;
+        log.appendChild(item);
+        while (log.children.length > 100) {
+            log.firstChild.remove();
+        }
+        log.scrollTop = log.scrollHeight;
+    }
+
+    // Tell how many units of each player moved outside our viewport of a
+    // large map, until the next such news
+    showActivity(activity) {
+        const parts = activity.map(a => {
+            const player = gameState.getPlayer(a.player_id);
+            const counts = [];
+            if (a.moved) counts.push(`${a.moved} moved`);
+            if (a.appeared) counts.push(`${a.appeared} new`);
+            if (a.gone) counts.push(`${a.gone} gone`);
+            return `${player ? player.name : a.player_id}: ${counts.join(', ')}`;
+        });
+
+        const element = document.getElementById('viewport-activity');
+        element.textContent = parts.length > 0 ? `Out of view: ${parts.join('; ')}` : '';
+        clearTimeout(this.activityTimer);
+        this.activityTimer = setTimeout(() => { element.textContent = ''; }, 10000);
+    }
+
+    // Tell of a city growing, and of the food its granary kept
+    grewText(city) {
+        const text = `${city.city_name} grew to size ${city.population}`;
+        return city.granary ? `${text}; its granary kept ${city.granary} food` : text;
+    }
+
+    // Tell of a technology discovered, and of the resources it revealed
+    revealedText(revealed) {
+        const text = `Your scholars discovered ${revealed.tech}`;
+        return revealed.resources.length ? `${text}; ${revealed.resources.join(', ')} can now be seen` : text;
+    }
+
+    // Show a short notice over the map, which fades after a few seconds,
+    // unless notices of its kind are muted
+    showNotice(text, kind) {
+        if (kind && gameState.preferences.muted_notices.includes(kind)) {
+            return;
+        }
+        const notice = document.createElement('div');
+        notice.className = 'notice';
+        notice.textContent = text;
+        this.notices.appendChild(notice);
+        setTimeout(() => notice.remove(), 6000);
+    }
+
+    // Show the advisor's most pressing hints as notices
+    showAdvice(advice) {
+        advice.hints.slice(0, 3).forEach(hint => {
+            this.showNotice(`Advisor: ${hint.message}`);
+        });
+    }
+
+    // Show what happened since the player's last turn
+    showTurnSummary(summary) {
+        const lines = [];
+
+        summary.cities_grown.forEach(c => {
+            lines.push(this.grewText(c));
+        });
+        summary.cities_starved.forEach(c => {
+            lines.push(`${c.city_name} is starving, down to size ${c.population}`);
+        });
+        summary.completed.forEach(c => {
+            lines.push(`${c.city_name} completed ${c.item}`);
+        });
+        summary.combats_against.forEach(c => {
+            if (c.bombard) {
+                const target = c.defender_unit ? `our ${c.defender_unit}` : 'our city';
+                const attacker = c.attacker_city ? `city of ${c.attacker_city}` : c.attacker_unit;
+                let line = `${c.attacker_name} ${attacker} bombarded ${target} at (${c.x},${c.y})`;
+                if (c.defender_won) {
+                    line += ' and missed';
+                } else {
+                    const hurt = [];
+                    if (c.damage) hurt.push(`${c.damage} damage`);
+                    if (c.city_damage) hurt.push(`${c.city_damage} to the city defenses`);
+                    line += hurt.length ? `, dealing ${hurt.join(' and ')}` : ' to no effect';
+                }
+                lines.push(line);
+                return;
+            }
+            if (!c.defender_unit) {
+                let line = `${c.attacker_name} ${c.attacker_unit} stormed our city at (${c.x},${c.y})`;
+                if (c.city_lost) {
+                    line += ` and captured ${c.city_lost}`;
+                } else if (c.defender_won) {
+                    line += ` and was repelled after dealing ${c.city_damage || 0} damage`;
+                }
+                lines.push(line);
+                return;
+            }
+            let line = `${c.attacker_name} ${c.attacker_unit} attacked our ${c.defender_unit} at (${c.x},${c.y})`;
+            if (c.defender_won) {
+                line += c.withdrew ? ' and withdrew' : ' and was defeated';
+            } else if (c.withdrew) {
+                line += ', our unit withdrew';
+            } else if (c.unit_lost) {
+                line += ' and destroyed it';
+                if (c.stack_lost) {
+                    line += ` along with ${c.stack_lost} stacked unit${c.stack_lost > 1 ? 's' : ''}`;
+                }
+            } else {
+                line += ' and won';
+            }
+            if (c.city_lost) {
+                line += `, ${c.city_lost} was captured`;
+            }
+            lines.push(line);
+        });
+        (summary.cities_in_disorder || []).forEach(c => {
+            lines.push(`${c.city_name} is in disorder: its unhappy citizens produce nothing`);
+        });
+        (summary.cities_celebrating || []).forEach(c => {
+            lines.push(`${c.city_name} celebrated We Love the King Day, its tiles yielding more trade`);
+        });
+        if (summary.trade_gold) {
+            lines.push(`Trade routes to the capital earned ${summary.trade_gold} gold`);
+        }
+        if (summary.economy) {
+            summary.economy.buildings_sold.forEach(s => {
+                lines.push(`${s.city_name} sold its ${s.item}, as the treasury could not pay its upkeep`);
+            });
+            summary.economy.units_disbanded.forEach(n => {
+                lines.push(`${n.unit_type} at (${n.x},${n.y}) was disbanded, as the treasury could not pay its upkeep`);
+            });
+            (summary.economy.discoveries || []).forEach(tech => {
+                lines.push(`Your scholars discovered ${tech}`);
+            });
+        }
+        summary.scenario_notices.forEach(n => {
+            const player = gameState.getPlayer(n.player_id);
+            const name = player ? player.name : '';
+            if (n.message) {
+                lines.push(n.message);
+            } else if (n.outcome === 'victory') {
+                lines.push(`${name} has won the scenario`);
+            } else if (n.outcome === 'defeat') {
+                lines.push(`${name} has been defeated`);
+            }
+        });
+        summary.random_events.forEach(e => {
+            switch (e.kind) {
+                case 'plague':
+                    lines.push(`Plague struck ${e.city_name}, down to size ${e.population}`);
+                    break;
+                case 'harvest':
+                    lines.push(`A good harvest brought ${e.city_name} ${e.food} extra food`);
+                    break;
+                case 'earthquake':
+                    lines.push(`An earthquake destroyed the ${e.building} in ${e.city_name}`);
+                    break;
+                case 'eruption': {
+                    let line = `The volcano at (${e.x},${e.y}) erupted near ${e.city_name}, down to size ${e.population}`;
+                    if (e.units_lost) line += `, ${e.units_lost} unit(s) lost`;
+                    if (e.tiles_changed) line += `, ${e.tiles_changed} tile(s) buried`;
+                    lines.push(line);
+                    break;
+                }
+                case 'flood': {
+                    let line = `The river flooded ${e.city_name}, spoiling ${e.food} food`;
+                    if (e.tiles_changed) line += ` and washing out ${e.tiles_changed} irrigated tile(s)`;
+                    lines.push(line);
+                    break;
+                }
+            }
+        });
+        summary.detonations.forEach(d => {
+            let line = `${d.attacker_name} detonated a nuclear weapon at (${d.x},${d.y})`;
+            const losses = [];
+            if (d.units_lost) losses.push(`${d.units_lost} of our unit${d.units_lost > 1 ? 's' : ''}`);
+            d.cities_hit.forEach(c => losses.push(`half of ${c.city_name}, now size ${c.population}`));
+            if (losses.length) line += `, destroying ${losses.join(', ')}`;
+            lines.push(line);
+        });
+        summary.resources_discovered.forEach(r => {
+            lines.push(`${r.city_name} can use ${r.resource} at (${r.x},${r.y})`);
+        });
+        const wakeReasons = {
+            enemy_sighted: 'sighted an enemy',
+            nothing_to_explore: 'has nothing left to explore',
+            no_work: 'has no work left to do',
+            patrol_blocked: 'cannot continue its patrol',
+            job_done: 'has finished its work',
+            order_collided: 'ran into another unit moving to the same tile',
+            order_blocked: 'found its way blocked',
+            order_failed: 'could not carry out its orders'
+        };
+        summary.units_woken.forEach(n => {
+            lines.push(`${n.unit_type} at (${n.x},${n.y}) ${wakeReasons[n.reason] || n.reason} and awaits orders`);
+        });
+
+        if (lines.length === 0) return;
+
+        const list = document.getElementById('turn-summary-list');
+        list.innerHTML = '';
+        lines.forEach(text => {
+            const item = document.createElement('li');
+            item.textContent = text;
+            list.appendChild(item);
+        });
+
+        document.getElementById('turn-summary-title').textContent = `Turn ${gameState.turn} Summary`;
+        document.getElementById('turn-summary-modal').classList.remove('hidden');
+    }
+
     showError(message) {
         console.error(message);
         // Could add a toast notification here
@@ -10,6 +10,14 @@ class GameWebSocket {
             onUpdate: null,
             onTurnChange: null,
             onCombatResult: null,
+            onEvent: null,
+            onQueryResult: null,
+            onTurnSummary: null,
+            onAdvice: null,
+            onTurnStatus: null,
+            onWelcome: null,
+            onHostState: null,
+            onPreferences: null,
             onError: null,
             onConnect: null,
             onDisconnect: null
@@ -21,7 +29,7 @@ class GameWebSocket {
             return;
         }
 
-        this.ws = new WebSocket(Config.API.WEBSOCKET);
+        this.ws = new WebSocket(`${Config.API.WEBSOCKET}?profile=${encodeURIComponent(this.profile())}`);
 
         this.ws.onopen = () => {
             console.log('WebSocket connected');
@@ -32,12 +40,24 @@ class GameWebSocket {
             }
         };
 
-        this.ws.onclose = () => {
+        this.ws.onclose = (event) => {
             console.log('WebSocket disconnected');
             this.connected = false;
             if (this.callbacks.onDisconnect) {
                 this.callbacks.onDisconnect();
             }
+
+            // Do not come back after the host has kicked us
+            if (event.code === 1008) {
+                if (this.callbacks.onError) {
+                    this.callbacks.onError({ code: 'kicked', message: event.reason });
+                }
+                return;
+            }
+            // Too slow to keep up: come back to a fresh state
+            if (event.code === 4008 && this.callbacks.onError) {
+                this.callbacks.onError({ code: 'too_slow', message: 'Connection too slow, reconnecting...' });
+            }
             this.attemptReconnect();
         };
 
@@ -50,6 +70,25 @@ class GameWebSocket {
         };
     }
 
+    // The key our preferences are kept by on the server, made up on first
+    // use. Entering the same key on another device brings them along.
+    profile() {
+        let profile = localStorage.getItem('yac_profile');
+        if (!profile) {
+            profile = crypto.randomUUID();
+            localStorage.setItem('yac_profile', profile);
+        }
+        return profile;
+    }
+
+    // Switch to another profile, and reconnect to be sent its preferences
+    setProfile(profile) {
+        localStorage.setItem('yac_profile', profile);
+        if (this.ws) {
+            this.ws.close();
+        }
+    }
+
     attemptReconnect() {
         if (this.reconnectAttempts < this.maxReconnectAttempts) {
             this.reconnectAttempts++;
@@ -87,6 +126,54 @@ class GameWebSocket {
                     }
                     break;
 
+                case 'event':
+                    if (this.callbacks.onEvent) {
+                        this.callbacks.onEvent(message.payload);
+                    }
+                    break;
+
+                case 'query_result':
+                    if (this.callbacks.onQueryResult) {
+                        this.callbacks.onQueryResult(message.payload);
+                    }
+                    break;
+
+                case 'turn_summary':
+                    if (this.callbacks.onTurnSummary) {
+                        this.callbacks.onTurnSummary(message.payload);
+                    }
+                    break;
+
+                case 'advice':
+                    if (this.callbacks.onAdvice) {
+                        this.callbacks.onAdvice(message.payload);
+                    }
+                    break;
+
+                case 'turn_status':
+                    if (this.callbacks.onTurnStatus) {
+                        this.callbacks.onTurnStatus(message.payload);
+                    }
+                    break;
+
+                case 'welcome':
+                    if (this.callbacks.onWelcome) {
+                        this.callbacks.onWelcome(message.payload);
+                    }
+                    break;
+
+                case 'host_state':
+                    if (this.callbacks.onHostState) {
+                        this.callbacks.onHostState(message.payload);
+                    }
+                    break;
+
+                case 'preferences':
+                    if (this.callbacks.onPreferences) {
+                        this.callbacks.onPreferences(message.payload);
+                    }
+                    break;
+
                 case 'error':
                     console.error('Server error:', message.payload);
                     if (this.callbacks.onError) {
@@ -125,7 +212,12 @@ class GameWebSocket {
         });
     }
 
+    // In the simultaneous phase moves and attacks are planned, and carried
+    // out once every player has submitted
     moveUnit(unitId, toX, toY) {
+        if (gameState.isSimultaneous()) {
+            return this.planOrder(unitId, 'move', toX, toY);
+        }
         return this.sendAction('move', {
             unit_id: unitId,
             to_x: toX,
@@ -134,6 +226,9 @@ class GameWebSocket {
     }
 
     attackUnit(attackerId, targetX, targetY) {
+        if (gameState.isSimultaneous()) {
+            return this.planOrder(attackerId, 'attack', targetX, targetY);
+        }
         return this.sendAction('attack', {
             attacker_id: attackerId,
             target_x: targetX,
@@ -141,6 +236,30 @@ class GameWebSocket {
         });
     }
 
+    bombard(unitId, targetX, targetY) {
+        return this.sendAction('bombard', {
+            unit_id: unitId,
+            target_x: targetX,
+            target_y: targetY
+        });
+    }
+
+    nuke(unitId, targetX, targetY) {
+        return this.sendAction('nuke', {
+            unit_id: unitId,
+            target_x: targetX,
+            target_y: targetY
+        });
+    }
+
+    cityStrike(cityId, targetX, targetY) {
+        return this.sendAction('city_strike', {
+            city_id: cityId,
+            target_x: targetX,
+            target_y: targetY
+        });
+    }
+
     foundCity(settlerId, cityName) {
         return this.sendAction('found_city', {
             settler_id: settlerId,
@@ -148,6 +267,63 @@ class GameWebSocket {
         });
     }
 
+    renameCity(cityId, name) {
+        return this.sendAction('rename_city', {
+            city_id: cityId,
+            name: name
+        });
+    }
+
+    setTaxRate(rate) {
+        return this.sendAction('set_tax_rate', {
+            player_id: gameState.myPlayerId,
+            tax_rate: rate
+        });
+    }
+
+    // Advanced start: item is 'city', 'unit' or 'tech'
+    buyStart(item, x, y, unitType) {
+        return this.sendAction('buy_start', {
+            player_id: gameState.myPlayerId,
+            item: item,
+            x: x,
+            y: y,
+            unit_type: unitType || 0
+        });
+    }
+
+    // Deals: offers carry who offered what to whom
+    proposeDeal(toPlayerId, deal, resource) {
+        return this.sendAction('propose_deal', {
+            from: gameState.myPlayerId,
+            to: toPlayerId,
+            deal: deal,
+            resource: resource || undefined
+        });
+    }
+
+    acceptDeal(offer) {
+        return this.sendAction('accept_deal', offer);
+    }
+
+    declineDeal(offer) {
+        return this.sendAction('decline_deal', offer);
+    }
+
+    cancelPact(partnerId) {
+        return this.sendAction('cancel_pact', {
+            player_id: gameState.myPlayerId,
+            partner_id: partnerId
+        });
+    }
+
+    renameUnit(unitId, name) {
+        return this.sendAction('rename_unit', {
+            unit_id: unitId,
+            name: name
+        });
+    }
+
     setProduction(cityId, isUnit, typeIndex) {
         return this.sendAction('set_production', {
             city_id: cityId,
@@ -165,22 +341,203 @@ class GameWebSocket {
         });
     }
 
+    wakeUnit(unitId) {
+        return this.sendAction('wake', {
+            unit_id: unitId
+        });
+    }
+
     skipUnit(unitId) {
         return this.sendAction('skip', {
             unit_id: unitId
         });
     }
 
+    helpWonder(unitId) {
+        return this.sendAction('help_wonder', {
+            unit_id: unitId
+        });
+    }
+
     buildRoad(unitId) {
         return this.sendAction('build_road', {
             unit_id: unitId
         });
     }
 
+    terraform(unitId, job) {
+        return this.sendAction('terraform', {
+            unit_id: unitId,
+            job: job
+        });
+    }
+
+    setUnitMode(unitId, mode) {
+        return this.sendAction('set_mode', {
+            unit_id: unitId,
+            mode: mode
+        });
+    }
+
+    patrolUnit(unitId, waypoints) {
+        return this.sendAction('patrol', {
+            unit_id: unitId,
+            waypoints: waypoints
+        });
+    }
+
+    createGroup(unitIds) {
+        return this.sendAction('create_group', {
+            unit_ids: unitIds
+        });
+    }
+
+    addToGroup(groupId, unitId) {
+        return this.sendAction('add_to_group', {
+            group_id: groupId,
+            unit_id: unitId
+        });
+    }
+
+    removeFromGroup(unitId) {
+        return this.sendAction('remove_from_group', {
+            unit_id: unitId
+        });
+    }
+
+    moveGroup(groupId, path) {
+        return this.sendAction('move_group', {
+            group_id: groupId,
+            path: path
+        });
+    }
+
+    planOrder(unitId, kind, x, y) {
+        return this.sendAction('plan_order', {
+            unit_id: unitId,
+            kind: kind,
+            x: x,
+            y: y
+        });
+    }
+
+    cancelOrders(unitId) {
+        return this.sendAction('cancel_orders', {
+            unit_id: unitId
+        });
+    }
+
+    // Move a unit, taking its whole group along if it has one. Groups do
+    // not move as one in the simultaneous phase.
+    moveUnitOrGroup(unit, toX, toY) {
+        if (unit.group_id && !gameState.isSimultaneous()) {
+            return this.moveGroup(unit.group_id, [{ x: toX, y: toY }]);
+        }
+        return this.moveUnit(unit.id, toX, toY);
+    }
+
     endTurn() {
+        if (gameState.isSimultaneous()) {
+            return this.sendAction('submit_orders', {
+                player_id: gameState.myPlayerId
+            });
+        }
         return this.sendAction('end_turn', {});
     }
 
+    // Choose where to be notified of our turn in an async game
+    setNotify(target) {
+        return this.send('set_notify', { target: target });
+    }
+
+    // Keep our preferences on the server, for every device we play from
+    setPreferences(prefs) {
+        return this.send('set_preferences', prefs);
+    }
+
+    // Host controls, refused by the server for other players
+    pauseGame() {
+        return this.send('pause', {});
+    }
+
+    resumeGame() {
+        return this.send('resume', {});
+    }
+
+    kickPlayer(playerId) {
+        return this.send('kick', { player_id: playerId });
+    }
+
+    setTurnTimer(minutes, policy) {
+        return this.send('set_turn_timer', { minutes: minutes, policy: policy });
+    }
+
+    transferHost(playerId) {
+        return this.send('transfer_host', { player_id: playerId });
+    }
+
+    // Query methods (read-only, answered with a query_result message)
+    sendQuery(queryType, data) {
+        return this.send('query', {
+            query_type: queryType,
+            data: data
+        });
+    }
+
+    queryCombatOdds(attackerId, targetX, targetY) {
+        return this.sendQuery('combat_odds', {
+            attacker_id: attackerId,
+            target_x: targetX,
+            target_y: targetY
+        });
+    }
+
+    // Subscribe to a rectangle of the map, or with no size end the
+    // subscription
+    setViewport(x, y, width, height) {
+        return this.send('viewport', { x: x, y: y, width: width, height: height });
+    }
+
+    // Ask for the tiles of one chunk of a streamed map
+    queryMapChunk(chunkX, chunkY) {
+        return this.sendQuery('map_chunk', {
+            chunk_x: chunkX,
+            chunk_y: chunkY
+        });
+    }
+
+    queryDemographics() {
+        return this.sendQuery('demographics', {});
+    }
+
+    queryHappiness() {
+        return this.sendQuery('happiness', {});
+    }
+
+    // Ask for our cities, sorted by a column of the empire overview
+    queryEmpire(sort, descending) {
+        return this.sendQuery('empire', { sort: sort, descending: descending });
+    }
+
+    // Ask where a unit of ours can move or attack this turn
+    queryReachable(unitId) {
+        return this.sendQuery('reachable', { unit_id: unitId });
+    }
+
+    // Ask about a tile, and what our selected unit, if any, could do there
+    queryTileInfo(x, y, unitId) {
+        return this.sendQuery('tile_info', { x: x, y: y, unit_id: unitId || '' });
+    }
+
+    // Ask what would come of actions, each given as { action_type, data },
+    // without taking them
+    querySimulation(actions, trials) {
+        return this.sendQuery('simulate', {
+            actions: actions,
+            trials: trials || 0
+        });
+    }
+
     // Callback setters
     onGameState(callback) {
         this.callbacks.onGameState = callback;
@@ -198,6 +555,38 @@ class GameWebSocket {
         this.callbacks.onCombatResult = callback;
     }
 
+    onEvent(callback) {
+        this.callbacks.onEvent = callback;
+    }
+
+    onQueryResult(callback) {
+        this.callbacks.onQueryResult = callback;
+    }
+
+    onTurnSummary(callback) {
+        this.callbacks.onTurnSummary = callback;
+    }
+
+    onAdvice(callback) {
+        this.callbacks.onAdvice = callback;
+    }
+
+    onTurnStatus(callback) {
+        this.callbacks.onTurnStatus = callback;
+    }
+
+    onWelcome(callback) {
+        this.callbacks.onWelcome = callback;
+    }
+
+    onHostState(callback) {
+        this.callbacks.onHostState = callback;
+    }
+
+    onPreferences(callback) {
+        this.callbacks.onPreferences = callback;
+    }
+
     onError(callback) {
         this.callbacks.onError = callback;
     }